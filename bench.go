@@ -0,0 +1,65 @@
+//go:build bench
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/bench"
+)
+
+// runBench runs the load test of the bench subcommand against an embedded NATS server and
+// returns the exit code, which is non-zero if the throughput is below -min-rate.
+func runBench(args []string) int {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	webhooks := flags.Int("webhooks", 10000, "number of webhooks to send")
+	concurrency := flags.Int("concurrency", 16, "number of webhooks sent at the same time")
+	workers := flags.Int("workers", 4, "number of adapter workers")
+	async := flags.Bool("async", false, "publish events without waiting for each publish ack")
+	timeout := flags.Duration("timeout", 5*time.Minute, "longest time to wait for all events")
+	allocProfile := flags.String("allocprofile", "", "write an allocation profile to this file")
+	minRate := flags.Float64("min-rate", 0, "fail if the throughput in events/s is lower")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	report, err := bench.Run(ctx, logger, bench.Config{
+		Webhooks:     *webhooks,
+		Concurrency:  *concurrency,
+		Workers:      *workers,
+		Async:        *async,
+		Timeout:      *timeout,
+		AllocProfile: *allocProfile,
+	})
+	if err != nil {
+		logger.Error("Benchmark failed", "error", err.Error())
+		return 1
+	}
+
+	if *asJSON {
+		json.NewEncoder(os.Stdout).Encode(report)
+	} else {
+		fmt.Print(report)
+	}
+
+	if *minRate > 0 && report.EventsPerSecond < *minRate {
+		logger.Error(fmt.Sprintf("Throughput of %.1f events/s is below the minimum of %.1f events/s", report.EventsPerSecond, *minRate))
+		return 1
+	}
+
+	return 0
+}
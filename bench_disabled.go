@@ -0,0 +1,15 @@
+//go:build !bench
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// runBench reports that the load test is not part of this build, since it embeds a NATS
+// server.
+func runBench(args []string) int {
+	fmt.Fprintln(os.Stderr, "The bench subcommand is only available in builds with -tags bench")
+	return 2
+}
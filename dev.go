@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+)
+
+// startDevServer starts an embedded NATS server with JetStream that keeps its data in a
+// temporary directory, so that the adapter can be run without any external dependencies. It
+// returns the URL of the server and a function that shuts it down and removes the directory.
+func startDevServer() (string, func(), error) {
+	dir, err := os.MkdirTemp("", "cdevents-adapter-dev-")
+	if err != nil {
+		return "", nil, err
+	}
+
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  dir,
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		os.RemoveAll(dir)
+		return "", nil, fmt.Errorf("failed to create NATS server: %w", err)
+	}
+	go ns.Start()
+
+	stop := func() {
+		ns.Shutdown()
+		ns.WaitForShutdown()
+		os.RemoveAll(dir)
+	}
+
+	if !ns.ReadyForConnections(10 * time.Second) {
+		stop()
+		return "", nil, fmt.Errorf("NATS server did not become ready")
+	}
+
+	return ns.ClientURL(), stop, nil
+}
+
+// printDevExamples prints commands for trying out the adapter started in development mode.
+func printDevExamples(env envConfig) {
+	fmt.Printf(`
+Development mode with an embedded NATS server on %[1]s
+
+Send a Gitea push webhook:
+
+  curl -H 'Content-Type: application/json' -H 'X-Gitea-Event: push' \
+    --data @examples/gitea/push_commit_main.json http://localhost:%[2]d/webhook
+
+Translate a webhook payload without publishing the event:
+
+  curl --data @examples/gitea/push_commit_main.json http://localhost:%[3]d/simulate/gitea.push
+
+Watch the published events:
+
+  nats --server %[1]s subscribe '%[4]s.>'
+
+`, env.NATSUrl, env.HttpPort, env.AdminPort, env.EventSubjectBase)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+
+	natsjs "github.com/nats-io/nats.go/jetstream"
+)
+
+// archivedMsg is a webhook message consumed from the archive stream in a dry run. Its subject is
+// mapped back to the subject it was received on in the webhook stream, which decides the
+// translator.
+type archivedMsg struct {
+	natsjs.Msg
+	subject string
+}
+
+func (m archivedMsg) Subject() string {
+	return m.subject
+}
+
+// archivedWebhookHandler returns a handler that maps the subjects of archived webhook messages
+// back to their webhook subjects before handling them.
+func archivedWebhookHandler(env envConfig, handler natsjs.MessageHandler) natsjs.MessageHandler {
+	return func(msg natsjs.Msg) {
+		subject := msg.Subject()
+		if rest, found := strings.CutPrefix(subject, env.ArchiveSubjectBase+"."); found {
+			subject = env.WebhookSubjectBase + "." + rest
+		}
+		handler(archivedMsg{Msg: msg, subject: subject})
+	}
+}
+
+// dryRunLinkStore looks up the events to link to without remembering the events of a dry run,
+// which are never published.
+type dryRunLinkStore struct {
+	adapter.LinkStore
+}
+
+func (dryRunLinkStore) Put(ctx context.Context, key string, link adapter.Link) error {
+	return nil
+}
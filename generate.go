@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/generate"
+
+	"github.com/kelseyhightower/envconfig"
+	natsjs "github.com/nats-io/nats.go/jetstream"
+)
+
+// runGenerate runs the generate subcommand, which publishes synthetic Gitea webhook payloads
+// directly on the webhook subjects to load the running adapters without the webhook endpoint.
+// NATS and the subjects are configured from the environment like the server.
+func runGenerate(args []string) int {
+	flags := flag.NewFlagSet("generate", flag.ContinueOnError)
+	msgs := flags.Int("msgs", 100000, "number of payloads to publish")
+	pubs := flags.Int("pubs", 1, "number of concurrent publishers")
+	rate := flags.Float64("rate", 0, "limit the total number of payloads published per second, 0 for no limit")
+	events := flags.String("events", strings.Join(generate.Events, ","), "comma-separated Gitea events to publish payloads for in turn")
+	repos := flags.Int("repos", 10, "number of repositories the payloads are spread over")
+	async := flags.Bool("async", false, "publish without waiting for each publish ack")
+	maxPending := flags.Int("max-pending", 4000, "most unacknowledged async publishes at a time")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	config := generate.Config{
+		Msgs:         *msgs,
+		Publishers:   *pubs,
+		Rate:         *rate,
+		Events:       strings.Split(*events, ","),
+		Repositories: *repos,
+		Async:        *async,
+	}
+	if err := config.Validate(); err != nil {
+		logger.Error("Invalid generate options", "error", err.Error())
+		return 2
+	}
+
+	var env envConfig
+	if err := envconfig.Process("", &env); err != nil {
+		logger.Error("Error when processing envvar configuration", "error", err.Error())
+		return 1
+	}
+	config.WebhookSubjectBase = env.WebhookSubjectBase
+
+	nc, err := connectNATS(env)
+	if err != nil {
+		logger.Error("Failed to connect to nats", "error", err.Error())
+		return 1
+	}
+	defer nc.Close()
+
+	jetstream, err := natsjs.New(nc, natsjs.WithPublishAsyncMaxPending(*maxPending))
+	if err != nil {
+		logger.Error("Failed to create JetStream instance", "error", err.Error())
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	logger.Info(fmt.Sprintf("Publishing %d payloads on %s.gitea.* with %d publishers", config.Msgs, config.WebhookSubjectBase, config.Publishers))
+
+	report, err := generate.Run(ctx, jetstream, config)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Generate stopped after %d payloads", report.Msgs), "error", err.Error())
+		return 1
+	}
+
+	if *asJSON {
+		json.NewEncoder(os.Stdout).Encode(report)
+	} else {
+		fmt.Print(report)
+	}
+
+	if report.Failed > 0 {
+		logger.Error(fmt.Sprintf("Failed to publish %d payloads", report.Failed))
+		return 1
+	}
+
+	return 0
+}
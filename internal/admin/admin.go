@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+type Server struct {
+	logger *slog.Logger
+	token  atomic.Pointer[string]
+	mux    *http.ServeMux
+	routes []string
+}
+
+// NewServer creates the admin API. When token is non-empty every request must carry it as a
+// bearer token in the Authorization header.
+func NewServer(logger *slog.Logger, token string) *Server {
+	s := &Server{
+		logger: logger,
+		mux:    http.NewServeMux(),
+	}
+	s.SetToken(token)
+	return s
+}
+
+// SetToken replaces the bearer token required by the admin API. An empty token disables
+// authentication.
+func (s *Server) SetToken(token string) {
+	s.token.Store(&token)
+}
+
+func (s *Server) HandleMetrics() {
+	s.Handle("GET /metrics", promhttp.Handler())
+}
+
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, s.authenticate(handler))
+	s.routes = append(s.routes, pattern)
+}
+
+func (s *Server) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	s.Handle(pattern, http.HandlerFunc(handler))
+}
+
+// HandleProtected registers a handler that requires the token even though the rest of the admin
+// API is open when no token is configured, e.g. for endpoints that serve webhook payloads or
+// publish events. Without a token the endpoint is forbidden.
+func (s *Server) HandleProtected(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, s.requireToken(s.authenticate(handler)))
+	s.routes = append(s.routes, pattern)
+}
+
+func (s *Server) HandleProtectedFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	s.HandleProtected(pattern, http.HandlerFunc(handler))
+}
+
+// Routes returns the patterns of the registered handlers, e.g. "GET /consumer", in the order they
+// were registered.
+func (s *Server) Routes() []string {
+	return s.routes
+}
+
+func (s *Server) Handler() http.Handler {
+	return s.mux
+}
+
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expected := *s.token.Load()
+		if expected == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if *s.token.Load() == "" {
+			http.Error(w, "Forbidden: endpoint requires ADMIN_TOKEN to be configured", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		s.logger.Error("Failed to marshal admin API response", "error", err.Error())
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
@@ -0,0 +1,161 @@
+package admin
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockConsumerControl struct {
+	paused bool
+}
+
+func (m *mockConsumerControl) Pause() error  { m.paused = true; return nil }
+func (m *mockConsumerControl) Resume() error { m.paused = false; return nil }
+func (m *mockConsumerControl) Paused() bool  { return m.paused }
+
+func TestConsumerControl(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tc := range []struct {
+		title                string
+		token                string
+		requestMethod        string
+		requestPath          string
+		authorization        string
+		initiallyPaused      bool
+		expectedResponseCode int
+		expectedResponseBody string
+		expectedPaused       bool
+	}{
+		{
+			title:                "returns consumer state",
+			requestMethod:        http.MethodGet,
+			requestPath:          "/consumer",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"paused":false}`,
+		},
+		{
+			title:                "pauses consumer",
+			token:                "secret",
+			requestMethod:        http.MethodPost,
+			requestPath:          "/consumer/pause",
+			authorization:        "Bearer secret",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"paused":true}`,
+			expectedPaused:       true,
+		},
+		{
+			title:                "resumes consumer",
+			token:                "secret",
+			requestMethod:        http.MethodPost,
+			requestPath:          "/consumer/resume",
+			authorization:        "Bearer secret",
+			initiallyPaused:      true,
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"paused":false}`,
+		},
+		{
+			title:                "forbidden to pause without configured token",
+			requestMethod:        http.MethodPost,
+			requestPath:          "/consumer/pause",
+			expectedResponseCode: http.StatusForbidden,
+			expectedResponseBody: `Forbidden: endpoint requires ADMIN_TOKEN to be configured`,
+		},
+		{
+			title:                "forbidden to resume without configured token",
+			requestMethod:        http.MethodPost,
+			requestPath:          "/consumer/resume",
+			initiallyPaused:      true,
+			expectedResponseCode: http.StatusForbidden,
+			expectedResponseBody: `Forbidden: endpoint requires ADMIN_TOKEN to be configured`,
+			expectedPaused:       true,
+		},
+		{
+			title:                "error on GET to pause",
+			requestMethod:        http.MethodGet,
+			requestPath:          "/consumer/pause",
+			expectedResponseCode: http.StatusMethodNotAllowed,
+			expectedResponseBody: `Method Not Allowed`,
+		},
+		{
+			title:                "unauthorized without token",
+			token:                "secret",
+			requestMethod:        http.MethodPost,
+			requestPath:          "/consumer/pause",
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `Unauthorized`,
+		},
+		{
+			title:                "unauthorized with wrong token",
+			token:                "secret",
+			requestMethod:        http.MethodPost,
+			requestPath:          "/consumer/pause",
+			authorization:        "Bearer wrong",
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedResponseBody: `Unauthorized`,
+		},
+		{
+			title:                "authorized with token",
+			token:                "secret",
+			requestMethod:        http.MethodPost,
+			requestPath:          "/consumer/pause",
+			authorization:        "Bearer secret",
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"paused":true}`,
+			expectedPaused:       true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			control := &mockConsumerControl{paused: tc.initiallyPaused}
+
+			server := NewServer(logger, tc.token)
+			server.HandleConsumerControl(control)
+
+			req := httptest.NewRequest(tc.requestMethod, tc.requestPath, nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			rec := httptest.NewRecorder()
+
+			server.Handler().ServeHTTP(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, tc.expectedResponseCode, res.StatusCode, "unexpected status code")
+
+			body, _ := io.ReadAll(res.Body)
+			assert.Equal(t, tc.expectedResponseBody, strings.TrimSpace(string(body)), "unexpected response body")
+			assert.Equal(t, tc.expectedPaused, control.paused, "unexpected consumer state")
+		})
+	}
+}
+
+func TestSetToken(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	server := NewServer(logger, "old")
+	server.HandleConsumerControl(&mockConsumerControl{})
+	server.SetToken("new")
+
+	for authorization, expectedResponseCode := range map[string]int{
+		"Bearer old": http.StatusUnauthorized,
+		"Bearer new": http.StatusOK,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/consumer/pause", nil)
+		req.Header.Set("Authorization", authorization)
+		rec := httptest.NewRecorder()
+
+		server.Handler().ServeHTTP(rec, req)
+
+		assert.Equal(t, expectedResponseCode, rec.Code, "unexpected status code for %s", authorization)
+	}
+}
@@ -0,0 +1,53 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/consumer"
+)
+
+type ConsumerControl interface {
+	Pause() error
+	Resume() error
+	Paused() bool
+}
+
+type consumerState struct {
+	Paused bool `json:"paused"`
+}
+
+// HandleConsumerControl registers endpoints for inspecting, pausing and resuming consumption
+// of the webhook stream. Pausing and resuming always require the token.
+func (s *Server) HandleConsumerControl(control ConsumerControl) {
+	s.HandleFunc("GET /consumer", func(w http.ResponseWriter, r *http.Request) {
+		s.writeJSON(w, http.StatusOK, consumerState{Paused: control.Paused()})
+	})
+
+	s.HandleProtectedFunc("POST /consumer/pause", func(w http.ResponseWriter, r *http.Request) {
+		if err := control.Pause(); err != nil {
+			s.logger.Error("Failed to pause consumer", "error", err.Error())
+			http.Error(w, "Failed to pause consumer", http.StatusInternalServerError)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, consumerState{Paused: control.Paused()})
+	})
+
+	s.HandleProtectedFunc("POST /consumer/resume", func(w http.ResponseWriter, r *http.Request) {
+		if err := control.Resume(); err != nil {
+			s.logger.Error("Failed to resume consumer", "error", err.Error())
+			http.Error(w, "Failed to resume consumer", http.StatusInternalServerError)
+			return
+		}
+		s.writeJSON(w, http.StatusOK, consumerState{Paused: control.Paused()})
+	})
+}
+
+type LagProvider interface {
+	Lag() consumer.Lag
+}
+
+func (s *Server) HandleConsumerLag(lag LagProvider) {
+	s.HandleFunc("GET /consumer/lag", func(w http.ResponseWriter, r *http.Request) {
+		s.writeJSON(w, http.StatusOK, lag.Lag())
+	})
+}
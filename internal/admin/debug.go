@@ -0,0 +1,18 @@
+package admin
+
+import (
+	"expvar"
+	"net/http/pprof"
+)
+
+// HandleDebug registers the net/http/pprof profiling endpoints and expvar under /debug/, which
+// always require the token since they expose the command line and memory of the process.
+func (s *Server) HandleDebug() {
+	s.HandleProtectedFunc("GET /debug/pprof/", pprof.Index)
+	s.HandleProtectedFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+	s.HandleProtectedFunc("GET /debug/pprof/profile", pprof.Profile)
+	s.HandleProtectedFunc("GET /debug/pprof/symbol", pprof.Symbol)
+	s.HandleProtectedFunc("POST /debug/pprof/symbol", pprof.Symbol)
+	s.HandleProtectedFunc("GET /debug/pprof/trace", pprof.Trace)
+	s.HandleProtected("GET /debug/vars", expvar.Handler())
+}
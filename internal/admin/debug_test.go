@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebug(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tc := range []struct {
+		title                string
+		token                string
+		requestPath          string
+		authorization        string
+		expectedResponseCode int
+		expectedContent      string
+	}{
+		{
+			title:                "serves pprof index",
+			token:                "secret",
+			requestPath:          "/debug/pprof/",
+			authorization:        "Bearer secret",
+			expectedResponseCode: http.StatusOK,
+			expectedContent:      "goroutine",
+		},
+		{
+			title:                "serves heap profile",
+			token:                "secret",
+			requestPath:          "/debug/pprof/heap?debug=1",
+			authorization:        "Bearer secret",
+			expectedResponseCode: http.StatusOK,
+			expectedContent:      "heap profile",
+		},
+		{
+			title:                "serves expvar",
+			token:                "secret",
+			requestPath:          "/debug/vars",
+			authorization:        "Bearer secret",
+			expectedResponseCode: http.StatusOK,
+			expectedContent:      "memstats",
+		},
+		{
+			title:                "unauthorized without token",
+			token:                "secret",
+			requestPath:          "/debug/pprof/",
+			expectedResponseCode: http.StatusUnauthorized,
+			expectedContent:      "Unauthorized",
+		},
+		{
+			title:                "forbidden without configured token",
+			requestPath:          "/debug/vars",
+			expectedResponseCode: http.StatusForbidden,
+			expectedContent:      "Forbidden",
+		},
+		{
+			title:                "authorized with token",
+			token:                "secret",
+			requestPath:          "/debug/vars",
+			authorization:        "Bearer secret",
+			expectedResponseCode: http.StatusOK,
+			expectedContent:      "cmdline",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			server := NewServer(logger, tc.token)
+			server.HandleDebug()
+
+			req := httptest.NewRequest(http.MethodGet, tc.requestPath, nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			rr := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectedResponseCode, rr.Code)
+			assert.Contains(t, rr.Body.String(), tc.expectedContent)
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+)
+
+type RecentFailuresProvider interface {
+	Recent() []adapter.RecentFailure
+}
+
+func (s *Server) HandleRecentFailures(failures RecentFailuresProvider) {
+	s.HandleProtectedFunc("GET /failures", func(w http.ResponseWriter, r *http.Request) {
+		s.writeJSON(w, http.StatusOK, failures.Recent())
+	})
+}
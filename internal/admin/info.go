@@ -0,0 +1,225 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+)
+
+const redacted = "REDACTED"
+
+var dsnPasswordPattern = regexp.MustCompile(`(?i)(password=)\S+`)
+
+type TranslatorProvider interface {
+	Translators() []adapter.TranslatorInfo
+}
+
+type TranslatorControl interface {
+	EnableTranslator(subject string) bool
+	DisableTranslator(subject string) bool
+}
+
+type TranslatorRegistry interface {
+	Map(subject, name string) error
+	Unregister(subject string) bool
+}
+
+type ProcessingStatsProvider interface {
+	Stats() adapter.ProcessingStats
+}
+
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"build_date,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+type info struct {
+	BuildInfo
+	Started time.Time               `json:"started"`
+	Uptime  string                  `json:"uptime"`
+	Stats   adapter.ProcessingStats `json:"stats"`
+}
+
+func (s *Server) HandleTranslators(translators TranslatorProvider) {
+	s.HandleFunc("GET /translators", func(w http.ResponseWriter, r *http.Request) {
+		s.writeJSON(w, http.StatusOK, translators.Translators())
+	})
+}
+
+// HandleTranslatorControl registers endpoints for enabling and disabling translators, which always
+// require the token. Enabling also re-enables a translator that was disabled because it was
+// degraded.
+func (s *Server) HandleTranslatorControl(control TranslatorControl) {
+	s.HandleProtectedFunc("POST /translators/{subject}/enable", func(w http.ResponseWriter, r *http.Request) {
+		subject := r.PathValue("subject")
+		if !control.EnableTranslator(subject) {
+			http.Error(w, fmt.Sprintf("No translator for subject: %s", subject), http.StatusNotFound)
+			return
+		}
+		s.logger.Info(fmt.Sprintf("Enabled translator: %s", subject))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	s.HandleProtectedFunc("POST /translators/{subject}/disable", func(w http.ResponseWriter, r *http.Request) {
+		subject := r.PathValue("subject")
+		if !control.DisableTranslator(subject) {
+			http.Error(w, fmt.Sprintf("No translator for subject: %s", subject), http.StatusNotFound)
+			return
+		}
+		s.logger.Info(fmt.Sprintf("Disabled translator: %s", subject))
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// HandleTranslatorRegistry registers endpoints for mapping a webhook subject to a translator by
+// name, e.g. PUT /translators/forgejo.push with {"translator": "gitea.push"}, and for removing
+// the translator of a subject. Both always require the token.
+func (s *Server) HandleTranslatorRegistry(registry TranslatorRegistry) {
+	s.HandleProtectedFunc("PUT /translators/{subject}", func(w http.ResponseWriter, r *http.Request) {
+		subject := r.PathValue("subject")
+
+		var mapping struct {
+			Translator string `json:"translator"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&mapping); err != nil || mapping.Translator == "" {
+			http.Error(w, "Request body must be a JSON object with a translator name", http.StatusBadRequest)
+			return
+		}
+
+		if err := registry.Map(subject, mapping.Translator); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.logger.Info(fmt.Sprintf("Mapped subject %s to translator: %s", subject, mapping.Translator))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	s.HandleProtectedFunc("DELETE /translators/{subject}", func(w http.ResponseWriter, r *http.Request) {
+		subject := r.PathValue("subject")
+		if !registry.Unregister(subject) {
+			http.Error(w, fmt.Sprintf("No translator for subject: %s", subject), http.StatusNotFound)
+			return
+		}
+		s.logger.Info(fmt.Sprintf("Removed translator for subject: %s", subject))
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// HandleConfig serves the effective configuration, as returned by config for every request so
+// that reloaded configuration is served. It always requires the token. Fields tagged as secret and
+// passwords embedded in URLs or connection strings are redacted.
+func (s *Server) HandleConfig(config func() interface{}) {
+	s.HandleProtectedFunc("GET /config", func(w http.ResponseWriter, r *http.Request) {
+		s.writeJSON(w, http.StatusOK, redact(reflect.ValueOf(config())))
+	})
+}
+
+func (s *Server) HandleVersion(build BuildInfo) {
+	s.HandleFunc("GET /version", func(w http.ResponseWriter, r *http.Request) {
+		s.writeJSON(w, http.StatusOK, build)
+	})
+}
+
+// HandleInfo serves build information together with uptime and processing statistics.
+func (s *Server) HandleInfo(build BuildInfo, started time.Time, stats ProcessingStatsProvider) {
+	if build.GoVersion == "" {
+		build.GoVersion = runtime.Version()
+	}
+
+	s.HandleFunc("GET /info", func(w http.ResponseWriter, r *http.Request) {
+		s.writeJSON(w, http.StatusOK, info{
+			BuildInfo: build,
+			Started:   started,
+			Uptime:    time.Since(started).Round(time.Second).String(),
+			Stats:     stats.Stats(),
+		})
+	})
+}
+
+// redact converts a configuration value into a JSON friendly structure keyed by the envconfig
+// names of struct fields, replacing the values of fields tagged with secret:"true". Every value of
+// a secret map, e.g. of headers that may carry an Authorization header, is redacted and its keys
+// are kept.
+func redact(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		return redact(v.Elem())
+	}
+
+	switch value := v.Interface().(type) {
+	case time.Duration:
+		return value.String()
+	case fmt.Stringer:
+		if v.Kind() != reflect.Struct {
+			return value.String()
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		fields := make(map[string]interface{}, v.NumField())
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			key := field.Tag.Get("envconfig")
+			if key == "" {
+				key = strings.ToUpper(field.Name)
+			}
+			if field.Tag.Get("secret") == "true" && !v.Field(i).IsZero() {
+				fields[key] = redactSecret(v.Field(i))
+				continue
+			}
+			fields[key] = redact(v.Field(i))
+		}
+		return fields
+	case reflect.Slice, reflect.Array:
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = redact(v.Index(i))
+		}
+		return items
+	case reflect.Map:
+		entries := make(map[string]interface{}, v.Len())
+		for _, key := range v.MapKeys() {
+			entries[fmt.Sprint(key.Interface())] = redact(v.MapIndex(key))
+		}
+		return entries
+	case reflect.String:
+		return redactString(v.String())
+	default:
+		return v.Interface()
+	}
+}
+
+func redactSecret(v reflect.Value) interface{} {
+	if v.Kind() != reflect.Map {
+		return redacted
+	}
+
+	entries := make(map[string]interface{}, v.Len())
+	for _, key := range v.MapKeys() {
+		entries[fmt.Sprint(key.Interface())] = redacted
+	}
+	return entries
+}
+
+func redactString(value string) string {
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			return u.Redacted()
+		}
+	}
+	return dsnPasswordPattern.ReplaceAllString(value, "${1}"+redacted)
+}
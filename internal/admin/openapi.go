@@ -0,0 +1,11 @@
+package admin
+
+import (
+	"github.com/ansig/cdevents-jetstream-adapter/internal/openapi"
+)
+
+// HandleOpenAPI serves the OpenAPI document of the adapter as YAML and JSON.
+func (s *Server) HandleOpenAPI() {
+	s.Handle("GET /openapi.yaml", openapi.Handler())
+	s.Handle("GET /openapi.json", openapi.Handler())
+}
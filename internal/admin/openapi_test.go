@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/openapi"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// webhookServerRoutes are the routes of the webhook server on HTTP_PORT, which main registers
+// outside of the admin API.
+var webhookServerRoutes = []string{"POST /webhook", "GET /healthz", "GET /readyz", "GET /openapi.yaml", "GET /openapi.json"}
+
+func TestOpenAPIDocumentsRoutes(t *testing.T) {
+
+	var doc struct {
+		Paths map[string]map[string]interface{} `yaml:"paths"`
+	}
+	require.NoError(t, yaml.Unmarshal(openapi.YAML(), &doc))
+
+	// Every handler of the admin API is registered, except the debug endpoints, which are not part
+	// of the API.
+	s := NewServer(slog.New(slog.NewTextHandler(io.Discard, nil)), "")
+	s.HandleConsumerControl(nil)
+	s.HandleConsumerLag(nil)
+	s.HandleSinkStats(nil)
+	s.HandleTranslators(nil)
+	s.HandleTranslatorControl(nil)
+	s.HandleTranslatorRegistry(nil)
+	s.HandleSimulate(nil)
+	s.HandleVersion(BuildInfo{})
+	s.HandleRecentFailures(nil)
+	s.HandleSchemas(nil)
+	s.HandleInfo(BuildInfo{}, time.Now(), nil)
+	s.HandleMetrics()
+	s.HandleOpenAPI()
+	s.HandleConfig(nil)
+
+	registered := map[string]bool{}
+	for _, route := range append(s.Routes(), webhookServerRoutes...) {
+		method, path, found := strings.Cut(route, " ")
+		require.True(t, found, "route %s has no method", route)
+		registered[strings.ToLower(method)+" "+path] = true
+		assert.Contains(t, doc.Paths[path], strings.ToLower(method), "route %s is not documented", route)
+	}
+
+	for path, item := range doc.Paths {
+		for method := range item {
+			assert.True(t, registered[method+" "+path], "%s %s is documented but not registered", strings.ToUpper(method), path)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/schema"
+)
+
+type SchemaProvider interface {
+	Schemas() []schema.Schema
+}
+
+// HandleSchemas serves the observed payload schema, watched fields and current drift of every
+// webhook subject.
+func (s *Server) HandleSchemas(schemas SchemaProvider) {
+	s.HandleFunc("GET /schemas", func(w http.ResponseWriter, r *http.Request) {
+		s.writeJSON(w, http.StatusOK, schemas.Schemas())
+	})
+}
@@ -0,0 +1,69 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+)
+
+// maxSimulatePayload is the largest webhook payload accepted by the simulate endpoint.
+const maxSimulatePayload = 10 << 20
+
+type Simulator interface {
+	Simulate(ctx context.Context, subject string, data []byte, publish bool) (adapter.Simulation, error)
+}
+
+// HandleSimulate registers an endpoint that translates a webhook payload sent in the request
+// body as if it had been received for the subject, e.g. POST /simulate/gitea.push, and returns
+// the event. The event is only published with ?publish=true. Since it can publish arbitrary
+// events, the endpoint requires the admin token.
+func (s *Server) HandleSimulate(simulator Simulator) {
+	s.HandleProtectedFunc("POST /simulate/{subject}", func(w http.ResponseWriter, r *http.Request) {
+		subject := r.PathValue("subject")
+
+		publish := false
+		if value := r.URL.Query().Get("publish"); value != "" {
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid publish parameter: %s", value), http.StatusBadRequest)
+				return
+			}
+			publish = parsed
+		}
+
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSimulatePayload))
+		if err != nil {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if !json.Valid(data) {
+			http.Error(w, "Request body must be a JSON webhook payload", http.StatusBadRequest)
+			return
+		}
+
+		simulation, err := simulator.Simulate(r.Context(), subject, data, publish)
+		switch {
+		case errors.Is(err, adapter.ErrNoTranslator):
+			http.Error(w, fmt.Sprintf("No translator for subject: %s", subject), http.StatusNotFound)
+			return
+		case err != nil && simulation.Event != nil:
+			s.logger.Error("Failed to publish simulated event", "subject", subject, "error", err.Error())
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+
+		if simulation.Published {
+			s.logger.Info(fmt.Sprintf("Published simulated event for subject: %s", subject), "id", simulation.Event.ID())
+		}
+		s.writeJSON(w, http.StatusOK, simulation)
+	})
+}
@@ -0,0 +1,169 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+	"github.com/stretchr/testify/assert"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+type mockSimulator struct {
+	published bool
+}
+
+func (m *mockSimulator) Simulate(ctx context.Context, subject string, data []byte, publish bool) (adapter.Simulation, error) {
+	simulation := adapter.Simulation{Subject: subject}
+	switch subject {
+	case "gitea.push":
+		event := cloudevents.NewEvent()
+		event.SetID("1")
+		event.SetSource("test")
+		event.SetType("dev.cdevents.change.merged.0.2.0")
+		simulation.Event = &event
+		simulation.Published = publish
+		m.published = publish
+	case "gitea.create":
+		simulation.Skipped = "not a branch"
+	case "gitea.issues":
+		return simulation, errors.New("invalid payload")
+	default:
+		return simulation, fmt.Errorf("%w: %s", adapter.ErrNoTranslator, subject)
+	}
+	return simulation, nil
+}
+
+func TestSimulate(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tc := range []struct {
+		title                string
+		requestPath          string
+		requestBody          string
+		expectedResponseCode int
+		expectedResponseBody string
+		expectedPublished    bool
+	}{
+		{
+			title:                "returns translated event",
+			requestPath:          "/simulate/gitea.push",
+			requestBody:          `{"ref": "refs/heads/main"}`,
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"subject":"gitea.push","event":{"specversion":"1.0","id":"1","source":"test","type":"dev.cdevents.change.merged.0.2.0"},"published":false}`,
+		},
+		{
+			title:                "publishes event when asked",
+			requestPath:          "/simulate/gitea.push?publish=true",
+			requestBody:          `{"ref": "refs/heads/main"}`,
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"subject":"gitea.push","event":{"specversion":"1.0","id":"1","source":"test","type":"dev.cdevents.change.merged.0.2.0"},"published":true}`,
+			expectedPublished:    true,
+		},
+		{
+			title:                "returns reason for skipped payload",
+			requestPath:          "/simulate/gitea.create",
+			requestBody:          `{}`,
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: `{"subject":"gitea.create","skipped":"not a branch","published":false}`,
+		},
+		{
+			title:                "unprocessable when translation fails",
+			requestPath:          "/simulate/gitea.issues",
+			requestBody:          `{}`,
+			expectedResponseCode: http.StatusUnprocessableEntity,
+		},
+		{
+			title:                "not found for unknown subject",
+			requestPath:          "/simulate/gitea.fork",
+			requestBody:          `{}`,
+			expectedResponseCode: http.StatusNotFound,
+		},
+		{
+			title:                "bad request for invalid payload",
+			requestPath:          "/simulate/gitea.push",
+			requestBody:          `not json`,
+			expectedResponseCode: http.StatusBadRequest,
+		},
+		{
+			title:                "bad request for invalid publish parameter",
+			requestPath:          "/simulate/gitea.push?publish=maybe",
+			requestBody:          `{}`,
+			expectedResponseCode: http.StatusBadRequest,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			simulator := &mockSimulator{}
+
+			server := NewServer(logger, "secret")
+			server.HandleSimulate(simulator)
+
+			req := httptest.NewRequest(http.MethodPost, tc.requestPath, strings.NewReader(tc.requestBody))
+			req.Header.Set("Authorization", "Bearer secret")
+
+			rec := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedResponseCode, rec.Code)
+			if tc.expectedResponseBody != "" {
+				assert.JSONEq(t, tc.expectedResponseBody, rec.Body.String())
+			}
+			assert.Equal(t, tc.expectedPublished, simulator.published)
+		})
+	}
+}
+
+func TestSimulateRequiresToken(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tc := range []struct {
+		title                string
+		token                string
+		authorization        string
+		expectedResponseCode int
+	}{
+		{
+			title:                "forbidden without configured token",
+			expectedResponseCode: http.StatusForbidden,
+		},
+		{
+			title:                "unauthorized without presented token",
+			token:                "secret",
+			expectedResponseCode: http.StatusUnauthorized,
+		},
+		{
+			title:                "publishes with presented token",
+			token:                "secret",
+			authorization:        "Bearer secret",
+			expectedResponseCode: http.StatusOK,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			simulator := &mockSimulator{}
+
+			server := NewServer(logger, tc.token)
+			server.HandleSimulate(simulator)
+
+			req := httptest.NewRequest(http.MethodPost, "/simulate/gitea.push?publish=true", strings.NewReader(`{}`))
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+
+			rec := httptest.NewRecorder()
+			server.Handler().ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedResponseCode, rec.Code)
+			assert.Equal(t, tc.expectedResponseCode == http.StatusOK, simulator.published)
+		})
+	}
+}
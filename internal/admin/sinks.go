@@ -0,0 +1,17 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/publisher"
+)
+
+type SinkStatsProvider interface {
+	Stats() map[string]publisher.SinkStats
+}
+
+func (s *Server) HandleSinkStats(stats SinkStatsProvider) {
+	s.HandleFunc("GET /sinks", func(w http.ResponseWriter, r *http.Request) {
+		s.writeJSON(w, http.StatusOK, stats.Stats())
+	})
+}
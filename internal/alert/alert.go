@@ -0,0 +1,170 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+)
+
+type Config struct {
+	URL       string        `envconfig:"URL" secret:"true"`
+	Format    string        `envconfig:"FORMAT" default:"generic"`
+	Threshold int           `envconfig:"THRESHOLD" default:"10"`
+	Window    time.Duration `envconfig:"WINDOW" default:"5m"`
+	Cooldown  time.Duration `envconfig:"COOLDOWN" default:"30m"`
+	Timeout   time.Duration `envconfig:"TIMEOUT" default:"10s"`
+}
+
+// Alert is the payload posted by the generic format. Failures is the threshold when it is reached,
+// as no more failures are kept than the threshold.
+type Alert struct {
+	Service  string              `json:"service"`
+	Message  string              `json:"message"`
+	Failures int                 `json:"failures"`
+	Window   string              `json:"window"`
+	Last     adapter.FailedEvent `json:"last"`
+}
+
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// Alerter counts reported failures over a sliding window and posts an alert to a Slack
+// incoming webhook or a generic HTTP endpoint when the threshold is reached. After an alert has
+// been sent, no new alert is sent until the cooldown has passed. Only the times of the most
+// recent failures up to the threshold are kept, so a burst of failures does not grow memory.
+type Alerter struct {
+	logger  *slog.Logger
+	config  Config
+	service string
+	client  *http.Client
+	now     func() time.Time
+
+	mu       sync.Mutex
+	failures []time.Time
+	lastSent time.Time
+}
+
+func NewAlerter(logger *slog.Logger, service string, config Config) (*Alerter, error) {
+	if config.Format != "generic" && config.Format != "slack" {
+		return nil, fmt.Errorf("unsupported alert format: %s", config.Format)
+	}
+	if config.Threshold < 1 {
+		return nil, fmt.Errorf("alert threshold must be at least 1")
+	}
+
+	return &Alerter{
+		logger:  logger,
+		config:  config,
+		service: service,
+		client:  &http.Client{Timeout: config.Timeout},
+		now:     time.Now,
+	}, nil
+}
+
+// Report records a failure and sends an alert in the background if the threshold is reached.
+func (a *Alerter) Report(failed adapter.FailedEvent) error {
+	count, fire := a.record()
+	if !fire {
+		return nil
+	}
+
+	alert := Alert{
+		Service:  a.service,
+		Message:  fmt.Sprintf("%s: at least %d failures in the last %s, latest: %s", a.service, count, a.config.Window, failed.Reason),
+		Failures: count,
+		Window:   a.config.Window.String(),
+		Last:     failed,
+	}
+
+	go func() {
+		if err := a.send(alert); err != nil {
+			a.logger.Error("Failed to send alert", "host", a.host(), "error", err.Error())
+		}
+	}()
+
+	return nil
+}
+
+func (a *Alerter) record() (int, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	cutoff := now.Add(-a.config.Window)
+
+	kept := a.failures[:0]
+	for _, t := range a.failures {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) == a.config.Threshold {
+		kept = append(kept[:0], kept[1:]...)
+	}
+	a.failures = append(kept, now)
+
+	count := len(a.failures)
+	if count < a.config.Threshold || (!a.lastSent.IsZero() && now.Sub(a.lastSent) < a.config.Cooldown) {
+		return count, false
+	}
+
+	a.lastSent = now
+	return count, true
+}
+
+func (a *Alerter) send(alert Alert) error {
+	var payload interface{} = alert
+	if a.config.Format == "slack" {
+		payload = slackMessage{Text: fmt.Sprintf(":rotating_light: %s", alert.Message)}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		// The URL of an incoming webhook is a credential, so it is left out of the error.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return fmt.Errorf("alert request to %s failed: %w", a.host(), urlErr.Err)
+		}
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("alert endpoint responded with status %d", res.StatusCode)
+	}
+
+	return nil
+}
+
+// host returns the host of the alert URL, which unlike the full URL can be logged.
+func (a *Alerter) host() string {
+	u, err := url.Parse(a.config.URL)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
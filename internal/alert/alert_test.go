@@ -0,0 +1,150 @@
+package alert
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecord(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	start := time.Date(2024, 11, 20, 10, 0, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		title         string
+		offsets       []time.Duration
+		expectedFired []bool
+	}{
+		{
+			title:         "fires when threshold is reached within window",
+			offsets:       []time.Duration{0, time.Second, 2 * time.Second},
+			expectedFired: []bool{false, false, true},
+		},
+		{
+			title:         "does not fire when failures fall outside window",
+			offsets:       []time.Duration{0, 2 * time.Minute, 4 * time.Minute},
+			expectedFired: []bool{false, false, false},
+		},
+		{
+			title:         "does not fire again within cooldown",
+			offsets:       []time.Duration{0, time.Second, 2 * time.Second, 3 * time.Second, 11 * time.Minute, 11*time.Minute + time.Second, 11*time.Minute + 2*time.Second},
+			expectedFired: []bool{false, false, true, false, false, false, true},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			alerter, err := NewAlerter(logger, "cdevents-adapter", Config{Format: "generic", Threshold: 3, Window: time.Minute, Cooldown: 10 * time.Minute})
+			require.NoError(t, err)
+
+			var fired []bool
+			for _, offset := range tc.offsets {
+				alerter.now = func() time.Time { return start.Add(offset) }
+				_, fire := alerter.record()
+				fired = append(fired, fire)
+			}
+
+			assert.Equal(t, tc.expectedFired, fired)
+		})
+	}
+}
+
+func TestRecordKeepsAtMostThreshold(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	alerter, err := NewAlerter(logger, "cdevents-adapter", Config{Format: "generic", Threshold: 3, Window: time.Minute, Cooldown: 10 * time.Minute})
+	require.NoError(t, err)
+
+	start := time.Date(2024, 11, 20, 10, 0, 0, 0, time.UTC)
+	for i := range 100 {
+		alerter.now = func() time.Time { return start.Add(time.Duration(i) * time.Millisecond) }
+		alerter.record()
+	}
+
+	assert.Len(t, alerter.failures, 3)
+	assert.Equal(t, start.Add(99*time.Millisecond), alerter.failures[2])
+}
+
+func TestSendLeavesOutURL(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	alerter, err := NewAlerter(logger, "cdevents-adapter", Config{URL: "http://127.0.0.1:1/services/T000/B000/secret", Format: "slack", Threshold: 1, Timeout: time.Second})
+	require.NoError(t, err)
+
+	err = alerter.send(Alert{Message: "test"})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret")
+	assert.Contains(t, err.Error(), "127.0.0.1:1")
+}
+
+func TestReportSendsAlert(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tc := range []struct {
+		title    string
+		format   string
+		expected func(t *testing.T, body []byte)
+	}{
+		{
+			title:  "generic format",
+			format: "generic",
+			expected: func(t *testing.T, body []byte) {
+				var alert Alert
+				require.NoError(t, json.Unmarshal(body, &alert))
+				assert.Equal(t, "cdevents-adapter", alert.Service)
+				assert.Equal(t, 2, alert.Failures)
+				assert.Equal(t, "1m0s", alert.Window)
+				assert.Equal(t, "no translator found for subject: gitea.fork", alert.Last.Reason)
+			},
+		},
+		{
+			title:  "slack format",
+			format: "slack",
+			expected: func(t *testing.T, body []byte) {
+				assert.JSONEq(t, `{"text":":rotating_light: cdevents-adapter: at least 2 failures in the last 1m0s, latest: no translator found for subject: gitea.fork"}`, string(body))
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			received := make(chan []byte, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				received <- body
+			}))
+			defer server.Close()
+
+			alerter, err := NewAlerter(logger, "cdevents-adapter", Config{URL: server.URL, Format: tc.format, Threshold: 2, Window: time.Minute, Timeout: time.Second})
+			require.NoError(t, err)
+
+			failed := adapter.FailedEvent{Reason: "no translator found for subject: gitea.fork"}
+			require.NoError(t, alerter.Report(failed))
+			require.NoError(t, alerter.Report(failed))
+
+			select {
+			case body := <-received:
+				tc.expected(t, body)
+			case <-time.After(5 * time.Second):
+				t.Fatal("no alert received")
+			}
+		})
+	}
+}
+
+func TestNewAlerterValidatesConfig(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	_, err := NewAlerter(logger, "cdevents-adapter", Config{Format: "teams", Threshold: 1})
+	assert.EqualError(t, err, "unsupported alert format: teams")
+
+	_, err = NewAlerter(logger, "cdevents-adapter", Config{Format: "slack", Threshold: 0})
+	assert.EqualError(t, err, "alert threshold must be at least 1")
+}
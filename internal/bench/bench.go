@@ -0,0 +1,397 @@
+// Package bench drives synthetic Gitea webhooks through the webhook endpoint, the adapter and
+// an embedded NATS server, and reports the throughput, latency and allocations of the whole
+// pipeline.
+package bench
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/consumer"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/publisher"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/webhook"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+const (
+	webhookStream = "bench-webhooks"
+	webhookBase   = "webhooks"
+	eventStream   = "bench-events"
+	eventSubjects = "dev.cdevents.>"
+)
+
+type Config struct {
+	// Webhooks is the number of webhooks to send.
+	Webhooks int
+	// Concurrency is the number of webhooks sent at the same time.
+	Concurrency int
+	// Workers is the number of adapter workers processing webhook messages.
+	Workers int
+	// Async publishes events without waiting for each publish ack.
+	Async bool
+	// Timeout is the longest time to wait for all events to be published.
+	Timeout time.Duration
+	// AllocProfile is a file to write the allocation profile of the run to, if set.
+	AllocProfile string
+}
+
+type Report struct {
+	Webhooks        int     `json:"webhooks"`
+	Events          int     `json:"events"`
+	Failed          uint64  `json:"failed"`
+	DurationMs      float64 `json:"duration_ms"`
+	EventsPerSecond float64 `json:"events_per_second"`
+	LatencyP50Ms    float64 `json:"latency_p50_ms"`
+	LatencyP90Ms    float64 `json:"latency_p90_ms"`
+	LatencyP99Ms    float64 `json:"latency_p99_ms"`
+	LatencyMaxMs    float64 `json:"latency_max_ms"`
+	AllocsPerEvent  float64 `json:"allocs_per_event"`
+	BytesPerEvent   float64 `json:"bytes_per_event"`
+}
+
+// String formats the report for the terminal.
+func (r Report) String() string {
+	return fmt.Sprintf(`webhooks:      %d
+events:        %d
+failed:        %d
+duration:      %.0f ms
+throughput:    %.1f events/s
+latency p50:   %.2f ms
+latency p90:   %.2f ms
+latency p99:   %.2f ms
+latency max:   %.2f ms
+allocs/event:  %.0f
+bytes/event:   %.0f
+`, r.Webhooks, r.Events, r.Failed, r.DurationMs, r.EventsPerSecond,
+		r.LatencyP50Ms, r.LatencyP90Ms, r.LatencyP99Ms, r.LatencyMaxMs,
+		r.AllocsPerEvent, r.BytesPerEvent)
+}
+
+// Run starts an embedded NATS server with JetStream, sends the webhooks to the webhook endpoint
+// and waits until the adapter has published an event for each of them. Latency is measured from
+// when a webhook is sent until its event is received from NATS. Allocations are those of the
+// whole process, including the embedded server.
+func Run(ctx context.Context, logger *slog.Logger, config Config) (*Report, error) {
+	if config.Webhooks <= 0 {
+		return nil, fmt.Errorf("number of webhooks must be positive: %d", config.Webhooks)
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = time.Minute
+	}
+
+	dir, err := os.MkdirTemp("", "cdevents-bench-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  dir,
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create NATS server: %w", err)
+	}
+	go ns.Start()
+	defer ns.Shutdown()
+
+	if !ns.ReadyForConnections(10 * time.Second) {
+		return nil, fmt.Errorf("NATS server did not become ready")
+	}
+
+	nc, err := nats.Connect(ns.ClientURL())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS server: %w", err)
+	}
+	defer nc.Close()
+
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := newPipeline(ctx, logger, nc, js, config)
+	if err != nil {
+		return nil, err
+	}
+	defer p.stop()
+
+	report, err := p.run(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.AllocProfile != "" {
+		if err := writeAllocProfile(config.AllocProfile); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// pipeline is the webhook endpoint, the adapter consuming the webhooks and a subscription for
+// the published events.
+type pipeline struct {
+	handler  http.Handler
+	adapter  *adapter.CDEventAdapter
+	consumer *consumer.PausableConsumer
+	workers  *consumer.WorkerPool
+	events   *nats.Subscription
+
+	sent     sync.Map
+	received atomic.Int64
+	done     chan struct{}
+	mu       sync.Mutex
+	latency  []time.Duration
+}
+
+func newPipeline(ctx context.Context, logger *slog.Logger, nc *nats.Conn, js jetstream.JetStream, config Config) (*pipeline, error) {
+	quiet := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	if _, err := js.CreateStream(ctx, jetstream.StreamConfig{
+		Name:     webhookStream,
+		Subjects: []string{webhookBase + ".>"},
+		Storage:  jetstream.MemoryStorage,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create webhook stream: %w", err)
+	}
+
+	if _, err := js.CreateStream(ctx, jetstream.StreamConfig{
+		Name:     eventStream,
+		Subjects: []string{eventSubjects},
+		Storage:  jetstream.MemoryStorage,
+	}); err != nil {
+		return nil, fmt.Errorf("failed to create event stream: %w", err)
+	}
+
+	webhookConsumer, err := js.CreateOrUpdateConsumer(ctx, webhookStream, jetstream.ConsumerConfig{
+		Durable:       "bench",
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		MaxAckPending: 1000,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook consumer: %w", err)
+	}
+
+	jetstreamPublisher, err := publisher.NewCloudEventJetstreamPublisher(nc, publisher.JetStreamConfig{Async: config.Async})
+	if err != nil {
+		return nil, err
+	}
+
+	var eventPublisher adapter.Publisher = jetstreamPublisher
+	if !config.Async {
+		eventPublisher = syncPublisher{jetstreamPublisher}
+	}
+
+	p := &pipeline{
+		handler: webhook.NewHttpWebhook(quiet).GetHandler(js, webhookBase),
+		adapter: adapter.NewCDEventAdapter(quiet, eventPublisher, translator.NewRegistry(translator.Builtin())),
+		done:    make(chan struct{}),
+		latency: make([]time.Duration, 0, config.Webhooks),
+	}
+
+	p.events, err = nc.Subscribe(eventSubjects, func(msg *nats.Msg) {
+		p.receive(msg, config.Webhooks)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to events: %w", err)
+	}
+
+	p.workers = consumer.NewWorkerPool(quiet, config.Workers, 1000)
+	p.workers.Start(func(msg jetstream.Msg) {
+		if err := p.adapter.Process(msg); err != nil {
+			logger.Error("Error when processing message", "error", err.Error())
+		}
+	})
+
+	p.consumer = consumer.NewPausableConsumer(quiet, webhookConsumer, p.workers.Handle)
+	if err := p.consumer.Start(); err != nil {
+		p.stop()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *pipeline) receive(msg *nats.Msg, expected int) {
+	sent, ok := p.sent.LoadAndDelete(msg.Header.Get("ce-subject"))
+	if !ok {
+		return
+	}
+
+	p.mu.Lock()
+	p.latency = append(p.latency, time.Since(sent.(time.Time)))
+	p.mu.Unlock()
+
+	if p.received.Add(1) == int64(expected) {
+		close(p.done)
+	}
+}
+
+func (p *pipeline) stop() {
+	p.consumer.Stop()
+	p.workers.Stop()
+	p.adapter.Wait()
+	p.events.Unsubscribe()
+}
+
+func (p *pipeline) run(ctx context.Context, config Config) (*Report, error) {
+	runtime.GC()
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	start := time.Now()
+
+	if err := p.send(config); err != nil {
+		return nil, err
+	}
+
+	timeout := time.NewTimer(config.Timeout)
+	defer timeout.Stop()
+
+	select {
+	case <-p.done:
+	case <-timeout.C:
+		return nil, fmt.Errorf("received %d of %d events within %s", p.received.Load(), config.Webhooks, config.Timeout)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	duration := time.Since(start)
+
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	sort.Slice(p.latency, func(i, j int) bool { return p.latency[i] < p.latency[j] })
+
+	events := len(p.latency)
+	return &Report{
+		Webhooks:        config.Webhooks,
+		Events:          events,
+		Failed:          p.adapter.Stats().Failed,
+		DurationMs:      milliseconds(duration),
+		EventsPerSecond: float64(events) / duration.Seconds(),
+		LatencyP50Ms:    milliseconds(percentile(p.latency, 50)),
+		LatencyP90Ms:    milliseconds(percentile(p.latency, 90)),
+		LatencyP99Ms:    milliseconds(percentile(p.latency, 99)),
+		LatencyMaxMs:    milliseconds(p.latency[events-1]),
+		AllocsPerEvent:  float64(after.Mallocs-before.Mallocs) / float64(events),
+		BytesPerEvent:   float64(after.TotalAlloc-before.TotalAlloc) / float64(events),
+	}, nil
+}
+
+// send posts the webhooks to the webhook endpoint from the configured number of senders.
+func (p *pipeline) send(config Config) error {
+	next := make(chan int)
+	errs := make(chan error, config.Concurrency)
+
+	var wg sync.WaitGroup
+	for i := 0; i < config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := range next {
+				if err := p.post(n); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+
+	var err error
+	for n := 0; n < config.Webhooks && err == nil; n++ {
+		select {
+		case next <- n:
+		case err = <-errs:
+		}
+	}
+	close(next)
+	wg.Wait()
+
+	if err != nil {
+		return err
+	}
+	select {
+	case err = <-errs:
+		return err
+	default:
+		return nil
+	}
+}
+
+func (p *pipeline) post(n int) error {
+	commit := fmt.Sprintf("%040x", n)
+	p.sent.Store(commit, time.Now())
+
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(pushPayload(commit)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gitea-Event", "push")
+
+	rec := httptest.NewRecorder()
+	p.handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		return fmt.Errorf("webhook %d was rejected: %d %s", n, rec.Code, rec.Body.String())
+	}
+	return nil
+}
+
+// percentile returns the p:th percentile of sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[(len(sorted)-1)*p/100]
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func writeAllocProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create allocation profile: %w", err)
+	}
+	defer f.Close()
+
+	if err := pprof.Lookup("allocs").WriteTo(f, 0); err != nil {
+		return fmt.Errorf("failed to write allocation profile: %w", err)
+	}
+	return nil
+}
+
+// syncPublisher hides the PublishAsync method of a publisher, so that the adapter waits for every
+// publish to be acknowledged.
+type syncPublisher struct {
+	adapter.Publisher
+}
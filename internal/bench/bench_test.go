@@ -0,0 +1,47 @@
+package bench
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tc := range []struct {
+		title  string
+		config Config
+	}{
+		{
+			title:  "publishes an event for every webhook",
+			config: Config{Webhooks: 50, Concurrency: 4, Workers: 2},
+		},
+		{
+			title:  "publishes an event for every webhook with async publishing",
+			config: Config{Webhooks: 50, Concurrency: 4, Workers: 2, Async: true},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			tc.config.Timeout = 30 * time.Second
+			tc.config.AllocProfile = filepath.Join(t.TempDir(), "allocs.pprof")
+
+			report, err := Run(context.Background(), logger, tc.config)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.config.Webhooks, report.Events, "every webhook should result in an event")
+			assert.Zero(t, report.Failed, "no webhook should fail")
+			assert.Greater(t, report.EventsPerSecond, 0.0)
+			assert.LessOrEqual(t, report.LatencyP50Ms, report.LatencyP99Ms)
+			assert.LessOrEqual(t, report.LatencyP99Ms, report.LatencyMaxMs)
+			assert.FileExists(t, tc.config.AllocProfile)
+		})
+	}
+}
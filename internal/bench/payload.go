@@ -0,0 +1,60 @@
+package bench
+
+import "fmt"
+
+// pushPayloadTemplate is a Gitea push webhook with a single commit, sized like the webhooks
+// Gitea sends for a repository with a few settings.
+const pushPayloadTemplate = `{
+  "ref": "refs/heads/main",
+  "before": "a359287123178c5d05654864e80ab6f3bfc3d78a",
+  "after": "%[1]s",
+  "compare_url": "http://git.example.com/yoloco/project1/compare/a359287123178c5d05654864e80ab6f3bfc3d78a...%[1]s",
+  "commits": [
+    {
+      "id": "%[1]s",
+      "message": "Update README.md\n",
+      "url": "http://git.example.com/yoloco/project1/commit/%[1]s",
+      "author": {"name": "anders", "email": "gi@tea.com", "username": "anders"},
+      "committer": {"name": "anders", "email": "gi@tea.com", "username": "anders"},
+      "verification": null,
+      "timestamp": "2024-11-17T18:19:39Z",
+      "added": [],
+      "removed": [],
+      "modified": ["README.md"]
+    }
+  ],
+  "total_commits": 1,
+  "head_commit": {
+    "id": "%[1]s",
+    "message": "Update README.md\n",
+    "url": "http://git.example.com/yoloco/project1/commit/%[1]s",
+    "author": {"name": "anders", "email": "gi@tea.com", "username": "anders"},
+    "committer": {"name": "anders", "email": "gi@tea.com", "username": "anders"},
+    "verification": null,
+    "timestamp": "2024-11-17T18:19:39Z",
+    "added": [],
+    "removed": [],
+    "modified": ["README.md"]
+  },
+  "repository": {
+    "id": 1,
+    "owner": {"id": 1, "login": "yoloco", "full_name": "", "email": "", "username": "yoloco"},
+    "name": "project1",
+    "full_name": "yoloco/project1",
+    "private": false,
+    "fork": false,
+    "html_url": "http://git.example.com/yoloco/project1",
+    "ssh_url": "git@git.example.com:yoloco/project1.git",
+    "clone_url": "http://git.example.com/yoloco/project1.git",
+    "default_branch": "main",
+    "created_at": "2024-11-17T18:10:02Z",
+    "updated_at": "2024-11-17T18:19:40Z"
+  },
+  "pusher": {"id": 1, "login": "anders", "full_name": "Anders", "email": "gi@tea.com", "username": "anders"},
+  "sender": {"id": 1, "login": "anders", "full_name": "Anders", "email": "gi@tea.com", "username": "anders"}
+}`
+
+// pushPayload returns a push webhook for a commit, whose id becomes the subject of the event.
+func pushPayload(commit string) []byte {
+	return []byte(fmt.Sprintf(pushPayloadTemplate, commit))
+}
@@ -0,0 +1,33 @@
+// Package bufpool pools the byte buffers used for reading webhook payloads and serializing
+// events, to reduce garbage collection during bursts of webhooks.
+package bufpool
+
+import (
+	"bytes"
+	"sync"
+)
+
+// MaxPooledSize is the capacity above which a buffer is dropped instead of returned to the pool,
+// so that an occasional large payload does not keep its memory alive.
+const MaxPooledSize = 1 << 20
+
+var pool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// Get returns an empty buffer from the pool.
+func Get() *bytes.Buffer {
+	return pool.Get().(*bytes.Buffer)
+}
+
+// Put returns a buffer to the pool. The buffer and any slices of its contents must not be used
+// after it has been returned.
+func Put(buf *bytes.Buffer) {
+	if buf.Cap() > MaxPooledSize {
+		return
+	}
+	buf.Reset()
+	pool.Put(buf)
+}
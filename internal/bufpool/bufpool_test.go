@@ -0,0 +1,40 @@
+package bufpool
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPut(t *testing.T) {
+
+	for _, tc := range []struct {
+		title      string
+		size       int
+		expectPool bool
+	}{
+		{
+			title:      "returns small buffer to the pool",
+			size:       4096,
+			expectPool: true,
+		},
+		{
+			title: "drops buffer larger than the maximum size",
+			size:  MaxPooledSize + 1,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			buf := Get()
+			buf.Write(bytes.Repeat([]byte("a"), tc.size))
+
+			Put(buf)
+
+			if tc.expectPool {
+				assert.Equal(t, 0, buf.Len(), "pooled buffer should be reset")
+			} else {
+				assert.Equal(t, tc.size, buf.Len(), "dropped buffer should not be reset")
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// Package capture writes copies of incoming webhook payloads to a directory or a JetStream object
+// store, so that real-world payloads can be collected as fixtures for translator development.
+package capture
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+type Config struct {
+	// Dir is the directory payloads are written to.
+	Dir string `envconfig:"DIR"`
+	// Bucket is the JetStream object store bucket payloads are written to.
+	Bucket string `envconfig:"BUCKET"`
+	// Limit is the maximum number of payloads captured per provider and event within every
+	// interval.
+	Limit    int           `envconfig:"LIMIT" default:"10"`
+	Interval time.Duration `envconfig:"INTERVAL" default:"1h"`
+}
+
+func (c Config) Enabled() bool {
+	return c.Dir != "" || c.Bucket != ""
+}
+
+func (c Config) Validate() error {
+	if c.Dir != "" && c.Bucket != "" {
+		return errors.New("capture directory and bucket are mutually exclusive")
+	}
+	if c.Limit < 1 {
+		return fmt.Errorf("capture limit must be at least 1, got %d", c.Limit)
+	}
+	if c.Interval <= 0 {
+		return fmt.Errorf("capture interval must be positive, got %s", c.Interval)
+	}
+	return nil
+}
+
+// Store stores a captured payload under a slash separated name, e.g. gitea/push/<id>.json.
+type Store interface {
+	Put(ctx context.Context, name string, data []byte) error
+}
+
+// DirStore stores payloads as files below a directory, readable only by the adapter user.
+type DirStore struct {
+	dir string
+}
+
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{dir: dir}
+}
+
+func (s *DirStore) Put(ctx context.Context, name string, data []byte) error {
+	path := filepath.Join(s.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// ObjectStore stores payloads as objects in a JetStream object store bucket.
+type ObjectStore struct {
+	store jetstream.ObjectStore
+}
+
+func NewObjectStore(store jetstream.ObjectStore) *ObjectStore {
+	return &ObjectStore{store: store}
+}
+
+func (s *ObjectStore) Put(ctx context.Context, name string, data []byte) error {
+	_, err := s.store.PutBytes(ctx, name, data)
+	return err
+}
+
+type capture struct {
+	name string
+	data []byte
+}
+
+// Capturer captures payloads in the background. At most Limit payloads are captured per
+// provider and event within every interval and payloads are dropped while the store is slower
+// than the webhooks arrive, so that capturing never holds up the webhook handler.
+type Capturer struct {
+	logger   *slog.Logger
+	store    Store
+	limit    int
+	interval time.Duration
+	queue    chan capture
+	seq      atomic.Uint64
+
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[string]int
+}
+
+func NewCapturer(logger *slog.Logger, config Config, store Store) *Capturer {
+	return &Capturer{
+		logger:   logger,
+		store:    store,
+		limit:    config.Limit,
+		interval: config.Interval,
+		queue:    make(chan capture, 64),
+		counts:   make(map[string]int),
+	}
+}
+
+// Capture queues a copy of a payload for the webhook event, e.g. gitea.push, to be stored as
+// <provider>/<event>/<time>-<delivery id>.json, matching the fixture layout of the
+// translatortest package. The payload is indented and may be reused by the caller once Capture
+// returns.
+func (c *Capturer) Capture(event string, deliveryID string, data []byte) {
+	if !c.allow(event) {
+		return
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, data, "", "  "); err != nil {
+		return
+	}
+	indented.WriteByte('\n')
+
+	id := unsafeNameChars.ReplaceAllString(deliveryID, "_")
+	if id == "" {
+		id = fmt.Sprintf("%d", c.seq.Add(1))
+	}
+	name := fmt.Sprintf("%s/%s-%s.json", strings.ReplaceAll(event, ".", "/"), time.Now().UTC().Format("20060102T150405Z"), id)
+
+	select {
+	case c.queue <- capture{name: name, data: indented.Bytes()}:
+	default:
+		c.logger.Debug(fmt.Sprintf("Dropping payload capture, queue is full: %s", name))
+	}
+}
+
+func (c *Capturer) allow(event string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(c.windowStart) >= c.interval {
+		c.windowStart = now
+		c.counts = make(map[string]int)
+	}
+	if c.counts[event] >= c.limit {
+		return false
+	}
+	c.counts[event]++
+	return true
+}
+
+// Run stores queued payloads until the context is done.
+func (c *Capturer) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case captured := <-c.queue:
+			storeCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			if err := c.store.Put(storeCtx, captured.name, captured.data); err != nil {
+				c.logger.Warn(fmt.Sprintf("Failed to store payload capture: %s", captured.name), "error", err.Error())
+			} else {
+				c.logger.Debug(fmt.Sprintf("Captured webhook payload: %s", captured.name))
+			}
+			cancel()
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package capture
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigValidate(t *testing.T) {
+
+	for _, tc := range []struct {
+		title       string
+		config      Config
+		expectedErr string
+	}{
+		{title: "directory", config: Config{Dir: "/tmp", Limit: 1, Interval: time.Hour}},
+		{title: "bucket", config: Config{Bucket: "captures", Limit: 1, Interval: time.Hour}},
+		{title: "directory and bucket", config: Config{Dir: "/tmp", Bucket: "captures", Limit: 1, Interval: time.Hour}, expectedErr: "capture directory and bucket are mutually exclusive"},
+		{title: "zero limit", config: Config{Dir: "/tmp", Interval: time.Hour}, expectedErr: "capture limit must be at least 1, got 0"},
+		{title: "zero interval", config: Config{Dir: "/tmp", Limit: 1}, expectedErr: "capture interval must be positive, got 0s"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	objects map[string]string
+}
+
+func (s *memoryStore) Put(ctx context.Context, name string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[name] = string(data)
+	return nil
+}
+
+func (s *memoryStore) names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.objects))
+	for name := range s.objects {
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestCapturerLimitsPayloadsPerEvent(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	store := &memoryStore{objects: make(map[string]string)}
+	capturer := NewCapturer(logger, Config{Limit: 2, Interval: time.Hour}, store)
+
+	for _, delivery := range []string{"a", "b", "c"} {
+		capturer.Capture("gitea.push", delivery, []byte(`{"ref":"refs/heads/main"}`))
+	}
+	capturer.Capture("gitea.create", "d", []byte(`{}`))
+	capturer.Capture("gitea.create", "e", []byte(`not json`))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go capturer.Run(ctx)
+
+	require.Eventually(t, func() bool { return len(store.names()) == 3 }, 5*time.Second, 10*time.Millisecond)
+
+	var pushes, creates int
+	for name, data := range store.objects {
+		switch filepath.Dir(name) {
+		case "gitea/push":
+			pushes++
+			assert.Equal(t, "{\n  \"ref\": \"refs/heads/main\"\n}\n", data)
+		case "gitea/create":
+			creates++
+		}
+	}
+	assert.Equal(t, 2, pushes)
+	assert.Equal(t, 1, creates)
+}
+
+func TestDirStore(t *testing.T) {
+
+	dir := t.TempDir()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	capturer := NewCapturer(logger, Config{Limit: 1, Interval: time.Hour}, NewDirStore(dir))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go capturer.Run(ctx)
+
+	capturer.Capture("gitea.push", "../delivery 1", []byte(`{}`))
+
+	var files []string
+	require.Eventually(t, func() bool {
+		files, _ = filepath.Glob(filepath.Join(dir, "gitea", "push", "*.json"))
+		return len(files) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	assert.Regexp(t, `^\d{8}T\d{6}Z-_delivery_1\.json$`, filepath.Base(files[0]))
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.Equal(t, "{}\n", string(data))
+
+	info, err := os.Stat(files[0])
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Family describes configuration keys of named instances, such as ROUTE_<NAME>_TYPES, where
+// the keys after the instance name are those of Spec.
+type Family struct {
+	Prefix string
+	Spec   interface{}
+}
+
+type keySet map[string]reflect.Kind
+
+// Load reads a YAML configuration file and sets every value in it as an environment variable,
+// unless that variable is already set, so that it is picked up by envconfig with environment
+// variables taking precedence. Nested mappings are joined into envconfig keys, so
+//
+//	http_sink:
+//	  url: http://example.com
+//
+// sets HTTP_SINK_URL. Lists become comma separated values and mappings of map fields become
+// key:value pairs. Keys that are not part of spec or any of the families are rejected.
+func Load(path string, spec interface{}, families ...Family) error {
+	return NewLoader(path, spec, families...).Load()
+}
+
+// Loader loads a configuration file like Load, and can load it again to pick up changes. Values
+// set from a previous load are replaced, or unset when removed from the file, while variables
+// set in the environment still take precedence.
+type Loader struct {
+	path     string
+	spec     interface{}
+	families []Family
+	set      map[string]bool
+}
+
+func NewLoader(path string, spec interface{}, families ...Family) *Loader {
+	return &Loader{path: path, spec: spec, families: families, set: make(map[string]bool)}
+}
+
+func (l *Loader) Load() error {
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", l.path, err)
+	}
+
+	values, err := Flatten(doc, l.spec, l.families...)
+	if err != nil {
+		return fmt.Errorf("invalid config file %s: %w", l.path, err)
+	}
+
+	for key := range l.set {
+		if _, exists := values[key]; !exists {
+			os.Unsetenv(key)
+		}
+	}
+
+	set := make(map[string]bool, len(values))
+	for key, value := range values {
+		if _, exists := os.LookupEnv(key); exists && !l.set[key] {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+		set[key] = true
+	}
+	l.set = set
+
+	return nil
+}
+
+// Flatten converts a parsed configuration document into envconfig keys and values.
+func Flatten(doc map[string]interface{}, spec interface{}, families ...Family) (map[string]string, error) {
+	static := keysOf("", reflect.TypeOf(spec))
+
+	dynamic := make(map[string]keySet, len(families))
+	for _, family := range families {
+		dynamic[family.Prefix] = keysOf("", reflect.TypeOf(family.Spec))
+	}
+
+	lookup := func(key string) (reflect.Kind, bool) {
+		if kind, ok := static[key]; ok {
+			return kind, true
+		}
+		for prefix, keys := range dynamic {
+			rest, found := strings.CutPrefix(key, prefix+"_")
+			if !found {
+				continue
+			}
+			for k, kind := range keys {
+				if len(rest) > len(k)+1 && strings.HasSuffix(rest, "_"+k) {
+					return kind, true
+				}
+			}
+		}
+		return reflect.Invalid, false
+	}
+
+	values := make(map[string]string)
+	var unknown []string
+
+	var walk func(prefix string, node map[string]interface{}) error
+	walk = func(prefix string, node map[string]interface{}) error {
+		for k, v := range node {
+			key := strings.ToUpper(strings.ReplaceAll(k, "-", "_"))
+			if prefix != "" {
+				key = prefix + "_" + key
+			}
+
+			kind, known := lookup(key)
+
+			if m, ok := v.(map[string]interface{}); ok && kind != reflect.Map {
+				if err := walk(key, m); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if !known {
+				unknown = append(unknown, key)
+				continue
+			}
+
+			value, err := format(key, v)
+			if err != nil {
+				return err
+			}
+			values[key] = value
+		}
+		return nil
+	}
+
+	if err := walk("", doc); err != nil {
+		return nil, err
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown configuration keys: %s", strings.Join(unknown, ", "))
+	}
+
+	return values, nil
+}
+
+func format(key string, v interface{}) (string, error) {
+	switch value := v.(type) {
+	case nil:
+		return "", nil
+	case []interface{}:
+		items := make([]string, len(value))
+		for i, item := range value {
+			if _, nested := item.(map[string]interface{}); nested {
+				return "", fmt.Errorf("list %s may only contain scalar values", key)
+			}
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ","), nil
+	case map[string]interface{}:
+		pairs := make([]string, 0, len(value))
+		for k, item := range value {
+			pairs = append(pairs, fmt.Sprintf("%s:%v", k, item))
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, ","), nil
+	default:
+		return fmt.Sprint(value), nil
+	}
+}
+
+// keysOf returns the keys envconfig reads for a struct type, together with the kind of each
+// field.
+func keysOf(prefix string, t reflect.Type) keySet {
+	keys := make(keySet)
+
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Tag.Get("ignored") == "true" {
+			continue
+		}
+
+		key := field.Tag.Get("envconfig")
+		if key == "" {
+			key = strings.ToUpper(field.Name)
+		}
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		if fieldType.Kind() == reflect.Struct && !isDecoder(fieldType) {
+			for k, kind := range keysOf(key, fieldType) {
+				keys[k] = kind
+			}
+			continue
+		}
+
+		keys[key] = fieldType.Kind()
+	}
+
+	return keys
+}
+
+// isDecoder reports whether envconfig decodes the type from a single value rather than from
+// its fields.
+func isDecoder(t reflect.Type) bool {
+	p := reflect.PointerTo(t)
+	for _, method := range []string{"Decode", "Set", "UnmarshalText", "UnmarshalBinary"} {
+		if _, ok := p.MethodByName(method); ok {
+			return true
+		}
+	}
+	return false
+}
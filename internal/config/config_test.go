@@ -0,0 +1,190 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+type testFilter struct {
+	Types []string `envconfig:"TYPES"`
+}
+
+type testSinkConfig struct {
+	URL     string            `envconfig:"URL"`
+	Timeout time.Duration     `envconfig:"TIMEOUT" default:"10s"`
+	Headers map[string]string `envconfig:"HEADERS"`
+}
+
+type testRoute struct {
+	Name  string   `ignored:"true"`
+	Types []string `envconfig:"TYPES"`
+	Sinks []string `envconfig:"SINKS"`
+}
+
+type testConfig struct {
+	LogLevel   string         `envconfig:"LOG_LEVEL" default:"info"`
+	EventSinks []string       `envconfig:"EVENT_SINKS" default:"jetstream"`
+	Routes     []string       `envconfig:"ROUTES"`
+	HTTPSink   testSinkConfig `envconfig:"HTTP_SINK"`
+	SinkFilter struct {
+		HTTP testFilter `envconfig:"HTTP"`
+	} `envconfig:"SINK_FILTER"`
+}
+
+var testFamilies = []Family{
+	{Prefix: "ROUTE", Spec: testRoute{}},
+	{Prefix: "HTTP_SINK", Spec: testSinkConfig{}},
+}
+
+func TestFlatten(t *testing.T) {
+
+	for _, tc := range []struct {
+		title          string
+		yaml           string
+		expectedValues map[string]string
+		expectedError  string
+	}{
+		{
+			title: "flattens nested keys, lists and maps",
+			yaml: `
+log_level: debug
+event_sinks: [jetstream, http]
+http_sink:
+  url: http://sink.example.com
+  timeout: 5s
+  headers:
+    X-Team: platform
+    X-Env: prod
+sink_filter:
+  http:
+    types:
+      - dev.cdevents.change.*
+`,
+			expectedValues: map[string]string{
+				"LOG_LEVEL":              "debug",
+				"EVENT_SINKS":            "jetstream,http",
+				"HTTP_SINK_URL":          "http://sink.example.com",
+				"HTTP_SINK_TIMEOUT":      "5s",
+				"HTTP_SINK_HEADERS":      "X-Env:prod,X-Team:platform",
+				"SINK_FILTER_HTTP_TYPES": "dev.cdevents.change.*",
+			},
+		},
+		{
+			title: "accepts named instances",
+			yaml: `
+routes: [critical]
+route:
+  critical:
+    types: [dev.cdevents.incident.*]
+    sinks: [http:next]
+http_sink:
+  next:
+    url: http://next.example.com
+`,
+			expectedValues: map[string]string{
+				"ROUTES":               "critical",
+				"ROUTE_CRITICAL_TYPES": "dev.cdevents.incident.*",
+				"ROUTE_CRITICAL_SINKS": "http:next",
+				"HTTP_SINK_NEXT_URL":   "http://next.example.com",
+			},
+		},
+		{
+			title: "rejects unknown keys",
+			yaml: `
+log_levl: debug
+http_sink:
+  uri: http://sink.example.com
+`,
+			expectedError: "unknown configuration keys: HTTP_SINK_URI, LOG_LEVL",
+		},
+		{
+			title: "rejects lists of mappings",
+			yaml: `
+event_sinks:
+  - name: http
+`,
+			expectedError: "list EVENT_SINKS may only contain scalar values",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			var doc map[string]interface{}
+			require.NoError(t, yaml.Unmarshal([]byte(tc.yaml), &doc))
+
+			values, err := Flatten(doc, testConfig{}, testFamilies...)
+
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedValues, values)
+		})
+	}
+}
+
+func TestLoadWithEnvOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+log_level: debug
+http_sink:
+  url: http://file.example.com
+  timeout: 5s
+`), 0o600))
+
+	t.Setenv("HTTP_SINK_URL", "http://env.example.com")
+	for _, key := range []string{"LOG_LEVEL", "HTTP_SINK_TIMEOUT"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+
+	require.NoError(t, Load(path, testConfig{}, testFamilies...))
+
+	var config testConfig
+	require.NoError(t, envconfig.Process("", &config))
+
+	assert.Equal(t, "debug", config.LogLevel, "value from file should be used")
+	assert.Equal(t, "http://env.example.com", config.HTTPSink.URL, "environment should override file")
+	assert.Equal(t, 5*time.Second, config.HTTPSink.Timeout)
+	assert.Equal(t, []string{"jetstream"}, config.EventSinks, "defaults should apply to keys not in file")
+}
+
+func TestLoadReportsParseErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("log_level: [debug\n"), 0o600))
+
+	err := Load(path, testConfig{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse config file")
+	assert.Contains(t, err.Error(), "line 1")
+}
+
+func TestLoaderReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("log_level: debug\nroutes: [critical]\n"), 0o600))
+
+	t.Setenv("EVENT_SINKS", "http")
+	for _, key := range []string{"LOG_LEVEL", "ROUTES"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+
+	loader := NewLoader(path, testConfig{}, testFamilies...)
+	require.NoError(t, loader.Load())
+	assert.Equal(t, "debug", os.Getenv("LOG_LEVEL"))
+	assert.Equal(t, "critical", os.Getenv("ROUTES"))
+
+	require.NoError(t, os.WriteFile(path, []byte("log_level: warn\nevent_sinks: [jetstream]\n"), 0o600))
+	require.NoError(t, loader.Load())
+
+	assert.Equal(t, "warn", os.Getenv("LOG_LEVEL"), "changed value should be replaced")
+	_, exists := os.LookupEnv("ROUTES")
+	assert.False(t, exists, "value removed from file should be unset")
+	assert.Equal(t, "http", os.Getenv("EVENT_SINKS"), "environment should still override file")
+}
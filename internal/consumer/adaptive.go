@@ -0,0 +1,198 @@
+package consumer
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/metrics"
+)
+
+// AdaptiveConfig configures the adaptive concurrency of the worker pool.
+type AdaptiveConfig struct {
+	Enabled       bool          `envconfig:"ENABLED" default:"false"`
+	MinWorkers    int           `envconfig:"MIN_WORKERS" default:"1"`
+	Interval      time.Duration `envconfig:"INTERVAL" default:"5s"`
+	TargetLatency time.Duration `envconfig:"TARGET_LATENCY" default:"250ms"`
+	MaxErrorRate  float64       `envconfig:"MAX_ERROR_RATE" default:"0.05"`
+	Backoff       float64       `envconfig:"BACKOFF" default:"0.5"`
+}
+
+// Validate checks that the limits of the controller make sense.
+func (c AdaptiveConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.MinWorkers < 1 {
+		return fmt.Errorf("minimum number of workers must be positive: %d", c.MinWorkers)
+	}
+	if c.Interval <= 0 {
+		return fmt.Errorf("adjustment interval must be positive: %s", c.Interval)
+	}
+	if c.Backoff <= 0 || c.Backoff >= 1 {
+		return fmt.Errorf("backoff must be between 0 and 1: %g", c.Backoff)
+	}
+	return nil
+}
+
+// AdaptiveLimiter limits how many workers process messages at the same time. The limit is
+// adjusted with additive increase and multiplicative decrease: it grows by one worker every
+// interval where the mean publish latency and the error rate stay within their targets, and is
+// multiplied by the backoff as soon as either is exceeded.
+type AdaptiveLimiter struct {
+	logger  *slog.Logger
+	config  AdaptiveConfig
+	max     int
+	mu      sync.Mutex
+	cond    *sync.Cond
+	limit   int
+	active  int
+	stopped bool
+
+	samples int
+	errors  int
+	latency time.Duration
+}
+
+// NewAdaptiveLimiter returns a limiter for the given number of workers. It starts with all of
+// them allowed and backs off once the sinks show that they cannot keep up.
+func NewAdaptiveLimiter(logger *slog.Logger, config AdaptiveConfig, workers int) *AdaptiveLimiter {
+	if config.MinWorkers < 1 {
+		config.MinWorkers = 1
+	}
+	if config.MinWorkers > workers {
+		config.MinWorkers = workers
+	}
+
+	l := &AdaptiveLimiter{
+		logger: logger,
+		config: config,
+		max:    workers,
+		limit:  workers,
+	}
+	l.cond = sync.NewCond(&l.mu)
+	metrics.WorkersLimit.Set(float64(l.limit))
+
+	return l
+}
+
+// Limit returns the current number of workers allowed to process messages.
+func (l *AdaptiveLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.limit
+}
+
+// ObservePublish records the latency and outcome of a published event.
+func (l *AdaptiveLimiter) ObservePublish(latency time.Duration, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.samples++
+	l.latency += latency
+	if err != nil {
+		l.errors++
+	}
+}
+
+// extend raises the maximum and the current limit by workers, e.g. for the workers of a burst,
+// so that the burst adds to the workers the limit allows. The returned function takes the
+// workers back.
+func (l *AdaptiveLimiter) extend(workers int) func() {
+	l.mu.Lock()
+	l.max += workers
+	l.limit += workers
+	metrics.WorkersLimit.Set(float64(l.limit))
+	l.mu.Unlock()
+
+	l.cond.Broadcast()
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		l.max -= workers
+		l.limit = max(l.config.MinWorkers, min(l.limit, l.max))
+		metrics.WorkersLimit.Set(float64(l.limit))
+	}
+}
+
+// acquire blocks until the worker is allowed to process a message. It returns false if the
+// limiter was stopped.
+func (l *AdaptiveLimiter) acquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for l.active >= l.limit && !l.stopped {
+		l.cond.Wait()
+	}
+	if l.stopped {
+		return false
+	}
+	l.active++
+	return true
+}
+
+func (l *AdaptiveLimiter) release() {
+	l.mu.Lock()
+	l.active--
+	l.mu.Unlock()
+
+	l.cond.Signal()
+}
+
+// adjust updates the limit from the publishes observed since the last adjustment. Nothing
+// changes if no events were published.
+func (l *AdaptiveLimiter) adjust() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.samples == 0 {
+		return
+	}
+
+	latency := l.latency / time.Duration(l.samples)
+	errorRate := float64(l.errors) / float64(l.samples)
+	l.samples, l.errors, l.latency = 0, 0, 0
+
+	previous := l.limit
+	if latency > l.config.TargetLatency || errorRate > l.config.MaxErrorRate {
+		l.limit = max(l.config.MinWorkers, int(float64(l.limit)*l.config.Backoff))
+	} else if l.limit < l.max {
+		l.limit++
+		l.cond.Broadcast()
+	}
+
+	if l.limit != previous {
+		metrics.WorkersLimit.Set(float64(l.limit))
+		l.logger.Debug(fmt.Sprintf("Adjusted webhook worker limit from %d to %d", previous, l.limit),
+			"latency_ms", float64(latency.Microseconds())/1000,
+			"error_rate", errorRate)
+	}
+}
+
+// run adjusts the limit every interval until stopped is closed.
+func (l *AdaptiveLimiter) run(stopped <-chan struct{}) {
+	ticker := time.NewTicker(l.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.adjust()
+		case <-stopped:
+			return
+		}
+	}
+}
+
+// stop wakes up all workers waiting to acquire.
+func (l *AdaptiveLimiter) stop() {
+	l.mu.Lock()
+	l.stopped = true
+	l.mu.Unlock()
+
+	l.cond.Broadcast()
+}
@@ -0,0 +1,161 @@
+package consumer
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptiveLimiterAdjust(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	config := AdaptiveConfig{MinWorkers: 2, TargetLatency: 100 * time.Millisecond, MaxErrorRate: 0.1, Backoff: 0.5}
+
+	for _, tc := range []struct {
+		title         string
+		limit         int
+		latency       time.Duration
+		errors        int
+		expectedLimit int
+	}{
+		{title: "grows when latency and errors are within targets", limit: 4, latency: 50 * time.Millisecond, expectedLimit: 5},
+		{title: "does not grow beyond the number of workers", limit: 8, latency: 50 * time.Millisecond, expectedLimit: 8},
+		{title: "backs off when latency exceeds the target", limit: 8, latency: 200 * time.Millisecond, expectedLimit: 4},
+		{title: "backs off when the error rate exceeds the maximum", limit: 8, latency: 50 * time.Millisecond, errors: 2, expectedLimit: 4},
+		{title: "does not back off below the minimum", limit: 3, latency: 200 * time.Millisecond, expectedLimit: 2},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			limiter := NewAdaptiveLimiter(logger, config, 8)
+			limiter.limit = tc.limit
+
+			for i := 0; i < 10; i++ {
+				var err error
+				if i < tc.errors {
+					err = fmt.Errorf("publish failed")
+				}
+				limiter.ObservePublish(tc.latency, err)
+			}
+			limiter.adjust()
+
+			assert.Equal(t, tc.expectedLimit, limiter.Limit())
+		})
+	}
+}
+
+func TestAdaptiveLimiterKeepsLimitWithoutSamples(t *testing.T) {
+
+	limiter := NewAdaptiveLimiter(slog.New(slog.NewTextHandler(io.Discard, nil)), AdaptiveConfig{Backoff: 0.5}, 4)
+	limiter.limit = 2
+
+	limiter.adjust()
+
+	assert.Equal(t, 2, limiter.Limit())
+}
+
+func TestWorkerPoolLimiter(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	pool := NewWorkerPool(logger, 4, 0)
+	limiter := NewAdaptiveLimiter(logger, AdaptiveConfig{Interval: time.Hour, Backoff: 0.5}, pool.Workers())
+	limiter.limit = 2
+	pool.SetLimiter(limiter)
+
+	var current, maxConcurrent atomic.Int32
+	pool.Start(func(msg jetstream.Msg) {
+		n := current.Add(1)
+		for {
+			m := maxConcurrent.Load()
+			if n <= m || maxConcurrent.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		current.Add(-1)
+	})
+
+	for i := 0; i < 12; i++ {
+		pool.Handle(testMsg{})
+	}
+	pool.Stop()
+
+	assert.Equal(t, int32(2), maxConcurrent.Load())
+}
+
+type nakMsg struct {
+	jetstream.Msg
+	naked chan struct{}
+}
+
+func (m nakMsg) Nak() error {
+	close(m.naked)
+	return nil
+}
+
+func TestWorkerPoolNaksMessageWhenLimiterStops(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	pool := NewWorkerPool(logger, 2, 0)
+	limiter := NewAdaptiveLimiter(logger, AdaptiveConfig{Interval: time.Hour, Backoff: 0.5}, pool.Workers())
+	limiter.limit = 1
+	pool.SetLimiter(limiter)
+
+	started, unblock := make(chan struct{}), make(chan struct{})
+	pool.Start(func(msg jetstream.Msg) {
+		close(started)
+		<-unblock
+	})
+
+	pool.Handle(testMsg{})
+	<-started
+
+	// The second worker takes the message and waits for the limit held by the first one.
+	waiting := nakMsg{naked: make(chan struct{})}
+	pool.Handle(waiting)
+
+	stopped := make(chan struct{})
+	go func() {
+		pool.Stop()
+		close(stopped)
+	}()
+
+	select {
+	case <-waiting.naked:
+	case <-time.After(time.Second):
+		t.Fatal("message taken when the limiter stopped should be negatively acknowledged")
+	}
+	close(unblock)
+	<-stopped
+}
+
+func TestWorkerPoolBurstExtendsLimiter(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	pool := NewWorkerPool(logger, 2, 0)
+	limiter := NewAdaptiveLimiter(logger, AdaptiveConfig{Interval: time.Hour, Backoff: 0.5}, pool.Workers())
+	pool.SetLimiter(limiter)
+	pool.Start(func(msg jetstream.Msg) {})
+	defer pool.Stop()
+
+	stop := pool.Burst(3)
+	assert.Equal(t, 5, limiter.Limit(), "burst should raise the limit by its workers")
+
+	limiter.ObservePublish(time.Second, nil)
+	limiter.adjust()
+	assert.Equal(t, 2, limiter.Limit())
+
+	stop()
+	assert.Equal(t, 2, limiter.Limit(), "limit below the steady-state maximum should be kept")
+
+	stop = pool.Burst(3)
+	stop()
+	assert.Equal(t, 2, limiter.Limit())
+}
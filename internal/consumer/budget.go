@@ -0,0 +1,123 @@
+package consumer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/metrics"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// byteBudget bounds the total size of the message payloads held by the worker pool, queued or
+// being processed, so that a burst of large payloads cannot exhaust memory.
+type byteBudget struct {
+	max     int64
+	mu      sync.Mutex
+	cond    *sync.Cond
+	used    int64
+	stopped bool
+}
+
+func newByteBudget(max int64) *byteBudget {
+	b := &byteBudget{max: max}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// fits reports whether n more bytes fit. A payload larger than the whole budget fits when nothing
+// else is held, so that it is not held up forever.
+func (b *byteBudget) fits(n int64) bool {
+	return b.used == 0 || b.used+n <= b.max
+}
+
+// acquire waits until n bytes fit in the budget. It returns false if the budget was stopped.
+func (b *byteBudget) acquire(n int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for !b.fits(n) && !b.stopped {
+		b.cond.Wait()
+	}
+	if b.stopped {
+		return false
+	}
+	b.used += n
+	metrics.InflightBytes.Set(float64(b.used))
+	return true
+}
+
+// tryAcquire takes n bytes of the budget if they fit right away.
+func (b *byteBudget) tryAcquire(n int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.fits(n) || b.stopped {
+		return false
+	}
+	b.used += n
+	metrics.InflightBytes.Set(float64(b.used))
+	return true
+}
+
+func (b *byteBudget) release(n int64) {
+	b.mu.Lock()
+	b.used -= n
+	metrics.InflightBytes.Set(float64(b.used))
+	b.mu.Unlock()
+
+	b.cond.Broadcast()
+}
+
+// stop wakes up everyone waiting to acquire.
+func (b *byteBudget) stop() {
+	b.mu.Lock()
+	b.stopped = true
+	b.mu.Unlock()
+
+	b.cond.Broadcast()
+}
+
+// budgetedMsg is a message handed to a worker while it holds part of the budget. The budget is
+// released once the message is acknowledged, negatively acknowledged or terminated, so that a
+// message whose event is still being published asynchronously keeps counting against it.
+type budgetedMsg struct {
+	jetstream.Msg
+	once    sync.Once
+	release func()
+}
+
+func (m *budgetedMsg) done() {
+	m.once.Do(m.release)
+}
+
+func (m *budgetedMsg) Ack() error {
+	defer m.done()
+	return m.Msg.Ack()
+}
+
+func (m *budgetedMsg) DoubleAck(ctx context.Context) error {
+	defer m.done()
+	return m.Msg.DoubleAck(ctx)
+}
+
+func (m *budgetedMsg) Nak() error {
+	defer m.done()
+	return m.Msg.Nak()
+}
+
+func (m *budgetedMsg) NakWithDelay(delay time.Duration) error {
+	defer m.done()
+	return m.Msg.NakWithDelay(delay)
+}
+
+func (m *budgetedMsg) Term() error {
+	defer m.done()
+	return m.Msg.Term()
+}
+
+func (m *budgetedMsg) TermWithReason(reason string) error {
+	defer m.done()
+	return m.Msg.TermWithReason(reason)
+}
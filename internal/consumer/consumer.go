@@ -0,0 +1,135 @@
+package consumer
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// drainTimeout is how long to wait for the fetched messages to be handled when consuming is
+// restarted.
+const drainTimeout = 30 * time.Second
+
+type JetStreamConsumer interface {
+	Consume(handler jetstream.MessageHandler, opts ...jetstream.PullConsumeOpt) (jetstream.ConsumeContext, error)
+}
+
+// PausableConsumer consumes messages from a JetStream consumer and can be paused and resumed
+// at runtime. While paused, messages stay in the stream until consumption is resumed.
+type PausableConsumer struct {
+	logger     *slog.Logger
+	consumer   JetStreamConsumer
+	handler    jetstream.MessageHandler
+	opts       []jetstream.PullConsumeOpt
+	mu         sync.Mutex
+	consumeCtx jetstream.ConsumeContext
+}
+
+func NewPausableConsumer(logger *slog.Logger, consumer JetStreamConsumer, handler jetstream.MessageHandler) *PausableConsumer {
+	return &PausableConsumer{
+		logger:   logger,
+		consumer: consumer,
+		handler:  handler,
+	}
+}
+
+// SetBatchSize sets the maximum number of messages fetched from the consumer in each pull
+// request. Takes effect the next time consuming is started.
+func (c *PausableConsumer) SetBatchSize(size int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.opts = []jetstream.PullConsumeOpt{jetstream.PullMaxMessages(size)}
+}
+
+// Rebatch changes the batch size while consuming. Consuming is drained, so that the messages
+// already fetched are handled, and started again with the new batch size.
+func (c *PausableConsumer) Rebatch(size int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.opts = []jetstream.PullConsumeOpt{jetstream.PullMaxMessages(size)}
+
+	if c.consumeCtx == nil {
+		return nil
+	}
+
+	c.consumeCtx.Drain()
+	select {
+	case <-c.consumeCtx.Closed():
+	case <-time.After(drainTimeout):
+		c.consumeCtx.Stop()
+	}
+	c.consumeCtx = nil
+
+	consumeCtx, err := c.consumer.Consume(c.handler, c.opts...)
+	if err != nil {
+		return fmt.Errorf("failed to restart consuming: %w", err)
+	}
+
+	c.consumeCtx = consumeCtx
+
+	return nil
+}
+
+func (c *PausableConsumer) Start() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.consumeCtx != nil {
+		return nil
+	}
+
+	consumeCtx, err := c.consumer.Consume(c.handler, c.opts...)
+	if err != nil {
+		return fmt.Errorf("failed to start consuming: %w", err)
+	}
+
+	c.consumeCtx = consumeCtx
+
+	return nil
+}
+
+func (c *PausableConsumer) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.consumeCtx != nil {
+		c.consumeCtx.Stop()
+		c.consumeCtx = nil
+	}
+}
+
+func (c *PausableConsumer) Pause() error {
+	if c.Paused() {
+		return nil
+	}
+
+	c.Stop()
+	c.logger.Info("Paused consuming webhook messages")
+
+	return nil
+}
+
+func (c *PausableConsumer) Resume() error {
+	if !c.Paused() {
+		return nil
+	}
+
+	if err := c.Start(); err != nil {
+		return err
+	}
+	c.logger.Info("Resumed consuming webhook messages")
+
+	return nil
+}
+
+func (c *PausableConsumer) Paused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.consumeCtx == nil
+}
@@ -0,0 +1,83 @@
+package consumer
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockJetStreamConsumer struct {
+	mock.Mock
+	opts []jetstream.PullConsumeOpt
+}
+
+func (m *MockJetStreamConsumer) Consume(handler jetstream.MessageHandler, opts ...jetstream.PullConsumeOpt) (jetstream.ConsumeContext, error) {
+	m.opts = opts
+	args := m.Called()
+	return args.Get(0).(jetstream.ConsumeContext), args.Error(1)
+}
+
+type MockConsumeContext struct {
+	stopped bool
+	drained bool
+}
+
+func (m *MockConsumeContext) Stop()  { m.stopped = true }
+func (m *MockConsumeContext) Drain() { m.drained = true }
+
+func (m *MockConsumeContext) Closed() <-chan struct{} {
+	closed := make(chan struct{})
+	close(closed)
+	return closed
+}
+
+func TestPausableConsumer(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mockConsumer := &MockJetStreamConsumer{}
+	first := &MockConsumeContext{}
+	second := &MockConsumeContext{}
+	mockConsumer.On("Consume").Return(first, nil).Once()
+	mockConsumer.On("Consume").Return(second, nil).Once()
+
+	c := NewPausableConsumer(logger, mockConsumer, func(msg jetstream.Msg) {})
+
+	require.NoError(t, c.Start(), "start should not return error")
+	assert.False(t, c.Paused(), "consumer should not be paused after start")
+
+	require.NoError(t, c.Pause(), "pause should not return error")
+	assert.True(t, c.Paused(), "consumer should be paused")
+	assert.True(t, first.stopped, "consume context should be stopped on pause")
+
+	require.NoError(t, c.Pause(), "pausing twice should not return error")
+
+	require.NoError(t, c.Resume(), "resume should not return error")
+	assert.False(t, c.Paused(), "consumer should not be paused after resume")
+	assert.False(t, second.stopped, "new consume context should be running")
+
+	require.NoError(t, c.Resume(), "resuming twice should not return error")
+	mockConsumer.AssertNumberOfCalls(t, "Consume", 2)
+
+	c.Stop()
+	assert.True(t, second.stopped, "consume context should be stopped on stop")
+}
+
+func TestPausableConsumerBatchSize(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mockConsumer := &MockJetStreamConsumer{}
+	mockConsumer.On("Consume").Return(&MockConsumeContext{}, nil)
+
+	c := NewPausableConsumer(logger, mockConsumer, func(msg jetstream.Msg) {})
+	c.SetBatchSize(50)
+
+	require.NoError(t, c.Start(), "start should not return error")
+	assert.Equal(t, []jetstream.PullConsumeOpt{jetstream.PullMaxMessages(50)}, mockConsumer.opts, "messages should be fetched in batches of the set size")
+}
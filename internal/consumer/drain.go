@@ -0,0 +1,166 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/metrics"
+)
+
+// DrainConfig configures the drain mode entered at startup when the consumer has a large backlog.
+type DrainConfig struct {
+	Enabled bool `envconfig:"ENABLED" default:"false"`
+	// Threshold is the number of pending messages at startup that enters drain mode.
+	Threshold uint64 `envconfig:"THRESHOLD" default:"10000"`
+	// ExitThreshold is the number of pending messages at which drain mode is left.
+	ExitThreshold uint64 `envconfig:"EXIT_THRESHOLD" default:"100"`
+	// Workers is the number of workers added while draining.
+	Workers int `envconfig:"WORKERS" default:"4"`
+	// FetchBatch is the batch size while draining. Zero keeps the steady-state batch size.
+	FetchBatch int `envconfig:"FETCH_BATCH" default:"0"`
+	// DeferLimit is the maximum number of audit records held back while draining.
+	DeferLimit int `envconfig:"DEFER_LIMIT" default:"100000"`
+	// Interval is how often the backlog is checked while draining.
+	Interval time.Duration `envconfig:"INTERVAL" default:"5s"`
+}
+
+// Deferrer holds back non-essential work while draining, e.g. audit records.
+type Deferrer interface {
+	Defer()
+	Flush() error
+}
+
+// BacklogDrain checks the backlog of the consumer at startup. If it is above the threshold, more
+// workers are started, messages are fetched in larger batches and non-essential work is deferred
+// until the backlog is back under the exit threshold.
+type BacklogDrain struct {
+	logger     *slog.Logger
+	config     DrainConfig
+	info       InfoProvider
+	pool       *WorkerPool
+	consumer   *PausableConsumer
+	batchSize  int
+	deferrers  []Deferrer
+	draining   atomic.Bool
+	stopBurst  func()
+	enteredAt  time.Time
+	drainStart uint64
+}
+
+// NewBacklogDrain returns a drain for the consumer and pool. batchSize is the steady-state batch
+// size that is restored when the drain is done.
+func NewBacklogDrain(logger *slog.Logger, config DrainConfig, info InfoProvider, pool *WorkerPool, consumer *PausableConsumer, batchSize int) *BacklogDrain {
+	if config.Interval <= 0 {
+		config.Interval = 5 * time.Second
+	}
+
+	return &BacklogDrain{
+		logger:    logger,
+		config:    config,
+		info:      info,
+		pool:      pool,
+		consumer:  consumer,
+		batchSize: batchSize,
+	}
+}
+
+// AddDeferrer adds work that is deferred while draining.
+func (d *BacklogDrain) AddDeferrer(deferrer Deferrer) {
+	d.deferrers = append(d.deferrers, deferrer)
+}
+
+func (d *BacklogDrain) Draining() bool {
+	return d.draining.Load()
+}
+
+// Run enters drain mode if the backlog is above the threshold and returns once it has been
+// drained or ctx is done. It returns immediately if the backlog is small. The pool and the
+// consumer must have been started.
+func (d *BacklogDrain) Run(ctx context.Context) error {
+	pending, err := d.pending(ctx)
+	if err != nil {
+		return err
+	}
+	if pending < d.config.Threshold {
+		return nil
+	}
+
+	d.enter(pending)
+	defer d.exit()
+
+	ticker := time.NewTicker(d.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		pending, err := d.pending(ctx)
+		if err != nil {
+			d.logger.Error("Failed to query consumer backlog while draining", "error", err.Error())
+			continue
+		}
+		if pending <= d.config.ExitThreshold {
+			return nil
+		}
+	}
+}
+
+func (d *BacklogDrain) pending(ctx context.Context) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	info, err := d.info.Info(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return info.NumPending, nil
+}
+
+func (d *BacklogDrain) enter(pending uint64) {
+	d.draining.Store(true)
+	d.enteredAt = time.Now()
+	d.drainStart = pending
+	metrics.DrainMode.Set(1)
+
+	d.logger.Info(fmt.Sprintf("Draining backlog of %d webhook messages with %d additional workers", pending, d.config.Workers))
+
+	for _, deferrer := range d.deferrers {
+		deferrer.Defer()
+	}
+
+	d.stopBurst = d.pool.Burst(d.config.Workers)
+
+	if d.config.FetchBatch > 0 {
+		if err := d.consumer.Rebatch(d.config.FetchBatch); err != nil {
+			d.logger.Error("Failed to raise batch size for draining", "error", err.Error())
+		}
+	}
+}
+
+func (d *BacklogDrain) exit() {
+	if d.config.FetchBatch > 0 {
+		if err := d.consumer.Rebatch(d.batchSize); err != nil {
+			d.logger.Error("Failed to restore batch size after draining", "error", err.Error())
+		}
+	}
+
+	d.stopBurst()
+
+	for _, deferrer := range d.deferrers {
+		if err := deferrer.Flush(); err != nil {
+			d.logger.Error("Failed to flush work deferred while draining", "error", err.Error())
+		}
+	}
+
+	metrics.DrainMode.Set(0)
+	d.draining.Store(false)
+
+	d.logger.Info(fmt.Sprintf("Drained backlog of %d webhook messages in %s", d.drainStart, time.Since(d.enteredAt).Round(time.Second)))
+}
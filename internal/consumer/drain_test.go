@@ -0,0 +1,119 @@
+package consumer
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingDeferrer struct {
+	deferred atomic.Bool
+	flushed  atomic.Bool
+}
+
+func (d *recordingDeferrer) Defer() { d.deferred.Store(true) }
+
+func (d *recordingDeferrer) Flush() error {
+	d.flushed.Store(true)
+	return nil
+}
+
+func TestBacklogDrain(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	config := DrainConfig{Threshold: 1000, ExitThreshold: 10, Workers: 3, FetchBatch: 1000, Interval: 10 * time.Millisecond}
+
+	t.Run("does not drain a small backlog", func(t *testing.T) {
+		mockInfo := &MockInfoProvider{}
+		mockInfo.On("Info").Return(&jetstream.ConsumerInfo{NumPending: 999}, nil).Once()
+
+		deferrer := &recordingDeferrer{}
+		drain := NewBacklogDrain(logger, config, mockInfo, NewWorkerPool(logger, 1, 0), NewPausableConsumer(logger, &MockJetStreamConsumer{}, nil), 100)
+		drain.AddDeferrer(deferrer)
+
+		require.NoError(t, drain.Run(context.Background()))
+		assert.False(t, deferrer.deferred.Load())
+		mockInfo.AssertExpectations(t)
+	})
+
+	t.Run("drains a large backlog and returns to steady state", func(t *testing.T) {
+		mockInfo := &MockInfoProvider{}
+		mockInfo.On("Info").Return(&jetstream.ConsumerInfo{NumPending: 5000}, nil).Twice()
+		mockInfo.On("Info").Return(&jetstream.ConsumerInfo{NumPending: 10}, nil).Once()
+
+		mockConsumer := &MockJetStreamConsumer{}
+		mockConsumer.On("Consume").Return(&MockConsumeContext{}, nil)
+		c := NewPausableConsumer(logger, mockConsumer, func(msg jetstream.Msg) {})
+		require.NoError(t, c.Start())
+
+		var busy, maxBusy atomic.Int32
+		release := make(chan struct{})
+		pool := NewWorkerPool(logger, 1, 0)
+		pool.Start(func(msg jetstream.Msg) {
+			n := busy.Add(1)
+			for {
+				m := maxBusy.Load()
+				if n <= m || maxBusy.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			<-release
+			busy.Add(-1)
+		})
+		defer pool.Stop()
+
+		deferrer := &recordingDeferrer{}
+		drain := NewBacklogDrain(logger, config, mockInfo, pool, c, 100)
+		drain.AddDeferrer(deferrer)
+
+		done := make(chan error)
+		go func() { done <- drain.Run(context.Background()) }()
+
+		require.Eventually(t, drain.Draining, time.Second, time.Millisecond)
+		assert.True(t, deferrer.deferred.Load(), "work should be deferred while draining")
+
+		for i := 0; i < 4; i++ {
+			go pool.Handle(testMsg{})
+		}
+		require.Eventually(t, func() bool { return maxBusy.Load() == 4 }, time.Second, time.Millisecond,
+			"additional workers should process messages while draining")
+		close(release)
+
+		require.NoError(t, <-done)
+		assert.False(t, drain.Draining())
+		assert.True(t, deferrer.flushed.Load(), "deferred work should be flushed after draining")
+		assert.Len(t, mockConsumer.opts, 1, "batch size should be restored")
+		mockConsumer.AssertNumberOfCalls(t, "Consume", 3)
+		mockInfo.AssertExpectations(t)
+	})
+}
+
+func TestPausableConsumerRebatch(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mockConsumer := &MockJetStreamConsumer{}
+	first := &MockConsumeContext{}
+	mockConsumer.On("Consume").Return(first, nil).Once()
+	mockConsumer.On("Consume").Return(&MockConsumeContext{}, nil).Once()
+
+	c := NewPausableConsumer(logger, mockConsumer, func(msg jetstream.Msg) {})
+
+	require.NoError(t, c.Rebatch(10), "rebatch should not start a stopped consumer")
+	mockConsumer.AssertNotCalled(t, "Consume", mock.Anything)
+
+	require.NoError(t, c.Start())
+	require.NoError(t, c.Rebatch(20))
+
+	assert.True(t, first.drained, "running consumer should be drained before restarting")
+	assert.False(t, c.Paused())
+	mockConsumer.AssertNumberOfCalls(t, "Consume", 2)
+}
@@ -0,0 +1,118 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/metrics"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type InfoProvider interface {
+	Info(ctx context.Context) (*jetstream.ConsumerInfo, error)
+}
+
+type Lag struct {
+	NumPending     uint64    `json:"num_pending"`
+	NumAckPending  int       `json:"num_ack_pending"`
+	NumRedelivered int       `json:"num_redelivered"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// LagMonitor periodically queries consumer info and keeps track of the backlog of webhook
+// messages. A warning is logged whenever the number of pending messages exceeds warnThreshold,
+// unless the threshold is zero.
+type LagMonitor struct {
+	logger        *slog.Logger
+	consumer      InfoProvider
+	interval      time.Duration
+	warnThreshold uint64
+	mu            sync.RWMutex
+	lag           Lag
+}
+
+func NewLagMonitor(logger *slog.Logger, consumer InfoProvider, interval time.Duration, warnThreshold uint64) *LagMonitor {
+	return &LagMonitor{
+		logger:        logger,
+		consumer:      consumer,
+		interval:      interval,
+		warnThreshold: warnThreshold,
+	}
+}
+
+// ValidateLagInterval checks that the interval of a lag monitor is positive.
+func ValidateLagInterval(interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("consumer lag interval must be positive: %s", interval)
+	}
+	return nil
+}
+
+// Run updates the lag every interval until ctx is done. It returns at once if the interval is
+// not positive.
+func (m *LagMonitor) Run(ctx context.Context) {
+	if err := ValidateLagInterval(m.interval); err != nil {
+		m.logger.Error("Not monitoring consumer lag", "error", err.Error())
+		return
+	}
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := m.Update(ctx); err != nil {
+			m.logger.Error("Failed to query consumer info", "error", err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *LagMonitor) Update(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	info, err := m.consumer.Info(ctx)
+	if err != nil {
+		return err
+	}
+
+	lag := Lag{
+		NumPending:     info.NumPending,
+		NumAckPending:  info.NumAckPending,
+		NumRedelivered: info.NumRedelivered,
+		UpdatedAt:      time.Now(),
+	}
+
+	m.mu.Lock()
+	m.lag = lag
+	m.mu.Unlock()
+
+	metrics.ConsumerNumPending.Set(float64(lag.NumPending))
+	metrics.ConsumerNumAckPending.Set(float64(lag.NumAckPending))
+	metrics.ConsumerNumRedelivered.Set(float64(lag.NumRedelivered))
+	metrics.ConsumerBacklog.Set(float64(lag.NumPending) + float64(lag.NumAckPending))
+
+	if m.warnThreshold > 0 && lag.NumPending > m.warnThreshold {
+		m.logger.Warn(fmt.Sprintf("Consumer lag of %d pending messages exceeds threshold of %d", lag.NumPending, m.warnThreshold),
+			"num_ack_pending", lag.NumAckPending,
+			"num_redelivered", lag.NumRedelivered)
+	}
+
+	return nil
+}
+
+func (m *LagMonitor) Lag() Lag {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.lag
+}
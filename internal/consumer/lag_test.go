@@ -0,0 +1,61 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockInfoProvider struct {
+	mock.Mock
+}
+
+func (m *MockInfoProvider) Info(ctx context.Context) (*jetstream.ConsumerInfo, error) {
+	args := m.Called()
+	info, _ := args.Get(0).(*jetstream.ConsumerInfo)
+	return info, args.Error(1)
+}
+
+func TestLagMonitorUpdate(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mockInfo := &MockInfoProvider{}
+	mockInfo.On("Info").Return(&jetstream.ConsumerInfo{
+		NumPending:     42,
+		NumAckPending:  3,
+		NumRedelivered: 1,
+	}, nil).Once()
+	mockInfo.On("Info").Return(nil, fmt.Errorf("timeout")).Once()
+
+	monitor := NewLagMonitor(logger, mockInfo, 0, 10)
+
+	require.NoError(t, monitor.Update(context.Background()), "update should not return error")
+
+	lag := monitor.Lag()
+	assert.Equal(t, uint64(42), lag.NumPending)
+	assert.Equal(t, 3, lag.NumAckPending)
+	assert.Equal(t, 1, lag.NumRedelivered)
+	assert.False(t, lag.UpdatedAt.IsZero(), "updated at should be set")
+
+	require.Error(t, monitor.Update(context.Background()), "update should return error from consumer info")
+	assert.Equal(t, lag, monitor.Lag(), "lag should be kept on failed update")
+}
+
+func TestLagMonitorRunWithoutInterval(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	monitor := NewLagMonitor(logger, &MockInfoProvider{}, 0, 10)
+
+	assert.Error(t, ValidateLagInterval(0))
+	assert.NotPanics(t, func() { monitor.Run(context.Background()) }, "run should return without an interval")
+	assert.True(t, monitor.Lag().UpdatedAt.IsZero(), "lag should not be updated")
+}
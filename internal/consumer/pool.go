@@ -0,0 +1,346 @@
+package consumer
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/metrics"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// WorkerPool processes consumed messages concurrently with a fixed number of workers. Messages
+// wait for a worker in a bounded queue. Without a queue, or with the block overflow policy, Handle
+// blocks until there is room, so the messages waiting for a worker stay buffered in the
+// JetStream consumer. When sharded, every worker has a queue of its own.
+type WorkerPool struct {
+	logger   *slog.Logger
+	workers  int
+	queue    QueueConfig
+	sharded  bool
+	spill    *spill
+	limiter  *AdaptiveLimiter
+	budget   *byteBudget
+	process  func(msg jetstream.Msg)
+	queues   []chan jetstream.Msg
+	stopped  chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewWorkerPool returns a pool with the given number of workers. Since every worker holds one
+// unacknowledged message, the number of workers is limited to maxAckPending of the consumer if
+// it is set.
+func NewWorkerPool(logger *slog.Logger, workers, maxAckPending int) *WorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	if maxAckPending > 0 && workers > maxAckPending {
+		logger.Warn(fmt.Sprintf("Limiting webhook workers to the consumer max ack pending of %d", maxAckPending))
+		workers = maxAckPending
+	}
+
+	p := &WorkerPool{
+		logger:  logger,
+		workers: workers,
+		stopped: make(chan struct{}),
+	}
+	p.makeQueues()
+
+	return p
+}
+
+// makeQueues creates one queue shared by all workers, or one per worker when sharded.
+func (p *WorkerPool) makeQueues() {
+	n := 1
+	if p.sharded {
+		n = p.workers
+	}
+
+	p.queues = make([]chan jetstream.Msg, n)
+	for i := range p.queues {
+		p.queues[i] = make(chan jetstream.Msg, p.queue.Size)
+	}
+}
+
+func (p *WorkerPool) Workers() int {
+	return p.workers
+}
+
+// SetQueue sets the size of the queue in front of the workers and the policy for messages that do
+// not fit. It must be called before the pool is started.
+func (p *WorkerPool) SetQueue(config QueueConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	p.queue = config
+	p.makeQueues()
+
+	if config.Overflow == OverflowSpill {
+		spill, err := openSpill(config.SpillDir, config.SpillLimit)
+		if err != nil {
+			return err
+		}
+		if n := spill.len(); n > 0 {
+			p.logger.Info(fmt.Sprintf("Recovered %d webhook messages spilled to %s", n, config.SpillDir))
+		}
+		p.spill = spill
+	}
+
+	return nil
+}
+
+// SetLimiter limits the number of workers processing messages at the same time to the adaptive
+// limit of the limiter, which is adjusted while the pool is running. It must be called before the
+// pool is started.
+func (p *WorkerPool) SetLimiter(limiter *AdaptiveLimiter) {
+	p.limiter = limiter
+}
+
+// SetSharded gives every worker a queue of its own and queues messages by their shard key, so that
+// the messages for a repository are processed in order by the same worker while other
+// repositories are processed in parallel. It must be called before the pool is started.
+func (p *WorkerPool) SetSharded(sharded bool) {
+	p.sharded = sharded
+	p.makeQueues()
+}
+
+// Handle queues a message for a worker. It is the message handler of the consumer. Messages
+// handled after the pool is stopped are left unacknowledged to be redelivered.
+func (p *WorkerPool) Handle(msg jetstream.Msg) {
+	queue := p.queueFor(msg)
+
+	if p.queue.Overflow == "" || p.queue.Overflow == OverflowBlock {
+		if p.budget != nil && !p.budget.acquire(size(msg)) {
+			return
+		}
+		select {
+		case queue <- msg:
+			p.updateQueueLength()
+		case <-p.stopped:
+			p.release(msg)
+		}
+		return
+	}
+
+	if p.budget != nil && !p.budget.tryAcquire(size(msg)) {
+		p.overflow(msg)
+		return
+	}
+
+	select {
+	case queue <- msg:
+		p.updateQueueLength()
+	case <-p.stopped:
+		p.release(msg)
+	default:
+		p.release(msg)
+		p.overflow(msg)
+	}
+}
+
+// SetMaxInflightBytes bounds the total size of the payloads of the messages that are queued or
+// being processed. A message is processed until it is acknowledged, which can be after the worker
+// is done with it when its event is published asynchronously. Messages that do not fit wait like messages that do not fit in the queue, or
+// overflow with the nak and spill policies. Zero means no limit. It must be called before the
+// pool is started.
+func (p *WorkerPool) SetMaxInflightBytes(max int64) {
+	p.budget = nil
+	if max > 0 {
+		p.budget = newByteBudget(max)
+	}
+}
+
+func size(msg jetstream.Msg) int64 {
+	return int64(len(msg.Data()))
+}
+
+// release returns the size of a message that has left the pool to the budget.
+func (p *WorkerPool) release(msg jetstream.Msg) {
+	if p.budget != nil {
+		p.budget.release(size(msg))
+	}
+}
+
+func (p *WorkerPool) queueFor(msg jetstream.Msg) chan jetstream.Msg {
+	if len(p.queues) == 1 {
+		return p.queues[0]
+	}
+	return p.queues[shard(ShardKey(msg), len(p.queues))]
+}
+
+func (p *WorkerPool) updateQueueLength() {
+	queued := 0
+	for _, queue := range p.queues {
+		queued += len(queue)
+	}
+	metrics.QueueLength.Set(float64(queued))
+}
+
+// overflow spills a message that did not fit in the queue to disk, or negatively acknowledges it
+// if the policy is nak or the spill is full or failing.
+func (p *WorkerPool) overflow(msg jetstream.Msg) {
+	if p.spill != nil {
+		stored, err := p.spill.store(msg)
+		if err != nil {
+			p.logger.Error("Failed to spill webhook message to disk", "error", err.Error())
+		}
+		if stored {
+			if err := msg.Ack(); err != nil {
+				p.logger.Error("Failed to ack spilled webhook message", "error", err.Error())
+			}
+			metrics.QueueOverflows.WithLabelValues(OverflowSpill).Inc()
+			return
+		}
+	}
+
+	if err := msg.NakWithDelay(p.queue.NakDelay); err != nil {
+		p.logger.Error("Failed to nak webhook message", "error", err.Error())
+	}
+	metrics.QueueOverflows.WithLabelValues(OverflowNak).Inc()
+}
+
+// drain queues spilled messages when there is room in the queue.
+func (p *WorkerPool) drain() {
+	defer p.wg.Done()
+	for {
+		msg, ok, err := p.spill.next()
+		if err != nil {
+			p.logger.Error("Dropped spilled webhook message", "error", err.Error())
+			continue
+		}
+		if !ok {
+			select {
+			case <-p.spill.notify:
+				continue
+			case <-p.stopped:
+				return
+			}
+		}
+
+		if p.budget != nil && !p.budget.acquire(size(msg)) {
+			p.spill.release(msg.name)
+			return
+		}
+
+		select {
+		case p.queueFor(msg) <- msg:
+			p.updateQueueLength()
+		case <-p.stopped:
+			p.release(msg)
+			p.spill.release(msg.name)
+			return
+		}
+	}
+}
+
+// Start starts the workers, which process messages until the pool is stopped.
+func (p *WorkerPool) Start(process func(msg jetstream.Msg)) {
+	if p.spill != nil {
+		p.wg.Add(1)
+		go p.drain()
+	}
+
+	if p.limiter != nil {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.limiter.run(p.stopped)
+		}()
+	}
+
+	p.process = process
+	for i := 0; i < p.workers; i++ {
+		p.startWorker(fmt.Sprintf("%d", i), p.queues[i%len(p.queues)], nil)
+	}
+}
+
+func (p *WorkerPool) startWorker(worker string, queue <-chan jetstream.Msg, done <-chan struct{}) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		for p.work(worker, queue, done) {
+		}
+	}()
+}
+
+// Burst starts additional workers, e.g. to drain a backlog, until the returned function is
+// called. The additional workers finish the messages they are processing before they stop. A
+// limiter is extended by the additional workers while the burst lasts. A sharded pool does not
+// burst, since the workers of a shard would no longer process its messages in order.
+func (p *WorkerPool) Burst(workers int) (stop func()) {
+	if p.sharded || workers < 1 || p.process == nil {
+		return func() {}
+	}
+
+	retract := func() {}
+	if p.limiter != nil {
+		retract = p.limiter.extend(workers)
+	}
+
+	done := make(chan struct{})
+	for i := 0; i < workers; i++ {
+		p.startWorker(fmt.Sprintf("burst-%d", i), p.queues[0], done)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			retract()
+		})
+	}
+}
+
+// work processes the next message from the queue once the worker is within the limit. The limit
+// is acquired after taking the message, so that idle workers of other shards do not hold it. A
+// message taken when the limiter has been stopped is negatively acknowledged to be redelivered. It
+// returns false when the pool has been stopped or done is closed.
+func (p *WorkerPool) work(worker string, queue <-chan jetstream.Msg, done <-chan struct{}) bool {
+	select {
+	case msg := <-queue:
+		p.updateQueueLength()
+		if p.limiter != nil {
+			if !p.limiter.acquire() {
+				if err := msg.Nak(); err != nil {
+					p.logger.Error("Failed to nak webhook message", "error", err.Error())
+				}
+				p.release(msg)
+				return false
+			}
+			defer p.limiter.release()
+		}
+		if p.budget != nil {
+			taken := msg
+			msg = &budgetedMsg{Msg: taken, release: func() { p.release(taken) }}
+		}
+		start := time.Now()
+		metrics.WorkersBusy.Inc()
+		p.process(msg)
+		metrics.WorkersBusy.Dec()
+		metrics.WorkerMessages.WithLabelValues(worker).Inc()
+		metrics.WorkerBusySeconds.WithLabelValues(worker).Add(time.Since(start).Seconds())
+		return true
+	case <-p.stopped:
+		return false
+	case <-done:
+		return false
+	}
+}
+
+// Stop stops the workers and waits for the messages being processed to finish.
+func (p *WorkerPool) Stop() {
+	p.stopOnce.Do(func() {
+		close(p.stopped)
+		if p.limiter != nil {
+			p.limiter.stop()
+		}
+		if p.budget != nil {
+			p.budget.stop()
+		}
+	})
+	p.wg.Wait()
+}
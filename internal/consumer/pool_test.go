@@ -0,0 +1,137 @@
+package consumer
+
+import (
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+)
+
+type testMsg struct {
+	jetstream.Msg
+}
+
+func (testMsg) Nak() error { return nil }
+
+func TestWorkerPool(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tc := range []struct {
+		title              string
+		workers            int
+		maxAckPending      int
+		expectedWorkers    int
+		expectedConcurrent int32
+	}{
+		{title: "processes messages concurrently", workers: 4, expectedWorkers: 4, expectedConcurrent: 4},
+		{title: "processes messages sequentially with one worker", workers: 1, expectedWorkers: 1, expectedConcurrent: 1},
+		{title: "limits workers to max ack pending", workers: 8, maxAckPending: 2, expectedWorkers: 2, expectedConcurrent: 2},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			pool := NewWorkerPool(logger, tc.workers, tc.maxAckPending)
+			assert.Equal(t, tc.expectedWorkers, pool.Workers())
+
+			var current, maxConcurrent, processed atomic.Int32
+			pool.Start(func(msg jetstream.Msg) {
+				n := current.Add(1)
+				for {
+					m := maxConcurrent.Load()
+					if n <= m || maxConcurrent.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+				current.Add(-1)
+				processed.Add(1)
+			})
+
+			var wg sync.WaitGroup
+			for i := 0; i < 16; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					pool.Handle(testMsg{})
+				}()
+			}
+			wg.Wait()
+			pool.Stop()
+
+			assert.Equal(t, int32(16), processed.Load())
+			assert.Equal(t, tc.expectedConcurrent, maxConcurrent.Load())
+		})
+	}
+}
+
+func TestWorkerPoolHandleAfterStop(t *testing.T) {
+
+	pool := NewWorkerPool(slog.New(slog.NewTextHandler(io.Discard, nil)), 1, 0)
+	pool.Start(func(msg jetstream.Msg) { t.Error("message should not be processed after stop") })
+	pool.Stop()
+
+	handled := make(chan struct{})
+	go func() {
+		pool.Handle(testMsg{})
+		close(handled)
+	}()
+
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		t.Fatal("handle should not block after stop")
+	}
+}
+
+type shardMsg struct {
+	jetstream.Msg
+	repository string
+	seq        int
+}
+
+func (m shardMsg) Subject() string { return "webhooks.gitea.push" }
+
+func (m shardMsg) Headers() nats.Header {
+	return nats.Header{adapter.RepositoryHeader: []string{m.repository}}
+}
+
+func TestWorkerPoolShardedPreservesOrderPerRepository(t *testing.T) {
+
+	pool := NewWorkerPool(slog.New(slog.NewTextHandler(io.Discard, nil)), 4, 0)
+	pool.SetSharded(true)
+
+	var mu sync.Mutex
+	processed := map[string][]int{}
+	pool.Start(func(msg jetstream.Msg) {
+		m := msg.(shardMsg)
+		time.Sleep(time.Millisecond)
+		mu.Lock()
+		processed[m.repository] = append(processed[m.repository], m.seq)
+		mu.Unlock()
+	})
+
+	repositories := []string{"platform/api", "platform/web", "tools/cli", "tools/ci", "docs/site"}
+	for seq := 0; seq < 10; seq++ {
+		for _, repository := range repositories {
+			pool.Handle(shardMsg{repository: repository, seq: seq})
+		}
+	}
+	pool.Stop()
+
+	for _, repository := range repositories {
+		assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, processed[repository], repository)
+	}
+}
+
+func TestShardKey(t *testing.T) {
+
+	assert.Equal(t, "platform/api", ShardKey(shardMsg{repository: "platform/api"}))
+	assert.Equal(t, "webhooks.gitea.push", ShardKey(shardMsg{}), "falls back to the subject without repository")
+}
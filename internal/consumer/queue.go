@@ -0,0 +1,52 @@
+package consumer
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// Policies for messages consumed while the queue in front of the workers is full.
+const (
+	// OverflowBlock blocks the consumer until there is room in the queue.
+	OverflowBlock = "block"
+	// OverflowSpill writes the message to disk and acknowledges it once it is synced. Spilled
+	// messages are queued again when there is room, also after a restart.
+	OverflowSpill = "spill"
+	// OverflowNak negatively acknowledges the message so that it is redelivered after a delay.
+	OverflowNak = "nak"
+)
+
+// QueueConfig configures the queue of consumed messages waiting for a worker. Messages wait in
+// the queue without being acknowledged, so it should hold fewer messages than are consumed
+// within the ack wait of the consumer. The spill directory must be an absolute path, so that
+// spilled messages are not lost by starting the adapter from another working directory.
+type QueueConfig struct {
+	Size       int           `envconfig:"SIZE" default:"100"`
+	Overflow   string        `envconfig:"OVERFLOW" default:"block"`
+	NakDelay   time.Duration `envconfig:"NAK_DELAY" default:"5s"`
+	SpillDir   string        `envconfig:"SPILL_DIR"`
+	SpillLimit int           `envconfig:"SPILL_LIMIT" default:"10000"`
+}
+
+// Validate checks the overflow policy and its options.
+func (c QueueConfig) Validate() error {
+	if c.Size < 0 {
+		return fmt.Errorf("queue size must not be negative: %d", c.Size)
+	}
+
+	switch c.Overflow {
+	case "", OverflowBlock, OverflowNak:
+		return nil
+	case OverflowSpill:
+		if c.SpillDir == "" {
+			return fmt.Errorf("overflow policy %s requires a spill directory", c.Overflow)
+		}
+		if !filepath.IsAbs(c.SpillDir) {
+			return fmt.Errorf("spill directory must be an absolute path: %s", c.SpillDir)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown queue overflow policy: %s", c.Overflow)
+	}
+}
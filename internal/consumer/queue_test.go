@@ -0,0 +1,195 @@
+package consumer
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type overflowMsg struct {
+	jetstream.Msg
+	subject string
+	data    []byte
+	acked   bool
+	naked   time.Duration
+}
+
+func (m *overflowMsg) Subject() string      { return m.subject }
+func (m *overflowMsg) Data() []byte         { return m.data }
+func (m *overflowMsg) Headers() nats.Header { return nats.Header{"X-Gitea-Event": []string{"push"}} }
+func (m *overflowMsg) Ack() error           { m.acked = true; return nil }
+
+func (m *overflowMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{Sequence: jetstream.SequencePair{Stream: 1}}, nil
+}
+
+func (m *overflowMsg) NakWithDelay(delay time.Duration) error {
+	m.naked = delay
+	return nil
+}
+
+func TestQueueConfigValidate(t *testing.T) {
+
+	for _, tc := range []struct {
+		title       string
+		config      QueueConfig
+		expectedErr string
+	}{
+		{title: "block", config: QueueConfig{Size: 10, Overflow: OverflowBlock}},
+		{title: "nak", config: QueueConfig{Size: 10, Overflow: OverflowNak}},
+		{title: "spill", config: QueueConfig{Size: 10, Overflow: OverflowSpill, SpillDir: "/var/lib/cdevents-adapter/spill"}},
+		{title: "spill to relative directory", config: QueueConfig{Overflow: OverflowSpill, SpillDir: "spill"}, expectedErr: "spill directory must be an absolute path: spill"},
+		{title: "spill without directory", config: QueueConfig{Overflow: OverflowSpill}, expectedErr: "overflow policy spill requires a spill directory"},
+		{title: "negative size", config: QueueConfig{Size: -1}, expectedErr: "queue size must not be negative: -1"},
+		{title: "unknown policy", config: QueueConfig{Overflow: "drop"}, expectedErr: "unknown queue overflow policy: drop"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWorkerPoolOverflowNak(t *testing.T) {
+
+	pool := NewWorkerPool(slog.New(slog.NewTextHandler(io.Discard, nil)), 1, 0)
+	require.NoError(t, pool.SetQueue(QueueConfig{Size: 1, Overflow: OverflowNak, NakDelay: time.Second}))
+
+	queued := &overflowMsg{}
+	overflowed := &overflowMsg{}
+
+	// Without started workers the first message fills the queue.
+	pool.Handle(queued)
+	pool.Handle(overflowed)
+
+	assert.Zero(t, queued.naked)
+	assert.Equal(t, time.Second, overflowed.naked)
+	assert.False(t, overflowed.acked)
+}
+
+func TestWorkerPoolOverflowSpill(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dir := t.TempDir()
+	config := QueueConfig{Size: 1, Overflow: OverflowSpill, SpillDir: dir, SpillLimit: 2, NakDelay: time.Second}
+
+	pool := NewWorkerPool(logger, 1, 0)
+	require.NoError(t, pool.SetQueue(config))
+
+	msgs := []*overflowMsg{
+		{subject: "webhooks.gitea.0", data: []byte("0")},
+		{subject: "webhooks.gitea.1", data: []byte("1")},
+		{subject: "webhooks.gitea.2", data: []byte("2")},
+		{subject: "webhooks.gitea.3", data: []byte("3")},
+	}
+	for _, msg := range msgs {
+		pool.Handle(msg)
+	}
+
+	assert.False(t, msgs[0].acked, "queued message is acked by its worker")
+	assert.True(t, msgs[1].acked, "spilled message is acked when written to disk")
+	assert.True(t, msgs[2].acked, "spilled message is acked when written to disk")
+	assert.Equal(t, time.Second, msgs[3].naked, "message is naked when the spill is full")
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+
+	// The spilled messages are recovered by a new pool, e.g. after a restart.
+	pool = NewWorkerPool(logger, 1, 0)
+	require.NoError(t, pool.SetQueue(config))
+
+	processed := make(chan jetstream.Msg, 2)
+	pool.Start(func(msg jetstream.Msg) {
+		processed <- msg
+		assert.NoError(t, msg.Ack())
+	})
+
+	var subjects []string
+	for i := 0; i < 2; i++ {
+		select {
+		case msg := <-processed:
+			subjects = append(subjects, msg.Subject())
+			assert.Equal(t, "push", msg.Headers().Get("X-Gitea-Event"))
+			metadata, err := msg.Metadata()
+			require.NoError(t, err)
+			assert.Equal(t, uint64(1), metadata.Sequence.Stream)
+		case <-time.After(5 * time.Second):
+			t.Fatal("spilled message was not processed")
+		}
+	}
+	pool.Stop()
+
+	assert.Equal(t, []string{"webhooks.gitea.1", "webhooks.gitea.2"}, subjects)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "acked spilled messages are removed from disk")
+}
+
+func TestWorkerPoolMaxInflightBytes(t *testing.T) {
+
+	pool := NewWorkerPool(slog.New(slog.NewTextHandler(io.Discard, nil)), 1, 0)
+	require.NoError(t, pool.SetQueue(QueueConfig{Size: 10, Overflow: OverflowNak, NakDelay: time.Second}))
+	pool.SetMaxInflightBytes(10)
+
+	large := &overflowMsg{data: []byte("0123456789abcdef")}
+	small := &overflowMsg{data: []byte("0123")}
+
+	// A payload larger than the budget is admitted when nothing else is held.
+	pool.Handle(large)
+	assert.Zero(t, large.naked)
+
+	pool.Handle(small)
+	assert.Equal(t, time.Second, small.naked, "message should overflow while the budget is used up")
+
+	processed := make(chan jetstream.Msg, 2)
+	pool.Start(func(msg jetstream.Msg) { processed <- msg })
+	msg := <-processed
+
+	// The budget is held until the message is acknowledged, which may be after the worker is done
+	// with it when its event is published asynchronously.
+	time.Sleep(10 * time.Millisecond)
+	assert.False(t, pool.budget.tryAcquire(0), "budget should be held until the message is acknowledged")
+
+	require.NoError(t, msg.Ack())
+	assert.True(t, large.acked)
+	assert.True(t, pool.budget.tryAcquire(0), "budget should be released once the message is acknowledged")
+
+	retried := &overflowMsg{data: []byte("0123")}
+	pool.Handle(retried)
+	require.NoError(t, (<-processed).Ack())
+	pool.Stop()
+
+	assert.Zero(t, retried.naked, "message should be admitted once the budget is released")
+}
+
+func TestByteBudget(t *testing.T) {
+
+	budget := newByteBudget(10)
+	require.True(t, budget.acquire(6))
+	assert.False(t, budget.tryAcquire(6))
+	assert.True(t, budget.tryAcquire(4))
+
+	acquired := make(chan bool)
+	go func() { acquired <- budget.acquire(5) }()
+
+	budget.release(6)
+	assert.True(t, <-acquired, "waiting acquire should succeed once bytes are released")
+
+	go func() { acquired <- budget.acquire(5) }()
+	budget.stop()
+	assert.False(t, <-acquired, "waiting acquire should fail once stopped")
+}
@@ -0,0 +1,31 @@
+package consumer
+
+import (
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// ShardKey returns the key that decides the worker of a message in a sharded pool: the repository
+// the webhook was sent for, or the subject of the message if it has none.
+func ShardKey(msg jetstream.Msg) string {
+	if repository := msg.Headers().Get(adapter.RepositoryHeader); repository != "" {
+		return repository
+	}
+	return msg.Subject()
+}
+
+// shard hashes a key with FNV-1a onto one of n shards.
+func shard(key string, n int) int {
+	const (
+		offset = 2166136261
+		prime  = 16777619
+	)
+
+	hash := uint32(offset)
+	for i := 0; i < len(key); i++ {
+		hash ^= uint32(key[i])
+		hash *= prime
+	}
+	return int(hash % uint32(n))
+}
@@ -0,0 +1,246 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/metrics"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// spill stores messages that did not fit in the queue on disk, one file per message named so
+// that the files sort in the order the messages were spilled. A file is removed once its message
+// has been acknowledged.
+type spill struct {
+	dir     string
+	limit   int
+	mu      sync.Mutex
+	waiting []string
+	count   int
+	seq     atomic.Uint64
+	notify  chan struct{}
+}
+
+type spilledRecord struct {
+	Subject  string                 `json:"subject"`
+	Headers  nats.Header            `json:"headers,omitempty"`
+	Data     []byte                 `json:"data"`
+	Metadata *jetstream.MsgMetadata `json:"metadata,omitempty"`
+}
+
+// openSpill opens the spill directory, creating it if needed, and picks up the messages spilled
+// before a restart.
+func openSpill(dir string, limit int) (*spill, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create spill directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spill directory: %w", err)
+	}
+
+	s := &spill{dir: dir, limit: limit, notify: make(chan struct{}, 1)}
+	for _, entry := range entries {
+		switch name := entry.Name(); {
+		case strings.HasPrefix(name, "."):
+			// Left over from a write that did not complete.
+			os.Remove(filepath.Join(dir, name))
+		case strings.HasSuffix(name, ".json"):
+			s.waiting = append(s.waiting, name)
+		}
+	}
+	sort.Strings(s.waiting)
+	s.count = len(s.waiting)
+	metrics.SpilledMessages.Set(float64(s.count))
+
+	if s.count > 0 {
+		s.signal()
+	}
+
+	return s, nil
+}
+
+func (s *spill) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.count
+}
+
+// store writes a message to disk and syncs it together with the directory, so that the message
+// can be acknowledged. It reports false if the spill already holds the maximum number of
+// messages. The lock is held while writing, so that concurrent stores cannot exceed the limit.
+func (s *spill) store(msg jetstream.Msg) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.limit > 0 && s.count >= s.limit {
+		return false, nil
+	}
+
+	record := spilledRecord{Subject: msg.Subject(), Headers: msg.Headers(), Data: msg.Data()}
+	if metadata, err := msg.Metadata(); err == nil {
+		record.Metadata = metadata
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return false, err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".spill-*")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return false, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return false, err
+	}
+	if err := tmp.Close(); err != nil {
+		return false, err
+	}
+
+	name := fmt.Sprintf("%020d-%010d.json", time.Now().UnixNano(), s.seq.Add(1))
+	if err := os.Rename(tmp.Name(), filepath.Join(s.dir, name)); err != nil {
+		return false, err
+	}
+	if err := syncDir(s.dir); err != nil {
+		os.Remove(filepath.Join(s.dir, name))
+		return false, err
+	}
+
+	s.waiting = append(s.waiting, name)
+	s.count++
+	metrics.SpilledMessages.Set(float64(s.count))
+
+	s.signal()
+
+	return true, nil
+}
+
+// syncDir syncs a directory, so that the files renamed into it survive a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+// next returns the oldest spilled message that is not already queued. It reports false if there
+// is none. A message that cannot be read back is removed and returned as an error.
+func (s *spill) next() (*spilledMsg, bool, error) {
+	s.mu.Lock()
+	if len(s.waiting) == 0 {
+		s.mu.Unlock()
+		return nil, false, nil
+	}
+	name := s.waiting[0]
+	s.waiting = s.waiting[1:]
+	s.mu.Unlock()
+
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		s.remove(name)
+		return nil, false, fmt.Errorf("failed to read spilled message %s: %w", name, err)
+	}
+
+	var record spilledRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		s.remove(name)
+		return nil, false, fmt.Errorf("failed to decode spilled message %s: %w", name, err)
+	}
+
+	return &spilledMsg{spill: s, name: name, record: record}, true, nil
+}
+
+// remove deletes a spilled message that has been processed.
+func (s *spill) remove(name string) error {
+	err := os.Remove(filepath.Join(s.dir, name))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	s.mu.Lock()
+	s.count--
+	metrics.SpilledMessages.Set(float64(s.count))
+	s.mu.Unlock()
+
+	return nil
+}
+
+// release puts a queued message back first in line, e.g. when it was not processed.
+func (s *spill) release(name string) {
+	s.mu.Lock()
+	s.waiting = append([]string{name}, s.waiting...)
+	s.mu.Unlock()
+
+	s.signal()
+}
+
+func (s *spill) signal() {
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// spilledMsg is a message read back from the spill. Acknowledging it removes it from disk and a
+// negative acknowledgement puts it back to be queued again.
+type spilledMsg struct {
+	spill  *spill
+	name   string
+	record spilledRecord
+}
+
+func (m *spilledMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	if m.record.Metadata == nil {
+		return nil, fmt.Errorf("spilled message %s has no metadata", m.name)
+	}
+	return m.record.Metadata, nil
+}
+
+func (m *spilledMsg) Data() []byte         { return m.record.Data }
+func (m *spilledMsg) Headers() nats.Header { return m.record.Headers }
+func (m *spilledMsg) Subject() string      { return m.record.Subject }
+func (m *spilledMsg) Reply() string        { return "" }
+func (m *spilledMsg) Ack() error           { return m.spill.remove(m.name) }
+func (m *spilledMsg) InProgress() error    { return nil }
+func (m *spilledMsg) Term() error          { return m.spill.remove(m.name) }
+
+func (m *spilledMsg) DoubleAck(ctx context.Context) error {
+	return m.spill.remove(m.name)
+}
+
+func (m *spilledMsg) Nak() error {
+	m.spill.release(m.name)
+	return nil
+}
+
+func (m *spilledMsg) NakWithDelay(delay time.Duration) error {
+	time.AfterFunc(delay, func() { m.spill.release(m.name) })
+	return nil
+}
+
+func (m *spilledMsg) TermWithReason(reason string) error {
+	return m.spill.remove(m.name)
+}
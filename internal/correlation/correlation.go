@@ -0,0 +1,47 @@
+package correlation
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// Header is the NATS message header carrying the correlation id of a webhook delivery.
+const Header = "Correlation-Id"
+
+// RequestHeaders are checked, in order, for a correlation id supplied by the caller.
+var RequestHeaders = []string{"X-Correlation-Id", "X-Request-Id"}
+
+type contextKey struct{}
+
+// FromRequest returns the correlation id supplied with an HTTP request or generates a new one.
+func FromRequest(r *http.Request) string {
+	for _, header := range RequestHeaders {
+		if id := r.Header.Get(header); id != "" {
+			return id
+		}
+	}
+	return uuid.NewString()
+}
+
+func WithID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Logger returns a logger that adds the correlation id in ctx, if any, to every log line.
+func Logger(ctx context.Context, logger *slog.Logger) *slog.Logger {
+	if id := FromContext(ctx); id != "" {
+		return logger.With("correlation_id", id)
+	}
+	return logger
+}
@@ -0,0 +1,63 @@
+package correlation
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromRequest(t *testing.T) {
+	for _, tc := range []struct {
+		title      string
+		headers    map[string]string
+		expectedID string
+	}{
+		{
+			title:      "uses correlation id header",
+			headers:    map[string]string{"X-Correlation-Id": "abc", "X-Request-Id": "def"},
+			expectedID: "abc",
+		},
+		{
+			title:      "uses request id header",
+			headers:    map[string]string{"X-Request-Id": "def"},
+			expectedID: "def",
+		},
+		{
+			title: "generates id without headers",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+
+			id := FromRequest(req)
+
+			if tc.expectedID != "" {
+				assert.Equal(t, tc.expectedID, id)
+			} else {
+				_, err := uuid.Parse(id)
+				require.NoError(t, err, "generated id should be a uuid")
+			}
+		})
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	Logger(context.Background(), logger).Info("without id")
+	assert.NotContains(t, buf.String(), "correlation_id")
+
+	Logger(WithID(context.Background(), "abc"), logger).Info("with id")
+	assert.Contains(t, buf.String(), "correlation_id=abc")
+}
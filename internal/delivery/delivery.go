@@ -0,0 +1,22 @@
+// Package delivery identifies the event of a webhook message that is being published, so that a
+// publisher can recognise the same event when the message is redelivered after a failed publish,
+// even though the event is translated again and gets a new id.
+package delivery
+
+import "context"
+
+type contextKey struct{}
+
+// WithKey returns a context carrying the key of the event that is published with it. The key is
+// the same for every delivery of the webhook message.
+func WithKey(ctx context.Context, key string) context.Context {
+	if key == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+func FromContext(ctx context.Context) string {
+	key, _ := ctx.Value(contextKey{}).(string)
+	return key
+}
@@ -0,0 +1,71 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+)
+
+// Expression is a boolean CEL expression evaluated against a JSON document. The top level
+// fields of the document are variables in the expression, e.g. `repository.full_name` for a
+// webhook payload, and the whole document is available as `doc`.
+type Expression struct {
+	source  string
+	program cel.Program
+}
+
+// Compile parses a CEL expression. Variables are resolved when the expression is evaluated, and
+// an expression that refers to fields missing from a document does not match it.
+func Compile(source string) (*Expression, error) {
+	env, err := cel.NewEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	ast, issues := env.Parse(source)
+	if issues != nil && issues.Err() != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", source, issues.Err())
+	}
+
+	program, err := env.Program(ast)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression %q: %w", source, err)
+	}
+
+	return &Expression{source: source, program: program}, nil
+}
+
+// Match evaluates the expression against a document.
+func (e *Expression) Match(document map[string]interface{}) (bool, error) {
+	vars := make(map[string]interface{}, len(document)+1)
+	for k, v := range document {
+		vars[k] = v
+	}
+	vars["doc"] = document
+
+	out, _, err := e.program.Eval(vars)
+	if err != nil && missingField(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate expression %q: %w", e.source, err)
+	}
+
+	match, ok := out.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expression %q evaluated to %s, not bool", e.source, out.Type().TypeName())
+	}
+
+	return match, nil
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// missingField reports whether an evaluation failed because the document lacks a field or key
+// the expression refers to. CEL does not export these errors, so they are told by their message.
+func missingField(err error) bool {
+	return strings.HasPrefix(err.Error(), "no such key:") || strings.HasPrefix(err.Error(), "no such attribute")
+}
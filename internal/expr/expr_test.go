@@ -0,0 +1,77 @@
+package expr
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpression(t *testing.T) {
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{"ref": "refs/heads/main", "repository": {"full_name": "platform/api", "private": true}}`), &payload))
+
+	for _, tc := range []struct {
+		title         string
+		expression    string
+		expectedMatch bool
+		expectedError string
+	}{
+		{
+			title:         "matches field",
+			expression:    `repository.full_name.startsWith("platform/")`,
+			expectedMatch: true,
+		},
+		{
+			title:         "does not match field",
+			expression:    `repository.full_name.startsWith("team/") && ref == "refs/heads/main"`,
+			expectedMatch: false,
+		},
+		{
+			title:         "matches whole document",
+			expression:    `has(doc.repository) && !has(doc.pull_request) && doc.repository.private`,
+			expectedMatch: true,
+		},
+		{
+			title:         "does not match missing field",
+			expression:    `pull_request.merged`,
+			expectedMatch: false,
+		},
+		{
+			title:         "does not match missing key",
+			expression:    `repository.owner.login == "platform"`,
+			expectedMatch: false,
+		},
+		{
+			title:         "error on invalid operation",
+			expression:    `repository.full_name + 1 == "platform/api"`,
+			expectedError: "failed to evaluate expression",
+		},
+		{
+			title:         "error when not bool",
+			expression:    `repository.full_name`,
+			expectedError: "evaluated to string, not bool",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			expression, err := Compile(tc.expression)
+			require.NoError(t, err)
+
+			match, err := expression.Match(payload)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedMatch, match)
+		})
+	}
+}
+
+func TestCompileError(t *testing.T) {
+
+	_, err := Compile(`repository.full_name.startsWith(`)
+	assert.ErrorContains(t, err, "invalid expression")
+}
@@ -0,0 +1,286 @@
+// Package generate publishes synthetic Gitea webhook payloads directly on the webhook subjects,
+// bypassing the webhook endpoint, to load the translation and publish stages of the adapters in
+// isolation. The report is formatted like the publisher statistics of nats bench.
+package generate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type Config struct {
+	// WebhookSubjectBase is the subject base the payloads are published under.
+	WebhookSubjectBase string
+	// Msgs is the total number of payloads to publish.
+	Msgs int
+	// Publishers is the number of concurrent publishers the payloads are split between.
+	Publishers int
+	// Rate limits the total number of payloads published per second, if set.
+	Rate float64
+	// Events are the Gitea events to publish payloads for, in turn.
+	Events []string
+	// Repositories is the number of distinct repositories the payloads are spread over.
+	Repositories int
+	// Async publishes without waiting for each publish ack.
+	Async bool
+}
+
+func (c Config) Validate() error {
+	if c.Msgs <= 0 {
+		return errors.New("number of messages must be positive")
+	}
+	if c.Publishers <= 0 {
+		return errors.New("number of publishers must be positive")
+	}
+	if c.Rate < 0 {
+		return errors.New("rate must not be negative")
+	}
+	if c.Repositories <= 0 {
+		return errors.New("number of repositories must be positive")
+	}
+	if len(c.Events) == 0 {
+		return errors.New("no events to generate")
+	}
+	for _, event := range c.Events {
+		if _, err := payload(event, payloadParams{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type Publisher interface {
+	PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+	PublishMsgAsync(msg *nats.Msg, opts ...jetstream.PublishOpt) (jetstream.PubAckFuture, error)
+	PublishAsyncComplete() <-chan struct{}
+}
+
+// PublisherStats are the statistics of a single publisher.
+type PublisherStats struct {
+	Msgs       int     `json:"msgs"`
+	Failed     int     `json:"failed"`
+	Bytes      int64   `json:"bytes"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+func (s PublisherStats) rate() float64 {
+	if s.DurationMs <= 0 {
+		return 0
+	}
+	return float64(s.Msgs) / (s.DurationMs / 1000)
+}
+
+func (s PublisherStats) throughput() float64 {
+	if s.DurationMs <= 0 {
+		return 0
+	}
+	return float64(s.Bytes) / (s.DurationMs / 1000)
+}
+
+type Report struct {
+	Msgs          int              `json:"msgs"`
+	Failed        int              `json:"failed"`
+	Bytes         int64            `json:"bytes"`
+	DurationMs    float64          `json:"duration_ms"`
+	MsgsPerSecond float64          `json:"msgs_per_second"`
+	Publishers    []PublisherStats `json:"publishers"`
+}
+
+// String formats the report like the publisher statistics of nats bench.
+func (r Report) String() string {
+	var b strings.Builder
+	total := PublisherStats{Msgs: r.Msgs, Bytes: r.Bytes, DurationMs: r.DurationMs}
+	fmt.Fprintf(&b, "Pub stats: %s msgs/sec ~ %s/sec\n", commaFormat(int64(total.rate())), humanBytes(total.throughput()))
+	if len(r.Publishers) > 1 {
+		rates := make([]float64, len(r.Publishers))
+		for i, s := range r.Publishers {
+			rates[i] = s.rate()
+			fmt.Fprintf(&b, " [%d] %s msgs/sec ~ %s/sec (%d msgs)\n", i+1, commaFormat(int64(rates[i])), humanBytes(s.throughput()), s.Msgs)
+		}
+		min, avg, max, stddev := statistics(rates)
+		fmt.Fprintf(&b, " min %s | avg %s | max %s | stddev %s msgs\n", commaFormat(int64(min)), commaFormat(int64(avg)), commaFormat(int64(max)), commaFormat(int64(stddev)))
+	}
+	if r.Failed > 0 {
+		fmt.Fprintf(&b, " failed %d msgs\n", r.Failed)
+	}
+	return b.String()
+}
+
+// Run publishes the payloads and returns the statistics of the run. Failed publishes are
+// counted but do not stop the run; an error is only returned when the context is done.
+func Run(ctx context.Context, publisher Publisher, config Config) (Report, error) {
+	var report Report
+
+	if err := config.Validate(); err != nil {
+		return report, err
+	}
+
+	// Every publisher paces its own share of the rate.
+	var interval time.Duration
+	if config.Rate > 0 {
+		interval = time.Duration(float64(time.Second) * float64(config.Publishers) / config.Rate)
+	}
+
+	// A commit sha per repository is shared between the publishers, so that there is a
+	// realistic mix of new and known commits.
+	commits := make([]string, config.Repositories)
+	for i := range commits {
+		commits[i] = randomSHA()
+	}
+
+	stats := make([]PublisherStats, config.Publishers)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for p := 0; p < config.Publishers; p++ {
+		msgs := config.Msgs / config.Publishers
+		if p < config.Msgs%config.Publishers {
+			msgs++
+		}
+		wg.Add(1)
+		go func(p, msgs int) {
+			defer wg.Done()
+			stats[p] = publish(ctx, publisher, config, commits, p, msgs, interval)
+		}(p, msgs)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	report.Publishers = stats
+	for _, s := range stats {
+		report.Msgs += s.Msgs
+		report.Failed += s.Failed
+		report.Bytes += s.Bytes
+	}
+	report.DurationMs = float64(elapsed) / float64(time.Millisecond)
+	if elapsed > 0 {
+		report.MsgsPerSecond = float64(report.Msgs) / elapsed.Seconds()
+	}
+
+	return report, ctx.Err()
+}
+
+// publish publishes msgs payloads, interleaved with the payloads of the other publishers, and
+// returns the statistics of the successful publishes.
+func publish(ctx context.Context, publisher Publisher, config Config, commits []string, p, msgs int, interval time.Duration) PublisherStats {
+	var stats PublisherStats
+	var futures []jetstream.PubAckFuture
+	var sizes []int
+
+	start := time.Now()
+	for i := 0; i < msgs && ctx.Err() == nil; i++ {
+		if interval > 0 {
+			if wait := time.Until(start.Add(time.Duration(i) * interval)); wait > 0 {
+				select {
+				case <-ctx.Done():
+					continue
+				case <-time.After(wait):
+				}
+			}
+		}
+
+		n := i*config.Publishers + p
+		repo := n % config.Repositories
+		event := config.Events[n%len(config.Events)]
+		params := payloadParams{
+			n:      n,
+			owner:  "generate",
+			name:   fmt.Sprintf("project%d", repo+1),
+			before: commits[repo],
+			commit: randomSHA(),
+		}
+		data, _ := payload(event, params)
+
+		msg := nats.NewMsg(fmt.Sprintf("%s.gitea.%s", config.WebhookSubjectBase, event))
+		msg.Data = data
+		msg.Header.Set(adapter.DeliveryIDHeader, fmt.Sprintf("generate-%d-%s", n, params.commit[:8]))
+		msg.Header.Set(adapter.RepositoryHeader, params.owner+"/"+params.name)
+
+		if config.Async {
+			future, err := publisher.PublishMsgAsync(msg)
+			if err != nil {
+				stats.Failed++
+				continue
+			}
+			futures = append(futures, future)
+			sizes = append(sizes, len(data))
+			continue
+		}
+
+		if _, err := publisher.PublishMsg(ctx, msg); err != nil {
+			stats.Failed++
+			continue
+		}
+		stats.Msgs++
+		stats.Bytes += int64(len(data))
+	}
+
+	if config.Async {
+		select {
+		case <-publisher.PublishAsyncComplete():
+		case <-ctx.Done():
+		}
+		for i, future := range futures {
+			select {
+			case <-future.Ok():
+				stats.Msgs++
+				stats.Bytes += int64(sizes[i])
+			default:
+				stats.Failed++
+			}
+		}
+	}
+
+	stats.DurationMs = float64(time.Since(start)) / float64(time.Millisecond)
+	return stats
+}
+
+func statistics(values []float64) (min, avg, max, stddev float64) {
+	min, max = math.MaxFloat64, 0
+	for _, v := range values {
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+		avg += v
+	}
+	avg /= float64(len(values))
+	for _, v := range values {
+		stddev += (v - avg) * (v - avg)
+	}
+	stddev = math.Sqrt(stddev / float64(len(values)))
+	return min, avg, max, stddev
+}
+
+// commaFormat formats a number with thousands separators like nats bench.
+func commaFormat(n int64) string {
+	s := fmt.Sprintf("%d", n)
+	if n < 0 {
+		return "-" + commaFormat(-n)
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
+
+// humanBytes formats a number of bytes with a binary unit like nats bench.
+func humanBytes(bytes float64) string {
+	const base = 1024
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	if bytes < base {
+		return fmt.Sprintf("%.2f %s", bytes, units[0])
+	}
+	exp := int(math.Log(bytes) / math.Log(base))
+	if exp >= len(units) {
+		exp = len(units) - 1
+	}
+	return fmt.Sprintf("%.2f %s", bytes/math.Pow(base, float64(exp)), units[exp])
+}
@@ -0,0 +1,177 @@
+package generate
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestJetStream(t *testing.T) jetstream.JetStream {
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+	ns.Start()
+	t.Cleanup(ns.Shutdown)
+	require.True(t, ns.ReadyForConnections(10*time.Second))
+
+	nc, err := nats.Connect(ns.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+	return js
+}
+
+func TestConfigValidate(t *testing.T) {
+
+	valid := Config{Msgs: 10, Publishers: 2, Repositories: 1, Events: Events}
+
+	testCases := map[string]struct {
+		modify func(c *Config)
+		errMsg string
+	}{
+		"valid":           {modify: func(c *Config) {}},
+		"no messages":     {modify: func(c *Config) { c.Msgs = 0 }, errMsg: "number of messages must be positive"},
+		"no publishers":   {modify: func(c *Config) { c.Publishers = 0 }, errMsg: "number of publishers must be positive"},
+		"negative rate":   {modify: func(c *Config) { c.Rate = -1 }, errMsg: "rate must not be negative"},
+		"no repositories": {modify: func(c *Config) { c.Repositories = 0 }, errMsg: "number of repositories must be positive"},
+		"no events":       {modify: func(c *Config) { c.Events = nil }, errMsg: "no events to generate"},
+		"unknown event":   {modify: func(c *Config) { c.Events = []string{"issues"} }, errMsg: "no payload for Gitea event: issues"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			config := valid
+			tc.modify(&config)
+			err := config.Validate()
+			if tc.errMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.errMsg)
+			}
+		})
+	}
+}
+
+// TestPayloadsTranslate checks that the generated payloads are translated by the built-in
+// translators, so that the load reaches the publish stage.
+func TestPayloadsTranslate(t *testing.T) {
+
+	registry := translator.NewRegistry(translator.Builtin())
+
+	for n := 0; n < 2; n++ {
+		for _, event := range Events {
+			data, err := payload(event, payloadParams{n: n, owner: "generate", name: "project1", commit: randomSHA(), before: randomSHA()})
+			require.NoError(t, err)
+			require.True(t, json.Valid(data), "%s payload is not valid json", event)
+
+			eventTranslator, found := registry.Lookup("gitea." + event)
+			require.True(t, found)
+			_, err = eventTranslator.Translate(data)
+			assert.NoError(t, err, "failed to translate %s payload %d", event, n)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+
+	for _, async := range []bool{false, true} {
+		t.Run(map[bool]string{false: "sync", true: "async"}[async], func(t *testing.T) {
+			ctx := context.Background()
+			js := newTestJetStream(t)
+
+			stream, err := js.CreateStream(ctx, jetstream.StreamConfig{Name: "webhooks", Subjects: []string{"webhooks.>"}})
+			require.NoError(t, err)
+
+			report, err := Run(ctx, js, Config{
+				WebhookSubjectBase: "webhooks",
+				Msgs:               9,
+				Publishers:         2,
+				Events:             []string{"push", "pull_request"},
+				Repositories:       2,
+				Async:              async,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, 9, report.Msgs)
+			assert.Zero(t, report.Failed)
+			require.Len(t, report.Publishers, 2)
+			assert.Equal(t, 5, report.Publishers[0].Msgs)
+			assert.Equal(t, 4, report.Publishers[1].Msgs)
+			assert.Contains(t, report.String(), "Pub stats: ")
+
+			info, err := stream.Info(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(9), info.State.Msgs)
+
+			subjects := map[string]int{}
+			deliveries := map[string]bool{}
+			for seq := uint64(1); seq <= 9; seq++ {
+				msg, err := stream.GetMsg(ctx, seq)
+				require.NoError(t, err)
+				subjects[msg.Subject]++
+				deliveries[msg.Header.Get(adapter.DeliveryIDHeader)] = true
+				assert.Contains(t, []string{"generate/project1", "generate/project2"}, msg.Header.Get(adapter.RepositoryHeader))
+			}
+			assert.Equal(t, map[string]int{"webhooks.gitea.push": 5, "webhooks.gitea.pull_request": 4}, subjects)
+			assert.Len(t, deliveries, 9, "every message should have a distinct delivery id")
+		})
+	}
+}
+
+func TestRunRate(t *testing.T) {
+
+	ctx := context.Background()
+	js := newTestJetStream(t)
+
+	_, err := js.CreateStream(ctx, jetstream.StreamConfig{Name: "webhooks", Subjects: []string{"webhooks.>"}})
+	require.NoError(t, err)
+
+	report, err := Run(ctx, js, Config{
+		WebhookSubjectBase: "webhooks",
+		Msgs:               5,
+		Publishers:         1,
+		Rate:               50,
+		Events:             []string{"push"},
+		Repositories:       1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5, report.Msgs)
+	assert.GreaterOrEqual(t, report.DurationMs, float64(80), "5 messages at 50 msgs/s should take at least 80 ms")
+}
+
+func TestReportString(t *testing.T) {
+
+	report := Report{
+		Msgs:       3000,
+		Bytes:      3 * 1024 * 1024,
+		DurationMs: 1000,
+		Publishers: []PublisherStats{
+			{Msgs: 1000, Bytes: 1024 * 1024, DurationMs: 1000},
+			{Msgs: 2000, Bytes: 2 * 1024 * 1024, DurationMs: 1000},
+		},
+	}
+
+	expected := `Pub stats: 3,000 msgs/sec ~ 3.00 MB/sec
+ [1] 1,000 msgs/sec ~ 1.00 MB/sec (1000 msgs)
+ [2] 2,000 msgs/sec ~ 2.00 MB/sec (2000 msgs)
+ min 1,000 | avg 1,500 | max 2,000 | stddev 500 msgs
+`
+	assert.Equal(t, expected, report.String())
+}
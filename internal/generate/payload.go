@@ -0,0 +1,157 @@
+package generate
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// Events are the Gitea events payloads can be generated for.
+var Events = []string{"push", "create", "delete", "pull_request"}
+
+// payloadParams vary the generated payloads, so that every payload results in a distinct event.
+type payloadParams struct {
+	// n is the sequence number of the payload, used for branch names and pull request ids.
+	n      int
+	owner  string
+	name   string
+	commit string
+	before string
+}
+
+// pushPayloadTemplate is a Gitea push webhook with a single commit, sized like the webhooks
+// Gitea sends for a repository with a few settings.
+const pushPayloadTemplate = `{
+  "ref": "refs/heads/main",
+  "before": "%[4]s",
+  "after": "%[3]s",
+  "compare_url": "http://git.example.com/%[1]s/%[2]s/compare/%[4]s...%[3]s",
+  "commits": [
+    {
+      "id": "%[3]s",
+      "message": "Update README.md\n",
+      "url": "http://git.example.com/%[1]s/%[2]s/commit/%[3]s",
+      "author": {"name": "anders", "email": "gi@tea.com", "username": "anders"},
+      "committer": {"name": "anders", "email": "gi@tea.com", "username": "anders"},
+      "verification": null,
+      "timestamp": "2024-11-17T18:19:39Z",
+      "added": [],
+      "removed": [],
+      "modified": ["README.md"]
+    }
+  ],
+  "total_commits": 1,
+  "head_commit": {
+    "id": "%[3]s",
+    "message": "Update README.md\n",
+    "url": "http://git.example.com/%[1]s/%[2]s/commit/%[3]s",
+    "author": {"name": "anders", "email": "gi@tea.com", "username": "anders"},
+    "committer": {"name": "anders", "email": "gi@tea.com", "username": "anders"},
+    "verification": null,
+    "timestamp": "2024-11-17T18:19:39Z",
+    "added": [],
+    "removed": [],
+    "modified": ["README.md"]
+  },
+  "repository": {
+    "id": 1,
+    "owner": {"id": 1, "login": "%[1]s", "full_name": "", "email": "", "username": "%[1]s"},
+    "name": "%[2]s",
+    "full_name": "%[1]s/%[2]s",
+    "private": false,
+    "fork": false,
+    "html_url": "http://git.example.com/%[1]s/%[2]s",
+    "ssh_url": "git@git.example.com:%[1]s/%[2]s.git",
+    "clone_url": "http://git.example.com/%[1]s/%[2]s.git",
+    "default_branch": "main",
+    "created_at": "2024-11-17T18:10:02Z",
+    "updated_at": "2024-11-17T18:19:40Z"
+  },
+  "pusher": {"id": 1, "login": "anders", "full_name": "Anders", "email": "gi@tea.com", "username": "anders"},
+  "sender": {"id": 1, "login": "anders", "full_name": "Anders", "email": "gi@tea.com", "username": "anders"}
+}`
+
+// refPayloadTemplate is a Gitea create or delete webhook for a branch.
+const refPayloadTemplate = `{
+  "sha": "%[3]s",
+  "ref": "feature-%[4]d",
+  "ref_type": "branch",
+  "repository": {
+    "id": 1,
+    "owner": {"id": 1, "login": "%[1]s", "username": "%[1]s"},
+    "name": "%[2]s",
+    "full_name": "%[1]s/%[2]s",
+    "html_url": "http://git.example.com/%[1]s/%[2]s",
+    "url": "http://git.example.com/api/v1/repos/%[1]s/%[2]s",
+    "ssh_url": "git@git.example.com:%[1]s/%[2]s.git",
+    "default_branch": "main"
+  },
+  "sender": {"id": 1, "login": "anders", "full_name": "Anders", "email": "gi@tea.com", "username": "anders"}
+}`
+
+// pullRequestPayloadTemplate is a Gitea pull_request webhook for a pull request from a feature
+// branch to main.
+const pullRequestPayloadTemplate = `{
+  "action": "%[6]s",
+  "number": %[5]d,
+  "pull_request": {
+    "id": %[5]d,
+    "url": "http://git.example.com/%[1]s/%[2]s/pulls/%[5]d",
+    "number": %[5]d,
+    "user": {"id": 1, "login": "anders", "username": "anders"},
+    "title": "Fix something PR",
+    "body": "",
+    "state": "%[7]s",
+    "base": {
+      "label": "main",
+      "ref": "main",
+      "sha": "%[4]s"
+    },
+    "head": {
+      "label": "feature-%[5]d",
+      "ref": "feature-%[5]d",
+      "sha": "%[3]s"
+    },
+    "merge_base": "%[4]s",
+    "merged": %[8]t,
+    "due_date": null,
+    "created_at": "2024-11-17T18:21:54Z",
+    "updated_at": "2024-11-17T18:24:31Z",
+    "closed_at": null
+  },
+  "repository": {
+    "id": 1,
+    "owner": {"id": 1, "login": "%[1]s", "username": "%[1]s"},
+    "name": "%[2]s",
+    "full_name": "%[1]s/%[2]s",
+    "html_url": "http://git.example.com/%[1]s/%[2]s",
+    "ssh_url": "git@git.example.com:%[1]s/%[2]s.git",
+    "default_branch": "main"
+  },
+  "sender": {"id": 1, "login": "anders", "full_name": "Anders", "email": "gi@tea.com", "username": "anders"}
+}`
+
+// payload returns a Gitea webhook payload for the event. Every other pull request is opened and
+// the rest are merged.
+func payload(event string, p payloadParams) ([]byte, error) {
+	switch event {
+	case "push":
+		return []byte(fmt.Sprintf(pushPayloadTemplate, p.owner, p.name, p.commit, p.before)), nil
+	case "create", "delete":
+		return []byte(fmt.Sprintf(refPayloadTemplate, p.owner, p.name, p.commit, p.n)), nil
+	case "pull_request":
+		action, state, merged := "opened", "open", false
+		if p.n%2 == 1 {
+			action, state, merged = "closed", "closed", true
+		}
+		return []byte(fmt.Sprintf(pullRequestPayloadTemplate, p.owner, p.name, p.commit, p.before, p.n, action, state, merged)), nil
+	default:
+		return nil, fmt.Errorf("no payload for Gitea event: %s", event)
+	}
+}
+
+func randomSHA() string {
+	b := make([]byte, 20)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,38 @@
+// Package glob matches names such as branches, refs and repositories against the glob patterns
+// of the configuration.
+package glob
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Match reports whether the name matches the pattern, e.g. "release/*". Patterns are matched
+// with path.Match after trimming surrounding spaces, so that lists like "main, v*" read from the
+// environment work. Malformed patterns match nothing.
+func Match(pattern, name string) bool {
+	matched, _ := path.Match(strings.TrimSpace(pattern), name)
+	return matched
+}
+
+// MatchAny reports whether the name matches any of the patterns.
+func MatchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if Match(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns an error for the first malformed pattern, e.g. "release/[", so that it is
+// rejected with the configuration instead of silently matching nothing.
+func Validate(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(strings.TrimSpace(pattern), ""); err != nil {
+			return fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchAny(t *testing.T) {
+
+	for _, tc := range []struct {
+		title    string
+		patterns []string
+		name     string
+		expected bool
+	}{
+		{title: "matches exact name", patterns: []string{"main"}, name: "main", expected: true},
+		{title: "matches wildcard", patterns: []string{"main", "release/*"}, name: "release/1.0", expected: true},
+		{title: "wildcard does not cross slashes", patterns: []string{"platform/*"}, name: "platform/api/v2", expected: false},
+		{title: "trims spaces", patterns: []string{" v*"}, name: "v1.0.0", expected: true},
+		{title: "malformed pattern matches nothing", patterns: []string{"[main"}, name: "[main", expected: false},
+		{title: "no patterns match nothing", name: "main", expected: false},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			assert.Equal(t, tc.expected, MatchAny(tc.patterns, tc.name))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+
+	for _, tc := range []struct {
+		title    string
+		patterns []string
+		valid    bool
+	}{
+		{title: "valid patterns", patterns: []string{"main", "release/*", " v[0-9]*"}, valid: true},
+		{title: "no patterns", valid: true},
+		{title: "unclosed bracket", patterns: []string{"main", "refs/heads/release/["}, valid: false},
+		{title: "trailing escape", patterns: []string{`main\`}, valid: false},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			err := Validate(tc.patterns)
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
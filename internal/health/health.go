@@ -0,0 +1,155 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+)
+
+// Check reports the state of a dependency. Non-critical checks only degrade the overall status
+// and do not make the adapter unready.
+type Check struct {
+	Name     string
+	Critical bool
+	Check    func(ctx context.Context) error
+}
+
+type CheckResult struct {
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type lastError struct {
+	err string
+	at  time.Time
+}
+
+// Checker runs dependency checks and remembers the last error of every dependency, so that the
+// detail report also explains intermittent failures.
+type Checker struct {
+	checks  []Check
+	timeout time.Duration
+
+	mu         sync.Mutex
+	lastErrors map[string]lastError
+}
+
+func NewChecker(timeout time.Duration, checks ...Check) *Checker {
+	return &Checker{
+		checks:     checks,
+		timeout:    timeout,
+		lastErrors: make(map[string]lastError),
+	}
+}
+
+func (c *Checker) Run(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	results := make([]CheckResult, len(c.checks))
+
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(c.checks))}
+	for i, check := range c.checks {
+		report.Checks[check.Name] = results[i]
+		if results[i].Status == StatusOK {
+			continue
+		}
+		if check.Critical {
+			report.Status = StatusUnavailable
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+
+	return report
+}
+
+func (c *Checker) run(ctx context.Context, check Check) CheckResult {
+	err := check.Check(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := CheckResult{Status: StatusOK}
+	if err != nil {
+		result.Status = StatusUnavailable
+		if !check.Critical {
+			result.Status = StatusDegraded
+		}
+		result.Error = err.Error()
+		c.lastErrors[check.Name] = lastError{err: err.Error(), at: time.Now()}
+	}
+
+	if last, exists := c.lastErrors[check.Name]; exists {
+		result.LastError = last.err
+		result.LastErrorAt = &last.at
+	}
+
+	return result
+}
+
+// Handler serves the result of the checks. The response is an opaque OK, or 503 when a critical
+// check fails, unless detail is enabled, in which case a JSON report of every dependency is
+// returned. The probes are unauthenticated, so the report is never returned on request. When
+// live is set only the process itself is considered, so the status code is always 200.
+func (c *Checker) Handler(okBody string, detail bool, live bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !detail {
+			if live {
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(okBody))
+				return
+			}
+
+			if c.Run(r.Context()).Status == StatusUnavailable {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(okBody))
+			return
+		}
+
+		report := c.Run(r.Context())
+
+		status := http.StatusOK
+		if report.Status == StatusUnavailable && !live {
+			status = http.StatusServiceUnavailable
+		}
+
+		data, err := json.Marshal(report)
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(data)
+	})
+}
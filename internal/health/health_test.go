@@ -0,0 +1,117 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func check(name string, critical bool, err error) Check {
+	return Check{Name: name, Critical: critical, Check: func(ctx context.Context) error { return err }}
+}
+
+func TestHandler(t *testing.T) {
+
+	for _, tc := range []struct {
+		title                string
+		checks               []Check
+		detail               bool
+		live                 bool
+		query                string
+		expectedResponseCode int
+		expectedResponseBody string
+		expectedStatus       string
+		expectedChecks       map[string]string
+	}{
+		{
+			title:                "opaque ok when all checks pass",
+			checks:               []Check{check("nats", true, nil)},
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: "READY",
+		},
+		{
+			title:                "opaque unavailable when critical check fails",
+			checks:               []Check{check("nats", true, fmt.Errorf("nats: connection closed"))},
+			expectedResponseCode: http.StatusServiceUnavailable,
+		},
+		{
+			title:                "opaque ok when non-critical check fails",
+			checks:               []Check{check("nats", true, nil), check("sink:http", false, fmt.Errorf("unavailable"))},
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: "READY",
+		},
+		{
+			title:                "liveness is ok when critical check fails",
+			checks:               []Check{check("nats", true, fmt.Errorf("nats: connection closed"))},
+			live:                 true,
+			expectedResponseCode: http.StatusOK,
+			expectedResponseBody: "READY",
+		},
+		{
+			title:                "detail report with degraded sink",
+			checks:               []Check{check("nats", true, nil), check("sink:http", false, fmt.Errorf("unavailable"))},
+			detail:               true,
+			expectedResponseCode: http.StatusOK,
+			expectedStatus:       StatusDegraded,
+			expectedChecks:       map[string]string{"nats": StatusOK, "sink:http": StatusDegraded},
+		},
+		{
+			title:                "detail report with unavailable dependency",
+			checks:               []Check{check("nats", true, fmt.Errorf("nats: connection closed")), check("sink:http", false, nil)},
+			detail:               true,
+			expectedResponseCode: http.StatusServiceUnavailable,
+			expectedStatus:       StatusUnavailable,
+			expectedChecks:       map[string]string{"nats": StatusUnavailable, "sink:http": StatusOK},
+		},
+		{
+			title:                "verbose query does not override disabled detail",
+			checks:               []Check{check("nats", true, fmt.Errorf("nats: connection closed"))},
+			query:                "?verbose",
+			expectedResponseCode: http.StatusServiceUnavailable,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			checker := NewChecker(time.Second, tc.checks...)
+
+			rec := httptest.NewRecorder()
+			checker.Handler("READY", tc.detail, tc.live).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz"+tc.query, nil))
+
+			assert.Equal(t, tc.expectedResponseCode, rec.Code)
+
+			if tc.expectedChecks == nil {
+				assert.Equal(t, tc.expectedResponseBody, rec.Body.String())
+				return
+			}
+
+			var report Report
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+			assert.Equal(t, tc.expectedStatus, report.Status)
+			for name, status := range tc.expectedChecks {
+				assert.Equal(t, status, report.Checks[name].Status, "unexpected status of %s", name)
+			}
+		})
+	}
+}
+
+func TestCheckerRemembersLastError(t *testing.T) {
+	var err error
+	checker := NewChecker(time.Second, Check{Name: "nats", Critical: true, Check: func(ctx context.Context) error { return err }})
+
+	err = fmt.Errorf("nats: timeout")
+	report := checker.Run(context.Background())
+	assert.Equal(t, "nats: timeout", report.Checks["nats"].Error)
+
+	err = nil
+	report = checker.Run(context.Background())
+	assert.Equal(t, StatusOK, report.Status)
+	assert.Empty(t, report.Checks["nats"].Error)
+	assert.Equal(t, "nats: timeout", report.Checks["nats"].LastError)
+	assert.NotNil(t, report.Checks["nats"].LastErrorAt)
+}
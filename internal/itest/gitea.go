@@ -0,0 +1,196 @@
+//go:build itest
+
+package itest
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/testcontainers/testcontainers-go"
+	tcexec "github.com/testcontainers/testcontainers-go/exec"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	giteaUser     = "itest"
+	giteaPassword = "itest-password"
+)
+
+// gitea is a Gitea container and a client for its API, authenticated as an admin user.
+type gitea struct {
+	container testcontainers.Container
+	baseURL   string
+	client    *http.Client
+}
+
+type repository struct {
+	FullName      string `json:"full_name"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+type pullRequest struct {
+	ID     int64 `json:"id"`
+	Number int64 `json:"number"`
+}
+
+// startGitea starts a Gitea container that can reach the given port on this host and creates
+// the admin user.
+func startGitea(ctx context.Context, image string, hostPort int) (*gitea, error) {
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: testcontainers.ContainerRequest{
+			Image:        image,
+			ExposedPorts: []string{"3000/tcp"},
+			Env: map[string]string{
+				"GITEA__security__INSTALL_LOCK":     "true",
+				"GITEA__database__DB_TYPE":          "sqlite3",
+				"GITEA__webhook__ALLOWED_HOST_LIST": "*",
+			},
+			HostAccessPorts: []int{hostPort},
+			WaitingFor:      wait.ForHTTP("/api/healthz").WithPort("3000/tcp").WithStartupTimeout(2 * time.Minute),
+		},
+		Started: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start Gitea container: %w", err)
+	}
+	g := &gitea{container: container, client: &http.Client{Timeout: 30 * time.Second}}
+
+	endpoint, err := container.PortEndpoint(ctx, "3000/tcp", "http")
+	if err != nil {
+		g.terminate()
+		return nil, err
+	}
+	g.baseURL = endpoint + "/api/v1"
+
+	// Gitea refuses to run admin commands as root.
+	code, output, err := container.Exec(ctx, []string{
+		"gitea", "admin", "user", "create", "--admin",
+		"--username", giteaUser, "--password", giteaPassword, "--email", giteaUser + "@example.com",
+		"--must-change-password=false",
+	}, tcexec.WithUser("git"))
+	if err == nil && code != 0 {
+		out, _ := io.ReadAll(output)
+		err = fmt.Errorf("exit code %d: %s", code, bytes.TrimSpace(out))
+	}
+	if err != nil {
+		g.terminate()
+		return nil, fmt.Errorf("failed to create Gitea admin user: %w", err)
+	}
+
+	return g, nil
+}
+
+func (g *gitea) terminate() {
+	testcontainers.TerminateContainer(g.container)
+}
+
+func (g *gitea) version(ctx context.Context) (string, error) {
+	var version struct {
+		Version string `json:"version"`
+	}
+	err := g.do(ctx, http.MethodGet, "/version", nil, &version)
+	return version.Version, err
+}
+
+func (g *gitea) createRepository(ctx context.Context, name string) (repository, error) {
+	var repo repository
+	err := g.do(ctx, http.MethodPost, "/user/repos", map[string]interface{}{
+		"name":           name,
+		"auto_init":      true,
+		"default_branch": "main",
+	}, &repo)
+	return repo, err
+}
+
+func (g *gitea) createWebhook(ctx context.Context, repo, url string) error {
+	return g.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/hooks", repo), map[string]interface{}{
+		"type":   "gitea",
+		"active": true,
+		"events": []string{"push", "create", "delete", "pull_request"},
+		"config": map[string]string{"url": url, "content_type": "json"},
+	}, nil)
+}
+
+// commitFile commits a new file to the branch, which Gitea delivers as a push, and returns the
+// commit sha.
+func (g *gitea) commitFile(ctx context.Context, repo, branch, path string) (string, error) {
+	var response struct {
+		Commit struct {
+			SHA string `json:"sha"`
+		} `json:"commit"`
+	}
+	err := g.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/contents/%s", repo, path), map[string]interface{}{
+		"branch":  branch,
+		"message": fmt.Sprintf("Add %s", path),
+		"content": base64.StdEncoding.EncodeToString([]byte(time.Now().String())),
+	}, &response)
+	return response.Commit.SHA, err
+}
+
+func (g *gitea) createBranch(ctx context.Context, repo, branch, from string) error {
+	return g.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/branches", repo), map[string]string{
+		"new_branch_name": branch,
+		"old_branch_name": from,
+	}, nil)
+}
+
+func (g *gitea) deleteBranch(ctx context.Context, repo, branch string) error {
+	return g.do(ctx, http.MethodDelete, fmt.Sprintf("/repos/%s/branches/%s", repo, branch), nil, nil)
+}
+
+func (g *gitea) createPullRequest(ctx context.Context, repo, head, base string) (pullRequest, error) {
+	var pull pullRequest
+	err := g.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/pulls", repo), map[string]string{
+		"head":  head,
+		"base":  base,
+		"title": fmt.Sprintf("Merge %s", head),
+	}, &pull)
+	return pull, err
+}
+
+func (g *gitea) mergePullRequest(ctx context.Context, repo string, number int64) error {
+	return g.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/pulls/%d/merge", repo, number), map[string]string{
+		"Do": "merge",
+	}, nil)
+}
+
+func (g *gitea) do(ctx context.Context, method, path string, body, v interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, g.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(giteaUser, giteaPassword)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s responded with %s: %s", method, path, resp.Status, bytes.TrimSpace(data))
+	}
+	if v != nil {
+		return json.NewDecoder(resp.Body).Decode(v)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+//go:build itest
+
+// Package itest runs contract tests of the adapter against a live Gitea instance: Gitea is
+// started in a container with testcontainers, a repository with a webhook to the adapter is
+// created in it and real pushes, branches and pull requests are made, and every action must
+// result in the expected CDEvent in the event stream.
+//
+// The package is only built with the itest build tag, e.g. go test -tags itest ./internal/itest,
+// since it needs a Docker daemon.
+package itest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	cdeventsv04 "github.com/cdevents/sdk-go/pkg/api/v04"
+	cejsm "github.com/cloudevents/sdk-go/protocol/nats_jetstream/v3"
+	"github.com/cloudevents/sdk-go/v2/binding"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// DefaultImage is the Gitea image the contract tests are run against.
+const DefaultImage = "gitea/gitea:1.22"
+
+type Config struct {
+	// WebhookURL is the URL of the webhook endpoint of the adapter on this host. Gitea reaches
+	// it through testcontainers host port forwarding.
+	WebhookURL string
+	Image      string
+	// Timeout is the longest time to wait for the event of every action.
+	Timeout time.Duration
+}
+
+type Stream interface {
+	Info(ctx context.Context, opts ...jetstream.StreamInfoOpt) (*jetstream.StreamInfo, error)
+	OrderedConsumer(ctx context.Context, cfg jetstream.OrderedConsumerConfig) (jetstream.Consumer, error)
+}
+
+// StepResult is the outcome of an action in Gitea and the event it resulted in.
+type StepResult struct {
+	Name      string        `json:"name"`
+	EventType string        `json:"event_type"`
+	SubjectID string        `json:"subject_id"`
+	Latency   time.Duration `json:"latency"`
+}
+
+type Result struct {
+	GiteaVersion string       `json:"gitea_version"`
+	Repository   string       `json:"repository"`
+	Steps        []StepResult `json:"steps"`
+}
+
+type step struct {
+	name string
+	// eventType is the subject and predicate of the expected event, e.g. change.merged.
+	eventType string
+	action    func(ctx context.Context) (subjectID string, err error)
+}
+
+// Run starts Gitea, creates a repository with a webhook to the adapter and checks the events in
+// the event stream for every action. The steps that passed are returned together with the error
+// of the first one that failed.
+func Run(ctx context.Context, logger *slog.Logger, stream Stream, config Config) (Result, error) {
+	var result Result
+
+	if config.Image == "" {
+		config.Image = DefaultImage
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+
+	webhookURL, port, err := containerWebhookURL(config.WebhookURL)
+	if err != nil {
+		return result, err
+	}
+
+	logger.Info(fmt.Sprintf("Starting Gitea container: %s", config.Image))
+	gitea, err := startGitea(ctx, config.Image, port)
+	if err != nil {
+		return result, err
+	}
+	defer gitea.terminate()
+
+	if result.GiteaVersion, err = gitea.version(ctx); err != nil {
+		return result, err
+	}
+
+	suffix, err := randomSuffix()
+	if err != nil {
+		return result, err
+	}
+	repository, err := gitea.createRepository(ctx, "itest-"+suffix)
+	if err != nil {
+		return result, err
+	}
+	result.Repository = repository.FullName
+
+	if err := gitea.createWebhook(ctx, repository.FullName, webhookURL); err != nil {
+		return result, err
+	}
+	logger.Info(fmt.Sprintf("Created repository %s in Gitea %s with webhook to %s", repository.FullName, result.GiteaVersion, webhookURL))
+
+	// Events are read from the end of the stream before the first action, so that no event can
+	// be missed.
+	info, err := stream.Info(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to look up event stream: %w", err)
+	}
+	consumer, err := stream.OrderedConsumer(ctx, jetstream.OrderedConsumerConfig{
+		DeliverPolicy: jetstream.DeliverByStartSequencePolicy,
+		OptStartSeq:   info.State.LastSeq + 1,
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to consume event stream: %w", err)
+	}
+
+	branch := "feature-" + suffix
+	var pull pullRequest
+	steps := []step{
+		{name: "push to default branch", eventType: "change.merged", action: func(ctx context.Context) (string, error) {
+			return gitea.commitFile(ctx, repository.FullName, repository.DefaultBranch, "itest/main.txt")
+		}},
+		{name: "create branch", eventType: "branch.created", action: func(ctx context.Context) (string, error) {
+			return branch, gitea.createBranch(ctx, repository.FullName, branch, repository.DefaultBranch)
+		}},
+		{name: "push to branch", eventType: "change.merged", action: func(ctx context.Context) (string, error) {
+			return gitea.commitFile(ctx, repository.FullName, branch, "itest/feature.txt")
+		}},
+		{name: "open pull request", eventType: "change.created", action: func(ctx context.Context) (string, error) {
+			created, err := gitea.createPullRequest(ctx, repository.FullName, branch, repository.DefaultBranch)
+			pull = created
+			return fmt.Sprintf("pr-%d", pull.ID), err
+		}},
+		{name: "merge pull request", eventType: "change.merged", action: func(ctx context.Context) (string, error) {
+			return fmt.Sprintf("pr-%d", pull.ID), gitea.mergePullRequest(ctx, repository.FullName, pull.Number)
+		}},
+		{name: "delete branch", eventType: "branch.deleted", action: func(ctx context.Context) (string, error) {
+			return branch, gitea.deleteBranch(ctx, repository.FullName, branch)
+		}},
+	}
+
+	for _, s := range steps {
+		start := time.Now()
+		subjectID, err := s.action(ctx)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", s.name, err)
+		}
+		if err := waitForEvent(ctx, consumer, s.eventType, subjectID, config.Timeout); err != nil {
+			return result, fmt.Errorf("%s: %w", s.name, err)
+		}
+		result.Steps = append(result.Steps, StepResult{Name: s.name, EventType: s.eventType, SubjectID: subjectID, Latency: time.Since(start)})
+		logger.Info(fmt.Sprintf("Passed %s: %s event for %s", s.name, s.eventType, subjectID))
+	}
+
+	return result, nil
+}
+
+// waitForEvent reads the event stream until there is an event of the type about the subject.
+// Other events, e.g. for pushes that are part of a merge, are skipped.
+func waitForEvent(ctx context.Context, consumer jetstream.Consumer, eventType, subjectID string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for {
+		msg, err := consumer.Next(jetstream.FetchMaxWait(time.Second))
+		if ctx.Err() != nil {
+			return fmt.Errorf("no %s event for %s within %s", eventType, subjectID, timeout)
+		}
+		if errors.Is(err, nats.ErrTimeout) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to read event stream: %w", err)
+		}
+
+		event, err := binding.ToEvent(ctx, cejsm.NewMessage(msg))
+		if err != nil {
+			return fmt.Errorf("invalid CloudEvent on %s: %w", msg.Subject(), err)
+		}
+		cdEvent, err := cdeventsv04.NewFromJsonBytes(event.Data())
+		if err != nil {
+			return fmt.Errorf("invalid CDEvent on %s: %w", msg.Subject(), err)
+		}
+
+		typ := cdEvent.GetType()
+		if typ.Subject+"."+typ.Predicate == eventType && cdEvent.GetSubjectId() == subjectID {
+			return nil
+		}
+	}
+}
+
+// containerWebhookURL returns the webhook URL as seen from a container with access to the port
+// of the URL on this host.
+func containerWebhookURL(webhookURL string) (string, int, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		return "", 0, fmt.Errorf("webhook URL %s has no port", webhookURL)
+	}
+	u.Host = net.JoinHostPort(testcontainers.HostInternal, u.Port())
+	return u.String(), port, nil
+}
+
+func randomSuffix() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
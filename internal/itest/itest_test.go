@@ -0,0 +1,88 @@
+//go:build itest
+
+package itest
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/publisher"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/webhook"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGitea runs the contract tests against an adapter pipeline in the test process: the webhook
+// endpoint, the webhook and event streams in an embedded NATS server and the adapter with the
+// built-in translators. Set ITEST_GITEA_IMAGE to test another Gitea version.
+func TestGitea(t *testing.T) {
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+	ns.Start()
+	t.Cleanup(ns.Shutdown)
+	require.True(t, ns.ReadyForConnections(10*time.Second))
+
+	nc, err := nats.Connect(ns.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	_, err = js.CreateStream(ctx, jetstream.StreamConfig{Name: "webhooks", Subjects: []string{"webhooks.>"}})
+	require.NoError(t, err)
+	events, err := js.CreateStream(ctx, jetstream.StreamConfig{Name: "events", Subjects: []string{"dev.cdevents.>"}})
+	require.NoError(t, err)
+
+	eventPublisher, err := publisher.NewCloudEventJetstreamPublisher(nc, publisher.JetStreamConfig{})
+	require.NoError(t, err)
+	cdEventsAdapter := adapter.NewCDEventAdapter(logger, eventPublisher, translator.NewRegistry(translator.Builtin()))
+
+	consumer, err := js.CreateConsumer(ctx, "webhooks", jetstream.ConsumerConfig{AckPolicy: jetstream.AckExplicitPolicy})
+	require.NoError(t, err)
+	consumeCtx, err := consumer.Consume(func(msg jetstream.Msg) {
+		if err := cdEventsAdapter.Process(msg); err != nil {
+			logger.Warn("Failed to process webhook", "subject", msg.Subject(), "error", err.Error())
+		}
+	})
+	require.NoError(t, err)
+	t.Cleanup(consumeCtx.Stop)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	srv := &http.Server{Handler: webhook.NewHttpWebhook(logger).GetHandler(js, "webhooks")}
+	go srv.Serve(listener)
+	t.Cleanup(func() { srv.Close() })
+
+	result, err := Run(ctx, logger, events, Config{
+		WebhookURL: fmt.Sprintf("http://%s/webhook", listener.Addr()),
+		Image:      os.Getenv("ITEST_GITEA_IMAGE"),
+	})
+	for _, step := range result.Steps {
+		t.Logf("%s: %s event for %s after %s", step.Name, step.EventType, step.SubjectID, step.Latency.Round(time.Millisecond))
+	}
+	require.NoError(t, err)
+	require.Len(t, result.Steps, 6)
+}
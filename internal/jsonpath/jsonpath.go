@@ -0,0 +1,145 @@
+// Package jsonpath implements the subset of JSONPath used to select fields of webhook payloads:
+// expressions of the form $.a.b[*].c, where [*] selects every element of an array and * every
+// field of an object.
+package jsonpath
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Path []string
+
+func Parse(expression string) (Path, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(expression), "$")
+	trimmed = strings.ReplaceAll(trimmed, "[*]", ".[*]")
+
+	var path Path
+	for _, segment := range strings.Split(trimmed, ".") {
+		if segment == "" {
+			continue
+		}
+		if segment != "[*]" && segment != "*" && strings.ContainsAny(segment, "[]*") {
+			return nil, fmt.Errorf("unsupported field path %s: only names, * and [*] are supported", expression)
+		}
+		path = append(path, segment)
+	}
+	if len(path) == 0 {
+		return nil, fmt.Errorf("empty field path %s", expression)
+	}
+	return path, nil
+}
+
+// Select returns the value at the path in a decoded JSON document, wrapped in the objects and
+// arrays that lead to it, and whether there is one.
+func (p Path) Select(doc interface{}) (interface{}, bool) {
+	if len(p) == 0 {
+		return doc, true
+	}
+
+	switch p[0] {
+	case "[*]":
+		elements, ok := doc.([]interface{})
+		if !ok {
+			return nil, false
+		}
+		selected := make([]interface{}, len(elements))
+		for i, element := range elements {
+			selected[i], _ = p[1:].Select(element)
+		}
+		return selected, true
+	case "*":
+		object, ok := doc.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		selected := make(map[string]interface{})
+		for key, value := range object {
+			if s, ok := p[1:].Select(value); ok {
+				selected[key] = s
+			}
+		}
+		return selected, len(selected) > 0
+	}
+
+	object, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	value, exists := object[p[0]]
+	if !exists {
+		return nil, false
+	}
+	selected, ok := p[1:].Select(value)
+	if !ok {
+		return nil, false
+	}
+	return map[string]interface{}{p[0]: selected}, true
+}
+
+// Update replaces every value at the path in a decoded JSON document, in place, with the result
+// of update.
+func (p Path) Update(doc interface{}, update func(value interface{}) interface{}) {
+	if len(p) == 0 {
+		return
+	}
+
+	last := len(p) == 1
+	switch container := doc.(type) {
+	case []interface{}:
+		if p[0] != "[*]" {
+			return
+		}
+		for i, element := range container {
+			if last {
+				container[i] = update(element)
+			} else {
+				p[1:].Update(element, update)
+			}
+		}
+	case map[string]interface{}:
+		for key, value := range container {
+			if p[0] != "*" && p[0] != key {
+				continue
+			}
+			if last {
+				container[key] = update(value)
+			} else {
+				p[1:].Update(value, update)
+			}
+		}
+	}
+}
+
+// Merge merges the values selected by several paths into one document.
+func Merge(dst, src interface{}) interface{} {
+	if src == nil {
+		return dst
+	}
+	switch d := dst.(type) {
+	case map[string]interface{}:
+		s, ok := src.(map[string]interface{})
+		if !ok {
+			return src
+		}
+		for key, value := range s {
+			if existing, exists := d[key]; exists {
+				d[key] = Merge(existing, value)
+			} else {
+				d[key] = value
+			}
+		}
+		return d
+	case []interface{}:
+		s, ok := src.([]interface{})
+		if !ok || len(s) != len(d) {
+			return src
+		}
+		for i := range d {
+			d[i] = Merge(d[i], s[i])
+		}
+		return d
+	default:
+		return src
+	}
+}
@@ -0,0 +1,75 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+
+	for _, tc := range []struct {
+		expression    string
+		expected      Path
+		expectedError string
+	}{
+		{expression: "$.repository.full_name", expected: Path{"repository", "full_name"}},
+		{expression: "commits[*].author.email", expected: Path{"commits", "[*]", "author", "email"}},
+		{expression: "$.*.email", expected: Path{"*", "email"}},
+		{expression: "$.commits[0].id", expectedError: "unsupported field path"},
+		{expression: "$", expectedError: "empty field path"},
+	} {
+		t.Run(tc.expression, func(t *testing.T) {
+			path, err := Parse(tc.expression)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, path)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+
+	for _, tc := range []struct {
+		title    string
+		path     string
+		expected string
+	}{
+		{
+			title:    "updates field of every array element",
+			path:     "$.commits[*].author.email",
+			expected: `{"commits": [{"author": {"email": "x", "name": "Jane"}}, {"author": {"email": "x", "name": "John"}}], "pusher": {"email": "jane@example.com"}}`,
+		},
+		{
+			title:    "updates field of every object",
+			path:     "$.*.email",
+			expected: `{"commits": [{"author": {"email": "jane@example.com", "name": "Jane"}}, {"author": {"email": "john@example.com", "name": "John"}}], "pusher": {"email": "x"}}`,
+		},
+		{
+			title:    "ignores missing field",
+			path:     "$.repository.owner",
+			expected: `{"commits": [{"author": {"email": "jane@example.com", "name": "Jane"}}, {"author": {"email": "john@example.com", "name": "John"}}], "pusher": {"email": "jane@example.com"}}`,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			var doc interface{}
+			require.NoError(t, json.Unmarshal([]byte(`{
+				"commits": [{"author": {"email": "jane@example.com", "name": "Jane"}}, {"author": {"email": "john@example.com", "name": "John"}}],
+				"pusher": {"email": "jane@example.com"}
+			}`), &doc))
+
+			path, err := Parse(tc.path)
+			require.NoError(t, err)
+			path.Update(doc, func(interface{}) interface{} { return "x" })
+
+			actual, err := json.Marshal(doc)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(actual))
+		})
+	}
+}
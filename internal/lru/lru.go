@@ -0,0 +1,88 @@
+// Package lru keeps the most recently used values in memory, up to a fixed number of entries, e.g.
+// translated events and responses of the Gitea API.
+package lru
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache is a least recently used cache that is safe for concurrent use. When it is full, adding a
+// value evicts the value that was used least recently.
+type Cache[K comparable, V any] struct {
+	size    int
+	mu      sync.Mutex
+	entries map[K]*list.Element
+	order   *list.List
+}
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// New returns a cache holding at most size values, and at least one.
+func New[K comparable, V any](size int) *Cache[K, V] {
+	if size < 1 {
+		size = 1
+	}
+
+	return &Cache[K, V]{
+		size:    size,
+		entries: make(map[K]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns the value of the key, if any, and marks it as the most recently used.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, found := c.entries[key]
+	if !found {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*entry[K, V]).value, true
+}
+
+// Add sets the value of the key as the most recently used, evicting the least recently used value
+// if the cache is full.
+func (c *Cache[K, V]) Add(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, found := c.entries[key]; found {
+		element.Value.(*entry[K, V]).value = value
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&entry[K, V]{key: key, value: value})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry[K, V]).key)
+	}
+}
+
+// Remove removes the value of the key, if any.
+func (c *Cache[K, V]) Remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, found := c.entries[key]; found {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+// Len returns the number of values in the cache.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
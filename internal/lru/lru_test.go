@@ -0,0 +1,81 @@
+package lru
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+
+	for _, tc := range []struct {
+		title    string
+		size     int
+		run      func(c *Cache[string, int])
+		expected map[string]int
+		missing  []string
+	}{
+		{
+			title:    "keeps values up to the size",
+			size:     2,
+			run:      func(c *Cache[string, int]) { c.Add("a", 1); c.Add("b", 2) },
+			expected: map[string]int{"a": 1, "b": 2},
+		},
+		{
+			title:    "evicts the least recently added value",
+			size:     2,
+			run:      func(c *Cache[string, int]) { c.Add("a", 1); c.Add("b", 2); c.Add("c", 3) },
+			expected: map[string]int{"b": 2, "c": 3},
+			missing:  []string{"a"},
+		},
+		{
+			title: "evicts the least recently used value",
+			size:  2,
+			run: func(c *Cache[string, int]) {
+				c.Add("a", 1)
+				c.Add("b", 2)
+				c.Get("a")
+				c.Add("c", 3)
+			},
+			expected: map[string]int{"a": 1, "c": 3},
+			missing:  []string{"b"},
+		},
+		{
+			title:    "replaces the value of a key",
+			size:     2,
+			run:      func(c *Cache[string, int]) { c.Add("a", 1); c.Add("b", 2); c.Add("a", 3); c.Add("c", 4) },
+			expected: map[string]int{"a": 3, "c": 4},
+			missing:  []string{"b"},
+		},
+		{
+			title:    "removes a value",
+			size:     2,
+			run:      func(c *Cache[string, int]) { c.Add("a", 1); c.Add("b", 2); c.Remove("a") },
+			expected: map[string]int{"b": 2},
+			missing:  []string{"a"},
+		},
+		{
+			title:    "holds at least one value",
+			size:     0,
+			run:      func(c *Cache[string, int]) { c.Add("a", 1); c.Add("b", 2) },
+			expected: map[string]int{"b": 2},
+			missing:  []string{"a"},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			c := New[string, int](tc.size)
+			tc.run(c)
+
+			assert.Equal(t, len(tc.expected), c.Len())
+			for key, expected := range tc.expected {
+				value, found := c.Get(key)
+				assert.True(t, found, key)
+				assert.Equal(t, expected, value, key)
+			}
+			for _, key := range tc.missing {
+				_, found := c.Get(key)
+				assert.False(t, found, key)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "cdevents_adapter"
+
+var BuildInfo = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: namespace,
+	Name:      "build_info",
+	Help:      "Always 1, labelled with the version, commit and build date of the running adapter.",
+}, []string{"version", "commit", "build_date", "go_version"})
+
+// The consumer backlog and the rate of processed webhook messages can be used to autoscale
+// adapter replicas with KEDA, since all replicas share the durable webhook consumer. With the
+// Prometheus scaler, use a query such as
+//
+//	max(cdevents_adapter_consumer_backlog)
+//
+// as every replica reports the same backlog, together with
+//
+//	sum(rate(cdevents_adapter_webhook_messages_total[1m]))
+//
+// for the processing rate. Alternatively, the NATS JetStream scaler can read the same backlog
+// directly from the NATS monitoring endpoint given the account, WEBHOOK_STREAM_NAME and
+// WEBHOOK_CONSUMER_NAME. See examples/k8s/keda-scaledobject.yaml.
+var (
+	ConsumerBacklog = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "consumer_backlog",
+		Help:      "Number of webhook messages pending delivery or acknowledgement, for autoscaling.",
+	})
+
+	WebhookMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_messages_total",
+		Help:      "Number of webhook messages processed, by outcome (published, skipped, failed).",
+	}, []string{"outcome"})
+)
+
+var (
+	ConsumerNumPending = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "consumer_num_pending",
+		Help:      "Number of webhook messages in the stream not yet delivered to the consumer.",
+	})
+
+	ConsumerNumAckPending = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "consumer_num_ack_pending",
+		Help:      "Number of webhook messages delivered but not yet acknowledged.",
+	})
+
+	ConsumerNumRedelivered = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "consumer_num_redelivered",
+		Help:      "Number of webhook messages that have been redelivered and not yet acknowledged.",
+	})
+)
+
+var (
+	StreamMsgs = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stream_msgs",
+		Help:      "Number of messages stored in the stream.",
+	}, []string{"stream"})
+
+	StreamBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stream_bytes",
+		Help:      "Number of bytes stored in the stream.",
+	}, []string{"stream"})
+
+	StreamOldestMsgAge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "stream_oldest_msg_age_seconds",
+		Help:      "Age of the oldest message stored in the stream.",
+	}, []string{"stream"})
+)
+
+var (
+	SinkEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "sink_events_total",
+		Help:      "Number of events handled per sink, by outcome (delivered, failed, dropped, filtered).",
+	}, []string{"sink", "outcome"})
+
+	SinkQueueLength = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "sink_queue_length",
+		Help:      "Number of events waiting to be delivered per sink.",
+	}, []string{"sink"})
+
+	RoutedEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "routed_events_total",
+		Help:      "Number of events that matched a routing rule, per route.",
+	}, []string{"route"})
+)
+
+var (
+	TranslatorMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "translator_messages_total",
+		Help:      "Number of webhook messages handled per translator, by outcome (success, failure).",
+	}, []string{"translator", "outcome"})
+
+	TranslatorDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "translator_duration_seconds",
+		Help:      "Time taken to process a webhook message per translator.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"translator"})
+
+	TranslatorSuccessRatio = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "translator_success_ratio",
+		Help:      "Ratio of successfully translated messages over the window of recent messages per translator.",
+	}, []string{"translator"})
+
+	TranslatorDegraded = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "translator_degraded",
+		Help:      "1 if the failure rate of the translator has crossed the configured threshold, otherwise 0.",
+	}, []string{"translator"})
+)
+
+var (
+	ResultCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "result_cache_lookups_total",
+		Help:      "Number of lookups of translated events for webhook messages, by result (memory, backing, miss).",
+	}, []string{"result"})
+
+	ResultCacheErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "result_cache_errors_total",
+		Help:      "Number of failures to read or write translated events in the key-value bucket backing the cache.",
+	})
+)
+
+var (
+	InvalidEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "events_invalid_total",
+		Help:      "Number of translated events that were not published because they are not valid against the CDEvents schema, per translator.",
+	}, []string{"translator"})
+)
+
+var (
+	EventLinks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "event_links_total",
+		Help:      "Number of lookups of earlier events to link translated events to, by result (linked, not_found, error).",
+	}, []string{"result"})
+)
+
+var (
+	RawWebhooks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhooks_raw_total",
+		Help:      "Number of webhooks of supported providers without a translator for their event type that were emitted as raw webhook events, per webhook subject.",
+	}, []string{"subject"})
+)
+
+var (
+	GiteaAPIRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "gitea_api_requests_total",
+		Help:      "Number of requests of translators to the Gitea API, by outcome (ok, error, cached, unavailable while backing off).",
+	}, []string{"outcome"})
+)
+
+var (
+	WorkersBusy = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "workers_busy",
+		Help:      "Number of webhook workers currently processing a message.",
+	})
+
+	WorkersLimit = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "workers_limit",
+		Help:      "Number of webhook workers allowed to process messages at the same time by the adaptive concurrency limit.",
+	})
+
+	WorkerMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "worker_messages_total",
+		Help:      "Number of webhook messages processed, by worker.",
+	}, []string{"worker"})
+
+	WorkerBusySeconds = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "worker_busy_seconds_total",
+		Help:      "Time spent processing webhook messages, by worker. Its rate is the utilization of the worker.",
+	}, []string{"worker"})
+
+	DrainMode = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "drain_mode",
+		Help:      "1 while a backlog found at startup is drained with additional workers, otherwise 0.",
+	})
+
+	DeferredAuditsDropped = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "deferred_audit_records_dropped_total",
+		Help:      "Number of audit records dropped because more were held back while draining than the limit.",
+	})
+
+	InflightBytes = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "inflight_bytes",
+		Help:      "Total size of the webhook payloads queued or being processed.",
+	})
+
+	QueueLength = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "queue_length",
+		Help:      "Number of webhook messages waiting in the queue for a worker.",
+	})
+
+	QueueOverflows = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "queue_overflows_total",
+		Help:      "Number of webhook messages that did not fit in the queue, by how they were handled: spill or nak.",
+	}, []string{"policy"})
+
+	SpilledMessages = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "spilled_messages",
+		Help:      "Number of webhook messages spilled to disk that have not been processed yet.",
+	})
+)
+
+var SchemaDrift = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "schema_drift_total",
+	Help:      "Number of webhook payloads where a field the translator depends on is missing or has changed type, by subject, field and kind.",
+}, []string{"subject", "field", "kind"})
@@ -0,0 +1,49 @@
+// Package openapi serves the OpenAPI 3 document describing the HTTP endpoints of the adapter, so
+// that clients for webhook senders and internal tooling can be generated from it.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed openapi.yaml
+var document []byte
+
+// YAML returns the OpenAPI document.
+func YAML() []byte {
+	return document
+}
+
+// JSON returns the OpenAPI document converted to JSON.
+func JSON() ([]byte, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(document, &doc); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// Handler serves the OpenAPI document as JSON when the request path ends with .json and as YAML
+// otherwise.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, ".json") {
+			w.Header().Set("Content-Type", "application/yaml")
+			w.Write(document)
+			return
+		}
+
+		data, err := JSON()
+		if err != nil {
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	})
+}
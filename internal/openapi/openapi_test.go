@@ -0,0 +1,84 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestDocument(t *testing.T) {
+
+	var doc map[string]interface{}
+	require.NoError(t, yaml.Unmarshal(YAML(), &doc))
+
+	assert.True(t, strings.HasPrefix(doc["openapi"].(string), "3."))
+
+	paths := doc["paths"].(map[string]interface{})
+	for path, item := range paths {
+		for method, operation := range item.(map[string]interface{}) {
+			assert.NotEmpty(t, operation.(map[string]interface{})["responses"], "%s %s has no responses", method, path)
+		}
+	}
+
+	var refs []string
+	collectRefs(doc, &refs)
+	for _, ref := range refs {
+		var target interface{} = doc
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			node, ok := target.(map[string]interface{})
+			require.True(t, ok, "unresolved reference %s", ref)
+			target = node[part]
+		}
+		assert.NotNil(t, target, "unresolved reference %s", ref)
+	}
+}
+
+func collectRefs(node interface{}, refs *[]string) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if ref, ok := value.(string); ok && key == "$ref" {
+				*refs = append(*refs, ref)
+			}
+			collectRefs(value, refs)
+		}
+	case []interface{}:
+		for _, value := range v {
+			collectRefs(value, refs)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+
+	for _, tc := range []struct {
+		title               string
+		requestPath         string
+		expectedContentType string
+	}{
+		{title: "serves yaml", requestPath: "/openapi.yaml", expectedContentType: "application/yaml"},
+		{title: "serves json", requestPath: "/openapi.json", expectedContentType: "application/json"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tc.requestPath, nil))
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, tc.expectedContentType, recorder.Header().Get("Content-Type"))
+
+			var doc map[string]interface{}
+			if tc.expectedContentType == "application/json" {
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &doc))
+			} else {
+				require.NoError(t, yaml.Unmarshal(recorder.Body.Bytes(), &doc))
+			}
+			assert.Contains(t, doc["paths"], "/webhook")
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package publisher
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+type AMQPConfig struct {
+	URL                string        `envconfig:"URL"`
+	Exchange           string        `envconfig:"EXCHANGE" default:"cdevents"`
+	RoutingKeyTemplate string        `envconfig:"ROUTING_KEY_TEMPLATE" default:"{{.Type}}"`
+	Structured         bool          `envconfig:"STRUCTURED" default:"false"`
+	ConfirmTimeout     time.Duration `envconfig:"CONFIRM_TIMEOUT" default:"10s"`
+	TLS                TLSConfig     `envconfig:"TLS"`
+}
+
+// AMQPPublisher publishes events as CloudEvents to an AMQP 0.9.1 exchange (e.g. RabbitMQ) and
+// waits for a publisher confirm from the broker for every event. The connection and channel are
+// re-established on the next publish if they have been closed.
+type AMQPPublisher struct {
+	config     AMQPConfig
+	routingKey *eventTemplate
+	tlsConfig  *tls.Config
+	mu         sync.Mutex
+	conn       *amqp.Connection
+	channel    *amqp.Channel
+}
+
+func NewAMQPPublisher(config AMQPConfig) (*AMQPPublisher, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("no URL configured for AMQP publisher")
+	}
+
+	routingKey, err := newEventTemplate("routing_key", config.RoutingKeyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid routing key template: %w", err)
+	}
+
+	tlsConfig, err := config.TLS.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	return &AMQPPublisher{
+		config:     config,
+		routingKey: routingKey,
+		tlsConfig:  tlsConfig,
+	}, nil
+}
+
+func (p *AMQPPublisher) Publish(ctx context.Context, event cloudevents.Event) error {
+	key, err := p.routingKey.Execute(event)
+	if err != nil {
+		return fmt.Errorf("failed to render routing key: %w", err)
+	}
+
+	msg, err := amqpPublishing(event, p.config.Structured)
+	if err != nil {
+		return err
+	}
+
+	channel, err := p.getChannel()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.ConfirmTimeout)
+	defer cancel()
+
+	confirmation, err := channel.PublishWithDeferredConfirmWithContext(ctx, p.config.Exchange, key, false, false, msg)
+	if err != nil {
+		p.reset()
+		return err
+	}
+
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("no publisher confirm received: %w", err)
+	}
+	if !acked {
+		return fmt.Errorf("event %s was nacked by broker", event.ID())
+	}
+
+	return nil
+}
+
+func (p *AMQPPublisher) getChannel() (*amqp.Channel, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.channel != nil && !p.channel.IsClosed() {
+		return p.channel, nil
+	}
+
+	if p.conn == nil || p.conn.IsClosed() {
+		conn, err := amqp.DialTLS(p.config.URL, p.tlsConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to AMQP broker: %w", err)
+		}
+		p.conn = conn
+	}
+
+	channel, err := p.conn.Channel()
+	if err != nil {
+		p.conn.Close()
+		p.conn = nil
+		return nil, err
+	}
+	closed := channel.NotifyClose(make(chan *amqp.Error, 1))
+
+	if err := channel.Confirm(false); err != nil {
+		channel.Close()
+		return nil, fmt.Errorf("failed to enable publisher confirms: %w", err)
+	}
+
+	p.channel = channel
+	go p.watch(channel, closed)
+
+	return channel, nil
+}
+
+// watch forgets the channel once it is closed, e.g. by the broker after a channel error such as
+// publishing to an exchange that does not exist, which leaves the connection open. The next
+// publish then opens a new channel.
+func (p *AMQPPublisher) watch(channel *amqp.Channel, closed <-chan *amqp.Error) {
+	<-closed
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.channel == channel {
+		p.channel = nil
+	}
+}
+
+func (p *AMQPPublisher) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.conn = nil
+	p.channel = nil
+}
+
+func (p *AMQPPublisher) Close(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		return nil
+	}
+
+	err := p.conn.Close()
+	p.conn = nil
+	p.channel = nil
+
+	return err
+}
+
+// amqpPublishing encodes the event according to the CloudEvents AMQP binding, with attributes
+// as "cloudEvents:" prefixed headers in binary mode.
+func amqpPublishing(event cloudevents.Event, structured bool) (amqp.Publishing, error) {
+	if structured {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return amqp.Publishing{}, err
+		}
+		return amqp.Publishing{
+			ContentType:  "application/cloudevents+json",
+			DeliveryMode: amqp.Persistent,
+			MessageId:    event.ID(),
+			Body:         data,
+		}, nil
+	}
+
+	headers := amqp.Table{
+		"cloudEvents:specversion": event.SpecVersion(),
+		"cloudEvents:id":          event.ID(),
+		"cloudEvents:source":      event.Source(),
+		"cloudEvents:type":        event.Type(),
+	}
+	if event.Subject() != "" {
+		headers["cloudEvents:subject"] = event.Subject()
+	}
+	if !event.Time().IsZero() {
+		headers["cloudEvents:time"] = event.Time().Format(time.RFC3339Nano)
+	}
+	for name, value := range event.Extensions() {
+		headers["cloudEvents:"+name] = fmt.Sprint(value)
+	}
+
+	return amqp.Publishing{
+		Headers:      headers,
+		ContentType:  event.DataContentType(),
+		DeliveryMode: amqp.Persistent,
+		MessageId:    event.ID(),
+		Body:         event.Data(),
+	}, nil
+}
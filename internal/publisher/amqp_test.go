@@ -0,0 +1,47 @@
+package publisher
+
+import (
+	"encoding/json"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAMQPPublishing(t *testing.T) {
+	event := newTestCloudEvent(t)
+
+	binary, err := amqpPublishing(event, false)
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", binary.ContentType)
+	assert.Equal(t, "dev.cdevents.change.merged.0.2.0", binary.Headers["cloudEvents:type"])
+	assert.Equal(t, "git.example.com", binary.Headers["cloudEvents:source"])
+	assert.Equal(t, "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2", binary.Headers["cloudEvents:subject"])
+	assert.JSONEq(t, `{"foo": "bar"}`, string(binary.Body))
+
+	structured, err := amqpPublishing(event, true)
+	require.NoError(t, err)
+	assert.Equal(t, "application/cloudevents+json", structured.ContentType)
+
+	decoded := cloudevents.NewEvent()
+	require.NoError(t, json.Unmarshal(structured.Body, &decoded), "structured body must be a CloudEvent")
+	assert.Equal(t, event.ID(), decoded.ID())
+}
+
+func TestEventTemplate(t *testing.T) {
+	tmpl, err := newEventTemplate("test", "cdevents.{{.Type}}.{{.Subject}}")
+	require.NoError(t, err)
+
+	key, err := tmpl.Execute(newTestCloudEvent(t))
+	require.NoError(t, err)
+	assert.Equal(t, "cdevents.dev.cdevents.change.merged.0.2.0.9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2", key)
+
+	_, err = newEventTemplate("test", "{{.Type")
+	require.Error(t, err, "invalid template should not be parsed")
+}
+
+func TestNewAMQPPublisherRequiresURL(t *testing.T) {
+	_, err := NewAMQPPublisher(AMQPConfig{RoutingKeyTemplate: "{{.Type}}"})
+	require.Error(t, err, "publisher without URL should not be created")
+}
@@ -0,0 +1,195 @@
+package publisher
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	snstypes "github.com/aws/aws-sdk-go-v2/service/sns/types"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+type EventBridgeConfig struct {
+	EventBusName string `envconfig:"EVENT_BUS_NAME" default:"default"`
+	Source       string `envconfig:"SOURCE"`
+	Region       string `envconfig:"REGION"`
+	Endpoint     string `envconfig:"ENDPOINT"`
+}
+
+type eventBridgeAPI interface {
+	PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error)
+}
+
+// EventBridgePublisher puts events on an AWS EventBridge bus with the CDEvent as detail and the
+// CDEvent type as detail-type, so that rules can match on specific event types.
+type EventBridgePublisher struct {
+	client eventBridgeAPI
+	config EventBridgeConfig
+}
+
+func NewEventBridgePublisher(ctx context.Context, config EventBridgeConfig) (*EventBridgePublisher, error) {
+	awsConfig, err := loadAWSConfig(ctx, config.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	client := eventbridge.NewFromConfig(awsConfig, func(o *eventbridge.Options) {
+		if config.Endpoint != "" {
+			o.BaseEndpoint = aws.String(config.Endpoint)
+		}
+	})
+
+	return &EventBridgePublisher{client: client, config: config}, nil
+}
+
+func (p *EventBridgePublisher) Publish(ctx context.Context, event cloudevents.Event) error {
+	source := p.config.Source
+	if source == "" {
+		source = event.Source()
+	}
+
+	output, err := p.client.PutEvents(ctx, &eventbridge.PutEventsInput{
+		Entries: []ebtypes.PutEventsRequestEntry{
+			{
+				EventBusName: aws.String(p.config.EventBusName),
+				Source:       aws.String(source),
+				DetailType:   aws.String(event.Type()),
+				Detail:       aws.String(string(event.Data())),
+				Time:         aws.Time(event.Time()),
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if output.FailedEntryCount > 0 && len(output.Entries) > 0 {
+		entry := output.Entries[0]
+		return fmt.Errorf("EventBridge rejected event %s: %s: %s", event.ID(), aws.ToString(entry.ErrorCode), aws.ToString(entry.ErrorMessage))
+	}
+
+	return nil
+}
+
+type SNSConfig struct {
+	TopicARN string `envconfig:"TOPIC_ARN"`
+	Region   string `envconfig:"REGION"`
+	Endpoint string `envconfig:"ENDPOINT"`
+}
+
+type snsAPI interface {
+	Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error)
+}
+
+// SNSPublisher publishes events as structured mode CloudEvents to an AWS SNS topic, with type,
+// source and subject as message attributes for subscription filter policies.
+type SNSPublisher struct {
+	client snsAPI
+	config SNSConfig
+}
+
+func NewSNSPublisher(ctx context.Context, config SNSConfig) (*SNSPublisher, error) {
+	if config.TopicARN == "" {
+		return nil, fmt.Errorf("no topic ARN configured for SNS publisher")
+	}
+
+	awsConfig, err := loadAWSConfig(ctx, config.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	client := sns.NewFromConfig(awsConfig, func(o *sns.Options) {
+		if config.Endpoint != "" {
+			o.BaseEndpoint = aws.String(config.Endpoint)
+		}
+	})
+
+	return &SNSPublisher{client: client, config: config}, nil
+}
+
+func (p *SNSPublisher) Publish(ctx context.Context, event cloudevents.Event) error {
+	message, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	input := &sns.PublishInput{
+		TopicArn: aws.String(p.config.TopicARN),
+		Message:  aws.String(string(message)),
+		MessageAttributes: map[string]snstypes.MessageAttributeValue{
+			"type":   stringAttribute(event.Type()),
+			"source": stringAttribute(event.Source()),
+		},
+	}
+	if event.Subject() != "" {
+		input.MessageAttributes["subject"] = stringAttribute(event.Subject())
+	}
+
+	if strings.HasSuffix(p.config.TopicARN, ".fifo") {
+		input.MessageGroupId = aws.String(snsMessageGroupID(event))
+		input.MessageDeduplicationId = aws.String(event.ID())
+	}
+
+	_, err = p.client.Publish(ctx, input)
+
+	return err
+}
+
+// snsMaxMessageGroupID is the longest message group id accepted by SNS FIFO topics.
+const snsMaxMessageGroupID = 128
+
+// snsMessageGroupID returns the message group id of an event published to a FIFO topic, which
+// SNS requires to be non-empty: the subject of the event, so that the events of a change are
+// delivered in order, or without a subject the repository of the CDEvent, i.e. its subject
+// source, or else the source of the event. Longer ids than SNS accepts are hashed.
+func snsMessageGroupID(event cloudevents.Event) string {
+	id := event.Subject()
+	if id == "" {
+		var data struct {
+			Subject struct {
+				Source string `json:"source"`
+			} `json:"subject"`
+		}
+		if err := event.DataAs(&data); err == nil {
+			id = data.Subject.Source
+		}
+	}
+	if id == "" {
+		id = event.Source()
+	}
+	if len(id) > snsMaxMessageGroupID {
+		sum := sha256.Sum256([]byte(id))
+		id = hex.EncodeToString(sum[:])
+	}
+	return id
+}
+
+func stringAttribute(value string) snstypes.MessageAttributeValue {
+	return snstypes.MessageAttributeValue{
+		DataType:    aws.String("String"),
+		StringValue: aws.String(value),
+	}
+}
+
+func loadAWSConfig(ctx context.Context, region string) (aws.Config, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		opts = append(opts, awsconfig.WithRegion(region))
+	}
+
+	awsConfig, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+
+	return awsConfig, nil
+}
@@ -0,0 +1,174 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
+	ebtypes "github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
+	"github.com/aws/aws-sdk-go-v2/service/sns"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockEventBridgeClient struct {
+	input  *eventbridge.PutEventsInput
+	output *eventbridge.PutEventsOutput
+}
+
+func (m *mockEventBridgeClient) PutEvents(ctx context.Context, params *eventbridge.PutEventsInput, optFns ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+	m.input = params
+	return m.output, nil
+}
+
+func TestEventBridgePublisher(t *testing.T) {
+
+	for _, tc := range []struct {
+		title          string
+		config         EventBridgeConfig
+		output         *eventbridge.PutEventsOutput
+		expectedSource string
+		expectError    bool
+	}{
+		{
+			title:          "puts event with source from event",
+			config:         EventBridgeConfig{EventBusName: "cdevents"},
+			output:         &eventbridge.PutEventsOutput{},
+			expectedSource: "git.example.com",
+		},
+		{
+			title:          "puts event with configured source",
+			config:         EventBridgeConfig{EventBusName: "cdevents", Source: "cdevents.adapter"},
+			output:         &eventbridge.PutEventsOutput{},
+			expectedSource: "cdevents.adapter",
+		},
+		{
+			title:  "returns error for failed entry",
+			config: EventBridgeConfig{EventBusName: "cdevents"},
+			output: &eventbridge.PutEventsOutput{
+				FailedEntryCount: 1,
+				Entries:          []ebtypes.PutEventsResultEntry{{ErrorCode: aws.String("InternalFailure")}},
+			},
+			expectedSource: "git.example.com",
+			expectError:    true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			client := &mockEventBridgeClient{output: tc.output}
+			p := &EventBridgePublisher{client: client, config: tc.config}
+
+			event := newTestCloudEvent(t)
+			err := p.Publish(context.Background(), event)
+			if tc.expectError {
+				require.Error(t, err, "publish should return error")
+			} else {
+				require.NoError(t, err, "publish should not return error")
+			}
+
+			require.Len(t, client.input.Entries, 1, "one entry should be put")
+			entry := client.input.Entries[0]
+			assert.Equal(t, "cdevents", aws.ToString(entry.EventBusName))
+			assert.Equal(t, tc.expectedSource, aws.ToString(entry.Source))
+			assert.Equal(t, "dev.cdevents.change.merged.0.2.0", aws.ToString(entry.DetailType))
+			assert.JSONEq(t, string(event.Data()), aws.ToString(entry.Detail))
+		})
+	}
+}
+
+type mockSNSClient struct {
+	input *sns.PublishInput
+}
+
+func (m *mockSNSClient) Publish(ctx context.Context, params *sns.PublishInput, optFns ...func(*sns.Options)) (*sns.PublishOutput, error) {
+	m.input = params
+	return &sns.PublishOutput{}, nil
+}
+
+func TestSNSPublisher(t *testing.T) {
+
+	for _, tc := range []struct {
+		title      string
+		topicARN   string
+		expectFIFO bool
+	}{
+		{
+			title:    "publishes to standard topic",
+			topicARN: "arn:aws:sns:eu-north-1:123456789012:cdevents",
+		},
+		{
+			title:      "publishes to fifo topic with group and deduplication id",
+			topicARN:   "arn:aws:sns:eu-north-1:123456789012:cdevents.fifo",
+			expectFIFO: true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			client := &mockSNSClient{}
+			p := &SNSPublisher{client: client, config: SNSConfig{TopicARN: tc.topicARN}}
+
+			event := newTestCloudEvent(t)
+			require.NoError(t, p.Publish(context.Background(), event), "publish should not return error")
+
+			assert.Equal(t, tc.topicARN, aws.ToString(client.input.TopicArn))
+			assert.Equal(t, event.Type(), aws.ToString(client.input.MessageAttributes["type"].StringValue))
+			assert.Equal(t, event.Subject(), aws.ToString(client.input.MessageAttributes["subject"].StringValue))
+
+			decoded := cloudevents.NewEvent()
+			require.NoError(t, json.Unmarshal([]byte(aws.ToString(client.input.Message)), &decoded), "message must be a structured CloudEvent")
+			assert.Equal(t, event.ID(), decoded.ID())
+
+			if tc.expectFIFO {
+				assert.Equal(t, event.Subject(), aws.ToString(client.input.MessageGroupId))
+				assert.Equal(t, event.ID(), aws.ToString(client.input.MessageDeduplicationId))
+			} else {
+				assert.Nil(t, client.input.MessageGroupId)
+			}
+		})
+	}
+}
+
+func TestSNSMessageGroupID(t *testing.T) {
+
+	withoutSubject := func(data interface{}) cloudevents.Event {
+		event := newTestCloudEvent(t)
+		event.SetSubject("")
+		require.NoError(t, event.SetData(cloudevents.ApplicationJSON, data))
+		return event
+	}
+	longSubject := newTestCloudEvent(t)
+	longSubject.SetSubject(strings.Repeat("a", 129))
+
+	for _, tc := range []struct {
+		title    string
+		event    cloudevents.Event
+		expected string
+	}{
+		{
+			title:    "groups by subject",
+			event:    newTestCloudEvent(t),
+			expected: "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2",
+		},
+		{
+			title:    "groups by repository without subject",
+			event:    withoutSubject(map[string]interface{}{"subject": map[string]string{"source": "git.example.com/yoloco/project1"}}),
+			expected: "git.example.com/yoloco/project1",
+		},
+		{
+			title:    "groups by source without subject and repository",
+			event:    withoutSubject(map[string]string{"foo": "bar"}),
+			expected: "git.example.com",
+		},
+		{
+			title:    "hashes subjects longer than SNS accepts",
+			event:    longSubject,
+			expected: "c12cb024a2e5551cca0e08fce8f1c5e314555cc3fef6329ee994a3db752166ae",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			assert.Equal(t, tc.expected, snsMessageGroupID(tc.event))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package publisher
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// gzipTransport compresses request bodies with gzip and sets the Content-Encoding header
+// accordingly. Bodies smaller than minSize are sent uncompressed.
+type gzipTransport struct {
+	minSize int
+	next    http.RoundTripper
+}
+
+func (t *gzipTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Header.Get("Content-Encoding") != "" {
+		return t.next.RoundTrip(req)
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+
+	if len(body) < t.minSize {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		return t.next.RoundTrip(req)
+	}
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write(body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	req.Body = io.NopCloser(&compressed)
+	req.ContentLength = int64(compressed.Len())
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Content-Length", strconv.Itoa(compressed.Len()))
+
+	return t.next.RoundTrip(req)
+}
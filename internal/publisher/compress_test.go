@@ -0,0 +1,68 @@
+package publisher
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPPublisherCompression(t *testing.T) {
+
+	for _, tc := range []struct {
+		title            string
+		minSize          int
+		expectCompressed bool
+	}{
+		{
+			title:            "compresses body",
+			expectCompressed: true,
+		},
+		{
+			title:   "does not compress body smaller than min size",
+			minSize: 1024,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			var encoding string
+			var body []byte
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				encoding = r.Header.Get("Content-Encoding")
+
+				reader := r.Body
+				if encoding == "gzip" {
+					zr, err := gzip.NewReader(r.Body)
+					require.NoError(t, err, "body should be gzip compressed")
+					reader = zr
+				}
+				body, _ = io.ReadAll(reader)
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			p, err := NewHTTPPublisher(HTTPConfig{URL: server.URL, Structured: true, Compress: true, CompressMin: tc.minSize})
+			require.NoError(t, err, "unable to create publisher")
+
+			event := newTestCloudEvent(t)
+			require.NoError(t, p.Publish(context.Background(), event))
+
+			if tc.expectCompressed {
+				assert.Equal(t, "gzip", encoding)
+			} else {
+				assert.Empty(t, encoding)
+			}
+
+			decoded := cloudevents.NewEvent()
+			require.NoError(t, json.Unmarshal(body, &decoded), "body must be a structured CloudEvent")
+			assert.Equal(t, event.ID(), decoded.ID())
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package publisher
+
+import (
+	"context"
+	"log/slog"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// DryRunPublisher logs the events that would have been published to a sink instead of
+// publishing them, so that translators, filters and routes can be tried out on real traffic.
+type DryRunPublisher struct {
+	logger *slog.Logger
+	sink   string
+}
+
+func NewDryRunPublisher(logger *slog.Logger, sink string) *DryRunPublisher {
+	return &DryRunPublisher{logger: logger, sink: sink}
+}
+
+func (p *DryRunPublisher) Publish(ctx context.Context, event cloudevents.Event) error {
+	attrs := []any{
+		"sink", p.sink,
+		"id", event.ID(),
+		"type", event.Type(),
+		"source", event.Source(),
+		"subject", event.Subject(),
+	}
+	if subject := subjectFromContext(ctx, ""); subject != "" {
+		attrs = append(attrs, "route_subject", subject)
+	}
+
+	p.logger.Info("Dry run: not publishing event", attrs...)
+	return nil
+}
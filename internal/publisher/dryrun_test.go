@@ -0,0 +1,34 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+func TestDryRunPublisher(t *testing.T) {
+
+	var out bytes.Buffer
+	p := NewDryRunPublisher(slog.New(slog.NewJSONHandler(&out, nil)), "jetstream")
+
+	event := cloudevents.NewEvent()
+	event.SetID("1")
+	event.SetSource("test")
+	event.SetType("dev.cdevents.change.merged.0.2.0")
+
+	require.NoError(t, p.Publish(WithSubject(context.Background(), "events.changes"), event))
+
+	var logged map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &logged))
+	assert.Equal(t, "jetstream", logged["sink"])
+	assert.Equal(t, "1", logged["id"])
+	assert.Equal(t, "dev.cdevents.change.merged.0.2.0", logged["type"])
+	assert.Equal(t, "events.changes", logged["route_subject"])
+}
@@ -0,0 +1,220 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+)
+
+const (
+	eventGridAPIVersion = "2018-01-01"
+	eventGridResource   = "https://eventgrid.azure.net"
+	azureIMDSEndpoint   = "http://169.254.169.254/metadata/identity/oauth2/token"
+)
+
+type EventGridConfig struct {
+	Endpoint                string        `envconfig:"ENDPOINT"`
+	SASKey                  string        `envconfig:"SAS_KEY" secret:"true"`
+	ManagedIdentity         bool          `envconfig:"MANAGED_IDENTITY" default:"false"`
+	ManagedIdentityClientID string        `envconfig:"MANAGED_IDENTITY_CLIENT_ID"`
+	Timeout                 time.Duration `envconfig:"TIMEOUT" default:"10s"`
+	MaxRetries              int           `envconfig:"MAX_RETRIES" default:"3"`
+	RetryDelay              time.Duration `envconfig:"RETRY_DELAY" default:"500ms"`
+}
+
+// EventGridPublisher sends events in structured mode to the CloudEvents schema endpoint of an
+// Azure Event Grid topic, authenticating with either a SAS key or a managed identity token.
+type EventGridPublisher struct {
+	client cloudevents.Client
+	config EventGridConfig
+}
+
+func NewEventGridPublisher(config EventGridConfig) (*EventGridPublisher, error) {
+	target, err := eventGridTarget(config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var transport http.RoundTripper
+	switch {
+	case config.SASKey != "" && config.ManagedIdentity:
+		return nil, fmt.Errorf("only one of SAS key and managed identity can be configured for Event Grid publisher")
+	case config.SASKey != "":
+		transport = &headerTransport{header: "aeg-sas-key", value: config.SASKey, next: http.DefaultTransport}
+	case config.ManagedIdentity:
+		transport = &bearerTransport{tokens: newManagedIdentityTokenSource(config.ManagedIdentityClientID), next: http.DefaultTransport}
+	default:
+		return nil, fmt.Errorf("no SAS key or managed identity configured for Event Grid publisher")
+	}
+
+	client, err := cloudevents.NewClientHTTP(cehttp.WithTarget(target), cehttp.WithRoundTripper(transport))
+	if err != nil {
+		return nil, err
+	}
+
+	return &EventGridPublisher{client: client, config: config}, nil
+}
+
+func (p *EventGridPublisher) Publish(ctx context.Context, event cloudevents.Event) error {
+	if p.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.config.Timeout)
+		defer cancel()
+	}
+
+	ctx = cloudevents.WithEncodingStructured(ctx)
+
+	if p.config.MaxRetries > 0 {
+		ctx = cloudevents.ContextWithRetriesExponentialBackoff(ctx, p.config.RetryDelay, p.config.MaxRetries)
+	}
+
+	if result := p.client.Send(ctx, event); !cloudevents.IsACK(result) {
+		return fmt.Errorf("failed to send event to Event Grid: %w", result)
+	}
+
+	return nil
+}
+
+func eventGridTarget(endpoint string) (string, error) {
+	if endpoint == "" {
+		return "", fmt.Errorf("no endpoint configured for Event Grid publisher")
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid Event Grid endpoint: %w", err)
+	}
+
+	query := u.Query()
+	if query.Get("api-version") == "" {
+		query.Set("api-version", eventGridAPIVersion)
+		u.RawQuery = query.Encode()
+	}
+
+	return u.String(), nil
+}
+
+type headerTransport struct {
+	header string
+	value  string
+	next   http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(t.header, t.value)
+	return t.next.RoundTrip(req)
+}
+
+type tokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+type bearerTransport struct {
+	tokens tokenSource
+	next   http.RoundTripper
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := t.tokens.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	return t.next.RoundTrip(req)
+}
+
+// managedIdentityTokenSource fetches and caches access tokens for Event Grid from the managed
+// identity endpoint, which is either the App Service/Container Apps identity endpoint when
+// available or the instance metadata service on VMs and AKS nodes.
+type managedIdentityTokenSource struct {
+	clientID  string
+	endpoint  string
+	header    string
+	client    *http.Client
+	mu        sync.Mutex
+	token     string
+	expiresOn time.Time
+}
+
+func newManagedIdentityTokenSource(clientID string) *managedIdentityTokenSource {
+	s := &managedIdentityTokenSource{
+		clientID: clientID,
+		endpoint: azureIMDSEndpoint,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+
+	if endpoint := os.Getenv("IDENTITY_ENDPOINT"); endpoint != "" {
+		s.endpoint = endpoint
+		s.header = os.Getenv("IDENTITY_HEADER")
+	}
+
+	return s
+}
+
+func (s *managedIdentityTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Now().Add(5*time.Minute).Before(s.expiresOn) {
+		return s.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+
+	query := req.URL.Query()
+	query.Set("resource", eventGridResource)
+	if s.clientID != "" {
+		query.Set("client_id", s.clientID)
+	}
+	if s.header != "" {
+		query.Set("api-version", "2019-08-01")
+		req.Header.Set("X-IDENTITY-HEADER", s.header)
+	} else {
+		query.Set("api-version", "2018-02-01")
+		req.Header.Set("Metadata", "true")
+	}
+	req.URL.RawQuery = query.Encode()
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to request managed identity token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to request managed identity token: %s", resp.Status)
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresOn   string `json:"expires_on"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode managed identity token: %w", err)
+	}
+
+	expiresOn, err := strconv.ParseInt(body.ExpiresOn, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid managed identity token expiry: %w", err)
+	}
+
+	s.token = body.AccessToken
+	s.expiresOn = time.Unix(expiresOn, 0)
+
+	return s.token, nil
+}
@@ -0,0 +1,92 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventGridPublisherSASKey(t *testing.T) {
+	var received *http.Request
+	var body []byte
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	p, err := NewEventGridPublisher(EventGridConfig{Endpoint: server.URL + "/api/events", SASKey: "secret"})
+	require.NoError(t, err, "publisher should be created")
+
+	event := newTestCloudEvent(t)
+	require.NoError(t, p.Publish(context.Background(), event), "publish should not return error")
+
+	assert.Equal(t, "secret", received.Header.Get("aeg-sas-key"))
+	assert.Equal(t, eventGridAPIVersion, received.URL.Query().Get("api-version"))
+	assert.Contains(t, received.Header.Get("Content-Type"), "application/cloudevents+json")
+
+	decoded := cloudevents.NewEvent()
+	require.NoError(t, json.Unmarshal(body, &decoded), "body must be a structured CloudEvent")
+	assert.Equal(t, event.ID(), decoded.ID())
+}
+
+func TestNewEventGridPublisherValidation(t *testing.T) {
+
+	for _, tc := range []struct {
+		title  string
+		config EventGridConfig
+	}{
+		{
+			title:  "no endpoint",
+			config: EventGridConfig{SASKey: "secret"},
+		},
+		{
+			title:  "no auth",
+			config: EventGridConfig{Endpoint: "https://topic.westeurope-1.eventgrid.azure.net/api/events"},
+		},
+		{
+			title:  "both SAS key and managed identity",
+			config: EventGridConfig{Endpoint: "https://topic.westeurope-1.eventgrid.azure.net/api/events", SASKey: "secret", ManagedIdentity: true},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			_, err := NewEventGridPublisher(tc.config)
+			require.Error(t, err, "publisher should not be created")
+		})
+	}
+}
+
+func TestManagedIdentityTokenSource(t *testing.T) {
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "true", r.Header.Get("Metadata"))
+		assert.Equal(t, eventGridResource, r.URL.Query().Get("resource"))
+		assert.Equal(t, "client-id", r.URL.Query().Get("client_id"))
+		fmt.Fprintf(w, `{"access_token": "token-%d", "expires_on": "%d"}`, requests, time.Now().Add(time.Hour).Unix())
+	}))
+	defer server.Close()
+
+	s := &managedIdentityTokenSource{clientID: "client-id", endpoint: server.URL, client: server.Client()}
+
+	token, err := s.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	token, err = s.Token(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token, "token should be cached until it expires")
+	assert.Equal(t, 1, requests)
+}
@@ -0,0 +1,415 @@
+package publisher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/correlation"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/delivery"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/lru"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/metrics"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/tracing"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// ErrQueueFull is returned, wrapped with the sink and event, when an event is not queued for a
+// sink because its queue is full.
+var ErrQueueFull = errors.New("sink queue is full")
+
+// maxRedeliveries is the number of events that failed to publish to some sink for which the sinks
+// that they were published to are remembered.
+const maxRedeliveries = 10000
+
+type Sink struct {
+	Name      string
+	Publisher Publisher
+	Filter    Filter
+}
+
+type SinkStats struct {
+	Delivered   uint64     `json:"delivered"`
+	Failed      uint64     `json:"failed"`
+	Dropped     uint64     `json:"dropped"`
+	Filtered    uint64     `json:"filtered"`
+	Failing     bool       `json:"failing"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+type sinkDelivery struct {
+	event         cloudevents.Event
+	subject       string
+	span          trace.SpanContext
+	correlationID string
+}
+
+// queuedDelivery is an event in the queue of a sink, whose outcome is sent to result once it has
+// been published.
+type queuedDelivery struct {
+	sinkDelivery
+	worker *sinkWorker
+	result chan error
+}
+
+type sinkWorker struct {
+	Sink
+	filter    atomic.Pointer[Filter]
+	sync      bool
+	async     bool
+	queue     chan queuedDelivery
+	delivered atomic.Uint64
+	failed    atomic.Uint64
+	dropped   atomic.Uint64
+	filtered  atomic.Uint64
+
+	mu          sync.Mutex
+	failing     bool
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// recordOutcome keeps track of whether the latest delivery to the sink failed, and of the most
+// recent error.
+func (w *sinkWorker) recordOutcome(err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.failing = err != nil
+	if err != nil {
+		w.lastError = err.Error()
+		w.lastErrorAt = time.Now()
+	}
+}
+
+// FanOut publishes every event to all sinks whose filter matches it. Each sink has its own
+// bounded queue and delivery goroutine, so a slow or failing sink does not hold back the others.
+// Publishing waits for the event to be delivered from the queues, and fails for a sink whose
+// queue is full. Sinks with a SyncPublisher that reports itself as synchronous are instead
+// published to directly from Publish, and sinks with an AsyncPublisher that reports itself as
+// asynchronous are published to directly without waiting for the acknowledgement when using
+// PublishAsync.
+//
+// An event published with a delivery key, see delivery.WithKey, that fails to publish to some
+// sinks is published only to those sinks when it is published again with the same key, so that
+// redelivering the webhook message does not duplicate the event in the sinks that already have it.
+// Every failure is returned wrapped in an adapter.SinkError and is not reported by the FanOut.
+type FanOut struct {
+	logger  *slog.Logger
+	workers []*sinkWorker
+	routes  atomic.Pointer[[]Route]
+	wg      sync.WaitGroup
+
+	// redeliveries remembers, by delivery key, the sinks that an event which failed to publish to
+	// other sinks was published to, so that those sinks are skipped when the webhook message is
+	// redelivered.
+	redeliveries *lru.Cache[string, []string]
+}
+
+func NewFanOut(logger *slog.Logger, queueSize int, sinks ...Sink) *FanOut {
+	f := &FanOut{logger: logger, redeliveries: lru.New[string, []string](maxRedeliveries)}
+
+	for _, sink := range sinks {
+		w := &sinkWorker{Sink: sink}
+		w.filter.Store(&sink.Filter)
+		f.workers = append(f.workers, w)
+
+		if p, ok := sink.Publisher.(SyncPublisher); ok && p.Synchronous() {
+			w.sync = true
+			continue
+		}
+
+		if p, ok := sink.Publisher.(AsyncPublisher); ok && p.Asynchronous() {
+			w.async = true
+			continue
+		}
+
+		w.queue = make(chan queuedDelivery, queueSize)
+
+		f.wg.Add(1)
+		go func() {
+			defer f.wg.Done()
+			f.deliver(w)
+		}()
+	}
+
+	return f
+}
+
+// SetRoutes sets the routing rules that are evaluated, in order, for every event before the
+// sink filters. Events that match no route are published to all sinks. It is safe to call while
+// events are being published.
+func (f *FanOut) SetRoutes(routes ...Route) {
+	f.routes.Store(&routes)
+}
+
+// SetFilters replaces the filters of the named sinks. Sinks not in filters keep their current
+// filter. It is safe to call while events are being published.
+func (f *FanOut) SetFilters(filters map[string]Filter) {
+	for _, w := range f.workers {
+		if filter, ok := filters[w.Name]; ok {
+			w.filter.Store(&filter)
+		}
+	}
+}
+
+// pendingAck is an asynchronous publish to a sink that has not been acknowledged yet.
+type pendingAck struct {
+	worker *sinkWorker
+	event  cloudevents.Event
+	span   trace.Span
+	result <-chan error
+}
+
+func (f *FanOut) Publish(ctx context.Context, event cloudevents.Event) error {
+	pending, err := f.PublishAsync(ctx, event)
+	if pending == nil {
+		return err
+	}
+	return <-pending
+}
+
+// PublishAsync publishes an event like Publish, but does not wait for queued sinks to deliver it
+// or for asynchronous sinks to acknowledge it. The returned channel receives the outcome for all
+// sinks once every queued sink has delivered the event and every asynchronous sink has
+// acknowledged it. It is nil when there is no sink to wait for, in which case the outcome is
+// returned directly.
+func (f *FanOut) PublishAsync(ctx context.Context, event cloudevents.Event) (<-chan error, error) {
+	var errs []error
+	var acks []pendingAck
+	var queued []queuedDelivery
+
+	key := delivery.FromContext(ctx)
+	var published []string
+	if key != "" {
+		published, _ = f.redeliveries.Get(key)
+	}
+
+	var route *Route
+	if routes := f.routes.Load(); routes != nil {
+		route = matchRoute(*routes, event)
+	}
+	d := sinkDelivery{event: event, span: trace.SpanContextFromContext(ctx), correlationID: correlation.FromContext(ctx)}
+	if route != nil {
+		d.subject = route.Subject
+		metrics.RoutedEvents.WithLabelValues(route.Name).Inc()
+	}
+
+	for _, w := range f.workers {
+		if (route != nil && !route.includes(w.Name)) || !w.filter.Load().Matches(event) {
+			w.filtered.Add(1)
+			metrics.SinkEvents.WithLabelValues(w.Name, "filtered").Inc()
+			continue
+		}
+
+		if slices.Contains(published, w.Name) {
+			f.logger.Debug("Skipping sink that the redelivered event was already published to",
+				"sink", w.Name,
+				"id", event.ID(),
+				"type", event.Type(),
+				"correlation_id", d.correlationID)
+			continue
+		}
+
+		if w.sync {
+			if err := f.publish(w, d); err != nil {
+				errs = append(errs, sinkError(w, event, err))
+				continue
+			}
+			published = append(published, w.Name)
+			continue
+		}
+
+		if w.async {
+			ack, err := f.publishAsync(w, d)
+			if err != nil {
+				errs = append(errs, sinkError(w, event, err))
+				continue
+			}
+			acks = append(acks, ack)
+			continue
+		}
+
+		entry := queuedDelivery{sinkDelivery: d, worker: w, result: make(chan error, 1)}
+		select {
+		case w.queue <- entry:
+			metrics.SinkQueueLength.WithLabelValues(w.Name).Set(float64(len(w.queue)))
+			queued = append(queued, entry)
+		default:
+			w.dropped.Add(1)
+			metrics.SinkEvents.WithLabelValues(w.Name, "dropped").Inc()
+			errs = append(errs, sinkError(w, event, ErrQueueFull))
+		}
+	}
+
+	if len(acks) == 0 && len(queued) == 0 {
+		return nil, f.settle(key, published, errs)
+	}
+
+	pending := make(chan error, 1)
+	go func() {
+		for _, d := range queued {
+			if err := <-d.result; err != nil {
+				errs = append(errs, sinkError(d.worker, event, err))
+				continue
+			}
+			published = append(published, d.worker.Name)
+		}
+		for _, ack := range acks {
+			if err := f.finish(ack.worker, ack.span, <-ack.result); err != nil {
+				errs = append(errs, sinkError(ack.worker, event, err))
+				continue
+			}
+			published = append(published, ack.worker.Name)
+		}
+		pending <- f.settle(key, published, errs)
+	}()
+
+	return pending, nil
+}
+
+// settle returns the outcome of publishing an event with a delivery key to all sinks, and
+// remembers the sinks it was published to if it failed for any other sink.
+func (f *FanOut) settle(key string, published []string, errs []error) error {
+	err := errors.Join(errs...)
+	if key == "" {
+		return err
+	}
+
+	if err != nil {
+		f.redeliveries.Add(key, published)
+	} else {
+		f.redeliveries.Remove(key)
+	}
+	return err
+}
+
+func sinkError(w *sinkWorker, event cloudevents.Event, err error) error {
+	return &adapter.SinkError{Sink: w.Name, EventID: event.ID(), EventType: event.Type(), Err: err}
+}
+
+func (f *FanOut) deliver(w *sinkWorker) {
+	for d := range w.queue {
+		metrics.SinkQueueLength.WithLabelValues(w.Name).Set(float64(len(w.queue)))
+
+		err := f.publish(w, d.sinkDelivery)
+		if err != nil {
+			f.logger.Error("Failed to publish event to sink",
+				"sink", w.Name,
+				"id", d.event.ID(),
+				"type", d.event.Type(),
+				"correlation_id", d.correlationID,
+				"error", err.Error())
+		}
+		d.result <- err
+	}
+}
+
+func (f *FanOut) publish(w *sinkWorker, d sinkDelivery) error {
+	ctx, span := startPublish(w, d)
+	return f.finish(w, span, w.Publisher.Publish(ctx, d.event))
+}
+
+func (f *FanOut) publishAsync(w *sinkWorker, d sinkDelivery) (pendingAck, error) {
+	ctx, span := startPublish(w, d)
+	result, err := w.Publisher.(AsyncPublisher).PublishAsync(ctx, d.event)
+	if err != nil {
+		return pendingAck{}, f.finish(w, span, err)
+	}
+	return pendingAck{worker: w, event: d.event, span: span, result: result}, nil
+}
+
+func startPublish(w *sinkWorker, d sinkDelivery) (context.Context, trace.Span) {
+	ctx := correlation.WithID(trace.ContextWithSpanContext(context.Background(), d.span), d.correlationID)
+	if d.subject != "" {
+		ctx = WithSubject(ctx, d.subject)
+	}
+
+	return tracing.Tracer().Start(ctx, fmt.Sprintf("publish %s", w.Name), trace.WithSpanKind(trace.SpanKindProducer))
+}
+
+// finish records the outcome of publishing an event to a sink and ends the publish span.
+func (f *FanOut) finish(w *sinkWorker, span trace.Span, err error) error {
+	defer span.End()
+
+	w.recordOutcome(err)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "publish failed")
+		w.failed.Add(1)
+		metrics.SinkEvents.WithLabelValues(w.Name, "failed").Inc()
+		return err
+	}
+
+	w.delivered.Add(1)
+	metrics.SinkEvents.WithLabelValues(w.Name, "delivered").Inc()
+
+	return nil
+}
+
+func (f *FanOut) Stats() map[string]SinkStats {
+	stats := make(map[string]SinkStats, len(f.workers))
+	for _, w := range f.workers {
+		stat := SinkStats{
+			Delivered: w.delivered.Load(),
+			Failed:    w.failed.Load(),
+			Dropped:   w.dropped.Load(),
+			Filtered:  w.filtered.Load(),
+		}
+
+		w.mu.Lock()
+		stat.Failing = w.failing
+		stat.LastError = w.lastError
+		if !w.lastErrorAt.IsZero() {
+			lastErrorAt := w.lastErrorAt
+			stat.LastErrorAt = &lastErrorAt
+		}
+		w.mu.Unlock()
+
+		stats[w.Name] = stat
+	}
+	return stats
+}
+
+// Close stops accepting events, waits for queued events to be delivered and then closes the
+// underlying publishers. Publish must not be called after Close.
+func (f *FanOut) Close(ctx context.Context) error {
+	for _, w := range f.workers {
+		if w.queue != nil {
+			close(w.queue)
+		}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.wg.Wait()
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("timeout waiting for sink queues to drain: %w", ctx.Err())
+	}
+
+	var errs []error
+	for _, w := range f.workers {
+		if closer, ok := w.Publisher.(interface{ Close(context.Context) error }); ok {
+			if err := closer.Close(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("failed to close sink %s: %w", w.Name, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
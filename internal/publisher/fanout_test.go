@@ -0,0 +1,345 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/delivery"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockPublisher struct {
+	mock.Mock
+}
+
+func (m *MockPublisher) Publish(ctx context.Context, event cloudevents.Event) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
+func TestFanOut(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	event := newTestCloudEvent(t)
+
+	delivering := &MockPublisher{}
+	delivering.On("Publish", event).Return(nil)
+
+	failing := &MockPublisher{}
+	failing.On("Publish", event).Return(fmt.Errorf("unavailable"))
+
+	filtered := &MockPublisher{}
+
+	fanOut := NewFanOut(logger, 10,
+		Sink{Name: "delivering", Publisher: delivering},
+		Sink{Name: "failing", Publisher: failing},
+		Sink{Name: "filtered", Publisher: filtered, Filter: Filter{Types: []string{"dev.cdevents.incident.*"}}},
+	)
+
+	for i := 0; i < 2; i++ {
+		err := fanOut.Publish(context.Background(), event)
+		require.Error(t, err, "publish should wait for queued sinks and return their errors")
+		assert.Contains(t, err.Error(), "to sink failing: unavailable")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, fanOut.Close(ctx), "close should not return error")
+
+	delivering.AssertNumberOfCalls(t, "Publish", 2)
+	failing.AssertNumberOfCalls(t, "Publish", 2)
+	filtered.AssertNotCalled(t, "Publish", mock.Anything)
+
+	stats := fanOut.Stats()
+	require.NotNil(t, stats["failing"].LastErrorAt, "time of last error should be set")
+	failingStats := stats["failing"]
+	failingStats.LastErrorAt = nil
+	stats["failing"] = failingStats
+
+	assert.Equal(t, map[string]SinkStats{
+		"delivering": {Delivered: 2},
+		"failing":    {Failed: 2, Failing: true, LastError: "unavailable"},
+		"filtered":   {Filtered: 2},
+	}, stats)
+}
+
+func TestFanOutRedelivery(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	event := newTestCloudEvent(t)
+
+	delivering := &MockPublisher{}
+	delivering.On("Publish", event).Return(nil)
+
+	recovering := &MockPublisher{}
+	recovering.On("Publish", event).Return(fmt.Errorf("unavailable")).Once()
+	recovering.On("Publish", event).Return(nil)
+
+	fanOut := NewFanOut(logger, 10,
+		Sink{Name: "delivering", Publisher: delivering},
+		Sink{Name: "recovering", Publisher: recovering},
+	)
+
+	ctx := delivery.WithKey(context.Background(), "webhooks/42/0")
+
+	err := fanOut.Publish(ctx, event)
+	var sinkErr *adapter.SinkError
+	require.ErrorAs(t, err, &sinkErr)
+	assert.Equal(t, "recovering", sinkErr.Sink)
+	assert.Equal(t, event.ID(), sinkErr.EventID)
+
+	require.NoError(t, fanOut.Publish(ctx, event), "redelivered event should be published to the failed sink")
+	require.NoError(t, fanOut.Publish(ctx, event), "event should be published to all sinks once it was published to every sink")
+
+	closeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, fanOut.Close(closeCtx))
+
+	delivering.AssertNumberOfCalls(t, "Publish", 2)
+	recovering.AssertNumberOfCalls(t, "Publish", 3)
+}
+
+func TestFanOutSlowSinkDoesNotBlockOthers(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	event := newTestCloudEvent(t)
+
+	started := make(chan struct{}, 3)
+	blocked := make(chan struct{})
+	slow := &MockPublisher{}
+	slow.On("Publish", event).Run(func(args mock.Arguments) {
+		started <- struct{}{}
+		<-blocked
+	}).Return(nil)
+
+	delivered := make(chan struct{}, 3)
+	fast := &MockPublisher{}
+	fast.On("Publish", event).Run(func(args mock.Arguments) { delivered <- struct{}{} }).Return(nil)
+
+	fanOut := NewFanOut(logger, 1,
+		Sink{Name: "slow", Publisher: slow},
+		Sink{Name: "fast", Publisher: fast},
+	)
+
+	first, err := fanOut.PublishAsync(context.Background(), event)
+	require.NoError(t, err)
+	<-started
+	<-delivered
+	second, err := fanOut.PublishAsync(context.Background(), event)
+	require.NoError(t, err)
+	<-delivered
+
+	third, err := fanOut.PublishAsync(context.Background(), event)
+	require.NoError(t, err)
+
+	select {
+	case <-delivered:
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "fast sink should receive event while slow sink is blocked")
+	}
+
+	select {
+	case err := <-third:
+		require.Error(t, err, "publish should fail for full sink queue")
+		assert.ErrorIs(t, err, ErrQueueFull)
+		assert.Contains(t, err.Error(), "sink slow")
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "publish to full sink queue should fail without waiting for the slow sink")
+	}
+
+	select {
+	case <-first:
+		require.Fail(t, "publish should be pending until the slow sink has delivered the event")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(blocked)
+
+	require.NoError(t, <-first, "publish should complete once delivered to the slow sink")
+	require.NoError(t, <-second, "publish should complete once delivered to the slow sink")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, fanOut.Close(ctx))
+
+	assert.Equal(t, uint64(3), fanOut.Stats()["fast"].Delivered)
+	assert.Equal(t, uint64(1), fanOut.Stats()["slow"].Dropped)
+}
+
+type subjectRecorder struct {
+	subjects []string
+}
+
+func (r *subjectRecorder) Publish(ctx context.Context, event cloudevents.Event) error {
+	r.subjects = append(r.subjects, subjectFromContext(ctx, event.Type()))
+	return nil
+}
+
+func TestFanOutRoutes(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	merged := newTestCloudEvent(t)
+
+	incident := newTestCloudEvent(t)
+	incident.SetType("dev.cdevents.incident.detected.0.2.0")
+
+	jetstream := &subjectRecorder{}
+	alerts := &subjectRecorder{}
+	audit := &subjectRecorder{}
+
+	fanOut := NewFanOut(logger, 10,
+		Sink{Name: "jetstream", Publisher: jetstream},
+		Sink{Name: "webhook:alerts", Publisher: alerts},
+		Sink{Name: "webhook:audit", Publisher: audit},
+	)
+	fanOut.SetRoutes(
+		Route{Name: "incidents", Types: []string{"dev.cdevents.incident.*"}, Sinks: []string{"jetstream", "webhook:alerts"}, Subject: "alerts.incidents"},
+		Route{Name: "changes", Types: []string{"dev.cdevents.change.*"}, Sinks: []string{"jetstream", "webhook:*"}},
+	)
+
+	require.NoError(t, fanOut.Publish(context.Background(), incident))
+	require.NoError(t, fanOut.Publish(context.Background(), merged))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, fanOut.Close(ctx))
+
+	assert.Equal(t, []string{"alerts.incidents", merged.Type()}, jetstream.subjects)
+	assert.Equal(t, []string{"alerts.incidents", merged.Type()}, alerts.subjects)
+	assert.Equal(t, []string{merged.Type()}, audit.subjects)
+	assert.Equal(t, uint64(1), fanOut.Stats()["webhook:audit"].Filtered)
+}
+
+func TestFanOutSetFilters(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	event := newTestCloudEvent(t)
+
+	recorder := &subjectRecorder{}
+
+	fanOut := NewFanOut(logger, 10, Sink{Name: "jetstream", Publisher: recorder})
+
+	fanOut.SetFilters(map[string]Filter{"jetstream": {Types: []string{"dev.cdevents.incident.*"}}})
+	require.NoError(t, fanOut.Publish(context.Background(), event))
+
+	fanOut.SetFilters(map[string]Filter{"jetstream": {}})
+	require.NoError(t, fanOut.Publish(context.Background(), event))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, fanOut.Close(ctx))
+
+	assert.Equal(t, []string{event.Type()}, recorder.subjects)
+	assert.Equal(t, uint64(1), fanOut.Stats()["jetstream"].Filtered)
+}
+
+type syncPublisher struct {
+	MockPublisher
+}
+
+func (p *syncPublisher) Synchronous() bool {
+	return true
+}
+
+func TestFanOutSynchronousSink(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	event := newTestCloudEvent(t)
+
+	sync := &syncPublisher{}
+	sync.On("Publish", event).Return(fmt.Errorf("no reply")).Once()
+	sync.On("Publish", event).Return(nil)
+
+	fanOut := NewFanOut(logger, 10, Sink{Name: "nats", Publisher: sync})
+
+	err := fanOut.Publish(context.Background(), event)
+	require.Error(t, err, "publish should return error from synchronous sink")
+	assert.Contains(t, err.Error(), "no reply")
+
+	require.NoError(t, fanOut.Publish(context.Background(), event))
+	sync.AssertNumberOfCalls(t, "Publish", 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, fanOut.Close(ctx))
+
+	stats := fanOut.Stats()["nats"]
+	require.NotNil(t, stats.LastErrorAt, "time of last error should be set")
+	stats.LastErrorAt = nil
+	assert.Equal(t, SinkStats{Delivered: 1, Failed: 1, LastError: "no reply"}, stats, "sink should no longer be failing")
+}
+
+type asyncPublisher struct {
+	MockPublisher
+	acks chan error
+}
+
+func (p *asyncPublisher) Asynchronous() bool {
+	return true
+}
+
+func (p *asyncPublisher) PublishAsync(ctx context.Context, event cloudevents.Event) (<-chan error, error) {
+	p.Called(event)
+	return p.acks, nil
+}
+
+func TestFanOutAsynchronousSink(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	event := newTestCloudEvent(t)
+
+	async := &asyncPublisher{acks: make(chan error, 1)}
+	async.On("PublishAsync", event)
+
+	queued := &MockPublisher{}
+	queued.On("Publish", event).Return(nil)
+
+	fanOut := NewFanOut(logger, 10, Sink{Name: "jetstream", Publisher: async}, Sink{Name: "http", Publisher: queued})
+
+	pending, err := fanOut.PublishAsync(context.Background(), event)
+	require.NoError(t, err, "publish should not return error")
+	require.NotNil(t, pending, "publish to asynchronous sink should be pending")
+
+	select {
+	case <-pending:
+		t.Fatal("publish should be pending until acknowledged")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	async.acks <- fmt.Errorf("stream unavailable")
+
+	select {
+	case err := <-pending:
+		require.Error(t, err, "outcome should contain error from asynchronous sink")
+		assert.Contains(t, err.Error(), "stream unavailable")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for publish outcome")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, fanOut.Close(ctx))
+
+	async.AssertNumberOfCalls(t, "PublishAsync", 1)
+	queued.AssertNumberOfCalls(t, "Publish", 1)
+
+	stats := fanOut.Stats()["jetstream"]
+	stats.LastErrorAt = nil
+	assert.Equal(t, SinkStats{Failed: 1, Failing: true, LastError: "stream unavailable"}, stats)
+}
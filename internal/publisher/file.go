@@ -0,0 +1,171 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/bufpool"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// backupLayout is the layout of the timestamp suffix of rotated event files.
+const backupLayout = "20060102T150405.000000000"
+
+type FileConfig struct {
+	Path       string `envconfig:"PATH" default:"-"`
+	MaxSize    int64  `envconfig:"MAX_SIZE" default:"104857600"`
+	MaxBackups int    `envconfig:"MAX_BACKUPS" default:"5"`
+}
+
+// FilePublisher appends events as structured CloudEvents, one JSON document per line (NDJSON),
+// to a file or to stdout if the path is "-". When the file grows beyond the max size it is
+// rotated by renaming it with a timestamp suffix and only the newest backups are kept.
+type FilePublisher struct {
+	config FileConfig
+	mu     sync.Mutex
+	out    io.Writer
+	file   *os.File
+	size   int64
+}
+
+func NewFilePublisher(config FileConfig) (*FilePublisher, error) {
+	p := &FilePublisher{config: config}
+
+	if config.Path == "" || config.Path == "-" {
+		p.out = os.Stdout
+		return p, nil
+	}
+
+	if err := p.open(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *FilePublisher) Publish(ctx context.Context, event cloudevents.Event) error {
+	buf := bufpool.Get()
+	defer bufpool.Put(buf)
+
+	if err := json.NewEncoder(buf).Encode(event); err != nil {
+		return err
+	}
+	line := buf.Bytes()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.file != nil && p.config.MaxSize > 0 && p.size > 0 && p.size+int64(len(line)) > p.config.MaxSize {
+		if err := p.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := p.out.Write(line)
+	p.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+
+	return nil
+}
+
+func (p *FilePublisher) Close(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.file == nil {
+		return nil
+	}
+
+	err := p.file.Close()
+	p.file = nil
+
+	return err
+}
+
+func (p *FilePublisher) open() error {
+	file, err := os.OpenFile(p.config.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open event file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	p.file = file
+	p.out = file
+	p.size = info.Size()
+
+	return nil
+}
+
+// rotate renames the event file to a backup and opens a new one. If the file cannot be renamed,
+// it is opened again and the error is returned, so that the event is not written and rotation is
+// tried again with the next one.
+func (p *FilePublisher) rotate() error {
+	if err := p.file.Close(); err != nil {
+		return err
+	}
+	p.file = nil
+
+	backup := fmt.Sprintf("%s.%s", p.config.Path, time.Now().UTC().Format(backupLayout))
+	if err := os.Rename(p.config.Path, backup); err != nil {
+		if openErr := p.open(); openErr != nil {
+			return fmt.Errorf("failed to rotate event file: %w", errors.Join(err, openErr))
+		}
+		return fmt.Errorf("failed to rotate event file: %w", err)
+	}
+
+	if err := p.open(); err != nil {
+		return err
+	}
+
+	return p.removeOldBackups()
+}
+
+func (p *FilePublisher) removeOldBackups() error {
+	if p.config.MaxBackups <= 0 {
+		return nil
+	}
+
+	matches, err := filepath.Glob(p.config.Path + ".*")
+	if err != nil {
+		return err
+	}
+
+	// Only the backups of the sink are removed, not other files that share its name as prefix.
+	var backups []string
+	for _, match := range matches {
+		suffix := strings.TrimPrefix(match, p.config.Path+".")
+		if _, err := time.Parse(backupLayout, suffix); err == nil {
+			backups = append(backups, match)
+		}
+	}
+
+	if len(backups) <= p.config.MaxBackups {
+		return nil
+	}
+
+	sort.Strings(backups)
+	for _, backup := range backups[:len(backups)-p.config.MaxBackups] {
+		if err := os.Remove(backup); err != nil {
+			return fmt.Errorf("failed to remove old event file: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package publisher
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+
+	p, err := NewFilePublisher(FileConfig{Path: path})
+	require.NoError(t, err, "publisher should be created")
+
+	event := newTestCloudEvent(t)
+	require.NoError(t, p.Publish(context.Background(), event))
+	require.NoError(t, p.Publish(context.Background(), event))
+	require.NoError(t, p.Close(context.Background()))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		decoded := cloudevents.NewEvent()
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &decoded), "every line must be a structured CloudEvent")
+		assert.Equal(t, event.ID(), decoded.ID())
+		lines++
+	}
+	assert.Equal(t, 2, lines)
+}
+
+func TestFilePublisherRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.ndjson")
+
+	unrelated := []string{path + ".old", path + ".20240101T000000.000000000.gz"}
+	for _, name := range unrelated {
+		require.NoError(t, os.WriteFile(name, []byte("{}"), 0o644))
+	}
+
+	p, err := NewFilePublisher(FileConfig{Path: path, MaxSize: 1, MaxBackups: 2})
+	require.NoError(t, err, "publisher should be created")
+	defer p.Close(context.Background())
+
+	event := newTestCloudEvent(t)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, p.Publish(context.Background(), event))
+	}
+
+	backups, err := filepath.Glob(path + ".*")
+	require.NoError(t, err)
+	assert.Len(t, backups, 2+len(unrelated), "only max backups should be kept")
+	for _, name := range unrelated {
+		assert.FileExists(t, name, "files that are not backups should be kept")
+	}
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Size(), "current file should contain the latest event")
+}
@@ -0,0 +1,37 @@
+package publisher
+
+import (
+	"github.com/ansig/cdevents-jetstream-adapter/internal/glob"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// Filter selects events based on glob patterns matched against the CloudEvent type and
+// subject. An empty list of patterns matches everything. Events of self tests are left out
+// unless Selftest is set.
+type Filter struct {
+	Types    []string `envconfig:"TYPES"`
+	Subjects []string `envconfig:"SUBJECTS"`
+	Selftest bool     `envconfig:"SELFTEST"`
+}
+
+// Validate checks the type and subject patterns.
+func (f Filter) Validate() error {
+	if err := glob.Validate(f.Types); err != nil {
+		return err
+	}
+	return glob.Validate(f.Subjects)
+}
+
+func (f Filter) Matches(event cloudevents.Event) bool {
+	if _, selftest := event.Extensions()[adapter.SelftestExtension]; selftest && !f.Selftest {
+		return false
+	}
+	return matchesAny(f.Types, event.Type()) && matchesAny(f.Subjects, event.Subject())
+}
+
+// matchesAny reports whether the value matches any of the patterns, or there are no patterns.
+func matchesAny(patterns []string, value string) bool {
+	return len(patterns) == 0 || glob.MatchAny(patterns, value)
+}
@@ -0,0 +1,64 @@
+package publisher
+
+import (
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterMatches(t *testing.T) {
+
+	for _, tc := range []struct {
+		title    string
+		filter   Filter
+		expected bool
+	}{
+		{
+			title:    "empty filter matches everything",
+			filter:   Filter{},
+			expected: true,
+		},
+		{
+			title:    "matches type pattern",
+			filter:   Filter{Types: []string{"dev.cdevents.branch.*", "dev.cdevents.change.*"}},
+			expected: true,
+		},
+		{
+			title:    "does not match other type pattern",
+			filter:   Filter{Types: []string{"dev.cdevents.incident.*"}},
+			expected: false,
+		},
+		{
+			title:    "matches type and subject pattern",
+			filter:   Filter{Types: []string{"dev.cdevents.change.*"}, Subjects: []string{"9d7b*"}},
+			expected: true,
+		},
+		{
+			title:    "does not match when subject pattern differs",
+			filter:   Filter{Types: []string{"dev.cdevents.change.*"}, Subjects: []string{"pr-*"}},
+			expected: false,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.filter.Matches(newTestCloudEvent(t)))
+		})
+	}
+}
+
+func TestFilterValidate(t *testing.T) {
+
+	assert.NoError(t, Filter{Types: []string{"dev.cdevents.change.*"}, Subjects: []string{"pr-*"}}.Validate())
+	assert.ErrorContains(t, Filter{Types: []string{"dev.cdevents.[change"}}.Validate(), "invalid pattern dev.cdevents.[change")
+	assert.ErrorContains(t, Filter{Subjects: []string{"pr-["}}.Validate(), "invalid pattern pr-[")
+}
+
+func TestFilterSelftestEvents(t *testing.T) {
+
+	event := newTestCloudEvent(t)
+	event.SetExtension(adapter.SelftestExtension, true)
+
+	assert.False(t, Filter{}.Matches(event), "events of self tests should be left out by default")
+	assert.True(t, Filter{Selftest: true}.Matches(event))
+	assert.False(t, Filter{Selftest: true, Types: []string{"dev.cdevents.incident.*"}}.Matches(event))
+}
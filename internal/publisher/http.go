@@ -0,0 +1,80 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+)
+
+type HTTPConfig struct {
+	URL         string            `envconfig:"URL"`
+	Headers     map[string]string `envconfig:"HEADERS" secret:"true"`
+	BearerToken string            `envconfig:"BEARER_TOKEN" secret:"true"`
+	Structured  bool              `envconfig:"STRUCTURED" default:"false"`
+	Timeout     time.Duration     `envconfig:"TIMEOUT" default:"10s"`
+	MaxRetries  int               `envconfig:"MAX_RETRIES" default:"3"`
+	RetryDelay  time.Duration     `envconfig:"RETRY_DELAY" default:"500ms"`
+	Compress    bool              `envconfig:"COMPRESS" default:"false"`
+	CompressMin int               `envconfig:"COMPRESS_MIN_SIZE" default:"1024"`
+}
+
+// HTTPPublisher sends events as CloudEvents over HTTP to a single endpoint, retrying with
+// exponential backoff on transient failures. Request bodies can optionally be gzip compressed
+// with "Content-Encoding: gzip", which the receiving endpoint must support.
+type HTTPPublisher struct {
+	client cloudevents.Client
+	config HTTPConfig
+}
+
+func NewHTTPPublisher(config HTTPConfig) (*HTTPPublisher, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("no URL configured for HTTP publisher")
+	}
+
+	opts := []cehttp.Option{cehttp.WithTarget(config.URL)}
+	for k, v := range config.Headers {
+		opts = append(opts, cehttp.WithHeader(k, v))
+	}
+	if config.BearerToken != "" {
+		opts = append(opts, cehttp.WithHeader("Authorization", fmt.Sprintf("Bearer %s", config.BearerToken)))
+	}
+
+	if config.Compress {
+		opts = append(opts, cehttp.WithRoundTripper(&gzipTransport{minSize: config.CompressMin, next: http.DefaultTransport}))
+	}
+
+	client, err := cloudevents.NewClientHTTP(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HTTPPublisher{client: client, config: config}, nil
+}
+
+func (p *HTTPPublisher) Publish(ctx context.Context, event cloudevents.Event) error {
+	if p.config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.config.Timeout)
+		defer cancel()
+	}
+
+	if p.config.Structured {
+		ctx = cloudevents.WithEncodingStructured(ctx)
+	} else {
+		ctx = cloudevents.WithEncodingBinary(ctx)
+	}
+
+	if p.config.MaxRetries > 0 {
+		ctx = cloudevents.ContextWithRetriesExponentialBackoff(ctx, p.config.RetryDelay, p.config.MaxRetries)
+	}
+
+	if result := p.client.Send(ctx, event); !cloudevents.IsACK(result) {
+		return fmt.Errorf("failed to send event to %s: %w", p.config.URL, result)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package publisher
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCloudEvent(t *testing.T) cloudevents.Event {
+	event := cloudevents.NewEvent()
+	event.SetID("4bd3d5d6-0a9b-4d27-9c5b-a0c0ad0fba65")
+	event.SetSource("git.example.com")
+	event.SetSubject("9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2")
+	event.SetType("dev.cdevents.change.merged.0.2.0")
+	require.NoError(t, event.SetData(cloudevents.ApplicationJSON, map[string]string{"foo": "bar"}))
+	return event
+}
+
+func TestHTTPPublisher(t *testing.T) {
+
+	for _, tc := range []struct {
+		title               string
+		config              HTTPConfig
+		responseCodes       []int
+		expectedError       bool
+		expectedRequests    int32
+		expectedContentType string
+		expectedCEHeader    string
+		expectedAuth        string
+		expectedHeader      string
+	}{
+		{
+			title:               "sends binary mode CloudEvent",
+			responseCodes:       []int{http.StatusAccepted},
+			expectedRequests:    1,
+			expectedContentType: "application/json",
+			expectedCEHeader:    "dev.cdevents.change.merged.0.2.0",
+		},
+		{
+			title:               "sends structured mode CloudEvent",
+			config:              HTTPConfig{Structured: true},
+			responseCodes:       []int{http.StatusOK},
+			expectedRequests:    1,
+			expectedContentType: "application/cloudevents+json",
+		},
+		{
+			title: "sends bearer token and custom headers",
+			config: HTTPConfig{
+				BearerToken: "secret",
+				Headers:     map[string]string{"X-Tenant": "yoloco"},
+			},
+			responseCodes:       []int{http.StatusOK},
+			expectedRequests:    1,
+			expectedContentType: "application/json",
+			expectedCEHeader:    "dev.cdevents.change.merged.0.2.0",
+			expectedAuth:        "Bearer secret",
+			expectedHeader:      "yoloco",
+		},
+		{
+			title:               "retries on service unavailable",
+			config:              HTTPConfig{MaxRetries: 3, RetryDelay: time.Millisecond},
+			responseCodes:       []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+			expectedRequests:    3,
+			expectedContentType: "application/json",
+			expectedCEHeader:    "dev.cdevents.change.merged.0.2.0",
+		},
+		{
+			title:               "error on bad request",
+			responseCodes:       []int{http.StatusBadRequest},
+			expectedError:       true,
+			expectedRequests:    1,
+			expectedContentType: "application/json",
+			expectedCEHeader:    "dev.cdevents.change.merged.0.2.0",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			var requests atomic.Int32
+			var lastRequest *http.Request
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := requests.Add(1)
+				io.Copy(io.Discard, r.Body)
+				lastRequest = r
+				w.WriteHeader(tc.responseCodes[int(n)-1])
+			}))
+			defer server.Close()
+
+			config := tc.config
+			config.URL = server.URL
+
+			p, err := NewHTTPPublisher(config)
+			require.NoError(t, err, "unable to create publisher")
+
+			err = p.Publish(context.Background(), newTestCloudEvent(t))
+			if tc.expectedError {
+				require.Error(t, err, "publish should return error")
+			} else {
+				require.NoError(t, err, "publish should not return error")
+			}
+
+			assert.Equal(t, tc.expectedRequests, requests.Load(), "unexpected number of requests")
+			require.NotNil(t, lastRequest, "no request received")
+			assert.Equal(t, tc.expectedContentType, lastRequest.Header.Get("Content-Type"))
+			assert.Equal(t, tc.expectedCEHeader, lastRequest.Header.Get("Ce-Type"))
+			assert.Equal(t, tc.expectedAuth, lastRequest.Header.Get("Authorization"))
+			assert.Equal(t, tc.expectedHeader, lastRequest.Header.Get("X-Tenant"))
+		})
+	}
+}
+
+func TestNewHTTPPublisherRequiresURL(t *testing.T) {
+	_, err := NewHTTPPublisher(HTTPConfig{})
+	require.Error(t, err, "publisher without URL should not be created")
+}
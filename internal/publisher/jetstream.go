@@ -0,0 +1,179 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/bufpool"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	cejsm "github.com/cloudevents/sdk-go/protocol/nats_jetstream/v3"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+)
+
+type JetStreamConfig struct {
+	Structured      bool          `envconfig:"STRUCTURED" default:"false"`
+	SubjectTemplate string        `envconfig:"SUBJECT_TEMPLATE" default:"{{.Type}}"`
+	Async           bool          `envconfig:"ASYNC" default:"false"`
+	MaxPending      int           `envconfig:"MAX_PENDING" default:"256"`
+	AckTimeout      time.Duration `envconfig:"ACK_TIMEOUT" default:"10s"`
+}
+
+// AsyncJetStream publishes messages to JetStream without waiting for the publish ack.
+type AsyncJetStream interface {
+	PublishMsgAsync(msg *nats.Msg, opts ...jetstream.PublishOpt) (jetstream.PubAckFuture, error)
+}
+
+// CloudEventJetstreamPublisher publishes events to the event stream on a subject equal to the
+// event type, either in binary mode with the attributes as NATS headers (default) or in
+// structured mode with the whole CloudEvent as a JSON body. The subject can instead be rendered
+// from SubjectTemplate. In async mode events are published without waiting for the publish ack,
+// with at most MaxPending publishes in flight.
+type CloudEventJetstreamPublisher struct {
+	nc      *nats.Conn
+	js      AsyncJetStream
+	subject *eventTemplate
+	config  JetStreamConfig
+}
+
+func NewCloudEventJetstreamPublisher(nc *nats.Conn, config JetStreamConfig) (*CloudEventJetstreamPublisher, error) {
+	if config.SubjectTemplate == "" {
+		config.SubjectTemplate = "{{.Type}}"
+	}
+	if config.MaxPending <= 0 {
+		config.MaxPending = 256
+	}
+	if config.AckTimeout <= 0 {
+		config.AckTimeout = 10 * time.Second
+	}
+
+	subject, err := newEventTemplate("subject", config.SubjectTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subject template: %w", err)
+	}
+
+	p := &CloudEventJetstreamPublisher{nc: nc, subject: subject, config: config}
+
+	if config.Async {
+		js, err := jetstream.New(nc, jetstream.WithPublishAsyncMaxPending(config.MaxPending))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create JetStream instance: %w", err)
+		}
+		p.js = js
+	}
+
+	return p, nil
+}
+
+// Asynchronous reports whether events are published without waiting for the publish ack.
+func (p *CloudEventJetstreamPublisher) Asynchronous() bool {
+	return p.config.Async
+}
+
+func (p *CloudEventJetstreamPublisher) Publish(ctx context.Context, event cloudevents.Event) error {
+	if p.config.Async {
+		pending, err := p.PublishAsync(ctx, event)
+		if err != nil {
+			return err
+		}
+		return <-pending
+	}
+
+	subject, err := p.subject.Execute(event)
+	if err != nil {
+		return fmt.Errorf("failed to render subject: %w", err)
+	}
+
+	connOpt := cejsm.WithConnection(p.nc)
+	sendopt := cejsm.WithSendSubject(subjectFromContext(ctx, subject))
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	proto, err := cejsm.New(ctx, connOpt, sendopt)
+	if err != nil {
+		return err
+	}
+
+	if p.config.Structured {
+		ctx = cloudevents.WithEncodingStructured(ctx)
+	} else {
+		ctx = cloudevents.WithEncodingBinary(ctx)
+	}
+
+	client, err := cloudevents.NewClient(proto)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Send(ctx, event); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// PublishAsync publishes an event without waiting for the publish ack. The returned channel
+// receives the outcome once the ack has returned or the ack timeout has passed. Blocks for at
+// most the ack timeout while the maximum number of publishes are in flight.
+func (p *CloudEventJetstreamPublisher) PublishAsync(ctx context.Context, event cloudevents.Event) (<-chan error, error) {
+	if p.js == nil {
+		return nil, fmt.Errorf("async publishing is not enabled")
+	}
+
+	subject, err := p.subject.Execute(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render subject: %w", err)
+	}
+
+	if p.config.Structured {
+		ctx = cloudevents.WithEncodingStructured(ctx)
+	} else {
+		ctx = cloudevents.WithEncodingBinary(ctx)
+	}
+
+	// The message is kept for retries until the publish has been acknowledged, so the pooled
+	// buffer is only returned once the outcome is known.
+	data := bufpool.Get()
+	header, err := cejsm.WriteMsg(ctx, binding.ToMessage(&event), data)
+	if err != nil {
+		bufpool.Put(data)
+		return nil, err
+	}
+
+	msg := &nats.Msg{
+		Subject: subjectFromContext(ctx, subject),
+		Header:  header,
+		Data:    data.Bytes(),
+	}
+
+	future, err := p.js.PublishMsgAsync(msg, jetstream.WithStallWait(p.config.AckTimeout))
+	if err != nil {
+		bufpool.Put(data)
+		return nil, fmt.Errorf("failed to publish event %s: %w", event.ID(), err)
+	}
+
+	pending := make(chan error, 1)
+	go func() {
+		timeout := time.NewTimer(p.config.AckTimeout)
+		defer timeout.Stop()
+
+		select {
+		case <-future.Ok():
+			bufpool.Put(data)
+			pending <- nil
+		case err := <-future.Err():
+			bufpool.Put(data)
+			pending <- fmt.Errorf("failed to publish event %s: %w", event.ID(), err)
+		case <-timeout.C:
+			// The message may still be retried, so the buffer is left to the garbage collector.
+			pending <- fmt.Errorf("no publish ack received for event %s within %s", event.ID(), p.config.AckTimeout)
+		}
+	}()
+
+	return pending, nil
+}
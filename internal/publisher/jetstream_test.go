@@ -0,0 +1,87 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockPubAckFuture struct {
+	msg *nats.Msg
+	ok  chan *jetstream.PubAck
+	err chan error
+}
+
+func (f *mockPubAckFuture) Ok() <-chan *jetstream.PubAck { return f.ok }
+func (f *mockPubAckFuture) Err() <-chan error            { return f.err }
+func (f *mockPubAckFuture) Msg() *nats.Msg               { return f.msg }
+
+type mockAsyncJetStream struct {
+	futures []*mockPubAckFuture
+}
+
+func (m *mockAsyncJetStream) PublishMsgAsync(msg *nats.Msg, opts ...jetstream.PublishOpt) (jetstream.PubAckFuture, error) {
+	future := &mockPubAckFuture{msg: msg, ok: make(chan *jetstream.PubAck, 1), err: make(chan error, 1)}
+	m.futures = append(m.futures, future)
+	return future, nil
+}
+
+func TestCloudEventJetstreamPublisherAsync(t *testing.T) {
+
+	for _, tc := range []struct {
+		title         string
+		ack           func(future *mockPubAckFuture)
+		expectedError string
+	}{
+		{
+			title: "publish is acknowledged",
+			ack: func(future *mockPubAckFuture) {
+				future.ok <- &jetstream.PubAck{Stream: "events", Sequence: 1}
+			},
+		},
+		{
+			title: "publish fails",
+			ack: func(future *mockPubAckFuture) {
+				future.err <- fmt.Errorf("no responders")
+			},
+			expectedError: "no responders",
+		},
+		{
+			title:         "publish is not acknowledged in time",
+			ack:           func(future *mockPubAckFuture) {},
+			expectedError: "no publish ack received",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			js := &mockAsyncJetStream{}
+			p, err := NewCloudEventJetstreamPublisher(nil, JetStreamConfig{AckTimeout: 100 * time.Millisecond})
+			require.NoError(t, err)
+			p.config.Async = true
+			p.js = js
+
+			pending, err := p.PublishAsync(context.Background(), newTestCloudEvent(t))
+			require.NoError(t, err, "publish should not return error")
+			require.Len(t, js.futures, 1, "one message should be published")
+
+			msg := js.futures[0].msg
+			assert.Equal(t, "dev.cdevents.change.merged.0.2.0", msg.Subject)
+			assert.Equal(t, "dev.cdevents.change.merged.0.2.0", msg.Header.Get("ce-type"))
+
+			tc.ack(js.futures[0])
+
+			err = <-pending
+			if tc.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
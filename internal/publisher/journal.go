@@ -0,0 +1,155 @@
+package publisher
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+type JournalConfig struct {
+	Driver string `envconfig:"DRIVER" default:"sqlite"`
+	DSN    string `envconfig:"DSN" default:"cdevents-journal.db"`
+}
+
+// journalMigrations are applied in order on startup and recorded in the journal_migrations
+// table. New migrations must be appended, never changed. Statements use "{{json}}" for the
+// column type of the raw event, which differs between the supported databases.
+var journalMigrations = []string{
+	`CREATE TABLE IF NOT EXISTS cdevents (
+		id          TEXT PRIMARY KEY,
+		type        TEXT NOT NULL,
+		subject     TEXT NOT NULL,
+		source      TEXT NOT NULL,
+		timestamp   TIMESTAMP NOT NULL,
+		data        {{json}} NOT NULL,
+		recorded_at TIMESTAMP NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS cdevents_type_timestamp ON cdevents (type, timestamp)`,
+	`CREATE INDEX IF NOT EXISTS cdevents_subject ON cdevents (subject)`,
+}
+
+type journalDialect struct {
+	driver      string
+	jsonType    string
+	placeholder func(n int) string
+}
+
+var journalDialects = map[string]journalDialect{
+	"sqlite": {
+		driver:      "sqlite",
+		jsonType:    "TEXT",
+		placeholder: func(n int) string { return "?" },
+	},
+	"postgres": {
+		driver:      "pgx",
+		jsonType:    "JSONB",
+		placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+	},
+}
+
+// JournalPublisher records every event in a relational database (SQLite or PostgreSQL) so that
+// events can be queried for reporting. The schema is migrated automatically on startup and
+// events that have already been recorded are ignored.
+type JournalPublisher struct {
+	db      *sql.DB
+	dialect journalDialect
+	insert  string
+}
+
+func NewJournalPublisher(ctx context.Context, config JournalConfig) (*JournalPublisher, error) {
+	dialect, ok := journalDialects[strings.ToLower(config.Driver)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported journal driver: %s", config.Driver)
+	}
+
+	db, err := sql.Open(dialect.driver, config.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal database: %w", err)
+	}
+
+	p := &JournalPublisher{
+		db:      db,
+		dialect: dialect,
+		insert: fmt.Sprintf(
+			"INSERT INTO cdevents (id, type, subject, source, timestamp, data, recorded_at) VALUES (%s) ON CONFLICT (id) DO NOTHING",
+			placeholders(dialect, 7)),
+	}
+
+	if err := p.migrate(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (p *JournalPublisher) Publish(ctx context.Context, event cloudevents.Event) error {
+	_, err := p.db.ExecContext(ctx, p.insert,
+		event.ID(),
+		event.Type(),
+		event.Subject(),
+		event.Source(),
+		event.Time().UTC(),
+		string(event.Data()),
+		time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("failed to record event %s in journal: %w", event.ID(), err)
+	}
+
+	return nil
+}
+
+func (p *JournalPublisher) Close(ctx context.Context) error {
+	return p.db.Close()
+}
+
+func (p *JournalPublisher) migrate(ctx context.Context) error {
+	if _, err := p.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS journal_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("failed to create journal migrations table: %w", err)
+	}
+
+	var version int
+	if err := p.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM journal_migrations`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to read journal schema version: %w", err)
+	}
+
+	for i := version; i < len(journalMigrations); i++ {
+		tx, err := p.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		statement := strings.ReplaceAll(journalMigrations[i], "{{json}}", p.dialect.jsonType)
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply journal migration %d: %w", i+1, err)
+		}
+
+		record := fmt.Sprintf("INSERT INTO journal_migrations (version) VALUES (%s)", p.dialect.placeholder(1))
+		if _, err := tx.ExecContext(ctx, record, i+1); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record journal migration %d: %w", i+1, err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func placeholders(dialect journalDialect, n int) string {
+	p := make([]string, n)
+	for i := range p {
+		p[i] = dialect.placeholder(i + 1)
+	}
+	return strings.Join(p, ", ")
+}
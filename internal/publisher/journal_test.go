@@ -0,0 +1,46 @@
+package publisher
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournalPublisher(t *testing.T) {
+	dsn := filepath.Join(t.TempDir(), "journal.db")
+
+	p, err := NewJournalPublisher(context.Background(), JournalConfig{Driver: "sqlite", DSN: dsn})
+	require.NoError(t, err, "publisher should be created")
+
+	event := newTestCloudEvent(t)
+	event.SetTime(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	require.NoError(t, p.Publish(context.Background(), event))
+	require.NoError(t, p.Publish(context.Background(), event), "recording the same event twice should be ignored")
+	require.NoError(t, p.Close(context.Background()))
+
+	p, err = NewJournalPublisher(context.Background(), JournalConfig{Driver: "sqlite", DSN: dsn})
+	require.NoError(t, err, "migrations should not be applied twice")
+	defer p.Close(context.Background())
+
+	var count int
+	var eventType, subject, data string
+	require.NoError(t, p.db.QueryRow(`SELECT COUNT(*), MAX(type), MAX(subject), MAX(data) FROM cdevents`).Scan(&count, &eventType, &subject, &data))
+	assert.Equal(t, 1, count)
+	assert.Equal(t, event.Type(), eventType)
+	assert.Equal(t, event.Subject(), subject)
+	assert.JSONEq(t, string(event.Data()), data)
+
+	var version int
+	require.NoError(t, p.db.QueryRow(`SELECT MAX(version) FROM journal_migrations`).Scan(&version))
+	assert.Equal(t, len(journalMigrations), version)
+}
+
+func TestNewJournalPublisherUnsupportedDriver(t *testing.T) {
+	_, err := NewJournalPublisher(context.Background(), JournalConfig{Driver: "oracle"})
+	require.Error(t, err, "publisher with unsupported driver should not be created")
+}
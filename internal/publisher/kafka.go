@@ -0,0 +1,151 @@
+package publisher
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"strings"
+
+	"github.com/IBM/sarama"
+	"github.com/xdg-go/scram"
+
+	"github.com/cloudevents/sdk-go/protocol/kafka_sarama/v2"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+type KafkaConfig struct {
+	Brokers       []string                `envconfig:"BROKERS"`
+	Topic         string                  `envconfig:"TOPIC" default:"cdevents"`
+	ClientID      string                  `envconfig:"CLIENT_ID" default:"cdevents-adapter"`
+	Structured    bool                    `envconfig:"STRUCTURED" default:"false"`
+	Compression   sarama.CompressionCodec `envconfig:"COMPRESSION" default:"none"`
+	SASLMechanism string                  `envconfig:"SASL_MECHANISM"`
+	SASLUsername  string                  `envconfig:"SASL_USERNAME"`
+	SASLPassword  string                  `envconfig:"SASL_PASSWORD" secret:"true"`
+	TLS           TLSConfig               `envconfig:"TLS"`
+}
+
+// KafkaPublisher sends events as CloudEvents to a Kafka topic, using the CDEvent subject id as
+// message key so that events for the same subject end up on the same partition. Record batches
+// can be compressed (gzip, snappy, lz4 or zstd), which is transparent to consumers.
+type KafkaPublisher struct {
+	sender *kafka_sarama.Sender
+	client cloudevents.Client
+	config KafkaConfig
+}
+
+func NewKafkaPublisher(config KafkaConfig) (*KafkaPublisher, error) {
+	if len(config.Brokers) == 0 {
+		return nil, fmt.Errorf("no brokers configured for Kafka publisher")
+	}
+
+	saramaConfig, err := newSaramaConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	sender, err := kafka_sarama.NewSender(config.Brokers, saramaConfig, config.Topic)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := cloudevents.NewClient(sender, cloudevents.WithTimeNow(), cloudevents.WithUUIDs())
+	if err != nil {
+		sender.Close(context.Background())
+		return nil, err
+	}
+
+	return &KafkaPublisher{sender: sender, client: client, config: config}, nil
+}
+
+func newSaramaConfig(config KafkaConfig) (*sarama.Config, error) {
+	saramaConfig := sarama.NewConfig()
+	saramaConfig.Version = sarama.V2_0_0_0
+	if config.ClientID != "" {
+		saramaConfig.ClientID = config.ClientID
+	}
+	saramaConfig.Producer.RequiredAcks = sarama.WaitForAll
+	saramaConfig.Producer.Return.Successes = true
+	saramaConfig.Producer.Compression = config.Compression
+
+	tlsConfig, err := config.TLS.Build()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		saramaConfig.Net.TLS.Enable = true
+		saramaConfig.Net.TLS.Config = tlsConfig
+	}
+
+	if config.SASLMechanism == "" {
+		return saramaConfig, nil
+	}
+
+	saramaConfig.Net.SASL.Enable = true
+	saramaConfig.Net.SASL.User = config.SASLUsername
+	saramaConfig.Net.SASL.Password = config.SASLPassword
+
+	switch strings.ToUpper(config.SASLMechanism) {
+	case sarama.SASLTypePlaintext:
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypePlaintext
+	case sarama.SASLTypeSCRAMSHA256:
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+		saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{HashGeneratorFcn: sha256.New}
+		}
+	case sarama.SASLTypeSCRAMSHA512:
+		saramaConfig.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+		saramaConfig.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{HashGeneratorFcn: sha512.New}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported SASL mechanism: %s", config.SASLMechanism)
+	}
+
+	return saramaConfig, nil
+}
+
+func (p *KafkaPublisher) Publish(ctx context.Context, event cloudevents.Event) error {
+	ctx = kafka_sarama.WithMessageKey(ctx, sarama.StringEncoder(event.Subject()))
+
+	if p.config.Structured {
+		ctx = cloudevents.WithEncodingStructured(ctx)
+	} else {
+		ctx = cloudevents.WithEncodingBinary(ctx)
+	}
+
+	if result := p.client.Send(ctx, event); !cloudevents.IsACK(result) {
+		return fmt.Errorf("failed to send event to Kafka topic %s: %w", p.config.Topic, result)
+	}
+
+	return nil
+}
+
+func (p *KafkaPublisher) Close(ctx context.Context) error {
+	return p.sender.Close(ctx)
+}
+
+type scramClient struct {
+	*scram.Client
+	*scram.ClientConversation
+	scram.HashGeneratorFcn
+}
+
+func (c *scramClient) Begin(userName, password, authzID string) error {
+	client, err := c.HashGeneratorFcn.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	c.Client = client
+	c.ClientConversation = client.NewConversation()
+	return nil
+}
+
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.ClientConversation.Step(challenge)
+}
+
+func (c *scramClient) Done() bool {
+	return c.ClientConversation.Done()
+}
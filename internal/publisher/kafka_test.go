@@ -0,0 +1,92 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+	"github.com/cloudevents/sdk-go/protocol/kafka_sarama/v2"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKafkaPublisherUsesSubjectAsKey(t *testing.T) {
+
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageWithMessageCheckerFunctionAndSucceed(func(msg *sarama.ProducerMessage) error {
+		key, err := msg.Key.Encode()
+		if err != nil {
+			return err
+		}
+		if string(key) != "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2" {
+			return fmt.Errorf("unexpected message key: %s", key)
+		}
+		if msg.Topic != "cdevents" {
+			return fmt.Errorf("unexpected topic: %s", msg.Topic)
+		}
+		return nil
+	})
+
+	sender, err := kafka_sarama.NewSenderFromSyncProducer("cdevents", producer)
+	require.NoError(t, err, "unable to create sender")
+
+	client, err := cloudevents.NewClient(sender)
+	require.NoError(t, err, "unable to create client")
+
+	p := &KafkaPublisher{sender: sender, client: client, config: KafkaConfig{Topic: "cdevents"}}
+
+	require.NoError(t, p.Publish(context.Background(), newTestCloudEvent(t)), "publish should not return error")
+	require.NoError(t, p.Close(context.Background()))
+}
+
+func TestNewSaramaConfig(t *testing.T) {
+
+	for _, tc := range []struct {
+		title             string
+		config            KafkaConfig
+		expectedError     bool
+		expectedMechanism sarama.SASLMechanism
+	}{
+		{
+			title: "no SASL by default",
+		},
+		{
+			title:             "plain SASL",
+			config:            KafkaConfig{SASLMechanism: "plain", SASLUsername: "user", SASLPassword: "pass"},
+			expectedMechanism: sarama.SASLTypePlaintext,
+		},
+		{
+			title:             "SCRAM-SHA-512 SASL",
+			config:            KafkaConfig{SASLMechanism: "SCRAM-SHA-512", SASLUsername: "user", SASLPassword: "pass"},
+			expectedMechanism: sarama.SASLTypeSCRAMSHA512,
+		},
+		{
+			title:  "gzip compression",
+			config: KafkaConfig{Compression: sarama.CompressionGZIP},
+		},
+		{
+			title:         "error on unknown SASL mechanism",
+			config:        KafkaConfig{SASLMechanism: "GSSAPI"},
+			expectedError: true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			saramaConfig, err := newSaramaConfig(tc.config)
+			if tc.expectedError {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.config.Compression, saramaConfig.Producer.Compression)
+			assert.Equal(t, tc.expectedMechanism != "", saramaConfig.Net.SASL.Enable)
+			if tc.expectedMechanism != "" {
+				assert.Equal(t, tc.expectedMechanism, saramaConfig.Net.SASL.Mechanism)
+				require.NoError(t, saramaConfig.Validate(), "sarama config should be valid")
+			}
+		})
+	}
+}
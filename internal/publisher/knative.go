@@ -0,0 +1,143 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	cehttp "github.com/cloudevents/sdk-go/v2/protocol/http"
+)
+
+type KnativeConfig struct {
+	URL           string        `envconfig:"URL"`
+	Structured    bool          `envconfig:"STRUCTURED" default:"false"`
+	Timeout       time.Duration `envconfig:"TIMEOUT" default:"10s"`
+	MaxRetries    int           `envconfig:"MAX_RETRIES" default:"5"`
+	RetryDelay    time.Duration `envconfig:"RETRY_DELAY" default:"500ms"`
+	MaxRetryDelay time.Duration `envconfig:"MAX_RETRY_DELAY" default:"30s"`
+}
+
+// KnativePublisher sends events over the CloudEvents HTTP protocol to a Knative Eventing Broker
+// or Channel. Failed deliveries are retried with exponential backoff, like by the HTTP sink, on
+// the status codes that Knative uses for transient errors. A Retry-After header on 429 and 503
+// responses holds back the following requests to the broker until the requested time, at most
+// MaxRetryDelay.
+type KnativePublisher struct {
+	client cloudevents.Client
+	config KnativeConfig
+}
+
+func NewKnativePublisher(config KnativeConfig) (*KnativePublisher, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("no URL configured for Knative publisher")
+	}
+
+	client, err := cloudevents.NewClientHTTP(
+		cehttp.WithTarget(config.URL),
+		cehttp.WithClient(http.Client{
+			Timeout:   config.Timeout,
+			Transport: &retryAfterTransport{next: http.DefaultTransport, maxDelay: config.MaxRetryDelay},
+		}),
+		cehttp.WithIsRetriableFunc(knativeRetriable),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KnativePublisher{client: client, config: config}, nil
+}
+
+func (p *KnativePublisher) Publish(ctx context.Context, event cloudevents.Event) error {
+	if p.config.Structured {
+		ctx = cloudevents.WithEncodingStructured(ctx)
+	} else {
+		ctx = cloudevents.WithEncodingBinary(ctx)
+	}
+
+	if p.config.MaxRetries > 0 {
+		ctx = cloudevents.ContextWithRetriesExponentialBackoff(ctx, p.config.RetryDelay, p.config.MaxRetries)
+	}
+
+	if result := p.client.Send(ctx, event); !cloudevents.IsACK(result) {
+		return fmt.Errorf("failed to send event to %s: %w", p.config.URL, result)
+	}
+
+	return nil
+}
+
+// knativeRetriable reports whether a delivery that failed with the status code is retried: on
+// throttling, timeouts and server errors, and on 404 and 409 while a broker or channel is not yet
+// ready.
+func knativeRetriable(statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusNotFound, http.StatusRequestTimeout, http.StatusConflict:
+		return true
+	}
+	return statusCode >= 500
+}
+
+// retryAfterTransport holds back requests until the time requested by the last Retry-After
+// header on a 429 or 503 response, at most maxDelay after the response if maxDelay is set.
+type retryAfterTransport struct {
+	next      http.RoundTripper
+	maxDelay  time.Duration
+	mu        sync.Mutex
+	notBefore time.Time
+}
+
+func (t *retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	wait := time.Until(t.notBefore)
+	t.mu.Unlock()
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		if delay := retryAfter(resp.Header.Get("Retry-After")); delay > 0 {
+			if t.maxDelay > 0 && delay > t.maxDelay {
+				delay = t.maxDelay
+			}
+			t.mu.Lock()
+			t.notBefore = time.Now().Add(delay)
+			t.mu.Unlock()
+		}
+	}
+
+	return resp, nil
+}
+
+// retryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := time.Until(date); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,112 @@
+package publisher
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKnativePublisher(t *testing.T) {
+
+	for _, tc := range []struct {
+		title            string
+		responses        []int
+		retryAfter       string
+		expectedRequests int
+		expectError      bool
+	}{
+		{
+			title:            "delivers binary mode event",
+			responses:        []int{http.StatusAccepted},
+			expectedRequests: 1,
+		},
+		{
+			title:            "retries on throttling and unavailable broker",
+			responses:        []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusNotFound, http.StatusAccepted},
+			retryAfter:       "1",
+			expectedRequests: 4,
+		},
+		{
+			title:            "does not retry on bad request",
+			responses:        []int{http.StatusBadRequest},
+			expectedRequests: 1,
+			expectError:      true,
+		},
+		{
+			title:            "gives up after max retries",
+			responses:        []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			expectedRequests: 4,
+			expectError:      true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			requests := 0
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "dev.cdevents.change.merged.0.2.0", r.Header.Get("Ce-Type"))
+
+				if tc.retryAfter != "" {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+				w.WriteHeader(tc.responses[requests])
+				requests++
+			}))
+			defer server.Close()
+
+			p, err := NewKnativePublisher(KnativeConfig{
+				URL:           server.URL,
+				MaxRetries:    3,
+				RetryDelay:    time.Millisecond,
+				MaxRetryDelay: 10 * time.Millisecond,
+			})
+			require.NoError(t, err, "publisher should be created")
+
+			err = p.Publish(context.Background(), newTestCloudEvent(t))
+			if tc.expectError {
+				require.Error(t, err, "publish should return error")
+			} else {
+				require.NoError(t, err, "publish should not return error")
+			}
+			assert.Equal(t, tc.expectedRequests, requests)
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	assert.Equal(t, 5*time.Second, retryAfter("5"))
+	assert.Equal(t, time.Duration(0), retryAfter(""))
+	assert.Equal(t, time.Duration(0), retryAfter("soon"))
+
+	wait := retryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	assert.InDelta(t, time.Minute, wait, float64(2*time.Second))
+}
+
+func TestRetryAfterTransport(t *testing.T) {
+
+	var requested []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, time.Now())
+		if len(requested) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: &retryAfterTransport{next: http.DefaultTransport, maxDelay: 50 * time.Millisecond}}
+	for range 2 {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	require.Len(t, requested, 2)
+	assert.GreaterOrEqual(t, requested[1].Sub(requested[0]), 50*time.Millisecond, "request after Retry-After should be held back up to the max delay")
+}
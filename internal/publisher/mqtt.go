@@ -0,0 +1,106 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+type MQTTConfig struct {
+	Brokers        []string      `envconfig:"BROKERS"`
+	ClientID       string        `envconfig:"CLIENT_ID" default:"cdevents-adapter"`
+	Username       string        `envconfig:"USERNAME"`
+	Password       string        `envconfig:"PASSWORD" secret:"true"`
+	TopicTemplate  string        `envconfig:"TOPIC_TEMPLATE" default:"cdevents/{{.Type}}"`
+	QoS            byte          `envconfig:"QOS" default:"1"`
+	Retained       bool          `envconfig:"RETAINED" default:"false"`
+	PublishTimeout time.Duration `envconfig:"PUBLISH_TIMEOUT" default:"10s"`
+	TLS            TLSConfig     `envconfig:"TLS"`
+}
+
+type mqttClient interface {
+	Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token
+	Disconnect(quiesce uint)
+}
+
+// MQTTPublisher publishes events as structured mode CloudEvents to MQTT topics rendered from a
+// template, since MQTT 3.1.1 has no message headers for binary mode.
+type MQTTPublisher struct {
+	client mqttClient
+	topic  *eventTemplate
+	config MQTTConfig
+}
+
+func NewMQTTPublisher(config MQTTConfig) (*MQTTPublisher, error) {
+	if len(config.Brokers) == 0 {
+		return nil, fmt.Errorf("no brokers configured for MQTT publisher")
+	}
+
+	if config.QoS > 2 {
+		return nil, fmt.Errorf("invalid MQTT QoS: %d", config.QoS)
+	}
+
+	topic, err := newEventTemplate("topic", config.TopicTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid topic template: %w", err)
+	}
+
+	tlsConfig, err := config.TLS.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := mqtt.NewClientOptions().
+		SetClientID(config.ClientID).
+		SetUsername(config.Username).
+		SetPassword(config.Password).
+		SetAutoReconnect(true).
+		SetConnectRetry(true)
+	for _, broker := range config.Brokers {
+		opts.AddBroker(broker)
+	}
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	client := mqtt.NewClient(opts)
+	client.Connect()
+
+	return &MQTTPublisher{client: client, topic: topic, config: config}, nil
+}
+
+func (p *MQTTPublisher) Publish(ctx context.Context, event cloudevents.Event) error {
+	topic, err := p.topic.Execute(event)
+	if err != nil {
+		return fmt.Errorf("failed to render topic: %w", err)
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	token := p.client.Publish(topic, p.config.QoS, p.config.Retained, payload)
+
+	timeout := time.NewTimer(p.config.PublishTimeout)
+	defer timeout.Stop()
+
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-timeout.C:
+		return fmt.Errorf("timeout publishing event %s to MQTT topic %s", event.ID(), topic)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *MQTTPublisher) Close(ctx context.Context) error {
+	p.client.Disconnect(250)
+	return nil
+}
@@ -0,0 +1,80 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockMQTTToken struct {
+	done chan struct{}
+	err  error
+}
+
+func newMockMQTTToken(err error) *mockMQTTToken {
+	done := make(chan struct{})
+	close(done)
+	return &mockMQTTToken{done: done, err: err}
+}
+
+func (t *mockMQTTToken) Wait() bool                     { return true }
+func (t *mockMQTTToken) WaitTimeout(time.Duration) bool { return true }
+func (t *mockMQTTToken) Done() <-chan struct{}          { return t.done }
+func (t *mockMQTTToken) Error() error                   { return t.err }
+
+type mockMQTTClient struct {
+	topic    string
+	qos      byte
+	payload  []byte
+	tokenErr error
+}
+
+func (m *mockMQTTClient) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	m.topic = topic
+	m.qos = qos
+	m.payload = payload.([]byte)
+	return newMockMQTTToken(m.tokenErr)
+}
+
+func (m *mockMQTTClient) Disconnect(quiesce uint) {}
+
+func TestMQTTPublisher(t *testing.T) {
+	client := &mockMQTTClient{}
+
+	topic, err := newEventTemplate("topic", "factory/{{.Source}}/{{.Type}}")
+	require.NoError(t, err)
+
+	p := &MQTTPublisher{
+		client: client,
+		topic:  topic,
+		config: MQTTConfig{QoS: 1, PublishTimeout: time.Second},
+	}
+
+	event := newTestCloudEvent(t)
+	require.NoError(t, p.Publish(context.Background(), event), "publish should not return error")
+
+	assert.Equal(t, "factory/git.example.com/dev.cdevents.change.merged.0.2.0", client.topic)
+	assert.Equal(t, byte(1), client.qos)
+
+	decoded := cloudevents.NewEvent()
+	require.NoError(t, json.Unmarshal(client.payload, &decoded), "payload must be a structured CloudEvent")
+	assert.Equal(t, event.ID(), decoded.ID())
+
+	client.tokenErr = fmt.Errorf("not connected")
+	require.Error(t, p.Publish(context.Background(), event), "publish should return token error")
+}
+
+func TestNewMQTTPublisherValidation(t *testing.T) {
+	_, err := NewMQTTPublisher(MQTTConfig{TopicTemplate: "{{.Type}}"})
+	require.Error(t, err, "publisher without brokers should not be created")
+
+	_, err = NewMQTTPublisher(MQTTConfig{Brokers: []string{"tcp://localhost:1883"}, TopicTemplate: "{{.Type}}", QoS: 3})
+	require.Error(t, err, "publisher with invalid QoS should not be created")
+}
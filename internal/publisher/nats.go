@@ -0,0 +1,93 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/bufpool"
+
+	"github.com/nats-io/nats.go"
+
+	cejsm "github.com/cloudevents/sdk-go/protocol/nats_jetstream/v3"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/cloudevents/sdk-go/v2/binding"
+)
+
+type NATSConfig struct {
+	SubjectPrefix  string        `envconfig:"SUBJECT_PREFIX"`
+	Structured     bool          `envconfig:"STRUCTURED" default:"false"`
+	Request        bool          `envconfig:"REQUEST" default:"false"`
+	RequestTimeout time.Duration `envconfig:"REQUEST_TIMEOUT" default:"5s"`
+}
+
+type NATSConn interface {
+	PublishMsg(msg *nats.Msg) error
+	RequestMsg(msg *nats.Msg, timeout time.Duration) (*nats.Msg, error)
+}
+
+// NATSPublisher publishes events as CloudEvents on core NATS subjects named after the event
+// type, without requiring a stream to capture them. Delivery is fire-and-forget, unless request
+// mode is enabled, in which case every event is sent as a request and a consumer has to reply
+// before the timeout for the event to count as delivered. A reply starting with "-ERR" is
+// treated as a rejection by the consumer.
+type NATSPublisher struct {
+	nc     NATSConn
+	config NATSConfig
+}
+
+func NewNATSPublisher(nc NATSConn, config NATSConfig) *NATSPublisher {
+	return &NATSPublisher{nc: nc, config: config}
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, event cloudevents.Event) error {
+	subject := event.Type()
+	if p.config.SubjectPrefix != "" {
+		subject = fmt.Sprintf("%s.%s", p.config.SubjectPrefix, subject)
+	}
+	subject = subjectFromContext(ctx, subject)
+
+	if p.config.Structured {
+		ctx = cloudevents.WithEncodingStructured(ctx)
+	} else {
+		ctx = cloudevents.WithEncodingBinary(ctx)
+	}
+
+	// The connection copies the message into its write buffer, so the pooled buffer can be
+	// returned as soon as the message has been sent.
+	data := bufpool.Get()
+	defer bufpool.Put(data)
+
+	header, err := cejsm.WriteMsg(ctx, binding.ToMessage(&event), data)
+	if err != nil {
+		return err
+	}
+
+	msg := &nats.Msg{
+		Subject: subject,
+		Header:  header,
+		Data:    data.Bytes(),
+	}
+
+	if !p.config.Request {
+		return p.nc.PublishMsg(msg)
+	}
+
+	reply, err := p.nc.RequestMsg(msg, p.config.RequestTimeout)
+	if err != nil {
+		return fmt.Errorf("no reply received for event %s: %w", event.ID(), err)
+	}
+
+	if reason, rejected := strings.CutPrefix(string(reply.Data), "-ERR"); rejected {
+		return fmt.Errorf("event %s was rejected by consumer: %s", event.ID(), strings.TrimSpace(reason))
+	}
+
+	return nil
+}
+
+// Synchronous makes the fan-out wait for the reply in request mode, so that the webhook message
+// is only acknowledged after a consumer has received the event.
+func (p *NATSPublisher) Synchronous() bool {
+	return p.config.Request
+}
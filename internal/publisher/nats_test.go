@@ -0,0 +1,109 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockNATSConn struct {
+	published []*nats.Msg
+	reply     *nats.Msg
+	err       error
+}
+
+func (m *mockNATSConn) PublishMsg(msg *nats.Msg) error {
+	m.published = append(m.published, msg)
+	return nil
+}
+
+func (m *mockNATSConn) RequestMsg(msg *nats.Msg, timeout time.Duration) (*nats.Msg, error) {
+	m.published = append(m.published, msg)
+	return m.reply, m.err
+}
+
+func TestNATSPublisher(t *testing.T) {
+
+	for _, tc := range []struct {
+		title           string
+		config          NATSConfig
+		expectedSubject string
+		expectedHeader  string
+		expectedType    string
+	}{
+		{
+			title:           "publishes binary mode event on type subject",
+			expectedSubject: "dev.cdevents.change.merged.0.2.0",
+			expectedHeader:  "dev.cdevents.change.merged.0.2.0",
+		},
+		{
+			title:           "publishes structured mode event on prefixed subject",
+			config:          NATSConfig{SubjectPrefix: "events", Structured: true},
+			expectedSubject: "events.dev.cdevents.change.merged.0.2.0",
+			expectedType:    "dev.cdevents.change.merged.0.2.0",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			nc := &mockNATSConn{}
+			p := NewNATSPublisher(nc, tc.config)
+
+			require.NoError(t, p.Publish(context.Background(), newTestCloudEvent(t)), "publish should not return error")
+			require.Len(t, nc.published, 1, "one message should be published")
+
+			msg := nc.published[0]
+			assert.Equal(t, tc.expectedSubject, msg.Subject)
+			assert.Equal(t, tc.expectedHeader, msg.Header.Get("ce-type"))
+
+			if tc.expectedType != "" {
+				var structured map[string]interface{}
+				require.NoError(t, json.Unmarshal(msg.Data, &structured), "structured event must be valid json")
+				assert.Equal(t, tc.expectedType, structured["type"])
+			}
+		})
+	}
+}
+
+func TestNATSPublisherRequestMode(t *testing.T) {
+
+	for _, tc := range []struct {
+		title       string
+		reply       *nats.Msg
+		err         error
+		expectError bool
+	}{
+		{
+			title: "delivered when consumer replies",
+			reply: &nats.Msg{Data: []byte("+ACK")},
+		},
+		{
+			title:       "failed when consumer rejects event",
+			reply:       &nats.Msg{Data: []byte("-ERR unknown event type")},
+			expectError: true,
+		},
+		{
+			title:       "failed when no reply before timeout",
+			err:         nats.ErrTimeout,
+			expectError: true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			nc := &mockNATSConn{reply: tc.reply, err: tc.err}
+			p := NewNATSPublisher(nc, NATSConfig{Request: true, RequestTimeout: time.Second})
+
+			require.True(t, p.Synchronous(), "publisher in request mode should be synchronous")
+
+			err := p.Publish(context.Background(), newTestCloudEvent(t))
+			if tc.expectError {
+				require.Error(t, err, "publish should return error")
+			} else {
+				require.NoError(t, err, "publish should not return error")
+			}
+			assert.Len(t, nc.published, 1, "event should be sent as request")
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package publisher
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+)
+
+// Pinger is implemented by publishers that can check that their destination is reachable
+// without publishing an event.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+func (p *HTTPPublisher) Ping(ctx context.Context) error {
+	return dialURL(ctx, p.config.URL)
+}
+
+func (p *WebhookPublisher) Ping(ctx context.Context) error {
+	return dialURL(ctx, p.config.URL)
+}
+
+func (p *KnativePublisher) Ping(ctx context.Context) error {
+	return dialURL(ctx, p.config.URL)
+}
+
+// dialURL opens and closes a TCP connection to the host of a URL.
+func dialURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return fmt.Errorf("%s is not reachable: %w", u.Host, err)
+	}
+	return conn.Close()
+}
@@ -0,0 +1,45 @@
+package publisher
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPing(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closedURL := "http://" + listener.Addr().String()
+	listener.Close()
+
+	for _, tc := range []struct {
+		title       string
+		url         string
+		expectError bool
+	}{
+		{title: "reachable", url: server.URL},
+		{title: "unreachable", url: closedURL, expectError: true},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			p, err := NewWebhookPublisher(WebhookTargetConfig{URL: tc.url})
+			require.NoError(t, err)
+
+			var pinger Pinger = p
+			err = pinger.Ping(context.Background())
+			if tc.expectError {
+				assert.ErrorContains(t, err, "is not reachable")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
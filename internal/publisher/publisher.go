@@ -0,0 +1,28 @@
+package publisher
+
+import (
+	"context"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+type Publisher interface {
+	Publish(ctx context.Context, event cloudevents.Event) error
+}
+
+// SyncPublisher is implemented by publishers that must be called synchronously when an event is
+// published, e.g. because they wait for a reply from the consumer, so that the webhook message is
+// not acknowledged before the event has been delivered.
+type SyncPublisher interface {
+	Publisher
+	Synchronous() bool
+}
+
+// AsyncPublisher is implemented by publishers that can publish an event without waiting for it
+// to be acknowledged by the destination, so that many publishes can be in flight at once. The
+// channel returned by PublishAsync receives the outcome once the event has been acknowledged.
+type AsyncPublisher interface {
+	Publisher
+	Asynchronous() bool
+	PublishAsync(ctx context.Context, event cloudevents.Event) (<-chan error, error)
+}
@@ -0,0 +1,122 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+type PubSubConfig struct {
+	ProjectID         string `envconfig:"PROJECT_ID"`
+	Topic             string `envconfig:"TOPIC" default:"cdevents"`
+	Structured        bool   `envconfig:"STRUCTURED" default:"false"`
+	OrderingBySubject bool   `envconfig:"ORDERING_BY_SUBJECT" default:"false"`
+}
+
+type pubsubTopic interface {
+	Publish(ctx context.Context, msg *pubsub.Message) (string, error)
+	Stop()
+}
+
+// PubSubPublisher publishes events to a Google Cloud Pub/Sub topic. In binary mode the CDEvent
+// is the message data and the CloudEvent attributes (type, source, subject etc.) are mapped to
+// "ce-" prefixed message attributes so that subscriptions can filter on them.
+type PubSubPublisher struct {
+	client *pubsub.Client
+	topic  pubsubTopic
+	config PubSubConfig
+}
+
+func NewPubSubPublisher(ctx context.Context, config PubSubConfig) (*PubSubPublisher, error) {
+	if config.ProjectID == "" {
+		return nil, fmt.Errorf("no project id configured for Pub/Sub publisher")
+	}
+
+	client, err := pubsub.NewClient(ctx, config.ProjectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Pub/Sub client: %w", err)
+	}
+
+	topic := client.Topic(config.Topic)
+	topic.EnableMessageOrdering = config.OrderingBySubject
+
+	return &PubSubPublisher{
+		client: client,
+		topic:  &gcpTopic{topic},
+		config: config,
+	}, nil
+}
+
+func (p *PubSubPublisher) Publish(ctx context.Context, event cloudevents.Event) error {
+	msg, err := pubsubMessage(event, p.config.Structured)
+	if err != nil {
+		return err
+	}
+
+	if p.config.OrderingBySubject {
+		msg.OrderingKey = event.Subject()
+	}
+
+	_, err = p.topic.Publish(ctx, msg)
+
+	return err
+}
+
+func (p *PubSubPublisher) Close(ctx context.Context) error {
+	p.topic.Stop()
+
+	if p.client == nil {
+		return nil
+	}
+
+	return p.client.Close()
+}
+
+// pubsubMessage encodes the event according to the CloudEvents Pub/Sub binding.
+func pubsubMessage(event cloudevents.Event, structured bool) (*pubsub.Message, error) {
+	if structured {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return nil, err
+		}
+		return &pubsub.Message{
+			Data:       data,
+			Attributes: map[string]string{"content-type": "application/cloudevents+json"},
+		}, nil
+	}
+
+	attributes := map[string]string{
+		"ce-specversion": event.SpecVersion(),
+		"ce-id":          event.ID(),
+		"ce-source":      event.Source(),
+		"ce-type":        event.Type(),
+		"content-type":   event.DataContentType(),
+	}
+	if event.Subject() != "" {
+		attributes["ce-subject"] = event.Subject()
+	}
+	if !event.Time().IsZero() {
+		attributes["ce-time"] = event.Time().Format(time.RFC3339Nano)
+	}
+	for name, value := range event.Extensions() {
+		attributes["ce-"+name] = fmt.Sprint(value)
+	}
+
+	return &pubsub.Message{
+		Data:       event.Data(),
+		Attributes: attributes,
+	}, nil
+}
+
+type gcpTopic struct {
+	*pubsub.Topic
+}
+
+func (t *gcpTopic) Publish(ctx context.Context, msg *pubsub.Message) (string, error) {
+	return t.Topic.Publish(ctx, msg).Get(ctx)
+}
@@ -0,0 +1,74 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockPubSubTopic struct {
+	published []*pubsub.Message
+}
+
+func (m *mockPubSubTopic) Publish(ctx context.Context, msg *pubsub.Message) (string, error) {
+	m.published = append(m.published, msg)
+	return "1", nil
+}
+
+func (m *mockPubSubTopic) Stop() {}
+
+func TestPubSubPublisher(t *testing.T) {
+
+	for _, tc := range []struct {
+		title               string
+		config              PubSubConfig
+		expectedAttributes  map[string]string
+		expectedOrderingKey string
+		expectStructured    bool
+	}{
+		{
+			title: "publishes binary mode event with attributes",
+			expectedAttributes: map[string]string{
+				"ce-type":      "dev.cdevents.change.merged.0.2.0",
+				"ce-source":    "git.example.com",
+				"ce-subject":   "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2",
+				"content-type": "application/json",
+			},
+		},
+		{
+			title:               "publishes structured mode event with ordering key",
+			config:              PubSubConfig{Structured: true, OrderingBySubject: true},
+			expectedAttributes:  map[string]string{"content-type": "application/cloudevents+json"},
+			expectedOrderingKey: "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2",
+			expectStructured:    true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			topic := &mockPubSubTopic{}
+			p := &PubSubPublisher{topic: topic, config: tc.config}
+
+			event := newTestCloudEvent(t)
+			require.NoError(t, p.Publish(context.Background(), event), "publish should not return error")
+			require.Len(t, topic.published, 1, "one message should be published")
+
+			msg := topic.published[0]
+			for name, value := range tc.expectedAttributes {
+				assert.Equal(t, value, msg.Attributes[name], "attribute %s", name)
+			}
+			assert.Equal(t, tc.expectedOrderingKey, msg.OrderingKey)
+
+			if tc.expectStructured {
+				decoded := cloudevents.NewEvent()
+				require.NoError(t, json.Unmarshal(msg.Data, &decoded), "data must be a structured CloudEvent")
+				assert.Equal(t, event.ID(), decoded.ID())
+			} else {
+				assert.JSONEq(t, string(event.Data()), string(msg.Data))
+			}
+		})
+	}
+}
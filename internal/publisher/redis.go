@@ -0,0 +1,83 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+type RedisConfig struct {
+	Addr     string    `envconfig:"ADDR" default:"localhost:6379"`
+	Username string    `envconfig:"USERNAME"`
+	Password string    `envconfig:"PASSWORD" secret:"true"`
+	DB       int       `envconfig:"DB" default:"0"`
+	Stream   string    `envconfig:"STREAM" default:"cdevents"`
+	MaxLen   int64     `envconfig:"MAX_LEN" default:"10000"`
+	TLS      TLSConfig `envconfig:"TLS"`
+}
+
+type redisClient interface {
+	XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd
+	Close() error
+}
+
+// RedisPublisher appends events to a Redis stream with XADD, trimming the stream to
+// approximately the configured max length. Every entry has the type, source and subject of the
+// event as separate fields and the structured CloudEvent in the "event" field.
+type RedisPublisher struct {
+	client redisClient
+	config RedisConfig
+}
+
+func NewRedisPublisher(config RedisConfig) (*RedisPublisher, error) {
+	tlsConfig, err := config.TLS.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:      config.Addr,
+		Username:  config.Username,
+		Password:  config.Password,
+		DB:        config.DB,
+		TLSConfig: tlsConfig,
+	})
+
+	return &RedisPublisher{client: client, config: config}, nil
+}
+
+func (p *RedisPublisher) Publish(ctx context.Context, event cloudevents.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	args := &redis.XAddArgs{
+		Stream: p.config.Stream,
+		Values: []interface{}{
+			"id", event.ID(),
+			"type", event.Type(),
+			"source", event.Source(),
+			"subject", event.Subject(),
+			"event", data,
+		},
+	}
+	if p.config.MaxLen > 0 {
+		args.MaxLen = p.config.MaxLen
+		args.Approx = true
+	}
+
+	if err := p.client.XAdd(ctx, args).Err(); err != nil {
+		return fmt.Errorf("failed to add event to Redis stream %s: %w", p.config.Stream, err)
+	}
+
+	return nil
+}
+
+func (p *RedisPublisher) Close(ctx context.Context) error {
+	return p.client.Close()
+}
@@ -0,0 +1,54 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockRedisClient struct {
+	args *redis.XAddArgs
+	err  error
+}
+
+func (m *mockRedisClient) XAdd(ctx context.Context, a *redis.XAddArgs) *redis.StringCmd {
+	m.args = a
+	return redis.NewStringResult("1700000000000-0", m.err)
+}
+
+func (m *mockRedisClient) Close() error {
+	return nil
+}
+
+func TestRedisPublisher(t *testing.T) {
+	client := &mockRedisClient{}
+	p := &RedisPublisher{client: client, config: RedisConfig{Stream: "cdevents", MaxLen: 100}}
+
+	event := newTestCloudEvent(t)
+	require.NoError(t, p.Publish(context.Background(), event), "publish should not return error")
+
+	assert.Equal(t, "cdevents", client.args.Stream)
+	assert.Equal(t, int64(100), client.args.MaxLen)
+	assert.True(t, client.args.Approx, "stream should be trimmed approximately")
+
+	values := client.args.Values.([]interface{})
+	fields := map[string]interface{}{}
+	for i := 0; i < len(values); i += 2 {
+		fields[values[i].(string)] = values[i+1]
+	}
+	assert.Equal(t, event.Type(), fields["type"])
+	assert.Equal(t, event.Subject(), fields["subject"])
+
+	decoded := cloudevents.NewEvent()
+	require.NoError(t, json.Unmarshal(fields["event"].([]byte), &decoded), "event field must be a structured CloudEvent")
+	assert.Equal(t, event.ID(), decoded.ID())
+
+	client.err = fmt.Errorf("connection refused")
+	require.Error(t, p.Publish(context.Background(), event), "publish should return XADD error")
+}
@@ -0,0 +1,50 @@
+package publisher
+
+import (
+	"context"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// Route sends events with a matching type to a subset of the sinks and optionally overrides the
+// subject they are published on by subject-based sinks (JetStream and NATS). Sinks are matched
+// by name with glob patterns, e.g. "webhook:*". An empty list of sinks matches all sinks.
+type Route struct {
+	Name    string   `ignored:"true"`
+	Types   []string `envconfig:"TYPES"`
+	Sinks   []string `envconfig:"SINKS"`
+	Subject string   `envconfig:"SUBJECT"`
+}
+
+func (r Route) Matches(event cloudevents.Event) bool {
+	return matchesAny(r.Types, event.Type())
+}
+
+func (r Route) includes(sink string) bool {
+	return matchesAny(r.Sinks, sink)
+}
+
+// matchRoute returns the first route that matches the event, or nil if none does.
+func matchRoute(routes []Route, event cloudevents.Event) *Route {
+	for i := range routes {
+		if routes[i].Matches(event) {
+			return &routes[i]
+		}
+	}
+	return nil
+}
+
+type subjectKey struct{}
+
+// WithSubject returns a context that makes subject-based publishers publish the event on the
+// given subject instead of the one derived from the event type.
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+func subjectFromContext(ctx context.Context, fallback string) string {
+	if subject, ok := ctx.Value(subjectKey{}).(string); ok && subject != "" {
+		return subject
+	}
+	return fallback
+}
@@ -0,0 +1,30 @@
+package publisher
+
+import (
+	"strings"
+	"text/template"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// eventTemplate renders destination names such as routing keys and topics from a CloudEvent,
+// e.g. "cdevents.{{.Type}}" or "{{.Source}}/{{.Subject}}".
+type eventTemplate struct {
+	tmpl *template.Template
+}
+
+func newEventTemplate(name, text string) (*eventTemplate, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &eventTemplate{tmpl: tmpl}, nil
+}
+
+func (t *eventTemplate) Execute(event cloudevents.Event) (string, error) {
+	var sb strings.Builder
+	if err := t.tmpl.Execute(&sb, event); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,49 @@
+package publisher
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+type TLSConfig struct {
+	Enabled            bool   `envconfig:"ENABLED" default:"false"`
+	CAFile             string `envconfig:"CA_FILE"`
+	CertFile           string `envconfig:"CERT_FILE"`
+	KeyFile            string `envconfig:"KEY_FILE"`
+	InsecureSkipVerify bool   `envconfig:"INSECURE_SKIP_VERIFY" default:"false"`
+}
+
+// Build returns the tls.Config described by c, or nil if TLS is not enabled.
+func (c TLSConfig) Build() (*tls.Config, error) {
+	if !c.Enabled {
+		return nil, nil
+	}
+
+	config := &tls.Config{
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	if c.CAFile != "" {
+		ca, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in CA file: %s", c.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
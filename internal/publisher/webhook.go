@@ -0,0 +1,138 @@
+package publisher
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+type WebhookConfig struct {
+	Targets []string `envconfig:"TARGETS"`
+}
+
+type WebhookTargetConfig struct {
+	URL             string        `envconfig:"URL"`
+	BearerToken     string        `envconfig:"BEARER_TOKEN" secret:"true"`
+	HMACSecret      string        `envconfig:"HMAC_SECRET" secret:"true"`
+	SignatureHeader string        `envconfig:"SIGNATURE_HEADER" default:"X-CDEvents-Signature-256"`
+	Timeout         time.Duration `envconfig:"TIMEOUT" default:"10s"`
+	MaxRetries      int           `envconfig:"MAX_RETRIES" default:"3"`
+	RetryDelay      time.Duration `envconfig:"RETRY_DELAY" default:"500ms"`
+	Filter          Filter        `envconfig:"FILTER"`
+}
+
+// SecretResolver resolves secrets that are referenced in the configuration, e.g. in
+// HMAC_SECRET, instead of being given as plain values.
+type SecretResolver interface {
+	Resolve(ctx context.Context, value string) (string, error)
+}
+
+// WebhookPublisher POSTs events as structured CloudEvents to a downstream webhook. If an HMAC
+// secret is configured the body is signed with HMAC-SHA256 and the signature is sent in the
+// signature header as "sha256=<hex>", in the same way as Gitea and GitHub sign their webhooks.
+type WebhookPublisher struct {
+	client  *http.Client
+	config  WebhookTargetConfig
+	secrets SecretResolver
+}
+
+func NewWebhookPublisher(config WebhookTargetConfig) (*WebhookPublisher, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("no URL configured for webhook target")
+	}
+
+	return &WebhookPublisher{
+		client: &http.Client{Timeout: config.Timeout},
+		config: config,
+	}, nil
+}
+
+// SetSecretResolver sets a resolver for the bearer token and HMAC secret, which are then resolved
+// on every request so that renewed secrets are picked up.
+func (p *WebhookPublisher) SetSecretResolver(secrets SecretResolver) {
+	p.secrets = secrets
+}
+
+func (p *WebhookPublisher) Publish(ctx context.Context, event cloudevents.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	delay := p.config.RetryDelay
+	for attempt := 0; ; attempt++ {
+		retry, err := p.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= p.config.MaxRetries {
+			return fmt.Errorf("failed to send event to %s: %w", p.config.URL, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+func (p *WebhookPublisher) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/cloudevents+json; charset=utf-8")
+	if p.config.BearerToken != "" {
+		token, err := p.secret(ctx, p.config.BearerToken)
+		if err != nil {
+			return true, err
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+	if p.config.HMACSecret != "" {
+		secret, err := p.secret(ctx, p.config.HMACSecret)
+		if err != nil {
+			return true, err
+		}
+		req.Header.Set(p.config.SignatureHeader, "sha256="+sign(secret, body))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("unexpected response: %s", resp.Status)
+	default:
+		return false, fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+}
+
+func (p *WebhookPublisher) secret(ctx context.Context, value string) (string, error) {
+	if p.secrets == nil {
+		return value, nil
+	}
+	return p.secrets.Resolve(ctx, value)
+}
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,117 @@
+package publisher
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookPublisher(t *testing.T) {
+
+	for _, tc := range []struct {
+		title            string
+		config           WebhookTargetConfig
+		statuses         []int
+		expectedRequests int
+		expectError      bool
+	}{
+		{
+			title:            "signs body and sends bearer token",
+			config:           WebhookTargetConfig{BearerToken: "token", HMACSecret: "secret", SignatureHeader: "X-Signature"},
+			statuses:         []int{http.StatusAccepted},
+			expectedRequests: 1,
+		},
+		{
+			title:            "retries on server error",
+			config:           WebhookTargetConfig{MaxRetries: 2, RetryDelay: time.Millisecond},
+			statuses:         []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK},
+			expectedRequests: 3,
+		},
+		{
+			title:            "gives up after max retries",
+			config:           WebhookTargetConfig{MaxRetries: 1, RetryDelay: time.Millisecond},
+			statuses:         []int{http.StatusBadGateway, http.StatusBadGateway},
+			expectedRequests: 2,
+			expectError:      true,
+		},
+		{
+			title:            "does not retry on client error",
+			config:           WebhookTargetConfig{MaxRetries: 3, RetryDelay: time.Millisecond},
+			statuses:         []int{http.StatusBadRequest},
+			expectedRequests: 1,
+			expectError:      true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			requests := 0
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+
+				if tc.config.BearerToken != "" {
+					assert.Equal(t, "Bearer "+tc.config.BearerToken, r.Header.Get("Authorization"))
+				}
+				if tc.config.HMACSecret != "" {
+					assert.Equal(t, "sha256="+sign(tc.config.HMACSecret, body), r.Header.Get(tc.config.SignatureHeader))
+				}
+				assert.Contains(t, r.Header.Get("Content-Type"), "application/cloudevents+json")
+
+				w.WriteHeader(tc.statuses[requests])
+				requests++
+			}))
+			defer server.Close()
+
+			tc.config.URL = server.URL
+			p, err := NewWebhookPublisher(tc.config)
+			require.NoError(t, err, "publisher should be created")
+
+			err = p.Publish(context.Background(), newTestCloudEvent(t))
+			if tc.expectError {
+				require.Error(t, err, "publish should return error")
+			} else {
+				require.NoError(t, err, "publish should not return error")
+			}
+			assert.Equal(t, tc.expectedRequests, requests)
+		})
+	}
+}
+
+type mapSecretResolver map[string]string
+
+func (m mapSecretResolver) Resolve(ctx context.Context, value string) (string, error) {
+	if secret, exists := m[value]; exists {
+		return secret, nil
+	}
+	return value, nil
+}
+
+func TestWebhookPublisherResolvesSecrets(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		assert.Equal(t, "Bearer resolved-token", r.Header.Get("Authorization"))
+		assert.Equal(t, "sha256="+sign("resolved-secret", body), r.Header.Get("X-Signature"))
+	}))
+	defer server.Close()
+
+	p, err := NewWebhookPublisher(WebhookTargetConfig{
+		URL:             server.URL,
+		BearerToken:     "${secret:vault:secret/adapter#token}",
+		HMACSecret:      "${secret:vault:secret/adapter#hmac}",
+		SignatureHeader: "X-Signature",
+	})
+	require.NoError(t, err)
+	p.SetSecretResolver(mapSecretResolver{
+		"${secret:vault:secret/adapter#token}": "resolved-token",
+		"${secret:vault:secret/adapter#hmac}":  "resolved-secret",
+	})
+
+	require.NoError(t, p.Publish(context.Background(), newTestCloudEvent(t)))
+}
@@ -0,0 +1,89 @@
+// Package redact removes personal data and secrets from webhook payloads, e.g. author emails,
+// before they are stored or embedded in CDEvents.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/jsonpath"
+)
+
+type Config struct {
+	// Fields are JSONPath expressions of fields whose values are replaced, e.g.
+	// "$.commits[*].author.email".
+	Fields []string `envconfig:"FIELDS"`
+	// Patterns are regular expressions that are replaced in every string value.
+	Patterns    []string `envconfig:"PATTERNS"`
+	Replacement string   `envconfig:"REPLACEMENT" default:"[REDACTED]"`
+}
+
+func (c Config) Enabled() bool {
+	return len(c.Fields) > 0 || len(c.Patterns) > 0
+}
+
+type Redactor struct {
+	fields      []jsonpath.Path
+	patterns    []*regexp.Regexp
+	replacement string
+}
+
+func New(config Config) (*Redactor, error) {
+	r := &Redactor{replacement: config.Replacement}
+
+	for _, field := range config.Fields {
+		path, err := jsonpath.Parse(field)
+		if err != nil {
+			return nil, err
+		}
+		r.fields = append(r.fields, path)
+	}
+
+	for _, pattern := range config.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	return r, nil
+}
+
+// Redact replaces the configured fields and patterns in a decoded JSON document in place.
+func (r *Redactor) Redact(doc interface{}) {
+	for _, path := range r.fields {
+		path.Update(doc, func(interface{}) interface{} { return r.replacement })
+	}
+	if len(r.patterns) > 0 {
+		r.redactStrings(doc)
+	}
+}
+
+func (r *Redactor) redactStrings(doc interface{}) {
+	switch container := doc.(type) {
+	case map[string]interface{}:
+		for key, value := range container {
+			if s, ok := value.(string); ok {
+				container[key] = r.redactString(s)
+			} else {
+				r.redactStrings(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range container {
+			if s, ok := value.(string); ok {
+				container[i] = r.redactString(s)
+			} else {
+				r.redactStrings(value)
+			}
+		}
+	}
+}
+
+func (r *Redactor) redactString(s string) string {
+	for _, re := range r.patterns {
+		s = re.ReplaceAllLiteralString(s, r.replacement)
+	}
+	return s
+}
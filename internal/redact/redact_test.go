@@ -0,0 +1,62 @@
+package redact
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedact(t *testing.T) {
+
+	payload := `{
+		"commits": [{"message": "Fix login, token ghp_abc123", "author": {"name": "Jane", "email": "jane@example.com"}}],
+		"pusher": {"email": "jane@example.com"}
+	}`
+
+	for _, tc := range []struct {
+		title         string
+		config        Config
+		expected      string
+		expectedError string
+	}{
+		{
+			title:    "redacts fields",
+			config:   Config{Fields: []string{"$.commits[*].author.email", "$.pusher.email"}, Replacement: "[REDACTED]"},
+			expected: `{"commits": [{"message": "Fix login, token ghp_abc123", "author": {"name": "Jane", "email": "[REDACTED]"}}], "pusher": {"email": "[REDACTED]"}}`,
+		},
+		{
+			title:    "redacts patterns in every string",
+			config:   Config{Patterns: []string{`ghp_[A-Za-z0-9]+`, `[\w.]+@example\.com`}, Replacement: "***"},
+			expected: `{"commits": [{"message": "Fix login, token ***", "author": {"name": "Jane", "email": "***"}}], "pusher": {"email": "***"}}`,
+		},
+		{
+			title:         "fails on invalid pattern",
+			config:        Config{Patterns: []string{`ghp_[`}},
+			expectedError: "invalid redaction pattern",
+		},
+		{
+			title:         "fails on invalid field",
+			config:        Config{Fields: []string{`$.commits[0].author`}},
+			expectedError: "unsupported field path",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			redactor, err := New(tc.config)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+
+			var doc interface{}
+			require.NoError(t, json.Unmarshal([]byte(payload), &doc))
+			redactor.Redact(doc)
+
+			actual, err := json.Marshal(doc)
+			require.NoError(t, err)
+			assert.JSONEq(t, tc.expected, string(actual))
+		})
+	}
+}
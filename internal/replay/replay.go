@@ -0,0 +1,168 @@
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Config selects the archived webhook messages to replay. Zero values do not restrict the
+// selection.
+type Config struct {
+	// ArchiveSubjectBase is the subject base the webhooks were archived under.
+	ArchiveSubjectBase string
+	// WebhookSubjectBase is the subject base the webhooks are replayed on.
+	WebhookSubjectBase string
+	// Subject restricts the replay to webhook subjects below the archive subject base, e.g.
+	// gitea.push or gitea.>.
+	Subject string
+	FromSeq uint64
+	ToSeq   uint64
+	Since   time.Time
+	Until   time.Time
+}
+
+func (c Config) Validate() error {
+	if c.ToSeq > 0 && c.FromSeq > c.ToSeq {
+		return fmt.Errorf("start sequence %d is after end sequence %d", c.FromSeq, c.ToSeq)
+	}
+	if !c.Since.IsZero() && !c.Until.IsZero() && c.Since.After(c.Until) {
+		return fmt.Errorf("start time %s is after end time %s", c.Since.Format(time.RFC3339), c.Until.Format(time.RFC3339))
+	}
+	if c.FromSeq > 0 && !c.Since.IsZero() {
+		return errors.New("start sequence and start time are mutually exclusive")
+	}
+	return nil
+}
+
+type Stream interface {
+	Info(ctx context.Context, opts ...jetstream.StreamInfoOpt) (*jetstream.StreamInfo, error)
+	OrderedConsumer(ctx context.Context, cfg jetstream.OrderedConsumerConfig) (jetstream.Consumer, error)
+}
+
+type Publisher interface {
+	PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+}
+
+type Report struct {
+	Replayed int    `json:"replayed"`
+	FirstSeq uint64 `json:"first_seq,omitempty"`
+	LastSeq  uint64 `json:"last_seq,omitempty"`
+}
+
+// Run publishes the selected messages of the archive stream on their original webhook
+// subjects, where they are translated again by the adapter. Only messages that are in the stream
+// when Run is called are replayed. Replayed messages have the adapter.ReplayHeader set, so that
+// their newly translated events are published instead of the ones in the result cache.
+func Run(ctx context.Context, logger *slog.Logger, stream Stream, publisher Publisher, config Config) (Report, error) {
+	var report Report
+
+	info, err := stream.Info(ctx)
+	if err != nil {
+		return report, err
+	}
+	if info.State.Msgs == 0 {
+		return report, nil
+	}
+
+	end := info.State.LastSeq
+	if config.ToSeq > 0 && config.ToSeq < end {
+		end = config.ToSeq
+	}
+
+	cfg := jetstream.OrderedConsumerConfig{DeliverPolicy: jetstream.DeliverAllPolicy}
+	if config.FromSeq > 0 {
+		cfg.DeliverPolicy = jetstream.DeliverByStartSequencePolicy
+		cfg.OptStartSeq = config.FromSeq
+	} else if !config.Since.IsZero() {
+		cfg.DeliverPolicy = jetstream.DeliverByStartTimePolicy
+		cfg.OptStartTime = &config.Since
+	}
+	if config.Subject != "" {
+		cfg.FilterSubjects = []string{fmt.Sprintf("%s.%s", config.ArchiveSubjectBase, config.Subject)}
+	}
+
+	consumer, err := stream.OrderedConsumer(ctx, cfg)
+	if err != nil {
+		return report, err
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		msg, err := consumer.Next(jetstream.FetchMaxWait(5 * time.Second))
+		if errors.Is(err, nats.ErrTimeout) {
+			// No more messages matching the filter.
+			return report, nil
+		} else if err != nil {
+			return report, err
+		}
+
+		metadata, err := msg.Metadata()
+		if err != nil {
+			return report, err
+		}
+		if metadata.Sequence.Stream > end {
+			return report, nil
+		}
+		if !config.Until.IsZero() && metadata.Timestamp.After(config.Until) {
+			return report, nil
+		}
+
+		replayed, err := replayMsg(msg, config)
+		if err != nil {
+			return report, fmt.Errorf("archived message %d: %w", metadata.Sequence.Stream, err)
+		}
+
+		logger.Debug(fmt.Sprintf("Replaying archived webhook message on subject: %s", replayed.Subject),
+			"stream_seq", metadata.Sequence.Stream)
+
+		publishCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		_, err = publisher.PublishMsg(publishCtx, replayed)
+		cancel()
+		if err != nil {
+			return report, fmt.Errorf("failed to replay archived message %d: %w", metadata.Sequence.Stream, err)
+		}
+
+		if report.Replayed == 0 {
+			report.FirstSeq = metadata.Sequence.Stream
+		}
+		report.LastSeq = metadata.Sequence.Stream
+		report.Replayed++
+
+		if metadata.Sequence.Stream == end {
+			return report, nil
+		}
+	}
+}
+
+// replayMsg returns the message to publish on the webhook subject for an archived message. The
+// headers added by JetStream when republishing into the archive are dropped.
+func replayMsg(msg jetstream.Msg, config Config) (*nats.Msg, error) {
+	prefix := config.ArchiveSubjectBase + "."
+	if !strings.HasPrefix(msg.Subject(), prefix) {
+		return nil, fmt.Errorf("subject %s is not below archive subject base %s", msg.Subject(), config.ArchiveSubjectBase)
+	}
+
+	replayed := nats.NewMsg(fmt.Sprintf("%s.%s", config.WebhookSubjectBase, strings.TrimPrefix(msg.Subject(), prefix)))
+	replayed.Data = msg.Data()
+	for key, values := range msg.Headers() {
+		if strings.HasPrefix(key, "Nats-") {
+			continue
+		}
+		replayed.Header[key] = values
+	}
+	replayed.Header.Set(adapter.ReplayHeader, "true")
+
+	return replayed, nil
+}
@@ -0,0 +1,113 @@
+package replay
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestJetStream(t *testing.T) jetstream.JetStream {
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+	ns.Start()
+	t.Cleanup(ns.Shutdown)
+	require.True(t, ns.ReadyForConnections(10*time.Second))
+
+	nc, err := nats.Connect(ns.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+	return js
+}
+
+func TestConfigValidate(t *testing.T) {
+
+	now := time.Now()
+
+	for _, tc := range []struct {
+		title       string
+		config      Config
+		expectedErr string
+	}{
+		{title: "empty", config: Config{}},
+		{title: "sequence range", config: Config{FromSeq: 1, ToSeq: 1}},
+		{title: "reversed sequence range", config: Config{FromSeq: 2, ToSeq: 1}, expectedErr: "start sequence 2 is after end sequence 1"},
+		{title: "reversed time range", config: Config{Since: now, Until: now.Add(-time.Second)}, expectedErr: fmt.Sprintf("start time %s is after end time %s", now.Format(time.RFC3339), now.Add(-time.Second).Format(time.RFC3339))},
+		{title: "sequence and time", config: Config{FromSeq: 1, Since: now}, expectedErr: "start sequence and start time are mutually exclusive"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.expectedErr != "" {
+				assert.EqualError(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+
+	ctx := context.Background()
+	js := newTestJetStream(t)
+
+	archive, err := js.CreateStream(ctx, jetstream.StreamConfig{Name: "archive", Subjects: []string{"archive.webhooks.>"}})
+	require.NoError(t, err)
+	_, err = js.CreateStream(ctx, jetstream.StreamConfig{Name: "webhooks", Subjects: []string{"webhooks.>"}})
+	require.NoError(t, err)
+
+	for i, subject := range []string{"gitea.push", "gitea.create", "gitea.push", "gitea.push"} {
+		msg := nats.NewMsg("archive.webhooks." + subject)
+		msg.Data = []byte(fmt.Sprintf(`{"n": %d}`, i+1))
+		msg.Header.Set(adapter.DeliveryIDHeader, fmt.Sprintf("delivery-%d", i+1))
+		msg.Header.Set("Nats-Stream", "webhooks")
+		_, err := js.PublishMsg(ctx, msg)
+		require.NoError(t, err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	config := Config{
+		ArchiveSubjectBase: "archive.webhooks",
+		WebhookSubjectBase: "webhooks",
+		Subject:            "gitea.push",
+		ToSeq:              3,
+	}
+
+	report, err := Run(ctx, logger, archive, js, config)
+	require.NoError(t, err)
+	assert.Equal(t, Report{Replayed: 2, FirstSeq: 1, LastSeq: 3}, report)
+
+	consumer, err := js.OrderedConsumer(ctx, "webhooks", jetstream.OrderedConsumerConfig{})
+	require.NoError(t, err)
+
+	var deliveries []string
+	for i := 0; i < 2; i++ {
+		msg, err := consumer.Next(jetstream.FetchMaxWait(5 * time.Second))
+		require.NoError(t, err)
+		assert.Equal(t, "webhooks.gitea.push", msg.Subject())
+		assert.Equal(t, "true", msg.Headers().Get(adapter.ReplayHeader))
+		assert.Empty(t, msg.Headers().Get("Nats-Stream"), "archive headers should be dropped")
+		deliveries = append(deliveries, msg.Headers().Get(adapter.DeliveryIDHeader))
+	}
+	assert.Equal(t, []string{"delivery-1", "delivery-3"}, deliveries)
+}
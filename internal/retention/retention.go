@@ -0,0 +1,196 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/metrics"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type Stream interface {
+	Info(ctx context.Context, opts ...jetstream.StreamInfoOpt) (*jetstream.StreamInfo, error)
+	Purge(ctx context.Context, opts ...jetstream.StreamPurgeOpt) error
+	OrderedConsumer(ctx context.Context, cfg jetstream.OrderedConsumerConfig) (jetstream.Consumer, error)
+}
+
+type Usage struct {
+	Stream    string        `json:"stream"`
+	Msgs      uint64        `json:"msgs"`
+	Bytes     uint64        `json:"bytes"`
+	OldestAge time.Duration `json:"oldest_age"`
+}
+
+// Report queries the current usage of a stream and updates the stream usage metrics.
+func Report(ctx context.Context, stream Stream) (Usage, error) {
+	info, err := stream.Info(ctx)
+	if err != nil {
+		return Usage{}, err
+	}
+
+	usage := Usage{
+		Stream: info.Config.Name,
+		Msgs:   info.State.Msgs,
+		Bytes:  info.State.Bytes,
+	}
+	if info.State.Msgs > 0 && !info.State.FirstTime.IsZero() {
+		usage.OldestAge = time.Since(info.State.FirstTime)
+	}
+
+	metrics.StreamMsgs.WithLabelValues(usage.Stream).Set(float64(usage.Msgs))
+	metrics.StreamBytes.WithLabelValues(usage.Stream).Set(float64(usage.Bytes))
+	metrics.StreamOldestMsgAge.WithLabelValues(usage.Stream).Set(usage.OldestAge.Seconds())
+
+	return usage, nil
+}
+
+// PurgeOlderThan removes all messages older than maxAge from the stream, optionally restricted
+// to messages on the given subject. Every purge is bounded by the sequence of the first message
+// to keep, so that newer messages are retained. Returns the sequence below which messages were
+// purged.
+func PurgeOlderThan(ctx context.Context, stream Stream, maxAge time.Duration, subject string) (uint64, error) {
+	info, err := stream.Info(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if info.State.Msgs == 0 {
+		return 0, nil
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	if info.State.FirstTime.After(cutoff) {
+		return 0, nil
+	}
+
+	var sequence uint64
+	if info.State.LastTime.Before(cutoff) {
+		sequence = info.State.LastSeq + 1
+	} else {
+		sequence, err = firstSequenceSince(ctx, stream, cutoff, subject)
+		if errors.Is(err, errNoMessageSince) && subject != "" {
+			// Every message on the subject is older than the cutoff.
+			sequence = info.State.LastSeq + 1
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	if sequence == 0 {
+		return 0, fmt.Errorf("refusing to purge stream %s without a sequence bound", info.Config.Name)
+	}
+
+	opts := []jetstream.StreamPurgeOpt{jetstream.WithPurgeSequence(sequence)}
+	if subject != "" {
+		opts = append(opts, jetstream.WithPurgeSubject(subject))
+	}
+
+	if err := stream.Purge(ctx, opts...); err != nil {
+		return 0, err
+	}
+
+	return sequence, nil
+}
+
+// errNoMessageSince is returned by firstSequenceSince when no message was found after the time.
+var errNoMessageSince = errors.New("no message found since")
+
+func firstSequenceSince(ctx context.Context, stream Stream, since time.Time, subject string) (uint64, error) {
+	cfg := jetstream.OrderedConsumerConfig{
+		DeliverPolicy: jetstream.DeliverByStartTimePolicy,
+		OptStartTime:  &since,
+		HeadersOnly:   true,
+	}
+	if subject != "" {
+		cfg.FilterSubjects = []string{subject}
+	}
+
+	consumer, err := stream.OrderedConsumer(ctx, cfg)
+	if err != nil {
+		return 0, err
+	}
+
+	msg, err := consumer.Next(jetstream.FetchMaxWait(5 * time.Second))
+	if errors.Is(err, nats.ErrTimeout) {
+		return 0, fmt.Errorf("%w %s", errNoMessageSince, since.Format(time.RFC3339))
+	} else if err != nil {
+		return 0, err
+	}
+
+	metadata, err := msg.Metadata()
+	if err != nil {
+		return 0, err
+	}
+
+	return metadata.Sequence.Stream, nil
+}
+
+type Task struct {
+	logger       *slog.Logger
+	streams      []Stream
+	archive      Stream
+	interval     time.Duration
+	purgeMaxAge  time.Duration
+	purgeSubject string
+}
+
+// NewTask creates a background task that reports usage of the given streams and, if archive is
+// not nil and purgeMaxAge is set, purges messages older than purgeMaxAge from the archive stream.
+func NewTask(logger *slog.Logger, interval time.Duration, streams []Stream, archive Stream, purgeMaxAge time.Duration, purgeSubject string) *Task {
+	return &Task{
+		logger:       logger,
+		streams:      streams,
+		archive:      archive,
+		interval:     interval,
+		purgeMaxAge:  purgeMaxAge,
+		purgeSubject: purgeSubject,
+	}
+}
+
+func (t *Task) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		t.runOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *Task) runOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if t.archive != nil && t.purgeMaxAge > 0 {
+		sequence, err := PurgeOlderThan(ctx, t.archive, t.purgeMaxAge, t.purgeSubject)
+		if err != nil {
+			t.logger.Error("Failed to purge archive stream", "error", err.Error())
+		} else if sequence > 0 {
+			t.logger.Info(fmt.Sprintf("Purged archive stream messages older than %s", t.purgeMaxAge),
+				"below_seq", sequence,
+				"subject", t.purgeSubject)
+		}
+	}
+
+	for _, stream := range t.streams {
+		usage, err := Report(ctx, stream)
+		if err != nil {
+			t.logger.Error("Failed to report stream usage", "error", err.Error())
+			continue
+		}
+		t.logger.Info("Stream usage",
+			"stream", usage.Stream,
+			"msgs", usage.Msgs,
+			"bytes", usage.Bytes,
+			"oldest_age", usage.OldestAge.String())
+	}
+}
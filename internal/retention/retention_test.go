@@ -0,0 +1,192 @@
+package retention
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockStream struct {
+	mock.Mock
+	info *jetstream.StreamInfo
+}
+
+func (m *MockStream) Info(ctx context.Context, opts ...jetstream.StreamInfoOpt) (*jetstream.StreamInfo, error) {
+	return m.info, nil
+}
+
+func (m *MockStream) Purge(ctx context.Context, opts ...jetstream.StreamPurgeOpt) error {
+	req := &jetstream.StreamPurgeRequest{}
+	for _, opt := range opts {
+		if err := opt(req); err != nil {
+			return err
+		}
+	}
+	args := m.Called(*req)
+	return args.Error(0)
+}
+
+func (m *MockStream) OrderedConsumer(ctx context.Context, cfg jetstream.OrderedConsumerConfig) (jetstream.Consumer, error) {
+	args := m.Called(cfg)
+	consumer, _ := args.Get(0).(jetstream.Consumer)
+	return consumer, args.Error(1)
+}
+
+type mockConsumer struct {
+	jetstream.Consumer
+	msg jetstream.Msg
+	err error
+}
+
+func (c *mockConsumer) Next(opts ...jetstream.FetchOpt) (jetstream.Msg, error) {
+	return c.msg, c.err
+}
+
+type mockMsg struct {
+	jetstream.Msg
+	sequence uint64
+}
+
+func (m mockMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{Sequence: jetstream.SequencePair{Stream: m.sequence}}, nil
+}
+
+func newMockStream(state jetstream.StreamState) *MockStream {
+	return &MockStream{
+		info: &jetstream.StreamInfo{
+			Config: jetstream.StreamConfig{Name: "archive"},
+			State:  state,
+		},
+	}
+}
+
+func TestReport(t *testing.T) {
+	stream := newMockStream(jetstream.StreamState{
+		Msgs:      10,
+		Bytes:     2048,
+		FirstTime: time.Now().Add(-time.Hour),
+	})
+
+	usage, err := Report(context.Background(), stream)
+	require.NoError(t, err, "report should not return error")
+
+	assert.Equal(t, "archive", usage.Stream)
+	assert.Equal(t, uint64(10), usage.Msgs)
+	assert.Equal(t, uint64(2048), usage.Bytes)
+	assert.InDelta(t, time.Hour.Seconds(), usage.OldestAge.Seconds(), 5, "oldest age should be about an hour")
+}
+
+func TestPurgeOlderThan(t *testing.T) {
+
+	now := time.Now()
+
+	for _, tc := range []struct {
+		title            string
+		state            jetstream.StreamState
+		subject          string
+		consumer         *mockConsumer
+		expectedSequence uint64
+		expectedPurge    *jetstream.StreamPurgeRequest
+	}{
+		{
+			title:            "nothing purged from empty stream",
+			state:            jetstream.StreamState{},
+			expectedSequence: 0,
+		},
+		{
+			title: "nothing purged when oldest message is newer than max age",
+			state: jetstream.StreamState{
+				Msgs:      5,
+				FirstSeq:  1,
+				FirstTime: now.Add(-time.Minute),
+				LastSeq:   5,
+				LastTime:  now,
+			},
+			expectedSequence: 0,
+		},
+		{
+			title: "all messages purged when newest message is older than max age",
+			state: jetstream.StreamState{
+				Msgs:      5,
+				FirstSeq:  1,
+				FirstTime: now.Add(-3 * time.Hour),
+				LastSeq:   5,
+				LastTime:  now.Add(-2 * time.Hour),
+			},
+			subject:          "archive.gitea.push",
+			expectedSequence: 6,
+			expectedPurge:    &jetstream.StreamPurgeRequest{Sequence: 6, Subject: "archive.gitea.push"},
+		},
+		{
+			title: "messages purged below first message newer than max age",
+			state: jetstream.StreamState{
+				Msgs:      5,
+				FirstSeq:  1,
+				FirstTime: now.Add(-3 * time.Hour),
+				LastSeq:   5,
+				LastTime:  now,
+			},
+			subject:          "archive.gitea.push",
+			consumer:         &mockConsumer{msg: mockMsg{sequence: 4}},
+			expectedSequence: 4,
+			expectedPurge:    &jetstream.StreamPurgeRequest{Sequence: 4, Subject: "archive.gitea.push"},
+		},
+		{
+			title: "all messages on subject purged when none is newer than max age",
+			state: jetstream.StreamState{
+				Msgs:      5,
+				FirstSeq:  1,
+				FirstTime: now.Add(-3 * time.Hour),
+				LastSeq:   5,
+				LastTime:  now,
+			},
+			subject:          "archive.gitea.push",
+			consumer:         &mockConsumer{err: nats.ErrTimeout},
+			expectedSequence: 6,
+			expectedPurge:    &jetstream.StreamPurgeRequest{Sequence: 6, Subject: "archive.gitea.push"},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			stream := newMockStream(tc.state)
+			if tc.consumer != nil {
+				stream.On("OrderedConsumer", mock.Anything).Return(tc.consumer, nil)
+			}
+			if tc.expectedPurge != nil {
+				stream.On("Purge", *tc.expectedPurge).Return(nil)
+			}
+
+			sequence, err := PurgeOlderThan(context.Background(), stream, time.Hour, tc.subject)
+			require.NoError(t, err, "purge should not return error")
+
+			assert.Equal(t, tc.expectedSequence, sequence)
+			if tc.expectedPurge != nil {
+				stream.AssertCalled(t, "Purge", *tc.expectedPurge)
+			} else {
+				stream.AssertNotCalled(t, "Purge", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestPurgeOlderThanFailsWithoutMessageSince(t *testing.T) {
+
+	now := time.Now()
+	stream := newMockStream(jetstream.StreamState{
+		Msgs:      5,
+		FirstSeq:  1,
+		FirstTime: now.Add(-3 * time.Hour),
+		LastSeq:   5,
+		LastTime:  now,
+	})
+	stream.On("OrderedConsumer", mock.Anything).Return(&mockConsumer{err: nats.ErrTimeout}, nil)
+
+	_, err := PurgeOlderThan(context.Background(), stream, time.Hour, "")
+	require.ErrorIs(t, err, errNoMessageSince)
+	stream.AssertNotCalled(t, "Purge", mock.Anything)
+}
@@ -0,0 +1,271 @@
+// Package schema tracks the schema of webhook payloads by webhook subject and detects drift: fields
+// the translators depend on that disappear or change type, which would otherwise only show as
+// silently wrong CDEvents.
+package schema
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/metrics"
+)
+
+const (
+	// DriftMissing is the kind of drift of a watched field that is not in a payload.
+	DriftMissing = "missing"
+	// DriftTypeChanged is the kind of drift of a watched field with another type than before.
+	DriftTypeChanged = "type_changed"
+
+	// maxFields bounds the number of fields tracked per subject, e.g. for payloads with maps
+	// keyed by arbitrary names.
+	maxFields = 1000
+)
+
+type Config struct {
+	Enabled bool `envconfig:"ENABLED" default:"false"`
+	// MinSamples is the number of payloads the fields to watch are learned from for translators
+	// that do not declare the fields they depend on. Fields that are in every one of them are
+	// watched.
+	MinSamples int `envconfig:"MIN_SAMPLES" default:"10"`
+}
+
+func (c Config) Validate() error {
+	if c.MinSamples < 1 {
+		return fmt.Errorf("schema drift minimum samples must be at least 1, got %d", c.MinSamples)
+	}
+	return nil
+}
+
+// Drift is a watched field of the payloads of a subject that is missing or has changed type.
+type Drift struct {
+	Field    string    `json:"field"`
+	Kind     string    `json:"kind"`
+	Expected string    `json:"expected"`
+	Actual   string    `json:"actual,omitempty"`
+	Since    time.Time `json:"since"`
+}
+
+// Schema is the observed schema of the payloads of a webhook subject.
+type Schema struct {
+	Subject string `json:"subject"`
+	Samples int    `json:"samples"`
+	// Fields are the types of every observed field by JSONPath, where [*] stands for the
+	// elements of an array.
+	Fields  map[string]string `json:"fields"`
+	Watched []string          `json:"watched"`
+	Drifts  []Drift           `json:"drifts,omitempty"`
+}
+
+type field struct {
+	typ  string
+	seen int
+}
+
+type subjectSchema struct {
+	samples int
+	fields  map[string]*field
+	// learned are the watched fields learned from the first payloads, for translators that do
+	// not declare their fields.
+	learned []string
+	watched []string
+	drifts  map[string]Drift
+}
+
+// Detector observes webhook payloads and reports drift of the watched fields of every subject:
+// the fields declared by the translator, or else the fields that were in every one of the first
+// payloads. The type of a field is the first type it was observed with. Null values are
+// compatible with every type. Drift is logged when a field starts drifting and counted in the
+// schema drift metric for every payload.
+type Detector struct {
+	logger     *slog.Logger
+	minSamples int
+
+	mu       sync.Mutex
+	subjects map[string]*subjectSchema
+}
+
+func NewDetector(logger *slog.Logger, config Config) *Detector {
+	return &Detector{
+		logger:     logger,
+		minSamples: config.MinSamples,
+		subjects:   make(map[string]*subjectSchema),
+	}
+}
+
+// ObserveSchema records the schema of a payload of the subject and checks the watched fields,
+// which are the given fields if any.
+func (d *Detector) ObserveSchema(subject string, fields []string, doc map[string]interface{}) {
+	observed := make(map[string]string)
+	emptyArrays := make(map[string]bool)
+	for key, value := range doc {
+		flatten("$."+key, value, observed, emptyArrays)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	s, exists := d.subjects[subject]
+	if !exists {
+		s = &subjectSchema{fields: make(map[string]*field), drifts: make(map[string]Drift)}
+		d.subjects[subject] = s
+	}
+
+	watched := fields
+	if len(watched) == 0 {
+		watched = s.learned
+	}
+	s.watched = watched
+
+	now := time.Now()
+	for _, path := range watched {
+		known, tracked := s.fields[path]
+		actual, present := observed[path]
+
+		var drift *Drift
+		switch {
+		case !present && !underEmptyArray(path, emptyArrays) && (tracked || len(fields) > 0):
+			expected := "any"
+			if tracked {
+				expected = known.typ
+			}
+			drift = &Drift{Field: path, Kind: DriftMissing, Expected: expected}
+		case present && tracked && actual != "null" && known.typ != "null" && actual != known.typ:
+			drift = &Drift{Field: path, Kind: DriftTypeChanged, Expected: known.typ, Actual: actual}
+		}
+
+		if drift == nil {
+			delete(s.drifts, path)
+			continue
+		}
+
+		metrics.SchemaDrift.WithLabelValues(subject, path, drift.Kind).Inc()
+		if previous, drifting := s.drifts[path]; drifting && previous.Kind == drift.Kind && previous.Actual == drift.Actual {
+			continue
+		}
+		drift.Since = now
+		s.drifts[path] = *drift
+		d.logger.Warn(fmt.Sprintf("Schema drift detected for webhook subject %s: field %s is %s", subject, path, describe(*drift)),
+			"subject", subject, "field", path, "kind", drift.Kind)
+	}
+
+	s.samples++
+	for path, typ := range observed {
+		f, tracked := s.fields[path]
+		if !tracked {
+			if len(s.fields) >= maxFields {
+				continue
+			}
+			f = &field{typ: typ}
+			s.fields[path] = f
+		} else if f.typ == "null" && typ != "null" {
+			f.typ = typ
+		}
+		f.seen++
+	}
+
+	if len(fields) == 0 && s.learned == nil && s.samples >= d.minSamples {
+		learned := []string{}
+		for path, f := range s.fields {
+			if f.seen == s.samples {
+				learned = append(learned, path)
+			}
+		}
+		sort.Strings(learned)
+		s.learned = learned
+		s.watched = learned
+		d.logger.Info(fmt.Sprintf("Watching %d fields of webhook subject %s for schema drift", len(learned), subject))
+	}
+}
+
+// Schemas returns the observed schema of every subject, sorted by subject.
+func (d *Detector) Schemas() []Schema {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	schemas := make([]Schema, 0, len(d.subjects))
+	for subject, s := range d.subjects {
+		schema := Schema{
+			Subject: subject,
+			Samples: s.samples,
+			Fields:  make(map[string]string, len(s.fields)),
+			Watched: append([]string{}, s.watched...),
+		}
+		for path, f := range s.fields {
+			schema.Fields[path] = f.typ
+		}
+		for _, drift := range s.drifts {
+			schema.Drifts = append(schema.Drifts, drift)
+		}
+		sort.Slice(schema.Drifts, func(i, j int) bool { return schema.Drifts[i].Field < schema.Drifts[j].Field })
+		schemas = append(schemas, schema)
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Subject < schemas[j].Subject })
+	return schemas
+}
+
+func describe(drift Drift) string {
+	if drift.Kind == DriftMissing {
+		return "missing"
+	}
+	return fmt.Sprintf("%s instead of %s", drift.Actual, drift.Expected)
+}
+
+// flatten records the type of every field of a decoded JSON value by path, with the elements of
+// arrays under [*], and the paths of empty arrays.
+func flatten(path string, value interface{}, observed map[string]string, emptyArrays map[string]bool) {
+	typ := typeOf(value)
+	if previous, seen := observed[path]; !seen || previous == "null" {
+		observed[path] = typ
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			flatten(path+"."+key, child, observed, emptyArrays)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			emptyArrays[path] = true
+		}
+		for _, element := range v {
+			flatten(path+"[*]", element, observed, emptyArrays)
+		}
+	}
+}
+
+// underEmptyArray reports whether a path is below an array that is empty or absent because of
+// an empty array higher up, in which case the field is not expected to be there.
+func underEmptyArray(path string, emptyArrays map[string]bool) bool {
+	for i := strings.Index(path, "[*]"); i >= 0; {
+		if emptyArrays[path[:i]] {
+			return true
+		}
+		next := strings.Index(path[i+3:], "[*]")
+		if next < 0 {
+			break
+		}
+		i += 3 + next
+	}
+	return false
+}
+
+func typeOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	default:
+		return "number"
+	}
+}
@@ -0,0 +1,117 @@
+package schema
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decode(t *testing.T, payload string) map[string]interface{} {
+	var doc map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(payload), &doc))
+	return doc
+}
+
+func drifts(d *Detector) map[string]string {
+	kinds := make(map[string]string)
+	for _, schema := range d.Schemas() {
+		for _, drift := range schema.Drifts {
+			kinds[schema.Subject+" "+drift.Field] = drift.Kind
+		}
+	}
+	return kinds
+}
+
+func TestDetectorDeclaredFields(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	fields := []string{"$.ref", "$.commits[*].id", "$.repository.full_name"}
+
+	for _, tc := range []struct {
+		title          string
+		payloads       []string
+		expectedDrifts map[string]string
+	}{
+		{
+			title: "no drift",
+			payloads: []string{
+				`{"ref": "refs/heads/main", "commits": [{"id": "a"}], "repository": {"full_name": "org/repo"}}`,
+				`{"ref": "refs/heads/main", "commits": [], "repository": {"full_name": "org/repo"}, "extra": 1}`,
+			},
+			expectedDrifts: map[string]string{},
+		},
+		{
+			title: "missing field",
+			payloads: []string{
+				`{"ref": "refs/heads/main", "commits": [{"id": "a"}], "repository": {"full_name": "org/repo"}}`,
+				`{"ref": "refs/heads/main", "commits": [{"sha": "a"}], "repository": {"name": "repo"}}`,
+			},
+			expectedDrifts: map[string]string{"gitea.push $.commits[*].id": DriftMissing, "gitea.push $.repository.full_name": DriftMissing},
+		},
+		{
+			title: "missing in first payload",
+			payloads: []string{
+				`{"commits": [{"id": "a"}], "repository": {"full_name": "org/repo"}}`,
+			},
+			expectedDrifts: map[string]string{"gitea.push $.ref": DriftMissing},
+		},
+		{
+			title: "changed type",
+			payloads: []string{
+				`{"ref": "refs/heads/main", "commits": [{"id": "a"}], "repository": {"full_name": "org/repo"}}`,
+				`{"ref": {"name": "main"}, "commits": [{"id": "a"}], "repository": {"full_name": "org/repo"}}`,
+			},
+			expectedDrifts: map[string]string{"gitea.push $.ref": DriftTypeChanged},
+		},
+		{
+			title: "null is compatible",
+			payloads: []string{
+				`{"ref": "refs/heads/main", "commits": [{"id": "a"}], "repository": {"full_name": "org/repo"}}`,
+				`{"ref": null, "commits": [{"id": "a"}], "repository": {"full_name": "org/repo"}}`,
+			},
+			expectedDrifts: map[string]string{},
+		},
+		{
+			title: "drift is cleared",
+			payloads: []string{
+				`{"ref": "refs/heads/main", "commits": [{"id": "a"}], "repository": {"full_name": "org/repo"}}`,
+				`{"ref": 1, "commits": [{"id": "a"}], "repository": {"full_name": "org/repo"}}`,
+				`{"ref": "refs/heads/main", "commits": [{"id": "a"}], "repository": {"full_name": "org/repo"}}`,
+			},
+			expectedDrifts: map[string]string{},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			detector := NewDetector(logger, Config{MinSamples: 10})
+			for _, payload := range tc.payloads {
+				detector.ObserveSchema("gitea.push", fields, decode(t, payload))
+			}
+			assert.Equal(t, tc.expectedDrifts, drifts(detector))
+		})
+	}
+}
+
+func TestDetectorLearnsWatchedFields(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	detector := NewDetector(logger, Config{MinSamples: 2})
+
+	detector.ObserveSchema("forgejo.push", nil, decode(t, `{"ref": "a", "optional": true}`))
+	detector.ObserveSchema("forgejo.push", nil, decode(t, `{"ref": "b"}`))
+
+	schemas := detector.Schemas()
+	require.Len(t, schemas, 1)
+	assert.Equal(t, Schema{
+		Subject: "forgejo.push",
+		Samples: 2,
+		Fields:  map[string]string{"$.ref": "string", "$.optional": "boolean"},
+		Watched: []string{"$.ref"},
+	}, schemas[0])
+
+	detector.ObserveSchema("forgejo.push", nil, decode(t, `{"optional": false}`))
+	assert.Equal(t, map[string]string{"forgejo.push $.ref": DriftMissing}, drifts(detector))
+}
@@ -0,0 +1,74 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+)
+
+type secretsManagerAPI interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+// AWSProvider reads secrets from AWS Secrets Manager. Paths are the name or ARN of a secret,
+// optionally followed by #<key> to read a key of a secret stored as a JSON object. The AWS
+// configuration is loaded when the first secret is fetched.
+type AWSProvider struct {
+	region  string
+	once    sync.Once
+	client  secretsManagerAPI
+	loadErr error
+}
+
+func NewAWSProvider(region string) *AWSProvider {
+	return &AWSProvider{region: region}
+}
+
+func (p *AWSProvider) Fetch(ctx context.Context, path string) (string, error) {
+	p.once.Do(func() {
+		if p.client != nil {
+			return
+		}
+		var opts []func(*awsconfig.LoadOptions) error
+		if p.region != "" {
+			opts = append(opts, awsconfig.WithRegion(p.region))
+		}
+		awsConfig, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+		if err != nil {
+			p.loadErr = fmt.Errorf("failed to load AWS configuration: %w", err)
+			return
+		}
+		p.client = secretsmanager.NewFromConfig(awsConfig)
+	})
+	if p.loadErr != nil {
+		return "", p.loadErr
+	}
+
+	name, key, hasKey := cutKey(path)
+
+	output, err := p.client.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(name)})
+	if err != nil {
+		return "", err
+	}
+	if output.SecretString == nil {
+		return "", fmt.Errorf("secret %s has no string value", name)
+	}
+	if !hasKey {
+		return *output.SecretString, nil
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(*output.SecretString), &values); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object: %w", name, err)
+	}
+	value, ok := values[key].(string)
+	if !ok {
+		return "", fmt.Errorf("no string value %s in secret %s", key, name)
+	}
+	return value, nil
+}
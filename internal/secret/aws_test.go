@@ -0,0 +1,37 @@
+package secret
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockSecretsManager map[string]string
+
+func (m mockSecretsManager) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(m[*params.SecretId])}, nil
+}
+
+func TestAWSProvider(t *testing.T) {
+
+	provider := NewAWSProvider("")
+	provider.client = mockSecretsManager{
+		"adapter/hmac": "s3cr3t",
+		"adapter/nats": `{"user": "adapter", "password": "p4ss"}`,
+	}
+
+	secret, err := provider.Fetch(context.Background(), "adapter/hmac")
+	require.NoError(t, err)
+	assert.Equal(t, "s3cr3t", secret)
+
+	secret, err = provider.Fetch(context.Background(), "adapter/nats#password")
+	require.NoError(t, err)
+	assert.Equal(t, "p4ss", secret)
+
+	_, err = provider.Fetch(context.Background(), "adapter/hmac#password")
+	assert.ErrorContains(t, err, "secret adapter/hmac is not a JSON object")
+}
@@ -0,0 +1,178 @@
+// Package secret resolves secrets that are referenced in the configuration instead of being
+// given as plain values, e.g. "${secret:file:/run/secrets/hmac}" or
+// "${secret:vault:secret/adapter#hmac}".
+package secret
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	// TTL is how long resolved secrets are cached before they are fetched again.
+	TTL time.Duration `envconfig:"TTL" default:"5m"`
+	// RenewInterval is how often cached secrets are renewed in the background. Zero disables
+	// renewal, in which case secrets are fetched again when they have expired.
+	RenewInterval time.Duration `envconfig:"RENEW_INTERVAL" default:"0"`
+	AWSRegion     string        `envconfig:"AWS_REGION"`
+}
+
+// Provider fetches the secret at a path from a secret source.
+type Provider interface {
+	Fetch(ctx context.Context, path string) (string, error)
+}
+
+type cachedSecret struct {
+	value   string
+	fetched time.Time
+}
+
+// Resolver resolves references of the form ${secret:<provider>:<path>} with the registered
+// providers and caches the secrets. Other values are returned as is, so that a plain value that
+// happens to contain a colon is never taken for a reference.
+type Resolver struct {
+	providers map[string]Provider
+	ttl       time.Duration
+	mu        sync.Mutex
+	cache     map[string]cachedSecret
+}
+
+// NewResolver returns a resolver with the "env" and "file" providers registered.
+func NewResolver(ttl time.Duration) *Resolver {
+	return &Resolver{
+		providers: map[string]Provider{"env": envProvider{}, "file": fileProvider{}},
+		ttl:       ttl,
+		cache:     make(map[string]cachedSecret),
+	}
+}
+
+func (r *Resolver) Register(name string, provider Provider) {
+	r.providers[name] = provider
+}
+
+// Resolve returns the secret for a value. A secret that cannot be renewed is served from the
+// cache until it can. A malformed reference, or a reference to a provider that is not
+// registered, is an error.
+func (r *Resolver) Resolve(ctx context.Context, value string) (string, error) {
+	if !strings.HasPrefix(value, referencePrefix) {
+		return value, nil
+	}
+	provider, path, err := r.lookup(value)
+	if err != nil {
+		return "", err
+	}
+
+	r.mu.Lock()
+	cached, isCached := r.cache[value]
+	r.mu.Unlock()
+
+	if isCached && time.Since(cached.fetched) < r.ttl {
+		return cached.value, nil
+	}
+
+	secret, err := provider.Fetch(ctx, path)
+	if err != nil {
+		if isCached {
+			return cached.value, nil
+		}
+		return "", fmt.Errorf("failed to resolve secret %s: %w", value, err)
+	}
+
+	r.mu.Lock()
+	r.cache[value] = cachedSecret{value: secret, fetched: time.Now()}
+	r.mu.Unlock()
+
+	return secret, nil
+}
+
+// Run renews the cached secrets every interval until the context is cancelled.
+func (r *Resolver) Run(ctx context.Context, logger *slog.Logger, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.renew(ctx, logger)
+		}
+	}
+}
+
+func (r *Resolver) renew(ctx context.Context, logger *slog.Logger) {
+	r.mu.Lock()
+	references := make([]string, 0, len(r.cache))
+	for reference := range r.cache {
+		references = append(references, reference)
+	}
+	r.mu.Unlock()
+
+	for _, reference := range references {
+		provider, path, err := r.lookup(reference)
+		if err != nil {
+			continue
+		}
+		secret, err := provider.Fetch(ctx, path)
+		if err != nil {
+			logger.Warn("Failed to renew secret", "secret", reference, "error", err.Error())
+			continue
+		}
+
+		r.mu.Lock()
+		r.cache[reference] = cachedSecret{value: secret, fetched: time.Now()}
+		r.mu.Unlock()
+	}
+}
+
+// Secret references are enclosed in referencePrefix and referenceSuffix.
+const (
+	referencePrefix = "${secret:"
+	referenceSuffix = "}"
+)
+
+// lookup returns the provider and path of a secret reference.
+func (r *Resolver) lookup(reference string) (Provider, string, error) {
+	inner, closed := strings.CutSuffix(strings.TrimPrefix(reference, referencePrefix), referenceSuffix)
+	name, path, found := strings.Cut(inner, ":")
+	if !closed || !found || name == "" || path == "" {
+		return nil, "", fmt.Errorf("invalid secret reference %s, expected ${secret:<provider>:<path>}", reference)
+	}
+	provider, exists := r.providers[name]
+	if !exists {
+		return nil, "", fmt.Errorf("unknown secret provider %s in %s", name, reference)
+	}
+	return provider, path, nil
+}
+
+type envProvider struct{}
+
+func (envProvider) Fetch(ctx context.Context, name string) (string, error) {
+	value, found := os.LookupEnv(name)
+	if !found {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+type fileProvider struct{}
+
+func (fileProvider) Fetch(ctx context.Context, path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// cutKey splits a path of the form <path>#<key> into the path and the key of a value in a
+// secret with several values.
+func cutKey(path string) (string, string, bool) {
+	path, key, found := strings.Cut(path, "#")
+	return path, key, found && key != ""
+}
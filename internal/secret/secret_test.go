@@ -0,0 +1,107 @@
+package secret
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingProvider struct {
+	value string
+	err   error
+	calls int
+}
+
+func (p *countingProvider) Fetch(ctx context.Context, path string) (string, error) {
+	p.calls++
+	if p.err != nil {
+		return "", p.err
+	}
+	return p.value + ":" + path, nil
+}
+
+func TestResolve(t *testing.T) {
+
+	dir := t.TempDir()
+	secretFile := filepath.Join(dir, "hmac")
+	require.NoError(t, os.WriteFile(secretFile, []byte("from-file\n"), 0600))
+	t.Setenv("TEST_SECRET", "from-env")
+
+	resolver := NewResolver(time.Minute)
+
+	for _, tc := range []struct {
+		value         string
+		expected      string
+		expectedError string
+	}{
+		{value: "plain-secret", expected: "plain-secret"},
+		{value: "env:TEST_SECRET", expected: "env:TEST_SECRET"},
+		{value: "${secret:env:TEST_SECRET}", expected: "from-env"},
+		{value: "${secret:file:" + secretFile + "}", expected: "from-file"},
+		{value: "${secret:env:MISSING_TEST_SECRET}", expectedError: "environment variable MISSING_TEST_SECRET is not set"},
+		{value: "${secret:vault:secret/adapter#hmac}", expectedError: "unknown secret provider vault"},
+		{value: "${secret:env:TEST_SECRET", expectedError: "invalid secret reference"},
+		{value: "${secret:TEST_SECRET}", expectedError: "invalid secret reference"},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			secret, err := resolver.Resolve(context.Background(), tc.value)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, secret)
+		})
+	}
+}
+
+func TestResolveCaches(t *testing.T) {
+
+	provider := &countingProvider{value: "secret"}
+	resolver := NewResolver(time.Hour)
+	resolver.Register("test", provider)
+
+	for i := 0; i < 3; i++ {
+		secret, err := resolver.Resolve(context.Background(), "${secret:test:a}")
+		require.NoError(t, err)
+		assert.Equal(t, "secret:a", secret)
+	}
+	assert.Equal(t, 1, provider.calls)
+
+	resolver.ttl = 0
+	provider.value = "renewed"
+	secret, err := resolver.Resolve(context.Background(), "${secret:test:a}")
+	require.NoError(t, err)
+	assert.Equal(t, "renewed:a", secret)
+
+	provider.err = fmt.Errorf("unavailable")
+	secret, err = resolver.Resolve(context.Background(), "${secret:test:a}")
+	require.NoError(t, err, "cached secret should be served when renewal fails")
+	assert.Equal(t, "renewed:a", secret)
+}
+
+func TestRenew(t *testing.T) {
+
+	provider := &countingProvider{value: "secret"}
+	resolver := NewResolver(time.Hour)
+	resolver.Register("test", provider)
+
+	_, err := resolver.Resolve(context.Background(), "${secret:test:a}")
+	require.NoError(t, err)
+
+	provider.value = "renewed"
+	resolver.renew(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	secret, err := resolver.Resolve(context.Background(), "${secret:test:a}")
+	require.NoError(t, err)
+	assert.Equal(t, "renewed:a", secret)
+	assert.Equal(t, 2, provider.calls)
+}
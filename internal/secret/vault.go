@@ -0,0 +1,108 @@
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+type VaultConfig struct {
+	Address string `envconfig:"ADDR"`
+	Token   string `envconfig:"TOKEN" secret:"true"`
+	// TokenFile is read on every request so that a token renewed by e.g. Vault Agent is used.
+	TokenFile string        `envconfig:"TOKEN_FILE"`
+	Namespace string        `envconfig:"NAMESPACE"`
+	Timeout   time.Duration `envconfig:"TIMEOUT" default:"10s"`
+}
+
+// VaultProvider reads secrets from a Vault KV version 2 secrets engine. Paths have the form
+// <mount>/<path>#<key>, e.g. "secret/adapter#hmac", where the key defaults to "value".
+type VaultProvider struct {
+	client *http.Client
+	config VaultConfig
+}
+
+func NewVaultProvider(config VaultConfig) (*VaultProvider, error) {
+	if config.Address == "" {
+		return nil, fmt.Errorf("no Vault address configured")
+	}
+	if config.Token == "" && config.TokenFile == "" {
+		return nil, fmt.Errorf("no Vault token configured")
+	}
+
+	return &VaultProvider{
+		client: &http.Client{Timeout: config.Timeout},
+		config: config,
+	}, nil
+}
+
+func (p *VaultProvider) Fetch(ctx context.Context, path string) (string, error) {
+	path, key, hasKey := cutKey(path)
+	if !hasKey {
+		key = "value"
+	}
+	mount, secretPath, found := strings.Cut(path, "/")
+	if !found || secretPath == "" {
+		return "", fmt.Errorf("invalid Vault secret path %s, expected <mount>/<path>", path)
+	}
+
+	token, err := p.token()
+	if err != nil {
+		return "", err
+	}
+
+	endpoint, err := url.JoinPath(p.config.Address, "v1", mount, "data", secretPath)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if p.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.config.Namespace)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected response from Vault: %s", resp.Status)
+	}
+
+	var secret struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("invalid response from Vault: %w", err)
+	}
+
+	value, ok := secret.Data.Data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("no string value %s in Vault secret %s", key, path)
+	}
+	return value, nil
+}
+
+func (p *VaultProvider) token() (string, error) {
+	if p.config.TokenFile == "" {
+		return p.config.Token, nil
+	}
+	data, err := os.ReadFile(p.config.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read Vault token: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,85 @@
+package secret
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVaultProvider(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/adapter" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"data": {"data": {"value": "default", "hmac": "s3cr3t"}, "metadata": {"version": 2}}}`))
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("vault-token\n"), 0600))
+
+	for _, tc := range []struct {
+		title         string
+		config        VaultConfig
+		path          string
+		expected      string
+		expectedError string
+	}{
+		{
+			title:    "reads key of secret",
+			config:   VaultConfig{Token: "vault-token"},
+			path:     "secret/adapter#hmac",
+			expected: "s3cr3t",
+		},
+		{
+			title:    "reads value key by default with token from file",
+			config:   VaultConfig{TokenFile: tokenFile},
+			path:     "secret/adapter",
+			expected: "default",
+		},
+		{
+			title:         "fails on missing key",
+			config:        VaultConfig{Token: "vault-token"},
+			path:          "secret/adapter#password",
+			expectedError: "no string value password in Vault secret secret/adapter",
+		},
+		{
+			title:         "fails on missing secret",
+			config:        VaultConfig{Token: "vault-token"},
+			path:          "secret/other",
+			expectedError: "404 Not Found",
+		},
+		{
+			title:         "fails without token",
+			config:        VaultConfig{Token: "wrong"},
+			path:          "secret/adapter",
+			expectedError: "403 Forbidden",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			tc.config.Address = server.URL
+			provider, err := NewVaultProvider(tc.config)
+			require.NoError(t, err)
+
+			secret, err := provider.Fetch(context.Background(), tc.path)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, secret)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Package selftest checks a running adapter end to end: a synthetic webhook is published on the
+// webhook stream and the test passes once the event translated from it is in the event stream.
+package selftest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// Repository is the repository of the synthetic webhooks, for consumers that need to tell the
+// events of self tests apart.
+const Repository = "cdevents-adapter/selftest"
+
+type Config struct {
+	// WebhookSubject is the subject of Gitea push webhooks in the webhook stream, e.g.
+	// "webhooks.gitea.push".
+	WebhookSubject string
+	Timeout        time.Duration
+}
+
+type Publisher interface {
+	PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+}
+
+type Stream interface {
+	Info(ctx context.Context, opts ...jetstream.StreamInfoOpt) (*jetstream.StreamInfo, error)
+	OrderedConsumer(ctx context.Context, cfg jetstream.OrderedConsumerConfig) (jetstream.Consumer, error)
+}
+
+type Result struct {
+	Commit       string        `json:"commit"`
+	EventSubject string        `json:"event_subject"`
+	Latency      time.Duration `json:"latency"`
+}
+
+// Run publishes a Gitea push webhook for a random commit on the webhook stream and waits for an
+// event about the commit in the event stream. Only events published after Run was called are
+// considered. The webhook message is marked as a self test, so that its event is published with
+// the selftest extension and only to JetStream sinks. The webhook endpoint does not accept the
+// mark, so that webhook senders cannot keep their events away from the sinks.
+func Run(ctx context.Context, publisher Publisher, stream Stream, config Config) (Result, error) {
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.Timeout)
+	defer cancel()
+
+	commit, err := randomCommit()
+	if err != nil {
+		return Result{}, err
+	}
+	result := Result{Commit: commit}
+
+	// Events are read from the end of the stream before the webhook is sent, so that the event
+	// cannot be missed.
+	info, err := stream.Info(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to look up event stream: %w", err)
+	}
+	consumer, err := stream.OrderedConsumer(ctx, jetstream.OrderedConsumerConfig{
+		DeliverPolicy: jetstream.DeliverByStartSequencePolicy,
+		OptStartSeq:   info.State.LastSeq + 1,
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to consume event stream: %w", err)
+	}
+
+	start := time.Now()
+	if err := publishWebhook(ctx, publisher, config.WebhookSubject, commit); err != nil {
+		return result, err
+	}
+
+	for {
+		msg, err := consumer.Next(jetstream.FetchMaxWait(time.Second))
+		if ctx.Err() != nil {
+			return result, fmt.Errorf("no event for commit %s within %s", commit, config.Timeout)
+		}
+		if errors.Is(err, nats.ErrTimeout) {
+			continue
+		} else if err != nil {
+			return result, fmt.Errorf("failed to read event stream: %w", err)
+		}
+
+		// The commit is the subject id of the event, whether the event is in binary or
+		// structured mode.
+		if bytes.Contains(msg.Data(), []byte(commit)) {
+			result.EventSubject = msg.Subject()
+			result.Latency = time.Since(start)
+			return result, nil
+		}
+	}
+}
+
+func publishWebhook(ctx context.Context, publisher Publisher, subject, commit string) error {
+	payload := fmt.Sprintf(`{
+		"ref": "refs/heads/main",
+		"after": %[1]q,
+		"commits": [{"id": %[1]q, "message": "cdevents-adapter selftest\n"}],
+		"total_commits": 1,
+		"head_commit": {"id": %[1]q, "message": "cdevents-adapter selftest\n"},
+		"repository": {"full_name": %[2]q, "html_url": "http://selftest.invalid/%[2]s"}
+	}`, commit, Repository)
+
+	msg := nats.NewMsg(subject)
+	msg.Data = []byte(payload)
+	msg.Header.Set(adapter.DeliveryIDHeader, "selftest-"+commit)
+	msg.Header.Set(adapter.RepositoryHeader, Repository)
+	msg.Header.Set(adapter.SelftestHeader, "true")
+
+	if _, err := publisher.PublishMsg(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish webhook: %w", err)
+	}
+	return nil
+}
+
+func randomCommit() (string, error) {
+	sha := make([]byte, 20)
+	if _, err := rand.Read(sha); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(sha), nil
+}
@@ -0,0 +1,99 @@
+package selftest
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStream(t *testing.T) (*nats.Conn, jetstream.JetStream, jetstream.Stream) {
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      server.RANDOM_PORT,
+		JetStream: true,
+		StoreDir:  t.TempDir(),
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	require.NoError(t, err)
+	ns.Start()
+	t.Cleanup(ns.Shutdown)
+	require.True(t, ns.ReadyForConnections(10*time.Second))
+
+	nc, err := nats.Connect(ns.ClientURL())
+	require.NoError(t, err)
+	t.Cleanup(nc.Close)
+
+	js, err := jetstream.New(nc)
+	require.NoError(t, err)
+
+	stream, err := js.CreateStream(context.Background(), jetstream.StreamConfig{Name: "events", Subjects: []string{"dev.cdevents.>"}})
+	require.NoError(t, err)
+
+	return nc, js, stream
+}
+
+func TestRun(t *testing.T) {
+
+	for _, tc := range []struct {
+		title         string
+		webhookStream bool
+		publish       bool
+		expectedErr   string
+	}{
+		{title: "passes when event is published", webhookStream: true, publish: true},
+		{title: "fails when webhook cannot be published", expectedErr: "failed to publish webhook"},
+		{title: "fails when no event is published", webhookStream: true, expectedErr: "no event for commit"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			nc, js, stream := newTestStream(t)
+
+			if tc.webhookStream {
+				_, err := js.CreateStream(context.Background(), jetstream.StreamConfig{Name: "webhooks", Subjects: []string{"webhooks.>"}})
+				require.NoError(t, err)
+			}
+
+			// The subscription stands in for the adapter and publishes an unrelated event before
+			// the one translated from the webhook.
+			sub, err := nc.Subscribe("webhooks.gitea.push", func(msg *nats.Msg) {
+				assert.Equal(t, "true", msg.Header.Get(adapter.SelftestHeader))
+				assert.Equal(t, Repository, msg.Header.Get(adapter.RepositoryHeader))
+
+				var payload struct {
+					After string `json:"after"`
+				}
+				require.NoError(t, json.Unmarshal(msg.Data, &payload))
+				assert.Equal(t, "selftest-"+payload.After, msg.Header.Get(adapter.DeliveryIDHeader))
+
+				if tc.publish {
+					_, err := js.Publish(context.Background(), "dev.cdevents.branch.created.0.2.0", []byte(`{"subject": {"id": "foo"}}`))
+					require.NoError(t, err)
+					_, err = js.Publish(context.Background(), "dev.cdevents.change.merged.0.2.0", []byte(`{"subject": {"id": "`+payload.After+`"}}`))
+					require.NoError(t, err)
+				}
+			})
+			require.NoError(t, err)
+			defer sub.Unsubscribe()
+
+			result, err := Run(context.Background(), js, stream, Config{WebhookSubject: "webhooks.gitea.push", Timeout: 2 * time.Second})
+			if tc.expectedErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tc.expectedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, result.Commit, 40)
+			assert.Equal(t, "dev.cdevents.change.merged.0.2.0", result.EventSubject)
+			assert.Positive(t, result.Latency)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+)
+
+type ConsumerControl interface {
+	Pause() error
+	Resume() error
+	Paused() bool
+}
+
+type controlResponse struct {
+	Paused bool   `json:"paused"`
+	Error  string `json:"error,omitempty"`
+}
+
+// SubscribeControl listens for pause and resume commands on <subjectBase>.control.pause and
+// <subjectBase>.control.resume. No queue group is used so that every adapter instance receives
+// the command.
+func SubscribeControl(logger *slog.Logger, nc *nats.Conn, subjectBase string, control ConsumerControl) (*nats.Subscription, error) {
+	return nc.Subscribe(fmt.Sprintf("%s.control.*", subjectBase), controlHandler(logger, control))
+}
+
+func controlHandler(logger *slog.Logger, control ConsumerControl) nats.MsgHandler {
+	return func(msg *nats.Msg) {
+		var err error
+
+		command := msg.Subject[strings.LastIndex(msg.Subject, ".")+1:]
+		switch command {
+		case "pause":
+			err = control.Pause()
+		case "resume":
+			err = control.Resume()
+		case "state":
+		default:
+			err = fmt.Errorf("unknown control command: %s", command)
+		}
+
+		response := controlResponse{Paused: control.Paused()}
+		if err != nil {
+			logger.Error("Failed to execute control command", "command", command, "error", err.Error())
+			response.Error = err.Error()
+		}
+
+		if msg.Reply == "" {
+			return
+		}
+
+		data, _ := json.Marshal(response)
+		if err := msg.Respond(data); err != nil {
+			logger.Error("Failed to respond to control command", "command", command, "error", err.Error())
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockConsumerControl struct {
+	paused bool
+}
+
+func (m *mockConsumerControl) Pause() error  { m.paused = true; return nil }
+func (m *mockConsumerControl) Resume() error { m.paused = false; return nil }
+func (m *mockConsumerControl) Paused() bool  { return m.paused }
+
+func TestControlHandler(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	control := &mockConsumerControl{}
+	handler := controlHandler(logger, control)
+
+	handler(&nats.Msg{Subject: "adapter.control.pause"})
+	assert.True(t, control.paused, "consumer should be paused")
+
+	handler(&nats.Msg{Subject: "adapter.control.unknown"})
+	assert.True(t, control.paused, "unknown command should not change state")
+
+	handler(&nats.Msg{Subject: "adapter.control.resume"})
+	assert.False(t, control.paused, "consumer should be resumed")
+}
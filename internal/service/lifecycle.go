@@ -0,0 +1,94 @@
+package service
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	LifecycleStarted               = "dev.cdevents.adapter.service.started"
+	LifecycleStopped               = "dev.cdevents.adapter.service.stopped"
+	LifecycleConfigurationReloaded = "dev.cdevents.adapter.configuration.reloaded"
+)
+
+type NATSPublisher interface {
+	PublishMsg(msg *nats.Msg) error
+}
+
+type LifecycleData struct {
+	Service  string                 `json:"service"`
+	Instance string                 `json:"instance"`
+	Version  string                 `json:"version"`
+	Commit   string                 `json:"commit,omitempty"`
+	Started  time.Time              `json:"started"`
+	Uptime   string                 `json:"uptime,omitempty"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+}
+
+// LifecyclePublisher publishes CloudEvents about the adapter itself, such as it starting,
+// stopping and reloading its configuration, on <subjectBase>.lifecycle.<event>.
+type LifecyclePublisher struct {
+	nc          NATSPublisher
+	subjectBase string
+	source      string
+	data        LifecycleData
+}
+
+func NewLifecyclePublisher(nc NATSPublisher, subjectBase string, data LifecycleData) *LifecyclePublisher {
+	if data.Instance == "" {
+		data.Instance, _ = os.Hostname()
+	}
+
+	return &LifecyclePublisher{
+		nc:          nc,
+		subjectBase: subjectBase,
+		source:      "/" + data.Service + "/" + data.Instance,
+		data:        data,
+	}
+}
+
+func (p *LifecyclePublisher) Started() error {
+	return p.publish(LifecycleStarted, "started", nil)
+}
+
+func (p *LifecyclePublisher) Stopped() error {
+	return p.publish(LifecycleStopped, "stopped", nil)
+}
+
+func (p *LifecyclePublisher) ConfigurationReloaded(details map[string]interface{}) error {
+	return p.publish(LifecycleConfigurationReloaded, "configuration.reloaded", details)
+}
+
+func (p *LifecyclePublisher) publish(eventType string, name string, details map[string]interface{}) error {
+	data := p.data
+	data.Details = details
+	if eventType != LifecycleStarted {
+		data.Uptime = time.Since(data.Started).Round(time.Second).String()
+	}
+
+	event := cloudevents.NewEvent()
+	event.SetID(uuid.NewString())
+	event.SetType(eventType)
+	event.SetSource(p.source)
+	event.SetSubject(data.Service)
+	event.SetTime(time.Now())
+	if err := event.SetData(cloudevents.ApplicationJSON, data); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(p.subjectBase + ".lifecycle." + name)
+	msg.Header.Set("Content-Type", "application/cloudevents+json")
+	msg.Data = body
+
+	return p.nc.PublishMsg(msg)
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockNATSPublisher struct {
+	published []*nats.Msg
+}
+
+func (m *mockNATSPublisher) PublishMsg(msg *nats.Msg) error {
+	m.published = append(m.published, msg)
+	return nil
+}
+
+func TestLifecyclePublisher(t *testing.T) {
+
+	started := time.Now().Add(-time.Hour)
+
+	for _, tc := range []struct {
+		title           string
+		publish         func(p *LifecyclePublisher) error
+		expectedSubject string
+		expectedType    string
+		expectedUptime  string
+		expectedDetails map[string]interface{}
+	}{
+		{
+			title:           "publishes started event",
+			publish:         (*LifecyclePublisher).Started,
+			expectedSubject: "cdevents-adapter.lifecycle.started",
+			expectedType:    LifecycleStarted,
+		},
+		{
+			title:           "publishes stopped event with uptime",
+			publish:         (*LifecyclePublisher).Stopped,
+			expectedSubject: "cdevents-adapter.lifecycle.stopped",
+			expectedType:    LifecycleStopped,
+			expectedUptime:  "1h0m0s",
+		},
+		{
+			title: "publishes configuration reloaded event with details",
+			publish: func(p *LifecyclePublisher) error {
+				return p.ConfigurationReloaded(map[string]interface{}{"changed": []string{"LOG_LEVEL"}})
+			},
+			expectedSubject: "cdevents-adapter.lifecycle.configuration.reloaded",
+			expectedType:    LifecycleConfigurationReloaded,
+			expectedUptime:  "1h0m0s",
+			expectedDetails: map[string]interface{}{"changed": []interface{}{"LOG_LEVEL"}},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			nc := &mockNATSPublisher{}
+			publisher := NewLifecyclePublisher(nc, "cdevents-adapter", LifecycleData{
+				Service:  "cdevents-adapter",
+				Instance: "adapter-0",
+				Version:  "1.2.3",
+				Started:  started,
+			})
+
+			require.NoError(t, tc.publish(publisher))
+			require.Len(t, nc.published, 1)
+
+			msg := nc.published[0]
+			assert.Equal(t, tc.expectedSubject, msg.Subject)
+			assert.Equal(t, "application/cloudevents+json", msg.Header.Get("Content-Type"))
+
+			event := cloudevents.NewEvent()
+			require.NoError(t, json.Unmarshal(msg.Data, &event))
+			assert.Equal(t, tc.expectedType, event.Type())
+			assert.Equal(t, "/cdevents-adapter/adapter-0", event.Source())
+			require.NoError(t, event.Validate())
+
+			var data LifecycleData
+			require.NoError(t, event.DataAs(&data))
+			assert.Equal(t, "1.2.3", data.Version)
+			assert.Equal(t, tc.expectedUptime, data.Uptime)
+			assert.Equal(t, tc.expectedDetails, data.Details)
+		})
+	}
+}
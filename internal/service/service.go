@@ -0,0 +1,58 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
+)
+
+type StatsProvider interface {
+	Stats() adapter.ProcessingStats
+}
+
+type Config struct {
+	Name        string
+	Version     string
+	SubjectBase string
+	Metadata    map[string]string
+}
+
+// NewMicroService registers the adapter as a NATS micro service so that it can be discovered
+// and inspected with `nats micro ls/info/stats`. Processing statistics are attached to the
+// stats of every endpoint and can also be requested on the status endpoint.
+func NewMicroService(nc *nats.Conn, config Config, stats StatsProvider) (micro.Service, error) {
+	svc, err := micro.AddService(nc, micro.Config{
+		Name:        config.Name,
+		Version:     config.Version,
+		Description: "Translates incoming webhooks into CDEvents",
+		Metadata:    config.Metadata,
+		StatsHandler: func(e *micro.Endpoint) any {
+			return stats.Stats()
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	group := svc.AddGroup(config.SubjectBase)
+	if err := group.AddEndpoint("status", statusHandler(stats)); err != nil {
+		svc.Stop()
+		return nil, err
+	}
+
+	return svc, nil
+}
+
+func statusHandler(stats StatsProvider) micro.HandlerFunc {
+	return func(req micro.Request) {
+		data, err := json.Marshal(stats.Stats())
+		if err != nil {
+			req.Error("500", err.Error(), nil)
+			return
+		}
+		req.Respond(data)
+	}
+}
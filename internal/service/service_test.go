@@ -0,0 +1,44 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+	"github.com/nats-io/nats.go/micro"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticStats adapter.ProcessingStats
+
+func (s staticStats) Stats() adapter.ProcessingStats { return adapter.ProcessingStats(s) }
+
+type mockRequest struct {
+	micro.Request
+	response  []byte
+	errorCode string
+}
+
+func (m *mockRequest) Respond(data []byte, opts ...micro.RespondOpt) error {
+	m.response = data
+	return nil
+}
+
+func (m *mockRequest) Error(code, description string, data []byte, opts ...micro.RespondOpt) error {
+	m.errorCode = code
+	return nil
+}
+
+func TestStatusHandler(t *testing.T) {
+	stats := staticStats{Processed: 10, Failed: 2, ErrorRate: 0.2}
+
+	req := &mockRequest{}
+	statusHandler(stats).Handle(req)
+
+	require.Empty(t, req.errorCode, "no error should be responded")
+
+	var response adapter.ProcessingStats
+	require.NoError(t, json.Unmarshal(req.response, &response), "response must be valid json")
+	assert.Equal(t, adapter.ProcessingStats(stats), response)
+}
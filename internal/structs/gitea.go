@@ -4,16 +4,17 @@ type GiteaPushEvent struct {
 	Ref          string   `json:"ref"`
 	Before       string   `json:"before"`
 	After        string   `json:"after"`
-	Commits      []commit `json:"commits"`
+	Commits      []Commit `json:"commits"`
 	TotalCommits int      `json:"total_commits"`
-	HeadCommit   commit   `json:"head_commit"`
+	HeadCommit   Commit   `json:"head_commit"`
+	Pusher       *User    `json:"pusher,omitempty"`
 	commonFields
 }
 
 type GiteaPullRequestEvent struct {
 	Action      string      `json:"action"`
 	Number      int         `json:"number"`
-	PullRequest pullRequest `json:"pull_request"`
+	PullRequest PullRequest `json:"pull_request"`
 	commonFields
 }
 
@@ -31,16 +32,21 @@ type GiteaDeleteEvent struct {
 }
 
 type commonFields struct {
-	Repository struct {
-		Name  string `json:"name"`
-		Owner struct {
-			Username string `json:"username"`
-		} `json:"owner"`
-		FullName string `json:"full_name"`
-		Url      string `json:"url"`
-		HtmlUrl  string `json:"html_url"`
-		SshUrl   string `json:"ssh_url"`
-	} `json:"repository"`
+	Repository Repository `json:"repository"`
+	Sender     *User      `json:"sender,omitempty"`
+}
+
+type Repository struct {
+	Name  string `json:"name"`
+	Owner struct {
+		Username string `json:"username"`
+	} `json:"owner"`
+	FullName string `json:"full_name"`
+	Url      string `json:"url"`
+	HtmlUrl  string `json:"html_url"`
+	SshUrl   string `json:"ssh_url"`
+
+	DefaultBranch string `json:"default_branch"`
 }
 
 type authorCommitter struct {
@@ -49,22 +55,41 @@ type authorCommitter struct {
 	Username string `json:"username"`
 }
 
-type commit struct {
+type Commit struct {
 	Id        string          `json:"id"`
 	Message   string          `json:"message"`
 	Timestamp string          `json:"timestamp"`
 	Author    authorCommitter `json:"author"`
 	Committer authorCommitter `json:"committer"`
+
+	Verification *Verification `json:"verification,omitempty"`
+}
+
+type Verification struct {
+	Verified bool             `json:"verified"`
+	Reason   string           `json:"reason"`
+	Signer   *authorCommitter `json:"signer,omitempty"`
 }
 
-type pullRequest struct {
+type PullRequest struct {
 	Id        int            `json:"id"`
 	Title     string         `json:"title"`
+	HtmlUrl   string         `json:"html_url"`
 	Base      pullRequestRef `json:"base"`
 	Head      pullRequestRef `json:"head"`
 	CreatedAt string         `json:"created_at"`
 	UpdatedAt string         `json:"updated_at"`
 	ClosedAt  string         `json:"closed_at"`
+
+	MergeCommitSha string `json:"merge_commit_sha,omitempty"`
+	MergedBy       *User  `json:"merged_by,omitempty"`
+}
+
+type User struct {
+	ID       int64  `json:"id"`
+	Login    string `json:"login"`
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
 }
 
 type pullRequestRef struct {
@@ -0,0 +1,126 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type sampleKey struct {
+	level   slog.Level
+	message string
+	err     string
+}
+
+type sampler struct {
+	mu         sync.Mutex
+	first      int
+	interval   time.Duration
+	handler    slog.Handler
+	counts     map[sampleKey]int
+	suppressed map[sampleKey]int
+}
+
+// SamplingHandler rate limits repeated identical warnings and errors. Within every interval
+// the first records with the same level, message and error are passed on and the rest are
+// counted. At the end of the interval a single summary line is logged for every record that
+// was suppressed. Records below the warn level are never sampled.
+type SamplingHandler struct {
+	handler slog.Handler
+	sampler *sampler
+}
+
+func NewSamplingHandler(handler slog.Handler, first int, interval time.Duration) *SamplingHandler {
+	return &SamplingHandler{
+		handler: handler,
+		sampler: &sampler{
+			first:      first,
+			interval:   interval,
+			handler:    handler,
+			counts:     make(map[sampleKey]int),
+			suppressed: make(map[sampleKey]int),
+		},
+	}
+}
+
+// Run flushes summaries of suppressed records every interval until the context is done.
+func (h *SamplingHandler) Run(ctx context.Context) {
+	ticker := time.NewTicker(h.sampler.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			h.Flush(context.Background())
+			return
+		case <-ticker.C:
+			h.Flush(ctx)
+		}
+	}
+}
+
+// Flush logs a summary for every suppressed record and starts a new interval.
+func (h *SamplingHandler) Flush(ctx context.Context) {
+	s := h.sampler
+
+	s.mu.Lock()
+	suppressed := s.suppressed
+	s.counts = make(map[sampleKey]int)
+	s.suppressed = make(map[sampleKey]int)
+	s.mu.Unlock()
+
+	for key, count := range suppressed {
+		record := slog.NewRecord(time.Now(), key.level, fmt.Sprintf("Suppressed %d repeated log lines: %s", count, key.message), 0)
+		if key.err != "" {
+			record.AddAttrs(slog.String("error", key.err))
+		}
+		record.AddAttrs(slog.Int("suppressed", count), slog.Duration("interval", s.interval))
+		s.handler.Handle(ctx, record)
+	}
+}
+
+func (h *SamplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *SamplingHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level < slog.LevelWarn || h.sampler.allow(recordKey(record)) {
+		return h.handler.Handle(ctx, record)
+	}
+	return nil
+}
+
+func (h *SamplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &SamplingHandler{handler: h.handler.WithAttrs(attrs), sampler: h.sampler}
+}
+
+func (h *SamplingHandler) WithGroup(name string) slog.Handler {
+	return &SamplingHandler{handler: h.handler.WithGroup(name), sampler: h.sampler}
+}
+
+func (s *sampler) allow(key sampleKey) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counts[key]++
+	if s.counts[key] <= s.first {
+		return true
+	}
+
+	s.suppressed[key]++
+	return false
+}
+
+func recordKey(record slog.Record) sampleKey {
+	key := sampleKey{level: record.Level, message: record.Message}
+	record.Attrs(func(attr slog.Attr) bool {
+		if attr.Key == "error" {
+			key.err = attr.Value.String()
+			return false
+		}
+		return true
+	})
+	return key
+}
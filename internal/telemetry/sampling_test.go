@@ -0,0 +1,43 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSamplingHandler(t *testing.T) {
+	var buf bytes.Buffer
+
+	sampling := NewSamplingHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}), 2, time.Minute)
+	logger := slog.New(sampling)
+
+	for i := 0; i < 5; i++ {
+		logger.With("correlation_id", i).Error("Error when processing message", "error", "no translator found for subject: gitea.fork")
+	}
+	logger.Error("Error when processing message", "error", "nats: timeout")
+	for i := 0; i < 3; i++ {
+		logger.Info("Processed message")
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 6, "only the first identical errors and all info lines should be logged")
+	assert.Equal(t, 3, strings.Count(buf.String(), "gitea.fork")+strings.Count(buf.String(), "nats: timeout"))
+
+	buf.Reset()
+	sampling.Flush(context.Background())
+
+	assert.Contains(t, buf.String(), `msg="Suppressed 3 repeated log lines: Error when processing message"`)
+	assert.Contains(t, buf.String(), `error="no translator found for subject: gitea.fork"`)
+	assert.Contains(t, buf.String(), "suppressed=3")
+	assert.NotContains(t, buf.String(), "nats: timeout")
+
+	buf.Reset()
+	logger.Error("Error when processing message", "error", "no translator found for subject: gitea.fork")
+	assert.Contains(t, buf.String(), "gitea.fork", "a new interval should log errors again")
+}
@@ -0,0 +1,125 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"go.opentelemetry.io/contrib/bridges/otelslog"
+	promexporter "go.opentelemetry.io/contrib/bridges/prometheus"
+	"go.opentelemetry.io/otel/exporters/otlp/otlplog/otlploghttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/sdk/log"
+	"go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const instrumentationName = "github.com/ansig/cdevents-jetstream-adapter"
+
+// Resource describes the adapter in exported telemetry. Attributes from OTEL_RESOURCE_ATTRIBUTES
+// and OTEL_SERVICE_NAME are merged in by the default resource.
+func Resource(serviceName, version string) (*resource.Resource, error) {
+	return resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+		semconv.ServiceVersion(version),
+	))
+}
+
+// SetupMetrics periodically pushes all metrics registered with the default Prometheus registry
+// over OTLP/HTTP, in addition to them being available for scraping. The exporter and interval
+// are configured with the standard OTEL_EXPORTER_OTLP_* and OTEL_METRIC_EXPORT_INTERVAL
+// environment variables.
+func SetupMetrics(ctx context.Context, res *resource.Resource) (func(context.Context) error, error) {
+	exporter, err := otlpmetrichttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := metric.NewPeriodicReader(exporter, metric.WithProducer(promexporter.NewMetricProducer()))
+	provider := metric.NewMeterProvider(metric.WithReader(reader), metric.WithResource(res))
+
+	return provider.Shutdown, nil
+}
+
+// SetupLogs returns a slog handler that exports log records over OTLP/HTTP.
+func SetupLogs(ctx context.Context, res *resource.Resource) (slog.Handler, func(context.Context) error, error) {
+	exporter, err := otlploghttp.New(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	provider := log.NewLoggerProvider(log.WithProcessor(log.NewBatchProcessor(exporter)), log.WithResource(res))
+
+	return otelslog.NewHandler(instrumentationName, otelslog.WithLoggerProvider(provider)), provider.Shutdown, nil
+}
+
+// MultiHandler sends every log record to all handlers that are enabled for its level.
+type MultiHandler struct {
+	handlers []slog.Handler
+}
+
+func NewMultiHandler(handlers ...slog.Handler) *MultiHandler {
+	return &MultiHandler{handlers: handlers}
+}
+
+func (h *MultiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *MultiHandler) Handle(ctx context.Context, record slog.Record) error {
+	var errs []error
+	for _, handler := range h.handlers {
+		if handler.Enabled(ctx, record.Level) {
+			errs = append(errs, handler.Handle(ctx, record.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *MultiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithAttrs(attrs)
+	}
+	return &MultiHandler{handlers: handlers}
+}
+
+func (h *MultiHandler) WithGroup(name string) slog.Handler {
+	handlers := make([]slog.Handler, len(h.handlers))
+	for i, handler := range h.handlers {
+		handlers[i] = handler.WithGroup(name)
+	}
+	return &MultiHandler{handlers: handlers}
+}
+
+// LevelHandler only passes on records at or above the given level.
+type LevelHandler struct {
+	level   slog.Leveler
+	handler slog.Handler
+}
+
+func NewLevelHandler(level slog.Leveler, handler slog.Handler) *LevelHandler {
+	return &LevelHandler{level: level, handler: handler}
+}
+
+func (h *LevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.handler.Enabled(ctx, level)
+}
+
+func (h *LevelHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.handler.Handle(ctx, record)
+}
+
+func (h *LevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return NewLevelHandler(h.level, h.handler.WithAttrs(attrs))
+}
+
+func (h *LevelHandler) WithGroup(name string) slog.Handler {
+	return NewLevelHandler(h.level, h.handler.WithGroup(name))
+}
@@ -0,0 +1,27 @@
+package telemetry
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiHandler(t *testing.T) {
+	var debug, info bytes.Buffer
+
+	logger := slog.New(NewMultiHandler(
+		slog.NewTextHandler(&debug, &slog.HandlerOptions{Level: slog.LevelDebug}),
+		NewLevelHandler(slog.LevelInfo, slog.NewTextHandler(&info, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	)).With("component", "test")
+
+	logger.Debug("debug message")
+	logger.Info("info message")
+
+	assert.Contains(t, debug.String(), "debug message")
+	assert.Contains(t, debug.String(), "info message")
+	assert.NotContains(t, info.String(), "debug message")
+	assert.Contains(t, info.String(), "info message")
+	assert.Contains(t, info.String(), "component=test")
+}
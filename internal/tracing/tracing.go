@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/telemetry"
+
+	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+const tracerName = "github.com/ansig/cdevents-jetstream-adapter"
+
+// Setup installs a global tracer provider that exports spans over OTLP/HTTP, configured with the
+// standard OTEL_EXPORTER_OTLP_* and OTEL_TRACES_SAMPLER environment variables, and the W3C trace
+// context propagator. The returned function flushes and stops the exporter.
+func Setup(ctx context.Context, serviceName, version string) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := telemetry.Resource(serviceName, version)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
+
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// HTTPContext extracts the trace context from incoming HTTP request headers.
+func HTTPContext(ctx context.Context, header http.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// InjectNATS adds the trace context of ctx to NATS message headers.
+func InjectNATS(ctx context.Context, header nats.Header) {
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// NATSContext extracts the trace context from NATS message headers.
+func NATSContext(ctx context.Context, header nats.Header) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// InjectCloudEvent sets the traceparent and tracestate extensions of the CloudEvents distributed
+// tracing extension from the trace context of ctx.
+func InjectCloudEvent(ctx context.Context, event *cloudevents.Event) {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+
+	for _, key := range []string{"traceparent", "tracestate"} {
+		if value := carrier.Get(key); value != "" {
+			event.SetExtension(key, value)
+		}
+	}
+}
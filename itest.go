@@ -0,0 +1,85 @@
+//go:build itest
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/itest"
+
+	"github.com/kelseyhightower/envconfig"
+	natsjs "github.com/nats-io/nats.go/jetstream"
+)
+
+// runItest runs the itest subcommand, which starts Gitea in a container, makes real pushes,
+// branches and pull requests in a repository with a webhook to a running adapter, e.g. one
+// started with --dev, and checks that the expected events are in the event stream.
+func runItest(args []string) int {
+	var env envConfig
+	if err := envconfig.Process("", &env); err != nil {
+		fmt.Fprintf(os.Stderr, "Error when processing envvar configuration: %v\n", err)
+		return 1
+	}
+
+	flags := flag.NewFlagSet("itest", flag.ContinueOnError)
+	url := flags.String("url", fmt.Sprintf("http://localhost:%d/webhook", env.HttpPort), "URL of the webhook endpoint on this host")
+	image := flags.String("image", itest.DefaultImage, "Gitea container image")
+	timeout := flags.Duration("timeout", 30*time.Second, "longest time to wait for the event of every action")
+	asJSON := flags.Bool("json", false, "print the result as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	nc, err := connectNATS(env)
+	if err != nil {
+		logger.Error("Failed to connect to nats", "error", err.Error())
+		return 1
+	}
+	defer nc.Close()
+
+	jetstream, err := natsjs.New(nc)
+	if err != nil {
+		logger.Error("Failed to create JetStream instance", "error", err.Error())
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	eventStream, err := jetstream.Stream(ctx, env.EventStreamName)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to look up event stream: %s", env.EventStreamName), "error", err.Error())
+		return 1
+	}
+
+	result, err := itest.Run(ctx, logger, eventStream, itest.Config{
+		WebhookURL: *url,
+		Image:      *image,
+		Timeout:    *timeout,
+	})
+	if err != nil {
+		logger.Error(fmt.Sprintf("Contract tests failed after %d steps", len(result.Steps)), "error", err.Error())
+		return 1
+	}
+
+	if *asJSON {
+		json.NewEncoder(os.Stdout).Encode(result)
+	} else {
+		fmt.Printf("Contract tests passed against Gitea %s in repository %s\n", result.GiteaVersion, result.Repository)
+		for _, step := range result.Steps {
+			fmt.Printf("  %s: %s event for %s after %s\n", step.Name, step.EventType, step.SubjectID, step.Latency.Round(time.Millisecond))
+		}
+	}
+
+	return 0
+}
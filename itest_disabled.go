@@ -0,0 +1,15 @@
+//go:build !itest
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// runItest reports that the contract tests against Gitea are not part of this build, since
+// they pull in the Docker client.
+func runItest(args []string) int {
+	fmt.Fprintln(os.Stderr, "The itest subcommand is only available in builds with -tags itest")
+	return 2
+}
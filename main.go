@@ -2,36 +2,65 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"runtime"
+	"runtime/debug"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
-	"github.com/ansig/cdevents-jetstream-adapter/internal/adapter"
-	"github.com/ansig/cdevents-jetstream-adapter/internal/translator"
-	"github.com/ansig/cdevents-jetstream-adapter/internal/webhook"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/admin"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/alert"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/capture"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/config"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/consumer"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/expr"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/health"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/metrics"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/openapi"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/publisher"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/redact"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/retention"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/schema"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/secret"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/service"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/telemetry"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/tracing"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/webhook"
 
 	"github.com/kelseyhightower/envconfig"
 	"github.com/nats-io/nats.go"
 	natsjs "github.com/nats-io/nats.go/jetstream"
 )
 
-var logger *slog.Logger
+// Set at build time with -ldflags "-X main.version=... -X main.commit=... -X main.buildDate=...".
+var (
+	version   = "0.0.0-dev"
+	commit    = ""
+	buildDate = ""
+)
 
-var translators = map[string]translator.CDEventTranslator{
-	"gitea.push":         &translator.GiteaPushTranslator{},
-	"gitea.pull_request": &translator.GiteaPullRequestTranslator{},
-	"gitea.create":       &translator.GiteaCreateTranslator{},
-	"gitea.delete":       &translator.GiteaDeleteTranslator{},
-}
+var logger *slog.Logger
 
 type envConfig struct {
+	ConfigFile          string        `envconfig:"CONFIG_FILE" required:"false"`
+	ConfigWatchInterval time.Duration `envconfig:"CONFIG_WATCH_INTERVAL" default:"0" required:"false"`
+
+	StrictStartup bool `envconfig:"STRICT_STARTUP" default:"false" required:"false"`
+
 	HttpPort            int64  `envconfig:"HTTP_PORT" default:"8080" required:"true"`
+	AdminPort           int64  `envconfig:"ADMIN_PORT" default:"8081" required:"true"`
+	AdminToken          string `envconfig:"ADMIN_TOKEN" required:"false" secret:"true"`
+	AdminDebugEnabled   bool   `envconfig:"ADMIN_DEBUG_ENABLED" default:"false" required:"false"`
 	NATSUrl             string `envconfig:"NATS_URL" default:"http://localhost:4222" required:"true"`
 	LogLevel            string `envconfig:"LOG_LEVEL" default:"info" required:"false"`
 	WebhookStreamName   string `envconfig:"WEBHOOK_STREAM_NAME" default:"cdevents-adapter-webhooks" required:"true"`
@@ -39,6 +68,157 @@ type envConfig struct {
 	WebhookConsumerName string `envconfig:"WEBHOOK_CONSUMER_NAME" default:"cdevents-adapter" required:"true"`
 	EventStreamName     string `envconfig:"EVENT_STREAM_NAME" default:"cdevents-adapter-events" required:"true"`
 	EventSubjectBase    string `envconfig:"EVENT_SUBJECT_BASE" default:"dev.cdevents" required:"true"`
+	ServiceName         string `envconfig:"SERVICE_NAME" default:"cdevents-adapter" required:"true"`
+
+	LogSamplingInterval time.Duration `envconfig:"LOG_SAMPLING_INTERVAL" default:"0" required:"false"`
+	LogSamplingFirst    int           `envconfig:"LOG_SAMPLING_FIRST" default:"10" required:"false"`
+
+	Translators         map[string]string `envconfig:"TRANSLATORS" default:"gitea.push:gitea.push,gitea.pull_request:gitea.pull_request,gitea.create:gitea.create,gitea.delete:gitea.delete" required:"false"`
+	DisabledTranslators []string          `envconfig:"DISABLED_TRANSLATORS" required:"false"`
+	TranslatorPluginDir string            `envconfig:"TRANSLATOR_PLUGIN_DIR" required:"false"`
+	ExecTranslators     []string          `envconfig:"EXEC_TRANSLATORS" required:"false"`
+	HTTPTranslators     []string          `envconfig:"HTTP_TRANSLATORS" required:"false"`
+	JQTranslators       []string          `envconfig:"JQ_TRANSLATORS" required:"false"`
+	TranslatorRollouts  []string          `envconfig:"TRANSLATOR_ROLLOUTS" required:"false"`
+	TranslatorSLO       adapter.SLOConfig `envconfig:"TRANSLATOR_SLO"`
+
+	TranslatorMaxPayloadSize int64 `envconfig:"TRANSLATOR_MAX_PAYLOAD_SIZE" required:"false"`
+
+	WASMTranslator translator.WASMConfig `envconfig:"WASM_TRANSLATOR"`
+
+	Gitea translator.GiteaConfig `envconfig:"GITEA"`
+
+	NATSUser        string `envconfig:"NATS_USER" required:"false"`
+	NATSPassword    string `envconfig:"NATS_PASSWORD" required:"false" secret:"true"`
+	NATSToken       string `envconfig:"NATS_TOKEN" required:"false" secret:"true"`
+	NATSCredentials string `envconfig:"NATS_CREDENTIALS" required:"false"`
+
+	Secrets secret.Config      `envconfig:"SECRETS"`
+	Vault   secret.VaultConfig `envconfig:"VAULT"`
+
+	Repositories webhook.RepositoryFilter `envconfig:"REPOSITORY"`
+	Redact       redact.Config            `envconfig:"REDACT"`
+	Capture      capture.Config           `envconfig:"CAPTURE"`
+	SchemaDrift  schema.Config            `envconfig:"SCHEMA_DRIFT"`
+
+	Labels             map[string]string `envconfig:"LABELS" required:"false"`
+	LabelsAsCustomData bool              `envconfig:"LABELS_AS_CUSTOM_DATA" default:"false" required:"false"`
+
+	RoutingExtensions adapter.RoutingExtensions `envconfig:"ROUTING_EXTENSIONS"`
+
+	EnvironmentMapping adapter.EnvironmentMapping `envconfig:"ENVIRONMENT_MAPPING"`
+
+	IdentityMapFile string `envconfig:"IDENTITY_MAP_FILE" required:"false"`
+
+	ProvenanceCustomData bool `envconfig:"PROVENANCE_CUSTOM_DATA" default:"false" required:"false"`
+
+	SpecVersion  string            `envconfig:"CDEVENTS_SPEC_VERSION" default:"0.4" required:"false"`
+	SpecVersions map[string]string `envconfig:"CDEVENTS_SPEC_VERSIONS" required:"false"`
+
+	CustomDataSchemaDir  string            `envconfig:"CUSTOM_DATA_SCHEMA_DIR" required:"false"`
+	CustomDataSchemaURIs map[string]string `envconfig:"CUSTOM_DATA_SCHEMA_URIS" required:"false"`
+
+	PayloadFilter string `envconfig:"PAYLOAD_FILTER" required:"false"`
+	EventFilter   string `envconfig:"EVENT_FILTER" required:"false"`
+
+	DryRun bool `envconfig:"DRY_RUN" default:"false" required:"false"`
+
+	EventSinks      []string                    `envconfig:"EVENT_SINKS" default:"jetstream" required:"true"`
+	SinkQueueSize   int                         `envconfig:"SINK_QUEUE_SIZE" default:"1000" required:"true"`
+	SinkFilter      sinkFilters                 `envconfig:"SINK_FILTER"`
+	Routes          []string                    `envconfig:"ROUTES"`
+	JetStreamSink   publisher.JetStreamConfig   `envconfig:"JETSTREAM_SINK"`
+	HTTPSink        publisher.HTTPConfig        `envconfig:"HTTP_SINK"`
+	KafkaSink       publisher.KafkaConfig       `envconfig:"KAFKA_SINK"`
+	NATSSink        publisher.NATSConfig        `envconfig:"NATS_SINK"`
+	AMQPSink        publisher.AMQPConfig        `envconfig:"AMQP_SINK"`
+	MQTTSink        publisher.MQTTConfig        `envconfig:"MQTT_SINK"`
+	EventBridgeSink publisher.EventBridgeConfig `envconfig:"EVENTBRIDGE_SINK"`
+	SNSSink         publisher.SNSConfig         `envconfig:"SNS_SINK"`
+	PubSubSink      publisher.PubSubConfig      `envconfig:"PUBSUB_SINK"`
+	EventGridSink   publisher.EventGridConfig   `envconfig:"EVENTGRID_SINK"`
+	RedisSink       publisher.RedisConfig       `envconfig:"REDIS_SINK"`
+	FileSink        publisher.FileConfig        `envconfig:"FILE_SINK"`
+	WebhookSink     publisher.WebhookConfig     `envconfig:"WEBHOOK_SINK"`
+	KnativeSink     publisher.KnativeConfig     `envconfig:"KNATIVE_SINK"`
+	JournalSink     publisher.JournalConfig     `envconfig:"JOURNAL_SINK"`
+
+	ArchiveStreamName   string        `envconfig:"ARCHIVE_STREAM_NAME" required:"false"`
+	ArchiveSubjectBase  string        `envconfig:"ARCHIVE_SUBJECT_BASE" default:"archive.webhooks" required:"true"`
+	ArchivePurgeMaxAge  time.Duration `envconfig:"ARCHIVE_PURGE_MAX_AGE" default:"0" required:"false"`
+	ArchivePurgeSubject string        `envconfig:"ARCHIVE_PURGE_SUBJECT" required:"false"`
+	RetentionInterval   time.Duration `envconfig:"RETENTION_INTERVAL" default:"0" required:"false"`
+
+	TracingEnabled bool `envconfig:"TRACING_ENABLED" default:"false" required:"false"`
+
+	LifecycleEvents bool `envconfig:"LIFECYCLE_EVENTS" default:"false" required:"false"`
+	HealthDetail    bool `envconfig:"HEALTH_DETAIL" default:"false" required:"false"`
+
+	MetricsExporter string `envconfig:"OTEL_METRICS_EXPORTER" default:"none" required:"false"`
+	LogsExporter    string `envconfig:"OTEL_LOGS_EXPORTER" default:"none" required:"false"`
+
+	ErrorSubject    string `envconfig:"ERROR_SUBJECT" required:"false"`
+	ErrorStreamName string `envconfig:"ERROR_STREAM_NAME" required:"false"`
+
+	RawWebhookSubject    string `envconfig:"RAW_WEBHOOK_SUBJECT" required:"false"`
+	RawWebhookStreamName string `envconfig:"RAW_WEBHOOK_STREAM_NAME" default:"cdevents-adapter-raw-webhooks" required:"false"`
+
+	Alert alert.Config `envconfig:"ALERT"`
+
+	RecentFailures           int `envconfig:"RECENT_FAILURES" default:"50" required:"false"`
+	RecentFailuresMaxPayload int `envconfig:"RECENT_FAILURES_MAX_PAYLOAD" default:"4096" required:"false"`
+
+	AuditSubject    string `envconfig:"AUDIT_SUBJECT" required:"false"`
+	AuditStreamName string `envconfig:"AUDIT_STREAM_NAME" required:"false"`
+	AuditLog        bool   `envconfig:"AUDIT_LOG" default:"false" required:"false"`
+
+	WebhookWorkers       int `envconfig:"WEBHOOK_WORKERS" default:"1" required:"false"`
+	WebhookMaxAckPending int `envconfig:"WEBHOOK_MAX_ACK_PENDING" default:"1000" required:"false"`
+	WebhookFetchBatch    int `envconfig:"WEBHOOK_FETCH_BATCH" default:"500" required:"false"`
+
+	WebhookMaxDeliver      int           `envconfig:"WEBHOOK_MAX_DELIVER" default:"10" required:"false"`
+	WebhookRedeliveryDelay time.Duration `envconfig:"WEBHOOK_REDELIVERY_DELAY" default:"5s" required:"false"`
+
+	WebhookSharded          bool  `envconfig:"WEBHOOK_SHARDED" default:"false" required:"false"`
+	WebhookMaxInflightBytes int64 `envconfig:"WEBHOOK_MAX_INFLIGHT_BYTES" default:"0" required:"false"`
+
+	BacklogDrain consumer.DrainConfig `envconfig:"BACKLOG_DRAIN"`
+
+	ResultCacheSize   int           `envconfig:"RESULT_CACHE_SIZE" default:"0" required:"false"`
+	ResultCacheBucket string        `envconfig:"RESULT_CACHE_BUCKET" required:"false"`
+	ResultCacheTTL    time.Duration `envconfig:"RESULT_CACHE_TTL" default:"24h" required:"false"`
+
+	DeterministicEventIDs bool `envconfig:"DETERMINISTIC_EVENT_IDS" default:"false" required:"false"`
+
+	LinkBucket string        `envconfig:"LINK_BUCKET" required:"false"`
+	LinkTTL    time.Duration `envconfig:"LINK_TTL" default:"168h" required:"false"`
+
+	WebhookQueue    consumer.QueueConfig    `envconfig:"WEBHOOK_QUEUE"`
+	WebhookAdaptive consumer.AdaptiveConfig `envconfig:"WEBHOOK_ADAPTIVE"`
+
+	ConsumerLagInterval      time.Duration `envconfig:"CONSUMER_LAG_INTERVAL" default:"15s" required:"true"`
+	ConsumerLagWarnThreshold uint64        `envconfig:"CONSUMER_LAG_WARN_THRESHOLD" default:"0" required:"false"`
+
+	EventRePublishSource      string `envconfig:"EVENT_REPUBLISH_SOURCE" required:"false"`
+	EventRePublishDestination string `envconfig:"EVENT_REPUBLISH_DESTINATION" required:"false"`
+	EventRePublishHeadersOnly bool   `envconfig:"EVENT_REPUBLISH_HEADERS_ONLY" default:"false" required:"false"`
+}
+
+func eventStreamRePublish(env envConfig) *natsjs.RePublish {
+	if env.EventRePublishDestination == "" {
+		return nil
+	}
+
+	source := env.EventRePublishSource
+	if source == "" {
+		source = fmt.Sprintf("%s.>", env.EventSubjectBase)
+	}
+
+	return &natsjs.RePublish{
+		Source:      source,
+		Destination: env.EventRePublishDestination,
+		HeadersOnly: env.EventRePublishHeadersOnly,
+	}
 }
 
 func MustCreateStream(ctx context.Context, jetstream natsjs.JetStream, config natsjs.StreamConfig) natsjs.Stream {
@@ -60,8 +240,85 @@ func MustCreateStream(ctx context.Context, jetstream natsjs.JetStream, config na
 	return stream
 }
 
+func setLogLevel(level *slog.LevelVar, name string) {
+	switch strings.ToLower(name) {
+	case "debug":
+		level.Set(slog.LevelDebug)
+	case "info":
+		level.Set(slog.LevelInfo)
+	case "error":
+		level.Set(slog.LevelError)
+	case "warn":
+		level.Set(slog.LevelWarn)
+	default:
+		logger.Warn(fmt.Sprintf("Unknown log level: %s (using default: %s)", name, level.Level()))
+	}
+}
+
+// buildInfo describes the running build. Commit and build date fall back to the VCS
+// information recorded by the toolchain when not set with ldflags.
+func buildInfo() admin.BuildInfo {
+	build := admin.BuildInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && build.Commit == "":
+				build.Commit = setting.Value
+			case setting.Key == "vcs.time" && build.BuildDate == "":
+				build.BuildDate = setting.Value
+			}
+		}
+	}
+
+	return build
+}
+
 func main() {
 
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		os.Exit(runBench(os.Args[2:]))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "translate" {
+		os.Exit(runTranslate(os.Args[2:]))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		os.Exit(runReplay(os.Args[2:]))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "selftest" {
+		os.Exit(runSelftest(os.Args[2:]))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "itest" {
+		os.Exit(runItest(os.Args[2:]))
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "generate" {
+		os.Exit(runGenerate(os.Args[2:]))
+	}
+
+	dev := flag.Bool("dev", false, "run against an embedded NATS server with JetStream in a temporary directory")
+	flag.Parse()
+
+	started := time.Now()
+
+	var configLoader *config.Loader
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		configLoader = config.NewLoader(path, envConfig{}, configFamilies()...)
+		if err := configLoader.Load(); err != nil {
+			fmt.Printf("Error when loading configuration file: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	var env envConfig
 	if err := envconfig.Process("", &env); err != nil {
 		fmt.Printf("Error when processing envvar configuration: %v\n", err)
@@ -71,22 +328,118 @@ func main() {
 	var programLevel = new(slog.LevelVar)
 	logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: programLevel}))
 
-	switch strings.ToLower(env.LogLevel) {
-	case "debug":
-		programLevel.Set(slog.LevelDebug)
-	case "info":
-		programLevel.Set(slog.LevelInfo)
-	case "error":
-		programLevel.Set(slog.LevelError)
-	case "warn":
-		programLevel.Set(slog.LevelWarn)
-	default:
-		logger.Warn(fmt.Sprintf("Unknown log level: %s (using default: %s)", env.LogLevel, programLevel.Level()))
+	setLogLevel(programLevel, env.LogLevel)
+
+	build := buildInfo()
+	metrics.BuildInfo.WithLabelValues(build.Version, build.Commit, build.BuildDate, build.GoVersion).Set(1)
+	logger.Info(fmt.Sprintf("Starting %s version %s", env.ServiceName, build.Version),
+		"commit", build.Commit,
+		"build_date", build.BuildDate,
+		"go_version", build.GoVersion)
+
+	if env.TracingEnabled {
+		shutdownTracing, err := tracing.Setup(context.Background(), env.ServiceName, version)
+		if err != nil {
+			logger.Error("Failed to set up tracing", "error", err.Error())
+			os.Exit(1)
+		}
+		logger.Info("Exporting traces over OTLP")
+
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := shutdownTracing(ctx); err != nil {
+				logger.Error("Error when shutting down tracing", "error", err.Error())
+			}
+		}()
+	}
+
+	if env.MetricsExporter == "otlp" || env.LogsExporter == "otlp" {
+		res, err := telemetry.Resource(env.ServiceName, version)
+		if err != nil {
+			logger.Error("Failed to create telemetry resource", "error", err.Error())
+			os.Exit(1)
+		}
+
+		var shutdowns []func(context.Context) error
+
+		if env.MetricsExporter == "otlp" {
+			shutdownMetrics, err := telemetry.SetupMetrics(context.Background(), res)
+			if err != nil {
+				logger.Error("Failed to set up metrics export", "error", err.Error())
+				os.Exit(1)
+			}
+			shutdowns = append(shutdowns, shutdownMetrics)
+			logger.Info("Exporting metrics over OTLP")
+		}
+
+		if env.LogsExporter == "otlp" {
+			handler, shutdownLogs, err := telemetry.SetupLogs(context.Background(), res)
+			if err != nil {
+				logger.Error("Failed to set up log export", "error", err.Error())
+				os.Exit(1)
+			}
+			shutdowns = append(shutdowns, shutdownLogs)
+			logger = slog.New(telemetry.NewMultiHandler(
+				slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: programLevel}),
+				telemetry.NewLevelHandler(programLevel, handler),
+			))
+			logger.Info("Exporting logs over OTLP")
+		}
+
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			for _, shutdown := range shutdowns {
+				if err := shutdown(ctx); err != nil {
+					logger.Error("Error when shutting down telemetry export", "error", err.Error())
+				}
+			}
+		}()
+	}
+
+	if env.LogSamplingInterval > 0 {
+		sampling := telemetry.NewSamplingHandler(logger.Handler(), env.LogSamplingFirst, env.LogSamplingInterval)
+		logger = slog.New(sampling)
+		logger.Info(fmt.Sprintf("Logging at most %d identical warnings or errors per %s", env.LogSamplingFirst, env.LogSamplingInterval))
+
+		samplingCtx, stopSampling := context.WithCancel(context.Background())
+		defer stopSampling()
+		go sampling.Run(samplingCtx)
+	}
+
+	if *dev {
+		natsURL, stopDevServer, err := startDevServer()
+		if err != nil {
+			logger.Error("Failed to start embedded NATS server", "error", err.Error())
+			os.Exit(1)
+		}
+		defer stopDevServer()
+		env.NATSUrl = natsURL
+		logger.Info(fmt.Sprintf("Started embedded NATS server for development on %s", natsURL))
+	}
+
+	secrets, err := newSecretResolver(env)
+	if err != nil {
+		logger.Error("Invalid secret provider configuration", "error", err.Error())
+		os.Exit(1)
+	}
+
+	if env.Secrets.RenewInterval > 0 {
+		secretsCtx, stopSecrets := context.WithCancel(context.Background())
+		defer stopSecrets()
+		go secrets.Run(secretsCtx, logger, env.Secrets.RenewInterval)
+	}
+
+	natsOpts, err := natsAuthOptions(context.Background(), env, secrets)
+	if err != nil {
+		logger.Error("Failed to resolve NATS credentials", "error", err.Error())
+		os.Exit(1)
 	}
 
 	logger.Info(fmt.Sprintf("Connecting to Nats on %s...", env.NATSUrl))
 
-	nc, err := nats.Connect(env.NATSUrl)
+	nc, err := nats.Connect(env.NATSUrl, natsOpts...)
 	if err != nil {
 		logger.Error("Failed to connect to nats", "error", err.Error())
 		os.Exit(1)
@@ -100,30 +453,159 @@ func main() {
 		os.Exit(1)
 	}
 
+	if env.StrictStartup {
+		logger.Info("Validating configuration...")
+		if errs := validateStartup(context.Background(), env, secrets, nc, jetstream); len(errs) > 0 {
+			for _, err := range errs {
+				logger.Error("Invalid configuration", "error", err.Error())
+			}
+			logger.Error(fmt.Sprintf("Startup validation failed with %d errors", len(errs)))
+			os.Exit(1)
+		}
+		logger.Info("Configuration is valid")
+	}
+
+	// The webhook stream is a work queue, so a dry run reads the webhooks from the archive stream
+	// instead of taking them away from the production consumer.
+	if env.DryRun && env.ArchiveStreamName == "" {
+		logger.Error("A dry run consumes webhooks from the archive stream, which requires ARCHIVE_STREAM_NAME")
+		os.Exit(1)
+	}
+
 	startupCtx, startupCancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer startupCancel()
 
 	webhookSubject := fmt.Sprintf("%s.>", env.WebhookSubjectBase)
 
+	// The webhook stream is a work queue, so raw payloads are kept for later inspection by
+	// republishing them into a separate archive stream with limits based retention.
+	var webhookRePublish *natsjs.RePublish
+	if env.ArchiveStreamName != "" {
+		webhookRePublish = &natsjs.RePublish{
+			Source:      webhookSubject,
+			Destination: fmt.Sprintf("%s.>", env.ArchiveSubjectBase),
+		}
+	}
+
 	WebhookStreamName := MustCreateStream(startupCtx, jetstream, natsjs.StreamConfig{
 		Name:        env.WebhookStreamName,
 		Subjects:    []string{webhookSubject},
 		Description: "CDEvents adapter incoming webhook stream",
 		Retention:   natsjs.WorkQueuePolicy,
+		RePublish:   webhookRePublish,
 	})
 
+	if env.ErrorStreamName != "" && env.ErrorSubject != "" && !env.DryRun {
+		MustCreateStream(startupCtx, jetstream, natsjs.StreamConfig{
+			Name:        env.ErrorStreamName,
+			Subjects:    []string{env.ErrorSubject},
+			Description: "CDEvents adapter failed messages",
+		})
+	}
+
+	if env.AuditStreamName != "" && env.AuditSubject != "" && !env.DryRun {
+		MustCreateStream(startupCtx, jetstream, natsjs.StreamConfig{
+			Name:        env.AuditStreamName,
+			Subjects:    []string{env.AuditSubject},
+			Description: "CDEvents adapter audit records",
+		})
+	}
+
+	if env.RawWebhookSubject != "" && !env.DryRun {
+		MustCreateStream(startupCtx, jetstream, natsjs.StreamConfig{
+			Name:        env.RawWebhookStreamName,
+			Subjects:    []string{fmt.Sprintf("%s.>", env.RawWebhookSubject)},
+			Description: "CDEvents adapter raw events of webhooks without translator",
+			Duplicates:  2 * time.Minute,
+		})
+	}
+
+	var archiveStream natsjs.Stream
+	if env.ArchiveStreamName != "" {
+		archiveStream = MustCreateStream(startupCtx, jetstream, natsjs.StreamConfig{
+			Name:        env.ArchiveStreamName,
+			Subjects:    []string{fmt.Sprintf("%s.>", env.ArchiveSubjectBase)},
+			Description: "CDEvents adapter archive of incoming webhooks",
+		})
+	}
+
 	eventSubject := fmt.Sprintf("%s.>", env.EventSubjectBase)
 
-	MustCreateStream(startupCtx, jetstream, natsjs.StreamConfig{
-		Name:        env.EventStreamName,
-		Subjects:    []string{eventSubject},
-		Description: "CDEvents adapter event output stream",
-	})
+	eventRePublish := eventStreamRePublish(env)
+	if eventRePublish != nil {
+		logger.Info(fmt.Sprintf("Republishing events from %s to %s", eventRePublish.Source, eventRePublish.Destination),
+			"headers_only", eventRePublish.HeadersOnly)
+	}
 
-	consumer, err := WebhookStreamName.CreateOrUpdateConsumer(startupCtx, natsjs.ConsumerConfig{
-		Durable:   env.WebhookConsumerName,
-		AckPolicy: natsjs.AckExplicitPolicy,
-	})
+	var eventStream natsjs.Stream
+	if sinkEnabled(env, "jetstream") && !env.DryRun {
+		eventStream = MustCreateStream(startupCtx, jetstream, natsjs.StreamConfig{
+			Name:        env.EventStreamName,
+			Subjects:    []string{eventSubject},
+			Description: "CDEvents adapter event output stream",
+			RePublish:   eventRePublish,
+		})
+	}
+
+	var resultCache *adapter.LRUResultCache
+	if env.ResultCacheSize > 0 {
+		resultCache = adapter.NewLRUResultCache(env.ResultCacheSize)
+		if env.ResultCacheBucket != "" && !env.DryRun {
+			kv, err := jetstream.CreateOrUpdateKeyValue(startupCtx, natsjs.KeyValueConfig{
+				Bucket:      env.ResultCacheBucket,
+				Description: "CDEvents adapter translated events by webhook message",
+				TTL:         env.ResultCacheTTL,
+			})
+			if err != nil {
+				logger.Error("Failed to create result cache bucket", "error", err.Error())
+				os.Exit(1)
+			}
+			resultCache.SetBacking(kv)
+		}
+		logger.Info(fmt.Sprintf("Caching up to %d translated events for redelivered messages", env.ResultCacheSize),
+			"bucket", env.ResultCacheBucket)
+	}
+
+	var linkStore adapter.LinkStore
+	if env.LinkBucket != "" && env.DryRun {
+		kv, err := jetstream.KeyValue(startupCtx, env.LinkBucket)
+		if err != nil {
+			logger.Error("Failed to open link bucket", "error", err.Error())
+			os.Exit(1)
+		}
+		linkStore = dryRunLinkStore{adapter.NewKVLinkStore(kv)}
+		logger.Info(fmt.Sprintf("Dry run: linking related events through key-value bucket without storing events: %s", env.LinkBucket))
+	} else if env.LinkBucket != "" {
+		kv, err := jetstream.CreateOrUpdateKeyValue(startupCtx, natsjs.KeyValueConfig{
+			Bucket:      env.LinkBucket,
+			Description: "CDEvents adapter published events by the keys that later events are linked by",
+			TTL:         env.LinkTTL,
+		})
+		if err != nil {
+			logger.Error("Failed to create link bucket", "error", err.Error())
+			os.Exit(1)
+		}
+		linkStore = adapter.NewKVLinkStore(kv)
+		logger.Info(fmt.Sprintf("Linking related events through key-value bucket: %s", env.LinkBucket))
+	}
+
+	var webhookConsumer natsjs.Consumer
+	if env.DryRun {
+		// An ephemeral consumer of new webhooks that acknowledges nothing, so that the archive is
+		// left as it is and the consumer goes away with the dry run.
+		webhookConsumer, err = archiveStream.CreateOrUpdateConsumer(startupCtx, natsjs.ConsumerConfig{
+			DeliverPolicy:     natsjs.DeliverNewPolicy,
+			AckPolicy:         natsjs.AckNonePolicy,
+			InactiveThreshold: time.Minute,
+		})
+	} else {
+		webhookConsumer, err = WebhookStreamName.CreateOrUpdateConsumer(startupCtx, natsjs.ConsumerConfig{
+			Durable:       env.WebhookConsumerName,
+			AckPolicy:     natsjs.AckExplicitPolicy,
+			MaxAckPending: env.WebhookMaxAckPending,
+			MaxDeliver:    env.WebhookMaxDeliver,
+		})
+	}
 
 	if err != nil {
 		logger.Error("Failed to create consumer", "error", err.Error())
@@ -132,52 +614,438 @@ func main() {
 
 	done := make(chan interface{})
 
-	messages := make(chan natsjs.Msg)
-	consContext, _ := consumer.Consume(func(msg natsjs.Msg) {
-		messages <- msg
-	})
+	workerPool := consumer.NewWorkerPool(logger, env.WebhookWorkers, env.WebhookMaxAckPending)
+	workerPool.SetSharded(env.WebhookSharded)
+	workerPool.SetMaxInflightBytes(env.WebhookMaxInflightBytes)
+	if err := workerPool.SetQueue(env.WebhookQueue); err != nil {
+		logger.Error("Failed to set up webhook queue", "error", err.Error())
+		os.Exit(1)
+	}
+
+	var workerLimiter *consumer.AdaptiveLimiter
+	if env.WebhookAdaptive.Enabled {
+		if err := env.WebhookAdaptive.Validate(); err != nil {
+			logger.Error("Invalid adaptive concurrency configuration", "error", err.Error())
+			os.Exit(1)
+		}
+		workerLimiter = consumer.NewAdaptiveLimiter(logger, env.WebhookAdaptive, workerPool.Workers())
+		workerPool.SetLimiter(workerLimiter)
+	}
+
+	webhookHandler := workerPool.Handle
+	if env.DryRun {
+		webhookHandler = archivedWebhookHandler(env, workerPool.Handle)
+	}
+
+	pausableConsumer := consumer.NewPausableConsumer(logger, webhookConsumer, webhookHandler)
+	pausableConsumer.SetBatchSize(env.WebhookFetchBatch)
 
 	var wg sync.WaitGroup
 
-	cdEventsAdapter := adapter.NewCDEventAdapter(logger, nc, translators)
+	if env.DryRun {
+		logger.Warn("Dry run: webhooks are read from the archive stream and translated events are logged and not published to the event sinks",
+			"archive_stream", env.ArchiveStreamName)
+	}
+
+	eventPublisher, err := newFanOutPublisher(env, nc, secrets)
+	if err != nil {
+		logger.Error("Failed to create event publisher", "error", err.Error())
+		os.Exit(1)
+	}
+
+	defer closePublisher(eventPublisher)
+
+	translatorCatalog, closeTranslators, err := newTranslatorCatalog(env, secrets)
+	if err != nil {
+		logger.Error("Failed to load translators", "error", err.Error())
+		os.Exit(1)
+	}
+
+	defer closeTranslators()
+
+	translators, err := resolveTranslators(env, translatorCatalog)
+	if err != nil {
+		logger.Error("Invalid translator configuration", "error", err.Error())
+		os.Exit(1)
+	}
+
+	translatorRegistry := translator.NewRegistry(translators)
+	translatorRegistry.SetCatalog(translatorCatalog)
+
+	cdEventsAdapter := adapter.NewCDEventAdapter(logger, eventPublisher, translatorRegistry)
+	cdEventsAdapter.SetDisabledTranslators(env.DisabledTranslators)
+	cdEventsAdapter.SetSLO(env.TranslatorSLO)
+	cdEventsAdapter.SetMaxPayloadSize(env.TranslatorMaxPayloadSize)
+	cdEventsAdapter.SetRedeliveryDelay(env.WebhookRedeliveryDelay)
+	if workerLimiter != nil {
+		cdEventsAdapter.SetPublishObserver(workerLimiter)
+	}
+	if resultCache != nil {
+		cdEventsAdapter.SetResultCache(resultCache)
+	}
+	if linkStore != nil {
+		cdEventsAdapter.SetLinkStore(linkStore)
+	}
+	if env.DeterministicEventIDs {
+		cdEventsAdapter.SetDeterministicIDs(true)
+		logger.Info("Deriving event ids from the provider and delivery id of webhooks")
+	}
+
+	if env.PayloadFilter != "" {
+		payloadFilter, err := expr.Compile(env.PayloadFilter)
+		if err != nil {
+			logger.Error("Invalid payload filter", "error", err.Error())
+			os.Exit(1)
+		}
+		cdEventsAdapter.SetPayloadFilter(payloadFilter)
+		logger.Info(fmt.Sprintf("Filtering webhook payloads with: %s", env.PayloadFilter))
+	}
+
+	if env.EventFilter != "" {
+		eventFilter, err := expr.Compile(env.EventFilter)
+		if err != nil {
+			logger.Error("Invalid event filter", "error", err.Error())
+			os.Exit(1)
+		}
+		cdEventsAdapter.SetEventFilter(eventFilter)
+		logger.Info(fmt.Sprintf("Filtering CDEvents with: %s", env.EventFilter))
+	}
+
+	if len(env.Labels) > 0 {
+		if err := cdEventsAdapter.SetLabels(adapter.Labels{Values: env.Labels, CustomData: env.LabelsAsCustomData}); err != nil {
+			logger.Error("Invalid labels", "error", err.Error())
+			os.Exit(1)
+		}
+		logger.Info(fmt.Sprintf("Adding labels to every event: %v", env.Labels))
+	}
+
+	if env.ProvenanceCustomData {
+		cdEventsAdapter.SetProvenance(version)
+		logger.Info("Adding delivery provenance to the custom data of every event")
+	}
+
+	if env.EnvironmentMapping.Enabled() {
+		if err := cdEventsAdapter.SetEnvironmentMapping(env.EnvironmentMapping); err != nil {
+			logger.Error("Invalid environment mapping", "error", err.Error())
+			os.Exit(1)
+		}
+		logger.Info(fmt.Sprintf("Mapping environments of events with rules: %v", env.EnvironmentMapping.Rules))
+	}
+
+	if env.IdentityMapFile != "" {
+		identities, err := adapter.LoadIdentityMap(env.IdentityMapFile)
+		if err != nil {
+			logger.Error("Invalid identity map", "error", err.Error())
+			os.Exit(1)
+		}
+		cdEventsAdapter.SetIdentityMap(identities)
+		logger.Info(fmt.Sprintf("Mapping actors of events to corporate identities from %s", env.IdentityMapFile))
+	}
+
+	if env.RoutingExtensions.Enabled() {
+		if err := cdEventsAdapter.SetRoutingExtensions(env.RoutingExtensions); err != nil {
+			logger.Error("Invalid routing extensions", "error", err.Error())
+			os.Exit(1)
+		}
+		logger.Info(fmt.Sprintf("Adding routing extensions to every event: provider=%t repository=%t tenant=%s tenants=%v environment=%s",
+			env.RoutingExtensions.Provider, env.RoutingExtensions.Repository, env.RoutingExtensions.Tenant, env.RoutingExtensions.Tenants, env.RoutingExtensions.Environment))
+	}
+
+	if err := cdEventsAdapter.SetSpecVersions(adapter.SpecVersions{Default: env.SpecVersion, Subjects: env.SpecVersions}); err != nil {
+		logger.Error("Invalid CDEvents spec version", "error", err.Error())
+		os.Exit(1)
+	}
+	if env.SpecVersion != adapter.SpecVersion04 || len(env.SpecVersions) > 0 {
+		logger.Info(fmt.Sprintf("Publishing CDEvents %s events, by subject: %v", env.SpecVersion, env.SpecVersions))
+	}
+
+	if len(env.CustomDataSchemaURIs) > 0 {
+		if err := cdEventsAdapter.SetSchemaURIs(env.CustomDataSchemaURIs); err != nil {
+			logger.Error("Invalid custom data schema URIs", "error", err.Error())
+			os.Exit(1)
+		}
+		logger.Info(fmt.Sprintf("Setting custom data schema URIs by subject: %v", env.CustomDataSchemaURIs))
+	}
+
+	var schemaDetector *schema.Detector
+	if env.SchemaDrift.Enabled {
+		if err := env.SchemaDrift.Validate(); err != nil {
+			logger.Error("Invalid schema drift configuration", "error", err.Error())
+			os.Exit(1)
+		}
+		schemaDetector = schema.NewDetector(logger, env.SchemaDrift)
+		cdEventsAdapter.SetSchemaObserver(schemaDetector)
+		logger.Info("Detecting schema drift of webhook payloads")
+	}
+
+	// A dry run has no side effects besides logging: failures are not reported or alerted on,
+	// audit records are only logged and raw webhook and lifecycle events are not published.
+	var reporters adapter.MultiReporter
+	if env.ErrorSubject != "" && !env.DryRun {
+		logger.Info(fmt.Sprintf("Reporting failed messages on subject: %s", env.ErrorSubject))
+		reporters = append(reporters, adapter.NewNATSErrorReporter(nc, env.ErrorSubject))
+	}
+	if env.Alert.URL != "" && !env.DryRun {
+		alerter, err := alert.NewAlerter(logger, env.ServiceName, env.Alert)
+		if err != nil {
+			logger.Error("Failed to create alerter", "error", err.Error())
+			os.Exit(1)
+		}
+		logger.Info(fmt.Sprintf("Alerting when %d failures occur within %s", env.Alert.Threshold, env.Alert.Window))
+		reporters = append(reporters, alerter)
+	}
+	var redactor *redact.Redactor
+	if env.Redact.Enabled() {
+		redactor, err = redact.New(env.Redact)
+		if err != nil {
+			logger.Error("Invalid redaction rules", "error", err.Error())
+			os.Exit(1)
+		}
+	}
+	var recentFailures *adapter.RecentFailures
+	if env.RecentFailures > 0 {
+		recentFailures = adapter.NewRecentFailures(env.RecentFailures, env.RecentFailuresMaxPayload)
+		if redactor != nil {
+			recentFailures.SetRedactor(redactor)
+		}
+		reporters = append(reporters, recentFailures)
+	}
+	if len(reporters) > 0 {
+		cdEventsAdapter.SetErrorReporter(reporters)
+	}
+
+	if env.RawWebhookSubject != "" && !env.DryRun {
+		logger.Info(fmt.Sprintf("Emitting raw webhook events for webhooks without translator on subject: %s.>", env.RawWebhookSubject),
+			"stream", env.RawWebhookStreamName)
+		cdEventsAdapter.SetRawWebhookEmitter(adapter.NewJetStreamRawWebhookEmitter(jetstream, env.RawWebhookSubject, env.RawWebhookStreamName))
+	}
+
+	var auditors adapter.MultiAuditor
+	if env.AuditSubject != "" && !env.DryRun {
+		logger.Info(fmt.Sprintf("Publishing audit records on subject: %s", env.AuditSubject))
+		auditors = append(auditors, adapter.NewNATSAuditor(nc, env.AuditSubject))
+	}
+	if env.AuditLog {
+		auditors = append(auditors, adapter.NewLogAuditor(slog.New(slog.NewJSONHandler(os.Stdout, nil)).With("log", "audit")))
+	}
+	// Audit records are held back while draining a backlog found at startup.
+	var deferredAuditor *adapter.DeferredAuditor
+	if len(auditors) > 0 && env.BacklogDrain.Enabled {
+		deferredAuditor = adapter.NewDeferredAuditor(auditors, env.BacklogDrain.DeferLimit)
+		cdEventsAdapter.SetAuditor(deferredAuditor)
+	} else if len(auditors) > 0 {
+		cdEventsAdapter.SetAuditor(auditors)
+	}
+
+	microService, err := service.NewMicroService(nc, service.Config{
+		Name:        env.ServiceName,
+		Version:     version,
+		SubjectBase: env.ServiceName,
+		Metadata: map[string]string{
+			"webhook_stream": env.WebhookStreamName,
+			"event_stream":   env.EventStreamName,
+		},
+	}, cdEventsAdapter)
+	if err != nil {
+		logger.Error("Failed to register NATS micro service", "error", err.Error())
+		os.Exit(1)
+	}
+
+	defer microService.Stop()
+
+	controlSub, err := service.SubscribeControl(logger, nc, env.ServiceName, pausableConsumer)
+	if err != nil {
+		logger.Error("Failed to subscribe to control subject", "error", err.Error())
+		os.Exit(1)
+	}
+
+	defer controlSub.Unsubscribe()
+
+	var lifecycle *service.LifecyclePublisher
+	if env.LifecycleEvents && !env.DryRun {
+		lifecycle = service.NewLifecyclePublisher(nc, env.ServiceName, service.LifecycleData{
+			Service: env.ServiceName,
+			Version: build.Version,
+			Commit:  build.Commit,
+			Started: started,
+		})
+
+		logger.Info(fmt.Sprintf("Publishing lifecycle events on subject: %s.lifecycle.>", env.ServiceName))
+		if err := lifecycle.Started(); err != nil {
+			logger.Error("Failed to publish started event", "error", err.Error())
+		}
+
+		defer func() {
+			if err := lifecycle.Stopped(); err != nil {
+				logger.Error("Failed to publish stopped event", "error", err.Error())
+			}
+			nc.FlushTimeout(5 * time.Second)
+		}()
+	}
+
+	logger.Info(fmt.Sprintf("Processing webhook messages with %d workers", workerPool.Workers()))
+	workerPool.Start(func(msg natsjs.Msg) {
+		if err := cdEventsAdapter.Process(msg); err != nil {
+			logger.Error("Error when processing message", "error", err.Error())
+		}
+	})
+
+	// The consumer is started last, so that no message is fetched, and its ack wait started, before
+	// the workers are ready to process it.
+	if err := pausableConsumer.Start(); err != nil {
+		logger.Error("Failed to start consuming webhook messages", "error", err.Error())
+		os.Exit(1)
+	}
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		defer consContext.Stop()
-		for {
-			select {
-			case msg := <-messages:
-				if err := cdEventsAdapter.Process(msg); err != nil {
-					logger.Error("Error when processing message", "error", err.Error())
-				}
-			case <-done:
-				logger.Info("Stopped processing messages")
-				return
+		<-done
+		pausableConsumer.Stop()
+		workerPool.Stop()
+		cdEventsAdapter.Wait()
+		logger.Info("Stopped processing messages")
+	}()
+
+	if err := consumer.ValidateLagInterval(env.ConsumerLagInterval); err != nil {
+		logger.Error("Invalid consumer lag configuration", "error", err.Error())
+		os.Exit(1)
+	}
+	lagMonitor := consumer.NewLagMonitor(logger, webhookConsumer, env.ConsumerLagInterval, env.ConsumerLagWarnThreshold)
+
+	monitorCtx, cancelMonitor := context.WithCancel(context.Background())
+	defer cancelMonitor()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		lagMonitor.Run(monitorCtx)
+	}()
+
+	if env.BacklogDrain.Enabled {
+		backlogDrain := consumer.NewBacklogDrain(logger, env.BacklogDrain, webhookConsumer, workerPool, pausableConsumer, env.WebhookFetchBatch)
+		if deferredAuditor != nil {
+			backlogDrain.AddDeferrer(deferredAuditor)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := backlogDrain.Run(monitorCtx); err != nil {
+				logger.Error("Failed to check consumer backlog for draining", "error", err.Error())
 			}
+		}()
+	}
+
+	if env.RetentionInterval > 0 {
+		reportedStreams := []retention.Stream{WebhookStreamName}
+		if eventStream != nil {
+			reportedStreams = append(reportedStreams, eventStream)
 		}
-	}()
+		var archive retention.Stream
+		if archiveStream != nil {
+			reportedStreams = append(reportedStreams, archiveStream)
+			archive = archiveStream
+		}
+
+		retentionTask := retention.NewTask(logger, env.RetentionInterval, reportedStreams, archive, env.ArchivePurgeMaxAge, env.ArchivePurgeSubject)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			retentionTask.Run(monitorCtx)
+		}()
+	}
 
 	logger.Info("JetStream consumer ready and listening...")
 
 	logger.Info("Starting server...")
 
 	webhook := webhook.NewHttpWebhook(logger)
+	webhook.SetMaxBodySize(env.TranslatorMaxPayloadSize)
+	if env.Repositories.Enabled() {
+		if err := env.Repositories.Validate(); err != nil {
+			logger.Error("Invalid repository filter", "error", err.Error())
+			os.Exit(1)
+		}
+		webhook.SetRepositoryFilter(env.Repositories)
+		logger.Info(fmt.Sprintf("Accepting webhooks for repositories matching %v and not %v", env.Repositories.Allow, env.Repositories.Deny))
+	}
+	if redactor != nil {
+		webhook.SetRedactor(redactor)
+		logger.Info(fmt.Sprintf("Redacting %d fields and %d patterns in webhook payloads", len(env.Redact.Fields), len(env.Redact.Patterns)))
+	}
 
-	mux := http.NewServeMux()
-	mux.Handle("/webhook", webhook.GetHandler(jetstream, env.WebhookSubjectBase))
-	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
-	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
-		if nc.IsConnected() {
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte("READY"))
+	if env.Capture.Enabled() {
+		if err := validateCapture(env); err != nil {
+			logger.Error("Invalid payload capture configuration", "error", err.Error())
+			os.Exit(1)
+		}
+
+		var store capture.Store
+		if env.Capture.Bucket != "" {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			objectStore, err := jetstream.CreateOrUpdateObjectStore(ctx, natsjs.ObjectStoreConfig{Bucket: env.Capture.Bucket})
+			cancel()
+			if err != nil {
+				logger.Error(fmt.Sprintf("Failed to create payload capture bucket: %s", env.Capture.Bucket), "error", err.Error())
+				os.Exit(1)
+			}
+			store = capture.NewObjectStore(objectStore)
+			logger.Info(fmt.Sprintf("Capturing up to %d payloads per event and %s in bucket: %s", env.Capture.Limit, env.Capture.Interval, env.Capture.Bucket))
 		} else {
-			w.WriteHeader(http.StatusServiceUnavailable)
+			store = capture.NewDirStore(env.Capture.Dir)
+			logger.Info(fmt.Sprintf("Capturing up to %d payloads per event and %s in directory: %s", env.Capture.Limit, env.Capture.Interval, env.Capture.Dir))
 		}
-	})
+
+		capturer := capture.NewCapturer(logger, env.Capture, store)
+		webhook.SetCapturer(capturer)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			capturer.Run(monitorCtx)
+		}()
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/webhook", webhook.GetHandler(jetstream, env.WebhookSubjectBase))
+	checks := []health.Check{
+		{Name: "nats", Critical: true, Check: func(ctx context.Context) error {
+			if !nc.IsConnected() {
+				return fmt.Errorf("nats connection is %s", nc.Status())
+			}
+			return nil
+		}},
+		{Name: "webhook_stream", Critical: true, Check: func(ctx context.Context) error {
+			_, err := WebhookStreamName.Info(ctx)
+			return err
+		}},
+		{Name: "consumer", Critical: true, Check: func(ctx context.Context) error {
+			_, err := webhookConsumer.Info(ctx)
+			return err
+		}},
+	}
+	if eventStream != nil {
+		checks = append(checks, health.Check{Name: "event_stream", Critical: true, Check: func(ctx context.Context) error {
+			_, err := eventStream.Info(ctx)
+			return err
+		}})
+	}
+	for name := range eventPublisher.Stats() {
+		checks = append(checks, health.Check{Name: fmt.Sprintf("sink:%s", name), Check: func(ctx context.Context) error {
+			if stats := eventPublisher.Stats()[name]; stats.Failing {
+				return errors.New(stats.LastError)
+			}
+			return nil
+		}})
+	}
+
+	healthChecker := health.NewChecker(5*time.Second, checks...)
+	mux.Handle("/healthz", healthChecker.Handler("OK", env.HealthDetail, true))
+	mux.Handle("/readyz", healthChecker.Handler("READY", env.HealthDetail, false))
+	mux.Handle("GET /openapi.yaml", openapi.Handler())
+	mux.Handle("GET /openapi.json", openapi.Handler())
 
 	srv := http.Server{
 		Addr:         fmt.Sprintf(":%d", env.HttpPort),
@@ -187,6 +1055,70 @@ func main() {
 		Handler:      mux,
 	}
 
+	if env.AdminToken == "" {
+		logger.Warn("No ADMIN_TOKEN configured, admin API is unauthenticated")
+	}
+
+	adminServer := admin.NewServer(logger, env.AdminToken)
+	adminServer.HandleConsumerControl(pausableConsumer)
+	adminServer.HandleConsumerLag(lagMonitor)
+	adminServer.HandleSinkStats(eventPublisher)
+	adminServer.HandleTranslators(cdEventsAdapter)
+	adminServer.HandleTranslatorControl(cdEventsAdapter)
+	adminServer.HandleTranslatorRegistry(translatorRegistry)
+	adminServer.HandleSimulate(cdEventsAdapter)
+	adminServer.HandleVersion(build)
+	if recentFailures != nil {
+		adminServer.HandleRecentFailures(recentFailures)
+	}
+	if schemaDetector != nil {
+		adminServer.HandleSchemas(schemaDetector)
+	}
+	adminServer.HandleInfo(build, started, cdEventsAdapter)
+	adminServer.HandleMetrics()
+	adminServer.HandleOpenAPI()
+
+	if env.AdminDebugEnabled {
+		adminServer.HandleDebug()
+		logger.Info("Serving pprof and expvar on admin port under /debug/")
+	}
+
+	if configLoader != nil {
+		configReloader, err := newReloader(configLoader, env, programLevel, translatorCatalog, translatorRegistry, cdEventsAdapter, eventPublisher, adminServer, lifecycle)
+		if err != nil {
+			logger.Error("Error when creating configuration reloader", "error", err.Error())
+			os.Exit(1)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			configReloader.run(monitorCtx, env.ConfigFile, env.ConfigWatchInterval)
+		}()
+		logger.Info(fmt.Sprintf("Reloading configuration from %s on SIGHUP", env.ConfigFile))
+
+		adminServer.HandleConfig(configReloader.config)
+	} else {
+		adminServer.HandleConfig(func() interface{} { return env })
+	}
+
+	adminSrv := http.Server{
+		Addr:         fmt.Sprintf(":%d", env.AdminPort),
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 60 * time.Second,
+		IdleTimeout:  90 * time.Second,
+		Handler:      adminServer.Handler(),
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		logger.Info(fmt.Sprintf("Admin server listening on port %d...", env.AdminPort))
+		if err := adminSrv.ListenAndServe(); err != http.ErrServerClosed {
+			logger.Error("Error from admin listen and serve", "error", err.Error())
+		}
+	}()
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
@@ -199,6 +1131,10 @@ func main() {
 		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), time.Second*10)
 		defer cancelShutdown()
 
+		if err := adminSrv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("Error when shutting down admin server", "error", err.Error())
+		}
+
 		if err := srv.Shutdown(shutdownCtx); err != nil {
 			logger.Error("Error when shutting down server", "error", err.Error())
 			os.Exit(1)
@@ -207,12 +1143,17 @@ func main() {
 
 	logger.Info(fmt.Sprintf("Server listening on port %d...", env.HttpPort))
 
+	if *dev {
+		printDevExamples(env)
+	}
+
 	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
 		logger.Error("Error from listen and server", "error", err.Error())
 		os.Exit(1)
 	}
 
 	close(done)
+	cancelMonitor()
 
 	logger.Info("Gracefully shutting down...")
 
@@ -0,0 +1,846 @@
+// Package adapter translates webhook messages consumed from JetStream into CDEvents and
+// publishes them. It can be embedded in other services together with custom translators.
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/correlation"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/delivery"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/metrics"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/tracing"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// ErrNoTranslator is returned, wrapped with the subject, for webhooks without a translator.
+var ErrNoTranslator = errors.New("no translator found for subject")
+
+// maxRedeliveryDelay caps the delay before a message whose event failed to publish is redelivered.
+const maxRedeliveryDelay = 10 * time.Minute
+
+// Adapter processes webhook messages consumed from JetStream.
+type Adapter interface {
+	Process(msg JetstreamMsg) error
+}
+
+// Publisher publishes translated events, e.g. to one or more event sinks.
+type Publisher interface {
+	Publish(ctx context.Context, event cloudevents.Event) error
+}
+
+// AsyncPublisher is implemented by publishers that can publish an event without waiting for it
+// to be acknowledged. The returned channel receives the outcome once the event has been
+// acknowledged and is nil when there was nothing to wait for.
+type AsyncPublisher interface {
+	PublishAsync(ctx context.Context, event cloudevents.Event) (<-chan error, error)
+}
+
+// PublishObserver is notified about the latency and outcome of every published event, e.g. to
+// adapt the processing concurrency to how fast the sinks keep up.
+type PublishObserver interface {
+	ObservePublish(latency time.Duration, err error)
+}
+
+// SchemaObserver is given the decoded payload of every webhook message before it is translated,
+// together with the fields the translator declares that it reads, e.g. to detect changes of the
+// webhook schema of the provider.
+type SchemaObserver interface {
+	ObserveSchema(subject string, fields []string, doc map[string]interface{})
+}
+
+// TranslatorRegistry looks up the translator for a webhook subject, e.g. "gitea.push".
+type TranslatorRegistry interface {
+	Lookup(subject string) (translator.CDEventTranslator, bool)
+	Subjects() []string
+}
+
+// Matcher decides whether a JSON document, e.g. a webhook payload or a CDEvent, is allowed.
+type Matcher interface {
+	Match(doc map[string]interface{}) (bool, error)
+}
+
+type JetstreamMsg interface {
+	Data() []byte
+	Subject() string
+	Headers() nats.Header
+	Ack() error
+	Metadata() (*jetstream.MsgMetadata, error)
+}
+
+type ProcessingStats struct {
+	Processed uint64  `json:"processed"`
+	Failed    uint64  `json:"failed"`
+	ErrorRate float64 `json:"error_rate"`
+}
+
+type TranslatorInfo struct {
+	Subject      string   `json:"subject"`
+	Translator   string   `json:"translator"`
+	Enabled      bool     `json:"enabled"`
+	Samples      int      `json:"samples,omitempty"`
+	SuccessRatio *float64 `json:"success_ratio,omitempty"`
+	P99Ms        float64  `json:"p99_ms,omitempty"`
+	Degraded     bool     `json:"degraded,omitempty"`
+}
+
+type CDEventAdapter struct {
+	logger           *slog.Logger
+	publisher        Publisher
+	translators      TranslatorRegistry
+	disabled         atomic.Pointer[map[string]bool]
+	disabledMu       sync.Mutex
+	disabledConfig   map[string]bool
+	disabledRuntime  map[string]bool
+	reporter         ErrorReporter
+	auditor          Auditor
+	observer         PublishObserver
+	schemas          SchemaObserver
+	results          ResultCache
+	links            LinkStore
+	specVersions     *SpecVersions
+	deterministicIDs bool
+	schemaURIs       map[string]string
+	routing          *RoutingExtensions
+	raw              RawWebhookEmitter
+	provenance       *string
+	environments     *EnvironmentMapping
+	identities       IdentityMap
+	maxPayloadSize   int64
+	redeliveryDelay  time.Duration
+	payloadRule      Matcher
+	eventRule        Matcher
+	labels           *Labels
+	slo              *SLOConfig
+	health           map[string]*translatorSLO
+	sloMu            sync.Mutex
+	processed        atomic.Uint64
+	failed           atomic.Uint64
+	inflight         sync.WaitGroup
+}
+
+func NewCDEventAdapter(logger *slog.Logger, publisher Publisher, translators TranslatorRegistry) *CDEventAdapter {
+	return &CDEventAdapter{
+		logger:      logger,
+		publisher:   publisher,
+		translators: translators}
+}
+
+// SetDisabledTranslators disables the translators for the given webhook subjects. Messages for
+// a disabled translator are acknowledged without being translated. Translators enabled or
+// disabled at runtime with EnableTranslator and DisableTranslator keep that state, so that
+// reloading the configuration does not undo it. It is safe to call while messages are being
+// processed.
+func (c *CDEventAdapter) SetDisabledTranslators(subjects []string) {
+	c.disabledMu.Lock()
+	defer c.disabledMu.Unlock()
+
+	c.disabledConfig = make(map[string]bool, len(subjects))
+	for _, subject := range subjects {
+		c.disabledConfig[subject] = true
+	}
+	c.storeDisabled()
+}
+
+// DisableTranslator disables the translator for a webhook subject and reports whether there is
+// one.
+func (c *CDEventAdapter) DisableTranslator(subject string) bool {
+	if _, exists := c.translators.Lookup(subject); !exists {
+		return false
+	}
+	c.updateDisabled(subject, true)
+	return true
+}
+
+func (c *CDEventAdapter) updateDisabled(subject string, disable bool) {
+	c.disabledMu.Lock()
+	defer c.disabledMu.Unlock()
+
+	if c.disabledRuntime == nil {
+		c.disabledRuntime = make(map[string]bool)
+	}
+	c.disabledRuntime[subject] = disable
+	c.storeDisabled()
+}
+
+// storeDisabled stores the configured disabled translators with the translators enabled or
+// disabled at runtime. Must be called with the lock held.
+func (c *CDEventAdapter) storeDisabled() {
+	disabled := make(map[string]bool, len(c.disabledConfig))
+	for subject := range c.disabledConfig {
+		disabled[subject] = true
+	}
+	for subject, disable := range c.disabledRuntime {
+		if disable {
+			disabled[subject] = true
+		} else {
+			delete(disabled, subject)
+		}
+	}
+	c.disabled.Store(&disabled)
+}
+
+func (c *CDEventAdapter) isDisabled(subject string) bool {
+	disabled := c.disabled.Load()
+	return disabled != nil && (*disabled)[subject]
+}
+
+// Translators lists the registered translators sorted by the webhook subject they handle.
+func (c *CDEventAdapter) Translators() []TranslatorInfo {
+	subjects := c.translators.Subjects()
+	infos := make([]TranslatorInfo, 0, len(subjects))
+	for _, subject := range subjects {
+		t, exists := c.translators.Lookup(subject)
+		if !exists {
+			continue
+		}
+		info := TranslatorInfo{
+			Subject:    subject,
+			Translator: strings.TrimPrefix(fmt.Sprintf("%T", t), "*"),
+			Enabled:    !c.isDisabled(subject),
+		}
+		c.translatorHealth(subject, &info)
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Subject < infos[j].Subject })
+	return infos
+}
+
+// SetErrorReporter sets a reporter that is notified about every message that fails processing.
+func (c *CDEventAdapter) SetErrorReporter(reporter ErrorReporter) {
+	c.reporter = reporter
+}
+
+// SetAuditor sets an auditor that receives a record for every processed message.
+func (c *CDEventAdapter) SetAuditor(auditor Auditor) {
+	c.auditor = auditor
+}
+
+// SetPublishObserver sets an observer that is notified when an event has been published, with the
+// time from when the event was handed to the publisher until the publish was acknowledged, so that
+// the time spent translating does not count.
+func (c *CDEventAdapter) SetPublishObserver(observer PublishObserver) {
+	c.observer = observer
+}
+
+// SetSchemaObserver sets an observer of the schema of webhook payloads by webhook subject.
+func (c *CDEventAdapter) SetSchemaObserver(observer SchemaObserver) {
+	c.schemas = observer
+}
+
+// SetResultCache sets a cache of translated events. A message that is found in the cache, e.g.
+// because it is redelivered after its event failed to publish, is not translated again. Its cached
+// event is filtered and published instead, with the same id.
+func (c *CDEventAdapter) SetResultCache(cache ResultCache) {
+	c.results = cache
+}
+
+// SetLinkStore sets a store of published events that later events are linked to, e.g. the
+// change.merged event of a pull request to its change.created event, per the CDEvents links spec.
+func (c *CDEventAdapter) SetLinkStore(store LinkStore) {
+	c.links = store
+}
+
+// SetPayloadFilter sets a filter for webhook payloads. Payloads that do not match are
+// acknowledged without being translated.
+func (c *CDEventAdapter) SetPayloadFilter(filter Matcher) {
+	c.payloadRule = filter
+}
+
+// SetEventFilter sets a filter for translated CDEvents. Events that do not match are not
+// published.
+func (c *CDEventAdapter) SetEventFilter(filter Matcher) {
+	c.eventRule = filter
+}
+
+// SetMaxPayloadSize sets the size limit in bytes for decoding webhook payloads. Zero means
+// translator.DefaultMaxPayloadSize and less than zero disables the limit.
+func (c *CDEventAdapter) SetMaxPayloadSize(size int64) {
+	c.maxPayloadSize = size
+}
+
+// SetRedeliveryDelay sets the delay before a message whose event failed to publish is
+// redelivered. The delay doubles with every delivery of the message, up to 10 minutes.
+func (c *CDEventAdapter) SetRedeliveryDelay(delay time.Duration) {
+	c.redeliveryDelay = delay
+}
+
+func (c *CDEventAdapter) Stats() ProcessingStats {
+	stats := ProcessingStats{
+		Processed: c.processed.Load(),
+		Failed:    c.failed.Load(),
+	}
+	if stats.Processed > 0 {
+		stats.ErrorRate = float64(stats.Failed) / float64(stats.Processed)
+	}
+	return stats
+}
+
+// Process translates and publishes a webhook message and acknowledges it. If the publisher is an
+// AsyncPublisher, Process returns as soon as the event has been published and the message is
+// acknowledged once the publish has been acknowledged, see Wait.
+func (c *CDEventAdapter) Process(msg JetstreamMsg) error {
+	ctx, span := tracing.Tracer().Start(tracing.NATSContext(context.Background(), msg.Headers()), "webhook process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attribute.String("messaging.destination.name", msg.Subject())))
+
+	ctx = correlation.WithID(ctx, msg.Headers().Get(correlation.Header))
+
+	start := time.Now()
+
+	event, pending, err := c.process(ctx, msg)
+	if pending != nil {
+		c.inflight.Add(1)
+		go func() {
+			defer c.inflight.Done()
+			if err := c.complete(ctx, span, msg, event, <-pending, start); err != nil {
+				correlation.Logger(ctx, c.logger).Error("Failed to publish event", "subject", msg.Subject(), "error", err.Error())
+			}
+		}()
+		return nil
+	}
+
+	return c.complete(ctx, span, msg, event, err, start)
+}
+
+// Wait blocks until all events published asynchronously have been acknowledged and the
+// corresponding webhook messages have been acknowledged in turn.
+func (c *CDEventAdapter) Wait() {
+	c.inflight.Wait()
+}
+
+// complete acknowledges a processed message and records the outcome. A message whose event
+// failed to publish is negatively acknowledged instead, if it supports it, so that it is
+// redelivered after the redelivery delay and the event published again.
+func (c *CDEventAdapter) complete(ctx context.Context, span trace.Span, msg JetstreamMsg, event *cloudevents.Event, err error, start time.Time) error {
+	defer span.End()
+
+	// An event is only returned together with an error when publishing it failed.
+	if nakable, ok := msg.(interface{ NakWithDelay(time.Duration) error }); ok && event != nil && err != nil {
+		nakable.NakWithDelay(c.nakDelay(msg))
+	} else {
+		msg.Ack()
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "failed to process webhook")
+	}
+
+	// Only failures before publishing count against the translator, so that an unavailable
+	// sink does not degrade it.
+	if _, subject, found := strings.Cut(msg.Subject(), "."); found && (event != nil || err != nil) {
+		if _, exists := c.translators.Lookup(subject); exists {
+			c.recordSLO(subject, event == nil, time.Since(start))
+		}
+	}
+
+	c.processed.Add(1)
+	metrics.WebhookMessages.WithLabelValues(outcome(event, err)).Inc()
+	if err != nil {
+		c.failed.Add(1)
+		c.report(ctx, msg, err)
+	}
+
+	c.audit(ctx, msg, event, err, time.Since(start))
+
+	return err
+}
+
+// nakDelay returns the redelivery delay doubled for every time the message has been delivered
+// before.
+func (c *CDEventAdapter) nakDelay(msg JetstreamMsg) time.Duration {
+	delay := c.redeliveryDelay
+	if metadata, err := msg.Metadata(); err == nil {
+		for i := uint64(1); i < metadata.NumDelivered && delay > 0 && delay < maxRedeliveryDelay; i++ {
+			delay *= 2
+		}
+	}
+	return min(delay, maxRedeliveryDelay)
+}
+
+func outcome(event *cloudevents.Event, err error) string {
+	switch {
+	case err != nil:
+		return OutcomeFailed
+	case event == nil:
+		return OutcomeSkipped
+	default:
+		return OutcomePublished
+	}
+}
+
+func (c *CDEventAdapter) audit(ctx context.Context, msg JetstreamMsg, event *cloudevents.Event, err error, duration time.Duration) {
+	if c.auditor == nil {
+		return
+	}
+
+	record := AuditRecord{
+		WebhookSubject: msg.Subject(),
+		DeliveryID:     msg.Headers().Get(DeliveryIDHeader),
+		CorrelationID:  correlation.FromContext(ctx),
+		Outcome:        outcome(event, err),
+		DurationMs:     float64(duration.Microseconds()) / 1000,
+		Time:           time.Now().UTC(),
+	}
+
+	if _, translator, found := strings.Cut(msg.Subject(), "."); found {
+		record.Translator = translator
+	}
+
+	if event != nil {
+		record.EventID = event.ID()
+		record.EventType = event.Type()
+	}
+
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	if err := c.auditor.Audit(record); err != nil {
+		correlation.Logger(ctx, c.logger).Error("Failed to write audit record", "subject", msg.Subject(), "error", err.Error())
+	}
+}
+
+func (c *CDEventAdapter) report(ctx context.Context, msg JetstreamMsg, err error) {
+	if c.reporter == nil {
+		return
+	}
+
+	failed := newFailedEvent(msg, err)
+	failed.CorrelationID = correlation.FromContext(ctx)
+
+	if err := c.reporter.Report(failed); err != nil {
+		correlation.Logger(ctx, c.logger).Error("Failed to report failed message", "subject", msg.Subject(), "error", err.Error())
+	}
+}
+
+// process translates and publishes a message. It returns the published event, which is nil
+// when the message was skipped or failed before translation, and a channel receiving the outcome
+// of the publish if it has not been acknowledged yet.
+func (c *CDEventAdapter) process(ctx context.Context, msg JetstreamMsg) (*cloudevents.Event, <-chan error, error) {
+
+	logger := correlation.Logger(ctx, c.logger)
+
+	metadata, err := msg.Metadata()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logger.Debug("Processing incoming webhook message",
+		"subject", msg.Subject(),
+		"stream_seq", metadata.Sequence.Stream,
+		"num_delivered", metadata.NumDelivered,
+		"stream", metadata.Stream,
+		"consumer", metadata.Consumer)
+
+	subjectParts := strings.Split(msg.Subject(), ".")
+	if len(subjectParts) < 2 {
+		return nil, nil, fmt.Errorf("unable to determine type of message as subject has to few parts: %s", msg.Subject())
+	}
+
+	eventSubject := strings.Join(subjectParts[1:], ".")
+	eventTranslator, exists := c.translators.Lookup(eventSubject)
+	if !exists {
+		if c.raw != nil && c.supportedProvider(eventSubject) {
+			return nil, nil, c.emitRaw(ctx, msg, eventSubject)
+		}
+		return nil, nil, fmt.Errorf("%w: %s", ErrNoTranslator, eventSubject)
+	}
+
+	if c.isDisabled(eventSubject) || c.autoDisabled(eventSubject) {
+		logger.Debug("Skipping webhook message for disabled translator", "subject", msg.Subject())
+		return nil, nil, nil
+	}
+
+	// A message redelivered by the stream is found by its stream sequence and a webhook sent again
+	// by the provider by its delivery id or payload. Its cached event is published without
+	// translating the message again, once it has passed the current payload and event filters.
+	// The additional events of a cached event have already been published.
+	var keys []string
+	if c.results != nil {
+		keys = []string{sequenceKey(metadata), resultKey(msg)}
+		if msg.Headers().Get(ReplayHeader) != "" {
+			logger.Debug("Translating replayed webhook message again instead of publishing cached CDEvent", "subject", msg.Subject())
+		} else if cached, found := c.cachedResult(keys); found {
+			err := c.filterCached(ctx, msg.Subject(), msg.Data(), cached)
+			if errors.Is(err, translator.ErrSkipped) {
+				return nil, nil, nil
+			}
+			if err != nil {
+				return nil, nil, err
+			}
+			logger.Debug("Publishing cached CDEvent for webhook message translated before",
+				"id", cached.ID(),
+				"subject", msg.Subject(),
+				"stream_seq", metadata.Sequence.Stream,
+				"num_delivered", metadata.NumDelivered)
+			return c.publish(delivery.WithKey(ctx, deliveryKey(metadata, 0)), cached)
+		}
+	}
+
+	cloudEvent, additional, err := c.translate(ctx, msg.Subject(), eventSubject, eventTranslator, msg.Data(), msg.Headers(), newProvenance(msg, metadata))
+	if errors.Is(err, translator.ErrSkipped) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Additional events are published before the event is cached. If one of them fails to
+	// publish, the message is negatively acknowledged, and the redelivered message is translated
+	// again, since there is no cached event, so that its additional events are published again.
+	for i, event := range additional {
+		if err := c.publishAndWait(delivery.WithKey(ctx, deliveryKey(metadata, i+1)), event); err != nil {
+			return event, nil, fmt.Errorf("failed to publish additional event: %w", err)
+		}
+		logger.Debug("Published additional CDEvent for webhook message", "type", event.Type(), "id", event.ID(), "subject", msg.Subject())
+	}
+
+	logger.Debug("Translated incoming webhook message into CDEvent",
+		"type", cloudEvent.Type(),
+		"subject", msg.Subject(),
+		"stream_seq", metadata.Sequence.Stream,
+		"num_delivered", metadata.NumDelivered,
+		"stream", metadata.Stream,
+		"consumer", metadata.Consumer)
+
+	// The event is cached before it is published, so that it is the same event that is published
+	// when the message is redelivered because publishing failed.
+	for _, key := range keys {
+		c.results.Add(key, cloudEvent)
+	}
+
+	return c.publish(delivery.WithKey(ctx, deliveryKey(metadata, 0)), cloudEvent)
+}
+
+// cachedResult returns the first event cached under one of the keys.
+func (c *CDEventAdapter) cachedResult(keys []string) (*cloudevents.Event, bool) {
+	for _, key := range keys {
+		if cached, found := c.results.Get(key); found {
+			return cached, true
+		}
+	}
+	return nil, false
+}
+
+// filterCached runs a webhook payload received on subject through the payload filter and its
+// cached event through the event filter, so that filters changed since the event was cached
+// apply. A filtered event returns an error wrapping translator.ErrSkipped.
+func (c *CDEventAdapter) filterCached(ctx context.Context, subject string, data []byte, cached *cloudevents.Event) error {
+	if err := c.matchPayload(ctx, subject, translator.NewPayloadContext(ctx, data, translator.WithMaxSize(c.maxPayloadSize))); err != nil {
+		return err
+	}
+
+	if c.eventRule != nil {
+		match, err := matchEventData(c.eventRule, cached.Data())
+		if err != nil {
+			return fmt.Errorf("event filter: %w", err)
+		}
+		if !match {
+			correlation.Logger(ctx, c.logger).Debug("Skipping cached CDEvent filtered by event filter", "type", cached.Type(), "subject", subject)
+			return translator.Skip("filtered by event filter")
+		}
+	}
+
+	return nil
+}
+
+// matchPayload runs a webhook payload received on subject through the payload filter. A payload
+// that does not match returns an error wrapping translator.ErrSkipped.
+func (c *CDEventAdapter) matchPayload(ctx context.Context, subject string, payload *translator.Payload) error {
+	if c.payloadRule == nil {
+		return nil
+	}
+
+	doc, err := payload.Document()
+	if err != nil {
+		return err
+	}
+	match, err := c.payloadRule.Match(doc)
+	if err != nil {
+		return fmt.Errorf("payload filter: %w", err)
+	}
+	if !match {
+		correlation.Logger(ctx, c.logger).Debug("Skipping webhook message filtered by payload filter", "subject", subject)
+		return translator.Skip("filtered by payload filter")
+	}
+	return nil
+}
+
+// deliveryKey identifies an event of a webhook message by the stream sequence of the message and
+// the position of the event among the events of the message, which are the same for every
+// delivery of the message.
+func deliveryKey(metadata *jetstream.MsgMetadata, index int) string {
+	return fmt.Sprintf("%s/%d/%d", metadata.Stream, metadata.Sequence.Stream, index)
+}
+
+// translate runs a webhook payload received on subject through the payload filter, the
+// translator for eventSubject and the event filter, and returns the labelled event and the
+// additional events of translators that translate a payload to several events. A payload that is
+// not translated returns an error wrapping translator.ErrSkipped. The delivery id of the webhook,
+// if any, is used for deterministic event ids.
+func (c *CDEventAdapter) translate(ctx context.Context, subject, eventSubject string, eventTranslator translator.CDEventTranslator, data []byte, headers nats.Header, delivery *Provenance) (*cloudevents.Event, []*cloudevents.Event, error) {
+
+	logger := correlation.Logger(ctx, c.logger)
+
+	// The payload is decoded into a generic document only when something needs it, and the
+	// document is shared with translators that can reuse it. Otherwise the translator is the
+	// only one to parse the payload, which the webhook endpoint has already checked is JSON.
+	payload := translator.NewPayloadContext(ctx, data, translator.WithMaxSize(c.maxPayloadSize))
+
+	if c.schemas != nil {
+		// Payloads that are not valid JSON fail translation and are reported as such.
+		if doc, err := payload.Document(); err == nil {
+			c.schemas.ObserveSchema(eventSubject, translator.DependentFields(eventTranslator), doc)
+		}
+	}
+
+	if err := c.matchPayload(ctx, subject, payload); err != nil {
+		return nil, nil, err
+	}
+
+	_, translateSpan := tracing.Tracer().Start(ctx, "translate", trace.WithAttributes(attribute.String("translator", eventSubject)))
+	cdEvents, err := translator.TranslateAll(eventTranslator, payload)
+	if errors.Is(err, translator.ErrSkipped) {
+		translateSpan.End()
+		logger.Debug("Skipping webhook message not translated by translator", "subject", subject, "reason", err.Error())
+		return nil, nil, err
+	}
+	if err != nil {
+		translateSpan.RecordError(err)
+		translateSpan.SetStatus(codes.Error, "translation failed")
+		translateSpan.End()
+		return nil, nil, err
+	}
+	if len(cdEvents) == 0 {
+		translateSpan.End()
+		return nil, nil, translator.Skip("translated to no events")
+	}
+	translateSpan.SetAttributes(attribute.String("cdevents.type", cdEvents[0].GetType().String()))
+	translateSpan.End()
+
+	var (
+		cloudEvents []*cloudevents.Event
+		skipped     error
+	)
+	for i, cdEvent := range cdEvents {
+		cloudEvent, err := c.prepare(ctx, subject, eventSubject, cdEvent, i, headers, delivery)
+		if errors.Is(err, translator.ErrSkipped) {
+			skipped = err
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		cloudEvents = append(cloudEvents, cloudEvent)
+	}
+	if len(cloudEvents) == 0 {
+		return nil, nil, skipped
+	}
+
+	return cloudEvents[0], cloudEvents[1:], nil
+}
+
+// prepare turns a translated event into the CloudEvent that is published, unless it is filtered
+// by the event filter. The index is the position of the event among the events of the payload.
+func (c *CDEventAdapter) prepare(ctx context.Context, subject, eventSubject string, cdEvent cdevents.CDEvent, index int, headers nats.Header, delivery *Provenance) (*cloudevents.Event, error) {
+
+	logger := correlation.Logger(ctx, c.logger)
+
+	deliveryID := headers.Get(DeliveryIDHeader)
+	deterministic := c.deterministicIDs && deliveryID != ""
+	if deterministic {
+		if index > 0 {
+			deliveryID = fmt.Sprintf("%s/%d", deliveryID, index)
+		}
+		cdEvent.SetId(deterministicID(eventSubject, deliveryID))
+		// A timestamp after the webhook was received is the time of translation, which differs
+		// between deliveries, while timestamps taken from the payload precede it.
+		if delivery != nil && !delivery.ReceivedAt.IsZero() && cdEvent.GetTimestamp().After(delivery.ReceivedAt) {
+			cdEvent.SetTimestamp(delivery.ReceivedAt.UTC())
+		}
+	}
+
+	if c.environments != nil {
+		c.environments.mapEnvironment(cdEvent)
+	}
+
+	if c.identities != nil {
+		if err := c.identities.mapActor(cdEvent); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.schemaURIs != nil {
+		c.setSchemaURI(eventSubject, cdEvent)
+	}
+
+	if c.eventRule != nil {
+		match, err := matchEvent(c.eventRule, cdEvent)
+		if err != nil {
+			return nil, fmt.Errorf("event filter: %w", err)
+		}
+		if !match {
+			logger.Debug("Skipping CDEvent filtered by event filter", "type", cdEvent.GetType(), "subject", subject)
+			return nil, translator.Skip("filtered by event filter")
+		}
+	}
+
+	if c.links != nil {
+		var newChainID string
+		if deterministic {
+			newChainID = deterministicChainID(eventSubject, deliveryID)
+		}
+		c.link(ctx, cdEvent, newChainID)
+	}
+
+	if c.labels != nil && c.labels.CustomData {
+		if err := addLabelsToCustomData(cdEvent, c.labels.Values); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.provenance != nil {
+		if err := addToCustomData(cdEvent, ProvenanceCustomDataKey, "provenance", c.provenanceOf(eventSubject, delivery)); err != nil {
+			return nil, err
+		}
+	}
+
+	// The chain id is kept as an extension of events converted to a spec version without it.
+	chainID := chainID(cdEvent)
+
+	if c.specVersions != nil && c.specVersions.version(eventSubject) == SpecVersion03 {
+		converted, err := convertToV03(cdEvent)
+		if err != nil {
+			return nil, err
+		}
+		cdEvent = converted
+	}
+
+	cloudEvent, err := asCloudEvent(eventSubject, cdEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	if chainID != "" {
+		cloudEvent.SetExtension(ChainIDExtension, chainID)
+	}
+
+	if c.labels != nil && !c.labels.CustomData {
+		addLabelsAsExtensions(cloudEvent, c.labels.Values)
+	}
+
+	if c.routing != nil {
+		c.routing.addRoutingExtensions(cloudEvent, cdEvent, eventSubject, headers)
+	}
+
+	if headers.Get(SelftestHeader) != "" {
+		cloudEvent.SetExtension(SelftestExtension, true)
+	}
+
+	return cloudEvent, nil
+}
+
+// publish publishes an event in a span of its own. It returns a channel receiving the outcome if
+// the publisher is asynchronous.
+func (c *CDEventAdapter) publish(ctx context.Context, cloudEvent *cloudevents.Event) (*cloudevents.Event, <-chan error, error) {
+	tracing.InjectCloudEvent(ctx, cloudEvent)
+
+	publishCtx, publishSpan := tracing.Tracer().Start(ctx, "publish", trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(attribute.String("cdevents.id", cloudEvent.ID())))
+	defer publishSpan.End()
+
+	var (
+		pending <-chan error
+		err     error
+	)
+	start := time.Now()
+	if async, ok := c.publisher.(AsyncPublisher); ok {
+		pending, err = async.PublishAsync(publishCtx, *cloudEvent)
+	} else {
+		err = c.publisher.Publish(publishCtx, *cloudEvent)
+	}
+	if pending != nil && err == nil {
+		if c.observer != nil || c.links != nil {
+			pending = c.settle(context.WithoutCancel(ctx), cloudEvent, start, pending)
+		}
+	} else {
+		c.published(ctx, cloudEvent, start, err)
+	}
+	if err != nil {
+		publishSpan.RecordError(err)
+		publishSpan.SetStatus(codes.Error, "publish failed")
+		return cloudEvent, nil, err
+	}
+
+	return cloudEvent, pending, nil
+}
+
+// published passes the outcome of a publish to the observer and, only if the event was published,
+// remembers it for linking, so that no event links to an event that does not exist.
+func (c *CDEventAdapter) published(ctx context.Context, cloudEvent *cloudevents.Event, start time.Time, err error) {
+	if c.observer != nil {
+		c.observer.ObservePublish(time.Since(start), err)
+	}
+	if c.links != nil && err == nil {
+		c.remember(ctx, cloudEvent)
+	}
+}
+
+// settle returns a channel receiving the outcome of an asynchronous publish, which is handled
+// once the publish is acknowledged.
+func (c *CDEventAdapter) settle(ctx context.Context, cloudEvent *cloudevents.Event, start time.Time, pending <-chan error) <-chan error {
+	settled := make(chan error, 1)
+	go func() {
+		err := <-pending
+		c.published(ctx, cloudEvent, start, err)
+		settled <- err
+	}()
+	return settled
+}
+
+// publishAndWait publishes an event and waits for the publish to be acknowledged.
+func (c *CDEventAdapter) publishAndWait(ctx context.Context, cloudEvent *cloudevents.Event) error {
+	_, pending, err := c.publish(ctx, cloudEvent)
+	if err == nil && pending != nil {
+		err = <-pending
+	}
+	return err
+}
+
+func matchEvent(filter Matcher, event cdevents.CDEvent) (bool, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return false, err
+	}
+	return matchEventData(filter, data)
+}
+
+// matchEventData matches an event encoded as JSON, e.g. the data of its CloudEvent.
+func matchEventData(filter Matcher, data []byte) (bool, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return false, err
+	}
+
+	return filter.Match(doc)
+}
@@ -0,0 +1,623 @@
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/correlation"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+	cdeventsv04 "github.com/cdevents/sdk-go/pkg/api/v04"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockPublisher struct {
+	mock.Mock
+}
+
+func (m *MockPublisher) Publish(ctx context.Context, event cloudevents.Event) error {
+	args := m.Called(event)
+	return args.Error(0)
+}
+
+type MockJetstreamMsg struct {
+	mock.Mock
+	subject      string
+	headers      nats.Header
+	data         []byte
+	acked        bool
+	consumerSeq  uint64
+	streamSeq    uint64
+	numDelivered uint64
+	timestamp    time.Time
+}
+
+func (m *MockJetstreamMsg) Subject() string { return m.subject }
+func (m *MockJetstreamMsg) Data() []byte    { return m.data }
+func (m *MockJetstreamMsg) Headers() nats.Header {
+	return m.headers
+}
+func (m *MockJetstreamMsg) Ack() error {
+	m.acked = true
+	return nil
+}
+func (m *MockJetstreamMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return &jetstream.MsgMetadata{
+		Sequence: jetstream.SequencePair{
+			Stream:   m.streamSeq,
+			Consumer: m.consumerSeq,
+		},
+		NumDelivered: m.numDelivered,
+		Timestamp:    m.timestamp,
+	}, nil
+}
+
+func newMockJetstreamMsg(subject string, data []byte) *MockJetstreamMsg {
+	return &MockJetstreamMsg{
+		subject: subject,
+		data:    data,
+	}
+}
+
+type MockCDEventTranslator struct {
+	mock.Mock
+}
+
+func (m *MockCDEventTranslator) Translate(data []byte) (cdevents.CDEvent, error) {
+	args := m.Called(data)
+	cde, _ := args.Get(0).(cdevents.CDEvent)
+	return cde, args.Error(1)
+}
+
+func newTestCDEvent(t *testing.T) cdevents.CDEvent {
+	cde, err := cdeventsv04.NewChangeMergedEvent()
+	require.NoError(t, err, "unable to create CDEvent for tests")
+
+	cde.SetSource("git.example.com")
+	cde.SetSubjectId("9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2")
+	cde.SetSubjectRepository(&cdevents.Reference{Id: "yoloco/project1"})
+
+	return cde
+}
+
+func TestProcess(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tc := range []struct {
+		title                   string
+		msgSubject              string
+		msgData                 []byte
+		translatorSubject       string
+		disabledTranslators     []string
+		translateReturnsEvent   bool
+		expectedError           error
+		expectEventPublished    bool
+		expectEventNotPublished bool
+		expectMsgDataTranslated bool
+	}{
+		{
+			title:                   "translates message data and publishes translated event",
+			msgSubject:              "webhook.test.event",
+			msgData:                 []byte("{\"foo\": \"bar\"}"),
+			translatorSubject:       "test.event",
+			expectEventPublished:    true,
+			expectMsgDataTranslated: true,
+		},
+		{
+			title:                   "error when no translator matching subject",
+			msgSubject:              "webhook.test.foo",
+			msgData:                 []byte("{\"foo\": \"bar\"}"),
+			translatorSubject:       "test.bar",
+			expectedError:           fmt.Errorf("%w: test.foo", ErrNoTranslator),
+			expectEventNotPublished: true,
+		},
+		{
+			title:                   "skips message for disabled translator",
+			msgSubject:              "webhook.test.event",
+			msgData:                 []byte("{\"foo\": \"bar\"}"),
+			translatorSubject:       "test.event",
+			disabledTranslators:     []string{"test.event"},
+			expectEventNotPublished: true,
+		},
+		{
+			title:                   "error on less than 2 subject parts",
+			msgSubject:              "webhook",
+			msgData:                 []byte("{\"foo\": \"bar\"}"),
+			translatorSubject:       "test.bar",
+			expectedError:           fmt.Errorf("unable to determine type of message as subject has to few parts: webhook"),
+			expectEventNotPublished: true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			mockPublisher := &MockPublisher{}
+			mockTranslator := &MockCDEventTranslator{}
+
+			adapter := &CDEventAdapter{
+				logger:      logger,
+				publisher:   mockPublisher,
+				translators: translator.NewRegistry(map[string]translator.CDEventTranslator{tc.translatorSubject: mockTranslator}),
+			}
+			adapter.SetDisabledTranslators(tc.disabledTranslators)
+
+			cde := newTestCDEvent(t)
+
+			var expectedData interface{}
+			if tc.expectMsgDataTranslated {
+				expectedData = tc.msgData
+			} else {
+				expectedData = mock.Anything
+			}
+
+			mockTranslator.On("Translate", expectedData).Return(cde, nil)
+
+			var expectedEvent interface{}
+			if tc.expectEventPublished {
+				ce, err := cdevents.AsCloudEvent(cde)
+				require.NoError(t, err, "unable to create CloudEvent for tests")
+				expectedEvent = *ce
+			} else {
+				expectedEvent = mock.Anything
+			}
+			mockPublisher.On("Publish", expectedEvent).Return(nil)
+
+			msg := newMockJetstreamMsg(tc.msgSubject, tc.msgData)
+
+			err := adapter.Process(msg)
+
+			if tc.expectedError != nil {
+				require.Equal(t, tc.expectedError, err, "did not return expected error")
+			} else {
+				require.NoError(t, err, "no error should be returned")
+			}
+
+			if tc.expectMsgDataTranslated {
+				mockTranslator.AssertCalled(t, "Translate", expectedData)
+			}
+
+			if tc.expectEventPublished {
+				mockPublisher.AssertCalled(t, "Publish", expectedEvent)
+			}
+
+			if tc.expectEventNotPublished {
+				mockPublisher.AssertNotCalled(t, "Publish", expectedEvent)
+			}
+		})
+	}
+}
+
+type MockAsyncPublisher struct {
+	MockPublisher
+	acks chan error
+}
+
+func (m *MockAsyncPublisher) PublishAsync(ctx context.Context, event cloudevents.Event) (<-chan error, error) {
+	m.Called(event)
+	return m.acks, nil
+}
+
+func TestProcessAcksAfterPublishAck(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tc := range []struct {
+		title           string
+		publishErr      error
+		expectedOutcome string
+	}{
+		{
+			title:           "acks message when publish is acknowledged",
+			expectedOutcome: OutcomePublished,
+		},
+		{
+			title:           "acks message and reports failure when publish fails",
+			publishErr:      fmt.Errorf("stream unavailable"),
+			expectedOutcome: OutcomeFailed,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			mockPublisher := &MockAsyncPublisher{acks: make(chan error, 1)}
+			mockPublisher.On("PublishAsync", mock.Anything)
+			mockTranslator := &MockCDEventTranslator{}
+			mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil)
+
+			nc := &mockNATSPublisher{}
+			adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}))
+			adapter.SetAuditor(NewNATSAuditor(nc, "cdevents-adapter.audit"))
+
+			msg := newMockJetstreamMsg("webhook.test.event", []byte("{\"foo\": \"bar\"}"))
+
+			require.NoError(t, adapter.Process(msg), "process should return before the publish is acknowledged")
+			require.False(t, msg.acked, "message should not be acked before the publish is acknowledged")
+
+			mockPublisher.acks <- tc.publishErr
+			adapter.Wait()
+
+			require.True(t, msg.acked, "message should be acked after the publish is acknowledged")
+			require.Len(t, nc.published, 1, "processed message should be audited")
+			require.Equal(t, tc.expectedOutcome, nc.published[0].Header.Get("Outcome"))
+			mockPublisher.AssertNotCalled(t, "Publish", mock.Anything)
+		})
+	}
+}
+
+type nakableJetstreamMsg struct {
+	*MockJetstreamMsg
+	naked bool
+	delay time.Duration
+}
+
+func (m *nakableJetstreamMsg) NakWithDelay(delay time.Duration) error {
+	m.naked = true
+	m.delay = delay
+	return nil
+}
+
+func TestProcessNaksWhenPublishFails(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mockPublisher := &MockAsyncPublisher{acks: make(chan error, 1)}
+	mockPublisher.On("PublishAsync", mock.Anything)
+	mockTranslator := &MockCDEventTranslator{}
+	mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil)
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}))
+
+	msg := &nakableJetstreamMsg{MockJetstreamMsg: newMockJetstreamMsg("webhook.test.event", []byte("{\"foo\": \"bar\"}"))}
+
+	require.NoError(t, adapter.Process(msg))
+	mockPublisher.acks <- fmt.Errorf("queue for sink is full")
+	adapter.Wait()
+
+	require.True(t, msg.naked, "message should be redelivered when its event failed to publish")
+	require.False(t, msg.acked, "message should not be acked when its event failed to publish")
+}
+
+func TestProcessDelaysRedelivery(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tc := range []struct {
+		title        string
+		numDelivered uint64
+		expected     time.Duration
+	}{
+		{
+			title:        "first delivery",
+			numDelivered: 1,
+			expected:     5 * time.Second,
+		},
+		{
+			title:        "third delivery",
+			numDelivered: 3,
+			expected:     20 * time.Second,
+		},
+		{
+			title:        "capped",
+			numDelivered: 100,
+			expected:     10 * time.Minute,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			mockPublisher := &MockPublisher{}
+			mockPublisher.On("Publish", mock.Anything).Return(fmt.Errorf("unavailable"))
+			mockTranslator := &MockCDEventTranslator{}
+			mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil)
+
+			adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}))
+			adapter.SetRedeliveryDelay(5 * time.Second)
+
+			msg := &nakableJetstreamMsg{MockJetstreamMsg: newMockJetstreamMsg("webhook.test.event", []byte(`{}`))}
+			msg.numDelivered = tc.numDelivered
+			require.Error(t, adapter.Process(msg))
+
+			require.True(t, msg.naked)
+			assert.Equal(t, tc.expected, msg.delay)
+		})
+	}
+}
+
+func TestProcessTranslatesAgainWhenAdditionalEventFails(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var published []cloudevents.Event
+	mockPublisher := &MockPublisher{}
+	mockPublisher.On("Publish", mock.Anything).Return(fmt.Errorf("unavailable")).Once()
+	mockPublisher.On("Publish", mock.Anything).Run(func(args mock.Arguments) {
+		published = append(published, args.Get(0).(cloudevents.Event))
+	}).Return(nil)
+
+	translations := 0
+	translators := translator.NewRegistry(map[string]translator.CDEventTranslator{
+		"gitea.push": multiTranslator{events: func() []cdevents.CDEvent {
+			translations++
+			return []cdevents.CDEvent{newTestCDEvent(t), newTestCDEvent(t)}
+		}},
+	})
+	adapter := NewCDEventAdapter(logger, mockPublisher, translators)
+	adapter.SetResultCache(NewLRUResultCache(10))
+
+	msg := &nakableJetstreamMsg{MockJetstreamMsg: newMockJetstreamMsg("webhook.gitea.push", []byte(`{}`))}
+	msg.headers = nats.Header{}
+	require.Error(t, adapter.Process(msg))
+	require.True(t, msg.naked)
+
+	// The event was not cached, so the redelivered message is translated again and the
+	// additional event published with it.
+	require.NoError(t, adapter.Process(msg))
+	assert.Equal(t, 2, translations)
+	assert.Len(t, published, 2)
+}
+
+func TestStats(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mockPublisher := &MockPublisher{}
+	mockTranslator := &MockCDEventTranslator{}
+
+	adapter := &CDEventAdapter{
+		logger:      logger,
+		publisher:   mockPublisher,
+		translators: translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}),
+	}
+
+	cde := newTestCDEvent(t)
+
+	mockTranslator.On("Translate", mock.Anything).Return(cde, nil)
+	mockPublisher.On("Publish", mock.Anything).Return(nil)
+
+	require.Equal(t, ProcessingStats{}, adapter.Stats(), "stats should be empty before processing")
+
+	require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.test.event", []byte("{}"))))
+	require.Error(t, adapter.Process(newMockJetstreamMsg("webhook.test.unknown", []byte("{}"))))
+	require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.test.event", []byte("{}"))))
+	require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.test.event", []byte("{}"))))
+
+	require.Equal(t, ProcessingStats{Processed: 4, Failed: 1, ErrorRate: 0.25}, adapter.Stats())
+}
+
+type recordingObserver struct {
+	latencies []time.Duration
+	errs      []error
+}
+
+func (o *recordingObserver) ObservePublish(latency time.Duration, err error) {
+	o.latencies = append(o.latencies, latency)
+	o.errs = append(o.errs, err)
+}
+
+func TestPublishObserver(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mockPublisher := &MockPublisher{}
+	mockTranslator := &MockCDEventTranslator{}
+	mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil)
+	mockPublisher.On("Publish", mock.Anything).Return(nil).Once()
+	mockPublisher.On("Publish", mock.Anything).Return(fmt.Errorf("sink unavailable")).Once()
+
+	observer := &recordingObserver{}
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}))
+	adapter.SetPublishObserver(observer)
+
+	require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.test.event", []byte("{}"))))
+	require.Error(t, adapter.Process(newMockJetstreamMsg("webhook.test.event", []byte("{}"))))
+	require.Error(t, adapter.Process(newMockJetstreamMsg("webhook.test.unknown", []byte("{}"))))
+
+	require.Len(t, observer.errs, 2, "only messages that reached the publisher should be observed")
+	require.NoError(t, observer.errs[0])
+	require.EqualError(t, observer.errs[1], "sink unavailable")
+}
+
+func TestPublishObserverExcludesTranslation(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mockPublisher := &MockPublisher{}
+	mockTranslator := &MockCDEventTranslator{}
+	mockTranslator.On("Translate", mock.Anything).Run(func(mock.Arguments) {
+		time.Sleep(50 * time.Millisecond)
+	}).Return(newTestCDEvent(t), nil)
+	mockPublisher.On("Publish", mock.Anything).Return(nil)
+
+	observer := &recordingObserver{}
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}))
+	adapter.SetPublishObserver(observer)
+
+	require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.test.event", []byte("{}"))))
+
+	require.Len(t, observer.latencies, 1)
+	assert.Less(t, observer.latencies[0], 50*time.Millisecond, "time spent translating should not be observed")
+}
+
+type recordingSchemaObserver struct {
+	subjects []string
+	fields   [][]string
+}
+
+func (o *recordingSchemaObserver) ObserveSchema(subject string, fields []string, doc map[string]interface{}) {
+	o.subjects = append(o.subjects, subject)
+	o.fields = append(o.fields, fields)
+}
+
+func TestSchemaObserver(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	observer := &recordingSchemaObserver{}
+	adapter := NewCDEventAdapter(logger, &MockPublisher{}, translator.NewRegistry(map[string]translator.CDEventTranslator{
+		"gitea.create": translator.NewGiteaCreateTranslator(translator.GiteaConfig{IgnoreTags: true}),
+	}))
+	adapter.SetSchemaObserver(observer)
+
+	require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.gitea.create", []byte(`{"ref": "v1", "ref_type": "tag"}`))))
+
+	require.Equal(t, []string{"gitea.create"}, observer.subjects)
+	require.Equal(t, [][]string{{"$.ref", "$.ref_type", "$.repository.full_name", "$.repository.html_url"}}, observer.fields)
+}
+
+func TestTranslators(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	adapter := NewCDEventAdapter(logger, &MockPublisher{}, translator.NewRegistry(map[string]translator.CDEventTranslator{
+		"gitea.push":   &translator.GiteaPushTranslator{},
+		"gitea.create": &translator.GiteaCreateTranslator{},
+	}))
+	adapter.SetDisabledTranslators([]string{"gitea.create"})
+
+	require.Equal(t, []TranslatorInfo{
+		{Subject: "gitea.create", Translator: "translator.GiteaCreateTranslator", Enabled: false},
+		{Subject: "gitea.push", Translator: "translator.GiteaPushTranslator", Enabled: true},
+	}, adapter.Translators())
+
+	require.True(t, adapter.EnableTranslator("gitea.create"))
+	require.True(t, adapter.DisableTranslator("gitea.push"))
+	require.False(t, adapter.DisableTranslator("gitea.fork"))
+
+	require.Equal(t, []TranslatorInfo{
+		{Subject: "gitea.create", Translator: "translator.GiteaCreateTranslator", Enabled: true},
+		{Subject: "gitea.push", Translator: "translator.GiteaPushTranslator", Enabled: false},
+	}, adapter.Translators())
+
+	// Reloading the configured translators keeps the changes made at runtime.
+	adapter.SetDisabledTranslators([]string{"gitea.create"})
+	require.Equal(t, []TranslatorInfo{
+		{Subject: "gitea.create", Translator: "translator.GiteaCreateTranslator", Enabled: true},
+		{Subject: "gitea.push", Translator: "translator.GiteaPushTranslator", Enabled: false},
+	}, adapter.Translators())
+}
+
+func TestProcessLogsCorrelationID(t *testing.T) {
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	mockPublisher := &MockPublisher{}
+	mockTranslator := &MockCDEventTranslator{}
+	mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil)
+	mockPublisher.On("Publish", mock.Anything).Return(nil)
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}))
+
+	msg := newMockJetstreamMsg("webhook.test.event", []byte("{}"))
+	msg.headers = nats.Header{correlation.Header: []string{"abc-123"}}
+	require.NoError(t, adapter.Process(msg))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.NotEmpty(t, lines)
+	for _, line := range lines {
+		require.Contains(t, line, "correlation_id=abc-123")
+	}
+}
+
+// multiTranslator translates every payload to a fixed list of events.
+type multiTranslator struct {
+	events func() []cdevents.CDEvent
+}
+
+func (m multiTranslator) Translate(data []byte) (cdevents.CDEvent, error) {
+	return m.events()[0], nil
+}
+
+func (m multiTranslator) TranslateAll(data []byte) ([]cdevents.CDEvent, error) {
+	return m.events(), nil
+}
+
+func TestProcessAdditionalEvents(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	newRelease := func() cdevents.CDEvent {
+		release, err := cdeventsv04.NewArtifactPublishedEvent()
+		require.NoError(t, err)
+		release.SetSource("git.example.com")
+		release.SetSubjectId("pkg:generic/yoloco/project1@v1.0.0")
+		return release
+	}
+
+	for _, tc := range []struct {
+		title       string
+		publishErr  error
+		expectedErr string
+		expected    int
+	}{
+		{title: "publishes additional events before the event", expected: 2},
+		{title: "fails when an additional event cannot be published", publishErr: fmt.Errorf("unavailable"), expectedErr: "failed to publish additional event: unavailable", expected: 1},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			var published []cloudevents.Event
+			mockPublisher := &MockPublisher{}
+			mockPublisher.On("Publish", mock.Anything).Run(func(args mock.Arguments) {
+				published = append(published, args.Get(0).(cloudevents.Event))
+			}).Return(tc.publishErr)
+
+			translators := translator.NewRegistry(map[string]translator.CDEventTranslator{
+				"gitea.push": multiTranslator{events: func() []cdevents.CDEvent { return []cdevents.CDEvent{newTestCDEvent(t), newRelease()} }},
+			})
+			adapter := NewCDEventAdapter(logger, mockPublisher, translators)
+			adapter.SetDeterministicIDs(true)
+
+			msg := &nakableJetstreamMsg{MockJetstreamMsg: newMockJetstreamMsg("webhook.gitea.push", []byte(`{}`))}
+			msg.headers = nats.Header{DeliveryIDHeader: []string{"delivery-1"}}
+			err := adapter.Process(msg)
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				assert.True(t, msg.naked, "message should be redelivered when an additional event failed to publish")
+				assert.False(t, msg.acked)
+			} else {
+				require.NoError(t, err)
+				assert.True(t, msg.acked)
+			}
+
+			require.Len(t, published, tc.expected)
+			assert.Equal(t, cdeventsv04.ArtifactPublishedEventType.String(), published[0].Type())
+			assert.Equal(t, deterministicID("gitea.push", "delivery-1/1"), published[0].ID())
+			if tc.expected > 1 {
+				assert.Equal(t, cdeventsv04.ChangeMergedEventType.String(), published[1].Type())
+				assert.Equal(t, deterministicID("gitea.push", "delivery-1"), published[1].ID())
+			}
+		})
+	}
+}
+
+func TestProcessMarksSelftestEvents(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var published []cloudevents.Event
+	mockPublisher := &MockPublisher{}
+	mockPublisher.On("Publish", mock.Anything).Run(func(args mock.Arguments) {
+		published = append(published, args.Get(0).(cloudevents.Event))
+	}).Return(nil)
+	mockTranslator := &MockCDEventTranslator{}
+	mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil)
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}))
+
+	msg := newMockJetstreamMsg("webhook.test.event", []byte("{}"))
+	msg.headers = nats.Header{}
+	require.NoError(t, adapter.Process(msg))
+
+	msg = newMockJetstreamMsg("webhook.test.event", []byte("{}"))
+	msg.headers = nats.Header{SelftestHeader: []string{"true"}}
+	require.NoError(t, adapter.Process(msg))
+
+	require.Len(t, published, 2)
+	assert.NotContains(t, published[0].Extensions(), SelftestExtension)
+	assert.Equal(t, true, published[1].Extensions()[SelftestExtension])
+}
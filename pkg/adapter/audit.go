@@ -0,0 +1,175 @@
+package adapter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/metrics"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	OutcomePublished = "published"
+	OutcomeSkipped   = "skipped"
+	OutcomeFailed    = "failed"
+)
+
+// DeliveryIDHeader carries the delivery id of the webhook request, when the provider sent one.
+const DeliveryIDHeader = "Webhook-Delivery-Id"
+
+// RepositoryHeader carries the full name of the repository the webhook was sent for, when the
+// payload has one.
+const RepositoryHeader = "Webhook-Repository"
+
+// ReplayHeader is set on webhook messages replayed from the archive, whose newly translated event
+// is published even if an event translated for the message before is cached.
+const ReplayHeader = "Webhook-Replay"
+
+// SelftestHeader is set on webhook messages sent by a self test, whose events are marked with the
+// SelftestExtension.
+const SelftestHeader = "Webhook-Selftest"
+
+// AuditRecord is a compact summary of how a single webhook message was processed.
+type AuditRecord struct {
+	WebhookSubject string    `json:"webhook_subject"`
+	DeliveryID     string    `json:"delivery_id,omitempty"`
+	CorrelationID  string    `json:"correlation_id,omitempty"`
+	Translator     string    `json:"translator,omitempty"`
+	EventID        string    `json:"event_id,omitempty"`
+	EventType      string    `json:"event_type,omitempty"`
+	Outcome        string    `json:"outcome"`
+	Error          string    `json:"error,omitempty"`
+	DurationMs     float64   `json:"duration_ms"`
+	Time           time.Time `json:"time"`
+}
+
+type Auditor interface {
+	Audit(record AuditRecord) error
+}
+
+// NATSAuditor publishes audit records as JSON on a dedicated subject.
+type NATSAuditor struct {
+	nc      NATSPublisher
+	subject string
+}
+
+func NewNATSAuditor(nc NATSPublisher, subject string) *NATSAuditor {
+	return &NATSAuditor{nc: nc, subject: subject}
+}
+
+func (a *NATSAuditor) Audit(record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(a.subject)
+	msg.Data = data
+	msg.Header.Set("Outcome", record.Outcome)
+
+	return a.nc.PublishMsg(msg)
+}
+
+// LogAuditor writes audit records as log lines, independent of the configured log level.
+type LogAuditor struct {
+	logger *slog.Logger
+}
+
+func NewLogAuditor(logger *slog.Logger) *LogAuditor {
+	return &LogAuditor{logger: logger}
+}
+
+func (a *LogAuditor) Audit(record AuditRecord) error {
+	a.logger.Info("Audit",
+		"webhook_subject", record.WebhookSubject,
+		"delivery_id", record.DeliveryID,
+		"correlation_id", record.CorrelationID,
+		"translator", record.Translator,
+		"event_id", record.EventID,
+		"event_type", record.EventType,
+		"outcome", record.Outcome,
+		"error", record.Error,
+		"duration_ms", record.DurationMs)
+	return nil
+}
+
+// MultiAuditor sends audit records to several auditors. Every auditor is given the record, even
+// if an earlier one fails, and the errors of all of them are returned.
+type MultiAuditor []Auditor
+
+func (m MultiAuditor) Audit(record AuditRecord) error {
+	var errs []error
+	for _, auditor := range m {
+		if err := auditor.Audit(record); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// DeferredAuditor passes audit records on to another auditor, or holds them back while deferred,
+// e.g. to spend the time on processing while draining a backlog. At most limit records are held
+// back and the rest are dropped, which is counted by the deferred_audit_records_dropped_total
+// metric.
+type DeferredAuditor struct {
+	auditor  Auditor
+	limit    int
+	mu       sync.Mutex
+	deferred bool
+	held     []AuditRecord
+	dropped  int
+}
+
+func NewDeferredAuditor(auditor Auditor, limit int) *DeferredAuditor {
+	return &DeferredAuditor{auditor: auditor, limit: limit}
+}
+
+func (a *DeferredAuditor) Audit(record AuditRecord) error {
+	a.mu.Lock()
+	if a.deferred {
+		if len(a.held) < a.limit {
+			a.held = append(a.held, record)
+		} else {
+			a.dropped++
+			metrics.DeferredAuditsDropped.Inc()
+		}
+		a.mu.Unlock()
+		return nil
+	}
+	a.mu.Unlock()
+
+	return a.auditor.Audit(record)
+}
+
+// Defer holds back audit records until Flush is called.
+func (a *DeferredAuditor) Defer() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.deferred = true
+}
+
+// Flush stops deferring and writes the records held back. It returns an error if a record could
+// not be written or records were dropped.
+func (a *DeferredAuditor) Flush() error {
+	a.mu.Lock()
+	held, dropped := a.held, a.dropped
+	a.deferred, a.held, a.dropped = false, nil, 0
+	a.mu.Unlock()
+
+	for _, record := range held {
+		if err := a.auditor.Audit(record); err != nil {
+			return err
+		}
+	}
+
+	if dropped > 0 {
+		return fmt.Errorf("dropped %d deferred audit records beyond the limit of %d", dropped, a.limit)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessAudits(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tc := range []struct {
+		title             string
+		msgSubject        string
+		disabled          []string
+		publishErr        error
+		expectedOutcome   string
+		expectedError     string
+		expectedEventType bool
+	}{
+		{
+			title:             "audits published event",
+			msgSubject:        "webhook.test.event",
+			expectedOutcome:   OutcomePublished,
+			expectedEventType: true,
+		},
+		{
+			title:             "audits failed publish",
+			msgSubject:        "webhook.test.event",
+			publishErr:        fmt.Errorf("sink unavailable"),
+			expectedOutcome:   OutcomeFailed,
+			expectedError:     "sink unavailable",
+			expectedEventType: true,
+		},
+		{
+			title:           "audits missing translator",
+			msgSubject:      "webhook.test.unknown",
+			expectedOutcome: OutcomeFailed,
+			expectedError:   "no translator found for subject: test.unknown",
+		},
+		{
+			title:           "audits skipped message",
+			msgSubject:      "webhook.test.event",
+			disabled:        []string{"test.event"},
+			expectedOutcome: OutcomeSkipped,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			mockPublisher := &MockPublisher{}
+			mockTranslator := &MockCDEventTranslator{}
+			mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil)
+			mockPublisher.On("Publish", mock.Anything).Return(tc.publishErr)
+
+			nc := &mockNATSPublisher{}
+
+			adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}))
+			adapter.SetDisabledTranslators(tc.disabled)
+			adapter.SetAuditor(NewNATSAuditor(nc, "cdevents-adapter.audit"))
+
+			msg := newMockJetstreamMsg(tc.msgSubject, []byte("{}"))
+			msg.headers = nats.Header{DeliveryIDHeader: []string{"f6266f16-1d7d-4ed3-a3c8-a2a1b9b2c1e7"}}
+			adapter.Process(msg)
+
+			require.Len(t, nc.published, 1, "processed message should be audited")
+			assert.Equal(t, "cdevents-adapter.audit", nc.published[0].Subject)
+			assert.Equal(t, tc.expectedOutcome, nc.published[0].Header.Get("Outcome"))
+
+			var record AuditRecord
+			require.NoError(t, json.Unmarshal(nc.published[0].Data, &record))
+			assert.Equal(t, tc.msgSubject, record.WebhookSubject)
+			assert.Equal(t, "f6266f16-1d7d-4ed3-a3c8-a2a1b9b2c1e7", record.DeliveryID)
+			assert.Equal(t, tc.expectedOutcome, record.Outcome)
+			assert.Equal(t, tc.expectedError, record.Error)
+			if tc.expectedEventType {
+				assert.Equal(t, "dev.cdevents.change.merged.0.2.0", record.EventType)
+				assert.NotEmpty(t, record.EventID)
+			} else {
+				assert.Empty(t, record.EventType)
+			}
+		})
+	}
+}
+
+type recordingAuditor struct {
+	records []AuditRecord
+}
+
+func (a *recordingAuditor) Audit(record AuditRecord) error {
+	a.records = append(a.records, record)
+	return nil
+}
+
+type failingAuditor struct {
+	err error
+}
+
+func (a failingAuditor) Audit(record AuditRecord) error {
+	return a.err
+}
+
+func TestMultiAuditor(t *testing.T) {
+
+	recorder := &recordingAuditor{}
+	auditor := MultiAuditor{
+		failingAuditor{err: fmt.Errorf("nats unavailable")},
+		recorder,
+		failingAuditor{err: fmt.Errorf("disk full")},
+	}
+
+	err := auditor.Audit(AuditRecord{EventID: "1"})
+
+	require.EqualError(t, err, "nats unavailable\ndisk full")
+	require.Len(t, recorder.records, 1, "auditors after a failing one should be given the record")
+}
+
+func TestDeferredAuditor(t *testing.T) {
+
+	recorder := &recordingAuditor{}
+	auditor := NewDeferredAuditor(recorder, 2)
+
+	require.NoError(t, auditor.Audit(AuditRecord{EventID: "1"}))
+	require.Len(t, recorder.records, 1, "records should be passed on when not deferred")
+
+	auditor.Defer()
+	for _, id := range []string{"2", "3", "4"} {
+		require.NoError(t, auditor.Audit(AuditRecord{EventID: id}))
+	}
+	require.Len(t, recorder.records, 1, "records should be held back while deferred")
+
+	require.EqualError(t, auditor.Flush(), "dropped 1 deferred audit records beyond the limit of 2")
+	require.Len(t, recorder.records, 3)
+	require.Equal(t, "3", recorder.records[2].EventID)
+
+	require.NoError(t, auditor.Audit(AuditRecord{EventID: "5"}))
+	require.Len(t, recorder.records, 4, "records should be passed on after flushing")
+}
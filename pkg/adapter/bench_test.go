@@ -0,0 +1,66 @@
+package adapter
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/expr"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+type discardPublisher struct{}
+
+func (discardPublisher) Publish(ctx context.Context, event cloudevents.Event) error {
+	return nil
+}
+
+func BenchmarkProcess(b *testing.B) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	data, err := os.ReadFile("testdata/gitea_push.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	jq, err := translator.NewJQTranslator(translator.JQConfig{
+		Program: `{type: "dev.cdevents.change.merged.0.2.0", subject_id: .after, source: .repository.html_url, repository: .repository.full_name}`,
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, bc := range []struct {
+		name          string
+		translator    translator.CDEventTranslator
+		payloadFilter string
+	}{
+		{name: "gitea", translator: translator.NewGiteaPushTranslator(translator.GiteaConfig{})},
+		{name: "gitea with payload filter", translator: translator.NewGiteaPushTranslator(translator.GiteaConfig{}), payloadFilter: `repository.full_name.startsWith("yoloco/")`},
+		{name: "jq", translator: jq},
+		{name: "jq with payload filter", translator: jq, payloadFilter: `repository.full_name.startsWith("yoloco/")`},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			adapter := NewCDEventAdapter(logger, discardPublisher{}, translator.NewRegistry(map[string]translator.CDEventTranslator{"gitea.push": bc.translator}))
+			if bc.payloadFilter != "" {
+				filter, err := expr.Compile(bc.payloadFilter)
+				if err != nil {
+					b.Fatal(err)
+				}
+				adapter.SetPayloadFilter(filter)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := adapter.Process(newMockJetstreamMsg("webhook.gitea.push", data)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
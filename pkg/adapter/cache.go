@@ -0,0 +1,146 @@
+package adapter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/lru"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/metrics"
+
+	"github.com/nats-io/nats.go/jetstream"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// ResultCache stores the events translated from webhook messages, so that a redelivered message
+// is not translated again and, if it passes the filters again, is published as the identical
+// event, with the same id.
+type ResultCache interface {
+	Get(key string) (*cloudevents.Event, bool)
+	Add(key string, event *cloudevents.Event)
+}
+
+// KeyValue is the part of a JetStream key-value bucket used as the backing store of the cache.
+type KeyValue interface {
+	Get(ctx context.Context, key string) (jetstream.KeyValueEntry, error)
+	Put(ctx context.Context, key string, value []byte) (uint64, error)
+}
+
+// resultKey identifies a webhook message by its subject and delivery id, or by a hash of its
+// payload when the provider sent no delivery id. The key is valid in a key-value bucket.
+func resultKey(msg JetstreamMsg) string {
+	hash := sha256.New()
+	hash.Write([]byte(msg.Subject()))
+	hash.Write([]byte{0})
+	if deliveryID := msg.Headers().Get(DeliveryIDHeader); deliveryID != "" {
+		hash.Write([]byte(deliveryID))
+	} else {
+		hash.Write(msg.Data())
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// sequenceKey identifies a webhook message by its sequence in the webhook stream, which is the
+// same for every redelivery of the message. The key is valid in a key-value bucket.
+func sequenceKey(metadata *jetstream.MsgMetadata) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d", metadata.Stream, metadata.Sequence.Stream)))
+	return hex.EncodeToString(hash[:])
+}
+
+// LRUResultCache keeps the most recently translated events in memory. With a backing key-value
+// bucket, events are also written to the bucket and looked up there when they have been evicted
+// from memory, e.g. after a restart or when the message is redelivered to another replica.
+type LRUResultCache struct {
+	events  *lru.Cache[string, *cloudevents.Event]
+	backing KeyValue
+	timeout time.Duration
+}
+
+// NewLRUResultCache returns a cache holding at most size events in memory.
+func NewLRUResultCache(size int) *LRUResultCache {
+	return &LRUResultCache{
+		events:  lru.New[string, *cloudevents.Event](size),
+		timeout: 2 * time.Second,
+	}
+}
+
+// SetBacking sets a key-value bucket that stores the events beyond the memory of the cache. How
+// long events are kept there is decided by the TTL of the bucket.
+func (c *LRUResultCache) SetBacking(kv KeyValue) {
+	c.backing = kv
+}
+
+// Get returns a copy of the event translated for the key, if any.
+func (c *LRUResultCache) Get(key string) (*cloudevents.Event, bool) {
+	if cached, found := c.events.Get(key); found {
+		metrics.ResultCacheLookups.WithLabelValues("memory").Inc()
+		event := cached.Clone()
+		return &event, true
+	}
+
+	if event, found := c.load(key); found {
+		metrics.ResultCacheLookups.WithLabelValues("backing").Inc()
+		c.events.Add(key, event)
+		clone := event.Clone()
+		return &clone, true
+	}
+
+	metrics.ResultCacheLookups.WithLabelValues("miss").Inc()
+	return nil, false
+}
+
+// Add stores a copy of the event translated for the key.
+func (c *LRUResultCache) Add(key string, event *cloudevents.Event) {
+	clone := event.Clone()
+	c.events.Add(key, &clone)
+	c.store(key, &clone)
+}
+
+// load looks up an event in the backing bucket. Failures are treated as misses, since the
+// message can always be translated again.
+func (c *LRUResultCache) load(key string) (*cloudevents.Event, bool) {
+	if c.backing == nil {
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	entry, err := c.backing.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, jetstream.ErrKeyNotFound) {
+			metrics.ResultCacheErrors.Inc()
+		}
+		return nil, false
+	}
+
+	event := cloudevents.NewEvent()
+	if err := event.UnmarshalJSON(entry.Value()); err != nil {
+		metrics.ResultCacheErrors.Inc()
+		return nil, false
+	}
+	return &event, true
+}
+
+func (c *LRUResultCache) store(key string, event *cloudevents.Event) {
+	if c.backing == nil {
+		return
+	}
+
+	data, err := event.MarshalJSON()
+	if err != nil {
+		metrics.ResultCacheErrors.Inc()
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	if _, err := c.backing.Put(ctx, key, data); err != nil {
+		metrics.ResultCacheErrors.Inc()
+	}
+}
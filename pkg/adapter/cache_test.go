@@ -0,0 +1,188 @@
+package adapter
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/expr"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+type mockKeyValueEntry struct {
+	jetstream.KeyValueEntry
+	value []byte
+}
+
+func (e mockKeyValueEntry) Value() []byte { return e.value }
+
+type mockKeyValue struct {
+	entries map[string][]byte
+}
+
+func (kv *mockKeyValue) Get(ctx context.Context, key string) (jetstream.KeyValueEntry, error) {
+	value, found := kv.entries[key]
+	if !found {
+		return nil, jetstream.ErrKeyNotFound
+	}
+	return mockKeyValueEntry{value: value}, nil
+}
+
+func (kv *mockKeyValue) Put(ctx context.Context, key string, value []byte) (uint64, error) {
+	kv.entries[key] = value
+	return uint64(len(kv.entries)), nil
+}
+
+func newCacheTestEvent(id string) *cloudevents.Event {
+	event := cloudevents.NewEvent()
+	event.SetID(id)
+	event.SetSource("test")
+	event.SetType("dev.cdevents.change.merged.0.2.0")
+	return &event
+}
+
+func TestLRUResultCache(t *testing.T) {
+
+	cache := NewLRUResultCache(2)
+	cache.Add("a", newCacheTestEvent("1"))
+	cache.Add("b", newCacheTestEvent("2"))
+
+	_, found := cache.Get("a")
+	require.True(t, found)
+
+	cache.Add("c", newCacheTestEvent("3"))
+
+	_, found = cache.Get("b")
+	assert.False(t, found, "least recently used event should be evicted")
+
+	event, found := cache.Get("a")
+	require.True(t, found)
+	assert.Equal(t, "1", event.ID())
+
+	event.SetID("changed")
+	event, _ = cache.Get("a")
+	assert.Equal(t, "1", event.ID(), "cached event should not be changed through a returned copy")
+}
+
+func TestLRUResultCacheBacking(t *testing.T) {
+
+	kv := &mockKeyValue{entries: map[string][]byte{}}
+
+	cache := NewLRUResultCache(1)
+	cache.SetBacking(kv)
+	cache.Add("a", newCacheTestEvent("1"))
+	cache.Add("b", newCacheTestEvent("2"))
+	assert.Len(t, kv.entries, 2)
+
+	// A new cache, e.g. after a restart, finds the events in the bucket.
+	cache = NewLRUResultCache(1)
+	cache.SetBacking(kv)
+
+	event, found := cache.Get("a")
+	require.True(t, found)
+	assert.Equal(t, "1", event.ID())
+	assert.Equal(t, "dev.cdevents.change.merged.0.2.0", event.Type())
+
+	_, found = cache.Get("unknown")
+	assert.False(t, found)
+}
+
+func TestResultKey(t *testing.T) {
+
+	withDelivery := func(subject, deliveryID string, data string) *MockJetstreamMsg {
+		msg := newMockJetstreamMsg(subject, []byte(data))
+		msg.headers = nats.Header{}
+		if deliveryID != "" {
+			msg.headers.Set(DeliveryIDHeader, deliveryID)
+		}
+		return msg
+	}
+
+	assert.Equal(t, resultKey(withDelivery("webhook.gitea.push", "1", "{}")), resultKey(withDelivery("webhook.gitea.push", "1", `{"a": 1}`)),
+		"messages with the same delivery id should have the same key")
+	assert.NotEqual(t, resultKey(withDelivery("webhook.gitea.push", "1", "{}")), resultKey(withDelivery("webhook.gitea.push", "2", "{}")))
+	assert.Equal(t, resultKey(withDelivery("webhook.gitea.push", "", "{}")), resultKey(withDelivery("webhook.gitea.push", "", "{}")),
+		"messages without delivery id should be keyed by payload")
+	assert.NotEqual(t, resultKey(withDelivery("webhook.gitea.push", "", "{}")), resultKey(withDelivery("webhook.gitea.create", "", "{}")))
+}
+
+func TestProcessPublishesCachedEventForRedeliveredMessage(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mockPublisher := &MockPublisher{}
+	mockTranslator := &MockCDEventTranslator{}
+	mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil).Once()
+
+	var published []string
+	mockPublisher.On("Publish", mock.Anything).Run(func(args mock.Arguments) {
+		published = append(published, args.Get(0).(cloudevents.Event).ID())
+	}).Return(nil)
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}))
+	adapter.SetResultCache(NewLRUResultCache(10))
+
+	msg := newMockJetstreamMsg("webhook.test.event", []byte(`{"foo": "bar"}`))
+	require.NoError(t, adapter.Process(msg))
+	require.NoError(t, adapter.Process(msg))
+
+	require.Len(t, published, 2)
+	assert.Equal(t, published[0], published[1], "redelivered message should be published with the same event id")
+	mockTranslator.AssertNumberOfCalls(t, "Translate", 1)
+}
+
+func TestProcessFiltersCachedEvent(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mockPublisher := &MockPublisher{}
+	mockTranslator := &MockCDEventTranslator{}
+	mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil).Once()
+	mockPublisher.On("Publish", mock.Anything).Return(nil)
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}))
+	adapter.SetResultCache(NewLRUResultCache(10))
+
+	msg := newMockJetstreamMsg("webhook.test.event", []byte(`{"foo": "bar"}`))
+	require.NoError(t, adapter.Process(msg))
+
+	filter, err := expr.Compile(`context.type.startsWith("dev.cdevents.incident")`)
+	require.NoError(t, err)
+	adapter.SetEventFilter(filter)
+	require.NoError(t, adapter.Process(msg))
+
+	mockPublisher.AssertNumberOfCalls(t, "Publish", 1)
+	mockTranslator.AssertNumberOfCalls(t, "Translate", 1)
+}
+
+func TestProcessTranslatesReplayedMessageAgain(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mockPublisher := &MockPublisher{}
+	mockTranslator := &MockCDEventTranslator{}
+	mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil).Once()
+	mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil).Once()
+	mockPublisher.On("Publish", mock.Anything).Return(nil)
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}))
+	adapter.SetResultCache(NewLRUResultCache(10))
+
+	msg := newMockJetstreamMsg("webhook.test.event", []byte(`{"foo": "bar"}`))
+	msg.headers = nats.Header{}
+	require.NoError(t, adapter.Process(msg))
+
+	msg.headers.Set(ReplayHeader, "true")
+	require.NoError(t, adapter.Process(msg))
+
+	mockTranslator.AssertNumberOfCalls(t, "Translate", 2)
+}
@@ -0,0 +1,94 @@
+package adapter
+
+import (
+	"encoding/json"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+)
+
+// EnvironmentMapping maps the environments that translators put in events, e.g. the branch,
+// Kubernetes namespace or cluster that a service was deployed to, to the environments of the
+// organisation, so that environments are named the same way whatever the provider of the event.
+type EnvironmentMapping struct {
+	// Rules maps glob patterns of the environment ids set by translators to environment ids, e.g.
+	// "prod-*:production,main:production". The longest matching pattern is used. Environments
+	// without a match are kept.
+	Rules map[string]string `envconfig:"RULES"`
+	// Names are the names of environments by their id, which are set on environment events, e.g.
+	// "production:Production".
+	Names map[string]string `envconfig:"NAMES"`
+
+	rules globMap
+}
+
+// Enabled reports whether any rules or names are configured.
+func (m EnvironmentMapping) Enabled() bool {
+	return len(m.Rules) > 0 || len(m.Names) > 0
+}
+
+// Validate checks the patterns of the rules.
+func (m EnvironmentMapping) Validate() error {
+	_, err := newGlobMap("environment", m.Rules)
+	return err
+}
+
+// SetEnvironmentMapping maps the environments of every translated event, which are the
+// environment that a service, incident or test event refers to and the subject of environment
+// events.
+func (c *CDEventAdapter) SetEnvironmentMapping(mapping EnvironmentMapping) error {
+	rules, err := newGlobMap("environment", mapping.Rules)
+	if err != nil {
+		return err
+	}
+	mapping.rules = rules
+	c.environments = &mapping
+	return nil
+}
+
+func (m EnvironmentMapping) environment(id string) string {
+	if mapped, found := m.rules.lookup(id); found {
+		return mapped
+	}
+	return id
+}
+
+// mapEnvironment maps the environment of an event, if it has one.
+func (m EnvironmentMapping) mapEnvironment(cdEvent cdevents.CDEvent) {
+	if cdEvent.GetType().Subject == "environment" {
+		id := m.environment(cdEvent.GetSubjectId())
+		cdEvent.SetSubjectId(id)
+		if named, ok := cdEvent.(interface{ SetSubjectName(string) }); ok && m.Names[id] != "" {
+			named.SetSubjectName(m.Names[id])
+		}
+		return
+	}
+
+	referring, ok := cdEvent.(interface {
+		SetSubjectEnvironment(*cdevents.Reference)
+	})
+	if !ok {
+		return
+	}
+	if environment := subjectEnvironment(cdEvent); environment != nil && environment.Id != "" {
+		referring.SetSubjectEnvironment(&cdevents.Reference{Id: m.environment(environment.Id), Source: environment.Source})
+	}
+}
+
+// subjectEnvironment returns the environment in the subject content of an event, if it has one.
+func subjectEnvironment(cdEvent cdevents.CDEvent) *cdevents.Reference {
+	data, err := json.Marshal(cdEvent)
+	if err != nil {
+		return nil
+	}
+	var event struct {
+		Subject struct {
+			Content struct {
+				Environment *cdevents.Reference `json:"environment"`
+			} `json:"content"`
+		} `json:"subject"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil
+	}
+	return event.Subject.Content.Environment
+}
@@ -0,0 +1,96 @@
+package adapter
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+	cdeventsv04 "github.com/cdevents/sdk-go/pkg/api/v04"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+func TestEnvironmentMapping(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	mapping := EnvironmentMapping{
+		Rules: map[string]string{"prod-*": "production", "prod-eu1-canary": "canary", "main": "production"},
+		Names: map[string]string{"production": "Production"},
+	}
+
+	newDeployed := func(environment string) cdevents.CDEvent {
+		event, err := cdeventsv04.NewServiceDeployedEvent()
+		require.NoError(t, err)
+		event.SetSource("argocd.example.com")
+		event.SetSubjectId("payments-api")
+		event.SetSubjectEnvironment(&cdevents.Reference{Id: environment, Source: "k8s.example.com"})
+		event.SetSubjectArtifactId("pkg:oci/payments-api@sha256%3A0b3b5b4b")
+		return event
+	}
+	newCreated := func(environment string) cdevents.CDEvent {
+		event, err := cdeventsv04.NewEnvironmentCreatedEvent()
+		require.NoError(t, err)
+		event.SetSource("k8s.example.com")
+		event.SetSubjectId(environment)
+		return event
+	}
+
+	for _, tc := range []struct {
+		title               string
+		event               cdevents.CDEvent
+		expectedEnvironment string
+		expectedName        string
+	}{
+		{title: "maps environment of service event", event: newDeployed("prod-eu1"), expectedEnvironment: "production"},
+		{title: "uses longest matching pattern", event: newDeployed("prod-eu1-canary"), expectedEnvironment: "canary"},
+		{title: "keeps environment without match", event: newDeployed("staging"), expectedEnvironment: "staging"},
+		{title: "maps and names environment event", event: newCreated("main"), expectedEnvironment: "production", expectedName: "Production"},
+		{title: "keeps name of environment without name", event: newCreated("staging"), expectedEnvironment: "staging"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			var published cloudevents.Event
+			mockPublisher := &MockPublisher{}
+			mockPublisher.On("Publish", mock.Anything).Run(func(args mock.Arguments) {
+				published = args.Get(0).(cloudevents.Event)
+			}).Return(nil)
+
+			mockTranslator := &MockCDEventTranslator{}
+			mockTranslator.On("Translate", mock.Anything).Return(tc.event, nil)
+
+			adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"argocd.sync": mockTranslator}))
+			require.NoError(t, adapter.SetEnvironmentMapping(mapping))
+			require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.argocd.sync", []byte(`{}`))))
+
+			var event struct {
+				Subject struct {
+					ID      string `json:"id"`
+					Content struct {
+						Name        string              `json:"name"`
+						Environment *cdevents.Reference `json:"environment"`
+					} `json:"content"`
+				} `json:"subject"`
+			}
+			require.NoError(t, json.Unmarshal(published.Data(), &event))
+			if environment := event.Subject.Content.Environment; environment != nil {
+				assert.Equal(t, tc.expectedEnvironment, environment.Id)
+				assert.Equal(t, "k8s.example.com", environment.Source, "source should be kept")
+			} else {
+				assert.Equal(t, tc.expectedEnvironment, event.Subject.ID)
+				assert.Equal(t, tc.expectedName, event.Subject.Content.Name)
+			}
+		})
+	}
+}
+
+func TestSetEnvironmentMappingRejectsInvalidPattern(t *testing.T) {
+	adapter := NewCDEventAdapter(slog.New(slog.NewTextHandler(io.Discard, nil)), &MockPublisher{}, translator.NewRegistry(nil))
+
+	assert.ErrorContains(t, adapter.SetEnvironmentMapping(EnvironmentMapping{Rules: map[string]string{"prod-[": "production"}}), "environment: invalid pattern prod-[")
+}
@@ -0,0 +1,139 @@
+package adapter
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// FailedEvent describes a webhook message or event that could not be translated or published.
+// The webhook stream sequence and payload hash can be used to find the raw payload in the
+// archive stream, where republished messages keep the original sequence in the
+// Nats-Sequence header.
+type FailedEvent struct {
+	Reason         string `json:"reason"`
+	Translator     string `json:"translator,omitempty"`
+	WebhookSubject string `json:"webhook_subject,omitempty"`
+	Stream         string `json:"stream,omitempty"`
+	StreamSequence uint64 `json:"stream_sequence,omitempty"`
+	PayloadSHA256  string `json:"payload_sha256,omitempty"`
+	CorrelationID  string `json:"correlation_id,omitempty"`
+	Sink           string `json:"sink,omitempty"`
+	EventID        string `json:"event_id,omitempty"`
+	EventType      string `json:"event_type,omitempty"`
+	// ValidationErrors are the violations of the CDEvents schema by the translated event.
+	ValidationErrors []string  `json:"validation_errors,omitempty"`
+	Time             time.Time `json:"time"`
+
+	// Payload is the raw webhook payload. It is not part of the published error record.
+	Payload []byte `json:"-"`
+}
+
+// SinkError is returned by a publisher that publishes to several sinks for an event that failed
+// to publish to one of them.
+type SinkError struct {
+	Sink      string
+	EventID   string
+	EventType string
+	Err       error
+}
+
+func (e *SinkError) Error() string {
+	return fmt.Sprintf("failed to publish event %s to sink %s: %s", e.EventID, e.Sink, e.Err)
+}
+
+func (e *SinkError) Unwrap() error {
+	return e.Err
+}
+
+type ErrorReporter interface {
+	Report(failed FailedEvent) error
+}
+
+// MultiReporter sends failed events to several reporters.
+type MultiReporter []ErrorReporter
+
+func (m MultiReporter) Report(failed FailedEvent) error {
+	var errs []error
+	for _, reporter := range m {
+		errs = append(errs, reporter.Report(failed))
+	}
+	return errors.Join(errs...)
+}
+
+type NATSPublisher interface {
+	PublishMsg(msg *nats.Msg) error
+}
+
+// NATSErrorReporter publishes failed events as JSON on an error subject, which can be captured
+// by a stream for alerting and later inspection.
+type NATSErrorReporter struct {
+	nc      NATSPublisher
+	subject string
+}
+
+func NewNATSErrorReporter(nc NATSPublisher, subject string) *NATSErrorReporter {
+	return &NATSErrorReporter{nc: nc, subject: subject}
+}
+
+func (r *NATSErrorReporter) Report(failed FailedEvent) error {
+	data, err := json.Marshal(failed)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(r.subject)
+	msg.Data = data
+	if failed.Translator != "" {
+		msg.Header.Set("Translator", failed.Translator)
+	}
+	if failed.Sink != "" {
+		msg.Header.Set("Sink", failed.Sink)
+	}
+
+	return r.nc.PublishMsg(msg)
+}
+
+func newFailedEvent(msg JetstreamMsg, err error) FailedEvent {
+	payloadHash := sha256.Sum256(msg.Data())
+
+	failed := FailedEvent{
+		Reason:         err.Error(),
+		WebhookSubject: msg.Subject(),
+		PayloadSHA256:  hex.EncodeToString(payloadHash[:]),
+		Payload:        msg.Data(),
+		Time:           time.Now().UTC(),
+	}
+
+	if _, translator, found := strings.Cut(msg.Subject(), "."); found {
+		failed.Translator = translator
+	}
+
+	if metadata, err := msg.Metadata(); err == nil {
+		failed.Stream = metadata.Stream
+		failed.StreamSequence = metadata.Sequence.Stream
+	}
+
+	var invalid *ValidationError
+	if errors.As(err, &invalid) {
+		failed.EventType = invalid.EventType
+		failed.ValidationErrors = invalid.Details
+	}
+
+	// Only the first sink is recorded when an event failed to publish to several sinks, all of
+	// which are in the reason.
+	var sinkErr *SinkError
+	if errors.As(err, &sinkErr) {
+		failed.Sink = sinkErr.Sink
+		failed.EventID = sinkErr.EventID
+		failed.EventType = sinkErr.EventType
+	}
+
+	return failed
+}
@@ -0,0 +1,82 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type mockNATSPublisher struct {
+	published []*nats.Msg
+}
+
+func (m *mockNATSPublisher) PublishMsg(msg *nats.Msg) error {
+	m.published = append(m.published, msg)
+	return nil
+}
+
+func TestProcessReportsFailedMessages(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mockPublisher := &MockPublisher{}
+	mockTranslator := &MockCDEventTranslator{}
+	mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil)
+	mockPublisher.On("Publish", mock.Anything).Return(fmt.Errorf("queue for sink kafka is full"))
+
+	nc := &mockNATSPublisher{}
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}))
+	adapter.SetErrorReporter(NewNATSErrorReporter(nc, "cdevents-adapter.errors"))
+
+	msg := newMockJetstreamMsg("webhook.test.event", []byte("{}"))
+	msg.streamSeq = 42
+	require.Error(t, adapter.Process(msg))
+
+	require.Len(t, nc.published, 1, "failed message should be reported")
+	assert.Equal(t, "cdevents-adapter.errors", nc.published[0].Subject)
+	assert.Equal(t, "test.event", nc.published[0].Header.Get("Translator"))
+
+	var failed FailedEvent
+	require.NoError(t, json.Unmarshal(nc.published[0].Data, &failed))
+	assert.Equal(t, "queue for sink kafka is full", failed.Reason)
+	assert.Equal(t, "test.event", failed.Translator)
+	assert.Equal(t, "webhook.test.event", failed.WebhookSubject)
+	assert.Equal(t, uint64(42), failed.StreamSequence)
+	assert.Equal(t, "44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a", failed.PayloadSHA256)
+}
+
+func TestProcessReportsFailedSink(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mockPublisher := &MockPublisher{}
+	mockTranslator := &MockCDEventTranslator{}
+	mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil)
+	mockPublisher.On("Publish", mock.Anything).Return(&SinkError{Sink: "kafka", EventID: "1", EventType: "dev.cdevents.change.merged.0.2.0", Err: fmt.Errorf("unavailable")})
+
+	nc := &mockNATSPublisher{}
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}))
+	adapter.SetErrorReporter(NewNATSErrorReporter(nc, "cdevents-adapter.errors"))
+
+	require.Error(t, adapter.Process(newMockJetstreamMsg("webhook.test.event", []byte("{}"))))
+
+	require.Len(t, nc.published, 1, "failed sink should be reported once")
+	assert.Equal(t, "kafka", nc.published[0].Header.Get("Sink"))
+
+	var failed FailedEvent
+	require.NoError(t, json.Unmarshal(nc.published[0].Data, &failed))
+	assert.Equal(t, "failed to publish event 1 to sink kafka: unavailable", failed.Reason)
+	assert.Equal(t, "kafka", failed.Sink)
+	assert.Equal(t, "1", failed.EventID)
+	assert.Equal(t, "dev.cdevents.change.merged.0.2.0", failed.EventType)
+}
@@ -0,0 +1,113 @@
+package adapter
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/expr"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilters(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tc := range []struct {
+		title            string
+		payloadFilter    string
+		eventFilter      string
+		msgData          string
+		expectPublished  bool
+		expectTranslated bool
+		expectedError    string
+	}{
+		{
+			title:            "publishes when filters match",
+			payloadFilter:    `repository.full_name.startsWith("platform/")`,
+			eventFilter:      `subject.content.repository.id == "yoloco/project1"`,
+			msgData:          `{"repository": {"full_name": "platform/api"}}`,
+			expectTranslated: true,
+			expectPublished:  true,
+		},
+		{
+			title:         "skips payload that does not match",
+			payloadFilter: `repository.full_name.startsWith("platform/")`,
+			msgData:       `{"repository": {"full_name": "team/api"}}`,
+		},
+		{
+			title:            "skips event that does not match",
+			eventFilter:      `context.type.startsWith("dev.cdevents.incident")`,
+			msgData:          `{}`,
+			expectTranslated: true,
+		},
+		{
+			title:         "skips payload without filtered field",
+			payloadFilter: `repository.full_name.startsWith("platform/")`,
+			msgData:       `{}`,
+		},
+		{
+			title:         "fails when payload filter cannot be evaluated",
+			payloadFilter: `repository.full_name + 1 == "platform/api"`,
+			msgData:       `{"repository": {"full_name": "platform/api"}}`,
+			expectedError: "payload filter: failed to evaluate expression",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			mockPublisher := &MockPublisher{}
+			mockPublisher.On("Publish", mock.Anything).Return(nil)
+			mockTranslator := &MockCDEventTranslator{}
+			mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil)
+
+			adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}))
+			if tc.payloadFilter != "" {
+				filter, err := expr.Compile(tc.payloadFilter)
+				require.NoError(t, err)
+				adapter.SetPayloadFilter(filter)
+			}
+			if tc.eventFilter != "" {
+				filter, err := expr.Compile(tc.eventFilter)
+				require.NoError(t, err)
+				adapter.SetEventFilter(filter)
+			}
+
+			err := adapter.Process(newMockJetstreamMsg("webhook.test.event", []byte(tc.msgData)))
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+			}
+
+			if tc.expectTranslated {
+				mockTranslator.AssertCalled(t, "Translate", mock.Anything)
+			} else {
+				mockTranslator.AssertNotCalled(t, "Translate", mock.Anything)
+			}
+			if tc.expectPublished {
+				mockPublisher.AssertCalled(t, "Publish", mock.Anything)
+			} else {
+				mockPublisher.AssertNotCalled(t, "Publish", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestProcessSkipsTranslation(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mockPublisher := &MockPublisher{}
+	mockTranslator := &MockCDEventTranslator{}
+	mockTranslator.On("Translate", mock.Anything).Return(nil, translator.Skip("branch %s is not a main branch", "feature"))
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}))
+
+	err := adapter.Process(newMockJetstreamMsg("webhook.test.event", []byte(`{}`)))
+	require.NoError(t, err)
+
+	mockPublisher.AssertNotCalled(t, "Publish", mock.Anything)
+	assert.Equal(t, uint64(0), adapter.Stats().Failed)
+}
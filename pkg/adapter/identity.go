@@ -0,0 +1,87 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+)
+
+// IdentityMap maps the identities of people at providers to their corporate identities, e.g. an
+// employee id, by "<provider>:<username>" or by email, e.g.
+//
+//	{"gitea:alice": "E1234", "alice@example.com": "E1234"}
+type IdentityMap map[string]string
+
+// LoadIdentityMap reads an identity map from a JSON file.
+func LoadIdentityMap(path string) (IdentityMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var identities IdentityMap
+	if err := json.Unmarshal(data, &identities); err != nil {
+		return nil, fmt.Errorf("invalid identity map %s: %w", path, err)
+	}
+	return identities, nil
+}
+
+// SetIdentityMap sets the corporate identity of the actor in the custom data of every event, see
+// translator.ActorCustomDataKey, if the actor is in the identity map. Keys are case insensitive.
+func (c *CDEventAdapter) SetIdentityMap(identities IdentityMap) {
+	normalized := make(IdentityMap, len(identities))
+	for key, corporate := range identities {
+		normalized[strings.ToLower(strings.TrimSpace(key))] = corporate
+	}
+	c.identities = normalized
+}
+
+// corporate returns the corporate identity of the actor, looked up by username before email.
+func (m IdentityMap) corporate(actor translator.Identity) (string, bool) {
+	if actor.Username != "" {
+		if corporate, found := m[strings.ToLower(actor.Provider+":"+actor.Username)]; found {
+			return corporate, true
+		}
+	}
+	if actor.Email != "" {
+		if corporate, found := m[strings.ToLower(actor.Email)]; found {
+			return corporate, true
+		}
+	}
+	return "", false
+}
+
+// mapActor sets the corporate identity of the actor of an event. Events without an actor in
+// their custom data, or whose actor is not in the map, are left as they are.
+func (m IdentityMap) mapActor(cdEvent cdevents.CDEvent) error {
+	if cdEvent.GetCustomDataContentType() != "application/json" {
+		return nil
+	}
+	raw, err := cdEvent.GetCustomDataRaw()
+	if err != nil || len(raw) == 0 {
+		return nil
+	}
+
+	var customData map[string]json.RawMessage
+	if json.Unmarshal(raw, &customData) != nil || customData[translator.ActorCustomDataKey] == nil {
+		return nil
+	}
+	var actor translator.Identity
+	if json.Unmarshal(customData[translator.ActorCustomDataKey], &actor) != nil {
+		return nil
+	}
+
+	corporate, found := m.corporate(actor)
+	if !found {
+		return nil
+	}
+	actor.Corporate = corporate
+	if customData[translator.ActorCustomDataKey], err = json.Marshal(actor); err != nil {
+		return err
+	}
+	return cdEvent.SetCustomData("application/json", customData)
+}
@@ -0,0 +1,92 @@
+package adapter
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+func TestIdentityMap(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	identities := IdentityMap{"Gitea:Alice": "E1001", "bob@example.com": "E1002"}
+
+	for _, tc := range []struct {
+		title             string
+		actor             *translator.Identity
+		expectedCorporate string
+	}{
+		{title: "maps actor by username", actor: &translator.Identity{Provider: "gitea", Username: "alice", Email: "alice@example.com"}, expectedCorporate: "E1001"},
+		{title: "maps actor by email", actor: &translator.Identity{Provider: "gitea", Username: "bobby", Email: "bob@example.com"}, expectedCorporate: "E1002"},
+		{title: "keeps actor without match", actor: &translator.Identity{Provider: "gitea", Username: "carol"}},
+		{title: "keeps event without actor"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			var published cloudevents.Event
+			mockPublisher := &MockPublisher{}
+			mockPublisher.On("Publish", mock.Anything).Run(func(args mock.Arguments) {
+				published = args.Get(0).(cloudevents.Event)
+			}).Return(nil)
+
+			cdEvent := newTestCDEvent(t)
+			customData := map[string]interface{}{"kind": "push"}
+			if tc.actor != nil {
+				customData[translator.ActorCustomDataKey] = tc.actor
+			}
+			require.NoError(t, cdEvent.SetCustomData("application/json", customData))
+
+			mockTranslator := &MockCDEventTranslator{}
+			mockTranslator.On("Translate", mock.Anything).Return(cdEvent, nil)
+
+			adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"gitea.push": mockTranslator}))
+			adapter.SetIdentityMap(identities)
+			require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.gitea.push", []byte(`{}`))))
+
+			var event struct {
+				CustomData struct {
+					Kind  string               `json:"kind"`
+					Actor *translator.Identity `json:"actor"`
+				} `json:"customData"`
+			}
+			require.NoError(t, json.Unmarshal(published.Data(), &event))
+			assert.Equal(t, "push", event.CustomData.Kind, "other custom data should be kept")
+			if tc.actor == nil {
+				assert.Nil(t, event.CustomData.Actor)
+				return
+			}
+			require.NotNil(t, event.CustomData.Actor)
+			assert.Equal(t, tc.actor.Username, event.CustomData.Actor.Username)
+			assert.Equal(t, tc.expectedCorporate, event.CustomData.Actor.Corporate)
+		})
+	}
+}
+
+func TestLoadIdentityMap(t *testing.T) {
+
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "identities.json")
+	require.NoError(t, os.WriteFile(valid, []byte(`{"gitea:alice": "E1001"}`), 0o600))
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte(`["gitea:alice"]`), 0o600))
+
+	identities, err := LoadIdentityMap(valid)
+	require.NoError(t, err)
+	assert.Equal(t, IdentityMap{"gitea:alice": "E1001"}, identities)
+
+	_, err = LoadIdentityMap(invalid)
+	assert.ErrorContains(t, err, "invalid identity map")
+
+	_, err = LoadIdentityMap(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,32 @@
+package adapter
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// EventIDNamespace is the UUID namespace of deterministic event ids.
+var EventIDNamespace = uuid.MustParse("3ecb09c8-33ff-4c22-8c94-5682fd2d2d37")
+
+// SetDeterministicIDs makes the id of every event translated from a webhook with a delivery id a
+// UUIDv5 of the provider and the delivery id, instead of a random UUID, so that the events of
+// webhooks redelivered by the provider have the same id and can be deduplicated downstream. The
+// chain id of an event that starts a chain is derived from the delivery id as well, and an event
+// timestamped with the time of translation gets the time the webhook was received instead.
+func (c *CDEventAdapter) SetDeterministicIDs(enabled bool) {
+	c.deterministicIDs = enabled
+}
+
+// deterministicID returns the event id for a delivery of the provider of the webhook subject,
+// e.g. "gitea" for "gitea.push".
+func deterministicID(eventSubject, deliveryID string) string {
+	provider, _, _ := strings.Cut(eventSubject, ".")
+	return uuid.NewSHA1(EventIDNamespace, []byte(provider+"/"+deliveryID)).String()
+}
+
+// deterministicChainID returns the id of the chain started by the event of a delivery, which
+// differs from the id of the event.
+func deterministicChainID(eventSubject, deliveryID string) string {
+	return deterministicID(eventSubject, deliveryID+"/chain")
+}
@@ -0,0 +1,109 @@
+package adapter
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+func TestDeterministicID(t *testing.T) {
+
+	id := deterministicID("gitea.push", "a2b9c3e1-4a2f-4a1d-9c1e-3f1a2b3c4d5e")
+	assert.Equal(t, id, deterministicID("gitea.pull_request", "a2b9c3e1-4a2f-4a1d-9c1e-3f1a2b3c4d5e"), "id should only depend on the provider and delivery")
+	assert.NotEqual(t, id, deterministicID("github.push", "a2b9c3e1-4a2f-4a1d-9c1e-3f1a2b3c4d5e"))
+	assert.NotEqual(t, id, deterministicID("gitea.push", "another-delivery"))
+	assert.Equal(t, "535a1498-ef51-592b-855f-ab204f047c2e", id)
+}
+
+func TestProcessDeterministicIDs(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var published []cloudevents.Event
+	mockPublisher := &MockPublisher{}
+	mockPublisher.On("Publish", mock.Anything).Run(func(args mock.Arguments) {
+		published = append(published, args.Get(0).(cloudevents.Event))
+	}).Return(nil)
+
+	mockTranslator := &MockCDEventTranslator{}
+	for i := 0; i < 3; i++ {
+		mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil).Once()
+	}
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"gitea.push": mockTranslator}))
+	adapter.SetDeterministicIDs(true)
+	adapter.SetLinkStore(NewKVLinkStore(&mockKeyValue{entries: map[string][]byte{}}))
+
+	received := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for _, deliveryID := range []string{"delivery-1", "delivery-1", ""} {
+		msg := newMockJetstreamMsg("webhook.gitea.push", []byte("{}"))
+		msg.timestamp = received
+		if deliveryID != "" {
+			msg.headers = nats.Header{DeliveryIDHeader: []string{deliveryID}}
+		}
+		require.NoError(t, adapter.Process(msg))
+	}
+
+	require.Len(t, published, 3)
+	assert.Equal(t, deterministicID("gitea.push", "delivery-1"), published[0].ID())
+	assert.Equal(t, published[0].ID(), published[1].ID(), "redelivery should have the same id")
+	assert.NotEqual(t, published[0].ID(), published[2].ID(), "webhook without delivery id should get a random id")
+
+	assert.Equal(t, deterministicChainID("gitea.push", "delivery-1"), published[0].Extensions()[ChainIDExtension])
+	assert.Equal(t, published[0].Extensions()[ChainIDExtension], published[1].Extensions()[ChainIDExtension], "redelivery should start the same chain")
+	assert.NotEqual(t, published[0].ID(), published[0].Extensions()[ChainIDExtension])
+
+	assert.Equal(t, received, eventTimestamp(t, published[0]), "translation time should be replaced by the time the webhook was received")
+	assert.Equal(t, eventTimestamp(t, published[0]), eventTimestamp(t, published[1]))
+	assert.True(t, eventTimestamp(t, published[2]).After(received), "webhook without delivery id should keep the translation time")
+}
+
+func TestProcessDeterministicIDsKeepsPayloadTimestamp(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var published []cloudevents.Event
+	mockPublisher := &MockPublisher{}
+	mockPublisher.On("Publish", mock.Anything).Run(func(args mock.Arguments) {
+		published = append(published, args.Get(0).(cloudevents.Event))
+	}).Return(nil)
+
+	committed := time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)
+	event := newTestCDEvent(t)
+	event.SetTimestamp(committed)
+	mockTranslator := &MockCDEventTranslator{}
+	mockTranslator.On("Translate", mock.Anything).Return(event, nil)
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"gitea.push": mockTranslator}))
+	adapter.SetDeterministicIDs(true)
+
+	msg := newMockJetstreamMsg("webhook.gitea.push", []byte("{}"))
+	msg.timestamp = committed.Add(time.Minute)
+	msg.headers = nats.Header{DeliveryIDHeader: []string{"delivery-1"}}
+	require.NoError(t, adapter.Process(msg))
+
+	require.Len(t, published, 1)
+	assert.Equal(t, committed, eventTimestamp(t, published[0]))
+}
+
+// eventTimestamp returns the timestamp in the context of the CDEvent of a published event.
+func eventTimestamp(t *testing.T, event cloudevents.Event) time.Time {
+	var cdEvent struct {
+		Context struct {
+			Timestamp time.Time `json:"timestamp"`
+		} `json:"context"`
+	}
+	require.NoError(t, json.Unmarshal(event.Data(), &cdEvent))
+	return cdEvent.Context.Timestamp
+}
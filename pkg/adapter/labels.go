@@ -0,0 +1,78 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// LabelsCustomDataKey is the key in the custom data of a CDEvent that labels are added under.
+const LabelsCustomDataKey = "labels"
+
+// Labels is static context added to every published event, e.g. the environment, cluster or
+// team of the adapter deployment, so that consumers can tell the events of deployments apart.
+type Labels struct {
+	Values map[string]string
+	// CustomData adds the labels to the custom data of the CDEvent instead of as CloudEvents
+	// extensions.
+	CustomData bool
+}
+
+// Validate checks that the names of labels added as CloudEvents extensions consist of ASCII
+// letters and digits only.
+func (l Labels) Validate() error {
+	if l.CustomData {
+		return nil
+	}
+	event := cloudevents.NewEvent()
+	for name, value := range l.Values {
+		if err := event.Context.SetExtension(name, value); err != nil {
+			return fmt.Errorf("invalid label %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// SetLabels sets labels that are added to every published event.
+func (c *CDEventAdapter) SetLabels(labels Labels) error {
+	if err := labels.Validate(); err != nil {
+		return err
+	}
+	c.labels = &labels
+	return nil
+}
+
+func addLabelsToCustomData(cdEvent cdevents.CDEvent, values map[string]string) error {
+	return addToCustomData(cdEvent, LabelsCustomDataKey, "labels", values)
+}
+
+// addToCustomData adds the value under the key of the custom data of the event, which must be a
+// JSON object if the event has custom data. The name of what is added is used in errors.
+func addToCustomData(cdEvent cdevents.CDEvent, key, name string, value interface{}) error {
+	raw, err := cdEvent.GetCustomDataRaw()
+	if err != nil {
+		return err
+	}
+
+	customData := make(map[string]interface{})
+	if len(raw) > 0 && string(raw) != "null" {
+		if cdEvent.GetCustomDataContentType() != "application/json" {
+			return fmt.Errorf("cannot add %s to custom data with content type %s", name, cdEvent.GetCustomDataContentType())
+		}
+		if err := json.Unmarshal(raw, &customData); err != nil {
+			return fmt.Errorf("cannot add %s to custom data that is not a JSON object: %w", name, err)
+		}
+	}
+	customData[key] = value
+
+	return cdEvent.SetCustomData("application/json", customData)
+}
+
+func addLabelsAsExtensions(event *cloudevents.Event, values map[string]string) {
+	for name, value := range values {
+		event.SetExtension(name, value)
+	}
+}
@@ -0,0 +1,67 @@
+package adapter
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabels(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	values := map[string]string{"environment": "prod", "cluster": "eu1"}
+
+	for _, tc := range []struct {
+		title      string
+		customData bool
+	}{
+		{title: "adds labels as CloudEvents extensions"},
+		{title: "adds labels to custom data", customData: true},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			var published cloudevents.Event
+			mockPublisher := &MockPublisher{}
+			mockPublisher.On("Publish", mock.Anything).Run(func(args mock.Arguments) {
+				published = args.Get(0).(cloudevents.Event)
+			}).Return(nil)
+
+			cde := newTestCDEvent(t)
+			require.NoError(t, cde.SetCustomData("application/json", map[string]string{"foo": "bar"}))
+			mockTranslator := &MockCDEventTranslator{}
+			mockTranslator.On("Translate", mock.Anything).Return(cde, nil)
+
+			adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}))
+			require.NoError(t, adapter.SetLabels(Labels{Values: values, CustomData: tc.customData}))
+
+			require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.test.event", []byte(`{}`))))
+
+			if tc.customData {
+				assert.Empty(t, published.Extensions()["environment"])
+
+				var data struct {
+					CustomData map[string]interface{} `json:"customData"`
+				}
+				require.NoError(t, json.Unmarshal(published.Data(), &data))
+				assert.Equal(t, "bar", data.CustomData["foo"])
+				assert.Equal(t, map[string]interface{}{"environment": "prod", "cluster": "eu1"}, data.CustomData[LabelsCustomDataKey])
+			} else {
+				assert.Equal(t, "prod", published.Extensions()["environment"])
+				assert.Equal(t, "eu1", published.Extensions()["cluster"])
+			}
+		})
+	}
+}
+
+func TestSetLabelsRejectsInvalidExtensionName(t *testing.T) {
+	adapter := NewCDEventAdapter(slog.New(slog.NewTextHandler(io.Discard, nil)), &MockPublisher{}, translator.NewRegistry(nil))
+
+	assert.ErrorContains(t, adapter.SetLabels(Labels{Values: map[string]string{"adapter-instance": "a"}}), "invalid label adapter-instance")
+	assert.NoError(t, adapter.SetLabels(Labels{Values: map[string]string{"adapter-instance": "a"}, CustomData: true}))
+}
@@ -0,0 +1,239 @@
+package adapter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/correlation"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/metrics"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go/jetstream"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// ChainIDExtension is the CloudEvents extension attribute that carries the chain id of an event,
+// so that consumers can follow a chain without decoding the CDEvent.
+const ChainIDExtension = "chainid"
+
+// SelftestExtension is the CloudEvents extension attribute that marks the events of self tests,
+// so that sinks and consumers can leave them out.
+const SelftestExtension = "selftest"
+
+// Link is a published event that later events are linked to.
+type Link struct {
+	EventID string `json:"event_id"`
+	ChainID string `json:"chain_id,omitempty"`
+}
+
+// LinkStore remembers published events by the keys that later events find them by, e.g. the
+// pull request of a change.created event, so that the later events can link to them and continue
+// their chain.
+type LinkStore interface {
+	Get(ctx context.Context, key string) (Link, bool, error)
+	Put(ctx context.Context, key string, link Link) error
+}
+
+// KVLinkStore keeps the event ids in a JetStream key-value bucket, which shares them between
+// replicas. How long events can be linked to is decided by the TTL of the bucket.
+type KVLinkStore struct {
+	kv KeyValue
+}
+
+func NewKVLinkStore(kv KeyValue) *KVLinkStore {
+	return &KVLinkStore{kv: kv}
+}
+
+func (s *KVLinkStore) Get(ctx context.Context, key string) (Link, bool, error) {
+	var link Link
+	entry, err := s.kv.Get(ctx, key)
+	if errors.Is(err, jetstream.ErrKeyNotFound) {
+		return link, false, nil
+	}
+	if err != nil {
+		return link, false, err
+	}
+	if err := json.Unmarshal(entry.Value(), &link); err != nil {
+		return link, false, err
+	}
+	return link, true, nil
+}
+
+func (s *KVLinkStore) Put(ctx context.Context, key string, link Link) error {
+	data, err := json.Marshal(link)
+	if err != nil {
+		return err
+	}
+	_, err = s.kv.Put(ctx, key, data)
+	return err
+}
+
+// linkTimeout bounds every lookup and write of the link store, which must not hold up the
+// translation of the webhook for long.
+const linkTimeout = 2 * time.Second
+
+// rememberKey returns the key an event is remembered by so that later events can link to it, or
+// an empty key if no events follow it: change.created events of pull requests are followed by
+// the change.merged event of the merge, and change.merged events of pushes, whose subject is the
+// commit, by the pipeline runs for the commit.
+func rememberKey(subject, predicate, source, subjectID string) string {
+	switch {
+	case subject == "change" && predicate == "created":
+		return linkKey("change", source, subjectID)
+	case subject == "change" && predicate == "merged":
+		return linkKey("commit", subjectID)
+	}
+	return ""
+}
+
+// followKeys returns the keys of the events that an event may follow, in the order they are
+// looked up, or no keys if it follows no event. A change.merged event follows the change.created
+// event with the same subject, which tells the merge of a pull request apart from a push
+// regardless of the format of subject ids, and so does a change.updated event of a push to the
+// branch of a pull request. A change.merged event of a push that follows no pull request may
+// instead be the push of the merge commit of a pull request, which the merge of the pull request
+// is remembered by. Pipeline runs name the commit they run for in the commit field of their
+// custom data.
+func followKeys(event cdevents.CDEvent) []string {
+	eventType := event.GetType()
+	switch {
+	case eventType.Subject == "change" && eventType.Predicate == "merged":
+		return []string{linkKey("change", event.GetSource(), event.GetSubjectId()), linkKey("commit", event.GetSubjectId())}
+	case eventType.Subject == "change" && eventType.Predicate == "updated":
+		return []string{linkKey("change", event.GetSource(), event.GetSubjectId())}
+	case eventType.Subject == "pipelinerun":
+		var customData struct {
+			Commit string `json:"commit"`
+		}
+		if event.GetCustomDataContentType() != "application/json" || event.GetCustomDataAs(&customData) != nil || customData.Commit == "" {
+			return nil
+		}
+		return []string{linkKey("commit", customData.Commit)}
+	}
+	return nil
+}
+
+// linkKey hashes the parts that identify an event into a key that is valid in a key-value
+// bucket.
+func linkKey(kind string, parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return kind + "." + hex.EncodeToString(hash.Sum(nil))
+}
+
+// link adds a path link from the event that the event follows, if it is in the link store, and
+// continues its chain. An event that follows no event in the store but can be followed itself,
+// e.g. a push or an opened pull request, starts a new chain instead, with the given id or a random
+// one. Failing to look up the event does not fail the translation; the event is published without
+// the link.
+func (c *CDEventAdapter) link(ctx context.Context, event cdevents.CDEvent, newChainID string) {
+	linked, ok := event.(interface {
+		cdevents.CDEventReaderV04
+		cdevents.CDEventWriterV04
+	})
+	if !ok {
+		return
+	}
+
+	for _, key := range followKeys(event) {
+		if from, found := c.lookupLink(ctx, key); found {
+			path := cdevents.NewEmbeddedLinkPath()
+			path.SetFrom(cdevents.EventReference{ContextId: from.EventID})
+			path.SetTags(cdevents.Tags{})
+			linked.SetLinks(append(linked.GetLinks(), path))
+			if linked.GetChainId() == "" && from.ChainID != "" {
+				linked.SetChainId(from.ChainID)
+			}
+			metrics.EventLinks.WithLabelValues("linked").Inc()
+			return
+		}
+	}
+
+	// Chain ids set by the translator are kept.
+	eventType := event.GetType()
+	if linked.GetChainId() == "" && rememberKey(eventType.Subject, eventType.Predicate, event.GetSource(), event.GetSubjectId()) != "" {
+		if newChainID == "" {
+			newChainID = uuid.NewString()
+		}
+		linked.SetChainId(newChainID)
+	}
+}
+
+func (c *CDEventAdapter) lookupLink(ctx context.Context, key string) (Link, bool) {
+	ctx, cancel := context.WithTimeout(ctx, linkTimeout)
+	defer cancel()
+
+	from, found, err := c.links.Get(ctx, key)
+	if err != nil {
+		metrics.EventLinks.WithLabelValues("error").Inc()
+		correlation.Logger(ctx, c.logger).Warn("Failed to look up event to link to", "key", key, "error", err.Error())
+		return from, false
+	}
+	if !found {
+		metrics.EventLinks.WithLabelValues("not_found").Inc()
+	}
+	return from, found
+}
+
+// chainID returns the chain id of the event, if it has one.
+func chainID(event cdevents.CDEvent) string {
+	if chained, ok := event.(cdevents.CDEventReaderV04); ok {
+		return chained.GetChainId()
+	}
+	return ""
+}
+
+// remember stores the id of a published event in the link store, if later events can follow it.
+// The merge of a pull request is also stored by the merge commit in the commit field of its
+// custom data, so that the push of the merge continues the chain of the pull request.
+func (c *CDEventAdapter) remember(ctx context.Context, event *cloudevents.Event) {
+	eventType, err := cdevents.ParseType(event.Type())
+	if err != nil {
+		return
+	}
+	key := rememberKey(eventType.Subject, eventType.Predicate, event.Source(), event.Subject())
+	if key == "" {
+		return
+	}
+	keys := []string{key}
+	if eventType.Subject == "change" && eventType.Predicate == "merged" {
+		if commit := mergeCommit(event); commit != "" && commit != event.Subject() {
+			keys = append(keys, linkKey("commit", commit))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, linkTimeout)
+	defer cancel()
+
+	link := Link{EventID: event.ID()}
+	if chainID, ok := event.Extensions()[ChainIDExtension].(string); ok {
+		link.ChainID = chainID
+	}
+	for _, key := range keys {
+		if err := c.links.Put(ctx, key, link); err != nil {
+			correlation.Logger(ctx, c.logger).Warn("Failed to store event for linking", "key", key, "error", err.Error())
+		}
+	}
+}
+
+// mergeCommit returns the commit field of the custom data of an event, if it has one.
+func mergeCommit(event *cloudevents.Event) string {
+	var data struct {
+		CustomData struct {
+			Commit string `json:"commit"`
+		} `json:"customData"`
+	}
+	if err := event.DataAs(&data); err != nil {
+		return ""
+	}
+	return data.CustomData.Commit
+}
@@ -0,0 +1,278 @@
+package adapter
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+	cdeventsv04 "github.com/cdevents/sdk-go/pkg/api/v04"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+func readTestEvent(t *testing.T, event cloudevents.Event) cdevents.CDEventReaderV04 {
+	cdEvent, err := cdeventsv04.NewFromJsonBytes(event.Data())
+	require.NoError(t, err)
+	return cdEvent
+}
+
+func linkedFrom(t *testing.T, event cloudevents.Event) []string {
+	var from []string
+	for _, link := range readTestEvent(t, event).GetLinks() {
+		require.Equal(t, cdevents.LinkTypePath, link.GetLinkType())
+		from = append(from, link.(cdevents.EmbeddedLinkWithTagsAndSource).GetFrom().ContextId)
+	}
+	return from
+}
+
+func TestLinkPullRequestMerge(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var published []cloudevents.Event
+	mockPublisher := &MockPublisher{}
+	mockPublisher.On("Publish", mock.Anything).Run(func(args mock.Arguments) {
+		published = append(published, args.Get(0).(cloudevents.Event))
+	}).Return(nil)
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{
+		"gitea.pull_request": &translator.GiteaPullRequestTranslator{},
+	}))
+	adapter.SetLinkStore(NewKVLinkStore(&mockKeyValue{entries: map[string][]byte{}}))
+
+	const repository = `"repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}`
+	for _, action := range []string{"opened", "closed"} {
+		payload := []byte(`{"action": "` + action + `", "pull_request": {"id": 3}, ` + repository + `}`)
+		require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.gitea.pull_request", payload)))
+	}
+
+	require.Len(t, published, 2)
+	assert.Empty(t, linkedFrom(t, published[0]))
+	assert.Equal(t, []string{published[0].ID()}, linkedFrom(t, published[1]), "merge should be linked from the opened pull request")
+
+	chainID := readTestEvent(t, published[0]).GetChainId()
+	require.NotEmpty(t, chainID, "opened pull request should start a chain")
+	assert.Equal(t, chainID, published[0].Extensions()[ChainIDExtension])
+	assert.Equal(t, chainID, readTestEvent(t, published[1]).GetChainId(), "merge should continue the chain")
+	assert.Equal(t, chainID, published[1].Extensions()[ChainIDExtension])
+}
+
+func TestLinkPullRequestMergeWithSubjectIDTemplate(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var published []cloudevents.Event
+	mockPublisher := &MockPublisher{}
+	mockPublisher.On("Publish", mock.Anything).Run(func(args mock.Arguments) {
+		published = append(published, args.Get(0).(cloudevents.Event))
+	}).Return(nil)
+
+	config := translator.GiteaConfig{SubjectIDs: translator.SubjectIDTemplates{PullRequest: "{{.FullName}}#{{.Number}}"}}
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{
+		"gitea.pull_request": translator.NewGiteaPullRequestTranslator(config),
+	}))
+	kv := &mockKeyValue{entries: map[string][]byte{}}
+	adapter.SetLinkStore(NewKVLinkStore(kv))
+
+	const repository = `"repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}`
+	for _, action := range []string{"opened", "closed"} {
+		payload := []byte(`{"action": "` + action + `", "number": 7, "pull_request": {"id": 3}, ` + repository + `}`)
+		require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.gitea.pull_request", payload)))
+	}
+
+	require.Len(t, published, 2)
+	assert.Equal(t, "yoloco/project1#7", published[1].Subject())
+	assert.Equal(t, []string{published[0].ID()}, linkedFrom(t, published[1]), "merge should be linked from the opened pull request")
+	assert.Equal(t, readTestEvent(t, published[0]).GetChainId(), readTestEvent(t, published[1]).GetChainId())
+}
+
+func TestLinkMergePushToPullRequest(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var published []cloudevents.Event
+	mockPublisher := &MockPublisher{}
+	mockPublisher.On("Publish", mock.Anything).Run(func(args mock.Arguments) {
+		published = append(published, args.Get(0).(cloudevents.Event))
+	}).Return(nil)
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{
+		"gitea.pull_request": &translator.GiteaPullRequestTranslator{},
+		"gitea.push":         &translator.GiteaPushTranslator{},
+	}))
+	adapter.SetLinkStore(NewKVLinkStore(&mockKeyValue{entries: map[string][]byte{}}))
+
+	const (
+		repository  = `"repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}`
+		mergeCommit = "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2"
+	)
+	for _, action := range []string{"opened", "closed"} {
+		payload := []byte(`{"action": "` + action + `", "pull_request": {"id": 3, "merge_commit_sha": "` + mergeCommit + `"}, ` + repository + `}`)
+		require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.gitea.pull_request", payload)))
+	}
+	push := []byte(`{"ref": "refs/heads/main", "after": "` + mergeCommit + `", "total_commits": 1, "commits": [{"id": "` + mergeCommit + `"}], ` + repository + `}`)
+	require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.gitea.push", push)))
+
+	require.Len(t, published, 3)
+	assert.Equal(t, mergeCommit, published[2].Subject())
+	assert.Equal(t, []string{published[1].ID()}, linkedFrom(t, published[2]), "merge push should be linked from the merge of the pull request")
+	assert.Equal(t, readTestEvent(t, published[0]).GetChainId(), readTestEvent(t, published[2]).GetChainId(), "merge push should continue the chain of the pull request")
+}
+
+func TestLinkNotRememberedWhenPublishFails(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tc := range []struct {
+		title string
+		async bool
+	}{
+		{title: "synchronous publish"},
+		{title: "asynchronous publish", async: true},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			mockTranslator := &MockCDEventTranslator{}
+			mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil)
+
+			var publisher Publisher
+			acks := make(chan error, 1)
+			if tc.async {
+				mockPublisher := &MockAsyncPublisher{acks: acks}
+				mockPublisher.On("PublishAsync", mock.Anything)
+				publisher = mockPublisher
+			} else {
+				mockPublisher := &MockPublisher{}
+				mockPublisher.On("Publish", mock.Anything).Return(fmt.Errorf("stream unavailable"))
+				publisher = mockPublisher
+			}
+
+			kv := &mockKeyValue{entries: map[string][]byte{}}
+			adapter := NewCDEventAdapter(logger, publisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"gitea.push": mockTranslator}))
+			adapter.SetLinkStore(NewKVLinkStore(kv))
+
+			adapter.Process(newMockJetstreamMsg("webhook.gitea.push", []byte("{}")))
+			acks <- fmt.Errorf("stream unavailable")
+			adapter.Wait()
+
+			assert.Empty(t, kv.entries, "event that failed to publish should not be remembered")
+		})
+	}
+}
+
+func TestLinkPipelineRunToPush(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	pipelineRun, err := cdeventsv04.NewPipelineRunQueuedEvent()
+	require.NoError(t, err)
+	pipelineRun.SetSource("ci.example.com")
+	pipelineRun.SetSubjectId("run-1")
+	pipelineRun.SetSubjectPipelineName("build")
+	require.NoError(t, pipelineRun.SetCustomData("application/json", map[string]string{"commit": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2"}))
+
+	pipelineTranslator := &MockCDEventTranslator{}
+	pipelineTranslator.On("Translate", mock.Anything).Return(pipelineRun, nil)
+	pushTranslator := &MockCDEventTranslator{}
+	pushTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil)
+
+	var published []cloudevents.Event
+	mockPublisher := &MockPublisher{}
+	mockPublisher.On("Publish", mock.Anything).Run(func(args mock.Arguments) {
+		published = append(published, args.Get(0).(cloudevents.Event))
+	}).Return(nil)
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{
+		"gitea.push": pushTranslator,
+		"ci.queued":  pipelineTranslator,
+	}))
+	adapter.SetLinkStore(NewKVLinkStore(&mockKeyValue{entries: map[string][]byte{}}))
+
+	require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.ci.queued", []byte("{}"))))
+	require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.gitea.push", []byte("{}"))))
+	require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.ci.queued", []byte("{}"))))
+
+	require.Len(t, published, 3)
+	assert.Empty(t, linkedFrom(t, published[0]), "pipeline run before the push should not be linked")
+	assert.Equal(t, []string{published[1].ID()}, linkedFrom(t, published[2]), "pipeline run should be linked from the push")
+
+	// The SDK makes up a chain id when decoding an event without one.
+	assert.NotContains(t, string(published[0].Data()), "chainId")
+	assert.NotContains(t, published[0].Extensions(), ChainIDExtension)
+	chainID := readTestEvent(t, published[1]).GetChainId()
+	require.NotEmpty(t, chainID, "push should start a chain")
+	assert.Equal(t, chainID, readTestEvent(t, published[2]).GetChainId(), "pipeline run should continue the chain of the push")
+}
+
+func TestLinkKeepsTranslatorChainID(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	push := newTestCDEvent(t).(*cdeventsv04.ChangeMergedEvent)
+	push.SetChainId("chain-from-translator")
+	mockTranslator := &MockCDEventTranslator{}
+	mockTranslator.On("Translate", mock.Anything).Return(push, nil)
+
+	var published []cloudevents.Event
+	mockPublisher := &MockPublisher{}
+	mockPublisher.On("Publish", mock.Anything).Run(func(args mock.Arguments) {
+		published = append(published, args.Get(0).(cloudevents.Event))
+	}).Return(nil)
+
+	kv := &mockKeyValue{entries: map[string][]byte{}}
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"gitea.push": mockTranslator}))
+	adapter.SetLinkStore(NewKVLinkStore(kv))
+
+	require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.gitea.push", []byte("{}"))))
+
+	require.Len(t, published, 1)
+	assert.Equal(t, "chain-from-translator", published[0].Extensions()[ChainIDExtension])
+
+	link, found, err := NewKVLinkStore(kv).Get(context.Background(), linkKey("commit", push.GetSubjectId()))
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, Link{EventID: published[0].ID(), ChainID: "chain-from-translator"}, link)
+}
+
+func TestFollowKey(t *testing.T) {
+
+	merged, err := cdeventsv04.NewChangeMergedEvent()
+	require.NoError(t, err)
+	merged.SetSource("git.example.com")
+	merged.SetSubjectId("pr-3")
+
+	updated, err := cdeventsv04.NewChangeUpdatedEvent()
+	require.NoError(t, err)
+	updated.SetSource("git.example.com")
+	updated.SetSubjectId("pr-3")
+
+	push := newTestCDEvent(t)
+
+	testCases := map[string]struct {
+		event    cdevents.CDEvent
+		expected []string
+	}{
+		"pull request merge": {event: merged, expected: []string{
+			rememberKey("change", "created", "git.example.com", "pr-3"),
+			rememberKey("change", "merged", "git.example.com", "pr-3"),
+		}},
+		"pull request update": {event: updated, expected: []string{rememberKey("change", "created", "git.example.com", "pr-3")}},
+		"push": {event: push, expected: []string{
+			rememberKey("change", "created", "git.example.com", push.GetSubjectId()),
+			rememberKey("change", "merged", "git.example.com", push.GetSubjectId()),
+		}},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, followKeys(tc.event))
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package adapter
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/glob"
+)
+
+// globMap maps glob patterns of names to values. The longest matching pattern is used, so that
+// specific patterns take precedence over general ones.
+type globMap struct {
+	values   map[string]string
+	patterns []string
+}
+
+// newGlobMap checks the patterns of the values, which are named kind in errors.
+func newGlobMap(kind string, values map[string]string) (globMap, error) {
+	m := globMap{values: values}
+	for pattern := range values {
+		m.patterns = append(m.patterns, pattern)
+	}
+	sort.Slice(m.patterns, func(i, j int) bool {
+		a, b := m.patterns[i], m.patterns[j]
+		if len(a) != len(b) {
+			return len(a) > len(b)
+		}
+		return a < b
+	})
+	if err := glob.Validate(m.patterns); err != nil {
+		return m, fmt.Errorf("%s: %w", kind, err)
+	}
+	return m, nil
+}
+
+// lookup returns the value of the longest pattern that matches the name.
+func (m globMap) lookup(name string) (string, bool) {
+	for _, pattern := range m.patterns {
+		if glob.Match(pattern, name) {
+			return m.values[pattern], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,56 @@
+package adapter
+
+import (
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// ProvenanceCustomDataKey is the key in the custom data of a CDEvent that the provenance of the
+// event is added under.
+const ProvenanceCustomDataKey = "provenance"
+
+// Provenance identifies the webhook delivery an event was translated from, so that consumers and
+// auditors can trace the event back to it, e.g. in the archive stream.
+type Provenance struct {
+	Provider       string `json:"provider"`
+	DeliveryID     string `json:"delivery_id,omitempty"`
+	WebhookSubject string `json:"webhook_subject,omitempty"`
+	Stream         string `json:"stream,omitempty"`
+	StreamSequence uint64 `json:"stream_sequence,omitempty"`
+	AdapterVersion string `json:"adapter_version,omitempty"`
+	// ReceivedAt is when the webhook was stored in the webhook stream. It is not added to events.
+	ReceivedAt time.Time `json:"-"`
+}
+
+// SetProvenance adds the provenance of every event to its custom data, with the version of the
+// adapter that translated it.
+func (c *CDEventAdapter) SetProvenance(adapterVersion string) {
+	c.provenance = &adapterVersion
+}
+
+func newProvenance(msg JetstreamMsg, metadata *jetstream.MsgMetadata) *Provenance {
+	provenance := &Provenance{
+		DeliveryID:     msg.Headers().Get(DeliveryIDHeader),
+		WebhookSubject: msg.Subject(),
+	}
+	if metadata != nil {
+		provenance.Stream = metadata.Stream
+		provenance.StreamSequence = metadata.Sequence.Stream
+		provenance.ReceivedAt = metadata.Timestamp
+	}
+	return provenance
+}
+
+// provenanceOf completes the provenance of an event translated from a webhook of the event
+// subject. Simulated webhooks have no delivery.
+func (c *CDEventAdapter) provenanceOf(eventSubject string, delivery *Provenance) Provenance {
+	var provenance Provenance
+	if delivery != nil {
+		provenance = *delivery
+	}
+	provenance.Provider, _, _ = strings.Cut(eventSubject, ".")
+	provenance.AdapterVersion = *c.provenance
+	return provenance
+}
@@ -0,0 +1,75 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+func TestProvenance(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var published cloudevents.Event
+	mockPublisher := &MockPublisher{}
+	mockPublisher.On("Publish", mock.Anything).Run(func(args mock.Arguments) {
+		published = args.Get(0).(cloudevents.Event)
+	}).Return(nil)
+
+	cde := newTestCDEvent(t)
+	require.NoError(t, cde.SetCustomData("application/json", map[string]string{"foo": "bar"}))
+	mockTranslator := &MockCDEventTranslator{}
+	mockTranslator.On("Translate", mock.Anything).Return(cde, nil)
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"gitea.push": mockTranslator}))
+	adapter.SetProvenance("1.2.3")
+
+	msg := newMockJetstreamMsg("webhook.gitea.push", []byte(`{}`))
+	msg.headers = nats.Header{DeliveryIDHeader: []string{"delivery-1"}}
+	msg.streamSeq = 42
+	require.NoError(t, adapter.Process(msg))
+
+	var data struct {
+		CustomData map[string]interface{} `json:"customData"`
+	}
+	require.NoError(t, json.Unmarshal(published.Data(), &data))
+	assert.Equal(t, "bar", data.CustomData["foo"])
+	assert.Equal(t, map[string]interface{}{
+		"provider":        "gitea",
+		"delivery_id":     "delivery-1",
+		"webhook_subject": "webhook.gitea.push",
+		"stream_sequence": float64(42),
+		"adapter_version": "1.2.3",
+	}, data.CustomData[ProvenanceCustomDataKey])
+}
+
+func TestSimulateProvenance(t *testing.T) {
+
+	cde := newTestCDEvent(t)
+	mockTranslator := &MockCDEventTranslator{}
+	mockTranslator.On("Translate", mock.Anything).Return(cde, nil)
+
+	adapter := NewCDEventAdapter(slog.New(slog.NewTextHandler(io.Discard, nil)), &MockPublisher{}, translator.NewRegistry(map[string]translator.CDEventTranslator{"gitea.push": mockTranslator}))
+	adapter.SetProvenance("1.2.3")
+
+	simulation, err := adapter.Simulate(context.Background(), "gitea.push", []byte(`{}`), false)
+	require.NoError(t, err)
+	require.NotNil(t, simulation.Event)
+
+	var data struct {
+		CustomData map[string]interface{} `json:"customData"`
+	}
+	require.NoError(t, json.Unmarshal(simulation.Event.Data(), &data))
+	assert.Equal(t, map[string]interface{}{"provider": "gitea", "adapter_version": "1.2.3"}, data.CustomData[ProvenanceCustomDataKey], "simulated webhooks should have no delivery")
+}
@@ -0,0 +1,129 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/correlation"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/metrics"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// rawEmitTimeout bounds the wait for the acknowledgement of an emitted raw webhook event.
+const rawEmitTimeout = 5 * time.Second
+
+// RawWebhookEventType is the type of the CloudEvents emitted for webhooks of supported providers
+// without a translator for their event type. They are not CDEvents.
+const RawWebhookEventType = "cdevents-adapter.webhook.observed"
+
+// RawWebhookEmitter emits the CloudEvent of a webhook that could not be translated.
+type RawWebhookEmitter interface {
+	Emit(ctx context.Context, eventSubject string, event *cloudevents.Event) error
+}
+
+// JetStreamPublisher publishes messages to JetStream and waits for the acknowledgement.
+type JetStreamPublisher interface {
+	PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+}
+
+// JetStreamRawWebhookEmitter publishes raw webhook events in structured mode on a side subject,
+// followed by the webhook subject, e.g. "cdevents-adapter.raw.gitea.issues", which must be
+// captured by the stream the emitter expects. The event id is the message id, so that an event
+// emitted again for a redelivered webhook is deduplicated by the stream.
+type JetStreamRawWebhookEmitter struct {
+	js      JetStreamPublisher
+	subject string
+	stream  string
+}
+
+func NewJetStreamRawWebhookEmitter(js JetStreamPublisher, subject, stream string) *JetStreamRawWebhookEmitter {
+	return &JetStreamRawWebhookEmitter{js: js, subject: subject, stream: stream}
+}
+
+func (e *JetStreamRawWebhookEmitter) Emit(ctx context.Context, eventSubject string, event *cloudevents.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(e.subject + "." + eventSubject)
+	msg.Data = data
+	msg.Header.Set("Content-Type", cloudevents.ApplicationCloudEventsJSON)
+
+	ctx, cancel := context.WithTimeout(ctx, rawEmitTimeout)
+	defer cancel()
+
+	// The stream rejects the event unless it is the one that captures the subject. A duplicate
+	// was already emitted for an earlier delivery of the webhook and is stored.
+	_, err = e.js.PublishMsg(ctx, msg, jetstream.WithMsgID(event.ID()), jetstream.WithExpectStream(e.stream))
+	return err
+}
+
+// SetRawWebhookEmitter makes webhooks of providers that have a translator for some event type,
+// but not for the event type of the webhook, be emitted as raw webhook events instead of failing,
+// so that they are not dropped unnoticed while a translator is missing.
+func (c *CDEventAdapter) SetRawWebhookEmitter(emitter RawWebhookEmitter) {
+	c.raw = emitter
+}
+
+// supportedProvider reports whether there is a translator for any event type of the provider of
+// the webhook subject.
+func (c *CDEventAdapter) supportedProvider(eventSubject string) bool {
+	provider, _, _ := strings.Cut(eventSubject, ".")
+	for _, subject := range c.translators.Subjects() {
+		if strings.HasPrefix(subject, provider+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CDEventAdapter) emitRaw(ctx context.Context, msg JetstreamMsg, eventSubject string) error {
+	event := newRawWebhookEvent(eventSubject, msg.Data(), msg.Headers())
+	if c.deterministicIDs {
+		if deliveryID := msg.Headers().Get(DeliveryIDHeader); deliveryID != "" {
+			event.SetID(deterministicID(eventSubject, deliveryID))
+		}
+	}
+
+	if err := c.raw.Emit(ctx, eventSubject, event); err != nil {
+		return fmt.Errorf("failed to emit raw webhook event: %w", err)
+	}
+
+	metrics.RawWebhooks.WithLabelValues(eventSubject).Inc()
+	correlation.Logger(ctx, c.logger).Info("Emitted raw webhook event for webhook without translator", "subject", msg.Subject(), "id", event.ID())
+	return nil
+}
+
+// newRawWebhookEvent wraps a webhook payload in a CloudEvent, as JSON if the payload is JSON.
+func newRawWebhookEvent(eventSubject string, data []byte, headers nats.Header) *cloudevents.Event {
+	provider, _, _ := strings.Cut(eventSubject, ".")
+
+	event := cloudevents.NewEvent()
+	event.SetID(uuid.NewString())
+	event.SetType(RawWebhookEventType)
+	event.SetSource("/webhooks/" + provider)
+	event.SetSubject(eventSubject)
+	event.SetTime(time.Now().UTC())
+	if json.Valid(data) {
+		event.SetData(cloudevents.ApplicationJSON, data)
+	} else {
+		event.SetData("application/octet-stream", data)
+	}
+
+	if deliveryID := headers.Get(DeliveryIDHeader); deliveryID != "" {
+		event.SetExtension("deliveryid", deliveryID)
+	}
+	if repository := headers.Get(RepositoryHeader); repository != "" {
+		event.SetExtension(RepositoryExtension, repository)
+	}
+	return &event
+}
@@ -0,0 +1,78 @@
+package adapter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+type mockJetStreamPublisher struct {
+	published []*nats.Msg
+	err       error
+}
+
+func (m *mockJetStreamPublisher) PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.published = append(m.published, msg)
+	return &jetstream.PubAck{Stream: "cdevents-adapter-raw"}, nil
+}
+
+func TestProcessEmitsRawWebhooks(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mockPublisher := &MockPublisher{}
+	nc := &mockJetStreamPublisher{}
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{
+		"gitea.push": &MockCDEventTranslator{},
+	}))
+	adapter.SetRawWebhookEmitter(NewJetStreamRawWebhookEmitter(nc, "cdevents-adapter.raw", "cdevents-adapter-raw"))
+
+	msg := newMockJetstreamMsg("webhook.gitea.issues", []byte(`{"action": "opened"}`))
+	msg.headers = nats.Header{DeliveryIDHeader: []string{"delivery-1"}, RepositoryHeader: []string{"yoloco/project1"}}
+	require.NoError(t, adapter.Process(msg), "webhook of supported provider should be emitted raw")
+	mockPublisher.AssertNotCalled(t, "Publish", mock.Anything)
+
+	require.Len(t, nc.published, 1)
+	assert.Equal(t, "cdevents-adapter.raw.gitea.issues", nc.published[0].Subject)
+
+	var event cloudevents.Event
+	require.NoError(t, json.Unmarshal(nc.published[0].Data, &event))
+	assert.Equal(t, RawWebhookEventType, event.Type())
+	assert.Equal(t, "/webhooks/gitea", event.Source())
+	assert.Equal(t, "gitea.issues", event.Subject())
+	assert.Equal(t, "delivery-1", event.Extensions()["deliveryid"])
+	assert.Equal(t, "yoloco/project1", event.Extensions()[RepositoryExtension])
+	assert.JSONEq(t, `{"action": "opened"}`, string(event.Data()))
+
+	err := adapter.Process(newMockJetstreamMsg("webhook.github.push", []byte("{}")))
+	assert.ErrorIs(t, err, ErrNoTranslator, "webhook of unsupported provider should fail")
+	assert.Len(t, nc.published, 1)
+
+	nc.err = jetstream.ErrNoStreamResponse
+	err = adapter.Process(newMockJetstreamMsg("webhook.gitea.issues", []byte("{}")))
+	assert.ErrorIs(t, err, jetstream.ErrNoStreamResponse, "webhook should fail when the raw webhook event is not stored")
+}
+
+func TestNewRawWebhookEventWithBinaryPayload(t *testing.T) {
+
+	event := newRawWebhookEvent("gitea.issues", []byte("payload=not+json"), nil)
+	assert.Equal(t, "application/octet-stream", event.DataContentType())
+	assert.Equal(t, []byte("payload=not+json"), event.Data())
+	assert.NoError(t, event.Validate())
+}
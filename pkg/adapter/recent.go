@@ -0,0 +1,108 @@
+package adapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+	"unicode/utf8"
+)
+
+type RecentFailure struct {
+	FailedEvent
+	Payload   string `json:"payload,omitempty"`
+	Truncated bool   `json:"truncated,omitempty"`
+}
+
+// PayloadRedactor removes sensitive data from a decoded payload in place.
+type PayloadRedactor interface {
+	Redact(doc interface{})
+}
+
+// RecentFailures keeps the most recent failed messages, with payloads redacted and truncated to a
+// maximum number of bytes, in a fixed size ring buffer.
+type RecentFailures struct {
+	mu         sync.Mutex
+	failures   []RecentFailure
+	next       int
+	full       bool
+	maxPayload int
+	redactor   PayloadRedactor
+}
+
+func NewRecentFailures(size int, maxPayload int) *RecentFailures {
+	return &RecentFailures{
+		failures:   make([]RecentFailure, size),
+		maxPayload: maxPayload,
+	}
+}
+
+// SetRedactor sets a redactor that is applied to payloads before they are buffered. Payloads that
+// are not JSON are redacted as a single string value. Must be called before the first failure is
+// reported.
+func (r *RecentFailures) SetRedactor(redactor PayloadRedactor) {
+	r.redactor = redactor
+}
+
+func (r *RecentFailures) Report(failed FailedEvent) error {
+	recent := RecentFailure{FailedEvent: failed, Payload: r.redact(failed.Payload)}
+	recent.FailedEvent.Payload = nil
+	if len(recent.Payload) > r.maxPayload {
+		payload := recent.Payload[:r.maxPayload]
+		for len(payload) > 0 && !utf8.ValidString(payload) {
+			payload = payload[:len(payload)-1]
+		}
+		recent.Payload = payload
+		recent.Truncated = true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.failures[r.next] = recent
+	r.next = (r.next + 1) % len(r.failures)
+	if r.next == 0 {
+		r.full = true
+	}
+
+	return nil
+}
+
+// Recent returns the buffered failures, most recent first.
+func (r *RecentFailures) Recent() []RecentFailure {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := r.next
+	if r.full {
+		count = len(r.failures)
+	}
+
+	recent := make([]RecentFailure, 0, count)
+	for i := 1; i <= count; i++ {
+		recent = append(recent, r.failures[(r.next-i+len(r.failures))%len(r.failures)])
+	}
+	return recent
+}
+
+func (r *RecentFailures) redact(payload []byte) string {
+	if r.redactor == nil || len(payload) == 0 {
+		return string(payload)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil || decoder.More() {
+		wrapped := []interface{}{string(payload)}
+		r.redactor.Redact(wrapped)
+		return wrapped[0].(string)
+	}
+
+	r.redactor.Redact(doc)
+	redacted, err := json.Marshal(doc)
+	if err != nil {
+		return ""
+	}
+	return string(redacted)
+}
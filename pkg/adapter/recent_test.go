@@ -0,0 +1,84 @@
+package adapter
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/redact"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecentFailures(t *testing.T) {
+
+	for _, tc := range []struct {
+		title            string
+		size             int
+		maxPayload       int
+		payloads         []string
+		expectedPayloads []string
+		expectTruncated  []bool
+	}{
+		{
+			title:            "returns failures most recent first",
+			size:             3,
+			maxPayload:       100,
+			payloads:         []string{"a", "b"},
+			expectedPayloads: []string{"b", "a"},
+			expectTruncated:  []bool{false, false},
+		},
+		{
+			title:            "overwrites oldest failures",
+			size:             3,
+			maxPayload:       100,
+			payloads:         []string{"a", "b", "c", "d", "e"},
+			expectedPayloads: []string{"e", "d", "c"},
+			expectTruncated:  []bool{false, false, false},
+		},
+		{
+			title:            "truncates payloads",
+			size:             2,
+			maxPayload:       4,
+			payloads:         []string{`{"a":1}`, "åäö"},
+			expectedPayloads: []string{"åä", `{"a"`},
+			expectTruncated:  []bool{true, true},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			recent := NewRecentFailures(tc.size, tc.maxPayload)
+
+			for i, payload := range tc.payloads {
+				recent.Report(FailedEvent{Reason: fmt.Sprintf("failure %d", i), Payload: []byte(payload)})
+			}
+
+			var payloads []string
+			var truncated []bool
+			for _, failure := range recent.Recent() {
+				payloads = append(payloads, failure.Payload)
+				truncated = append(truncated, failure.Truncated)
+			}
+
+			assert.Equal(t, tc.expectedPayloads, payloads)
+			assert.Equal(t, tc.expectTruncated, truncated)
+		})
+	}
+}
+
+func TestRecentFailuresRedactsPayloads(t *testing.T) {
+
+	redactor, err := redact.New(redact.Config{Fields: []string{"$.pusher.email"}, Patterns: []string{`token=\w+`}, Replacement: "[REDACTED]"})
+	require.NoError(t, err)
+
+	recent := NewRecentFailures(3, 100)
+	recent.SetRedactor(redactor)
+
+	recent.Report(FailedEvent{Reason: "json", Payload: []byte(`{"pusher":{"email":"a@example.com"},"id":9007199254740993}`)})
+	recent.Report(FailedEvent{Reason: "text", Payload: []byte(`url?token=abc`)})
+
+	failures := recent.Recent()
+	require.Len(t, failures, 2)
+	assert.Equal(t, `url?[REDACTED]`, failures[0].Payload)
+	assert.Equal(t, `{"id":9007199254740993,"pusher":{"email":"[REDACTED]"}}`, failures[1].Payload)
+	assert.Nil(t, failures[1].FailedEvent.Payload)
+}
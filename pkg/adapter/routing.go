@@ -0,0 +1,115 @@
+package adapter
+
+import (
+	"encoding/json"
+	"strings"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+	"github.com/nats-io/nats.go"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// Names of the CloudEvents extensions with routing metadata.
+const (
+	TenantExtension      = "tenant"
+	ProviderExtension    = "provider"
+	RepositoryExtension  = "repository"
+	EnvironmentExtension = "environment"
+)
+
+// RoutingExtensions configures CloudEvents extensions with routing metadata, so that consumers
+// can filter events on attributes without parsing the CDEvent. Extensions without a value are
+// left out.
+type RoutingExtensions struct {
+	// Provider adds the provider of the webhook, e.g. "gitea" for "gitea.push".
+	Provider bool `envconfig:"PROVIDER" default:"false"`
+	// Repository adds the full name of the repository the webhook was sent for.
+	Repository bool `envconfig:"REPOSITORY" default:"false"`
+	// Tenants maps glob patterns of repository full names to tenants, e.g. "payments/*:payments".
+	// The longest matching pattern is used, so that specific patterns take precedence.
+	Tenants map[string]string `envconfig:"TENANTS"`
+	// Tenant is the tenant of repositories that do not match any of the Tenants.
+	Tenant string `envconfig:"TENANT"`
+	// Environment is the environment of the adapter deployment, e.g. "production".
+	Environment string `envconfig:"ENVIRONMENT"`
+
+	tenants globMap
+}
+
+// Enabled reports whether any extension is configured.
+func (r RoutingExtensions) Enabled() bool {
+	return r.Provider || r.Repository || len(r.Tenants) > 0 || r.Tenant != "" || r.Environment != ""
+}
+
+// Validate checks the tenant patterns.
+func (r RoutingExtensions) Validate() error {
+	_, err := newGlobMap("tenant", r.Tenants)
+	return err
+}
+
+// SetRoutingExtensions sets the routing metadata added as extensions to every published event.
+func (c *CDEventAdapter) SetRoutingExtensions(extensions RoutingExtensions) error {
+	tenants, err := newGlobMap("tenant", extensions.Tenants)
+	if err != nil {
+		return err
+	}
+	extensions.tenants = tenants
+	c.routing = &extensions
+	return nil
+}
+
+func (r RoutingExtensions) tenant(repository string) string {
+	if repository != "" {
+		if tenant, found := r.tenants.lookup(repository); found {
+			return tenant
+		}
+	}
+	return r.Tenant
+}
+
+// addRoutingExtensions adds the extensions for an event translated from a webhook of the event
+// subject. The repository is taken from the webhook headers, or from the subject content of the
+// event for webhooks without one.
+func (r RoutingExtensions) addRoutingExtensions(event *cloudevents.Event, cdEvent cdevents.CDEvent, eventSubject string, headers nats.Header) {
+	repository := headers.Get(RepositoryHeader)
+	if repository == "" && (r.Repository || len(r.Tenants) > 0) {
+		repository = subjectRepository(cdEvent)
+	}
+
+	if r.Provider {
+		provider, _, _ := strings.Cut(eventSubject, ".")
+		event.SetExtension(ProviderExtension, provider)
+	}
+	if r.Repository && repository != "" {
+		event.SetExtension(RepositoryExtension, repository)
+	}
+	if tenant := r.tenant(repository); tenant != "" {
+		event.SetExtension(TenantExtension, tenant)
+	}
+	if r.Environment != "" {
+		event.SetExtension(EnvironmentExtension, r.Environment)
+	}
+}
+
+// subjectRepository returns the id of the repository in the subject content of an event, if it
+// has one.
+func subjectRepository(cdEvent cdevents.CDEvent) string {
+	data, err := json.Marshal(cdEvent)
+	if err != nil {
+		return ""
+	}
+	var event struct {
+		Subject struct {
+			Content struct {
+				Repository struct {
+					ID string `json:"id"`
+				} `json:"repository"`
+			} `json:"content"`
+		} `json:"subject"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil {
+		return ""
+	}
+	return event.Subject.Content.Repository.ID
+}
@@ -0,0 +1,94 @@
+package adapter
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+func TestRoutingExtensions(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tc := range []struct {
+		title      string
+		extensions RoutingExtensions
+		headers    nats.Header
+		expected   map[string]interface{}
+	}{
+		{
+			title:      "adds provider and repository from headers",
+			extensions: RoutingExtensions{Provider: true, Repository: true},
+			headers:    nats.Header{RepositoryHeader: []string{"payments/api"}},
+			expected:   map[string]interface{}{"provider": "gitea", "repository": "payments/api"},
+		},
+		{
+			title:      "adds repository from event without header",
+			extensions: RoutingExtensions{Repository: true},
+			expected:   map[string]interface{}{"repository": "yoloco/project1"},
+		},
+		{
+			title:      "maps repository to tenant",
+			extensions: RoutingExtensions{Tenants: map[string]string{"payments/*": "payments", "*/*": "other"}, Tenant: "default"},
+			headers:    nats.Header{RepositoryHeader: []string{"payments/api"}},
+			expected:   map[string]interface{}{"tenant": "payments"},
+		},
+		{
+			title:      "uses default tenant without matching pattern",
+			extensions: RoutingExtensions{Tenants: map[string]string{"payments/*": "payments"}, Tenant: "default", Environment: "production"},
+			expected:   map[string]interface{}{"tenant": "default", "environment": "production"},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			var published cloudevents.Event
+			mockPublisher := &MockPublisher{}
+			mockPublisher.On("Publish", mock.Anything).Run(func(args mock.Arguments) {
+				published = args.Get(0).(cloudevents.Event)
+			}).Return(nil)
+
+			mockTranslator := &MockCDEventTranslator{}
+			mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil)
+
+			adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"gitea.push": mockTranslator}))
+			require.NoError(t, adapter.SetRoutingExtensions(tc.extensions))
+
+			msg := newMockJetstreamMsg("webhook.gitea.push", []byte("{}"))
+			msg.headers = tc.headers
+			require.NoError(t, adapter.Process(msg))
+
+			assert.Equal(t, tc.expected, published.Extensions())
+		})
+	}
+}
+
+func TestRoutingExtensionsValidate(t *testing.T) {
+
+	assert.NoError(t, RoutingExtensions{Tenants: map[string]string{"payments/*": "payments"}}.Validate())
+	assert.ErrorContains(t, RoutingExtensions{Tenants: map[string]string{"payments/[": "payments"}}.Validate(), "tenant: invalid pattern payments/[")
+}
+
+func TestSimulateRoutingExtensions(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mockTranslator := &MockCDEventTranslator{}
+	mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil)
+
+	adapter := NewCDEventAdapter(logger, &MockPublisher{}, translator.NewRegistry(map[string]translator.CDEventTranslator{"gitea.push": mockTranslator}))
+	require.NoError(t, adapter.SetRoutingExtensions(RoutingExtensions{Provider: true, Repository: true}))
+
+	simulation, err := adapter.Simulate(context.Background(), "gitea.push", []byte("{}"), false)
+	require.NoError(t, err)
+	require.NotNil(t, simulation.Event)
+	assert.Equal(t, map[string]interface{}{"provider": "gitea", "repository": "yoloco/project1"}, simulation.Event.Extensions())
+}
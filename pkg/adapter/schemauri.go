@@ -0,0 +1,40 @@
+package adapter
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+)
+
+// SetSchemaURIs sets the schemaUri of the events translated from webhooks of a subject, e.g.
+// "jenkins.build", that the translator did not set one for. The schemas must be loaded, since
+// events are validated against them before they are published.
+func (c *CDEventAdapter) SetSchemaURIs(uris map[string]string) error {
+	subjects := make([]string, 0, len(uris))
+	for subject := range uris {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+
+	for _, subject := range subjects {
+		if !translator.SchemaLoaded(uris[subject]) {
+			return fmt.Errorf("%s: schema %s is not loaded", subject, uris[subject])
+		}
+	}
+	c.schemaURIs = uris
+	return nil
+}
+
+// setSchemaURI sets the schemaUri mapped to the webhook subject, unless the event has one.
+func (c *CDEventAdapter) setSchemaURI(eventSubject string, event cdevents.CDEvent) {
+	uri, mapped := c.schemaURIs[eventSubject]
+	if !mapped {
+		return
+	}
+	if v04, ok := event.(cdevents.CDEventV04); ok && v04.GetSchemaUri() == "" {
+		v04.SetSchemaUri(uri)
+	}
+}
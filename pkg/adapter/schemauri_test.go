@@ -0,0 +1,61 @@
+package adapter
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+func TestSchemaURIBySubject(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	const schemaURI = "https://schemas.example.com/adapter-test.json"
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "adapter-test.json"), []byte(`{"$id": "`+schemaURI+`", "type": "object"}`), 0o644))
+	require.NoError(t, translator.LoadSchemaDir(dir))
+
+	var published []cloudevents.Event
+	mockPublisher := &MockPublisher{}
+	mockPublisher.On("Publish", mock.Anything).Run(func(args mock.Arguments) {
+		published = append(published, args.Get(0).(cloudevents.Event))
+	}).Return(nil)
+
+	mockTranslator := &MockCDEventTranslator{}
+	for i := 0; i < 2; i++ {
+		mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil).Once()
+	}
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{
+		"jenkins.build": mockTranslator,
+		"other.build":   mockTranslator,
+	}))
+	assert.EqualError(t, adapter.SetSchemaURIs(map[string]string{"jenkins.build": "https://schemas.example.com/unknown.json"}),
+		"jenkins.build: schema https://schemas.example.com/unknown.json is not loaded")
+	require.NoError(t, adapter.SetSchemaURIs(map[string]string{"jenkins.build": schemaURI}))
+
+	require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.jenkins.build", []byte("{}"))))
+	require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.other.build", []byte("{}"))))
+
+	require.Len(t, published, 2)
+	for i, expected := range []string{schemaURI, ""} {
+		var data struct {
+			Context struct {
+				SchemaURI string `json:"schemaUri"`
+			} `json:"context"`
+		}
+		require.NoError(t, json.Unmarshal(published[i].Data(), &data))
+		assert.Equal(t, expected, data.Context.SchemaURI)
+	}
+}
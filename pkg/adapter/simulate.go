@@ -0,0 +1,65 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// Simulation is the outcome of simulating a webhook.
+type Simulation struct {
+	Subject    string               `json:"subject"`
+	Event      *cloudevents.Event   `json:"event,omitempty"`
+	Additional []*cloudevents.Event `json:"additional,omitempty"`
+	Skipped    string               `json:"skipped,omitempty"`
+	Published  bool                 `json:"published"`
+}
+
+// Simulate translates a webhook payload for a subject, e.g. "gitea.push", the way a webhook
+// message on that subject would be, with the filters and labels of the adapter. The event is
+// only published if publish is true. Simulated webhooks are not counted, audited or reported.
+func (c *CDEventAdapter) Simulate(ctx context.Context, subject string, data []byte, publish bool) (Simulation, error) {
+	simulation := Simulation{Subject: subject}
+
+	eventTranslator, exists := c.translators.Lookup(subject)
+	if !exists {
+		return simulation, fmt.Errorf("%w: %s", ErrNoTranslator, subject)
+	}
+
+	if c.isDisabled(subject) || c.autoDisabled(subject) {
+		simulation.Skipped = "translator is disabled"
+		return simulation, nil
+	}
+
+	event, additional, err := c.translate(ctx, subject, subject, eventTranslator, data, nil, nil)
+	if errors.Is(err, translator.ErrSkipped) {
+		simulation.Skipped = strings.TrimPrefix(err.Error(), translator.ErrSkipped.Error()+": ")
+		return simulation, nil
+	}
+	if err != nil {
+		return simulation, err
+	}
+	simulation.Event = event
+	simulation.Additional = additional
+
+	if !publish {
+		return simulation, nil
+	}
+
+	// The published events are copies, so that the trace context injected when publishing is not
+	// part of the returned events. Additional events are published first, as for webhook messages.
+	for _, event := range append(additional, event) {
+		published := event.Clone()
+		if err := c.publishAndWait(ctx, &published); err != nil {
+			return simulation, fmt.Errorf("failed to publish event: %w", err)
+		}
+	}
+	simulation.Published = true
+
+	return simulation, nil
+}
@@ -0,0 +1,71 @@
+package adapter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSimulate(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tc := range []struct {
+		title             string
+		subject           string
+		publish           bool
+		translateErr      error
+		publishErr        error
+		expectedErr       error
+		expectedEvent     bool
+		expectedSkipped   string
+		expectedPublished bool
+	}{
+		{title: "translates without publishing", subject: "test.event", expectedEvent: true},
+		{title: "publishes when asked", subject: "test.event", publish: true, expectedEvent: true, expectedPublished: true},
+		{title: "returns reason for skipped payload", subject: "test.event", translateErr: translator.Skip("not a branch"), expectedSkipped: "not a branch"},
+		{title: "fails for unknown subject", subject: "test.unknown", expectedErr: ErrNoTranslator},
+		{title: "returns event when publishing fails", subject: "test.event", publish: true, publishErr: errors.New("unavailable"), expectedEvent: true},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			mockPublisher := &MockPublisher{}
+			mockPublisher.On("Publish", mock.Anything).Return(tc.publishErr)
+
+			mockTranslator := &MockCDEventTranslator{}
+			if tc.translateErr != nil {
+				mockTranslator.On("Translate", mock.Anything).Return(nil, tc.translateErr)
+			} else {
+				mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil)
+			}
+
+			adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}))
+
+			simulation, err := adapter.Simulate(context.Background(), tc.subject, []byte(`{"foo": "bar"}`), tc.publish)
+			switch {
+			case tc.expectedErr != nil:
+				assert.ErrorIs(t, err, tc.expectedErr)
+			case tc.publishErr != nil:
+				assert.ErrorIs(t, err, tc.publishErr)
+			default:
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.expectedEvent, simulation.Event != nil)
+			assert.Equal(t, tc.expectedSkipped, simulation.Skipped)
+			assert.Equal(t, tc.expectedPublished, simulation.Published)
+			if tc.publish && tc.expectedEvent {
+				mockPublisher.AssertNumberOfCalls(t, "Publish", 1)
+			} else {
+				mockPublisher.AssertNotCalled(t, "Publish", mock.Anything)
+			}
+		})
+	}
+}
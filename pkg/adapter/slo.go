@@ -0,0 +1,173 @@
+package adapter
+
+import (
+	"sort"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/metrics"
+)
+
+type SLOConfig struct {
+	Window           int     `envconfig:"WINDOW" default:"100"`
+	MinSamples       int     `envconfig:"MIN_SAMPLES" default:"20"`
+	FailureThreshold float64 `envconfig:"FAILURE_THRESHOLD" default:"0.5"`
+	AutoDisable      bool    `envconfig:"AUTO_DISABLE" default:"false"`
+}
+
+// translatorSLO tracks the outcome and latency of the most recent messages handled by a
+// translator.
+type translatorSLO struct {
+	failed    []bool
+	durations []time.Duration
+	next      int
+	count     int
+	degraded  bool
+	disabled  bool
+}
+
+func newTranslatorSLO(window int) *translatorSLO {
+	return &translatorSLO{
+		failed:    make([]bool, window),
+		durations: make([]time.Duration, window),
+	}
+}
+
+func (s *translatorSLO) record(failed bool, duration time.Duration) {
+	s.failed[s.next] = failed
+	s.durations[s.next] = duration
+	s.next = (s.next + 1) % len(s.failed)
+	if s.count < len(s.failed) {
+		s.count++
+	}
+}
+
+func (s *translatorSLO) failureRate() float64 {
+	if s.count == 0 {
+		return 0
+	}
+
+	failures := 0
+	for _, failed := range s.failed[:s.count] {
+		if failed {
+			failures++
+		}
+	}
+	return float64(failures) / float64(s.count)
+}
+
+func (s *translatorSLO) p99() time.Duration {
+	if s.count == 0 {
+		return 0
+	}
+
+	durations := make([]time.Duration, s.count)
+	copy(durations, s.durations[:s.count])
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	return durations[(len(durations)*99+99)/100-1]
+}
+
+// SetSLO enables tracking of success ratio and latency per translator. A translator is
+// degraded when its failure rate over the window of recent messages crosses the threshold, and
+// with auto disable it stops translating until it is enabled again.
+func (c *CDEventAdapter) SetSLO(config SLOConfig) {
+	c.sloMu.Lock()
+	defer c.sloMu.Unlock()
+
+	if config.Window < 1 {
+		config.Window = 1
+	}
+
+	c.slo = &config
+	c.health = make(map[string]*translatorSLO)
+}
+
+// EnableTranslator enables the translator for a webhook subject, whether it was disabled by
+// configuration or because it was degraded, and starts a new window for it. It reports whether
+// there is a translator for the subject.
+func (c *CDEventAdapter) EnableTranslator(subject string) bool {
+	if _, exists := c.translators.Lookup(subject); !exists {
+		return false
+	}
+
+	c.updateDisabled(subject, false)
+
+	c.sloMu.Lock()
+	defer c.sloMu.Unlock()
+
+	if c.slo != nil {
+		c.health[subject] = newTranslatorSLO(c.slo.Window)
+		metrics.TranslatorDegraded.WithLabelValues(subject).Set(0)
+	}
+
+	return true
+}
+
+func (c *CDEventAdapter) recordSLO(subject string, failed bool, duration time.Duration) {
+	outcome := "success"
+	if failed {
+		outcome = "failure"
+	}
+	metrics.TranslatorMessages.WithLabelValues(subject, outcome).Inc()
+	metrics.TranslatorDuration.WithLabelValues(subject).Observe(duration.Seconds())
+
+	c.sloMu.Lock()
+	defer c.sloMu.Unlock()
+
+	if c.slo == nil {
+		return
+	}
+
+	health, exists := c.health[subject]
+	if !exists {
+		health = newTranslatorSLO(c.slo.Window)
+		c.health[subject] = health
+	}
+	health.record(failed, duration)
+
+	degraded := health.count >= c.slo.MinSamples && health.failureRate() >= c.slo.FailureThreshold
+	if degraded && !health.degraded {
+		c.logger.Warn("Translator is degraded", "translator", subject, "failure_rate", health.failureRate())
+		if c.slo.AutoDisable {
+			c.logger.Warn("Disabling degraded translator", "translator", subject)
+			health.disabled = true
+		}
+	} else if !degraded && health.degraded {
+		c.logger.Info("Translator recovered", "translator", subject)
+	}
+	health.degraded = degraded
+
+	metrics.TranslatorSuccessRatio.WithLabelValues(subject).Set(1 - health.failureRate())
+	if degraded {
+		metrics.TranslatorDegraded.WithLabelValues(subject).Set(1)
+	} else {
+		metrics.TranslatorDegraded.WithLabelValues(subject).Set(0)
+	}
+}
+
+func (c *CDEventAdapter) autoDisabled(subject string) bool {
+	c.sloMu.Lock()
+	defer c.sloMu.Unlock()
+
+	health, exists := c.health[subject]
+	return exists && health.disabled
+}
+
+func (c *CDEventAdapter) translatorHealth(subject string, info *TranslatorInfo) {
+	c.sloMu.Lock()
+	defer c.sloMu.Unlock()
+
+	health, exists := c.health[subject]
+	if !exists {
+		return
+	}
+
+	successRatio := 1 - health.failureRate()
+	info.Samples = health.count
+	info.SuccessRatio = &successRatio
+	info.P99Ms = float64(health.p99().Microseconds()) / 1000
+	info.Degraded = health.degraded
+	if health.disabled {
+		info.Enabled = false
+	}
+}
@@ -0,0 +1,137 @@
+package adapter
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// sequenceTranslator fails or succeeds according to a sequence of outcomes, and succeeds once
+// the sequence is exhausted.
+type sequenceTranslator struct {
+	event    cdevents.CDEvent
+	failures []bool
+	calls    int
+}
+
+func (s *sequenceTranslator) Translate(data []byte) (cdevents.CDEvent, error) {
+	s.calls++
+	if s.calls <= len(s.failures) && s.failures[s.calls-1] {
+		return nil, fmt.Errorf("unexpected payload")
+	}
+	return s.event, nil
+}
+
+func TestTranslatorSLO(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tc := range []struct {
+		title                string
+		config               SLOConfig
+		failures             []bool
+		publishErr           error
+		expectedSuccessRatio float64
+		expectedDegraded     bool
+		expectedEnabled      bool
+	}{
+		{
+			title:                "healthy translator",
+			config:               SLOConfig{Window: 10, MinSamples: 4, FailureThreshold: 0.5},
+			failures:             []bool{false, false, true, false},
+			expectedSuccessRatio: 0.75,
+			expectedEnabled:      true,
+		},
+		{
+			title:                "degraded translator",
+			config:               SLOConfig{Window: 10, MinSamples: 4, FailureThreshold: 0.5},
+			failures:             []bool{false, true, true, false},
+			expectedSuccessRatio: 0.5,
+			expectedDegraded:     true,
+			expectedEnabled:      true,
+		},
+		{
+			title:                "not degraded before min samples",
+			config:               SLOConfig{Window: 10, MinSamples: 4, FailureThreshold: 0.5},
+			failures:             []bool{true, true, true},
+			expectedSuccessRatio: 0,
+			expectedEnabled:      true,
+		},
+		{
+			title:                "only counts the window of recent messages",
+			config:               SLOConfig{Window: 3, MinSamples: 3, FailureThreshold: 0.5},
+			failures:             []bool{true, true, true, false, false, false},
+			expectedSuccessRatio: 1,
+			expectedEnabled:      true,
+		},
+		{
+			title:                "auto disables degraded translator",
+			config:               SLOConfig{Window: 10, MinSamples: 2, FailureThreshold: 0.5, AutoDisable: true},
+			failures:             []bool{true, true},
+			expectedSuccessRatio: 0,
+			expectedDegraded:     true,
+		},
+		{
+			title:                "publish failures do not count against translator",
+			config:               SLOConfig{Window: 10, MinSamples: 2, FailureThreshold: 0.5},
+			failures:             []bool{false, false},
+			publishErr:           fmt.Errorf("sink unavailable"),
+			expectedSuccessRatio: 1,
+			expectedEnabled:      true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			mockPublisher := &MockPublisher{}
+			mockPublisher.On("Publish", mock.Anything).Return(tc.publishErr)
+
+			sequence := &sequenceTranslator{event: newTestCDEvent(t), failures: tc.failures}
+			adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": sequence}))
+			adapter.SetSLO(tc.config)
+
+			for range tc.failures {
+				adapter.Process(newMockJetstreamMsg("webhook.test.event", []byte("{}")))
+			}
+
+			infos := adapter.Translators()
+			require.Len(t, infos, 1)
+			require.NotNil(t, infos[0].SuccessRatio)
+			assert.Equal(t, tc.expectedSuccessRatio, *infos[0].SuccessRatio)
+			assert.Equal(t, tc.expectedDegraded, infos[0].Degraded)
+			assert.Equal(t, tc.expectedEnabled, infos[0].Enabled)
+			assert.Greater(t, infos[0].P99Ms, 0.0)
+		})
+	}
+}
+
+func TestAutoDisabledTranslatorSkipsMessages(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	sequence := &sequenceTranslator{event: newTestCDEvent(t), failures: []bool{true}}
+	adapter := NewCDEventAdapter(logger, &MockPublisher{}, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": sequence}))
+	adapter.SetSLO(SLOConfig{Window: 10, MinSamples: 1, FailureThreshold: 0.5, AutoDisable: true})
+
+	require.Error(t, adapter.Process(newMockJetstreamMsg("webhook.test.event", []byte("{}"))))
+	require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.test.event", []byte("{}"))), "message should be skipped")
+	assert.Equal(t, 1, sequence.calls)
+
+	require.True(t, adapter.EnableTranslator("test.event"))
+	assert.True(t, adapter.Translators()[0].Enabled)
+	assert.False(t, adapter.EnableTranslator("test.unknown"))
+}
+
+func TestTranslatorSLOP99(t *testing.T) {
+	slo := newTranslatorSLO(200)
+	for i := 1; i <= 200; i++ {
+		slo.record(false, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 198*time.Millisecond, slo.p99())
+}
@@ -0,0 +1,106 @@
+package adapter
+
+import (
+	"encoding/json"
+	"fmt"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+	cdeventsv03 "github.com/cdevents/sdk-go/pkg/api/v03"
+)
+
+const (
+	SpecVersion03 = "0.3"
+	SpecVersion04 = "0.4"
+)
+
+// SpecVersions chooses the CDEvents spec version that events are published in, for the whole
+// deployment and by webhook subject, e.g. for consumers that have not upgraded to the subject
+// content of v0.4 events. Translators produce v0.4 events, which are converted to v0.3 when it
+// is chosen.
+type SpecVersions struct {
+	// Default is the version of events from subjects without a version of their own.
+	Default  string
+	Subjects map[string]string
+}
+
+func (v SpecVersions) Validate() error {
+	if err := validateSpecVersion(v.Default); err != nil {
+		return err
+	}
+	for subject, version := range v.Subjects {
+		if err := validateSpecVersion(version); err != nil {
+			return fmt.Errorf("%s: %w", subject, err)
+		}
+	}
+	return nil
+}
+
+func validateSpecVersion(version string) error {
+	switch version {
+	case "", SpecVersion03, SpecVersion04:
+		return nil
+	}
+	return fmt.Errorf("unsupported CDEvents spec version %s, must be %s or %s", version, SpecVersion03, SpecVersion04)
+}
+
+func (v SpecVersions) version(subject string) string {
+	if version, found := v.Subjects[subject]; found {
+		return version
+	}
+	return v.Default
+}
+
+// SetSpecVersions sets the CDEvents spec version of published events.
+func (c *CDEventAdapter) SetSpecVersions(versions SpecVersions) error {
+	if err := versions.Validate(); err != nil {
+		return err
+	}
+	c.specVersions = &versions
+	return nil
+}
+
+// convertToV03 converts an event to the event of the same subject and predicate in the v0.3
+// spec. Content that is new in v0.4, like links and the chain id, is dropped, and there is no
+// conversion of event types that are new in v0.4.
+func convertToV03(event cdevents.CDEvent) (cdevents.CDEvent, error) {
+	if event.GetVersion() == cdeventsv03.SpecVersion {
+		return event, nil
+	}
+
+	template, found := cdeventsv03.CDEventsByUnversionedTypes[event.GetType().UnversionedString()]
+	if !found {
+		return nil, fmt.Errorf("no CDEvents %s event type for %s", cdeventsv03.SpecVersion, event.GetType().UnversionedString())
+	}
+	eventType := template.GetType().String()
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	eventContext, _ := doc["context"].(map[string]interface{})
+	if eventContext == nil {
+		return nil, fmt.Errorf("event of type %s has no context", event.GetType())
+	}
+	eventContext["version"] = cdeventsv03.SpecVersion
+	eventContext["type"] = eventType
+	delete(eventContext, "chainId")
+	delete(eventContext, "links")
+	delete(eventContext, "schemaUri")
+
+	if data, err = json.Marshal(doc); err != nil {
+		return nil, err
+	}
+
+	converted, err := cdeventsv03.NewCDEvent(eventType, cdeventsv03.SpecVersion)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, converted); err != nil {
+		return nil, fmt.Errorf("cannot convert %s to CDEvents %s: %w", event.GetType(), cdeventsv03.SpecVersion, err)
+	}
+	return converted, nil
+}
@@ -0,0 +1,106 @@
+package adapter
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+	cdeventsv03 "github.com/cdevents/sdk-go/pkg/api/v03"
+	cdeventsv04 "github.com/cdevents/sdk-go/pkg/api/v04"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+func TestSpecVersionsValidate(t *testing.T) {
+
+	testCases := map[string]struct {
+		versions SpecVersions
+		errMsg   string
+	}{
+		"empty":           {versions: SpecVersions{}},
+		"v0.3 by default": {versions: SpecVersions{Default: "0.3"}},
+		"v0.3 by subject": {versions: SpecVersions{Default: "0.4", Subjects: map[string]string{"gitea.push": "0.3"}}},
+		"unsupported default": {
+			versions: SpecVersions{Default: "0.2"},
+			errMsg:   "unsupported CDEvents spec version 0.2, must be 0.3 or 0.4",
+		},
+		"unsupported by subject": {
+			versions: SpecVersions{Subjects: map[string]string{"gitea.push": "0.4.1"}},
+			errMsg:   "gitea.push: unsupported CDEvents spec version 0.4.1, must be 0.3 or 0.4",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			err := tc.versions.Validate()
+			if tc.errMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestConvertToV03(t *testing.T) {
+
+	event, err := cdeventsv04.NewChangeCreatedEvent()
+	require.NoError(t, err)
+	event.SetSource("git.example.com")
+	event.SetSubjectId("pr-3")
+	event.SetSubjectDescription("Fix something PR")
+	event.SetSubjectRepository(&cdevents.Reference{Id: "yoloco/project1"})
+	event.SetChainId("chain-1")
+	path := cdevents.NewEmbeddedLinkPath()
+	path.SetFrom(cdevents.EventReference{ContextId: "earlier"})
+	event.SetLinks(cdevents.EmbeddedLinksArray{path})
+
+	converted, err := convertToV03(event)
+	require.NoError(t, err)
+
+	assert.Equal(t, "0.3.0", converted.GetVersion())
+	assert.Equal(t, "dev.cdevents.change.created.0.1.2", converted.GetType().String())
+	assert.Equal(t, event.GetId(), converted.GetId())
+	assert.Equal(t, "git.example.com", converted.GetSource())
+	assert.Equal(t, "pr-3", converted.GetSubjectId())
+	assert.Equal(t, &cdevents.Reference{Id: "yoloco/project1"}, converted.(*cdeventsv03.ChangeCreatedEvent).Subject.Content.Repository)
+	assert.NoError(t, cdevents.Validate(converted))
+
+	ticket, err := cdeventsv04.NewTicketCreatedEvent()
+	require.NoError(t, err)
+	_, err = convertToV03(ticket)
+	assert.EqualError(t, err, "no CDEvents 0.3.0 event type for dev.cdevents.ticket.created")
+}
+
+func TestSpecVersionBySubject(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var published []cloudevents.Event
+	mockPublisher := &MockPublisher{}
+	mockPublisher.On("Publish", mock.Anything).Run(func(args mock.Arguments) {
+		published = append(published, args.Get(0).(cloudevents.Event))
+	}).Return(nil)
+
+	mockTranslator := &MockCDEventTranslator{}
+	mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil)
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{
+		"gitea.push": mockTranslator,
+		"other.push": mockTranslator,
+	}))
+	require.NoError(t, adapter.SetSpecVersions(SpecVersions{Default: SpecVersion04, Subjects: map[string]string{"gitea.push": SpecVersion03}}))
+
+	require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.gitea.push", []byte("{}"))))
+	require.NoError(t, adapter.Process(newMockJetstreamMsg("webhook.other.push", []byte("{}"))))
+
+	require.Len(t, published, 2)
+	assert.Equal(t, "dev.cdevents.change.merged.0.1.2", published[0].Type())
+	assert.Equal(t, "dev.cdevents.change.merged.0.2.0", published[1].Type())
+}
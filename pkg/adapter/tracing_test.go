@@ -0,0 +1,47 @@
+package adapter
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestProcessPropagatesTraceContext(t *testing.T) {
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	mockPublisher := &MockPublisher{}
+	mockTranslator := &MockCDEventTranslator{}
+	mockTranslator.On("Translate", mock.Anything).Return(newTestCDEvent(t), nil)
+	mockPublisher.On("Publish", mock.Anything).Return(nil)
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}))
+
+	msg := newMockJetstreamMsg("webhook.test.event", []byte("{}"))
+	msg.headers = nats.Header{"Traceparent": []string{"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}}
+	require.NoError(t, adapter.Process(msg))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3, "process, translate and publish spans should be recorded")
+	for _, span := range spans {
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String(), "span %s should be part of incoming trace", span.Name())
+	}
+
+	published := mockPublisher.Calls[0].Arguments.Get(0).(cloudevents.Event)
+	assert.Contains(t, published.Extensions()["traceparent"], "4bf92f3577b34da6a3ce929d0e0e4736", "traceparent should be set on CloudEvent")
+}
@@ -0,0 +1,86 @@
+package adapter
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/metrics"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+	"github.com/go-playground/validator/v10"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+)
+
+// ValidationError is returned for a translated event that is not valid against the CDEvents
+// schema of its type. The event is not published and the details are reported as part of the
+// failed message.
+type ValidationError struct {
+	EventType string
+	// Details are the individual violations, e.g. "/subject/id: minLength: got 0, want 1".
+	Details []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid CDEvent %s: %s", e.EventType, strings.Join(e.Details, "; "))
+}
+
+var validationPrinter = message.NewPrinter(language.English)
+
+// asCloudEvent validates an event against the schema of its type and renders it as a
+// CloudEvent. It is cdevents.AsCloudEvent with the violations kept in a ValidationError.
+func asCloudEvent(subject string, event cdevents.CDEvent) (*cloudevents.Event, error) {
+	if err := cdevents.Validate(event); err != nil {
+		metrics.InvalidEvents.WithLabelValues(subject).Inc()
+		return nil, &ValidationError{EventType: event.GetType().String(), Details: validationDetails(err)}
+	}
+
+	cloudEvent := cloudevents.NewEvent()
+	cloudEvent.SetID(event.GetId())
+	cloudEvent.SetSource(event.GetSource())
+	cloudEvent.SetSubject(event.GetSubjectId())
+	cloudEvent.SetType(event.GetType().String())
+	if err := cloudEvent.SetData(cloudevents.ApplicationJSON, event); err != nil {
+		return nil, err
+	}
+	return &cloudEvent, nil
+}
+
+// validationDetails lists the violations of the struct tags and of the JSON schema of the SDK,
+// or the error itself for failures to validate at all.
+func validationDetails(err error) []string {
+	var details []string
+
+	var fieldErrors validator.ValidationErrors
+	var schemaError *jsonschema.ValidationError
+	switch {
+	case errors.As(err, &fieldErrors):
+		for _, fieldError := range fieldErrors {
+			details = append(details, fmt.Sprintf("%s: %s", fieldError.Namespace(), fieldError.Tag()))
+		}
+	case errors.As(err, &schemaError):
+		details = schemaViolations(schemaError, details)
+	default:
+		details = append(details, err.Error())
+	}
+
+	sort.Strings(details)
+	return details
+}
+
+// schemaViolations collects the innermost causes of a schema validation error, which point at
+// the offending values.
+func schemaViolations(err *jsonschema.ValidationError, details []string) []string {
+	if len(err.Causes) == 0 {
+		return append(details, fmt.Sprintf("/%s: %s", strings.Join(err.InstanceLocation, "/"), err.ErrorKind.LocalizedString(validationPrinter)))
+	}
+	for _, cause := range err.Causes {
+		details = schemaViolations(cause, details)
+	}
+	return details
+}
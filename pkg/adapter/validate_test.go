@@ -0,0 +1,64 @@
+package adapter
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	cdeventsv04 "github.com/cdevents/sdk-go/pkg/api/v04"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAsCloudEvent(t *testing.T) {
+
+	event := newTestCDEvent(t)
+	cloudEvent, err := asCloudEvent("test.event", event)
+	require.NoError(t, err)
+	assert.Equal(t, event.GetId(), cloudEvent.ID())
+	assert.Equal(t, "git.example.com", cloudEvent.Source())
+	assert.Equal(t, event.GetSubjectId(), cloudEvent.Subject())
+	assert.Equal(t, "dev.cdevents.change.merged.0.2.0", cloudEvent.Type())
+
+	invalid, err := cdeventsv04.NewChangeMergedEvent()
+	require.NoError(t, err)
+	invalid.SetSource("git.example.com")
+
+	_, err = asCloudEvent("test.event", invalid)
+	var validationError *ValidationError
+	require.True(t, errors.As(err, &validationError), "expected a validation error, got %v", err)
+	assert.Equal(t, "dev.cdevents.change.merged.0.2.0", validationError.EventType)
+	assert.NotEmpty(t, validationError.Details)
+}
+
+func TestProcessReportsInvalidEvents(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	invalid, err := cdeventsv04.NewChangeMergedEvent()
+	require.NoError(t, err)
+	invalid.SetSource("git.example.com")
+
+	mockPublisher := &MockPublisher{}
+	mockTranslator := &MockCDEventTranslator{}
+	mockTranslator.On("Translate", mock.Anything).Return(invalid, nil)
+
+	nc := &mockNATSPublisher{}
+
+	adapter := NewCDEventAdapter(logger, mockPublisher, translator.NewRegistry(map[string]translator.CDEventTranslator{"test.event": mockTranslator}))
+	adapter.SetErrorReporter(NewNATSErrorReporter(nc, "cdevents-adapter.errors"))
+
+	require.ErrorContains(t, adapter.Process(newMockJetstreamMsg("webhook.test.event", []byte("{}"))), "invalid CDEvent dev.cdevents.change.merged.0.2.0")
+	mockPublisher.AssertNotCalled(t, "Publish", mock.Anything)
+
+	require.Len(t, nc.published, 1, "invalid event should be reported")
+	var failed FailedEvent
+	require.NoError(t, json.Unmarshal(nc.published[0].Data, &failed))
+	assert.Equal(t, "dev.cdevents.change.merged.0.2.0", failed.EventType)
+	assert.Equal(t, []string{"/subject/id: minLength: got 0, want 1"}, failed.ValidationErrors)
+}
@@ -0,0 +1,39 @@
+package translator
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/jsonpath"
+)
+
+// Policies for embedding the provider payload as custom data of the CDEvents.
+const (
+	CustomDataFull   = "full"
+	CustomDataFields = "fields"
+	CustomDataNone   = "none"
+)
+
+// selectFields returns the fields of the payload at the given paths, keeping their structure.
+func selectFields(payload interface{}, paths []jsonpath.Path) (map[string]interface{}, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	// Numbers are kept as json.Number, so that large ids do not lose precision as float64.
+	var doc interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]interface{})
+	for _, path := range paths {
+		if selected, ok := path.Select(doc); ok {
+			fields = jsonpath.Merge(fields, selected).(map[string]interface{})
+		}
+	}
+	return fields, nil
+}
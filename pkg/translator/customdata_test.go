@@ -0,0 +1,148 @@
+package translator
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/jsonpath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectFields(t *testing.T) {
+
+	payload := map[string]interface{}{
+		"id":  int64(9007199254740993),
+		"ref": "refs/heads/main",
+		"commits": []interface{}{
+			map[string]interface{}{"id": "a1", "message": "first", "author": map[string]interface{}{"email": "jane@example.com"}},
+			map[string]interface{}{"id": "b2", "message": "second", "author": map[string]interface{}{"email": "john@example.com"}},
+		},
+		"repository": map[string]interface{}{"full_name": "yoloco/project1", "ssh_url": "git@example.com:yoloco/project1.git"},
+	}
+
+	for _, tc := range []struct {
+		title    string
+		paths    []string
+		expected map[string]interface{}
+	}{
+		{
+			title:    "selects nested field",
+			paths:    []string{"$.repository.full_name"},
+			expected: map[string]interface{}{"repository": map[string]interface{}{"full_name": "yoloco/project1"}},
+		},
+		{
+			title: "selects fields of every array element",
+			paths: []string{"$.commits[*].id", "commits[*].message"},
+			expected: map[string]interface{}{"commits": []interface{}{
+				map[string]interface{}{"id": "a1", "message": "first"},
+				map[string]interface{}{"id": "b2", "message": "second"},
+			}},
+		},
+		{
+			title:    "keeps large numbers",
+			paths:    []string{"$.id"},
+			expected: map[string]interface{}{"id": json.Number("9007199254740993")},
+		},
+		{
+			title:    "ignores missing fields",
+			paths:    []string{"$.ref", "$.pusher.email"},
+			expected: map[string]interface{}{"ref": "refs/heads/main"},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			var paths []jsonpath.Path
+			for _, expression := range tc.paths {
+				path, err := jsonpath.Parse(expression)
+				require.NoError(t, err)
+				paths = append(paths, path)
+			}
+
+			fields, err := selectFields(payload, paths)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, fields)
+		})
+	}
+}
+
+func TestGiteaConfigValidate(t *testing.T) {
+
+	for _, tc := range []struct {
+		title         string
+		config        GiteaConfig
+		expectedError string
+	}{
+		{title: "accepts zero value"},
+		{title: "accepts none", config: GiteaConfig{CustomData: CustomDataNone}},
+		{title: "accepts fields", config: GiteaConfig{CustomData: CustomDataFields, CustomDataFields: []string{"$.commits[*].id"}}},
+		{
+			title:         "rejects unknown policy",
+			config:        GiteaConfig{CustomData: "some"},
+			expectedError: "unknown custom data policy: some",
+		},
+		{
+			title:         "rejects fields policy without fields",
+			config:        GiteaConfig{CustomData: CustomDataFields},
+			expectedError: "requires at least one field",
+		},
+		{
+			title:         "rejects unsupported path",
+			config:        GiteaConfig{CustomData: CustomDataFields, CustomDataFields: []string{"$.commits[0].id"}},
+			expectedError: "unsupported field path $.commits[0].id",
+		},
+		{title: "accepts custom data schemas", config: GiteaConfig{CustomDataSchemas: true}},
+		{
+			title:         "rejects custom data schemas with fields policy",
+			config:        GiteaConfig{CustomDataSchemas: true, CustomData: CustomDataFields, CustomDataFields: []string{"$.ref"}},
+			expectedError: "custom data schemas require the full custom data policy",
+		},
+		{title: "accepts subject id templates", config: GiteaConfig{SubjectIDs: SubjectIDTemplates{PullRequest: "{{.FullName}}#{{.Number}}", Push: "{{.Commit}}"}}},
+		{
+			title:         "rejects unknown subject id template field",
+			config:        GiteaConfig{SubjectIDs: SubjectIDTemplates{Delete: "{{.Number}}-{{.Sha}}"}},
+			expectedError: "invalid subject id template",
+		},
+		{
+			title:         "rejects empty subject id",
+			config:        GiteaConfig{SubjectIDs: SubjectIDTemplates{Create: "{{if false}}x{{end}}"}},
+			expectedError: "subject id template rendered an empty subject id",
+		},
+		{title: "accepts source template", config: GiteaConfig{SourceTemplate: "/adapter-1/{{.Provider}}/{{.Host}}"}},
+		{
+			title:         "rejects unparsable source template",
+			config:        GiteaConfig{SourceTemplate: "/adapter-1/{{.Host"},
+			expectedError: "invalid source template",
+		},
+		{
+			title:         "rejects unknown source template field",
+			config:        GiteaConfig{SourceTemplate: "/adapter-1/{{.Instance}}"},
+			expectedError: "invalid source template",
+		},
+		{
+			title:         "rejects invalid source",
+			config:        GiteaConfig{SourceTemplate: "%{{.Host}}"},
+			expectedError: "source template rendered an invalid source",
+		},
+		{title: "accepts ref patterns", config: GiteaConfig{MainBranches: []string{"main", "release/*"}, IncludeRefs: []string{"refs/heads/*"}}},
+		{
+			title:         "rejects malformed include ref pattern",
+			config:        GiteaConfig{IncludeRefs: []string{"refs/heads/release/["}},
+			expectedError: "invalid pattern refs/heads/release/[",
+		},
+		{
+			title:         "rejects malformed release tag pattern",
+			config:        GiteaConfig{ReleaseTags: []string{"v[0-9"}},
+			expectedError: "invalid pattern v[0-9",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
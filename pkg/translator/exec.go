@@ -0,0 +1,137 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+	cdeventsv04 "github.com/cdevents/sdk-go/pkg/api/v04"
+)
+
+// ExecConfig configures a translator that runs an executable for every webhook payload. The
+// payload is written to its stdin and it writes the CDEvent as JSON to stdout. A non-zero exit
+// code fails the translation with the message written to stderr. The process only gets PATH
+// and the variables named in Env from the environment of the adapter, so that credentials of the
+// adapter are not passed on, and its output is limited to MaxOutputSize bytes, which defaults to
+// DefaultMaxPayloadSize.
+type ExecConfig struct {
+	Command       string        `envconfig:"COMMAND"`
+	Args          []string      `envconfig:"ARGS"`
+	Env           []string      `envconfig:"ENV"`
+	Timeout       time.Duration `envconfig:"TIMEOUT" default:"10s"`
+	Concurrency   int           `envconfig:"CONCURRENCY" default:"4"`
+	MaxOutputSize int64         `envconfig:"MAX_OUTPUT_SIZE"`
+}
+
+// errOutputTooLarge is returned by a limitedBuffer that is written more than its limit.
+var errOutputTooLarge = errors.New("output too large")
+
+// limitedBuffer is a buffer that fails writes beyond a limit, and calls onOverflow, if set, the
+// first time it does, e.g. to stop the process writing to it. The buffer is not embedded, so
+// that io.Copy cannot bypass the limit with its ReadFrom.
+type limitedBuffer struct {
+	buf        bytes.Buffer
+	limit      int64
+	onOverflow func()
+	overflowed bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.overflowed || int64(b.buf.Len()+len(p)) > b.limit {
+		if !b.overflowed && b.onOverflow != nil {
+			b.onOverflow()
+		}
+		b.overflowed = true
+		return 0, fmt.Errorf("%w: more than %d bytes", errOutputTooLarge, b.limit)
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
+
+// ExecTranslator translates webhook payloads with an external process. At most Concurrency
+// processes run at the same time and a process is killed when Timeout expires.
+type ExecTranslator struct {
+	config ExecConfig
+	path   string
+	slots  chan struct{}
+}
+
+func NewExecTranslator(config ExecConfig) (*ExecTranslator, error) {
+	if config.Command == "" {
+		return nil, fmt.Errorf("no command configured for exec translator")
+	}
+	if config.Concurrency < 1 {
+		return nil, fmt.Errorf("invalid concurrency for exec translator: %d", config.Concurrency)
+	}
+	if config.MaxOutputSize <= 0 {
+		config.MaxOutputSize = DefaultMaxPayloadSize
+	}
+
+	path, err := exec.LookPath(config.Command)
+	if err != nil {
+		return nil, fmt.Errorf("invalid command for exec translator: %w", err)
+	}
+
+	return &ExecTranslator{
+		config: config,
+		path:   path,
+		slots:  make(chan struct{}, config.Concurrency),
+	}, nil
+}
+
+func (t *ExecTranslator) Translate(data []byte) (cdevents.CDEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), t.config.Timeout)
+	defer cancel()
+
+	select {
+	case t.slots <- struct{}{}:
+		defer func() { <-t.slots }()
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out waiting to run %s: %w", t.config.Command, ctx.Err())
+	}
+
+	stdout := &limitedBuffer{limit: t.config.MaxOutputSize, onOverflow: cancel}
+	stderr := &limitedBuffer{limit: t.config.MaxOutputSize, onOverflow: cancel}
+	cmd := exec.CommandContext(ctx, t.path, t.config.Args...)
+	cmd.Env = t.environ()
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = time.Second
+
+	if err := cmd.Run(); err != nil {
+		switch {
+		case stdout.overflowed || stderr.overflowed:
+			err = fmt.Errorf("%w: more than %d bytes", errOutputTooLarge, t.config.MaxOutputSize)
+		case ctx.Err() != nil:
+			err = ctx.Err()
+		}
+		return nil, fmt.Errorf("exec translator %s failed: %w: %s", t.config.Command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return cdeventsv04.NewFromJsonBytes(stdout.Bytes())
+}
+
+// environ returns PATH and the variables named in Env from the environment of the adapter.
+func (t *ExecTranslator) environ() []string {
+	var env []string
+	for _, name := range append([]string{"PATH"}, t.config.Env...) {
+		if value, found := os.LookupEnv(strings.TrimSpace(name)); found {
+			env = append(env, fmt.Sprintf("%s=%s", strings.TrimSpace(name), value))
+		}
+	}
+	return env
+}
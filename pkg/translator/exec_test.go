@@ -0,0 +1,123 @@
+package translator
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testEvent = `{"context":{"version":"0.4.1","id":"test","source":"exec","type":"dev.cdevents.change.merged.0.2.0","timestamp":"2024-01-01T00:00:00Z"},"subject":{"id":"42","type":"change","content":{"repository":{"id":"repo"}}}}`
+
+func TestExecTranslator(t *testing.T) {
+
+	for _, tc := range []struct {
+		title           string
+		config          ExecConfig
+		expectedSubject string
+		expectedError   string
+	}{
+		{
+			title:           "translates payload",
+			config:          ExecConfig{Command: "sh", Args: []string{"-c", fmt.Sprintf("cat > /dev/null; echo '%s'", testEvent)}},
+			expectedSubject: "42",
+		},
+		{
+			title:         "fails with stderr",
+			config:        ExecConfig{Command: "sh", Args: []string{"-c", "echo 'unsupported payload' >&2; exit 3"}},
+			expectedError: "exec translator sh failed: exit status 3: unsupported payload",
+		},
+		{
+			title:         "fails on timeout",
+			config:        ExecConfig{Command: "sleep", Args: []string{"10"}, Timeout: 100 * time.Millisecond},
+			expectedError: "exec translator sleep failed: context deadline exceeded",
+		},
+		{
+			title:         "fails on too large output",
+			config:        ExecConfig{Command: "sh", Args: []string{"-c", "yes"}, MaxOutputSize: 1024},
+			expectedError: "exec translator sh failed: output too large: more than 1024 bytes",
+		},
+		{
+			title:         "fails on invalid output",
+			config:        ExecConfig{Command: "echo", Args: []string{"not json"}},
+			expectedError: "invalid character",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			if tc.config.Timeout == 0 {
+				tc.config.Timeout = 5 * time.Second
+			}
+			tc.config.Concurrency = 1
+
+			exec, err := NewExecTranslator(tc.config)
+			require.NoError(t, err)
+
+			event, err := exec.Translate([]byte(`{}`))
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedSubject, event.GetSubjectId())
+		})
+	}
+}
+
+func TestExecTranslatorEnvironment(t *testing.T) {
+
+	t.Setenv("EXEC_TEST_ALLOWED", "allowed")
+	t.Setenv("EXEC_TEST_SECRET", "secret")
+
+	exec, err := NewExecTranslator(ExecConfig{
+		Command:     "sh",
+		Args:        []string{"-c", "echo \"$EXEC_TEST_ALLOWED:$EXEC_TEST_SECRET\" >&2; exit 1"},
+		Env:         []string{"EXEC_TEST_ALLOWED"},
+		Timeout:     5 * time.Second,
+		Concurrency: 1,
+	})
+	require.NoError(t, err)
+
+	_, err = exec.Translate([]byte(`{}`))
+	assert.ErrorContains(t, err, ": allowed:", "only variables named in Env should be passed to the process")
+}
+
+func TestExecTranslatorConcurrency(t *testing.T) {
+
+	exec, err := NewExecTranslator(ExecConfig{
+		Command:     "sh",
+		Args:        []string{"-c", fmt.Sprintf("sleep 0.2; echo '%s'", testEvent)},
+		Timeout:     5 * time.Second,
+		Concurrency: 1,
+	})
+	require.NoError(t, err)
+
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := exec.Translate([]byte(`{}`))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond, "processes should not run concurrently")
+}
+
+func TestNewExecTranslatorErrors(t *testing.T) {
+
+	_, err := NewExecTranslator(ExecConfig{Concurrency: 1})
+	assert.EqualError(t, err, "no command configured for exec translator")
+
+	_, err = NewExecTranslator(ExecConfig{Command: "sh"})
+	assert.EqualError(t, err, "invalid concurrency for exec translator: 0")
+
+	_, err = NewExecTranslator(ExecConfig{Command: "does-not-exist", Concurrency: 1})
+	assert.ErrorContains(t, err, "invalid command for exec translator")
+}
@@ -0,0 +1,891 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/glob"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/jsonpath"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/structs"
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+	cdeventsv04 "github.com/cdevents/sdk-go/pkg/api/v04"
+)
+
+// GiteaConfig holds the options of the Gitea translators. The zero value translates every
+// push to a ChangeMerged event, regardless of branch, and includes the commits in the custom
+// data.
+type GiteaConfig struct {
+	// MainBranches are glob patterns for the branches where a push is a merged change, e.g.
+	// "main,release/*". Pushes to other branches are skipped. Empty means every branch, or only
+	// the default branch of the repository if the API is configured.
+	MainBranches []string `envconfig:"MAIN_BRANCHES"`
+	// IgnoreTags skips pushes of tags and the creation and deletion of tags.
+	IgnoreTags bool `envconfig:"IGNORE_TAGS" default:"false"`
+	// ReleaseTags are glob patterns for the tags that mark releases, e.g. "v*". Pushes and
+	// creations of release tags are also translated to an ArtifactPublished event of the release.
+	ReleaseTags []string `envconfig:"RELEASE_TAGS"`
+	// OmitCommits leaves the list of commits out of the custom data of push events.
+	OmitCommits bool `envconfig:"OMIT_COMMITS" default:"false"`
+	// IncludeRefs are glob patterns for the full refs that are translated on push, create and
+	// delete, e.g. "refs/heads/main,refs/heads/release/*". Empty means every ref.
+	IncludeRefs []string `envconfig:"INCLUDE_REFS"`
+	// ExcludeRefs are glob patterns for full refs that are skipped even if they are included.
+	ExcludeRefs []string `envconfig:"EXCLUDE_REFS"`
+	// CustomData is the policy for embedding the Gitea payload as custom data: "full", "fields"
+	// for only the CustomDataFields or "none". Empty means "full".
+	CustomData string `envconfig:"CUSTOM_DATA" default:"full"`
+	// CustomDataFields are JSONPath expressions of the payload fields that are embedded with the
+	// "fields" policy, e.g. "$.repository.full_name,$.commits[*].id".
+	CustomDataFields []string `envconfig:"CUSTOM_DATA_FIELDS"`
+	// CommitEvents translates a push to a main branch to a ChangeMerged event for every commit,
+	// instead of only for the first commit, for consumers that follow individual commits.
+	CommitEvents bool `envconfig:"COMMIT_EVENTS" default:"false"`
+	// MaxCommitEvents is the largest number of events a push is translated to with CommitEvents.
+	// Events of the commits beyond are left out. Zero means every commit.
+	MaxCommitEvents int `envconfig:"MAX_COMMIT_EVENTS" default:"20"`
+	// MaxCommits is the number of commits of a push event kept in the custom data. The rest are
+	// left out and counted in the truncation marker. Zero means every commit.
+	MaxCommits int `envconfig:"MAX_COMMITS" default:"0"`
+	// MaxCustomDataSize is the largest custom data in bytes of JSON. Larger payloads are left out
+	// of the custom data and only the truncation marker is kept. Zero means no limit.
+	MaxCustomDataSize int `envconfig:"MAX_CUSTOM_DATA_SIZE" default:"0"`
+	// ProviderTimestamps sets the timestamp of events to when the change happened according to
+	// the payload, e.g. the time of the head commit of a push, instead of the translation time.
+	// Events of payloads without a timestamp keep the translation time.
+	ProviderTimestamps bool `envconfig:"PROVIDER_TIMESTAMPS" default:"false"`
+	// SourceTemplate renders the source of events from the repository of the payload, e.g.
+	// "/adapter-1/{{.Provider}}/{{.Host}}" to tell apart the events of several adapters and Git
+	// hosts. Empty means the host of the repository.
+	SourceTemplate string `envconfig:"SOURCE_TEMPLATE"`
+	// CustomDataSchemas sets the schemaUri of events to the published schema of the embedded
+	// payload, e.g. SchemaBaseURI+"gitea-push.json". Requires the full custom data policy.
+	CustomDataSchemas bool `envconfig:"CUSTOM_DATA_SCHEMAS" default:"false"`
+	// SubjectIDs are templates of the subject ids of events, e.g. "{{.FullName}}#{{.Number}}"
+	// for pull requests, so that subject ids are unique across repositories.
+	SubjectIDs SubjectIDTemplates `envconfig:"SUBJECT_ID"`
+	// API is the Gitea API that pushes to branches other than the main branches are looked up in.
+	// A push to the branch of an open pull request is translated to a ChangeUpdated event of the
+	// pull request instead of being skipped.
+	API GiteaAPIConfig `envconfig:"API"`
+	// MaxPayloadSize is the size limit in bytes for decoding webhook payloads, which is set from
+	// TRANSLATOR_MAX_PAYLOAD_SIZE. Zero means DefaultMaxPayloadSize and less than zero no limit.
+	MaxPayloadSize int64 `ignored:"true"`
+
+	// templates are the parsed source and subject id templates by name and text, and paths the
+	// parsed CustomDataFields, which the constructors of the translators parse once instead of on
+	// every event.
+	templates map[templateKey]*template.Template
+	paths     []jsonpath.Path
+}
+
+type templateKey struct {
+	name string
+	text string
+}
+
+// SubjectIDTemplates are templates of subject ids by Gitea webhook event. Empty templates keep
+// the default subject ids: the first commit of a push, "pr-{{.ID}}" for pull requests, the ref of
+// a create or delete and "pkg:generic/{{.FullName}}@{{.Tag}}" for releases.
+type SubjectIDTemplates struct {
+	Push        string `envconfig:"PUSH"`
+	PullRequest string `envconfig:"PULL_REQUEST"`
+	Create      string `envconfig:"CREATE"`
+	Delete      string `envconfig:"DELETE"`
+	Release     string `envconfig:"RELEASE"`
+}
+
+// sourceFields are the fields available to the source template.
+type sourceFields struct {
+	// Provider is the name of the Git provider, e.g. "gitea".
+	Provider string
+	// Host is the host of the repository URL, including the port if any.
+	Host string
+	// Owner and Name are the owner and name of the repository, FullName is both joined by a slash.
+	Owner    string
+	Name     string
+	FullName string
+}
+
+// subjectIDFields are the fields available to the subject id templates, in addition to the
+// fields of the source template.
+type subjectIDFields struct {
+	sourceFields
+	// Commit is the first commit of a push or the commit of a created ref.
+	Commit string
+	// Ref is the full ref of a push, or the branch or tag name of a create or delete.
+	Ref string
+	// ID, Number and URL are the id, number and web URL of a pull request.
+	ID     int
+	Number int
+	URL    string
+	// Tag is the name of the tag of a release.
+	Tag string
+}
+
+// Validate checks the ref patterns, custom data policy, field paths, templates and API.
+func (c GiteaConfig) Validate() error {
+	if err := c.API.Validate(); err != nil {
+		return err
+	}
+	for _, patterns := range [][]string{c.MainBranches, c.ReleaseTags, c.IncludeRefs, c.ExcludeRefs} {
+		if err := glob.Validate(patterns); err != nil {
+			return err
+		}
+	}
+	example := sourceFields{Provider: "gitea", Host: "git.example.com", Owner: "owner", Name: "repo", FullName: "owner/repo"}
+	if c.SourceTemplate != "" {
+		if _, err := c.source(example); err != nil {
+			return err
+		}
+	}
+	for _, text := range []string{c.SubjectIDs.Push, c.SubjectIDs.PullRequest, c.SubjectIDs.Create, c.SubjectIDs.Delete, c.SubjectIDs.Release} {
+		fields := subjectIDFields{sourceFields: example, Commit: "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2", Ref: "refs/heads/main", ID: 1, Number: 1, URL: "https://git.example.com/owner/repo/pulls/1", Tag: "v1.0.0"}
+		if _, err := c.subjectID(text, "", fields); err != nil {
+			return err
+		}
+	}
+
+	if c.CustomDataSchemas && c.CustomData != "" && c.CustomData != CustomDataFull {
+		return fmt.Errorf("custom data schemas require the %s custom data policy", CustomDataFull)
+	}
+
+	switch c.CustomData {
+	case "", CustomDataFull, CustomDataNone:
+		return nil
+	case CustomDataFields:
+		if len(c.CustomDataFields) == 0 {
+			return fmt.Errorf("custom data policy %s requires at least one field", c.CustomData)
+		}
+		_, err := c.fieldPaths()
+		return err
+	default:
+		return fmt.Errorf("unknown custom data policy: %s", c.CustomData)
+	}
+}
+
+// compile returns the config with its source and subject id templates and custom data field
+// paths parsed. Templates and paths that fail to parse are left out, to be reported when they are
+// used as they are by Validate.
+func (c GiteaConfig) compile() GiteaConfig {
+	c.templates = map[templateKey]*template.Template{}
+	parse := func(name, text string) {
+		if text == "" {
+			return
+		}
+		if tmpl, err := parseTemplate(name, text); err == nil {
+			c.templates[templateKey{name: name, text: text}] = tmpl
+		}
+	}
+	parse("source", c.SourceTemplate)
+	for _, text := range []string{c.SubjectIDs.Push, c.SubjectIDs.PullRequest, c.SubjectIDs.Create, c.SubjectIDs.Delete, c.SubjectIDs.Release} {
+		parse("subject id", text)
+	}
+	if paths, err := c.fieldPaths(); err == nil {
+		c.paths = paths
+	}
+	return c
+}
+
+// fieldPaths returns the parsed CustomDataFields, parsing them only if they were not parsed by
+// the constructor of the translator.
+func (c GiteaConfig) fieldPaths() ([]jsonpath.Path, error) {
+	if c.paths != nil {
+		return c.paths, nil
+	}
+	paths := make([]jsonpath.Path, 0, len(c.CustomDataFields))
+	for _, expression := range c.CustomDataFields {
+		path, err := jsonpath.Parse(expression)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// source renders the source of an event from the repository fields. The result must be a
+// non-empty URI reference, as required by the CDEvents spec.
+func (c GiteaConfig) source(fields sourceFields) (string, error) {
+	if c.SourceTemplate == "" {
+		return fields.Host, nil
+	}
+
+	source, err := c.render("source", c.SourceTemplate, fields)
+	if err != nil {
+		return "", err
+	}
+	if source == "" {
+		return "", fmt.Errorf("source template rendered an empty source")
+	}
+	if _, err := url.Parse(source); err != nil {
+		return "", fmt.Errorf("source template rendered an invalid source: %w", err)
+	}
+	return source, nil
+}
+
+// subjectID renders the subject id of an event with the template, or returns the default
+// subject id if there is no template.
+func (c GiteaConfig) subjectID(text, defaultID string, fields subjectIDFields) (string, error) {
+	if text == "" {
+		return defaultID, nil
+	}
+
+	id, err := c.render("subject id", text, fields)
+	if err != nil {
+		return "", err
+	}
+	if id == "" {
+		return "", fmt.Errorf("subject id template rendered an empty subject id")
+	}
+	return id, nil
+}
+
+// render executes the template with the data, parsing it only if it was not parsed by the
+// constructor of the translator.
+func (c GiteaConfig) render(name, text string, data interface{}) (string, error) {
+	tmpl, found := c.templates[templateKey{name: name, text: text}]
+	if !found {
+		var err error
+		if tmpl, err = parseTemplate(name, text); err != nil {
+			return "", err
+		}
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return sb.String(), nil
+}
+
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// isMainBranch reports whether a push to the branch is a merged change. Without main branches
+// every branch is, unless the API is configured to look up the pull requests of other branches,
+// in which case only the default branch of the repository is. An unknown default branch is then
+// no main branch.
+func (c GiteaConfig) isMainBranch(branch, defaultBranch string) bool {
+	if len(c.MainBranches) > 0 {
+		return glob.MatchAny(c.MainBranches, branch)
+	}
+	return c.API.URL == "" || (defaultBranch != "" && branch == defaultBranch)
+}
+
+func (c GiteaConfig) isIncludedRef(ref string) bool {
+	if glob.MatchAny(c.ExcludeRefs, ref) {
+		return false
+	}
+	return len(c.IncludeRefs) == 0 || glob.MatchAny(c.IncludeRefs, ref)
+}
+
+// setTimestamp sets the timestamp of the event to the first of the payload timestamps that is an
+// RFC 3339 time, if provider timestamps are enabled.
+func (c GiteaConfig) setTimestamp(cdEvent cdevents.CDEvent, timestamps ...string) {
+	if !c.ProviderTimestamps {
+		return
+	}
+	for _, timestamp := range timestamps {
+		if parsed, err := time.Parse(time.RFC3339, timestamp); err == nil {
+			cdEvent.SetTimestamp(parsed.UTC())
+			return
+		}
+	}
+}
+
+// releaseTag returns the name of the tag of the full ref, and whether it is a release tag.
+func (c GiteaConfig) releaseTag(ref string) (string, bool) {
+	tag, isTag := strings.CutPrefix(ref, "refs/tags/")
+	return tag, isTag && glob.MatchAny(c.ReleaseTags, tag)
+}
+
+// newReleaseEvent returns the ArtifactPublished event of the release marked by the tag in the
+// repository of the Gitea event.
+func (c GiteaConfig) newReleaseEvent(giteaEvent interface{}, tag string, fields subjectIDFields, truncated map[string]int) (cdevents.CDEvent, error) {
+	releaseEvent, err := cdeventsv04.NewArtifactPublishedEvent()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := addSourcesFromRepositoryUrl(c, giteaEvent, releaseEvent); err != nil {
+		return nil, err
+	}
+	fields.Tag = tag
+	if err := c.setSubjectID(c.SubjectIDs.Release, fmt.Sprintf("pkg:generic/%s@%s", giteaRepository(giteaEvent).FullName, tag), giteaEvent, fields, releaseEvent); err != nil {
+		return nil, err
+	}
+
+	if err := addGiteaEventAsCustomData(c, giteaEvent, releaseEvent, truncated); err != nil {
+		return nil, err
+	}
+
+	return releaseEvent, nil
+}
+
+// fullRef returns the full ref of the short ref name in Gitea create and delete events.
+func fullRef(refType, ref string) string {
+	switch refType {
+	case "branch":
+		return "refs/heads/" + ref
+	case "tag":
+		return "refs/tags/" + ref
+	default:
+		return ref
+	}
+}
+
+// giteaRepositoryFields are the repository fields read by every Gitea translator.
+var giteaRepositoryFields = []string{"$.repository.full_name", "$.repository.html_url"}
+
+type GiteaPushTranslator struct {
+	config GiteaConfig
+	api    *giteaAPI
+}
+
+func NewGiteaPushTranslator(config GiteaConfig) *GiteaPushTranslator {
+	return newGiteaPushTranslator(config, newGiteaAPI(config.API))
+}
+
+func newGiteaPushTranslator(config GiteaConfig, api *giteaAPI) *GiteaPushTranslator {
+	return &GiteaPushTranslator{config: config.compile(), api: api}
+}
+
+// SetSecretResolver sets a resolver for the Gitea API token, which is then resolved on every
+// request so that renewed tokens are picked up.
+func (g *GiteaPushTranslator) SetSecretResolver(secrets SecretResolver) {
+	g.api.setSecretResolver(secrets)
+}
+
+func (g *GiteaPushTranslator) DependentFields() []string {
+	return append([]string{"$.ref", "$.total_commits", "$.commits[*].id"}, giteaRepositoryFields...)
+}
+
+func (g *GiteaPushTranslator) Translate(data []byte) (cdevents.CDEvent, error) {
+	events, err := g.TranslateAll(data)
+	if err != nil {
+		return nil, err
+	}
+	return events[0], nil
+}
+
+// TranslateAll translates a push to a ChangeMerged or ChangeUpdated event, followed by the
+// ChangeMerged events of the other commits with CommitEvents and the ArtifactPublished event of
+// the release if a release tag is pushed. The push of a release tag without new commits is only
+// translated to the release.
+func (g *GiteaPushTranslator) TranslateAll(data []byte) ([]cdevents.CDEvent, error) {
+
+	var giteaEvent structs.GiteaPushEvent
+	if err := decode(data, &giteaEvent, g.config.MaxPayloadSize); err != nil {
+		return nil, err
+	}
+
+	if !g.config.isIncludedRef(giteaEvent.Ref) {
+		return nil, Skip("push to ref %s that is not included", giteaEvent.Ref)
+	}
+
+	enricher := g.api.enricher(GiteaEnrichPush)
+	enricher.enrichRepository(&giteaEvent.Repository)
+
+	// Without main branches the default branch tells merged changes apart from pushes to pull
+	// requests, so a push is not translated without it rather than taken for a merge.
+	if g.api != nil && len(g.config.MainBranches) == 0 && giteaEvent.Repository.DefaultBranch == "" && !strings.HasPrefix(giteaEvent.Ref, "refs/tags/") {
+		defaultBranch, err := g.api.defaultBranch(giteaEvent.Repository)
+		if err != nil {
+			return nil, err
+		}
+		giteaEvent.Repository.DefaultBranch = defaultBranch
+	}
+
+	// The open pull request of a push to a branch that is not a main branch, if there is one.
+	var pull *giteaPullRequest
+	if strings.HasPrefix(giteaEvent.Ref, "refs/tags/") {
+		if g.config.IgnoreTags {
+			return nil, Skip("push of tag %s", giteaEvent.Ref)
+		}
+	} else if branch := strings.TrimPrefix(giteaEvent.Ref, "refs/heads/"); !g.config.isMainBranch(branch, giteaEvent.Repository.DefaultBranch) {
+		if g.api == nil {
+			return nil, Skip("push to branch %s that is not a main branch", branch)
+		}
+		open, found, err := g.api.openPullRequest(giteaEvent.Repository, branch)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, Skip("push to branch %s without an open pull request", branch)
+		}
+		pull = &open
+	}
+
+	tag, release := g.config.releaseTag(giteaEvent.Ref)
+
+	if giteaEvent.TotalCommits == 0 {
+		if release {
+			releaseEvent, err := g.config.newReleaseEvent(giteaEvent, tag, subjectIDFields{Commit: giteaEvent.After, Ref: giteaEvent.Ref}, nil)
+			if err != nil {
+				return nil, err
+			}
+			return []cdevents.CDEvent{releaseEvent}, nil
+		}
+		return nil, fmt.Errorf("Push event contains no new commits, will not convert to a CD Event")
+	}
+
+	enricher.enrichVerification(giteaEvent.Repository, &giteaEvent.HeadCommit)
+
+	var cdEvent cdevents.CDEvent
+	if pull != nil {
+		changeUpdatedEvent, err := cdeventsv04.NewChangeUpdatedEvent()
+		if err != nil {
+			return nil, err
+		}
+		changeUpdatedEvent.SetSubjectRepository(&cdevents.Reference{Id: giteaEvent.Repository.FullName})
+		cdEvent = changeUpdatedEvent
+	} else {
+		changeMergedEvent, err := cdeventsv04.NewChangeMergedEvent()
+		if err != nil {
+			return nil, err
+		}
+		changeMergedEvent.SetSubjectRepository(&cdevents.Reference{Id: giteaEvent.Repository.FullName})
+		cdEvent = changeMergedEvent
+	}
+
+	if err := addSourcesFromRepositoryUrl(g.config, giteaEvent, cdEvent); err != nil {
+		return nil, err
+	}
+	fields := subjectIDFields{Commit: giteaEvent.Commits[0].Id, Ref: giteaEvent.Ref}
+	if pull != nil {
+		// The subject of a ChangeUpdated event is the pull request, as in the pull request events.
+		fields.ID, fields.Number, fields.URL = pull.ID, pull.Number, pull.HtmlUrl
+		if err := g.config.setSubjectID(g.config.SubjectIDs.PullRequest, fmt.Sprintf("pr-%d", pull.ID), giteaEvent, fields, cdEvent); err != nil {
+			return nil, err
+		}
+	} else if err := g.config.setSubjectID(g.config.SubjectIDs.Push, giteaEvent.Commits[0].Id, giteaEvent, fields, cdEvent); err != nil {
+		return nil, err
+	}
+	g.config.setTimestamp(cdEvent, giteaEvent.HeadCommit.Timestamp, giteaEvent.Commits[0].Timestamp)
+
+	commits := giteaEvent.Commits
+
+	var truncated map[string]int
+	if g.config.OmitCommits {
+		giteaEvent.Commits = nil
+	} else if n := g.config.MaxCommits; n > 0 && len(giteaEvent.Commits) > n {
+		truncated = map[string]int{"commits": len(giteaEvent.Commits) - n}
+		giteaEvent.Commits = giteaEvent.Commits[:n]
+	}
+
+	if err := addGiteaEventAsCustomData(g.config, giteaEvent, cdEvent, truncated); err != nil {
+		return nil, err
+	}
+
+	events := []cdevents.CDEvent{cdEvent}
+
+	if pull == nil && g.config.CommitEvents {
+		if n := g.config.MaxCommitEvents; n > 0 && len(commits) > n {
+			commits = commits[:n]
+		}
+		for i := 1; i < len(commits); i++ {
+			commitEvent, err := g.newCommitEvent(giteaEvent, commits[i:i+1])
+			if err != nil {
+				return nil, err
+			}
+			events = append(events, commitEvent)
+		}
+	}
+
+	if !release {
+		return events, nil
+	}
+	releaseEvent, err := g.config.newReleaseEvent(giteaEvent, tag, subjectIDFields{Commit: giteaEvent.After, Ref: giteaEvent.Ref}, truncated)
+	if err != nil {
+		return nil, err
+	}
+	g.config.setTimestamp(releaseEvent, giteaEvent.HeadCommit.Timestamp)
+
+	return append(events, releaseEvent), nil
+}
+
+// newCommitEvent returns the ChangeMerged event of a single commit of a push, whose custom data
+// only has that commit.
+func (g *GiteaPushTranslator) newCommitEvent(giteaEvent structs.GiteaPushEvent, commit []structs.Commit) (cdevents.CDEvent, error) {
+	commitEvent, err := cdeventsv04.NewChangeMergedEvent()
+	if err != nil {
+		return nil, err
+	}
+	commitEvent.SetSubjectRepository(&cdevents.Reference{Id: giteaEvent.Repository.FullName})
+
+	if err := addSourcesFromRepositoryUrl(g.config, giteaEvent, commitEvent); err != nil {
+		return nil, err
+	}
+	if err := g.config.setSubjectID(g.config.SubjectIDs.Push, commit[0].Id, giteaEvent, subjectIDFields{Commit: commit[0].Id, Ref: giteaEvent.Ref}, commitEvent); err != nil {
+		return nil, err
+	}
+	g.config.setTimestamp(commitEvent, commit[0].Timestamp)
+
+	if !g.config.OmitCommits {
+		giteaEvent.Commits = commit
+	}
+	if err := addGiteaEventAsCustomData(g.config, giteaEvent, commitEvent, nil); err != nil {
+		return nil, err
+	}
+
+	return commitEvent, nil
+}
+
+type GiteaPullRequestTranslator struct {
+	config GiteaConfig
+	api    *giteaAPI
+}
+
+func NewGiteaPullRequestTranslator(config GiteaConfig) *GiteaPullRequestTranslator {
+	return newGiteaPullRequestTranslator(config, newGiteaAPI(config.API))
+}
+
+func newGiteaPullRequestTranslator(config GiteaConfig, api *giteaAPI) *GiteaPullRequestTranslator {
+	return &GiteaPullRequestTranslator{config: config.compile(), api: api}
+}
+
+// SetSecretResolver sets a resolver for the Gitea API token, which is then resolved on every
+// request so that renewed tokens are picked up.
+func (g *GiteaPullRequestTranslator) SetSecretResolver(secrets SecretResolver) {
+	g.api.setSecretResolver(secrets)
+}
+
+func (g *GiteaPullRequestTranslator) DependentFields() []string {
+	return append([]string{"$.action", "$.pull_request.id"}, giteaRepositoryFields...)
+}
+
+func (g *GiteaPullRequestTranslator) Translate(data []byte) (cdevents.CDEvent, error) {
+
+	var giteaEvent structs.GiteaPullRequestEvent
+	if err := decode(data, &giteaEvent, g.config.MaxPayloadSize); err != nil {
+		return nil, err
+	}
+
+	enricher := g.api.enricher(GiteaEnrichPullRequest)
+	enricher.enrichRepository(&giteaEvent.Repository)
+	if giteaEvent.Action == "closed" {
+		enricher.enrichMergedBy(giteaEvent.Repository, giteaEvent.Number, &giteaEvent.PullRequest)
+	}
+
+	var cdEvent cdevents.CDEvent
+
+	switch giteaEvent.Action {
+	case "opened":
+		changeCreatedEvent, err := cdeventsv04.NewChangeCreatedEvent()
+		if err != nil {
+			return nil, err
+		}
+		changeCreatedEvent.SetSubjectRepository(&cdevents.Reference{Id: giteaEvent.Repository.FullName})
+		cdEvent = changeCreatedEvent
+		g.config.setTimestamp(cdEvent, giteaEvent.PullRequest.CreatedAt)
+	case "closed":
+		changeMergedEvent, err := cdeventsv04.NewChangeMergedEvent()
+		if err != nil {
+			return nil, err
+		}
+		changeMergedEvent.SetSubjectRepository(&cdevents.Reference{Id: giteaEvent.Repository.FullName})
+		cdEvent = changeMergedEvent
+		g.config.setTimestamp(cdEvent, giteaEvent.PullRequest.ClosedAt, giteaEvent.PullRequest.UpdatedAt)
+	default:
+		return nil, fmt.Errorf("unsupported Gitea Pull Request action: %s", giteaEvent.Action)
+	}
+
+	if err := addSourcesFromRepositoryUrl(g.config, giteaEvent, cdEvent); err != nil {
+		return nil, err
+	}
+	fields := subjectIDFields{ID: giteaEvent.PullRequest.Id, Number: giteaEvent.Number, URL: giteaEvent.PullRequest.HtmlUrl}
+	if err := g.config.setSubjectID(g.config.SubjectIDs.PullRequest, fmt.Sprintf("pr-%d", giteaEvent.PullRequest.Id), giteaEvent, fields, cdEvent); err != nil {
+		return nil, err
+	}
+
+	if err := addGiteaEventAsCustomData(g.config, giteaEvent, cdEvent, nil); err != nil {
+		return nil, err
+	}
+
+	return cdEvent, nil
+}
+
+type GiteaCreateTranslator struct {
+	config GiteaConfig
+	api    *giteaAPI
+}
+
+func NewGiteaCreateTranslator(config GiteaConfig) *GiteaCreateTranslator {
+	return newGiteaCreateTranslator(config, newGiteaAPI(config.API))
+}
+
+func newGiteaCreateTranslator(config GiteaConfig, api *giteaAPI) *GiteaCreateTranslator {
+	return &GiteaCreateTranslator{config: config.compile(), api: api}
+}
+
+// SetSecretResolver sets a resolver for the Gitea API token, which is then resolved on every
+// request so that renewed tokens are picked up.
+func (g *GiteaCreateTranslator) SetSecretResolver(secrets SecretResolver) {
+	g.api.setSecretResolver(secrets)
+}
+
+func (g *GiteaCreateTranslator) DependentFields() []string {
+	return append([]string{"$.ref", "$.ref_type"}, giteaRepositoryFields...)
+}
+
+func (g *GiteaCreateTranslator) Translate(data []byte) (cdevents.CDEvent, error) {
+
+	var giteaEvent structs.GiteaCreateEvent
+	if err := decode(data, &giteaEvent, g.config.MaxPayloadSize); err != nil {
+		return nil, err
+	}
+
+	if giteaEvent.RefType == "tag" && g.config.IgnoreTags {
+		return nil, Skip("creation of tag %s", giteaEvent.Ref)
+	}
+	if ref := fullRef(giteaEvent.RefType, giteaEvent.Ref); !g.config.isIncludedRef(ref) {
+		return nil, Skip("creation of ref %s that is not included", ref)
+	}
+
+	g.api.enricher(GiteaEnrichCreate).enrichRepository(&giteaEvent.Repository)
+
+	if tag, release := g.config.releaseTag(fullRef(giteaEvent.RefType, giteaEvent.Ref)); release {
+		return g.config.newReleaseEvent(giteaEvent, tag, subjectIDFields{Commit: giteaEvent.Sha, Ref: giteaEvent.Ref}, nil)
+	}
+
+	var cdEvent cdevents.CDEvent
+
+	switch giteaEvent.RefType {
+	case "branch":
+		branchCreatedEvent, err := cdeventsv04.NewBranchCreatedEvent()
+		if err != nil {
+			return nil, err
+		}
+		branchCreatedEvent.SetSubjectRepository(&cdevents.Reference{Id: giteaEvent.Repository.FullName})
+		cdEvent = branchCreatedEvent
+	default:
+		return nil, fmt.Errorf("unsupported Gitea create ref type: %s", giteaEvent.RefType)
+	}
+
+	if err := addSourcesFromRepositoryUrl(g.config, giteaEvent, cdEvent); err != nil {
+		return nil, err
+	}
+	if err := g.config.setSubjectID(g.config.SubjectIDs.Create, giteaEvent.Ref, giteaEvent, subjectIDFields{Ref: giteaEvent.Ref, Commit: giteaEvent.Sha}, cdEvent); err != nil {
+		return nil, err
+	}
+
+	if err := addGiteaEventAsCustomData(g.config, giteaEvent, cdEvent, nil); err != nil {
+		return nil, err
+	}
+
+	return cdEvent, nil
+}
+
+type GiteaDeleteTranslator struct {
+	config GiteaConfig
+	api    *giteaAPI
+}
+
+func NewGiteaDeleteTranslator(config GiteaConfig) *GiteaDeleteTranslator {
+	return newGiteaDeleteTranslator(config, newGiteaAPI(config.API))
+}
+
+func newGiteaDeleteTranslator(config GiteaConfig, api *giteaAPI) *GiteaDeleteTranslator {
+	return &GiteaDeleteTranslator{config: config.compile(), api: api}
+}
+
+// SetSecretResolver sets a resolver for the Gitea API token, which is then resolved on every
+// request so that renewed tokens are picked up.
+func (g *GiteaDeleteTranslator) SetSecretResolver(secrets SecretResolver) {
+	g.api.setSecretResolver(secrets)
+}
+
+func (g *GiteaDeleteTranslator) DependentFields() []string {
+	return append([]string{"$.ref", "$.ref_type"}, giteaRepositoryFields...)
+}
+
+func (g *GiteaDeleteTranslator) Translate(data []byte) (cdevents.CDEvent, error) {
+
+	var giteaEvent structs.GiteaDeleteEvent
+	if err := decode(data, &giteaEvent, g.config.MaxPayloadSize); err != nil {
+		return nil, err
+	}
+
+	if giteaEvent.RefType == "tag" && g.config.IgnoreTags {
+		return nil, Skip("deletion of tag %s", giteaEvent.Ref)
+	}
+	if ref := fullRef(giteaEvent.RefType, giteaEvent.Ref); !g.config.isIncludedRef(ref) {
+		return nil, Skip("deletion of ref %s that is not included", ref)
+	}
+
+	g.api.enricher(GiteaEnrichDelete).enrichRepository(&giteaEvent.Repository)
+
+	var cdEvent cdevents.CDEvent
+
+	switch giteaEvent.RefType {
+	case "branch":
+		branchDeletedEvent, err := cdeventsv04.NewBranchDeletedEvent()
+		if err != nil {
+			return nil, err
+		}
+		branchDeletedEvent.SetSubjectRepository(&cdevents.Reference{Id: giteaEvent.Repository.FullName})
+		cdEvent = branchDeletedEvent
+	default:
+		return nil, fmt.Errorf("unsupported Gitea create ref type: %s", giteaEvent.RefType)
+	}
+
+	if err := addSourcesFromRepositoryUrl(g.config, giteaEvent, cdEvent); err != nil {
+		return nil, err
+	}
+	if err := g.config.setSubjectID(g.config.SubjectIDs.Delete, giteaEvent.Ref, giteaEvent, subjectIDFields{Ref: giteaEvent.Ref}, cdEvent); err != nil {
+		return nil, err
+	}
+
+	if err := addGiteaEventAsCustomData(g.config, giteaEvent, cdEvent, nil); err != nil {
+		return nil, err
+	}
+
+	return cdEvent, nil
+}
+
+// giteaCustomData is the custom data of events translated from Gitea payloads. Truncated marks
+// what was left out to bound the size of the event, e.g. the number of commits, or the size in
+// bytes of the content if it was left out entirely. Commit is the merge commit of a merged pull
+// request, which the events of the push of the merge are linked by.
+type giteaCustomData struct {
+	Kind      string
+	Content   interface{}    `json:",omitempty"`
+	Truncated map[string]int `json:",omitempty"`
+	Actor     *Identity      `json:"actor,omitempty"`
+	Commit    string         `json:"commit,omitempty"`
+}
+
+func addGiteaEventAsCustomData(config GiteaConfig, giteaEvent interface{}, cdEvent cdevents.CDEvent, truncated map[string]int) error {
+	customData := giteaCustomData{
+		Kind:      fmt.Sprintf("%T", giteaEvent),
+		Content:   giteaEvent,
+		Truncated: truncated,
+		Actor:     giteaActor(giteaEvent),
+	}
+	if pullRequest, ok := giteaEvent.(structs.GiteaPullRequestEvent); ok && pullRequest.Action == "closed" {
+		customData.Commit = pullRequest.PullRequest.MergeCommitSha
+	}
+
+	switch config.CustomData {
+	case CustomDataNone:
+		return nil
+	case CustomDataFields:
+		paths, err := config.fieldPaths()
+		if err != nil {
+			return err
+		}
+		if customData.Content, err = selectFields(giteaEvent, paths); err != nil {
+			return err
+		}
+	}
+	if config.MaxCustomDataSize > 0 {
+		data, err := json.Marshal(customData)
+		if err != nil {
+			return err
+		}
+		if len(data) > config.MaxCustomDataSize {
+			customData.Content = nil
+			customData.Truncated = map[string]int{"bytes": len(data)}
+		}
+	}
+	if err := cdEvent.SetCustomData("application/json", customData); err != nil {
+		return err
+	}
+	if config.CustomDataSchemas && config.CustomData != CustomDataFields {
+		setSchemaURI(cdEvent, giteaSchemaURI(giteaEvent))
+	}
+	return nil
+}
+
+// giteaSchemaURI returns the published schema of events with the Gitea payload as custom data.
+func giteaSchemaURI(giteaEvent interface{}) string {
+	switch giteaEvent.(type) {
+	case structs.GiteaCreateEvent:
+		return SchemaBaseURI + "gitea-create.json"
+	case structs.GiteaDeleteEvent:
+		return SchemaBaseURI + "gitea-delete.json"
+	case structs.GiteaPushEvent:
+		return SchemaBaseURI + "gitea-push.json"
+	case structs.GiteaPullRequestEvent:
+		return SchemaBaseURI + "gitea-pull_request.json"
+	default:
+		return ""
+	}
+}
+
+func addSourcesFromRepositoryUrl(config GiteaConfig, giteaEvent interface{}, cdEvent cdevents.CDEvent) error {
+
+	repository := giteaRepository(giteaEvent)
+
+	repoUrl, err := url.Parse(repository.HtmlUrl)
+	if err != nil {
+		return err
+	}
+
+	source, err := config.source(repositoryFields(repository, repoUrl))
+	if err != nil {
+		return err
+	}
+	cdEvent.SetSource(source)
+
+	subjectSource, err := url.JoinPath(repoUrl.Host, repoUrl.Path)
+	if err != nil {
+		return err
+	}
+
+	cdEvent.SetSubjectSource(subjectSource)
+
+	return nil
+}
+
+func giteaRepository(giteaEvent interface{}) structs.Repository {
+	switch v := giteaEvent.(type) {
+	case structs.GiteaCreateEvent:
+		return v.Repository
+	case structs.GiteaDeleteEvent:
+		return v.Repository
+	case structs.GiteaPushEvent:
+		return v.Repository
+	case structs.GiteaPullRequestEvent:
+		return v.Repository
+	default:
+		panic(fmt.Sprintf("failed to extract repository URL from Gitea event with type: %T", giteaEvent))
+	}
+}
+
+func repositoryFields(repository structs.Repository, repoUrl *url.URL) sourceFields {
+	return sourceFields{
+		Provider: "gitea",
+		Host:     repoUrl.Host,
+		Owner:    repository.Owner.Username,
+		Name:     repository.Name,
+		FullName: repository.FullName,
+	}
+}
+
+// setSubjectID sets the subject id rendered with the template, or the default subject id.
+func (c GiteaConfig) setSubjectID(text, defaultID string, giteaEvent interface{}, fields subjectIDFields, cdEvent cdevents.CDEvent) error {
+	if text != "" {
+		repository := giteaRepository(giteaEvent)
+		repoUrl, err := url.Parse(repository.HtmlUrl)
+		if err != nil {
+			return err
+		}
+		fields.sourceFields = repositoryFields(repository, repoUrl)
+	}
+
+	id, err := c.subjectID(text, defaultID, fields)
+	if err != nil {
+		return err
+	}
+	cdEvent.SetSubjectId(id)
+	return nil
+}
@@ -0,0 +1,377 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/lru"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/metrics"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/structs"
+)
+
+// Gitea webhook events whose CDEvents can be enriched with data from the Gitea API.
+const (
+	GiteaEnrichPush        = "push"
+	GiteaEnrichPullRequest = "pull_request"
+	GiteaEnrichCreate      = "create"
+	GiteaEnrichDelete      = "delete"
+)
+
+// GiteaAPIConfig configures the Gitea API, which the push translator asks for the open pull
+// request of a branch and which enriches events with data missing from webhooks.
+type GiteaAPIConfig struct {
+	// URL is the base URL of the Gitea instance, e.g. "https://git.example.com". Empty disables
+	// the lookups.
+	URL string `envconfig:"URL"`
+	// Token is an access token with read access to the repositories. It may reference a secret,
+	// which is resolved on every request when a secret resolver is set.
+	Token string `envconfig:"TOKEN" secret:"true"`
+	// Timeout bounds every request to the API, and the lookup of the open pull request of a
+	// branch as a whole.
+	Timeout time.Duration `envconfig:"TIMEOUT" default:"5s"`
+	// PullRequestPages is the largest number of pages of open pull requests, most recently
+	// updated first, that are searched for the pull request of a branch.
+	PullRequestPages int `envconfig:"PULL_REQUEST_PAGES" default:"4"`
+	// Enrich lists the webhook events whose CDEvents are enriched with the default branch of the
+	// repository, the user who merged a pull request and the verification of the head commit of a
+	// push, when the webhook does not have them: "push", "pull_request", "create" and "delete".
+	Enrich []string `envconfig:"ENRICH"`
+	// CacheTTL is how long the responses used for enrichment are cached.
+	CacheTTL time.Duration `envconfig:"CACHE_TTL" default:"5m"`
+	// CacheSize is the largest number of cached responses.
+	CacheSize int `envconfig:"CACHE_SIZE" default:"1000"`
+	// Backoff is how long the API is not called after it failed to respond, so that translation is
+	// not held up by timeouts while it is down. Events are then not enriched.
+	Backoff time.Duration `envconfig:"BACKOFF" default:"30s"`
+}
+
+// Validate checks the base URL and the enriched events.
+func (c GiteaAPIConfig) Validate() error {
+	for _, event := range c.Enrich {
+		switch strings.TrimSpace(event) {
+		case GiteaEnrichPush, GiteaEnrichPullRequest, GiteaEnrichCreate, GiteaEnrichDelete:
+		default:
+			return fmt.Errorf("unknown Gitea event to enrich: %s", event)
+		}
+	}
+	if c.URL == "" {
+		if len(c.Enrich) > 0 {
+			return fmt.Errorf("enriching Gitea events requires a Gitea API URL")
+		}
+		return nil
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return fmt.Errorf("invalid Gitea API URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid Gitea API URL %s: must be an absolute http(s) URL", c.URL)
+	}
+	return nil
+}
+
+func (c GiteaAPIConfig) enriches(event string) bool {
+	for _, enriched := range c.Enrich {
+		if strings.TrimSpace(enriched) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// giteaPullRequestsPageSize is the number of pull requests listed per request, which is the
+// largest page Gitea returns by default.
+const giteaPullRequestsPageSize = 50
+
+// giteaAPIMaxResponseSize bounds the responses of the API that are read, which is far more than
+// a page of pull requests takes.
+const giteaAPIMaxResponseSize = 10 << 20
+
+// defaultGiteaPullRequestPages is the number of pages of open pull requests that are searched
+// when none is configured.
+const defaultGiteaPullRequestPages = 4
+
+// errGiteaAPIUnavailable is returned without calling the API while backing off.
+var errGiteaAPIUnavailable = errors.New("gitea API is unavailable")
+
+// giteaPullRequest is a pull request as listed by the Gitea API.
+type giteaPullRequest struct {
+	ID      int    `json:"id"`
+	Number  int    `json:"number"`
+	HtmlUrl string `json:"html_url"`
+	Head    struct {
+		Ref  string `json:"ref"`
+		Repo *struct {
+			FullName string `json:"full_name"`
+		} `json:"repo"`
+	} `json:"head"`
+}
+
+type giteaAPI struct {
+	client  *http.Client
+	config  GiteaAPIConfig
+	cache   *giteaAPICache
+	secrets SecretResolver
+
+	mu               sync.Mutex
+	unavailableUntil time.Time
+}
+
+func newGiteaAPI(config GiteaAPIConfig) *giteaAPI {
+	if config.URL == "" {
+		return nil
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.PullRequestPages <= 0 {
+		config.PullRequestPages = defaultGiteaPullRequestPages
+	}
+	return &giteaAPI{
+		client: &http.Client{Timeout: config.Timeout},
+		config: config,
+		cache:  newGiteaAPICache(config.CacheSize, config.CacheTTL),
+	}
+}
+
+func (a *giteaAPI) setSecretResolver(secrets SecretResolver) {
+	if a != nil {
+		a.secrets = secrets
+	}
+}
+
+// enricher returns the API if events of the webhook event are enriched.
+func (a *giteaAPI) enricher(event string) *giteaAPI {
+	if a == nil || !a.config.enriches(event) {
+		return nil
+	}
+	return a
+}
+
+// openPullRequest returns the open pull request of the repository from the branch of the same
+// repository, if there is one. Pull requests from forks are not pushed to by the webhook. The API
+// cannot filter pull requests by branch, so only the most recently updated ones are searched, up
+// to a number of pages and within the timeout. The pull request of a branch that was just pushed
+// to is updated by the push, so it is among the first.
+func (a *giteaAPI) openPullRequest(repository structs.Repository, branch string) (giteaPullRequest, bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), a.config.Timeout)
+	defer cancel()
+
+	for page := 1; page <= a.config.PullRequestPages; page++ {
+		var pulls []giteaPullRequest
+		path := fmt.Sprintf("%s/pulls?state=open&sort=recentupdate&page=%d&limit=%d", repositoryPath(repository), page, giteaPullRequestsPageSize)
+		if err := a.get(ctx, path, &pulls); err != nil {
+			return giteaPullRequest{}, false, fmt.Errorf("failed to list open pull requests of %s: %w", repository.FullName, err)
+		}
+		for _, pull := range pulls {
+			if pull.Head.Ref == branch && pull.Head.Repo != nil && pull.Head.Repo.FullName == repository.FullName {
+				return pull, true, nil
+			}
+		}
+		if len(pulls) < giteaPullRequestsPageSize {
+			return giteaPullRequest{}, false, nil
+		}
+	}
+
+	return giteaPullRequest{}, false, nil
+}
+
+// defaultBranch returns the default branch of the repository.
+func (a *giteaAPI) defaultBranch(repository structs.Repository) (string, error) {
+	var repo struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := a.getCached(repositoryPath(repository), &repo); err != nil {
+		return "", fmt.Errorf("failed to get the default branch of %s: %w", repository.FullName, err)
+	}
+	if repo.DefaultBranch == "" {
+		return "", fmt.Errorf("repository %s has no default branch", repository.FullName)
+	}
+	return repo.DefaultBranch, nil
+}
+
+// enrichRepository sets the default branch of the repository if the webhook has none.
+func (a *giteaAPI) enrichRepository(repository *structs.Repository) {
+	if a == nil || repository.DefaultBranch != "" || repository.FullName == "" {
+		return
+	}
+	if branch, err := a.defaultBranch(*repository); err == nil {
+		repository.DefaultBranch = branch
+	}
+}
+
+// enrichMergedBy sets the user who merged the pull request with the number if the webhook has
+// none.
+func (a *giteaAPI) enrichMergedBy(repository structs.Repository, number int, pullRequest *structs.PullRequest) {
+	if a == nil || pullRequest.MergedBy != nil || number == 0 {
+		return
+	}
+	var pull struct {
+		MergedBy *structs.User `json:"merged_by"`
+	}
+	if a.getCached(fmt.Sprintf("%s/pulls/%d", repositoryPath(repository), number), &pull) == nil {
+		pullRequest.MergedBy = pull.MergedBy
+	}
+}
+
+// enrichVerification sets the signature verification of the commit if the webhook has none.
+func (a *giteaAPI) enrichVerification(repository structs.Repository, commit *structs.Commit) {
+	if a == nil || commit.Verification != nil || commit.Id == "" {
+		return
+	}
+	var repoCommit struct {
+		Commit struct {
+			Verification *structs.Verification `json:"verification"`
+		} `json:"commit"`
+	}
+	if a.getCached(fmt.Sprintf("%s/git/commits/%s", repositoryPath(repository), url.PathEscape(commit.Id)), &repoCommit) == nil {
+		commit.Verification = repoCommit.Commit.Verification
+	}
+}
+
+func repositoryPath(repository structs.Repository) string {
+	owner, name, _ := strings.Cut(repository.FullName, "/")
+	return fmt.Sprintf("/api/v1/repos/%s/%s", url.PathEscape(owner), url.PathEscape(name))
+}
+
+// getCached decodes the cached response of the path, or gets and caches it.
+func (a *giteaAPI) getCached(path string, v interface{}) error {
+	if body, found := a.cache.get(path); found {
+		metrics.GiteaAPIRequests.WithLabelValues("cached").Inc()
+		return json.Unmarshal(body, v)
+	}
+	body, err := a.getBody(context.Background(), path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return err
+	}
+	a.cache.add(path, body)
+	return nil
+}
+
+func (a *giteaAPI) get(ctx context.Context, path string, v interface{}) error {
+	body, err := a.getBody(ctx, path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// getBody gets the path of the API. Failing to reach the API, or an error of the API itself,
+// makes the API back off.
+func (a *giteaAPI) getBody(ctx context.Context, path string) ([]byte, error) {
+	if a.backingOff() {
+		metrics.GiteaAPIRequests.WithLabelValues("unavailable").Inc()
+		return nil, errGiteaAPIUnavailable
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(a.config.URL, "/")+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if a.config.Token != "" {
+		token := a.config.Token
+		if a.secrets != nil {
+			if token, err = a.secrets.Resolve(ctx, token); err != nil {
+				metrics.GiteaAPIRequests.WithLabelValues("error").Inc()
+				return nil, fmt.Errorf("failed to resolve Gitea API token: %w", err)
+			}
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("token %s", token))
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		a.backOff()
+		metrics.GiteaAPIRequests.WithLabelValues("error").Inc()
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+			a.backOff()
+		}
+		metrics.GiteaAPIRequests.WithLabelValues("error").Inc()
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, giteaAPIMaxResponseSize+1))
+	if err != nil {
+		metrics.GiteaAPIRequests.WithLabelValues("error").Inc()
+		return nil, err
+	}
+	if len(body) > giteaAPIMaxResponseSize {
+		metrics.GiteaAPIRequests.WithLabelValues("error").Inc()
+		return nil, fmt.Errorf("response larger than %d bytes", giteaAPIMaxResponseSize)
+	}
+	metrics.GiteaAPIRequests.WithLabelValues("ok").Inc()
+	return body, nil
+}
+
+func (a *giteaAPI) backingOff() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return time.Now().Before(a.unavailableUntil)
+}
+
+func (a *giteaAPI) backOff() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.unavailableUntil = time.Now().Add(a.config.Backoff)
+}
+
+// giteaAPICache keeps the most recently used API responses until they expire.
+type giteaAPICache struct {
+	ttl       time.Duration
+	responses *lru.Cache[string, giteaAPIResponse]
+}
+
+type giteaAPIResponse struct {
+	body    []byte
+	expires time.Time
+}
+
+// newGiteaAPICache returns a cache of up to size responses. Responses are not cached if the size
+// or the TTL is not positive.
+func newGiteaAPICache(size int, ttl time.Duration) *giteaAPICache {
+	if size <= 0 || ttl <= 0 {
+		return &giteaAPICache{}
+	}
+	return &giteaAPICache{ttl: ttl, responses: lru.New[string, giteaAPIResponse](size)}
+}
+
+func (c *giteaAPICache) get(key string) ([]byte, bool) {
+	if c.responses == nil {
+		return nil, false
+	}
+
+	response, found := c.responses.Get(key)
+	if !found {
+		return nil, false
+	}
+	if time.Now().After(response.expires) {
+		c.responses.Remove(key)
+		return nil, false
+	}
+	return response.body, true
+}
+
+func (c *giteaAPICache) add(key string, body []byte) {
+	if c.responses == nil {
+		return
+	}
+	c.responses.Add(key, giteaAPIResponse{body: body, expires: time.Now().Add(c.ttl)})
+}
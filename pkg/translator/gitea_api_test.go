@@ -0,0 +1,233 @@
+package translator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/structs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	enrichPushPayload = `{
+		"ref": "refs/heads/main",
+		"commits": [{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2"}],
+		"total_commits": 1,
+		"head_commit": {"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2"},
+		"repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}
+	}`
+	enrichPullRequestPayload = `{
+		"action": "closed",
+		"number": 7,
+		"pull_request": {"id": 3},
+		"repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1", "default_branch": "main"}
+	}`
+)
+
+func newGiteaAPIServer(t *testing.T, requests *atomic.Int32) *httptest.Server {
+	responses := map[string]string{
+		"/api/v1/repos/yoloco/project1":                                                      `{"full_name": "yoloco/project1", "default_branch": "main"}`,
+		"/api/v1/repos/yoloco/project1/pulls/7":                                              `{"id": 3, "number": 7, "merged_by": {"id": 1, "login": "alice", "email": "alice@example.com"}}`,
+		"/api/v1/repos/yoloco/project1/git/commits/9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2": `{"sha": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2", "commit": {"verification": {"verified": true, "reason": "alice / key", "signer": {"name": "Alice", "email": "alice@example.com", "username": "alice"}}}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+		response, found := responses[r.URL.Path]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGiteaAPIEnrichment(t *testing.T) {
+
+	var customData struct {
+		Content struct {
+			Repository struct {
+				DefaultBranch string `json:"default_branch"`
+			} `json:"repository"`
+			HeadCommit struct {
+				Verification *struct {
+					Verified bool `json:"verified"`
+				} `json:"verification"`
+			} `json:"head_commit"`
+			PullRequest struct {
+				MergedBy *struct {
+					Login string `json:"login"`
+				} `json:"merged_by"`
+			} `json:"pull_request"`
+		}
+	}
+
+	t.Run("enriches push", func(t *testing.T) {
+		var requests atomic.Int32
+		server := newGiteaAPIServer(t, &requests)
+		translator := NewGiteaPushTranslator(GiteaConfig{API: GiteaAPIConfig{URL: server.URL, Token: "secret", Enrich: []string{"push"}, CacheTTL: time.Minute, CacheSize: 10}})
+
+		for i := 0; i < 2; i++ {
+			event, err := translator.Translate([]byte(enrichPushPayload))
+			require.NoError(t, err)
+			require.NoError(t, event.GetCustomDataAs(&customData))
+			assert.Equal(t, "main", customData.Content.Repository.DefaultBranch)
+			require.NotNil(t, customData.Content.HeadCommit.Verification)
+			assert.True(t, customData.Content.HeadCommit.Verification.Verified)
+		}
+		assert.Equal(t, int32(2), requests.Load(), "responses should be cached")
+	})
+
+	t.Run("enriches merged pull request", func(t *testing.T) {
+		var requests atomic.Int32
+		server := newGiteaAPIServer(t, &requests)
+		translator := NewGiteaPullRequestTranslator(GiteaConfig{API: GiteaAPIConfig{URL: server.URL, Token: "secret", Enrich: []string{"pull_request"}}})
+
+		event, err := translator.Translate([]byte(enrichPullRequestPayload))
+		require.NoError(t, err)
+		require.NoError(t, event.GetCustomDataAs(&customData))
+		require.NotNil(t, customData.Content.PullRequest.MergedBy)
+		assert.Equal(t, "alice", customData.Content.PullRequest.MergedBy.Login)
+		assert.Equal(t, int32(1), requests.Load(), "default branch in the webhook should not be looked up")
+	})
+
+	t.Run("does not enrich events that are not listed", func(t *testing.T) {
+		var requests atomic.Int32
+		server := newGiteaAPIServer(t, &requests)
+		translator := NewGiteaPushTranslator(GiteaConfig{MainBranches: []string{"main"}, API: GiteaAPIConfig{URL: server.URL, Token: "secret", Enrich: []string{"pull_request"}}})
+
+		_, err := translator.Translate([]byte(enrichPushPayload))
+		require.NoError(t, err)
+		assert.Zero(t, requests.Load())
+	})
+}
+
+func TestGiteaAPIEnrichmentBacksOff(t *testing.T) {
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	translator := NewGiteaPushTranslator(GiteaConfig{MainBranches: []string{"main"}, API: GiteaAPIConfig{URL: server.URL, Enrich: []string{"push"}, Backoff: time.Minute}})
+
+	for i := 0; i < 2; i++ {
+		event, err := translator.Translate([]byte(enrichPushPayload))
+		require.NoError(t, err, "unavailable API should not fail translation")
+		assert.Equal(t, "change", event.GetType().Subject)
+	}
+	assert.Equal(t, int32(1), requests.Load(), "API should not be called while backing off")
+}
+
+func TestGiteaAPIConfigValidate(t *testing.T) {
+
+	for _, tc := range []struct {
+		title       string
+		config      GiteaAPIConfig
+		expectedErr string
+	}{
+		{title: "accepts no API"},
+		{title: "accepts enrichment", config: GiteaAPIConfig{URL: "https://git.example.com", Enrich: []string{"push", "pull_request"}}},
+		{title: "rejects relative URL", config: GiteaAPIConfig{URL: "git.example.com"}, expectedErr: "must be an absolute http(s) URL"},
+		{title: "rejects unknown event", config: GiteaAPIConfig{URL: "https://git.example.com", Enrich: []string{"issues"}}, expectedErr: "unknown Gitea event to enrich: issues"},
+		{title: "rejects enrichment without URL", config: GiteaAPIConfig{Enrich: []string{"push"}}, expectedErr: "requires a Gitea API URL"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			err := tc.config.Validate()
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestGiteaAPIDefaultBranch(t *testing.T) {
+
+	const payload = `{
+		"ref": "refs/heads/main",
+		"commits": [{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2"}],
+		"total_commits": 1,
+		"repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}
+	}`
+
+	t.Run("looks up default branch missing in the webhook", func(t *testing.T) {
+		var requests atomic.Int32
+		server := newGiteaAPIServer(t, &requests)
+
+		translator := NewGiteaPushTranslator(GiteaConfig{API: GiteaAPIConfig{URL: server.URL, Token: "${secret:vault:gitea#token}"}})
+		translator.SetSecretResolver(staticSecrets{"${secret:vault:gitea#token}": "secret"})
+
+		event, err := translator.Translate([]byte(payload))
+		require.NoError(t, err)
+		assert.Equal(t, "merged", event.GetType().Predicate)
+		assert.Equal(t, int32(1), requests.Load())
+	})
+
+	t.Run("fails without default branch", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		_, err := NewGiteaPushTranslator(GiteaConfig{API: GiteaAPIConfig{URL: server.URL}}).Translate([]byte(payload))
+		assert.ErrorContains(t, err, "failed to get the default branch of yoloco/project1")
+		assert.NotErrorIs(t, err, ErrSkipped)
+	})
+}
+
+func TestGiteaAPIOpenPullRequestPages(t *testing.T) {
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.Equal(t, "recentupdate", r.URL.Query().Get("sort"))
+		pulls := make([]giteaPullRequest, giteaPullRequestsPageSize)
+		for i := range pulls {
+			pulls[i].Head.Ref = "other"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(pulls))
+	}))
+	defer server.Close()
+
+	payload := `{"ref": "refs/heads/feature/foo", "commits": [{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2"}], "total_commits": 1, "repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1", "default_branch": "main"}}`
+	_, err := NewGiteaPushTranslator(GiteaConfig{API: GiteaAPIConfig{URL: server.URL, PullRequestPages: 2}}).Translate([]byte(payload))
+	assert.ErrorIs(t, err, ErrSkipped)
+	assert.Equal(t, int32(2), requests.Load(), "search should stop after the configured pages")
+}
+
+func TestGiteaAPIResponseSizeLimit(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"default_branch": "` + strings.Repeat("x", giteaAPIMaxResponseSize) + `"}`))
+	}))
+	defer server.Close()
+
+	_, err := newGiteaAPI(GiteaAPIConfig{URL: server.URL}).defaultBranch(structs.Repository{FullName: "yoloco/project1"})
+	assert.ErrorContains(t, err, "response larger than")
+}
+
+func TestBuiltinSharesGiteaAPI(t *testing.T) {
+
+	catalog := BuiltinWithConfig(GiteaConfig{API: GiteaAPIConfig{URL: "https://git.example.com"}})
+
+	api := catalog["gitea.push"].(*GiteaPushTranslator).api
+	require.NotNil(t, api)
+	assert.Same(t, api, catalog["gitea.pull_request"].(*GiteaPullRequestTranslator).api)
+	assert.Same(t, api, catalog["gitea.create"].(*GiteaCreateTranslator).api)
+	assert.Same(t, api, catalog["gitea.delete"].(*GiteaDeleteTranslator).api)
+}
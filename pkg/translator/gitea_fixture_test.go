@@ -0,0 +1,25 @@
+package translator_test
+
+import (
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator/translatortest"
+)
+
+func TestGiteaTranslatorFixtures(t *testing.T) {
+
+	for _, tc := range []struct {
+		dir        string
+		translator translator.CDEventTranslator
+	}{
+		{dir: "push", translator: &translator.GiteaPushTranslator{}},
+		{dir: "pull_request", translator: &translator.GiteaPullRequestTranslator{}},
+		{dir: "create", translator: &translator.GiteaCreateTranslator{}},
+		{dir: "delete", translator: &translator.GiteaDeleteTranslator{}},
+	} {
+		t.Run(tc.dir, func(t *testing.T) {
+			translatortest.Run(t, tc.translator, "testdata/gitea/"+tc.dir)
+		})
+	}
+}
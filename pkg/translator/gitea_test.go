@@ -0,0 +1,715 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGiteaTranslatorOptions(t *testing.T) {
+
+	pushPayload := func(ref string) string {
+		return fmt.Sprintf(`{
+			"ref": %q,
+			"commits": [{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2", "message": "Update README.md\n"}],
+			"total_commits": 1,
+			"repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}
+		}`, ref)
+	}
+	refPayload := func(refType, ref string) string {
+		return fmt.Sprintf(`{
+			"ref": %q,
+			"ref_type": %q,
+			"repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}
+		}`, ref, refType)
+	}
+
+	for _, tc := range []struct {
+		title           string
+		config          GiteaConfig
+		translator      func(GiteaConfig) CDEventTranslator
+		payload         string
+		expectedSkipped bool
+	}{
+		{
+			title:      "translates push to main branch",
+			config:     GiteaConfig{MainBranches: []string{"main", "release/*"}},
+			translator: func(c GiteaConfig) CDEventTranslator { return NewGiteaPushTranslator(c) },
+			payload:    pushPayload("refs/heads/release/1.0"),
+		},
+		{
+			title:           "skips push to other branch",
+			config:          GiteaConfig{MainBranches: []string{"main", "release/*"}},
+			translator:      func(c GiteaConfig) CDEventTranslator { return NewGiteaPushTranslator(c) },
+			payload:         pushPayload("refs/heads/feature/foo"),
+			expectedSkipped: true,
+		},
+		{
+			title:      "translates push to any branch by default",
+			translator: func(c GiteaConfig) CDEventTranslator { return NewGiteaPushTranslator(c) },
+			payload:    pushPayload("refs/heads/feature/foo"),
+		},
+		{
+			title:           "skips push of tag",
+			config:          GiteaConfig{MainBranches: []string{"main"}, IgnoreTags: true},
+			translator:      func(c GiteaConfig) CDEventTranslator { return NewGiteaPushTranslator(c) },
+			payload:         pushPayload("refs/tags/v1.0.0"),
+			expectedSkipped: true,
+		},
+		{
+			title:      "translates push to included ref",
+			config:     GiteaConfig{IncludeRefs: []string{"refs/heads/main", "refs/heads/release/*"}},
+			translator: func(c GiteaConfig) CDEventTranslator { return NewGiteaPushTranslator(c) },
+			payload:    pushPayload("refs/heads/release/1.0"),
+		},
+		{
+			title:           "skips push to ref that is not included",
+			config:          GiteaConfig{IncludeRefs: []string{"refs/heads/main", "refs/heads/release/*"}},
+			translator:      func(c GiteaConfig) CDEventTranslator { return NewGiteaPushTranslator(c) },
+			payload:         pushPayload("refs/heads/feature/foo"),
+			expectedSkipped: true,
+		},
+		{
+			title:           "skips push to excluded ref",
+			config:          GiteaConfig{IncludeRefs: []string{"refs/heads/release/*"}, ExcludeRefs: []string{"refs/heads/release/old"}},
+			translator:      func(c GiteaConfig) CDEventTranslator { return NewGiteaPushTranslator(c) },
+			payload:         pushPayload("refs/heads/release/old"),
+			expectedSkipped: true,
+		},
+		{
+			title:           "skips creation of branch that is not included",
+			config:          GiteaConfig{IncludeRefs: []string{"refs/heads/release/*"}},
+			translator:      func(c GiteaConfig) CDEventTranslator { return NewGiteaCreateTranslator(c) },
+			payload:         refPayload("branch", "feature/foo"),
+			expectedSkipped: true,
+		},
+		{
+			title:           "skips deletion of excluded branch",
+			config:          GiteaConfig{ExcludeRefs: []string{"refs/heads/feature/*"}},
+			translator:      func(c GiteaConfig) CDEventTranslator { return NewGiteaDeleteTranslator(c) },
+			payload:         refPayload("branch", "feature/foo"),
+			expectedSkipped: true,
+		},
+		{
+			title:           "skips creation of tag",
+			config:          GiteaConfig{IgnoreTags: true},
+			translator:      func(c GiteaConfig) CDEventTranslator { return NewGiteaCreateTranslator(c) },
+			payload:         refPayload("tag", "v1.0.0"),
+			expectedSkipped: true,
+		},
+		{
+			title:           "skips deletion of tag",
+			config:          GiteaConfig{IgnoreTags: true},
+			translator:      func(c GiteaConfig) CDEventTranslator { return NewGiteaDeleteTranslator(c) },
+			payload:         refPayload("tag", "v1.0.0"),
+			expectedSkipped: true,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			cdEvent, err := tc.translator(tc.config).Translate([]byte(tc.payload))
+
+			if tc.expectedSkipped {
+				assert.ErrorIs(t, err, ErrSkipped)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, cdevents.ChangeMergedEventTypeV0_2_0, cdEvent.GetType())
+		})
+	}
+}
+
+func TestGiteaPushTranslatorOmitCommits(t *testing.T) {
+
+	payload := `{
+		"ref": "refs/heads/main",
+		"commits": [{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2", "message": "Update README.md\n"}],
+		"total_commits": 1,
+		"repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}
+	}`
+
+	for _, omit := range []bool{false, true} {
+		cdEvent, err := NewGiteaPushTranslator(GiteaConfig{OmitCommits: omit}).Translate([]byte(payload))
+		require.NoError(t, err)
+
+		customData, err := cdEvent.GetCustomDataRaw()
+		require.NoError(t, err)
+		assert.Equal(t, !omit, strings.Contains(string(customData), "Update README.md"), "commits included when omit is %t", omit)
+	}
+}
+
+func TestGiteaPushTranslatorTruncation(t *testing.T) {
+
+	payload := `{
+		"ref": "refs/heads/main",
+		"commits": [
+			{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2", "message": "Update README.md\n"},
+			{"id": "5b0e0b1c6a4f1c3e0d8c1e5f4c2b9a7d6e3f2a1b", "message": "Fix typo\n"},
+			{"id": "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b", "message": "Add license\n"}
+		],
+		"total_commits": 3,
+		"repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}
+	}`
+
+	for _, tc := range []struct {
+		title           string
+		config          GiteaConfig
+		expectedCommits int
+		expectedOmitted int
+		expectedLeftOut bool
+	}{
+		{title: "keeps every commit without limit", expectedCommits: 3},
+		{title: "keeps commits within limit", config: GiteaConfig{MaxCommits: 3}, expectedCommits: 3},
+		{title: "truncates commits beyond limit", config: GiteaConfig{MaxCommits: 1}, expectedCommits: 1, expectedOmitted: 2},
+		{title: "leaves out content larger than limit", config: GiteaConfig{MaxCustomDataSize: 100}, expectedLeftOut: true},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			cdEvent, err := NewGiteaPushTranslator(tc.config).Translate([]byte(payload))
+			require.NoError(t, err)
+
+			var customData struct {
+				Content *struct {
+					Commits []interface{} `json:"commits"`
+				}
+				Truncated map[string]int
+			}
+			require.NoError(t, cdEvent.GetCustomDataAs(&customData))
+
+			if tc.expectedLeftOut {
+				assert.Nil(t, customData.Content)
+				assert.Greater(t, customData.Truncated["bytes"], tc.config.MaxCustomDataSize)
+				return
+			}
+
+			require.NotNil(t, customData.Content)
+			assert.Len(t, customData.Content.Commits, tc.expectedCommits)
+			assert.Equal(t, tc.expectedOmitted, customData.Truncated["commits"])
+		})
+	}
+}
+
+func TestGiteaTranslatorCustomDataPolicy(t *testing.T) {
+
+	payload := `{
+		"ref": "refs/heads/main",
+		"commits": [{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2", "message": "Update README.md\n"}],
+		"total_commits": 1,
+		"repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}
+	}`
+
+	for _, tc := range []struct {
+		title              string
+		config             GiteaConfig
+		expectedCustomData string
+	}{
+		{
+			title:  "embeds selected fields",
+			config: GiteaConfig{CustomData: CustomDataFields, CustomDataFields: []string{"$.ref", "$.commits[*].id"}},
+			expectedCustomData: `{"Kind": "structs.GiteaPushEvent", "Content": {
+				"ref": "refs/heads/main",
+				"commits": [{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2"}]
+			}}`,
+		},
+		{
+			title:  "embeds nothing",
+			config: GiteaConfig{CustomData: CustomDataNone},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			cdEvent, err := NewGiteaPushTranslator(tc.config).Translate([]byte(payload))
+			require.NoError(t, err)
+
+			customData, err := cdEvent.GetCustomDataRaw()
+			require.NoError(t, err)
+			if tc.expectedCustomData == "" {
+				assert.Equal(t, "null", string(customData))
+			} else {
+				assert.JSONEq(t, tc.expectedCustomData, string(customData))
+			}
+		})
+	}
+}
+
+func TestGiteaTranslatorProviderTimestamps(t *testing.T) {
+
+	const repository = `"repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}`
+
+	for _, tc := range []struct {
+		title      string
+		translator func(GiteaConfig) CDEventTranslator
+		payload    string
+		expected   string
+	}{
+		{
+			title:      "push at head commit time",
+			translator: func(c GiteaConfig) CDEventTranslator { return NewGiteaPushTranslator(c) },
+			payload: `{"ref": "refs/heads/main", "total_commits": 1,
+				"commits": [{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2", "timestamp": "2024-11-17T19:19:39+01:00"}],
+				"head_commit": {"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2", "timestamp": "2024-11-17T19:19:39+01:00"}, ` + repository + `}`,
+			expected: "2024-11-17T18:19:39Z",
+		},
+		{
+			title:      "opened pull request at creation time",
+			translator: func(c GiteaConfig) CDEventTranslator { return NewGiteaPullRequestTranslator(c) },
+			payload:    `{"action": "opened", "pull_request": {"id": 3, "created_at": "2024-11-17T18:21:54Z"}, ` + repository + `}`,
+			expected:   "2024-11-17T18:21:54Z",
+		},
+		{
+			title:      "merged pull request at close time",
+			translator: func(c GiteaConfig) CDEventTranslator { return NewGiteaPullRequestTranslator(c) },
+			payload:    `{"action": "closed", "pull_request": {"id": 3, "updated_at": "2024-11-17T18:24:30Z", "closed_at": "2024-11-17T18:24:31Z"}, ` + repository + `}`,
+			expected:   "2024-11-17T18:24:31Z",
+		},
+		{
+			title:      "merged pull request at update time without close time",
+			translator: func(c GiteaConfig) CDEventTranslator { return NewGiteaPullRequestTranslator(c) },
+			payload:    `{"action": "closed", "pull_request": {"id": 3, "updated_at": "2024-11-17T18:24:30Z", "closed_at": null}, ` + repository + `}`,
+			expected:   "2024-11-17T18:24:30Z",
+		},
+		{
+			title:      "branch created at translation time",
+			translator: func(c GiteaConfig) CDEventTranslator { return NewGiteaCreateTranslator(c) },
+			payload:    `{"ref": "foo", "ref_type": "branch", ` + repository + `}`,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			before := time.Now()
+
+			event, err := tc.translator(GiteaConfig{ProviderTimestamps: true}).Translate([]byte(tc.payload))
+			require.NoError(t, err)
+			if tc.expected == "" {
+				assert.False(t, event.GetTimestamp().Before(before), "event should have the translation time")
+			} else {
+				assert.Equal(t, tc.expected, event.GetTimestamp().Format(time.RFC3339))
+			}
+
+			event, err = tc.translator(GiteaConfig{}).Translate([]byte(tc.payload))
+			require.NoError(t, err)
+			assert.False(t, event.GetTimestamp().Before(before), "event should have the translation time when disabled")
+		})
+	}
+}
+
+func TestGiteaTranslatorSourceTemplate(t *testing.T) {
+
+	const repository = `"repository": {"name": "project1", "owner": {"username": "yoloco"}, "full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}`
+
+	for _, tc := range []struct {
+		title    string
+		template string
+		expected string
+	}{
+		{title: "defaults to host", expected: "git.example.com"},
+		{title: "renders adapter and provider", template: "/adapter-1/{{.Provider}}/{{.Host}}", expected: "/adapter-1/gitea/git.example.com"},
+		{title: "renders repository", template: "https://{{.Host}}/{{.Owner}}/{{.Name}}", expected: "https://git.example.com/yoloco/project1"},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			config := GiteaConfig{SourceTemplate: tc.template}
+			for _, translate := range []struct {
+				translator CDEventTranslator
+				payload    string
+			}{
+				{NewGiteaPushTranslator(config), `{"ref": "refs/heads/main", "total_commits": 1, "commits": [{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2"}], ` + repository + `}`},
+				{NewGiteaPullRequestTranslator(config), `{"action": "opened", "pull_request": {"id": 3}, ` + repository + `}`},
+				{NewGiteaCreateTranslator(config), `{"ref": "foo", "ref_type": "branch", ` + repository + `}`},
+				{NewGiteaDeleteTranslator(config), `{"ref": "foo", "ref_type": "branch", ` + repository + `}`},
+			} {
+				event, err := translate.translator.Translate([]byte(translate.payload))
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, event.GetSource())
+				assert.Equal(t, "git.example.com/yoloco/project1", event.GetSubjectSource(), "subject source should be the repository")
+			}
+		})
+	}
+}
+
+func TestGiteaTranslatorSubjectIDs(t *testing.T) {
+
+	const repository = `"repository": {"name": "project1", "owner": {"username": "yoloco"}, "full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}`
+	const pullRequest = `{"action": "opened", "number": 7, "pull_request": {"id": 3, "html_url": "http://git.example.com/yoloco/project1/pulls/7"}, ` + repository + `}`
+
+	for _, tc := range []struct {
+		title      string
+		translator func(GiteaConfig) CDEventTranslator
+		templates  SubjectIDTemplates
+		payload    string
+		expected   string
+	}{
+		{
+			title:      "pull request by default",
+			translator: func(c GiteaConfig) CDEventTranslator { return NewGiteaPullRequestTranslator(c) },
+			payload:    pullRequest,
+			expected:   "pr-3",
+		},
+		{
+			title:      "pull request by repository and number",
+			translator: func(c GiteaConfig) CDEventTranslator { return NewGiteaPullRequestTranslator(c) },
+			templates:  SubjectIDTemplates{PullRequest: "{{.FullName}}#{{.Number}}"},
+			payload:    pullRequest,
+			expected:   "yoloco/project1#7",
+		},
+		{
+			title:      "pull request by URL",
+			translator: func(c GiteaConfig) CDEventTranslator { return NewGiteaPullRequestTranslator(c) },
+			templates:  SubjectIDTemplates{PullRequest: "{{.URL}}"},
+			payload:    pullRequest,
+			expected:   "http://git.example.com/yoloco/project1/pulls/7",
+		},
+		{
+			title:      "push by repository and commit",
+			translator: func(c GiteaConfig) CDEventTranslator { return NewGiteaPushTranslator(c) },
+			templates:  SubjectIDTemplates{Push: "{{.FullName}}@{{.Commit}}"},
+			payload:    `{"ref": "refs/heads/main", "total_commits": 1, "commits": [{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2"}], ` + repository + `}`,
+			expected:   "yoloco/project1@9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2",
+		},
+		{
+			title:      "created branch by host, repository and ref",
+			translator: func(c GiteaConfig) CDEventTranslator { return NewGiteaCreateTranslator(c) },
+			templates:  SubjectIDTemplates{Create: "{{.Host}}/{{.FullName}}/{{.Ref}}"},
+			payload:    `{"ref": "foo", "ref_type": "branch", ` + repository + `}`,
+			expected:   "git.example.com/yoloco/project1/foo",
+		},
+		{
+			title:      "deleted branch by default",
+			translator: func(c GiteaConfig) CDEventTranslator { return NewGiteaDeleteTranslator(c) },
+			templates:  SubjectIDTemplates{Create: "{{.FullName}}/{{.Ref}}"},
+			payload:    `{"ref": "foo", "ref_type": "branch", ` + repository + `}`,
+			expected:   "foo",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			event, err := tc.translator(GiteaConfig{SubjectIDs: tc.templates}).Translate([]byte(tc.payload))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, event.GetSubjectId())
+		})
+	}
+}
+
+func TestGiteaTranslatorCompilesConfigOnce(t *testing.T) {
+
+	config := GiteaConfig{
+		SourceTemplate:   "/adapter-1/{{.Host}}",
+		SubjectIDs:       SubjectIDTemplates{Push: "{{.FullName}}@{{.Commit}}", Release: "{{.FullName}}@{{.Tag}}", Create: "{{.Missing"},
+		CustomData:       CustomDataFields,
+		CustomDataFields: []string{"$.repository.full_name", "$.commits[*].id"},
+	}
+
+	compiled := NewGiteaPushTranslator(config).config
+	assert.Len(t, compiled.paths, 2, "constructor should parse the custom data fields")
+	parsed := compiled.templates
+	assert.Len(t, parsed, 3, "constructor should parse every valid template")
+	assert.Contains(t, parsed, templateKey{name: "source", text: config.SourceTemplate})
+	assert.Contains(t, parsed, templateKey{name: "subject id", text: config.SubjectIDs.Release})
+
+	_, err := NewGiteaCreateTranslator(config).Translate([]byte(`{"ref": "foo", "ref_type": "branch", "repository": {"html_url": "http://git.example.com/yoloco/project1"}}`))
+	assert.ErrorContains(t, err, "invalid subject id template", "template that failed to parse should be reported when rendered")
+}
+
+func TestGiteaPushTranslatorChangeUpdates(t *testing.T) {
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		assert.Equal(t, "token secret", r.Header.Get("Authorization"))
+		assert.Equal(t, "open", r.URL.Query().Get("state"))
+
+		pulls := []map[string]interface{}{
+			{"id": 4, "number": 8, "html_url": "http://git.example.com/yoloco/project1/pulls/8", "head": map[string]interface{}{"ref": "feature/foo", "repo": map[string]string{"full_name": "someone/fork"}}},
+			{"id": 3, "number": 7, "html_url": "http://git.example.com/yoloco/project1/pulls/7", "head": map[string]interface{}{"ref": "feature/foo", "repo": map[string]string{"full_name": "yoloco/project1"}}},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(pulls))
+	}))
+	defer server.Close()
+
+	pushPayload := func(ref string) []byte {
+		return []byte(fmt.Sprintf(`{
+			"ref": %q,
+			"commits": [{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2"}],
+			"total_commits": 1,
+			"repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1", "default_branch": "main"}
+		}`, ref))
+	}
+
+	for _, tc := range []struct {
+		title             string
+		config            GiteaConfig
+		ref               string
+		expectedType      string
+		expectedSubjectID string
+		expectedSkipped   bool
+		expectedRequests  int
+	}{
+		{
+			title:             "translates push to default branch to ChangeMerged",
+			ref:               "refs/heads/main",
+			expectedType:      "merged",
+			expectedSubjectID: "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2",
+		},
+		{
+			title:             "translates push to branch of open pull request to ChangeUpdated",
+			ref:               "refs/heads/feature/foo",
+			expectedType:      "updated",
+			expectedSubjectID: "pr-3",
+			expectedRequests:  1,
+		},
+		{
+			title:             "renders subject id of pull request",
+			config:            GiteaConfig{SubjectIDs: SubjectIDTemplates{PullRequest: "{{.FullName}}#{{.Number}}"}},
+			ref:               "refs/heads/feature/foo",
+			expectedType:      "updated",
+			expectedSubjectID: "yoloco/project1#7",
+			expectedRequests:  1,
+		},
+		{
+			title:            "skips push to branch without open pull request",
+			ref:              "refs/heads/feature/bar",
+			expectedSkipped:  true,
+			expectedRequests: 1,
+		},
+		{
+			title:             "translates push to main branch to ChangeMerged",
+			config:            GiteaConfig{MainBranches: []string{"main", "feature/*"}},
+			ref:               "refs/heads/feature/foo",
+			expectedType:      "merged",
+			expectedSubjectID: "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			requests = nil
+			tc.config.API = GiteaAPIConfig{URL: server.URL, Token: "secret"}
+
+			event, err := NewGiteaPushTranslator(tc.config).Translate(pushPayload(tc.ref))
+			assert.Len(t, requests, tc.expectedRequests)
+			for _, path := range requests {
+				assert.Equal(t, "/api/v1/repos/yoloco/project1/pulls", path)
+			}
+			if tc.expectedSkipped {
+				assert.ErrorIs(t, err, ErrSkipped)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedType, event.GetType().Predicate)
+			assert.Equal(t, tc.expectedSubjectID, event.GetSubjectId())
+		})
+	}
+}
+
+func TestGiteaPushTranslatorChangeUpdatesAPIError(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	payload := `{"ref": "refs/heads/feature/foo", "commits": [{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2"}], "total_commits": 1, "repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1", "default_branch": "main"}}`
+	_, err := NewGiteaPushTranslator(GiteaConfig{API: GiteaAPIConfig{URL: server.URL}}).Translate([]byte(payload))
+	assert.ErrorContains(t, err, "failed to list open pull requests of yoloco/project1: unexpected status 500")
+	assert.NotErrorIs(t, err, ErrSkipped)
+}
+
+func TestGiteaTranslatorReleaseTags(t *testing.T) {
+
+	const repository = `"repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}`
+	const commits = `"commits": [{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2"}], "total_commits": 1`
+
+	for _, tc := range []struct {
+		title             string
+		translator        func(GiteaConfig) CDEventTranslator
+		templates         SubjectIDTemplates
+		payload           string
+		expectedTypes     []string
+		expectedSubjectID string
+		expectedErr       string
+	}{
+		{
+			title:             "translates push of release tag with commits to change and release",
+			translator:        func(c GiteaConfig) CDEventTranslator { return NewGiteaPushTranslator(c) },
+			payload:           `{"ref": "refs/tags/v1.0.0", "after": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2", ` + commits + `, ` + repository + `}`,
+			expectedTypes:     []string{"change.merged", "artifact.published"},
+			expectedSubjectID: "pkg:generic/yoloco/project1@v1.0.0",
+		},
+		{
+			title:             "translates push of release tag without commits to release",
+			translator:        func(c GiteaConfig) CDEventTranslator { return NewGiteaPushTranslator(c) },
+			payload:           `{"ref": "refs/tags/v1.0.0", "after": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2", "total_commits": 0, ` + repository + `}`,
+			expectedTypes:     []string{"artifact.published"},
+			expectedSubjectID: "pkg:generic/yoloco/project1@v1.0.0",
+		},
+		{
+			title:         "translates push of other tag to change only",
+			translator:    func(c GiteaConfig) CDEventTranslator { return NewGiteaPushTranslator(c) },
+			payload:       `{"ref": "refs/tags/nightly", ` + commits + `, ` + repository + `}`,
+			expectedTypes: []string{"change.merged"},
+		},
+		{
+			title:             "translates creation of release tag to release",
+			translator:        func(c GiteaConfig) CDEventTranslator { return NewGiteaCreateTranslator(c) },
+			templates:         SubjectIDTemplates{Release: "{{.FullName}}@{{.Tag}}#{{.Commit}}"},
+			payload:           `{"ref": "v1.0.0", "ref_type": "tag", "sha": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2", ` + repository + `}`,
+			expectedTypes:     []string{"artifact.published"},
+			expectedSubjectID: "yoloco/project1@v1.0.0#9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2",
+		},
+		{
+			title:       "fails creation of other tag",
+			translator:  func(c GiteaConfig) CDEventTranslator { return NewGiteaCreateTranslator(c) },
+			payload:     `{"ref": "nightly", "ref_type": "tag", ` + repository + `}`,
+			expectedErr: "unsupported Gitea create ref type: tag",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			events, err := TranslateAll(tc.translator(GiteaConfig{ReleaseTags: []string{"v*"}, SubjectIDs: tc.templates}), NewPayload([]byte(tc.payload)))
+			if tc.expectedErr != "" {
+				assert.ErrorContains(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var types []string
+			for _, event := range events {
+				types = append(types, event.GetType().Subject+"."+event.GetType().Predicate)
+			}
+			assert.Equal(t, tc.expectedTypes, types)
+			if tc.expectedSubjectID != "" {
+				assert.Equal(t, tc.expectedSubjectID, events[len(events)-1].GetSubjectId())
+			}
+		})
+	}
+}
+
+func TestGiteaPushTranslatorCommitEvents(t *testing.T) {
+
+	payload := []byte(`{
+		"ref": "refs/heads/main",
+		"commits": [
+			{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2", "timestamp": "2024-05-01T10:00:00Z"},
+			{"id": "5c2b1a9e0f3d4c6b8a7e9d1f2a3b4c5d6e7f8a9b", "timestamp": "2024-05-01T10:01:00Z"},
+			{"id": "1f2e3d4c5b6a79881726354433221100ffeeddcc", "timestamp": "2024-05-01T10:02:00Z"}
+		],
+		"total_commits": 3,
+		"repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}
+	}`)
+
+	for _, tc := range []struct {
+		title      string
+		config     GiteaConfig
+		expectedID []string
+	}{
+		{
+			title:      "translates push to first commit by default",
+			expectedID: []string{"9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2"},
+		},
+		{
+			title:      "translates push to every commit",
+			config:     GiteaConfig{CommitEvents: true},
+			expectedID: []string{"9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2", "5c2b1a9e0f3d4c6b8a7e9d1f2a3b4c5d6e7f8a9b", "1f2e3d4c5b6a79881726354433221100ffeeddcc"},
+		},
+		{
+			title:      "bounds events of commits",
+			config:     GiteaConfig{CommitEvents: true, MaxCommitEvents: 2},
+			expectedID: []string{"9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2", "5c2b1a9e0f3d4c6b8a7e9d1f2a3b4c5d6e7f8a9b"},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			events, err := NewGiteaPushTranslator(tc.config).TranslateAll(payload)
+			require.NoError(t, err)
+
+			var ids []string
+			for _, event := range events {
+				assert.Equal(t, "change.merged", event.GetType().Subject+"."+event.GetType().Predicate)
+				ids = append(ids, event.GetSubjectId())
+			}
+			assert.Equal(t, tc.expectedID, ids)
+
+			if len(events) > 1 {
+				var customData struct {
+					Content struct {
+						Commits []struct {
+							ID string `json:"id"`
+						} `json:"commits"`
+					}
+				}
+				require.NoError(t, events[1].GetCustomDataAs(&customData))
+				require.Len(t, customData.Content.Commits, 1, "commit event should only have its commit")
+				assert.Equal(t, tc.expectedID[1], customData.Content.Commits[0].ID)
+			}
+		})
+	}
+}
+
+func TestGiteaTranslatorActor(t *testing.T) {
+
+	for _, tc := range []struct {
+		title      string
+		translator CDEventTranslator
+		payload    string
+		expected   *Identity
+	}{
+		{
+			title:      "pusher of push",
+			translator: NewGiteaPushTranslator(GiteaConfig{}),
+			payload: `{
+				"ref": "refs/heads/main",
+				"commits": [{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2"}],
+				"total_commits": 1,
+				"head_commit": {"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2", "author": {"name": "Someone Else", "email": "else@example.com", "username": "else"}},
+				"pusher": {"id": 1, "login": "alice", "full_name": "Alice", "email": " Alice@Example.com "},
+				"repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}
+			}`,
+			expected: &Identity{Provider: "gitea", Username: "alice", Email: "alice@example.com", Name: "Alice"},
+		},
+		{
+			title:      "author of head commit of push without pusher",
+			translator: NewGiteaPushTranslator(GiteaConfig{}),
+			payload: `{
+				"ref": "refs/heads/main",
+				"commits": [{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2"}],
+				"total_commits": 1,
+				"head_commit": {"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2", "author": {"name": "Bob", "email": "bob@example.com", "username": "bob"}},
+				"repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}
+			}`,
+			expected: &Identity{Provider: "gitea", Username: "bob", Email: "bob@example.com", Name: "Bob"},
+		},
+		{
+			title:      "sender of pull request",
+			translator: NewGiteaPullRequestTranslator(GiteaConfig{}),
+			payload: `{
+				"action": "opened",
+				"number": 1,
+				"pull_request": {"id": 1, "number": 1, "title": "Add feature", "head": {"ref": "feature"}, "base": {"ref": "main"}},
+				"sender": {"id": 2, "login": "carol", "email": "carol@example.com"},
+				"repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}
+			}`,
+			expected: &Identity{Provider: "gitea", Username: "carol", Email: "carol@example.com"},
+		},
+		{
+			title:      "no actor without user",
+			translator: NewGiteaPushTranslator(GiteaConfig{}),
+			payload: `{
+				"ref": "refs/heads/main",
+				"commits": [{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2"}],
+				"total_commits": 1,
+				"repository": {"full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}
+			}`,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			cdEvent, err := tc.translator.Translate([]byte(tc.payload))
+			require.NoError(t, err)
+
+			var customData struct {
+				Actor *Identity `json:"actor"`
+			}
+			require.NoError(t, cdEvent.GetCustomDataAs(&customData))
+			assert.Equal(t, tc.expected, customData.Actor)
+		})
+	}
+}
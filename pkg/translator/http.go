@@ -0,0 +1,168 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"mime"
+	"net/http"
+	"time"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+	cdeventsv04 "github.com/cdevents/sdk-go/pkg/api/v04"
+)
+
+// DefaultHTTPMaxElapsed bounds the time an http translator spends on a payload, including
+// retries, when no MaxElapsed is configured. It is below the default acknowledgement wait of
+// JetStream consumers, so that a message is not redelivered while it is still being translated.
+const DefaultHTTPMaxElapsed = 20 * time.Second
+
+// HTTPConfig configures a translator that POSTs webhook payloads to an external transformation
+// service, which responds with the CDEvent as JSON.
+type HTTPConfig struct {
+	URL              string            `envconfig:"URL"`
+	BearerToken      string            `envconfig:"BEARER_TOKEN" secret:"true"`
+	Headers          map[string]string `envconfig:"HEADERS" secret:"true"`
+	Timeout          time.Duration     `envconfig:"TIMEOUT" default:"10s"`
+	MaxRetries       int               `envconfig:"MAX_RETRIES" default:"3"`
+	RetryDelay       time.Duration     `envconfig:"RETRY_DELAY" default:"500ms"`
+	MaxElapsed       time.Duration     `envconfig:"MAX_ELAPSED" default:"20s"`
+	MaxResponseBytes int64             `envconfig:"MAX_RESPONSE_BYTES" default:"1048576"`
+}
+
+// SecretResolver resolves secrets that are referenced in the configuration, e.g. in
+// BEARER_TOKEN, instead of being given as plain values.
+type SecretResolver interface {
+	Resolve(ctx context.Context, value string) (string, error)
+}
+
+// HTTPTranslator translates webhook payloads with an external service. Connection errors, 429
+// and 5xx responses are retried with jittered exponential backoff until MaxElapsed has passed.
+// Successful responses must be JSON and contain a CDEvent that passes schema validation.
+type HTTPTranslator struct {
+	client  *http.Client
+	config  HTTPConfig
+	secrets SecretResolver
+}
+
+func NewHTTPTranslator(config HTTPConfig) (*HTTPTranslator, error) {
+	if config.URL == "" {
+		return nil, fmt.Errorf("no URL configured for http translator")
+	}
+	if config.MaxElapsed <= 0 {
+		config.MaxElapsed = DefaultHTTPMaxElapsed
+	}
+
+	return &HTTPTranslator{
+		client: &http.Client{Timeout: config.Timeout},
+		config: config,
+	}, nil
+}
+
+// SetSecretResolver sets a resolver for the bearer token, which is then resolved on every request
+// so that renewed secrets are picked up.
+func (t *HTTPTranslator) SetSecretResolver(secrets SecretResolver) {
+	t.secrets = secrets
+}
+
+func (t *HTTPTranslator) Translate(data []byte) (cdevents.CDEvent, error) {
+	return t.TranslatePayload(NewPayload(data))
+}
+
+// TranslatePayload posts the payload within the context of the payload, so that the request and
+// its retries are cancelled together with the webhook message.
+func (t *HTTPTranslator) TranslatePayload(payload *Payload) (cdevents.CDEvent, error) {
+	ctx, cancel := context.WithTimeout(payload.Context(), t.config.MaxElapsed)
+	defer cancel()
+
+	delay := t.config.RetryDelay
+	for attempt := 0; ; attempt++ {
+		body, retry, err := t.post(ctx, payload.Data())
+		if err == nil {
+			return t.decode(body)
+		}
+		if !retry || attempt >= t.config.MaxRetries {
+			return nil, fmt.Errorf("http translator %s failed: %w", t.config.URL, err)
+		}
+
+		// Equal jitter keeps at least half of the delay while spreading out the retries of
+		// translators that failed at the same time.
+		jittered := delay/2 + rand.N(delay/2+1)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("http translator %s failed: %w: %w", t.config.URL, err, ctx.Err())
+		case <-time.After(jittered):
+		}
+		delay *= 2
+	}
+}
+
+func (t *HTTPTranslator) post(ctx context.Context, data []byte) ([]byte, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.config.URL, bytes.NewReader(data))
+	if err != nil {
+		return nil, false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for name, value := range t.config.Headers {
+		req.Header.Set(name, value)
+	}
+	if t.config.BearerToken != "" {
+		token, err := t.secret(ctx, t.config.BearerToken)
+		if err != nil {
+			return nil, true, err
+		}
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, ctx.Err() == nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, t.config.MaxResponseBytes+1))
+	if err != nil {
+		return nil, true, err
+	}
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return nil, true, fmt.Errorf("unexpected response: %s", resp.Status)
+	default:
+		return nil, false, fmt.Errorf("unexpected response: %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+
+	if int64(len(body)) > t.config.MaxResponseBytes {
+		return nil, false, fmt.Errorf("response exceeds %d bytes", t.config.MaxResponseBytes)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || (mediaType != "application/json" && mediaType != "application/cdevents+json") {
+		return nil, false, fmt.Errorf("unexpected content type: %s", resp.Header.Get("Content-Type"))
+	}
+
+	return body, false, nil
+}
+
+func (t *HTTPTranslator) secret(ctx context.Context, value string) (string, error) {
+	if t.secrets == nil {
+		return value, nil
+	}
+	return t.secrets.Resolve(ctx, value)
+}
+
+func (t *HTTPTranslator) decode(body []byte) (cdevents.CDEvent, error) {
+	event, err := cdeventsv04.NewFromJsonBytes(body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response from http translator %s: %w", t.config.URL, err)
+	}
+	if err := cdevents.Validate(event); err != nil {
+		return nil, fmt.Errorf("invalid CDEvent from http translator %s: %w", t.config.URL, err)
+	}
+	return event, nil
+}
@@ -0,0 +1,186 @@
+package translator
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPTranslator(t *testing.T) {
+
+	for _, tc := range []struct {
+		title            string
+		statuses         []int
+		contentType      string
+		response         string
+		expectedRequests int
+		expectedError    string
+	}{
+		{
+			title:            "translates payload",
+			statuses:         []int{http.StatusOK},
+			response:         testEvent,
+			expectedRequests: 1,
+		},
+		{
+			title:            "retries on server error",
+			statuses:         []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK},
+			response:         testEvent,
+			expectedRequests: 3,
+		},
+		{
+			title:            "gives up after max retries",
+			statuses:         []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			expectedRequests: 3,
+			expectedError:    "unexpected response: 502 Bad Gateway",
+		},
+		{
+			title:            "does not retry on client error",
+			statuses:         []int{http.StatusUnprocessableEntity},
+			response:         "unsupported event",
+			expectedRequests: 1,
+			expectedError:    "unexpected response: 422 Unprocessable Entity: unsupported event",
+		},
+		{
+			title:            "rejects response that is not JSON",
+			statuses:         []int{http.StatusOK},
+			contentType:      "text/plain",
+			response:         testEvent,
+			expectedRequests: 1,
+			expectedError:    "unexpected content type: text/plain",
+		},
+		{
+			title:            "rejects invalid CDEvent",
+			statuses:         []int{http.StatusOK},
+			response:         `{"context":{"version":"0.4.1","id":"test","source":"http","type":"dev.cdevents.change.merged.0.2.0","timestamp":"2024-01-01T00:00:00Z"},"subject":{"id":"","type":"change","content":{}}}`,
+			expectedRequests: 1,
+			expectedError:    "invalid CDEvent from http translator",
+		},
+		{
+			title:            "rejects response exceeding max size",
+			statuses:         []int{http.StatusOK},
+			response:         testEvent + testEvent,
+			expectedRequests: 1,
+			expectedError:    "response exceeds 256 bytes",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			var requests atomic.Int32
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := requests.Add(1)
+
+				assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+				assert.Equal(t, "value", r.Header.Get("X-Custom"))
+				body, _ := io.ReadAll(r.Body)
+				assert.Equal(t, `{"number": 42}`, string(body))
+
+				contentType := tc.contentType
+				if contentType == "" {
+					contentType = "application/json"
+				}
+				w.Header().Set("Content-Type", contentType)
+				w.WriteHeader(tc.statuses[n-1])
+				io.WriteString(w, tc.response)
+			}))
+			defer server.Close()
+
+			callout, err := NewHTTPTranslator(HTTPConfig{
+				URL:              server.URL,
+				BearerToken:      "token",
+				Headers:          map[string]string{"X-Custom": "value"},
+				Timeout:          time.Second,
+				MaxRetries:       2,
+				RetryDelay:       time.Millisecond,
+				MaxResponseBytes: 256,
+			})
+			require.NoError(t, err)
+
+			event, err := callout.Translate([]byte(`{"number": 42}`))
+
+			assert.Equal(t, tc.expectedRequests, int(requests.Load()))
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "42", event.GetSubjectId())
+		})
+	}
+}
+
+func TestNewHTTPTranslatorErrors(t *testing.T) {
+
+	_, err := NewHTTPTranslator(HTTPConfig{})
+	assert.EqualError(t, err, "no URL configured for http translator")
+}
+
+type staticSecrets map[string]string
+
+func (s staticSecrets) Resolve(ctx context.Context, value string) (string, error) {
+	return s[value], nil
+}
+
+func TestHTTPTranslatorResolvesBearerToken(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer resolved", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, testEvent)
+	}))
+	defer server.Close()
+
+	callout, err := NewHTTPTranslator(HTTPConfig{URL: server.URL, BearerToken: "${secret:vault:translator#token}", Timeout: time.Second, MaxResponseBytes: 1024})
+	require.NoError(t, err)
+	callout.SetSecretResolver(staticSecrets{"${secret:vault:translator#token}": "resolved"})
+
+	_, err = callout.Translate([]byte(`{"number": 42}`))
+	assert.NoError(t, err)
+}
+
+func TestHTTPTranslatorStopsRetrying(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	for _, tc := range []struct {
+		title      string
+		maxElapsed time.Duration
+		ctx        func() (context.Context, context.CancelFunc)
+	}{
+		{
+			title:      "after max elapsed",
+			maxElapsed: 100 * time.Millisecond,
+			ctx:        func() (context.Context, context.CancelFunc) { return context.Background(), func() {} },
+		},
+		{
+			title:      "when the message context is done",
+			maxElapsed: time.Minute,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 100*time.Millisecond)
+			},
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			callout, err := NewHTTPTranslator(HTTPConfig{URL: server.URL, Timeout: time.Second, MaxRetries: 100, RetryDelay: 50 * time.Millisecond, MaxElapsed: tc.maxElapsed})
+			require.NoError(t, err)
+
+			ctx, cancel := tc.ctx()
+			defer cancel()
+
+			start := time.Now()
+			_, err = callout.TranslatePayload(NewPayloadContext(ctx, []byte(`{}`)))
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+			assert.Less(t, time.Since(start), 5*time.Second)
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package translator
+
+import (
+	"strings"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/structs"
+)
+
+// ActorCustomDataKey is the key in the custom data of events that the person behind the event is
+// added under, in the same structure whatever the provider, so that events can be attributed to
+// people uniformly.
+const ActorCustomDataKey = "actor"
+
+// Identity is a person as known by a provider. Emails are lower case.
+type Identity struct {
+	Provider string `json:"provider"`
+	Username string `json:"username,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Name     string `json:"name,omitempty"`
+	// Corporate is the corporate identity of the person, which is set by the adapter from its
+	// identity map.
+	Corporate string `json:"corporate,omitempty"`
+}
+
+// NewIdentity returns the normalized identity of a person at a provider, or nil if neither the
+// username nor the email is known.
+func NewIdentity(provider, username, email, name string) *Identity {
+	identity := &Identity{
+		Provider: provider,
+		Username: strings.TrimSpace(username),
+		Email:    strings.ToLower(strings.TrimSpace(email)),
+		Name:     strings.TrimSpace(name),
+	}
+	if identity.Username == "" && identity.Email == "" {
+		return nil
+	}
+	return identity
+}
+
+// giteaActor returns the identity of the user who triggered a Gitea webhook: the pusher of a
+// push, or the author of its head commit if the webhook has no pusher, and the sender of other
+// webhooks.
+func giteaActor(giteaEvent interface{}) *Identity {
+	var user *structs.User
+	switch event := giteaEvent.(type) {
+	case structs.GiteaPushEvent:
+		user = event.Pusher
+		if user == nil {
+			user = event.Sender
+		}
+		if user == nil {
+			author := event.HeadCommit.Author
+			return NewIdentity("gitea", author.Username, author.Email, author.Name)
+		}
+	case structs.GiteaPullRequestEvent:
+		user = event.Sender
+	case structs.GiteaCreateEvent:
+		user = event.Sender
+	case structs.GiteaDeleteEvent:
+		user = event.Sender
+	}
+	if user == nil {
+		return nil
+	}
+	return NewIdentity("gitea", user.Login, user.Email, user.FullName)
+}
@@ -0,0 +1,204 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+	cdeventsv04 "github.com/cdevents/sdk-go/pkg/api/v04"
+
+	"github.com/itchyny/gojq"
+)
+
+// JQConfig configures a translator defined by a jq program. The program transforms the webhook
+// payload into an object with the fields of the CDEvent:
+//
+//	{
+//	  type: "dev.cdevents.change.merged.0.2.0",
+//	  subject_id: (.pull_request.number | tostring),
+//	  source: .repository.html_url,
+//	  repository: .repository.full_name,
+//	  content: {},
+//	  custom_data: {sender: .sender.login}
+//	}
+//
+// The type and source fields can be left out of the output if Type and Source are configured.
+// The repository field is a shorthand for content.repository.id, and any other fields of the
+// subject content required by the event type are given in content. The optional schema_uri,
+// or SchemaURI, is the schema of the custom data, which must be loaded with LoadSchemaDir.
+type JQConfig struct {
+	Program     string `envconfig:"PROGRAM"`
+	ProgramFile string `envconfig:"PROGRAM_FILE"`
+	Type        string `envconfig:"TYPE"`
+	Source      string `envconfig:"SOURCE"`
+	SchemaURI   string `envconfig:"SCHEMA_URI"`
+}
+
+type jqOutput struct {
+	Type       string                 `json:"type"`
+	SubjectID  interface{}            `json:"subject_id"`
+	Source     string                 `json:"source"`
+	SchemaURI  string                 `json:"schema_uri"`
+	Repository interface{}            `json:"repository"`
+	Content    map[string]interface{} `json:"content"`
+	CustomData interface{}            `json:"custom_data"`
+}
+
+// JQTranslator translates webhook payloads with a jq program.
+type JQTranslator struct {
+	config JQConfig
+	code   *gojq.Code
+}
+
+// NewJQTranslator compiles the program and validates the configured event type.
+func NewJQTranslator(config JQConfig) (*JQTranslator, error) {
+	program := config.Program
+	if config.ProgramFile != "" {
+		data, err := os.ReadFile(config.ProgramFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read jq program: %w", err)
+		}
+		program = string(data)
+	}
+	if program == "" {
+		return nil, fmt.Errorf("no program configured for jq translator")
+	}
+
+	query, err := gojq.Parse(program)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq program: %w", err)
+	}
+
+	code, err := gojq.Compile(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq program: %w", err)
+	}
+
+	if config.Type != "" {
+		if _, err := cdeventsv04.NewCDEvent(config.Type, cdeventsv04.SpecVersion); err != nil {
+			return nil, fmt.Errorf("invalid event type for jq translator: %w", err)
+		}
+	}
+
+	return &JQTranslator{config: config, code: code}, nil
+}
+
+func (t *JQTranslator) Translate(data []byte) (cdevents.CDEvent, error) {
+	return t.TranslatePayload(NewPayload(data))
+}
+
+func (t *JQTranslator) TranslatePayload(payload *Payload) (cdevents.CDEvent, error) {
+	doc, err := payload.Document()
+	if err != nil {
+		return nil, err
+	}
+
+	result, ok := t.code.Run(doc).Next()
+	if !ok {
+		return nil, fmt.Errorf("jq program produced no output")
+	}
+	if err, ok := result.(error); ok {
+		return nil, fmt.Errorf("jq program failed: %w", err)
+	}
+
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+
+	var output jqOutput
+	if err := json.Unmarshal(encoded, &output); err != nil {
+		return nil, fmt.Errorf("jq program must produce an object with the fields of the event: %w", err)
+	}
+
+	return t.newEvent(output)
+}
+
+func (t *JQTranslator) newEvent(output jqOutput) (cdevents.CDEvent, error) {
+	eventType := output.Type
+	if eventType == "" {
+		eventType = t.config.Type
+	}
+	source := output.Source
+	if source == "" {
+		source = t.config.Source
+	}
+	schemaURI := output.SchemaURI
+	if schemaURI == "" {
+		schemaURI = t.config.SchemaURI
+	}
+
+	subjectID, err := toString(output.SubjectID)
+	if err != nil || subjectID == "" {
+		return nil, fmt.Errorf("jq program must produce a subject_id")
+	}
+
+	event, err := cdeventsv04.NewCDEvent(eventType, cdeventsv04.SpecVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid event type from jq program: %w", err)
+	}
+	event.SetSource(source)
+	event.SetSubjectId(subjectID)
+	setSchemaURI(event, schemaURI)
+	if output.CustomData != nil {
+		if err := event.SetCustomData("application/json", output.CustomData); err != nil {
+			return nil, err
+		}
+	}
+
+	// The subject content is typed per event, so it is set by decoding the event again with
+	// the content from the program merged in.
+	encoded, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(encoded, &doc); err != nil {
+		return nil, err
+	}
+
+	subject, _ := doc["subject"].(map[string]interface{})
+	content, _ := subject["content"].(map[string]interface{})
+	if content == nil {
+		content = make(map[string]interface{})
+	}
+	for k, v := range output.Content {
+		content[k] = v
+	}
+	switch repository := output.Repository.(type) {
+	case nil:
+	case string:
+		content["repository"] = map[string]interface{}{"id": repository}
+	default:
+		content["repository"] = repository
+	}
+	subject["content"] = content
+
+	if encoded, err = json.Marshal(doc); err != nil {
+		return nil, err
+	}
+
+	translated, err := cdeventsv04.NewFromJsonBytes(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if err := cdevents.Validate(translated); err != nil {
+		return nil, fmt.Errorf("invalid CDEvent from jq program: %w", err)
+	}
+
+	return translated, nil
+}
+
+func toString(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("unexpected type %T", v)
+	}
+}
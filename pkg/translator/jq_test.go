@@ -0,0 +1,127 @@
+package translator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	cdeventsv04 "github.com/cdevents/sdk-go/pkg/api/v04"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPullRequestPayload = `{
+	"action": "closed",
+	"number": 7,
+	"pull_request": {"merged": true},
+	"repository": {"full_name": "platform/api", "html_url": "https://git.example.com/platform/api"},
+	"sender": {"login": "alice"}
+}`
+
+func TestJQTranslator(t *testing.T) {
+
+	for _, tc := range []struct {
+		title         string
+		config        JQConfig
+		expectedType  string
+		expectedError string
+	}{
+		{
+			title: "translates payload",
+			config: JQConfig{Program: `{
+				type: "dev.cdevents.change.merged.0.2.0",
+				subject_id: .number,
+				source: .repository.html_url,
+				repository: .repository.full_name,
+				custom_data: {sender: .sender.login}
+			}`},
+			expectedType: "dev.cdevents.change.merged.0.2.0",
+		},
+		{
+			title: "uses configured type and source",
+			config: JQConfig{
+				Program: `{subject_id: (.number | tostring), content: {repository: {id: .repository.full_name}}}`,
+				Type:    "dev.cdevents.change.merged.0.2.0",
+				Source:  "https://git.example.com/platform/api",
+			},
+			expectedType: "dev.cdevents.change.merged.0.2.0",
+		},
+		{
+			title:         "fails without subject id",
+			config:        JQConfig{Program: `{repository: .repository.full_name}`, Type: "dev.cdevents.change.merged.0.2.0", Source: "test"},
+			expectedError: "jq program must produce a subject_id",
+		},
+		{
+			title:         "fails on unknown event type",
+			config:        JQConfig{Program: `{type: "dev.cdevents.change.unknown.0.1.0", subject_id: "7"}`},
+			expectedError: "invalid event type from jq program",
+		},
+		{
+			title:         "fails on invalid event",
+			config:        JQConfig{Program: `{subject_id: "7"}`, Type: "dev.cdevents.change.merged.0.2.0"},
+			expectedError: "invalid CDEvent from jq program",
+		},
+		{
+			title:         "fails when program errors",
+			config:        JQConfig{Program: `error("unsupported action")`},
+			expectedError: "unsupported action",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			jq, err := NewJQTranslator(tc.config)
+			require.NoError(t, err)
+
+			event, err := jq.Translate([]byte(testPullRequestPayload))
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectedType, event.GetType().String())
+			assert.Equal(t, "7", event.GetSubjectId())
+			assert.Equal(t, "https://git.example.com/platform/api", event.GetSource())
+
+			merged, ok := event.(*cdeventsv04.ChangeMergedEvent)
+			require.True(t, ok)
+			assert.Equal(t, "platform/api", merged.Subject.Content.Repository.Id)
+		})
+	}
+}
+
+func TestJQTranslatorCustomData(t *testing.T) {
+
+	jq, err := NewJQTranslator(JQConfig{
+		Program: `{subject_id: .number, repository: .repository.full_name, custom_data: {sender: .sender.login}}`,
+		Type:    "dev.cdevents.change.merged.0.2.0",
+		Source:  "test",
+	})
+	require.NoError(t, err)
+
+	event, err := jq.Translate([]byte(testPullRequestPayload))
+	require.NoError(t, err)
+
+	customData, err := event.GetCustomDataRaw()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"sender": "alice"}`, string(customData))
+}
+
+func TestNewJQTranslatorErrors(t *testing.T) {
+
+	_, err := NewJQTranslator(JQConfig{})
+	assert.EqualError(t, err, "no program configured for jq translator")
+
+	_, err = NewJQTranslator(JQConfig{Program: `{subject_id: .number`})
+	assert.ErrorContains(t, err, "invalid jq program")
+
+	_, err = NewJQTranslator(JQConfig{Program: `{subject_id: $undefined}`})
+	assert.ErrorContains(t, err, "invalid jq program")
+
+	_, err = NewJQTranslator(JQConfig{Program: `.`, Type: "dev.cdevents.change.unknown.0.1.0"})
+	assert.ErrorContains(t, err, "invalid event type for jq translator")
+
+	path := filepath.Join(t.TempDir(), "program.jq")
+	require.NoError(t, os.WriteFile(path, []byte(`{subject_id: .number}`), 0o644))
+	_, err = NewJQTranslator(JQConfig{ProgramFile: path})
+	assert.NoError(t, err)
+}
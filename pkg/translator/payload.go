@@ -0,0 +1,137 @@
+package translator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+)
+
+// DefaultMaxPayloadSize is the size limit for decoding webhook payloads, which is the largest
+// payload delivered by the common Git forges.
+const DefaultMaxPayloadSize = 25 << 20
+
+// ErrPayloadTooLarge is returned, wrapped with the size, when decoding a payload that exceeds
+// the size limit.
+var ErrPayloadTooLarge = errors.New("payload too large")
+
+// PayloadOption configures a Payload.
+type PayloadOption func(*Payload)
+
+// WithMaxSize sets the size limit in bytes for decoding the payload. Zero means
+// DefaultMaxPayloadSize and less than zero disables the limit.
+func WithMaxSize(size int64) PayloadOption {
+	return func(p *Payload) {
+		p.maxSize = size
+	}
+}
+
+// PayloadTranslator is implemented by translators that can translate a payload that may already
+// have been decoded, reusing the decoded document instead of parsing the data again.
+type PayloadTranslator interface {
+	TranslatePayload(payload *Payload) (cdevents.CDEvent, error)
+}
+
+// TranslatePayload translates a payload, reusing the decoded document if the translator is a
+// PayloadTranslator.
+func TranslatePayload(t CDEventTranslator, payload *Payload) (cdevents.CDEvent, error) {
+	if pt, ok := t.(PayloadTranslator); ok {
+		return pt.TranslatePayload(payload)
+	}
+	return t.Translate(payload.Data())
+}
+
+// MultiTranslator is implemented by translators that translate some payloads to more than one
+// event, e.g. the push of a release tag. The first event is the one returned by Translate.
+type MultiTranslator interface {
+	TranslateAll(data []byte) ([]cdevents.CDEvent, error)
+}
+
+// TranslateAll translates a payload to all of its events, which is only the event returned by
+// TranslatePayload unless the translator is a MultiTranslator.
+func TranslateAll(t CDEventTranslator, payload *Payload) ([]cdevents.CDEvent, error) {
+	if mt, ok := t.(MultiTranslator); ok {
+		return mt.TranslateAll(payload.Data())
+	}
+	event, err := TranslatePayload(t, payload)
+	if err != nil {
+		return nil, err
+	}
+	return []cdevents.CDEvent{event}, nil
+}
+
+// Payload is a webhook payload that is decoded into a generic JSON document at most once, so
+// that the document can be shared by everything that inspects the payload. It is not safe for
+// concurrent use.
+type Payload struct {
+	ctx     context.Context
+	data    []byte
+	maxSize int64
+	doc     map[string]interface{}
+	err     error
+	decoded bool
+}
+
+func NewPayload(data []byte, opts ...PayloadOption) *Payload {
+	p := &Payload{data: data}
+	p.apply(opts)
+	return p
+}
+
+// NewPayloadContext returns a payload that is translated within the context of the webhook
+// message it was received in, so that translators calling out can be cancelled with it.
+func NewPayloadContext(ctx context.Context, data []byte, opts ...PayloadOption) *Payload {
+	p := &Payload{ctx: ctx, data: data}
+	p.apply(opts)
+	return p
+}
+
+func (p *Payload) apply(opts []PayloadOption) {
+	for _, opt := range opts {
+		opt(p)
+	}
+}
+
+// Context returns the context of the webhook message, or the background context for payloads
+// without one.
+func (p *Payload) Context() context.Context {
+	if p.ctx == nil {
+		return context.Background()
+	}
+	return p.ctx
+}
+
+// Data returns the raw payload.
+func (p *Payload) Data() []byte {
+	return p.data
+}
+
+// Document returns the payload decoded into a generic JSON document. The document is shared and
+// must not be modified.
+func (p *Payload) Document() (map[string]interface{}, error) {
+	if !p.decoded {
+		p.err = decode(p.data, &p.doc, p.maxSize)
+		p.decoded = true
+	}
+	return p.doc, p.err
+}
+
+// Decode decodes the payload into v, e.g. a typed webhook event.
+func (p *Payload) Decode(v interface{}) error {
+	return decode(p.data, v, p.maxSize)
+}
+
+// decode decodes a JSON payload into v, rejecting payloads that exceed maxSize before parsing
+// them. Zero means DefaultMaxPayloadSize and less than zero no limit.
+func decode(data []byte, v interface{}, maxSize int64) error {
+	limit := maxSize
+	if limit == 0 {
+		limit = DefaultMaxPayloadSize
+	}
+	if limit > 0 && int64(len(data)) > limit {
+		return fmt.Errorf("%w: %d bytes exceeds the limit of %d bytes", ErrPayloadTooLarge, len(data), limit)
+	}
+	return json.Unmarshal(data, v)
+}
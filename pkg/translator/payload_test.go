@@ -0,0 +1,61 @@
+package translator
+
+import (
+	"testing"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type documentTranslator struct {
+	documents []map[string]interface{}
+}
+
+func (d *documentTranslator) Translate(data []byte) (cdevents.CDEvent, error) {
+	return d.TranslatePayload(NewPayload(data))
+}
+
+func (d *documentTranslator) TranslatePayload(payload *Payload) (cdevents.CDEvent, error) {
+	doc, err := payload.Document()
+	d.documents = append(d.documents, doc)
+	return nil, err
+}
+
+func TestPayloadIsDecodedOnce(t *testing.T) {
+
+	payload := NewPayload([]byte(`{"repository": {"full_name": "team/api"}}`))
+
+	doc, err := payload.Document()
+	require.NoError(t, err)
+	doc["decoded"] = true
+
+	translator := &documentTranslator{}
+	rollout, err := NewRolloutTranslator(translator, translator, RolloutConfig{Repositories: []string{"team/*"}})
+	require.NoError(t, err)
+
+	_, err = TranslatePayload(rollout, payload)
+	require.NoError(t, err)
+
+	require.Len(t, translator.documents, 1, "payload should be translated once")
+	assert.Equal(t, true, translator.documents[0]["decoded"], "translator should reuse the decoded document")
+}
+
+func TestPayloadSizeLimit(t *testing.T) {
+
+	data := []byte(`{"ref": "refs/heads/main", "total_commits": 1, "commits": [{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2"}]}`)
+
+	limit := int64(len(data) - 1)
+
+	_, err := NewPayload(data, WithMaxSize(limit)).Document()
+	assert.ErrorIs(t, err, ErrPayloadTooLarge, "document should not be decoded")
+
+	_, err = NewGiteaPushTranslator(GiteaConfig{MaxPayloadSize: limit}).Translate(data)
+	assert.ErrorIs(t, err, ErrPayloadTooLarge, "push event should not be decoded")
+
+	_, err = NewPayload(data).Document()
+	assert.NoError(t, err, "payload should be decoded within the default limit")
+
+	_, err = NewPayload(data, WithMaxSize(-1)).Document()
+	assert.NoError(t, err, "payload should be decoded without a limit")
+}
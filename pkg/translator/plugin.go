@@ -0,0 +1,68 @@
+package translator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"plugin"
+	"sort"
+)
+
+// PluginSymbol is the name of the variable a translator plugin exports with its translators
+// keyed by the webhook subject they handle:
+//
+//	var Translators = map[string]translator.CDEventTranslator{
+//		"jenkins.build": &JenkinsBuildTranslator{},
+//	}
+//
+// Plugins must be built with -buildmode=plugin against the same version of this module and Go
+// toolchain as the adapter, and loading them requires a binary built with cgo enabled.
+const PluginSymbol = "Translators"
+
+// LoadPlugins opens every .so file in dir and returns a catalog of the translators they export,
+// named by webhook subject. It is an error for two plugins to export the same subject.
+func LoadPlugins(dir string) (Catalog, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.so"))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("unable to read plugin directory: %w", err)
+	}
+	sort.Strings(paths)
+
+	catalog := make(Catalog)
+	for _, path := range paths {
+		translators, err := loadPlugin(path)
+		if err != nil {
+			return nil, err
+		}
+		for subject, t := range translators {
+			if _, exists := catalog[subject]; exists {
+				return nil, fmt.Errorf("translator for subject %s exported by more than one plugin: %s", subject, path)
+			}
+			catalog[subject] = t
+		}
+	}
+
+	return catalog, nil
+}
+
+func loadPlugin(path string) (map[string]CDEventTranslator, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open translator plugin %s: %w", path, err)
+	}
+
+	symbol, err := p.Lookup(PluginSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("invalid translator plugin %s: %w", path, err)
+	}
+
+	translators, ok := symbol.(*map[string]CDEventTranslator)
+	if !ok {
+		return nil, fmt.Errorf("invalid translator plugin %s: %s is %T, not map[string]translator.CDEventTranslator", path, PluginSymbol, symbol)
+	}
+
+	return *translators, nil
+}
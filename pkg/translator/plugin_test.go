@@ -0,0 +1,26 @@
+package translator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadPluginsErrors(t *testing.T) {
+
+	empty := t.TempDir()
+	catalog, err := LoadPlugins(empty)
+	require.NoError(t, err)
+	assert.Empty(t, catalog)
+
+	invalid := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(invalid, "invalid.so"), []byte("not a plugin"), 0o644))
+	_, err = LoadPlugins(invalid)
+	assert.ErrorContains(t, err, "unable to open translator plugin")
+
+	_, err = LoadPlugins(filepath.Join(empty, "missing"))
+	assert.ErrorContains(t, err, "unable to read plugin directory")
+}
@@ -0,0 +1,161 @@
+package translator
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Catalog lists the available translators by name so that they can be mapped to webhook
+// subjects through configuration.
+type Catalog map[string]CDEventTranslator
+
+// Builtin returns a catalog of the built-in translators with the default options.
+func Builtin() Catalog {
+	return BuiltinWithConfig(GiteaConfig{})
+}
+
+// BuiltinWithConfig returns a catalog of the built-in translators created with the given options.
+func BuiltinWithConfig(config GiteaConfig) Catalog {
+	// The Gitea translators share the API, so that they share its cache and back off together.
+	api := newGiteaAPI(config.API)
+	return Catalog{
+		"gitea.push":         newGiteaPushTranslator(config, api),
+		"gitea.pull_request": newGiteaPullRequestTranslator(config, api),
+		"gitea.create":       newGiteaCreateTranslator(config, api),
+		"gitea.delete":       newGiteaDeleteTranslator(config, api),
+	}
+}
+
+// Resolve maps webhook subjects to the translators with the given names. Several subjects can
+// be mapped to the same translator.
+func (c Catalog) Resolve(mappings map[string]string) (map[string]CDEventTranslator, error) {
+	translators := make(map[string]CDEventTranslator, len(mappings))
+	for subject, name := range mappings {
+		t, exists := c[name]
+		if !exists {
+			return nil, fmt.Errorf("unknown translator %s for subject: %s", name, subject)
+		}
+		translators[subject] = t
+	}
+	return translators, nil
+}
+
+// Registry maps webhook subjects, e.g. "gitea.push", to the translators that handle them. It
+// is safe for concurrent use.
+type Registry struct {
+	mu          sync.RWMutex
+	translators map[string]CDEventTranslator
+	catalog     Catalog
+	// overrides are the translators of the subjects registered, mapped or unregistered at
+	// runtime, which take precedence over the translators given to Replace. Unregistered
+	// subjects have no translator.
+	overrides map[string]CDEventTranslator
+}
+
+// NewRegistry creates a registry with the given translators keyed by webhook subject.
+func NewRegistry(translators map[string]CDEventTranslator) *Registry {
+	r := &Registry{}
+	r.Replace(translators)
+	return r
+}
+
+// SetCatalog sets the translators that subjects can be mapped to by name with Map.
+func (r *Registry) SetCatalog(catalog Catalog) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.catalog = catalog
+}
+
+// Register adds a translator for a webhook subject. It is an error to register two
+// translators for the same subject.
+func (r *Registry) Register(subject string, t CDEventTranslator) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.translators[subject]; exists {
+		return fmt.Errorf("translator already registered for subject: %s", subject)
+	}
+	r.override(subject, t)
+	return nil
+}
+
+// Map maps a webhook subject to the catalog translator with the given name, replacing any
+// translator already registered for the subject.
+func (r *Registry) Map(subject, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, exists := r.catalog[name]
+	if !exists {
+		return fmt.Errorf("unknown translator: %s", name)
+	}
+	r.override(subject, t)
+	return nil
+}
+
+// Unregister removes the translator for a webhook subject and reports whether there was one.
+func (r *Registry) Unregister(subject string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, exists := r.translators[subject]
+	r.override(subject, nil)
+	return exists
+}
+
+// override sets the translator of a subject, or removes it if nil, and keeps it when the
+// translators are replaced. Must be called with the lock held.
+func (r *Registry) override(subject string, t CDEventTranslator) {
+	if r.overrides == nil {
+		r.overrides = make(map[string]CDEventTranslator)
+	}
+	r.overrides[subject] = t
+	if t == nil {
+		delete(r.translators, subject)
+	} else {
+		r.translators[subject] = t
+	}
+}
+
+// Replace replaces all registered translators. Subjects registered, mapped or unregistered with
+// Register, Map or Unregister keep that translator, so that reloading the configuration does not
+// undo changes made at runtime.
+func (r *Registry) Replace(translators map[string]CDEventTranslator) {
+	copied := make(map[string]CDEventTranslator, len(translators))
+	for subject, t := range translators {
+		copied[subject] = t
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for subject, t := range r.overrides {
+		if t == nil {
+			delete(copied, subject)
+		} else {
+			copied[subject] = t
+		}
+	}
+	r.translators = copied
+}
+
+func (r *Registry) Lookup(subject string) (CDEventTranslator, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, exists := r.translators[subject]
+	return t, exists
+}
+
+// Subjects returns the registered webhook subjects in sorted order.
+func (r *Registry) Subjects() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subjects := make([]string, 0, len(r.translators))
+	for subject := range r.translators {
+		subjects = append(subjects, subject)
+	}
+	sort.Strings(subjects)
+	return subjects
+}
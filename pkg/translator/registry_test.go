@@ -0,0 +1,59 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+
+	registry := NewRegistry(map[string]CDEventTranslator{
+		"gitea.push": &GiteaPushTranslator{},
+	})
+
+	require.NoError(t, registry.Register("gitea.create", &GiteaCreateTranslator{}))
+	require.EqualError(t, registry.Register("gitea.push", &GiteaPushTranslator{}), "translator already registered for subject: gitea.push")
+
+	assert.Equal(t, []string{"gitea.create", "gitea.push"}, registry.Subjects())
+
+	push, exists := registry.Lookup("gitea.push")
+	require.True(t, exists)
+	assert.IsType(t, &GiteaPushTranslator{}, push)
+
+	_, exists = registry.Lookup("gitea.delete")
+	assert.False(t, exists)
+}
+
+func TestRegistryMapping(t *testing.T) {
+
+	translators, err := Builtin().Resolve(map[string]string{
+		"gitea.push":   "gitea.push",
+		"forgejo.push": "gitea.push",
+	})
+	require.NoError(t, err)
+
+	registry := NewRegistry(translators)
+	registry.SetCatalog(Builtin())
+
+	assert.Equal(t, []string{"forgejo.push", "gitea.push"}, registry.Subjects())
+
+	require.NoError(t, registry.Map("forgejo.create", "gitea.create"))
+	require.EqualError(t, registry.Map("forgejo.fork", "gitea.fork"), "unknown translator: gitea.fork")
+
+	create, exists := registry.Lookup("forgejo.create")
+	require.True(t, exists)
+	assert.IsType(t, &GiteaCreateTranslator{}, create)
+
+	assert.True(t, registry.Unregister("forgejo.push"))
+	assert.False(t, registry.Unregister("forgejo.push"))
+	assert.Equal(t, []string{"forgejo.create", "gitea.push"}, registry.Subjects())
+
+	// Replacing the translators, e.g. on reload, keeps the changes made at runtime.
+	registry.Replace(translators)
+	assert.Equal(t, []string{"forgejo.create", "gitea.push"}, registry.Subjects())
+
+	_, err = Builtin().Resolve(map[string]string{"forgejo.fork": "gitea.fork"})
+	require.EqualError(t, err, "unknown translator gitea.fork for subject: forgejo.fork")
+}
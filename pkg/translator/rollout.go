@@ -0,0 +1,85 @@
+package translator
+
+import (
+	"fmt"
+	"hash/fnv"
+	"slices"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/glob"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+)
+
+// RolloutConfig rolls out a candidate translator for a webhook subject to a share of the
+// webhooks, or to specific repositories, before it replaces the current translator.
+type RolloutConfig struct {
+	// Subject is the webhook subject, e.g. "gitea.push".
+	Subject string `envconfig:"SUBJECT"`
+	// Translator is the name of the candidate translator.
+	Translator string `envconfig:"TRANSLATOR"`
+	// Percent is the share of webhooks translated by the candidate.
+	Percent int `envconfig:"PERCENT" default:"0"`
+	// Repositories are glob patterns of repositories, e.g. "platform/*", whose webhooks are
+	// always translated by the candidate.
+	Repositories []string `envconfig:"REPOSITORIES"`
+}
+
+// RolloutTranslator translates webhooks with either the current or the candidate translator of
+// a rollout. The choice is based on a hash of the payload, so a redelivered webhook is
+// translated by the same translator.
+type RolloutTranslator struct {
+	current   CDEventTranslator
+	candidate CDEventTranslator
+	config    RolloutConfig
+}
+
+func NewRolloutTranslator(current, candidate CDEventTranslator, config RolloutConfig) (*RolloutTranslator, error) {
+	if config.Percent < 0 || config.Percent > 100 {
+		return nil, fmt.Errorf("rollout percent must be between 0 and 100: %d", config.Percent)
+	}
+
+	return &RolloutTranslator{current: current, candidate: candidate, config: config}, nil
+}
+
+func (r *RolloutTranslator) Translate(data []byte) (cdevents.CDEvent, error) {
+	return r.TranslatePayload(NewPayload(data))
+}
+
+func (r *RolloutTranslator) TranslatePayload(payload *Payload) (cdevents.CDEvent, error) {
+	if r.usesCandidate(payload) {
+		return TranslatePayload(r.candidate, payload)
+	}
+	return TranslatePayload(r.current, payload)
+}
+
+// DependentFields returns the fields declared by the current and the candidate translator.
+func (r *RolloutTranslator) DependentFields() []string {
+	fields := DependentFields(r.current)
+	for _, field := range DependentFields(r.candidate) {
+		if !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// UsesCandidate reports whether a webhook payload is translated by the candidate translator.
+func (r *RolloutTranslator) UsesCandidate(data []byte) bool {
+	return r.usesCandidate(NewPayload(data))
+}
+
+func (r *RolloutTranslator) usesCandidate(payload *Payload) bool {
+	if len(r.config.Repositories) > 0 {
+		if doc, err := payload.Document(); err == nil {
+			repository, _ := doc["repository"].(map[string]interface{})
+			fullName, _ := repository["full_name"].(string)
+			if glob.MatchAny(r.config.Repositories, fullName) {
+				return true
+			}
+		}
+	}
+
+	hash := fnv.New32a()
+	hash.Write(payload.Data())
+	return int(hash.Sum32()%100) < r.config.Percent
+}
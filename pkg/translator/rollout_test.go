@@ -0,0 +1,97 @@
+package translator
+
+import (
+	"fmt"
+	"testing"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type namedTranslator string
+
+func (n namedTranslator) Translate(data []byte) (cdevents.CDEvent, error) {
+	return nil, fmt.Errorf("%s", string(n))
+}
+
+func TestRolloutTranslator(t *testing.T) {
+
+	payload := func(i int, repository string) []byte {
+		return []byte(fmt.Sprintf(`{"id": %d, "repository": {"full_name": %q}}`, i, repository))
+	}
+
+	for _, tc := range []struct {
+		title             string
+		config            RolloutConfig
+		repository        string
+		expectedCandidate int
+	}{
+		{
+			title:             "translates nothing with the candidate at zero percent",
+			config:            RolloutConfig{Percent: 0},
+			repository:        "team/api",
+			expectedCandidate: 0,
+		},
+		{
+			title:             "translates everything with the candidate at hundred percent",
+			config:            RolloutConfig{Percent: 100},
+			repository:        "team/api",
+			expectedCandidate: 1000,
+		},
+		{
+			title:             "translates selected repositories with the candidate",
+			config:            RolloutConfig{Repositories: []string{"platform/*"}},
+			repository:        "platform/api",
+			expectedCandidate: 1000,
+		},
+		{
+			title:             "translates other repositories with the current translator",
+			config:            RolloutConfig{Repositories: []string{"platform/*"}},
+			repository:        "team/api",
+			expectedCandidate: 0,
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			rollout, err := NewRolloutTranslator(namedTranslator("current"), namedTranslator("candidate"), tc.config)
+			require.NoError(t, err)
+
+			candidate := 0
+			for i := 0; i < 1000; i++ {
+				_, err := rollout.Translate(payload(i, tc.repository))
+				if err.Error() == "candidate" {
+					candidate++
+				}
+			}
+			assert.Equal(t, tc.expectedCandidate, candidate)
+		})
+	}
+}
+
+func TestRolloutTranslatorPercent(t *testing.T) {
+
+	rollout, err := NewRolloutTranslator(namedTranslator("current"), namedTranslator("candidate"), RolloutConfig{Percent: 10})
+	require.NoError(t, err)
+
+	candidate := 0
+	for i := 0; i < 10000; i++ {
+		data := []byte(fmt.Sprintf(`{"id": %d}`, i))
+		if rollout.UsesCandidate(data) {
+			candidate++
+		}
+		assert.Equal(t, rollout.UsesCandidate(data), rollout.UsesCandidate(data), "choice should be stable")
+	}
+	assert.InDelta(t, 1000, candidate, 200)
+
+	_, err = NewRolloutTranslator(namedTranslator("current"), namedTranslator("candidate"), RolloutConfig{Percent: 101})
+	assert.ErrorContains(t, err, "rollout percent must be between 0 and 100")
+}
+
+func TestRolloutTranslatorDependentFields(t *testing.T) {
+
+	rollout, err := NewRolloutTranslator(NewGiteaCreateTranslator(GiteaConfig{}), NewGiteaPushTranslator(GiteaConfig{}), RolloutConfig{Percent: 10})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"$.ref", "$.ref_type", "$.repository.full_name", "$.repository.html_url", "$.total_commits", "$.commits[*].id"}, DependentFields(rollout))
+	assert.Nil(t, DependentFields(namedTranslator("current")))
+}
@@ -0,0 +1,80 @@
+package translator
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+)
+
+// SchemaBaseURI is where the JSON schemas of the custom data of the built-in translators are
+// published. The schemas are also embedded and loaded with LoadSchemas, so events can be
+// validated against them without fetching them.
+const SchemaBaseURI = "https://raw.githubusercontent.com/ansig/cdevents-jetstream-adapter/main/pkg/translator/schemas/"
+
+//go:embed schemas/*.json
+var schemas embed.FS
+
+// LoadSchemas loads the schemas of the custom data of the built-in translators into the
+// CDEvents SDK, which validates events with a schemaUri against the schema of that id.
+func LoadSchemas() error {
+	return loadSchemas(schemas, "schemas")
+}
+
+// LoadSchemaDir loads every .json file in dir as the schema of the custom data of events. The
+// schema is identified by its $id, which is the schemaUri of the events conforming to it.
+func LoadSchemaDir(dir string) error {
+	return loadSchemas(os.DirFS(dir), ".")
+}
+
+func loadSchemas(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		if err := loadSchema(data); err != nil {
+			return fmt.Errorf("invalid schema %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// loadSchema loads a schema unless a schema with the same id is already loaded, since the SDK
+// cannot replace a schema once it has been compiled.
+func loadSchema(data []byte) error {
+	var schema struct {
+		ID string `json:"$id"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return err
+	}
+	if schema.ID == "" {
+		return fmt.Errorf("schema has no $id")
+	}
+	if _, loaded := cdevents.CompiledCustomSchemas[schema.ID]; loaded {
+		return nil
+	}
+	return cdevents.LoadJsonSchema(schema.ID, data)
+}
+
+// SchemaLoaded reports whether the schema with the uri as $id is loaded.
+func SchemaLoaded(uri string) bool {
+	_, loaded := cdevents.CompiledCustomSchemas[uri]
+	return loaded
+}
+
+// setSchemaURI sets the schemaUri of events of spec versions that have one.
+func setSchemaURI(event cdevents.CDEvent, uri string) {
+	if writer, ok := event.(cdevents.CDEventWriterV04); ok && uri != "" {
+		writer.SetSchemaUri(uri)
+	}
+}
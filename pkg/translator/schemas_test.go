@@ -0,0 +1,94 @@
+package translator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"$id": "https://schemas.example.com/jenkins-build.json",
+	"type": "object",
+	"properties": {
+		"customData": {
+			"type": "object",
+			"properties": {"sender": {"type": "string"}},
+			"required": ["sender"]
+		}
+	},
+	"required": ["customData"]
+}`
+
+func TestGiteaTranslatorCustomDataSchemas(t *testing.T) {
+
+	require.NoError(t, LoadSchemas())
+	require.NoError(t, LoadSchemas(), "loading the schemas again should be a no-op")
+
+	const repository = `"repository": {"name": "project1", "owner": {"username": "yoloco"}, "full_name": "yoloco/project1", "html_url": "http://git.example.com/yoloco/project1"}`
+	config := GiteaConfig{CustomDataSchemas: true, MaxCommits: 1}
+
+	for _, tc := range []struct {
+		translator CDEventTranslator
+		payload    string
+		schema     string
+	}{
+		{NewGiteaPushTranslator(config), `{"ref": "refs/heads/main", "total_commits": 2, "commits": [{"id": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2"}, {"id": "0ba2f2b5c1dd00b7e09d1a1b1a5a7a2c3e0d9f11"}], ` + repository + `}`, "gitea-push.json"},
+		{NewGiteaPullRequestTranslator(config), `{"action": "closed", "number": 3, "pull_request": {"id": 3, "closed_at": null}, ` + repository + `}`, "gitea-pull_request.json"},
+		{NewGiteaCreateTranslator(config), `{"sha": "9d7b2d18bf7f315c666a4b3607f47bd452e7c8d2", "ref": "foo", "ref_type": "branch", ` + repository + `}`, "gitea-create.json"},
+		{NewGiteaDeleteTranslator(config), `{"ref": "foo", "ref_type": "branch", ` + repository + `}`, "gitea-delete.json"},
+	} {
+		t.Run(tc.schema, func(t *testing.T) {
+			event, err := tc.translator.Translate([]byte(tc.payload))
+			require.NoError(t, err)
+
+			v04, ok := event.(cdevents.CDEventV04)
+			require.True(t, ok)
+			assert.Equal(t, SchemaBaseURI+tc.schema, v04.GetSchemaUri())
+			assert.NoError(t, cdevents.Validate(event), "event should be valid against its custom data schema")
+		})
+	}
+
+	event, err := NewGiteaCreateTranslator(GiteaConfig{}).Translate([]byte(`{"ref": "foo", "ref_type": "branch", ` + repository + `}`))
+	require.NoError(t, err)
+	assert.Empty(t, event.(cdevents.CDEventV04).GetSchemaUri(), "schema should only be set when enabled")
+}
+
+func TestLoadSchemaDir(t *testing.T) {
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "jenkins-build.json"), []byte(testSchema), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("not a schema"), 0o644))
+
+	require.NoError(t, LoadSchemaDir(dir))
+	assert.True(t, SchemaLoaded("https://schemas.example.com/jenkins-build.json"))
+	assert.False(t, SchemaLoaded("https://schemas.example.com/unknown.json"))
+
+	jq, err := NewJQTranslator(JQConfig{
+		Program:   `{subject_id: .number, repository: .repository.full_name, custom_data: {sender: .sender.login}}`,
+		Type:      "dev.cdevents.change.merged.0.2.0",
+		Source:    "test",
+		SchemaURI: "https://schemas.example.com/jenkins-build.json",
+	})
+	require.NoError(t, err)
+	event, err := jq.Translate([]byte(testPullRequestPayload))
+	require.NoError(t, err)
+	assert.Equal(t, "https://schemas.example.com/jenkins-build.json", event.(cdevents.CDEventV04).GetSchemaUri())
+
+	jq, err = NewJQTranslator(JQConfig{
+		Program: `{subject_id: .number, repository: .repository.full_name, custom_data: {}, schema_uri: "https://schemas.example.com/jenkins-build.json"}`,
+		Type:    "dev.cdevents.change.merged.0.2.0",
+		Source:  "test",
+	})
+	require.NoError(t, err)
+	_, err = jq.Translate([]byte(testPullRequestPayload))
+	assert.ErrorContains(t, err, "invalid CDEvent from jq program", "custom data should be validated against the schema")
+
+	invalid := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(invalid, "schema.json"), []byte(`{"type": "object"}`), 0o644))
+	assert.EqualError(t, LoadSchemaDir(invalid), "invalid schema schema.json: schema has no $id")
+}
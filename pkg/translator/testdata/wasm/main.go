@@ -0,0 +1,21 @@
+// Translator compiled to WASM for tests: GOOS=wasip1 GOARCH=wasm go build
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+func main() {
+	var payload struct {
+		Number int  `json:"number"`
+		Fail   bool `json:"fail"`
+	}
+	if err := json.NewDecoder(os.Stdin).Decode(&payload); err != nil || payload.Fail {
+		fmt.Fprintln(os.Stderr, "unable to translate payload")
+		os.Exit(1)
+	}
+
+	fmt.Printf(`{"context":{"version":"0.4.1","id":"test","source":"wasm","type":"dev.cdevents.change.merged.0.2.0","timestamp":"2024-01-01T00:00:00Z"},"subject":{"id":"%d","type":"change","content":{"repository":{"id":"repo"}}}}`, payload.Number)
+}
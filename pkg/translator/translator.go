@@ -0,0 +1,40 @@
+// Package translator defines the interface for translating webhook payloads into CDEvents,
+// the registry of translators by webhook subject and the built-in Gitea translators.
+package translator
+
+import (
+	"errors"
+	"fmt"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+)
+
+// ErrSkipped is returned, wrapped with the reason, by translators for payloads that are
+// deliberately not translated, e.g. pushes to branches that are filtered out. Skipped messages
+// are acknowledged without being reported as failures.
+var ErrSkipped = errors.New("skipped")
+
+type CDEventTranslator interface {
+	Translate(data []byte) (cdevents.CDEvent, error)
+}
+
+// Skip returns an error wrapping ErrSkipped with the reason a payload was not translated.
+func Skip(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrSkipped, fmt.Sprintf(format, args...))
+}
+
+// FieldDependent is implemented by translators that declare the payload fields they read, as
+// JSONPath expressions such as $.repository.full_name, so that changes of the webhook schema of
+// the provider that affect them can be detected.
+type FieldDependent interface {
+	DependentFields() []string
+}
+
+// DependentFields returns the payload fields a translator declares that it reads, or nil if it
+// does not declare them.
+func DependentFields(t CDEventTranslator) []string {
+	if fd, ok := t.(FieldDependent); ok {
+		return fd.DependentFields()
+	}
+	return nil
+}
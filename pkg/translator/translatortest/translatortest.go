@@ -0,0 +1,192 @@
+// Package translatortest tests translators against fixtures: webhook payload files and golden
+// files with the expected CDEvents. It works for the built-in translators as well as for
+// translators of embedding services.
+//
+// A fixture directory holds a payload file <name>.json for every fixture together with either a
+// golden file <name>.golden with the expected CDEvent or a file <name>.error with the expected
+// error message. Golden files are written from the actual results when the tests are run with
+// TRANSLATORTEST_UPDATE=true, e.g. TRANSLATORTEST_UPDATE=true go test ./pkg/translator.
+package translatortest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+)
+
+const (
+	// NormalizedID replaces the generated id of translated events.
+	NormalizedID = "00000000-0000-0000-0000-000000000000"
+	// NormalizedTimestamp replaces the generated timestamp of translated events.
+	NormalizedTimestamp = "1970-01-01T00:00:00Z"
+)
+
+// UpdateEnv is the environment variable that makes the tests write golden files from the
+// translated events when set to true. It is an environment variable rather than a test flag, so
+// that packages importing translatortest do not share a flag that may clash with their own.
+const UpdateEnv = "TRANSLATORTEST_UPDATE"
+
+func updating() bool {
+	update, _ := strconv.ParseBool(os.Getenv(UpdateEnv))
+	return update
+}
+
+// Fixture is a webhook payload and the expected outcome of translating it.
+type Fixture struct {
+	Name    string
+	Payload string
+	Golden  string
+	Error   string
+}
+
+// Fixtures returns the fixtures in dir, sorted by name.
+func Fixtures(dir string) ([]Fixture, error) {
+	payloads, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(payloads) == 0 {
+		return nil, fmt.Errorf("no fixtures found in %s", dir)
+	}
+
+	fixtures := make([]Fixture, 0, len(payloads))
+	for _, payload := range payloads {
+		base := strings.TrimSuffix(payload, ".json")
+		fixtures = append(fixtures, Fixture{
+			Name:    filepath.Base(base),
+			Payload: payload,
+			Golden:  base + ".golden",
+			Error:   base + ".error",
+		})
+	}
+	return fixtures, nil
+}
+
+// Run translates the payload of every fixture in dir with the translator and compares the
+// outcome with the golden or error file of the fixture, each in a subtest named after it.
+func Run(t *testing.T, tr translator.CDEventTranslator, dir string) {
+	t.Helper()
+
+	fixtures, err := Fixtures(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fixture := range fixtures {
+		t.Run(fixture.Name, func(t *testing.T) {
+			RunFixture(t, tr, fixture)
+		})
+	}
+}
+
+// RunFixture translates the payload of a fixture with the translator and compares the outcome
+// with its golden or error file.
+func RunFixture(t testing.TB, tr translator.CDEventTranslator, fixture Fixture) {
+	t.Helper()
+
+	payload, err := os.ReadFile(fixture.Payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	event, translateErr := translator.TranslatePayload(tr, translator.NewPayload(payload))
+
+	if updating() {
+		writeGolden(t, fixture, event, translateErr)
+		return
+	}
+
+	expectedErr, err := os.ReadFile(fixture.Error)
+	if err == nil {
+		if translateErr == nil {
+			t.Fatalf("expected error %q, translated event of type %s", strings.TrimSpace(string(expectedErr)), event.GetType())
+		}
+		if translateErr.Error() != strings.TrimSpace(string(expectedErr)) {
+			t.Fatalf("expected error %q, got %q", strings.TrimSpace(string(expectedErr)), translateErr.Error())
+		}
+		return
+	} else if !errors.Is(err, os.ErrNotExist) {
+		t.Fatal(err)
+	}
+
+	if translateErr != nil {
+		t.Fatalf("failed to translate payload: %v", translateErr)
+	}
+
+	actual, err := Normalize(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden, err := os.ReadFile(fixture.Golden)
+	if err != nil {
+		t.Fatalf("failed to read golden file, run with %s=true to create it: %v", UpdateEnv, err)
+	}
+	expected, err := normalizeJSON(golden)
+	if err != nil {
+		t.Fatalf("invalid golden file %s: %v", fixture.Golden, err)
+	}
+
+	if !bytes.Equal(expected, actual) {
+		t.Fatalf("translated event does not match %s\n--- expected\n%s\n--- actual\n%s", fixture.Golden, expected, actual)
+	}
+}
+
+// Normalize returns the indented JSON of an event with its generated id and timestamp replaced,
+// so that it can be compared with a golden file.
+func Normalize(event cdevents.CDEventReader) ([]byte, error) {
+	data, err := cdevents.AsJsonBytes(event)
+	if err != nil {
+		return nil, err
+	}
+	return normalizeJSON(data)
+}
+
+func normalizeJSON(data []byte) ([]byte, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	if context, ok := doc["context"].(map[string]interface{}); ok {
+		if _, found := context["id"]; found {
+			context["id"] = NormalizedID
+		}
+		if _, found := context["timestamp"]; found {
+			context["timestamp"] = NormalizedTimestamp
+		}
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+func writeGolden(t testing.TB, fixture Fixture, event cdevents.CDEvent, translateErr error) {
+	t.Helper()
+
+	if translateErr != nil {
+		os.Remove(fixture.Golden)
+		if err := os.WriteFile(fixture.Error, []byte(translateErr.Error()+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	data, err := Normalize(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(fixture.Error)
+	if err := os.WriteFile(fixture.Golden, append(data, '\n'), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,128 @@
+package translatortest
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+	cdeventsv04 "github.com/cdevents/sdk-go/pkg/api/v04"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticTranslator struct {
+	event cdevents.CDEvent
+	err   error
+}
+
+func (s staticTranslator) Translate(data []byte) (cdevents.CDEvent, error) {
+	return s.event, s.err
+}
+
+func newTestEvent(t *testing.T) cdevents.CDEvent {
+	event, err := cdeventsv04.NewBranchCreatedEvent()
+	require.NoError(t, err)
+	event.SetSource("git.example.com")
+	event.SetSubjectId("foo")
+	return event
+}
+
+func TestNormalize(t *testing.T) {
+
+	first, err := Normalize(newTestEvent(t))
+	require.NoError(t, err)
+	second, err := Normalize(newTestEvent(t))
+	require.NoError(t, err)
+
+	assert.Equal(t, string(first), string(second), "generated id and timestamp should be normalized")
+	assert.Contains(t, string(first), NormalizedID)
+	assert.Contains(t, string(first), NormalizedTimestamp)
+}
+
+func TestRunFixture(t *testing.T) {
+
+	dir := t.TempDir()
+	writeFile := func(name, content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+
+	golden, err := Normalize(newTestEvent(t))
+	require.NoError(t, err)
+
+	writeFile("created.json", `{}`)
+	writeFile("created.golden", string(golden))
+	writeFile("skipped.json", `{}`)
+	writeFile("skipped.error", "skipped: tag\n")
+
+	fixtures, err := Fixtures(dir)
+	require.NoError(t, err)
+	require.Len(t, fixtures, 2)
+	assert.Equal(t, "created", fixtures[0].Name)
+	assert.Equal(t, "skipped", fixtures[1].Name)
+
+	RunFixture(t, staticTranslator{event: newTestEvent(t)}, fixtures[0])
+	RunFixture(t, staticTranslator{err: translator.Skip("tag")}, fixtures[1])
+
+	other, err := cdeventsv04.NewBranchDeletedEvent()
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		title      string
+		translator translator.CDEventTranslator
+		fixture    Fixture
+	}{
+		{title: "different event", translator: staticTranslator{event: other}, fixture: fixtures[0]},
+		{title: "unexpected error", translator: staticTranslator{err: translator.Skip("tag")}, fixture: fixtures[0]},
+		{title: "different error", translator: staticTranslator{err: translator.Skip("branch")}, fixture: fixtures[1]},
+		{title: "missing error", translator: staticTranslator{event: newTestEvent(t)}, fixture: fixtures[1]},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			recorder := &failureRecorder{TB: t}
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				RunFixture(recorder, tc.translator, tc.fixture)
+			}()
+			<-done
+			assert.True(t, recorder.failed, "fixture should fail")
+		})
+	}
+}
+
+// failureRecorder records a fatal failure instead of failing the test.
+type failureRecorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *failureRecorder) Helper() {}
+
+func (r *failureRecorder) Fatal(args ...any) { r.fail() }
+
+func (r *failureRecorder) Fatalf(format string, args ...any) { r.fail() }
+
+func (r *failureRecorder) fail() {
+	r.failed = true
+	runtime.Goexit()
+}
+
+func TestRunFixtureUpdatesGoldenFiles(t *testing.T) {
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "created.json"), []byte(`{}`), 0o644))
+	fixtures, err := Fixtures(dir)
+	require.NoError(t, err)
+
+	t.Setenv(UpdateEnv, "true")
+	RunFixture(t, staticTranslator{event: newTestEvent(t)}, fixtures[0])
+
+	golden, err := os.ReadFile(fixtures[0].Golden)
+	require.NoError(t, err)
+	expected, err := Normalize(newTestEvent(t))
+	require.NoError(t, err)
+	assert.Equal(t, string(expected)+"\n", string(golden))
+}
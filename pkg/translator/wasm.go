@@ -0,0 +1,222 @@
+package translator
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+	cdeventsv04 "github.com/cdevents/sdk-go/pkg/api/v04"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// WASMConfig configures translators compiled to WebAssembly. Every <name>.wasm file in Dir is
+// a translator named <name>, e.g. jenkins.build.wasm. Modules are WASI command modules that
+// read the webhook payload from stdin and write the CDEvent as JSON to stdout. A non-zero exit
+// code fails the translation with the message written to stderr. A module that writes more than
+// MaxOutputSize bytes, which defaults to DefaultMaxPayloadSize, is stopped.
+type WASMConfig struct {
+	Dir           string        `envconfig:"DIR"`
+	Timeout       time.Duration `envconfig:"TIMEOUT" default:"5s"`
+	MemoryMB      uint32        `envconfig:"MEMORY_MB" default:"64"`
+	MaxOutputSize int64         `envconfig:"MAX_OUTPUT_SIZE"`
+}
+
+// WASMHost runs WASM translators sandboxed, without access to the file system, network or
+// environment, and with limited memory and execution time.
+type WASMHost struct {
+	runtime wazero.Runtime
+	config  WASMConfig
+}
+
+func NewWASMHost(ctx context.Context, config WASMConfig) (*WASMHost, error) {
+	if config.MaxOutputSize <= 0 {
+		config.MaxOutputSize = DefaultMaxPayloadSize
+	}
+
+	runtimeConfig := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(config.MemoryMB * 16).
+		WithCloseOnContextDone(true)
+
+	runtime := wazero.NewRuntimeWithConfig(ctx, runtimeConfig)
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		runtime.Close(ctx)
+		return nil, err
+	}
+
+	return &WASMHost{runtime: runtime, config: config}, nil
+}
+
+// Load compiles every module in the configured directory and returns a catalog of them.
+func (h *WASMHost) Load() (Catalog, error) {
+	if _, err := os.Stat(h.config.Dir); err != nil {
+		return nil, fmt.Errorf("unable to read WASM translator directory: %w", err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(h.config.Dir, "*.wasm"))
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := make(Catalog, len(paths))
+	for _, path := range paths {
+		t := &WASMTranslator{host: h, path: path}
+		t.mu.Lock()
+		err := t.compile()
+		t.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		catalog[strings.TrimSuffix(filepath.Base(path), ".wasm")] = t
+	}
+
+	return catalog, nil
+}
+
+func (h *WASMHost) Close(ctx context.Context) error {
+	return h.runtime.Close(ctx)
+}
+
+// WASMTranslator translates webhook payloads with a WASM module. The module is compiled again
+// when the file changes, so that a translator can be updated without restarting.
+type WASMTranslator struct {
+	host    *WASMHost
+	path    string
+	mu      sync.Mutex
+	module  *wasmModule
+	modTime time.Time
+	// modules holds the current module and the replaced modules that are still running, by the
+	// checksum of their code. The runtime shares the compiled code of identical modules, so a
+	// module is reused rather than compiled again while it is open.
+	modules map[[sha256.Size]byte]*wasmModule
+}
+
+// wasmModule is a compiled module together with the number of translations running it. A module
+// that has been replaced is closed once the last of them is done.
+type wasmModule struct {
+	compiled wazero.CompiledModule
+	sum      [sha256.Size]byte
+	refs     int
+	replaced bool
+}
+
+func (t *WASMTranslator) Translate(data []byte) (cdevents.CDEvent, error) {
+	module, err := t.acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer t.release(module)
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.host.config.Timeout)
+	defer cancel()
+
+	stdout := &limitedBuffer{limit: t.host.config.MaxOutputSize, onOverflow: cancel}
+	stderr := &limitedBuffer{limit: t.host.config.MaxOutputSize, onOverflow: cancel}
+	config := wazero.NewModuleConfig().
+		WithName("").
+		WithStdin(bytes.NewReader(data)).
+		WithStdout(stdout).
+		WithStderr(stderr)
+
+	instance, err := t.host.runtime.InstantiateModule(ctx, module.compiled, config)
+	if err == nil {
+		instance.Close(ctx)
+	}
+
+	if stdout.overflowed || stderr.overflowed {
+		return nil, fmt.Errorf("wasm translator %s failed: %w: more than %d bytes", filepath.Base(t.path), errOutputTooLarge, t.host.config.MaxOutputSize)
+	}
+
+	var exitErr *sys.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 0) {
+		return nil, fmt.Errorf("wasm translator %s failed: %w: %s", filepath.Base(t.path), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return cdeventsv04.NewFromJsonBytes(stdout.Bytes())
+}
+
+// acquire returns the current module, compiled again if the file has changed, and keeps it open
+// until it is released.
+func (t *WASMTranslator) acquire() (*wasmModule, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.compile(); err != nil {
+		return nil, err
+	}
+
+	t.module.refs++
+	return t.module, nil
+}
+
+func (t *WASMTranslator) release(module *wasmModule) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	module.refs--
+	t.closeIfDone(module)
+}
+
+// closeIfDone closes a module that has been replaced once no translation runs it. Must be called
+// with the lock held.
+func (t *WASMTranslator) closeIfDone(module *wasmModule) {
+	if module.replaced && module.refs == 0 {
+		delete(t.modules, module.sum)
+		module.compiled.Close(context.Background())
+	}
+}
+
+// compile compiles the module if it has not been compiled or the file has changed. The module it
+// replaces is closed once no translation runs it. Must be called with the lock held.
+func (t *WASMTranslator) compile() error {
+	info, err := os.Stat(t.path)
+	if err != nil {
+		if t.module != nil {
+			return nil
+		}
+		return fmt.Errorf("unable to read wasm translator: %w", err)
+	}
+
+	if t.module != nil && info.ModTime().Equal(t.modTime) {
+		return nil
+	}
+
+	code, err := os.ReadFile(t.path)
+	if err != nil {
+		return fmt.Errorf("unable to read wasm translator: %w", err)
+	}
+
+	sum := sha256.Sum256(code)
+	module, exists := t.modules[sum]
+	if !exists {
+		compiled, err := t.host.runtime.CompileModule(context.Background(), code)
+		if err != nil {
+			return fmt.Errorf("unable to compile wasm translator %s: %w", filepath.Base(t.path), err)
+		}
+		module = &wasmModule{compiled: compiled, sum: sum}
+		if t.modules == nil {
+			t.modules = make(map[[sha256.Size]byte]*wasmModule)
+		}
+		t.modules[sum] = module
+	}
+	module.replaced = false
+
+	if previous := t.module; previous != nil && previous != module {
+		previous.replaced = true
+		t.closeIfDone(previous)
+	}
+	t.module = module
+	t.modTime = info.ModTime()
+
+	return nil
+}
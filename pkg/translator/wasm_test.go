@@ -0,0 +1,96 @@
+package translator
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func buildWASMTranslator(t *testing.T, dir, name string) {
+	t.Helper()
+
+	build := exec.Command("go", "build", "-o", filepath.Join(dir, name+".wasm"), "./testdata/wasm")
+	build.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+	out, err := build.CombinedOutput()
+	require.NoError(t, err, "unable to build wasm translator: %s", out)
+}
+
+func TestWASMTranslator(t *testing.T) {
+
+	if testing.Short() {
+		t.Skip("building a wasm module is slow")
+	}
+
+	dir := t.TempDir()
+	buildWASMTranslator(t, dir, "test.merged")
+
+	ctx := context.Background()
+	host, err := NewWASMHost(ctx, WASMConfig{Dir: dir, Timeout: 10 * time.Second, MemoryMB: 64})
+	require.NoError(t, err)
+	defer host.Close(ctx)
+
+	catalog, err := host.Load()
+	require.NoError(t, err)
+	require.Contains(t, catalog, "test.merged")
+
+	event, err := catalog["test.merged"].Translate([]byte(`{"number": 42}`))
+	require.NoError(t, err)
+	assert.Equal(t, "dev.cdevents.change.merged.0.2.0", event.GetType().String())
+	assert.Equal(t, "42", event.GetSubjectId())
+
+	_, err = catalog["test.merged"].Translate([]byte(`{"fail": true}`))
+	assert.ErrorContains(t, err, "unable to translate payload")
+
+	// Translations running while the module is compiled again keep using the previous module.
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := catalog["test.merged"].Translate([]byte(`{"number": 42}`))
+			assert.NoError(t, err)
+		}()
+		modTime := time.Now().Add(time.Duration(i+1) * time.Second)
+		require.NoError(t, os.Chtimes(filepath.Join(dir, "test.merged.wasm"), modTime, modTime))
+	}
+	wg.Wait()
+
+	limited, err := NewWASMHost(ctx, WASMConfig{Dir: dir, Timeout: 10 * time.Second, MemoryMB: 64, MaxOutputSize: 16})
+	require.NoError(t, err)
+	defer limited.Close(ctx)
+
+	catalog, err = limited.Load()
+	require.NoError(t, err)
+
+	_, err = catalog["test.merged"].Translate([]byte(`{"number": 42}`))
+	assert.ErrorIs(t, err, errOutputTooLarge)
+}
+
+func TestWASMHostErrors(t *testing.T) {
+
+	ctx := context.Background()
+
+	invalid := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(invalid, "invalid.wasm"), []byte("not wasm"), 0o644))
+
+	host, err := NewWASMHost(ctx, WASMConfig{Dir: invalid, Timeout: time.Second, MemoryMB: 16})
+	require.NoError(t, err)
+	defer host.Close(ctx)
+
+	_, err = host.Load()
+	assert.ErrorContains(t, err, "unable to compile wasm translator invalid.wasm")
+
+	missing, err := NewWASMHost(ctx, WASMConfig{Dir: filepath.Join(invalid, "missing"), Timeout: time.Second, MemoryMB: 16})
+	require.NoError(t, err)
+	defer missing.Close(ctx)
+
+	_, err = missing.Load()
+	assert.ErrorContains(t, err, "unable to read WASM translator directory")
+}
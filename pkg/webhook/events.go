@@ -0,0 +1,41 @@
+package webhook
+
+// giteaEvents are the event types Gitea sends in the X-Gitea-Event header.
+var giteaEvents = map[string]bool{
+	"create":                       true,
+	"delete":                       true,
+	"fork":                         true,
+	"push":                         true,
+	"issues":                       true,
+	"issue_assign":                 true,
+	"issue_label":                  true,
+	"issue_milestone":              true,
+	"issue_comment":                true,
+	"pull_request":                 true,
+	"pull_request_assign":          true,
+	"pull_request_label":           true,
+	"pull_request_milestone":       true,
+	"pull_request_comment":         true,
+	"pull_request_review_approved": true,
+	"pull_request_review_rejected": true,
+	"pull_request_review_comment":  true,
+	"pull_request_sync":            true,
+	"pull_request_review_request":  true,
+	"wiki":                         true,
+	"repository":                   true,
+	"release":                      true,
+	"package":                      true,
+	"status":                       true,
+	"workflow_run":                 true,
+	"workflow_job":                 true,
+}
+
+// detectedEvent returns the provider and event of a webhook, e.g. gitea.push, or unknown when
+// the headers name no known event. Unlike the subject, it is bounded to the known events, so
+// that it can be used as a key of state kept per event.
+func detectedEvent(giteaEventHeader string) string {
+	if giteaEvents[giteaEventHeader] {
+		return "gitea." + giteaEventHeader
+	}
+	return "unknown"
+}
@@ -0,0 +1,44 @@
+package webhook
+
+import (
+	"github.com/ansig/cdevents-jetstream-adapter/internal/glob"
+)
+
+// RepositoryFilter decides which repositories webhooks are accepted for by matching their full
+// name, e.g. "platform/api", against glob patterns. A pattern like "platform/*" matches every
+// repository of an organization.
+type RepositoryFilter struct {
+	// Allow are the patterns of accepted repositories. Empty means every repository.
+	Allow []string `envconfig:"ALLOW"`
+	// Deny are the patterns of repositories that are rejected even if they are allowed.
+	Deny []string `envconfig:"DENY"`
+}
+
+func (f RepositoryFilter) Enabled() bool {
+	return len(f.Allow) > 0 || len(f.Deny) > 0
+}
+
+// Validate checks the allow and deny patterns.
+func (f RepositoryFilter) Validate() error {
+	if err := glob.Validate(f.Allow); err != nil {
+		return err
+	}
+	return glob.Validate(f.Deny)
+}
+
+func (f RepositoryFilter) Allows(fullName string) bool {
+	if glob.MatchAny(f.Deny, fullName) {
+		return false
+	}
+	return len(f.Allow) == 0 || glob.MatchAny(f.Allow, fullName)
+}
+
+// repositoryName returns the full name of the repository of a webhook payload, if it has one.
+func repositoryName(payload map[string]interface{}) (string, bool) {
+	repository, ok := payload["repository"].(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	fullName, ok := repository["full_name"].(string)
+	return fullName, ok && fullName != ""
+}
@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRepositoryFilterAllows(t *testing.T) {
+
+	for _, tc := range []struct {
+		title    string
+		filter   RepositoryFilter
+		fullName string
+		expected bool
+	}{
+		{
+			title:    "allows every repository without patterns",
+			fullName: "team/api",
+			expected: true,
+		},
+		{
+			title:    "allows repository of allowed organization",
+			filter:   RepositoryFilter{Allow: []string{"platform/*", "team/web"}},
+			fullName: "platform/api",
+			expected: true,
+		},
+		{
+			title:    "rejects repository that is not allowed",
+			filter:   RepositoryFilter{Allow: []string{"platform/*", "team/web"}},
+			fullName: "team/api",
+		},
+		{
+			title:    "rejects denied repository of allowed organization",
+			filter:   RepositoryFilter{Allow: []string{"platform/*"}, Deny: []string{"platform/sandbox"}},
+			fullName: "platform/sandbox",
+		},
+		{
+			title:    "rejects denied organization without allow patterns",
+			filter:   RepositoryFilter{Deny: []string{"archive/*"}},
+			fullName: "archive/api",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.filter.Allows(tc.fullName))
+		})
+	}
+}
+
+func TestRepositoryFilterValidate(t *testing.T) {
+
+	assert.NoError(t, RepositoryFilter{Allow: []string{"platform/*"}, Deny: []string{"platform/sandbox"}}.Validate())
+	assert.ErrorContains(t, RepositoryFilter{Allow: []string{"platform/[api"}}.Validate(), "invalid pattern platform/[api")
+	assert.ErrorContains(t, RepositoryFilter{Deny: []string{"archive/["}}.Validate(), "invalid pattern archive/[")
+}
+
+func TestHttpWebhookRepositoryFilter(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	webhook := NewHttpWebhook(logger)
+	webhook.SetRepositoryFilter(RepositoryFilter{Allow: []string{"platform/*"}})
+
+	for _, tc := range []struct {
+		title           string
+		requestBody     string
+		expectPublished bool
+		expectedBody    string
+	}{
+		{
+			title:           "publishes webhook for allowed repository",
+			requestBody:     `{"repository": {"full_name": "platform/api"}}`,
+			expectPublished: true,
+			expectedBody:    "OK",
+		},
+		{
+			title:        "drops webhook for repository that is not allowed",
+			requestBody:  `{"repository": {"full_name": "team/api"}}`,
+			expectedBody: "Ignored",
+		},
+		{
+			title:           "publishes webhook without repository",
+			requestBody:     `{"foo": "bar"}`,
+			expectPublished: true,
+			expectedBody:    "OK",
+		},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			mockJS := &MockJetStreamClient{}
+			mockJS.On("PublishMsg", mock.Anything, mock.Anything).Return(&jetstream.PubAck{Stream: "mockStream"}, nil)
+
+			webhook.GetHandler(mockJS, "test").ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tc.expectedBody, rec.Body.String())
+			if tc.expectPublished {
+				mockJS.AssertCalled(t, "PublishMsg", mock.Anything, []byte(tc.requestBody))
+			} else {
+				mockJS.AssertNotCalled(t, "PublishMsg", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+// Package webhook receives webhooks over HTTP and publishes them to JetStream for the adapter
+// to process.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/bufpool"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/correlation"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/tracing"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type JetStreamClient interface {
+	PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+}
+
+// Redactor removes sensitive data from a decoded webhook payload in place.
+type Redactor interface {
+	Redact(doc interface{})
+}
+
+// Capturer keeps copies of published webhook payloads, e.g. to collect fixtures. The payload
+// must not be retained after Capture returns.
+type Capturer interface {
+	Capture(event string, deliveryID string, data []byte)
+}
+
+type HttpWebhook struct {
+	logger       *slog.Logger
+	repositories RepositoryFilter
+	redactor     Redactor
+	capturer     Capturer
+	maxBodySize  int64
+}
+
+func NewHttpWebhook(logger *slog.Logger) *HttpWebhook {
+	return &HttpWebhook{logger: logger, maxBodySize: translator.DefaultMaxPayloadSize}
+}
+
+// SetMaxBodySize sets the size limit in bytes of webhook bodies. Larger bodies are rejected with
+// 413 Request Entity Too Large before they are read in full. Zero means
+// translator.DefaultMaxPayloadSize and less than zero disables the limit.
+func (s *HttpWebhook) SetMaxBodySize(size int64) {
+	if size == 0 {
+		size = translator.DefaultMaxPayloadSize
+	}
+	s.maxBodySize = size
+}
+
+// SetRepositoryFilter restricts the repositories webhooks are published for. Webhooks for other
+// repositories are acknowledged and dropped, while payloads without a repository are always
+// published.
+func (s *HttpWebhook) SetRepositoryFilter(filter RepositoryFilter) {
+	s.repositories = filter
+}
+
+// SetRedactor sets a redactor that is applied to payloads before they are published, so that
+// sensitive data is neither stored in the stream nor embedded in CDEvents.
+func (s *HttpWebhook) SetRedactor(redactor Redactor) {
+	s.redactor = redactor
+}
+
+// SetCapturer sets a capturer that is given every published payload, after redaction, together
+// with the known provider and event it was published for, e.g. gitea.push, or unknown.
+func (s *HttpWebhook) SetCapturer(capturer Capturer) {
+	s.capturer = capturer
+}
+
+func (s *HttpWebhook) GetHandler(jsClient JetStreamClient, subjectBase string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		spanCtx, span := tracing.Tracer().Start(tracing.HTTPContext(r.Context(), r.Header), "webhook receive",
+			trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+
+		correlationID := correlation.FromRequest(r)
+		w.Header().Set(correlation.RequestHeaders[0], correlationID)
+		logger := s.logger.With("correlation_id", correlationID)
+
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not supported", http.StatusNotImplemented)
+			return
+		}
+
+		contentType := r.Header.Get("Content-Type")
+		if contentType == "" {
+			http.Error(w, "Content-Type header not set", http.StatusBadRequest)
+			return
+		}
+
+		mt, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			http.Error(w, "Malformed Content-Type header", http.StatusBadRequest)
+			return
+		}
+
+		if mt != "application/json" {
+			http.Error(w, "Content-Type header must be application/json", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var subject, event string
+		giteaEventHeader := r.Header.Get("X-Gitea-Event")
+		if giteaEventHeader != "" {
+			logger.Debug(fmt.Sprintf("Setting message subject based on X-Gitea-Event header: %s", giteaEventHeader))
+			event = fmt.Sprintf("gitea.%s", giteaEventHeader)
+			subject = fmt.Sprintf("%s.%s", subjectBase, event)
+		} else {
+			event = "unknown"
+			subject = fmt.Sprintf("%s.%s", subjectBase, event)
+			logger.Warn(fmt.Sprintf("Found no known headers on which to route incoming webhook message, sending to subject: %s", subject))
+		}
+
+		// The body is read into a pooled buffer and published without copying, so the buffer is
+		// returned to the pool only once the handler is done with it.
+		body := bufpool.Get()
+		defer bufpool.Put(body)
+
+		if s.maxBodySize > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, s.maxBodySize)
+		}
+		if _, err := body.ReadFrom(r.Body); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				logger.Warn("Rejecting webhook body larger than the limit", "limit", tooLarge.Limit)
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			logger.Error("Failure when reading request body", "error", err.Error())
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		data := body.Bytes()
+
+		if len(data) == 0 {
+			http.Error(w, "Received empty body", http.StatusBadRequest)
+			return
+		}
+
+		// Numbers are kept as json.Number, so that a redacted payload is encoded again without
+		// losing the precision of large ids.
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+
+		var v map[string]interface{}
+		if err := decoder.Decode(&v); err != nil || decoder.More() {
+			http.Error(w, "Payload is not valid json", http.StatusBadRequest)
+			return
+		}
+
+		repository, found := repositoryName(v)
+		if found && !s.repositories.Allows(repository) {
+			logger.Debug(fmt.Sprintf("Dropping webhook for repository that is not allowed: %s", repository))
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("Ignored"))
+			return
+		}
+
+		if s.redactor != nil {
+			s.redactor.Redact(v)
+
+			redacted := bufpool.Get()
+			defer bufpool.Put(redacted)
+
+			if err := json.NewEncoder(redacted).Encode(v); err != nil {
+				logger.Error("Failure when encoding redacted payload", "error", err.Error())
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+			data = bytes.TrimSuffix(redacted.Bytes(), []byte("\n"))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		logger.Debug(fmt.Sprintf("Publishing incoming webhook to Jetstream subject: %s", subject))
+
+		span.SetAttributes(attribute.String("messaging.destination.name", subject))
+
+		msg := nats.NewMsg(subject)
+		msg.Data = data
+		tracing.InjectNATS(spanCtx, msg.Header)
+		msg.Header.Set(correlation.Header, correlationID)
+		deliveryID := r.Header.Get("X-Gitea-Delivery")
+		if deliveryID != "" {
+			msg.Header.Set(adapter.DeliveryIDHeader, deliveryID)
+		}
+		if found {
+			msg.Header.Set(adapter.RepositoryHeader, repository)
+		}
+
+		_, err = jsClient.PublishMsg(ctx, msg)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, "failed to publish webhook")
+			logger.Error("Error when publishing event to Jetstream", "error", err.Error())
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if s.capturer != nil {
+			s.capturer.Capture(detectedEvent(giteaEventHeader), deliveryID, data)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("OK"))
+	})
+}
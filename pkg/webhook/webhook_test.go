@@ -0,0 +1,329 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockJetStreamClient struct {
+	mock.Mock
+}
+
+func (m *MockJetStreamClient) PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	args := m.Called(msg.Subject, msg.Data)
+	return args.Get(0).(*jetstream.PubAck), args.Error(1)
+}
+
+type httpWebhookHandlerTC struct {
+	title                  string
+	requestMethod          string
+	requestBody            string
+	requestHeaders         map[string][]string
+	jetstreamSubjectBase   string
+	expectedPublishSubject string
+	expectedPublishData    string
+	expectedResponseCode   int
+	expectedResponseBody   string
+}
+
+func newDefaultWebhookHandlerTC() httpWebhookHandlerTC {
+	return httpWebhookHandlerTC{
+		requestMethod: http.MethodPost,
+		requestBody:   "{\"foo\": \"bar\"}",
+		requestHeaders: map[string][]string{
+			"Content-Type": {"application/json"},
+		},
+		expectedResponseCode: http.StatusOK,
+		expectedResponseBody: `OK`,
+	}
+}
+
+func TestHttpWebhookHandler(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	webhook := NewHttpWebhook(logger)
+
+	for _, tc := range []httpWebhookHandlerTC{
+		func() httpWebhookHandlerTC {
+			tc := newDefaultWebhookHandlerTC()
+			tc.title = "ok on POST request with valid headers and JSON payload"
+			return tc
+		}(),
+		func() httpWebhookHandlerTC {
+			tc := newDefaultWebhookHandlerTC()
+			tc.title = "error on GET request"
+			tc.requestMethod = http.MethodGet
+			tc.expectedResponseCode = http.StatusNotImplemented
+			tc.expectedResponseBody = `Method not supported`
+			return tc
+		}(),
+		func() httpWebhookHandlerTC {
+			tc := newDefaultWebhookHandlerTC()
+			tc.title = "error without Content-Type header"
+			tc.requestHeaders = map[string][]string{}
+			tc.expectedResponseCode = http.StatusBadRequest
+			tc.expectedResponseBody = `Content-Type header not set`
+			return tc
+		}(),
+		func() httpWebhookHandlerTC {
+			tc := newDefaultWebhookHandlerTC()
+			tc.title = "error with malformed Content-Type header"
+			tc.requestHeaders = map[string][]string{
+				"Content-Type": {"malformed;thing"},
+			}
+			tc.expectedResponseCode = http.StatusBadRequest
+			tc.expectedResponseBody = `Malformed Content-Type header`
+			return tc
+		}(),
+		func() httpWebhookHandlerTC {
+			tc := newDefaultWebhookHandlerTC()
+			tc.title = "error with empty body"
+			tc.requestBody = ""
+			tc.expectedResponseCode = http.StatusBadRequest
+			tc.expectedResponseBody = `Received empty body`
+			return tc
+		}(),
+		func() httpWebhookHandlerTC {
+			tc := newDefaultWebhookHandlerTC()
+			tc.title = "error with invalid Json body"
+			tc.requestBody = "notvalidjson"
+			tc.expectedResponseCode = http.StatusBadRequest
+			tc.expectedResponseBody = `Payload is not valid json`
+			return tc
+		}(),
+		func() httpWebhookHandlerTC {
+			tc := newDefaultWebhookHandlerTC()
+			tc.title = "publish to subject test.unknown without any known headers"
+			tc.jetstreamSubjectBase = "test"
+			tc.expectedPublishSubject = "test.unknown"
+			return tc
+		}(),
+		func() httpWebhookHandlerTC {
+			tc := newDefaultWebhookHandlerTC()
+			tc.title = "publish to subject test.gitea.push with X-Gitea-Event header"
+			tc.requestHeaders["X-Gitea-Event"] = []string{"push"}
+			tc.jetstreamSubjectBase = "test"
+			tc.expectedPublishSubject = "test.gitea.push"
+			return tc
+		}(),
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+
+			reader := strings.NewReader(tc.requestBody)
+
+			req := httptest.NewRequest(tc.requestMethod, "/", reader)
+			for k, v := range tc.requestHeaders {
+				req.Header.Add(k, strings.Join(v, ","))
+			}
+			rec := httptest.NewRecorder()
+
+			mockJS := &MockJetStreamClient{}
+
+			var expectedSubject string
+			if tc.expectedPublishSubject != "" {
+				expectedSubject = tc.expectedPublishSubject
+			} else {
+				expectedSubject = mock.Anything
+			}
+
+			var expectedData []byte
+			if tc.expectedPublishData != "" {
+				expectedData = []byte(tc.expectedPublishData)
+			} else {
+				expectedData = []byte(tc.requestBody)
+			}
+
+			mockJS.On("PublishMsg", expectedSubject, expectedData).Return(&jetstream.PubAck{Stream: "mockStream"}, nil)
+
+			webhook.GetHandler(mockJS, tc.jetstreamSubjectBase).ServeHTTP(rec, req)
+
+			res := rec.Result()
+			defer res.Body.Close()
+
+			if res.StatusCode != tc.expectedResponseCode {
+				t.Errorf("expected status OK; got %d", res.StatusCode)
+			}
+
+			body, _ := io.ReadAll(res.Body)
+			if strings.TrimSpace(string(body)) != tc.expectedResponseBody {
+				t.Errorf("expected body %q; got %q", tc.expectedResponseBody, body)
+			}
+		})
+	}
+}
+
+type replaceRedactor struct{}
+
+func (replaceRedactor) Redact(doc interface{}) {
+	doc.(map[string]interface{})["email"] = "[REDACTED]"
+}
+
+func TestHttpWebhookRedactsPayload(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	webhook := NewHttpWebhook(logger)
+	webhook.SetRedactor(replaceRedactor{})
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email": "jane@example.com", "id": 9007199254740993, "score": 0.1}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	mockJS := &MockJetStreamClient{}
+	mockJS.On("PublishMsg", "test.unknown", []byte(`{"email":"[REDACTED]","id":9007199254740993,"score":0.1}`)).Return(&jetstream.PubAck{Stream: "mockStream"}, nil)
+
+	webhook.GetHandler(mockJS, "test").ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	mockJS.AssertExpectations(t)
+}
+
+type capturingJetStreamClient struct {
+	published []*nats.Msg
+}
+
+func (c *capturingJetStreamClient) PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	c.published = append(c.published, msg)
+	return &jetstream.PubAck{Stream: "mockStream"}, nil
+}
+
+func TestHttpWebhookSetsRepositoryHeader(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	for _, tc := range []struct {
+		title              string
+		body               string
+		expectedRepository string
+	}{
+		{title: "sets header from repository full name", body: `{"repository": {"full_name": "platform/api"}}`, expectedRepository: "platform/api"},
+		{title: "leaves header out without repository", body: `{"foo": "bar"}`},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			js := &capturingJetStreamClient{}
+			NewHttpWebhook(logger).GetHandler(js, "test").ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Len(t, js.published, 1)
+			assert.Equal(t, tc.expectedRepository, js.published[0].Header.Get(adapter.RepositoryHeader))
+		})
+	}
+}
+
+type recordingCapturer struct {
+	event      string
+	deliveryID string
+	data       string
+}
+
+func (c *recordingCapturer) Capture(event string, deliveryID string, data []byte) {
+	c.event, c.deliveryID, c.data = event, deliveryID, string(data)
+}
+
+func TestHttpWebhookCapturesRedactedPayload(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	webhook := NewHttpWebhook(logger)
+	webhook.SetRedactor(replaceRedactor{})
+	capturer := &recordingCapturer{}
+	webhook.SetCapturer(capturer)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email": "jane@example.com"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gitea-Event", "push")
+	req.Header.Set("X-Gitea-Delivery", "abc-123")
+	rec := httptest.NewRecorder()
+
+	webhook.GetHandler(&capturingJetStreamClient{}, "test").ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, recordingCapturer{event: "gitea.push", deliveryID: "abc-123", data: `{"email":"[REDACTED]"}`}, *capturer)
+}
+
+func TestHttpWebhookCapturesUnknownEvents(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	webhook := NewHttpWebhook(logger)
+	capturer := &recordingCapturer{}
+	webhook.SetCapturer(capturer)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gitea-Event", "made_up_1")
+	rec := httptest.NewRecorder()
+
+	webhook.GetHandler(&capturingJetStreamClient{}, "test").ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "unknown", capturer.event)
+}
+
+func TestHttpWebhookIgnoresSelftestHeader(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	webhook := NewHttpWebhook(logger)
+	capturer := &recordingCapturer{}
+	webhook.SetCapturer(capturer)
+	js := &capturingJetStreamClient{}
+
+	// Only the self test publishes webhook messages marked as self tests, so that a webhook sender
+	// cannot keep its events away from the sinks.
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gitea-Event", "push")
+	req.Header.Set("X-Cdevents-Adapter-Selftest", "true")
+	req.Header.Set(adapter.SelftestHeader, "true")
+	rec := httptest.NewRecorder()
+
+	webhook.GetHandler(js, "test").ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.Len(t, js.published, 1)
+	assert.Empty(t, js.published[0].Header.Get(adapter.SelftestHeader))
+	assert.Equal(t, "gitea.push", capturer.event)
+}
+
+func TestHttpWebhookRejectsLargeBody(t *testing.T) {
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	webhook := NewHttpWebhook(logger)
+	webhook.SetMaxBodySize(16)
+	js := &capturingJetStreamClient{}
+
+	for _, tc := range []struct {
+		title          string
+		body           string
+		expectedStatus int
+	}{
+		{title: "accepts body within the limit", body: `{"a": 1}`, expectedStatus: http.StatusOK},
+		{title: "rejects body larger than the limit", body: `{"a": "0123456789abcdef"}`, expectedStatus: http.StatusRequestEntityTooLarge},
+	} {
+		t.Run(tc.title, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Gitea-Event", "push")
+			rec := httptest.NewRecorder()
+
+			webhook.GetHandler(js, "test").ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+		})
+	}
+	assert.Len(t, js.published, 1)
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/admin"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/config"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/publisher"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/service"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// reloadableKeys are the configuration keys that are applied without restarting. Routes, sink
+// filters and rollouts also include the keys of named routes, sink instances and rollouts.
+var reloadableKeys = map[string]bool{
+	"LOG_LEVEL":            true,
+	"TRANSLATORS":          true,
+	"DISABLED_TRANSLATORS": true,
+	"TRANSLATOR_ROLLOUTS":  true,
+	"ADMIN_TOKEN":          true,
+	"ROUTES":               true,
+	"SINK_FILTER":          true,
+}
+
+// reloader reloads the config file and applies changes to translator mappings and enablement,
+// routing rules, sink filters, the admin token and the log level. Other changes are reported as
+// requiring a restart. Translator mappings and enablement changed through the admin API take
+// precedence over the reloaded configuration.
+type reloader struct {
+	mu        sync.Mutex
+	loader    *config.Loader
+	current   envConfig
+	routes    []publisher.Route
+	filters   map[string]publisher.Filter
+	rollouts  map[string]translator.RolloutConfig
+	level     *slog.LevelVar
+	catalog   translator.Catalog
+	registry  *translator.Registry
+	adapter   *adapter.CDEventAdapter
+	publisher *publisher.FanOut
+	admin     *admin.Server
+	lifecycle *service.LifecyclePublisher
+}
+
+func newReloader(loader *config.Loader, env envConfig, level *slog.LevelVar, catalog translator.Catalog,
+	registry *translator.Registry, cdEventsAdapter *adapter.CDEventAdapter, eventPublisher *publisher.FanOut,
+	adminServer *admin.Server, lifecycle *service.LifecyclePublisher) (*reloader, error) {
+
+	routes, err := newRoutes(env)
+	if err != nil {
+		return nil, err
+	}
+
+	filters, err := sinkFilterSet(env)
+	if err != nil {
+		return nil, err
+	}
+
+	rollouts, err := newRollouts(env)
+	if err != nil {
+		return nil, err
+	}
+
+	return &reloader{
+		loader:    loader,
+		current:   env,
+		routes:    routes,
+		filters:   filters,
+		rollouts:  rollouts,
+		level:     level,
+		catalog:   catalog,
+		registry:  registry,
+		adapter:   cdEventsAdapter,
+		publisher: eventPublisher,
+		admin:     adminServer,
+		lifecycle: lifecycle,
+	}, nil
+}
+
+// run reloads the configuration on SIGHUP and, if interval is non-zero, whenever the
+// modification time of the config file changes.
+func (r *reloader) run(ctx context.Context, path string, interval time.Duration) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	modTime := fileModTime(path)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			logger.Info("Received SIGHUP, reloading configuration")
+			modTime = fileModTime(path)
+			r.reload()
+		case <-tick:
+			if t := fileModTime(path); !t.Equal(modTime) {
+				logger.Info(fmt.Sprintf("Config file %s changed, reloading configuration", path))
+				modTime = t
+				r.reload()
+			}
+		}
+	}
+}
+
+func fileModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func (r *reloader) reload() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.loader.Load(); err != nil {
+		logger.Error("Failed to reload configuration, keeping current configuration", "error", err.Error())
+		return
+	}
+
+	var env envConfig
+	if err := envconfig.Process("", &env); err != nil {
+		logger.Error("Invalid configuration, keeping current configuration", "error", err.Error())
+		return
+	}
+
+	routes, err := newRoutes(env)
+	if err != nil {
+		logger.Error("Invalid routes, keeping current configuration", "error", err.Error())
+		return
+	}
+
+	filters, err := sinkFilterSet(env)
+	if err != nil {
+		logger.Error("Invalid sink filters, keeping current configuration", "error", err.Error())
+		return
+	}
+
+	rollouts, err := newRollouts(env)
+	if err != nil {
+		logger.Error("Invalid rollouts, keeping current configuration", "error", err.Error())
+		return
+	}
+
+	translators, err := resolveTranslators(env, r.catalog)
+	if err != nil {
+		logger.Error("Invalid translator configuration, keeping current configuration", "error", err.Error())
+		return
+	}
+
+	var applied, restartRequired []string
+	for _, key := range changedKeys(r.current, env) {
+		if !reloadableKeys[key] {
+			restartRequired = append(restartRequired, key)
+		}
+	}
+
+	if env.LogLevel != r.current.LogLevel {
+		setLogLevel(r.level, env.LogLevel)
+		applied = append(applied, "LOG_LEVEL")
+	}
+
+	if !reflect.DeepEqual(env.Translators, r.current.Translators) {
+		r.registry.Replace(translators)
+		applied = append(applied, "TRANSLATORS")
+	}
+
+	if !reflect.DeepEqual(rollouts, r.rollouts) {
+		r.registry.Replace(translators)
+		r.rollouts = rollouts
+		applied = append(applied, "TRANSLATOR_ROLLOUTS")
+	}
+
+	if !reflect.DeepEqual(env.DisabledTranslators, r.current.DisabledTranslators) {
+		r.adapter.SetDisabledTranslators(env.DisabledTranslators)
+		applied = append(applied, "DISABLED_TRANSLATORS")
+	}
+
+	if env.AdminToken != r.current.AdminToken {
+		r.admin.SetToken(env.AdminToken)
+		applied = append(applied, "ADMIN_TOKEN")
+	}
+
+	if !reflect.DeepEqual(routes, r.routes) {
+		r.publisher.SetRoutes(routes...)
+		r.routes = routes
+		applied = append(applied, "ROUTES")
+	}
+
+	if !reflect.DeepEqual(filters, r.filters) {
+		r.publisher.SetFilters(filters)
+		r.filters = filters
+		applied = append(applied, "SINK_FILTER")
+	}
+
+	r.current.LogLevel = env.LogLevel
+	r.current.Translators = env.Translators
+	r.current.DisabledTranslators = env.DisabledTranslators
+	r.current.TranslatorRollouts = env.TranslatorRollouts
+	r.current.AdminToken = env.AdminToken
+	r.current.Routes = env.Routes
+	r.current.SinkFilter = env.SinkFilter
+
+	if len(applied) > 0 {
+		logger.Info("Applied configuration changes", "keys", strings.Join(applied, ","))
+	} else {
+		logger.Info("No configuration changes to apply")
+	}
+
+	if len(restartRequired) > 0 {
+		logger.Warn("Configuration changes require a restart to take effect", "keys", strings.Join(restartRequired, ","))
+	}
+
+	if r.lifecycle != nil {
+		details := map[string]interface{}{"applied": applied, "restart_required": restartRequired}
+		if err := r.lifecycle.ConfigurationReloaded(details); err != nil {
+			logger.Error("Failed to publish configuration reloaded event", "error", err.Error())
+		}
+	}
+}
+
+// config returns the configuration in effect, with the changes that have been applied.
+func (r *reloader) config() interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.current
+}
+
+// changedKeys returns the envconfig keys of the top level configuration fields that differ.
+func changedKeys(current, next envConfig) []string {
+	var keys []string
+
+	c, n := reflect.ValueOf(current), reflect.ValueOf(next)
+	for i := 0; i < c.NumField(); i++ {
+		if !reflect.DeepEqual(c.Field(i).Interface(), n.Field(i).Interface()) {
+			keys = append(keys, c.Type().Field(i).Tag.Get("envconfig"))
+		}
+	}
+
+	return keys
+}
+
+// sinkFilterSet returns the filter of every configured sink, keyed by sink name.
+func sinkFilterSet(env envConfig) (map[string]publisher.Filter, error) {
+	filters := make(map[string]publisher.Filter)
+
+	for _, name := range env.EventSinks {
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		if name == "webhook" {
+			for _, target := range env.WebhookSink.Targets {
+				target = strings.TrimSpace(target)
+
+				var config publisher.WebhookTargetConfig
+				if err := envconfig.Process(fmt.Sprintf("WEBHOOK_SINK_%s", strings.ToUpper(target)), &config); err != nil {
+					return nil, fmt.Errorf("invalid configuration for webhook target %s: %w", target, err)
+				}
+				filters["webhook:"+target] = config.Filter
+			}
+			continue
+		}
+
+		kind, instance, _ := strings.Cut(name, ":")
+
+		instanceEnv := env
+		if instance != "" {
+			if err := loadSinkInstance(&instanceEnv, kind, instance); err != nil {
+				return nil, err
+			}
+		}
+
+		if sink, ok := sinkConfigs(&instanceEnv)[kind]; ok {
+			filters[name] = sinkFilter(kind, *sink.filter)
+		}
+	}
+
+	for name, filter := range filters {
+		if err := filter.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid filter for sink %s: %w", name, err)
+		}
+	}
+
+	return filters, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/replay"
+
+	"github.com/kelseyhightower/envconfig"
+	natsjs "github.com/nats-io/nats.go/jetstream"
+)
+
+// runReplay runs the replay subcommand, which publishes archived webhook messages on the webhook
+// subjects again so that they are translated by the running adapters, e.g. after a translator
+// bug has been fixed. NATS and the streams are configured from the environment like the server.
+func runReplay(args []string) int {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	subject := flags.String("subject", "", "replay only webhooks on this subject, e.g. gitea.push or gitea.>")
+	fromSeq := flags.Uint64("from-seq", 0, "first archive stream sequence to replay")
+	toSeq := flags.Uint64("to-seq", 0, "last archive stream sequence to replay")
+	since := flags.String("since", "", "replay webhooks archived at or after this RFC 3339 time")
+	until := flags.String("until", "", "replay webhooks archived at or before this RFC 3339 time")
+	asJSON := flags.Bool("json", false, "print the report as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	var env envConfig
+	if err := envconfig.Process("", &env); err != nil {
+		logger.Error("Error when processing envvar configuration", "error", err.Error())
+		return 1
+	}
+
+	if env.ArchiveStreamName == "" {
+		logger.Error("ARCHIVE_STREAM_NAME must be set to replay archived webhooks")
+		return 1
+	}
+
+	config := replay.Config{
+		ArchiveSubjectBase: env.ArchiveSubjectBase,
+		WebhookSubjectBase: env.WebhookSubjectBase,
+		Subject:            *subject,
+		FromSeq:            *fromSeq,
+		ToSeq:              *toSeq,
+	}
+	for _, option := range []struct {
+		value  string
+		target *time.Time
+	}{{*since, &config.Since}, {*until, &config.Until}} {
+		if option.value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, option.value)
+		if err != nil {
+			logger.Error("Invalid replay time", "error", err.Error())
+			return 2
+		}
+		*option.target = parsed
+	}
+	if err := config.Validate(); err != nil {
+		logger.Error("Invalid replay selection", "error", err.Error())
+		return 2
+	}
+
+	nc, err := connectNATS(env)
+	if err != nil {
+		logger.Error("Failed to connect to nats", "error", err.Error())
+		return 1
+	}
+	defer nc.Close()
+
+	jetstream, err := natsjs.New(nc)
+	if err != nil {
+		logger.Error("Failed to create JetStream instance", "error", err.Error())
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	archive, err := jetstream.Stream(ctx, env.ArchiveStreamName)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to look up archive stream: %s", env.ArchiveStreamName), "error", err.Error())
+		return 1
+	}
+
+	report, err := replay.Run(ctx, logger, archive, jetstream, config)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Replay stopped after %d webhooks", report.Replayed), "error", err.Error())
+		return 1
+	}
+
+	if *asJSON {
+		json.NewEncoder(os.Stdout).Encode(report)
+	} else if report.Replayed > 0 {
+		fmt.Printf("Replayed %d webhooks from archive sequence %d to %d\n", report.Replayed, report.FirstSeq, report.LastSeq)
+	} else {
+		fmt.Println("No archived webhooks matched the selection")
+	}
+
+	return 0
+}
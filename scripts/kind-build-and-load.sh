@@ -4,7 +4,11 @@ IMAGE_NAME="gitea-cdevents-adapter:latest"
 TAR_FILE="gitea-cdevents-adapter.tar"
 
 echo "Building image with Podman..."
-podman build -t "$IMAGE_NAME" .
+podman build -t "$IMAGE_NAME" \
+  --build-arg VERSION="$(git describe --tags --always --dirty 2>/dev/null || echo 0.0.0-dev)" \
+  --build-arg COMMIT="$(git rev-parse HEAD 2>/dev/null)" \
+  --build-arg BUILD_DATE="$(date -u +%Y-%m-%dT%H:%M:%SZ)" \
+  .
 
 echo "Saving image to $TAR_FILE..."
 podman save -o "$TAR_FILE" "$IMAGE_NAME"
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/secret"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+)
+
+// newSecretResolver returns a resolver of the secrets referenced in the configuration, e.g.
+// NATS_PASSWORD=${secret:vault:nats#password}, with the env and file providers, Vault if
+// VAULT_ADDR is set and AWS Secrets Manager.
+func newSecretResolver(env envConfig) (*secret.Resolver, error) {
+	resolver := secret.NewResolver(env.Secrets.TTL)
+
+	if env.Vault.Address != "" {
+		vault, err := secret.NewVaultProvider(env.Vault)
+		if err != nil {
+			return nil, err
+		}
+		resolver.Register("vault", vault)
+	}
+
+	resolver.Register("aws", secret.NewAWSProvider(env.Secrets.AWSRegion))
+
+	return resolver, nil
+}
+
+// natsAuthOptions returns the options that authenticate the NATS connection. Secrets are
+// resolved on every (re)connect so that renewed credentials are used.
+func natsAuthOptions(ctx context.Context, env envConfig, secrets *secret.Resolver) ([]nats.Option, error) {
+	var opts []nats.Option
+
+	resolve := func(value string) string {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		resolved, err := secrets.Resolve(ctx, value)
+		if err != nil {
+			logger.Error("Failed to resolve NATS credentials", "error", err.Error())
+		}
+		return resolved
+	}
+
+	for _, value := range []string{env.NATSPassword, env.NATSToken, env.NATSCredentials} {
+		if value == "" {
+			continue
+		}
+		if _, err := secrets.Resolve(ctx, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if env.NATSUser != "" {
+		opts = append(opts, nats.UserInfoHandler(func() (string, string) {
+			return env.NATSUser, resolve(env.NATSPassword)
+		}))
+	}
+
+	if env.NATSToken != "" {
+		opts = append(opts, nats.TokenHandler(func() string {
+			return resolve(env.NATSToken)
+		}))
+	}
+
+	if env.NATSCredentials != "" {
+		opts = append(opts, nats.UserJWT(
+			func() (string, error) {
+				return nkeys.ParseDecoratedJWT([]byte(resolve(env.NATSCredentials)))
+			},
+			func(nonce []byte) ([]byte, error) {
+				keyPair, err := nkeys.ParseDecoratedNKey([]byte(resolve(env.NATSCredentials)))
+				if err != nil {
+					return nil, fmt.Errorf("invalid NATS credentials: %w", err)
+				}
+				defer keyPair.Wipe()
+				return keyPair.Sign(nonce)
+			}))
+	}
+
+	return opts, nil
+}
+
+// connectNATS connects to NATS with the configured credentials, for subcommands that run
+// without the server.
+func connectNATS(env envConfig) (*nats.Conn, error) {
+	secrets, err := newSecretResolver(env)
+	if err != nil {
+		return nil, fmt.Errorf("invalid secret provider configuration: %w", err)
+	}
+
+	opts, err := natsAuthOptions(context.Background(), env, secrets)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve NATS credentials: %w", err)
+	}
+
+	return nats.Connect(env.NATSUrl, opts...)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/selftest"
+
+	"github.com/kelseyhightower/envconfig"
+	natsjs "github.com/nats-io/nats.go/jetstream"
+)
+
+// runSelftest runs the selftest subcommand, which publishes a synthetic webhook on the webhook
+// stream of a running adapter and waits for the translated event in the event stream. The exit
+// code is non-zero if the event does not arrive, so the command can gate deployments or back a
+// startup probe.
+func runSelftest(args []string) int {
+	var env envConfig
+	if err := envconfig.Process("", &env); err != nil {
+		fmt.Fprintf(os.Stderr, "Error when processing envvar configuration: %v\n", err)
+		return 1
+	}
+
+	flags := flag.NewFlagSet("selftest", flag.ContinueOnError)
+	timeout := flags.Duration("timeout", 30*time.Second, "longest time to wait for the event")
+	asJSON := flags.Bool("json", false, "print the result as JSON")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	logger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+	nc, err := connectNATS(env)
+	if err != nil {
+		logger.Error("Failed to connect to nats", "error", err.Error())
+		return 1
+	}
+	defer nc.Close()
+
+	jetstream, err := natsjs.New(nc)
+	if err != nil {
+		logger.Error("Failed to create JetStream instance", "error", err.Error())
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	eventStream, err := jetstream.Stream(ctx, env.EventStreamName)
+	if err != nil {
+		logger.Error(fmt.Sprintf("Failed to look up event stream: %s", env.EventStreamName), "error", err.Error())
+		return 1
+	}
+
+	result, err := selftest.Run(ctx, jetstream, eventStream, selftest.Config{
+		WebhookSubject: env.WebhookSubjectBase + ".gitea.push",
+		Timeout:        *timeout,
+	})
+	if err != nil {
+		logger.Error("Selftest failed", "error", err.Error())
+		return 1
+	}
+
+	if *asJSON {
+		json.NewEncoder(os.Stdout).Encode(result)
+	} else {
+		fmt.Printf("Selftest passed: event for commit %s published on %s after %s\n", result.Commit, result.EventSubject, result.Latency.Round(time.Millisecond))
+	}
+
+	return 0
+}
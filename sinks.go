@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/config"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/publisher"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/secret"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/nats-io/nats.go"
+)
+
+type sinkFilters struct {
+	JetStream   publisher.Filter `envconfig:"JETSTREAM"`
+	HTTP        publisher.Filter `envconfig:"HTTP"`
+	Kafka       publisher.Filter `envconfig:"KAFKA"`
+	NATS        publisher.Filter `envconfig:"NATS"`
+	AMQP        publisher.Filter `envconfig:"AMQP"`
+	MQTT        publisher.Filter `envconfig:"MQTT"`
+	EventBridge publisher.Filter `envconfig:"EVENTBRIDGE"`
+	SNS         publisher.Filter `envconfig:"SNS"`
+	PubSub      publisher.Filter `envconfig:"PUBSUB"`
+	EventGrid   publisher.Filter `envconfig:"EVENTGRID"`
+	Redis       publisher.Filter `envconfig:"REDIS"`
+	File        publisher.Filter `envconfig:"FILE"`
+	Knative     publisher.Filter `envconfig:"KNATIVE"`
+	Journal     publisher.Filter `envconfig:"JOURNAL"`
+}
+
+// sinkEnabled reports whether a sink of the given kind is enabled, either on its own or as a
+// named instance such as "jetstream:next".
+func sinkEnabled(env envConfig, kind string) bool {
+	for _, sink := range env.EventSinks {
+		sinkKind, _, _ := strings.Cut(strings.TrimSpace(sink), ":")
+		if strings.EqualFold(sinkKind, kind) {
+			return true
+		}
+	}
+	return false
+}
+
+func newFanOutPublisher(env envConfig, nc *nats.Conn, secrets *secret.Resolver) (*publisher.FanOut, error) {
+	sinks, err := newSinks(env, nc, secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	routes, err := newRoutes(env)
+	if err != nil {
+		for _, sink := range sinks {
+			closePublisher(sink.Publisher)
+		}
+		return nil, err
+	}
+
+	fanOut := publisher.NewFanOut(logger, env.SinkQueueSize, sinks...)
+	fanOut.SetRoutes(routes...)
+
+	return fanOut, nil
+}
+
+// sinkFilter returns the filter of a sink of the kind. JetStream sinks always publish the events of
+// self tests, since the self test waits for its event in the event stream.
+func sinkFilter(kind string, filter publisher.Filter) publisher.Filter {
+	if kind == "jetstream" {
+		filter.Selftest = true
+	}
+	return filter
+}
+
+// newSinks creates the publishers of the sinks in EVENT_SINKS.
+func newSinks(env envConfig, nc *nats.Conn, secrets *secret.Resolver) ([]publisher.Sink, error) {
+	var sinks []publisher.Sink
+
+	for _, name := range env.EventSinks {
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		if name == "webhook" {
+			targets, err := newWebhookSinks(env, secrets)
+			if err != nil {
+				for _, sink := range sinks {
+					closePublisher(sink.Publisher)
+				}
+				return nil, err
+			}
+			sinks = append(sinks, targets...)
+			continue
+		}
+
+		p, filter, err := newSinkPublisher(env, nc, name)
+		if err != nil {
+			for _, sink := range sinks {
+				closePublisher(sink.Publisher)
+			}
+			return nil, err
+		}
+
+		sinks = append(sinks, publisher.Sink{Name: name, Publisher: p, Filter: filter})
+	}
+
+	if len(sinks) == 0 {
+		return nil, fmt.Errorf("no event sinks configured")
+	}
+
+	for _, sink := range sinks {
+		if err := sink.Filter.Validate(); err != nil {
+			for _, sink := range sinks {
+				closePublisher(sink.Publisher)
+			}
+			return nil, fmt.Errorf("invalid filter for sink %s: %w", sink.Name, err)
+		}
+	}
+
+	return sinks, nil
+}
+
+// newRoutes loads the routing rules listed in ROUTES, in order. Every route is configured with
+// environment variables prefixed with ROUTE_<NAME>_, e.g. ROUTE_INCIDENTS_TYPES.
+func newRoutes(env envConfig) ([]publisher.Route, error) {
+	var routes []publisher.Route
+
+	for _, name := range env.Routes {
+		name = strings.TrimSpace(name)
+
+		var route publisher.Route
+		if err := envconfig.Process(fmt.Sprintf("ROUTE_%s", strings.ToUpper(name)), &route); err != nil {
+			return nil, fmt.Errorf("invalid configuration for route %s: %w", name, err)
+		}
+		if len(route.Types) == 0 {
+			return nil, fmt.Errorf("no event types configured for route %s", name)
+		}
+		route.Name = name
+
+		logger.Info(fmt.Sprintf("Routing events of type %s", strings.Join(route.Types, ",")),
+			"route", name, "sinks", strings.Join(route.Sinks, ","), "subject", route.Subject)
+		routes = append(routes, route)
+	}
+
+	return routes, nil
+}
+
+// newSinkPublisher creates the publisher for a sink. A sink can be given as "<kind>:<instance>"
+// to run several sinks of the same kind side by side, e.g. to dual-write events with an old and
+// a new subject scheme while consumers are migrated. Instances are configured independently
+// with environment variables prefixed with <KIND>_SINK_<INSTANCE>_ and
+// SINK_FILTER_<KIND>_<INSTANCE>_. Every instance has its own queue and delivery stats, and a
+// message redelivered because one instance failed is only published to that instance again.
+func newSinkPublisher(env envConfig, nc *nats.Conn, name string) (publisher.Publisher, publisher.Filter, error) {
+	kind, instance, _ := strings.Cut(name, ":")
+	if instance != "" {
+		if err := loadSinkInstance(&env, kind, instance); err != nil {
+			return nil, publisher.Filter{}, err
+		}
+		logger.Info(fmt.Sprintf("Configuring %s sink instance: %s", kind, instance))
+	}
+
+	// In a dry run the sinks keep their filters, so that routing can be checked, but only the file
+	// sink writes events.
+	if env.DryRun && kind != "file" {
+		sink, ok := sinkConfigs(&env)[kind]
+		if !ok {
+			return nil, publisher.Filter{}, fmt.Errorf("unknown event sink: %s", name)
+		}
+		logger.Info(fmt.Sprintf("Dry run: logging events instead of publishing them to sink: %s", name))
+		return publisher.NewDryRunPublisher(logger, name), sinkFilter(kind, *sink.filter), nil
+	}
+
+	switch kind {
+	case "jetstream":
+		logger.Info("Publishing events to JetStream", "subject", env.JetStreamSink.SubjectTemplate)
+		p, err := publisher.NewCloudEventJetstreamPublisher(nc, env.JetStreamSink)
+		return p, sinkFilter(kind, env.SinkFilter.JetStream), err
+	case "http":
+		logger.Info(fmt.Sprintf("Publishing events to HTTP sink: %s", env.HTTPSink.URL))
+		p, err := publisher.NewHTTPPublisher(env.HTTPSink)
+		return p, env.SinkFilter.HTTP, err
+	case "kafka":
+		logger.Info(fmt.Sprintf("Publishing events to Kafka topic: %s", env.KafkaSink.Topic))
+		p, err := publisher.NewKafkaPublisher(env.KafkaSink)
+		return p, env.SinkFilter.Kafka, err
+	case "nats":
+		logger.Info("Publishing events on core NATS subjects", "prefix", env.NATSSink.SubjectPrefix, "request", env.NATSSink.Request)
+		return publisher.NewNATSPublisher(nc, env.NATSSink), env.SinkFilter.NATS, nil
+	case "amqp":
+		logger.Info(fmt.Sprintf("Publishing events to AMQP exchange: %s", env.AMQPSink.Exchange))
+		p, err := publisher.NewAMQPPublisher(env.AMQPSink)
+		return p, env.SinkFilter.AMQP, err
+	case "mqtt":
+		logger.Info(fmt.Sprintf("Publishing events to MQTT brokers: %s", strings.Join(env.MQTTSink.Brokers, ",")))
+		p, err := publisher.NewMQTTPublisher(env.MQTTSink)
+		return p, env.SinkFilter.MQTT, err
+	case "eventbridge":
+		logger.Info(fmt.Sprintf("Publishing events to EventBridge bus: %s", env.EventBridgeSink.EventBusName))
+		p, err := publisher.NewEventBridgePublisher(context.Background(), env.EventBridgeSink)
+		return p, env.SinkFilter.EventBridge, err
+	case "sns":
+		logger.Info(fmt.Sprintf("Publishing events to SNS topic: %s", env.SNSSink.TopicARN))
+		p, err := publisher.NewSNSPublisher(context.Background(), env.SNSSink)
+		return p, env.SinkFilter.SNS, err
+	case "pubsub":
+		logger.Info(fmt.Sprintf("Publishing events to Pub/Sub topic: %s", env.PubSubSink.Topic))
+		p, err := publisher.NewPubSubPublisher(context.Background(), env.PubSubSink)
+		return p, env.SinkFilter.PubSub, err
+	case "eventgrid":
+		logger.Info(fmt.Sprintf("Publishing events to Event Grid topic: %s", env.EventGridSink.Endpoint))
+		p, err := publisher.NewEventGridPublisher(env.EventGridSink)
+		return p, env.SinkFilter.EventGrid, err
+	case "redis":
+		logger.Info(fmt.Sprintf("Publishing events to Redis stream: %s", env.RedisSink.Stream))
+		p, err := publisher.NewRedisPublisher(env.RedisSink)
+		return p, env.SinkFilter.Redis, err
+	case "file":
+		logger.Info(fmt.Sprintf("Writing events as NDJSON to: %s", env.FileSink.Path))
+		p, err := publisher.NewFilePublisher(env.FileSink)
+		return p, env.SinkFilter.File, err
+	case "knative":
+		logger.Info(fmt.Sprintf("Publishing events to Knative broker: %s", env.KnativeSink.URL))
+		p, err := publisher.NewKnativePublisher(env.KnativeSink)
+		return p, env.SinkFilter.Knative, err
+	case "journal":
+		logger.Info(fmt.Sprintf("Recording events in %s journal", env.JournalSink.Driver))
+		p, err := publisher.NewJournalPublisher(context.Background(), env.JournalSink)
+		return p, env.SinkFilter.Journal, err
+	default:
+		return nil, publisher.Filter{}, fmt.Errorf("unknown event sink: %s", name)
+	}
+}
+
+type sinkConfig struct {
+	config any
+	filter *publisher.Filter
+}
+
+// sinkConfigs returns the configuration and filter of every sink kind that supports named
+// instances.
+func sinkConfigs(env *envConfig) map[string]sinkConfig {
+	return map[string]sinkConfig{
+		"jetstream":   {&env.JetStreamSink, &env.SinkFilter.JetStream},
+		"http":        {&env.HTTPSink, &env.SinkFilter.HTTP},
+		"kafka":       {&env.KafkaSink, &env.SinkFilter.Kafka},
+		"nats":        {&env.NATSSink, &env.SinkFilter.NATS},
+		"amqp":        {&env.AMQPSink, &env.SinkFilter.AMQP},
+		"mqtt":        {&env.MQTTSink, &env.SinkFilter.MQTT},
+		"eventbridge": {&env.EventBridgeSink, &env.SinkFilter.EventBridge},
+		"sns":         {&env.SNSSink, &env.SinkFilter.SNS},
+		"pubsub":      {&env.PubSubSink, &env.SinkFilter.PubSub},
+		"eventgrid":   {&env.EventGridSink, &env.SinkFilter.EventGrid},
+		"redis":       {&env.RedisSink, &env.SinkFilter.Redis},
+		"file":        {&env.FileSink, &env.SinkFilter.File},
+		"knative":     {&env.KnativeSink, &env.SinkFilter.Knative},
+		"journal":     {&env.JournalSink, &env.SinkFilter.Journal},
+	}
+}
+
+// configFamilies describes the configuration keys of named sink instances, webhook targets and
+// routes, for validating the config file.
+func configFamilies() []config.Family {
+	families := []config.Family{
+		{Prefix: "ROUTE", Spec: publisher.Route{}},
+		{Prefix: "WEBHOOK_SINK", Spec: publisher.WebhookTargetConfig{}},
+		{Prefix: "EXEC_TRANSLATOR", Spec: translator.ExecConfig{}},
+		{Prefix: "HTTP_TRANSLATOR", Spec: translator.HTTPConfig{}},
+		{Prefix: "JQ_TRANSLATOR", Spec: translator.JQConfig{}},
+		{Prefix: "TRANSLATOR_ROLLOUT", Spec: translator.RolloutConfig{}},
+	}
+
+	for kind, sink := range sinkConfigs(&envConfig{}) {
+		families = append(families,
+			config.Family{Prefix: strings.ToUpper(kind) + "_SINK", Spec: sink.config},
+			config.Family{Prefix: "SINK_FILTER_" + strings.ToUpper(kind), Spec: publisher.Filter{}},
+		)
+	}
+
+	return families
+}
+
+func loadSinkInstance(env *envConfig, kind, instance string) error {
+	sinks := sinkConfigs(env)
+
+	sink, ok := sinks[kind]
+	if !ok {
+		return fmt.Errorf("event sink %s does not support instances", kind)
+	}
+
+	prefix := strings.ToUpper(fmt.Sprintf("%s_SINK_%s", kind, instance))
+	if err := envconfig.Process(prefix, sink.config); err != nil {
+		return fmt.Errorf("invalid configuration for sink %s:%s: %w", kind, instance, err)
+	}
+
+	*sink.filter = publisher.Filter{}
+	if err := envconfig.Process(strings.ToUpper(fmt.Sprintf("SINK_FILTER_%s_%s", kind, instance)), sink.filter); err != nil {
+		return fmt.Errorf("invalid filter for sink %s:%s: %w", kind, instance, err)
+	}
+
+	return nil
+}
+
+// newWebhookSinks creates one sink per outbound webhook target so that every target gets its
+// own queue, filter and delivery stats. Targets are configured with environment variables
+// prefixed with WEBHOOK_SINK_<TARGET>_, e.g. WEBHOOK_SINK_ALERTS_URL.
+func newWebhookSinks(env envConfig, secrets *secret.Resolver) ([]publisher.Sink, error) {
+	if len(env.WebhookSink.Targets) == 0 {
+		return nil, fmt.Errorf("no targets configured for webhook sink")
+	}
+
+	var sinks []publisher.Sink
+
+	for _, target := range env.WebhookSink.Targets {
+		target = strings.TrimSpace(target)
+
+		var config publisher.WebhookTargetConfig
+		if err := envconfig.Process(fmt.Sprintf("WEBHOOK_SINK_%s", strings.ToUpper(target)), &config); err != nil {
+			return nil, fmt.Errorf("invalid configuration for webhook target %s: %w", target, err)
+		}
+
+		if env.DryRun {
+			logger.Info(fmt.Sprintf("Dry run: logging events instead of publishing them to webhook target: %s", target))
+			sinks = append(sinks, publisher.Sink{Name: "webhook:" + target, Publisher: publisher.NewDryRunPublisher(logger, "webhook:"+target), Filter: config.Filter})
+			continue
+		}
+
+		p, err := publisher.NewWebhookPublisher(config)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration for webhook target %s: %w", target, err)
+		}
+		if secrets != nil {
+			for _, value := range []string{config.BearerToken, config.HMACSecret} {
+				if _, err := secrets.Resolve(context.Background(), value); err != nil {
+					return nil, fmt.Errorf("invalid configuration for webhook target %s: %w", target, err)
+				}
+			}
+			p.SetSecretResolver(secrets)
+		}
+
+		logger.Info(fmt.Sprintf("Publishing events to webhook target %s: %s", target, config.URL))
+		sinks = append(sinks, publisher.Sink{Name: "webhook:" + target, Publisher: p, Filter: config.Filter})
+	}
+
+	return sinks, nil
+}
+
+func closePublisher(p publisher.Publisher) {
+	closer, ok := p.(interface{ Close(context.Context) error })
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := closer.Close(ctx); err != nil {
+		logger.Error("Error when closing event publisher", "error", err.Error())
+	}
+}
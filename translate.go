@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	cdevents "github.com/cdevents/sdk-go/pkg/api"
+	"github.com/kelseyhightower/envconfig"
+)
+
+// runTranslate runs a webhook payload through the translator of the translate subcommand and
+// prints the resulting CDEvent, or its CloudEvent envelope, without connecting to NATS. The
+// translators are configured from the environment like the server.
+func runTranslate(args []string) int {
+	flags := flag.NewFlagSet("translate", flag.ContinueOnError)
+	provider := flags.String("provider", "gitea", "provider of the webhook, e.g. gitea")
+	event := flags.String("event", "", "type of the webhook event, e.g. push")
+	file := flags.String("file", "-", "file with the webhook payload, - for stdin")
+	envelope := flags.Bool("cloudevent", false, "print the CloudEvent envelope instead of the CDEvent")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *event == "" {
+		fmt.Fprintln(os.Stderr, "-event is required")
+		flags.Usage()
+		return 2
+	}
+
+	var env envConfig
+	if err := envconfig.Process("", &env); err != nil {
+		fmt.Fprintf(os.Stderr, "Error when processing envvar configuration: %v\n", err)
+		return 1
+	}
+
+	catalog, closeCatalog, err := newTranslatorCatalog(env, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid translator configuration: %v\n", err)
+		return 1
+	}
+	defer closeCatalog()
+
+	translators, err := resolveTranslators(env, catalog)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid translator configuration: %v\n", err)
+		return 1
+	}
+
+	subject := fmt.Sprintf("%s.%s", *provider, *event)
+	eventTranslator, exists := translators[subject]
+	if !exists {
+		fmt.Fprintf(os.Stderr, "No translator found for subject: %s\n", subject)
+		return 1
+	}
+
+	data, err := readPayload(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read payload: %v\n", err)
+		return 1
+	}
+
+	cdEvent, err := translator.TranslatePayload(eventTranslator, translator.NewPayload(data, translator.WithMaxSize(env.TranslatorMaxPayloadSize)))
+	if errors.Is(err, translator.ErrSkipped) {
+		fmt.Fprintf(os.Stderr, "Webhook skipped by translator: %v\n", err)
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to translate payload: %v\n", err)
+		return 1
+	}
+
+	var output interface{} = cdEvent
+	if *envelope {
+		cloudEvent, err := cdevents.AsCloudEvent(cdEvent)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to render CloudEvent: %v\n", err)
+			return 1
+		}
+		output = cloudEvent
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to print event: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func readPayload(file string) ([]byte, error) {
+	if file == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(file)
+}
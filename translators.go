@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/secret"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/translator"
+
+	"github.com/kelseyhightower/envconfig"
+)
+
+// newTranslatorCatalog returns the built-in translators together with the translators loaded
+// from the plugins in TRANSLATOR_PLUGIN_DIR, the WASM modules in WASM_TRANSLATOR_DIR, the
+// executables listed in EXEC_TRANSLATORS, the services listed in HTTP_TRANSLATORS and the jq
+// programs listed in JQ_TRANSLATORS. The returned function releases the WASM runtime. The
+// custom data schemas of the built-in translators and CUSTOM_DATA_SCHEMA_DIR are loaded first.
+// Secrets referenced by the translators are resolved with secrets, unless it is nil.
+func newTranslatorCatalog(env envConfig, secrets *secret.Resolver) (translator.Catalog, func(), error) {
+	if err := env.Gitea.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("gitea translators: %w", err)
+	}
+
+	if err := translator.LoadSchemas(); err != nil {
+		return nil, nil, err
+	}
+	if env.CustomDataSchemaDir != "" {
+		if err := translator.LoadSchemaDir(env.CustomDataSchemaDir); err != nil {
+			return nil, nil, fmt.Errorf("custom data schemas: %w", err)
+		}
+	}
+
+	catalog := translator.BuiltinWithConfig(giteaConfig(env))
+	closeCatalog := func() {}
+
+	if secrets != nil && env.Gitea.API.Token != "" {
+		if _, err := secrets.Resolve(context.Background(), env.Gitea.API.Token); err != nil {
+			return nil, nil, fmt.Errorf("gitea API token: %w", err)
+		}
+		for _, t := range catalog {
+			if resolving, ok := t.(interface {
+				SetSecretResolver(translator.SecretResolver)
+			}); ok {
+				resolving.SetSecretResolver(secrets)
+			}
+		}
+	}
+
+	if env.TranslatorPluginDir != "" {
+		plugins, err := translator.LoadPlugins(env.TranslatorPluginDir)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := addTranslators(catalog, plugins, "plugin"); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(env.ExecTranslators) > 0 {
+		executables, err := newExecTranslators(env)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := addTranslators(catalog, executables, "exec"); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(env.HTTPTranslators) > 0 {
+		services, err := newHTTPTranslators(env, secrets)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := addTranslators(catalog, services, "http"); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(env.JQTranslators) > 0 {
+		programs, err := newJQTranslators(env)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := addTranslators(catalog, programs, "jq"); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if env.WASMTranslator.Dir != "" {
+		config := env.WASMTranslator
+		if config.MaxOutputSize == 0 {
+			config.MaxOutputSize = env.TranslatorMaxPayloadSize
+		}
+
+		host, err := translator.NewWASMHost(context.Background(), config)
+		if err != nil {
+			return nil, nil, err
+		}
+		closeCatalog = func() { host.Close(context.Background()) }
+
+		modules, err := host.Load()
+		if err != nil {
+			closeCatalog()
+			return nil, nil, err
+		}
+		if err := addTranslators(catalog, modules, "WASM"); err != nil {
+			closeCatalog()
+			return nil, nil, err
+		}
+	}
+
+	return catalog, closeCatalog, nil
+}
+
+// newExecTranslators creates the translators listed in EXEC_TRANSLATORS. Every translator is
+// configured with environment variables prefixed with EXEC_TRANSLATOR_<NAME>_, e.g.
+// EXEC_TRANSLATOR_JENKINS_COMMAND.
+func newExecTranslators(env envConfig) (translator.Catalog, error) {
+	return newConfiguredTranslators(env.ExecTranslators, "exec", func(config translator.ExecConfig) (translator.CDEventTranslator, error) {
+		if config.MaxOutputSize == 0 {
+			config.MaxOutputSize = env.TranslatorMaxPayloadSize
+		}
+		return translator.NewExecTranslator(config)
+	})
+}
+
+// newHTTPTranslators creates the translators listed in HTTP_TRANSLATORS. Every translator is
+// configured with environment variables prefixed with HTTP_TRANSLATOR_<NAME>_, e.g.
+// HTTP_TRANSLATOR_JENKINS_URL. The bearer token may reference a secret.
+func newHTTPTranslators(env envConfig, secrets *secret.Resolver) (translator.Catalog, error) {
+	return newConfiguredTranslators(env.HTTPTranslators, "http", func(config translator.HTTPConfig) (translator.CDEventTranslator, error) {
+		t, err := translator.NewHTTPTranslator(config)
+		if err != nil {
+			return nil, err
+		}
+		if secrets != nil {
+			if _, err := secrets.Resolve(context.Background(), config.BearerToken); err != nil {
+				return nil, err
+			}
+			t.SetSecretResolver(secrets)
+		}
+		return t, nil
+	})
+}
+
+// newJQTranslators creates the translators listed in JQ_TRANSLATORS. Every translator is
+// configured with environment variables prefixed with JQ_TRANSLATOR_<NAME>_, e.g.
+// JQ_TRANSLATOR_JENKINS_PROGRAM.
+func newJQTranslators(env envConfig) (translator.Catalog, error) {
+	return newConfiguredTranslators(env.JQTranslators, "jq", func(config translator.JQConfig) (translator.CDEventTranslator, error) {
+		return translator.NewJQTranslator(config)
+	})
+}
+
+// newConfiguredTranslators creates a translator of a kind for every name, configured with
+// environment variables prefixed with <KIND>_TRANSLATOR_<NAME>_.
+func newConfiguredTranslators[C any](names []string, kind string, create func(config C) (translator.CDEventTranslator, error)) (translator.Catalog, error) {
+	catalog := make(translator.Catalog, len(names))
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+
+		var config C
+		if err := envconfig.Process(fmt.Sprintf("%s_TRANSLATOR_%s", strings.ToUpper(kind), strings.ToUpper(name)), &config); err != nil {
+			return nil, fmt.Errorf("invalid configuration for %s translator %s: %w", kind, name, err)
+		}
+
+		t, err := create(config)
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration for %s translator %s: %w", kind, name, err)
+		}
+		catalog[name] = t
+	}
+
+	return catalog, nil
+}
+
+func addTranslators(catalog, translators translator.Catalog, kind string) error {
+	for name, t := range translators {
+		if _, exists := catalog[name]; exists {
+			return fmt.Errorf("%s translator %s conflicts with another translator", kind, name)
+		}
+		catalog[name] = t
+		logger.Info(fmt.Sprintf("Loaded %s translator: %s", kind, name))
+	}
+	return nil
+}
+
+// giteaConfig returns the configuration of the Gitea translators, which decode payloads up to
+// TRANSLATOR_MAX_PAYLOAD_SIZE.
+func giteaConfig(env envConfig) translator.GiteaConfig {
+	config := env.Gitea
+	config.MaxPayloadSize = env.TranslatorMaxPayloadSize
+	return config
+}
+
+// resolveTranslators maps the subjects in TRANSLATORS to translators in the catalog. Plugin,
+// WASM, exec, http and jq translators that are not mapped there, or rolled out in
+// TRANSLATOR_ROLLOUTS, handle the subject they are named after.
+func resolveTranslators(env envConfig, catalog translator.Catalog) (map[string]translator.CDEventTranslator, error) {
+	builtin := translator.BuiltinWithConfig(giteaConfig(env))
+
+	rollouts, err := newRollouts(env)
+	if err != nil {
+		return nil, err
+	}
+
+	mappings := make(map[string]string, len(env.Translators))
+	for subject, name := range env.Translators {
+		mappings[subject] = name
+	}
+
+	mapped := make(map[string]bool, len(mappings))
+	for _, name := range mappings {
+		mapped[name] = true
+	}
+	for _, rollout := range rollouts {
+		mapped[rollout.Translator] = true
+	}
+
+	for name := range catalog {
+		if _, isBuiltin := builtin[name]; isBuiltin || mapped[name] {
+			continue
+		}
+		if _, exists := mappings[name]; !exists {
+			mappings[name] = name
+		}
+	}
+
+	translators, err := catalog.Resolve(mappings)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, rollout := range rollouts {
+		current, exists := translators[rollout.Subject]
+		if !exists {
+			return nil, fmt.Errorf("rollout %s: no translator for subject %s", name, rollout.Subject)
+		}
+		candidate, exists := catalog[rollout.Translator]
+		if !exists {
+			return nil, fmt.Errorf("rollout %s: unknown translator %s", name, rollout.Translator)
+		}
+		t, err := translator.NewRolloutTranslator(current, candidate, rollout)
+		if err != nil {
+			return nil, fmt.Errorf("rollout %s: %w", name, err)
+		}
+		translators[rollout.Subject] = t
+		logger.Info(fmt.Sprintf("Rolling out translator %s for %d%% of %s webhooks", rollout.Translator, rollout.Percent, rollout.Subject),
+			"rollout", name, "repositories", strings.Join(rollout.Repositories, ","))
+	}
+
+	return translators, nil
+}
+
+// newRollouts reads the rollouts listed in TRANSLATOR_ROLLOUTS. Every rollout is configured with
+// environment variables prefixed with TRANSLATOR_ROLLOUT_<NAME>_, e.g.
+// TRANSLATOR_ROLLOUT_PUSHV2_PERCENT.
+func newRollouts(env envConfig) (map[string]translator.RolloutConfig, error) {
+	rollouts := make(map[string]translator.RolloutConfig, len(env.TranslatorRollouts))
+
+	for _, name := range env.TranslatorRollouts {
+		name = strings.TrimSpace(name)
+
+		var rollout translator.RolloutConfig
+		if err := envconfig.Process(fmt.Sprintf("TRANSLATOR_ROLLOUT_%s", strings.ToUpper(name)), &rollout); err != nil {
+			return nil, fmt.Errorf("invalid configuration for rollout %s: %w", name, err)
+		}
+		if rollout.Subject == "" || rollout.Translator == "" {
+			return nil, fmt.Errorf("rollout %s requires a subject and a translator", name)
+		}
+		for _, other := range rollouts {
+			if other.Subject == rollout.Subject {
+				return nil, fmt.Errorf("rollout %s: subject %s is already rolled out", name, rollout.Subject)
+			}
+		}
+		rollouts[name] = rollout
+	}
+
+	return rollouts, nil
+}
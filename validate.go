@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ansig/cdevents-jetstream-adapter/internal/consumer"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/expr"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/publisher"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/redact"
+	"github.com/ansig/cdevents-jetstream-adapter/internal/secret"
+	"github.com/ansig/cdevents-jetstream-adapter/pkg/adapter"
+
+	"github.com/nats-io/nats.go"
+	natsjs "github.com/nats-io/nats.go/jetstream"
+)
+
+// startupCheck is a check of the configuration that is run at startup with STRICT_STARTUP.
+type startupCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+// validateStartup runs every startup check and returns all failures, so that a misconfiguration
+// is reported at once instead of failing lazily at runtime.
+func validateStartup(ctx context.Context, env envConfig, secrets *secret.Resolver, nc *nats.Conn, js natsjs.JetStream) []error {
+	checks := []startupCheck{
+		{name: "translators", check: func(ctx context.Context) error {
+			catalog, closeCatalog, err := newTranslatorCatalog(env, secrets)
+			if err != nil {
+				return err
+			}
+			defer closeCatalog()
+			_, err = resolveTranslators(env, catalog)
+			return err
+		}},
+		{name: "payload filter", check: func(ctx context.Context) error {
+			return compileFilter(env.PayloadFilter)
+		}},
+		{name: "event filter", check: func(ctx context.Context) error {
+			return compileFilter(env.EventFilter)
+		}},
+		{name: "labels", check: func(ctx context.Context) error {
+			return adapter.Labels{Values: env.Labels, CustomData: env.LabelsAsCustomData}.Validate()
+		}},
+		{name: "repository filter", check: func(ctx context.Context) error {
+			return env.Repositories.Validate()
+		}},
+		{name: "routing extensions", check: func(ctx context.Context) error {
+			return env.RoutingExtensions.Validate()
+		}},
+		{name: "environment mapping", check: func(ctx context.Context) error {
+			return env.EnvironmentMapping.Validate()
+		}},
+		{name: "identity map", check: func(ctx context.Context) error {
+			if env.IdentityMapFile == "" {
+				return nil
+			}
+			_, err := adapter.LoadIdentityMap(env.IdentityMapFile)
+			return err
+		}},
+		{name: "redaction", check: func(ctx context.Context) error {
+			_, err := redact.New(env.Redact)
+			return err
+		}},
+		{name: "payload capture", check: func(ctx context.Context) error {
+			if !env.Capture.Enabled() {
+				return nil
+			}
+			return validateCapture(env)
+		}},
+		{name: "schema drift", check: func(ctx context.Context) error {
+			if !env.SchemaDrift.Enabled {
+				return nil
+			}
+			return env.SchemaDrift.Validate()
+		}},
+		{name: "routes", check: func(ctx context.Context) error {
+			_, err := newRoutes(env)
+			return err
+		}},
+		{name: "webhook queue", check: func(ctx context.Context) error {
+			return env.WebhookQueue.Validate()
+		}},
+		{name: "consumer lag", check: func(ctx context.Context) error {
+			return consumer.ValidateLagInterval(env.ConsumerLagInterval)
+		}},
+		{name: "adaptive concurrency", check: func(ctx context.Context) error {
+			return env.WebhookAdaptive.Validate()
+		}},
+		{name: "sink filters", check: func(ctx context.Context) error {
+			_, err := sinkFilterSet(env)
+			return err
+		}},
+		{name: "sinks", check: func(ctx context.Context) error {
+			return checkSinks(ctx, env, nc, secrets)
+		}},
+		{name: "streams", check: func(ctx context.Context) error {
+			return checkStreams(ctx, js, streamNames(env))
+		}},
+	}
+
+	var errs []error
+	for _, c := range checks {
+		checkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		if err := c.check(checkCtx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+		}
+		cancel()
+	}
+	return errs
+}
+
+func compileFilter(source string) error {
+	if source == "" {
+		return nil
+	}
+	_, err := expr.Compile(source)
+	return err
+}
+
+// checkSinks creates the publishers of all sinks and checks that the destinations of those that
+// support it are reachable.
+func checkSinks(ctx context.Context, env envConfig, nc *nats.Conn, secrets *secret.Resolver) error {
+	sinks, err := newSinks(env, nc, secrets)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, sink := range sinks {
+		if pinger, ok := sink.Publisher.(publisher.Pinger); ok {
+			if err := pinger.Ping(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("sink %s: %w", sink.Name, err))
+			}
+		}
+		closePublisher(sink.Publisher)
+	}
+	return errors.Join(errs...)
+}
+
+// streamNames returns the names of the streams that are created or updated at startup.
+func streamNames(env envConfig) []string {
+	names := []string{env.WebhookStreamName}
+	if env.ErrorStreamName != "" && env.ErrorSubject != "" && !env.DryRun {
+		names = append(names, env.ErrorStreamName)
+	}
+	if env.AuditStreamName != "" && env.AuditSubject != "" && !env.DryRun {
+		names = append(names, env.AuditStreamName)
+	}
+	if env.RawWebhookSubject != "" && !env.DryRun {
+		names = append(names, env.RawWebhookStreamName)
+	}
+	if env.ArchiveStreamName != "" {
+		names = append(names, env.ArchiveStreamName)
+	}
+	if sinkEnabled(env, "jetstream") && !env.DryRun {
+		names = append(names, env.EventStreamName)
+	}
+	return names
+}
+
+// checkStreams checks that JetStream is available, that existing streams are not sealed and
+// that the account has room for the streams that do not exist yet.
+func checkStreams(ctx context.Context, js natsjs.JetStream, names []string) error {
+	account, err := js.AccountInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("jetstream is not available: %w", err)
+	}
+
+	var errs []error
+	missing := 0
+	for _, name := range names {
+		stream, err := js.Stream(ctx, name)
+		if errors.Is(err, natsjs.ErrStreamNotFound) {
+			missing++
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("stream %s: %w", name, err))
+			continue
+		}
+		if stream.CachedInfo().Config.Sealed {
+			errs = append(errs, fmt.Errorf("stream %s is sealed", name))
+		}
+	}
+
+	if limit := account.Limits.MaxStreams; limit > 0 && account.Streams+missing > limit {
+		errs = append(errs, fmt.Errorf("account has room for %d more streams but %d are missing", limit-account.Streams, missing))
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateCapture validates the payload capture configuration. Captured payloads are kept outside
+// of the webhook stream's retention, so capturing is refused unless personal data and secrets are
+// redacted from them.
+func validateCapture(env envConfig) error {
+	if err := env.Capture.Validate(); err != nil {
+		return err
+	}
+	if !env.Redact.Enabled() {
+		return errors.New("payload capture requires REDACT_FIELDS or REDACT_PATTERNS")
+	}
+	return nil
+}